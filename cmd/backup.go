@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	c "github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/VersusControl/versus-incident/pkg/services"
+	"github.com/VersusControl/versus-incident/pkg/storage"
+)
+
+// backupPassphraseEnv names the environment variable holding the archive
+// passphrase, so it stays out of the process list and shell history.
+const backupPassphraseEnv = "BACKUP_PASSPHRASE"
+
+// runBackup is the backup subcommand: it writes an archive of everything the
+// configured storage backend holds. With the server stopped the archive is a
+// single point in time.
+//
+// Usage:
+//
+//	run backup [-config config/config.yaml] -out versus.tar.zst [-encrypt] [-include-secrets]
+//
+// -out - writes to stdout. -encrypt reads the passphrase from
+// BACKUP_PASSPHRASE. Without it, generated secrets are left out unless
+// -include-secrets, and storage encrypted at rest is refused. It returns the
+// process exit code: 0 on success, 1 on failure, 2 on a usage error or a
+// refused backup.
+func runBackup(args []string) int {
+	fs := flag.NewFlagSet("backup", flag.ContinueOnError)
+	cfgPath := fs.String("config", "config/config.yaml", "config file whose storage: block is backed up")
+	out := fs.String("out", "", "archive to write (- for stdout)")
+	encrypt := fs.Bool("encrypt", false, "encrypt with the passphrase in "+backupPassphraseEnv)
+	includeSecrets := fs.Bool("include-secrets", false, "write secrets and decrypted payloads into an unencrypted archive")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *out == "" {
+		fmt.Fprintln(os.Stderr, "backup: -out is required")
+		fs.Usage()
+		return 2
+	}
+	passphrase, ok := backupPassphrase(*encrypt)
+	if !ok {
+		return 2
+	}
+
+	cfg, err := c.Load(*cfgPath)
+	if err != nil {
+		log.Printf("backup: load %s: %v", *cfgPath, err)
+		return 1
+	}
+	opts := services.BackupOptions{
+		Passphrase:     passphrase,
+		Backend:        cfg.Storage.Type,
+		SealedAtRest:   cfg.Storage.Encryption.Enable,
+		IncludeSecrets: *includeSecrets,
+	}
+	if err := services.CheckBackup(opts); err != nil {
+		log.Printf("backup: %v", err)
+		return 2
+	}
+	st, err := storage.New(storageConfig(cfg))
+	if err != nil {
+		log.Printf("backup: open storage: %v", err)
+		return 1
+	}
+	defer func() { _ = st.Close() }()
+
+	var w io.Writer = os.Stdout
+	if *out != "-" {
+		f, err := os.OpenFile(*out, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err != nil {
+			log.Printf("backup: %v", err)
+			return 1
+		}
+		defer f.Close()
+		w = f
+	}
+	bw := bufio.NewWriter(w)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	manifest, err := services.WriteBackup(ctx, st, bw, opts)
+	if err == nil {
+		err = bw.Flush()
+	}
+	if err != nil {
+		log.Printf("backup: %v", err)
+		return 1
+	}
+	log.Printf("backup: wrote %s from %s: %v", *out, storageConfig(cfg).Target(), manifest.Counts)
+	return 0
+}
+
+// runRestore is the restore subcommand: it loads an archive written by backup
+// (or GET /api/admin/backup) into the configured storage backend. Stop the
+// server first; it caches settings and the catalog in memory.
+//
+// Usage:
+//
+//	run restore [-config config/config.yaml] -in versus.tar.zst [-force]
+//
+// An encrypted archive reads its passphrase from BACKUP_PASSPHRASE. It
+// returns the process exit code: 0 on success, 1 on failure, 2 on a usage
+// error or a refused restore.
+func runRestore(args []string) int {
+	fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+	cfgPath := fs.String("config", "config/config.yaml", "config file whose storage: block is restored into")
+	in := fs.String("in", "", "archive to read (- for stdin)")
+	force := fs.Bool("force", false, "restore into storage that already holds data")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *in == "" {
+		fmt.Fprintln(os.Stderr, "restore: -in is required")
+		fs.Usage()
+		return 2
+	}
+
+	var r io.Reader = os.Stdin
+	if *in != "-" {
+		f, err := os.Open(*in)
+		if err != nil {
+			log.Printf("restore: %v", err)
+			return 1
+		}
+		defer f.Close()
+		r = f
+	}
+
+	cfg, err := c.Load(*cfgPath)
+	if err != nil {
+		log.Printf("restore: load %s: %v", *cfgPath, err)
+		return 1
+	}
+	st, err := storage.New(storageConfig(cfg))
+	if err != nil {
+		log.Printf("restore: open storage: %v", err)
+		return 1
+	}
+	defer func() { _ = st.Close() }()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	manifest, err := services.RestoreBackup(ctx, st, r, services.RestoreOptions{
+		Passphrase: os.Getenv(backupPassphraseEnv),
+		Force:      *force,
+	})
+	switch {
+	case errors.Is(err, services.ErrBackupTargetNotEmpty):
+		log.Printf("restore: %v", err)
+		return 2
+	case errors.Is(err, services.ErrBackupPassphrase):
+		log.Printf("restore: %v (set %s)", err, backupPassphraseEnv)
+		return 1
+	case err != nil:
+		log.Printf("restore: %v", err)
+		return 1
+	}
+	log.Printf("restore: %s (version %d, written %s) into %s: %v",
+		*in, manifest.Version, manifest.CreatedAt.Format("2006-01-02 15:04:05Z"), storageConfig(cfg).Target(), manifest.Counts)
+	return 0
+}
+
+// backupPassphrase returns the passphrase to encrypt with, or "" when
+// encryption is off. ok is false when -encrypt is set without one.
+func backupPassphrase(encrypt bool) (string, bool) {
+	if !encrypt {
+		return "", true
+	}
+	p := os.Getenv(backupPassphraseEnv)
+	if p == "" {
+		fmt.Fprintf(os.Stderr, "backup: -encrypt needs a passphrase in %s\n", backupPassphraseEnv)
+		return "", false
+	}
+	return p, true
+}
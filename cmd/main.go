@@ -35,6 +35,17 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate-storage":
+			os.Exit(runMigrateStorage(os.Args[2:]))
+		case "backup":
+			os.Exit(runBackup(os.Args[2:]))
+		case "restore":
+			os.Exit(runRestore(os.Args[2:]))
+		}
+	}
+
 	err := c.LoadConfig("config/config.yaml")
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
@@ -43,35 +54,17 @@ func main() {
 	cfg := c.GetConfig()
 
 	// Construct the durable storage provider once and inject everywhere
-	// that needs to persist (agent catalog/shadow, incident history). Today
-	// only the file backend is implemented; redis/database return
-	// storage.ErrUnsupported.
-	store, err := storage.New(storage.Config{
-		Type: cfg.Storage.Type,
-		File: storage.FileOptions{
-			MaxIncidents: cfg.Storage.File.MaxIncidents,
-		},
-		Redis: storage.RedisOptions{
-			Host:               cfg.Storage.Redis.Host,
-			Port:               cfg.Storage.Redis.Port,
-			Password:           cfg.Storage.Redis.Password,
-			DB:                 cfg.Storage.Redis.DB,
-			InsecureSkipVerify: cfg.Storage.Redis.InsecureSkipVerify,
-			KeyPrefix:          cfg.Storage.Redis.KeyPrefix,
-			MaxIncidents:       cfg.Storage.Redis.MaxIncidents,
-		},
-		Database: storage.DatabaseOptions{
-			Driver: cfg.Storage.Database.Driver,
-			DSN:    cfg.Storage.Database.DSN,
-		},
-		Postgres: storage.PostgresOptions{
-			DSN: cfg.Storage.Postgres.DSN,
-		},
-	})
+	// that needs to persist (agent catalog/shadow, incident history). The
+	// database type opens an embedded SQLite file. The redis backend dials
+	// with the TLS and cluster settings of the top-level redis: block.
+	store, err := storage.New(storageConfig(cfg))
 	if err != nil {
 		log.Fatalf("Failed to initialize storage: %v", err)
 	}
 	defer func() { _ = store.Close() }()
+	if cfg.Storage.Encryption.Enable {
+		log.Printf("storage: encryption at rest enabled")
+	}
 
 	// Make storage available to the incident service (used to persist every
 	// alert + record acks).
@@ -155,7 +148,11 @@ func main() {
 
 		for _, listener := range listeners {
 			go func(l core.QueueListener) {
-				if err := l.StartListening(handleQueueMessage); err != nil {
+				source := "sqs"
+				if n, ok := l.(core.QueueSourceNamer); ok {
+					source = n.SourceName()
+				}
+				if err := l.StartListening(queueMessageHandler(source)); err != nil {
 					log.Printf("Listener error: %v", err)
 				}
 			}(listener)
@@ -196,6 +193,27 @@ func main() {
 	// default install starts a single idle ticker and sends nothing.
 	services.StartReportScheduler(rootCtx, store)
 
+	// Start the storage retention purge. Off unless storage.retention.enable is
+	// set with at least one maximum age, and a no-op on backends that cannot
+	// purge (file keeps its own rolling cap).
+	services.StartRetentionScheduler(rootCtx, store, cfg.Storage.Retention)
+
+	// Open the incident archive and start moving aged records into it. Off
+	// unless storage.archive.enable is set; the read-through and archive
+	// search work as soon as the bucket is configured.
+	arc, err := services.OpenArchive(rootCtx, cfg.Storage.Archive)
+	if err != nil {
+		log.Fatalf("storage archive: %v", err)
+	}
+	if arc != nil {
+		services.SetArchive(arc)
+		if r, a := cfg.Storage.Retention, cfg.Storage.Archive; r.Enable &&
+			(r.IncidentsDays > 0 && r.IncidentsDays <= a.IncidentsDays || r.AnalysesDays > 0 && r.AnalysesDays <= a.AnalysesDays) {
+			log.Printf("storage archive: retention purges records at or before the archive age, so they are deleted instead of archived")
+		}
+		services.StartArchiveScheduler(rootCtx, store, arc, cfg.Storage.Archive)
+	}
+
 	// agentDone closes once the worker has finished its shutdown flush. The
 	// process must not exit before then: the catalog only reaches storage on its
 	// flush interval, so racing it away drops everything learned since the last
@@ -276,6 +294,13 @@ func startAgent(ctx context.Context, app *fiber.App, cfg c.AgentConfig, gatewayS
 	var pgCatalog agent.CatalogStore
 	if acc, ok := store.(storage.SQLAccessor); ok {
 		pgCatalog = agent.NewPostgresCatalogStore(acc.DB(), storage.DefaultOrgID, 0)
+		// Seal the learned samples ring with the provider's keyring, so
+		// encryption at rest covers the typed tables too.
+		if kh, ok := store.(storage.KeyringHolder); ok {
+			if setter, ok := pgCatalog.(interface{ SetSampleKeyring(*storage.Keyring) }); ok {
+				setter.SetSampleKeyring(kh.Keyring())
+			}
+		}
 		agent.SetCatalogStore(pgCatalog)
 		log.Printf("agent: postgres typed catalog store installed (instance 0)")
 	}
@@ -405,6 +430,9 @@ func startAgent(ctx context.Context, app *fiber.App, cfg c.AgentConfig, gatewayS
 	if err != nil || pollInterval <= 0 {
 		pollInterval = 30 * time.Second
 	}
+	// The ingest route authenticates with per-source tokens, so it goes ahead
+	// of the agent group and its gateway-secret middleware.
+	controllers.NewIngestController(sources).Register(api)
 	controllers.NewAgentController(catalog, miner, shadowLog, detectLog, overrideStore, aiBundle.Runbooks != nil).
 		SetCursorStore(cursors).
 		SetSources(sources).
@@ -448,12 +476,51 @@ Agent status   -> GET  %s/api/agent/status
 		base, base, base, base, base, base)
 }
 
-func handleQueueMessage(content *map[string]interface{}) error {
-	// Stamp the ingress transport so persisted incidents say "sqs"
-	// instead of the default "http". Agent-originated incidents carry
-	// their own Source and ignore this hint.
-	overwrite := map[string]string{"incident_source": "sqs"}
-	return services.CreateIncident("", content, &overwrite) // teamID as empty string
+// storageConfig maps the storage: block of a loaded config onto the
+// storage factory's options. The redis backend takes its TLS and cluster
+// settings from the top-level redis: block.
+func storageConfig(cfg *c.Config) storage.Config {
+	return storage.Config{
+		Type: cfg.Storage.Type,
+		File: storage.FileOptions{
+			MaxIncidents: cfg.Storage.File.MaxIncidents,
+		},
+		Redis: storage.RedisOptions{
+			Host:               cfg.Storage.Redis.Host,
+			Port:               cfg.Storage.Redis.Port,
+			Password:           cfg.Storage.Redis.Password,
+			DB:                 cfg.Storage.Redis.DB,
+			InsecureSkipVerify: cfg.Storage.Redis.InsecureSkipVerify,
+			KeyPrefix:          cfg.Storage.Redis.KeyPrefix,
+			MaxIncidents:       cfg.Storage.Redis.MaxIncidents,
+			TLS:                cfg.Redis.TLSEnabled(),
+			Cluster:            cfg.Redis.ClusterEnabled(),
+		},
+		Database: storage.DatabaseOptions{
+			Driver: cfg.Storage.Database.Driver,
+			DSN:    cfg.Storage.Database.DSN,
+		},
+		Postgres: storage.PostgresOptions{
+			DSN: cfg.Storage.Postgres.DSN,
+		},
+		Encryption: storage.EncryptionOptions{
+			Enable:    cfg.Storage.Encryption.Enable,
+			KeyFile:   cfg.Storage.Encryption.KeyFile,
+			Keys:      cfg.Storage.Encryption.Keys,
+			ActiveKey: cfg.Storage.Encryption.ActiveKey,
+		},
+	}
+}
+
+// queueMessageHandler returns the handler a queue listener feeds. It stamps
+// the ingress transport so persisted incidents say e.g. "sqs" or "amqp"
+// instead of the default "webhook". Agent-originated incidents carry their
+// own Source and ignore this hint.
+func queueMessageHandler(source string) func(content *map[string]interface{}) error {
+	return func(content *map[string]interface{}) error {
+		overwrite := map[string]string{"incident_source": source}
+		return services.CreateIncident("", content, &overwrite) // teamID as empty string
+	}
 }
 
 // newRedisClient builds the Redis client both subsystems share. It reuses
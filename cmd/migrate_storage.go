@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	c "github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/VersusControl/versus-incident/pkg/services"
+	"github.com/VersusControl/versus-incident/pkg/storage"
+)
+
+// runMigrateStorage is the migrate-storage subcommand: it copies incident
+// history, timelines, analyses, every blob and the pattern catalog from the
+// storage backend of one config file to the backend of another, then checks
+// the destination holds every source record. Stop the server first so the
+// source does not change mid-copy. A rerun resumes an interrupted copy.
+//
+// Usage:
+//
+//	run migrate-storage -from config/file.yaml -to config/postgres.yaml [-dry-run]
+//
+// It returns the process exit code: 0 on success, 1 when the copy or the
+// verification fails, 2 on a usage error.
+func runMigrateStorage(args []string) int {
+	fs := flag.NewFlagSet("migrate-storage", flag.ContinueOnError)
+	from := fs.String("from", "", "config file whose storage: block is the source")
+	to := fs.String("to", "", "config file whose storage: block is the destination")
+	dryRun := fs.Bool("dry-run", false, "report what would be copied without writing")
+	pageSize := fs.Int("page-size", 0, "incidents or analyses read per page (default 500)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *from == "" || *to == "" {
+		fmt.Fprintln(os.Stderr, "migrate-storage: both -from and -to are required")
+		fs.Usage()
+		return 2
+	}
+
+	srcCfg, err := c.Load(*from)
+	if err != nil {
+		log.Printf("migrate-storage: load %s: %v", *from, err)
+		return 1
+	}
+	dstCfg, err := c.Load(*to)
+	if err != nil {
+		log.Printf("migrate-storage: load %s: %v", *to, err)
+		return 1
+	}
+	srcStorage, dstStorage := storageConfig(srcCfg), storageConfig(dstCfg)
+	if srcStorage.Target() == dstStorage.Target() {
+		log.Printf("migrate-storage: source and destination are the same storage (%s); check STORAGE_TYPE and the DSN env vars are not overriding both configs", srcStorage.Target())
+		return 2
+	}
+
+	src, err := storage.New(srcStorage)
+	if err != nil {
+		log.Printf("migrate-storage: open source: %v", err)
+		return 1
+	}
+	defer func() { _ = src.Close() }()
+	dst, err := storage.New(dstStorage)
+	if err != nil {
+		log.Printf("migrate-storage: open destination: %v", err)
+		return 1
+	}
+	defer func() { _ = dst.Close() }()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log.Printf("migrate-storage: %s -> %s (dry run: %t)", srcStorage.Target(), dstStorage.Target(), *dryRun)
+	_, err = services.MigrateStorage(ctx, src, dst, services.StorageMigrationOptions{
+		DryRun:   *dryRun,
+		PageSize: *pageSize,
+	})
+	switch {
+	case errors.Is(err, services.ErrStorageMigrationVerify):
+		log.Printf("migrate-storage: %v", err)
+		log.Printf("migrate-storage: a capped destination (file or redis max_incidents) keeps only the newest incidents; raise the cap and rerun")
+		return 1
+	case err != nil:
+		log.Printf("migrate-storage: %v; rerun to resume", err)
+		return 1
+	case *dryRun:
+		log.Printf("migrate-storage: dry run complete, nothing written")
+	default:
+		log.Printf("migrate-storage: complete and verified")
+	}
+	return 0
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestMigrateStorageRefusesSameTarget checks the usage errors, including two
+// configs that resolve to the same store.
+func TestMigrateStorageRefusesSameTarget(t *testing.T) {
+	t.Setenv("STORAGE_TYPE", "")
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.yaml")
+	b := filepath.Join(dir, "b.yaml")
+	for _, p := range []string{a, b} {
+		if err := os.WriteFile(p, []byte("storage:\n  type: file\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if code := runMigrateStorage([]string{"-from", a}); code != 2 {
+		t.Fatalf("missing -to: exit %d, want 2", code)
+	}
+	if code := runMigrateStorage([]string{"-from", a, "-to", b}); code != 2 {
+		t.Fatalf("same storage: exit %d, want 2", code)
+	}
+}
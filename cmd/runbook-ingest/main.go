@@ -71,6 +71,8 @@ func main() {
 			InsecureSkipVerify: cfg.Storage.Redis.InsecureSkipVerify,
 			KeyPrefix:          cfg.Storage.Redis.KeyPrefix,
 			MaxIncidents:       cfg.Storage.Redis.MaxIncidents,
+			TLS:                cfg.Redis.TLSEnabled(),
+			Cluster:            cfg.Redis.ClusterEnabled(),
 		},
 		Database: storage.DatabaseOptions{
 			Driver: cfg.Storage.Database.Driver,
@@ -79,6 +81,12 @@ func main() {
 		Postgres: storage.PostgresOptions{
 			DSN: cfg.Storage.Postgres.DSN,
 		},
+		Encryption: storage.EncryptionOptions{
+			Enable:    cfg.Storage.Encryption.Enable,
+			KeyFile:   cfg.Storage.Encryption.KeyFile,
+			Keys:      cfg.Storage.Encryption.Keys,
+			ActiveKey: cfg.Storage.Encryption.ActiveKey,
+		},
 	})
 	if err != nil {
 		log.Fatalf("runbook-ingest: init storage: %v", err)
@@ -143,6 +143,27 @@ Optional BYOK master key (VERSUS_ENTERPRISE_SECRET_KEY) Secret reference.
 {{- printf "%s-secrets" (include "versus-incident.fullname" .) -}}
 {{- end -}}
 {{- end -}}
+{{- define "versus-incident.storageEncryptionSecretName" -}}
+{{- if .Values.storage.encryption.existingSecret -}}
+{{- .Values.storage.encryption.existingSecret -}}
+{{- else -}}
+{{- printf "%s-secrets" (include "versus-incident.fullname" .) -}}
+{{- end -}}
+{{- end -}}
+{{- define "versus-incident.storageEncryptionSecretKey" -}}
+{{- if .Values.storage.encryption.existingSecret -}}
+{{- .Values.storage.encryption.existingSecretKey | default "storage_encryption_keys" -}}
+{{- else -}}
+storage_encryption_keys
+{{- end -}}
+{{- end -}}
+{{- define "versus-incident.storageArchiveSecretName" -}}
+{{- if .Values.storage.archive.s3.existingSecret -}}
+{{- .Values.storage.archive.s3.existingSecret -}}
+{{- else -}}
+{{- printf "%s-secrets" (include "versus-incident.fullname" .) -}}
+{{- end -}}
+{{- end -}}
 {{- define "versus-incident.enterpriseSecretKeyKey" -}}
 {{- if .Values.enterprise.secretKeyExistingSecret -}}
 {{- .Values.enterprise.secretKeyExistingSecretKey | default "versus_enterprise_secret_key" -}}
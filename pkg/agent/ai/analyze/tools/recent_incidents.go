@@ -3,6 +3,7 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -13,7 +14,10 @@ import (
 
 // RecentIncidents lists incidents from storage within a time window,
 // optionally filtered by service. The agent uses it to spot bursts /
-// recurring incidents on the same service.
+// recurring incidents on the same service. Each item carries its merge,
+// parent and related links, and naming an incident_id adds that incident's
+// whole relationship cluster regardless of the window, so an investigation
+// sees every incident an operator has tied to the one under analysis.
 type RecentIncidents struct {
 	Store storage.Provider
 	// OrgID scopes the listing to one organization. Blank means
@@ -27,7 +31,7 @@ func (RecentIncidents) Name() string { return "recent_incidents" }
 
 // Description implements core.AnalyzeTool.
 func (RecentIncidents) Description() string {
-	return "List incidents from the local store within the last N minutes, optionally filtered by service. Returns id, title, service, severity, resolved, created_at."
+	return "List incidents from the local store within the last N minutes, optionally filtered by service. Returns id, title, service, severity, resolved, created_at and any merged_into / parent_id / related_ids links. Pass incident_id to also get that incident's cluster: every incident merged into, linked to, or parent/child of it."
 }
 
 // ArgsSchema implements core.AnalyzeTool.
@@ -47,6 +51,10 @@ func (RecentIncidents) ArgsSchema() map[string]any {
 				"type":        "integer",
 				"description": "Cap the number of incidents returned. Default 20, max 100.",
 			},
+			"incident_id": map[string]any{
+				"type":        "string",
+				"description": "Optional incident id whose relationship cluster (merged duplicates, parent, children, related links) is returned as cluster.",
+			},
 		},
 	}
 }
@@ -55,6 +63,7 @@ type recentIncidentsArgs struct {
 	WindowMinutes int    `json:"window_minutes"`
 	Service       string `json:"service"`
 	Limit         int    `json:"limit"`
+	IncidentID    string `json:"incident_id"`
 }
 
 type recentIncidentItem struct {
@@ -64,6 +73,24 @@ type recentIncidentItem struct {
 	Severity  string    `json:"severity,omitempty"`
 	Resolved  bool      `json:"resolved"`
 	CreatedAt time.Time `json:"created_at"`
+	// MergedInto, ParentID and RelatedIDs are the operator-recorded
+	// relationships; see storage.IncidentRecord.
+	MergedInto string   `json:"merged_into,omitempty"`
+	ParentID   string   `json:"parent_id,omitempty"`
+	RelatedIDs []string `json:"related_ids,omitempty"`
+}
+
+func newRecentIncidentItem(rec *storage.IncidentRecord) recentIncidentItem {
+	return recentIncidentItem{
+		ID:         rec.ID,
+		Title:      rec.Title,
+		Service:    rec.ServiceLabel(),
+		Resolved:   rec.Resolved,
+		CreatedAt:  rec.CreatedAt,
+		MergedInto: rec.MergedInto,
+		ParentID:   rec.ParentID,
+		RelatedIDs: rec.RelatedIDs,
+	}
 }
 
 // Invoke implements core.AnalyzeTool.
@@ -100,29 +127,65 @@ func (r RecentIncidents) Invoke(_ context.Context, args json.RawMessage) (*core.
 		if rec.CreatedAt.Before(cutoff) {
 			continue
 		}
-		service := rec.ServiceLabel()
-		if a.Service != "" && !strings.EqualFold(service, a.Service) {
+		if a.Service != "" && !strings.EqualFold(rec.ServiceLabel(), a.Service) {
 			continue
 		}
-		out = append(out, recentIncidentItem{
-			ID:        rec.ID,
-			Title:     rec.Title,
-			Service:   service,
-			Resolved:  rec.Resolved,
-			CreatedAt: rec.CreatedAt,
-		})
+		out = append(out, newRecentIncidentItem(rec))
 		if len(out) >= a.Limit {
 			break
 		}
 	}
+	data := map[string]any{
+		"count":          len(out),
+		"window_minutes": a.WindowMinutes,
+		"service":        a.Service,
+		"incidents":      out,
+	}
+	if a.IncidentID != "" {
+		cluster, err := r.cluster(a.IncidentID, a.Limit)
+		if err != nil {
+			return nil, fmt.Errorf("recent_incidents: related: %w", err)
+		}
+		data["incident_id"] = a.IncidentID
+		data["cluster"] = cluster
+	}
 	return &core.ToolResult{
 		Tool:  RecentIncidents{}.Name(),
 		Found: true,
-		Data: map[string]any{
-			"count":          len(out),
-			"window_minutes": a.WindowMinutes,
-			"service":        a.Service,
-			"incidents":      out,
-		},
+		Data:  data,
 	}, nil
 }
+
+// cluster returns the incidents related to id through storage.RelatedIncidents,
+// the same walk the /related API serves, kept to the caller's org and
+// bounded by limit. It ignores the window and service filter: a duplicate
+// merged hours ago is still part of the same event. An id outside the org,
+// or unknown, has an empty cluster.
+func (r RecentIncidents) cluster(id string, limit int) ([]recentIncidentItem, error) {
+	out := make([]recentIncidentItem, 0)
+	org := storage.NormalizeOrgID(r.OrgID)
+	root, err := r.Store.GetIncident(id)
+	if errors.Is(err, storage.ErrNotFound) {
+		return out, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if storage.NormalizeOrgID(root.OrgID) != org {
+		return out, nil
+	}
+	related, err := storage.RelatedIncidents(r.Store, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, rec := range related {
+		if storage.NormalizeOrgID(rec.OrgID) != org {
+			continue
+		}
+		out = append(out, newRecentIncidentItem(rec))
+		if len(out) >= limit {
+			break
+		}
+	}
+	return out, nil
+}
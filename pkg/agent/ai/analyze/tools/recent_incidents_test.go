@@ -127,3 +127,58 @@ func TestRecentIncidents_LimitCapAndDefaults(t *testing.T) {
 		t.Errorf("len(incidents) = %d, want 100 (limit cap)", len(incidents))
 	}
 }
+
+// TestRecentIncidents_ClusterIgnoresWindow proves naming an incident_id
+// returns its whole relationship cluster, including a duplicate merged long
+// before the window and a child on another service.
+func TestRecentIncidents_ClusterIgnoresWindow(t *testing.T) {
+	now := time.Now().UTC()
+	store := newStoreWithIncidents(t,
+		&storage.IncidentRecord{ID: "db", Service: "postgres", CreatedAt: now.Add(-5 * time.Minute)},
+		&storage.IncidentRecord{ID: "dup", Service: "postgres", CreatedAt: now.Add(-6 * time.Hour), MergedInto: "db", Resolved: true},
+		&storage.IncidentRecord{ID: "child", Service: "checkout", CreatedAt: now.Add(-4 * time.Minute), ParentID: "db"},
+		&storage.IncidentRecord{ID: "other", Service: "checkout", CreatedAt: now.Add(-3 * time.Minute)},
+	)
+	tool := RecentIncidents{Store: store}
+
+	res, err := tool.Invoke(context.Background(), mustArgs(t, recentIncidentsArgs{Service: "postgres", IncidentID: "db"}))
+	if err != nil {
+		t.Fatalf("Invoke: %v", err)
+	}
+	cluster := res.Data["cluster"].([]recentIncidentItem)
+	if len(cluster) != 2 || cluster[0].ID != "child" || cluster[1].ID != "dup" {
+		t.Fatalf("cluster = %+v, want [child dup]", cluster)
+	}
+	if cluster[1].MergedInto != "db" || cluster[0].ParentID != "db" {
+		t.Errorf("cluster items lost their links: %+v", cluster)
+	}
+}
+
+// TestRecentIncidents_ClusterStaysInOrg proves the cluster is kept to the
+// caller's org: a linked incident of another org is left out, and naming an
+// incident of another org returns no cluster at all.
+func TestRecentIncidents_ClusterStaysInOrg(t *testing.T) {
+	now := time.Now().UTC()
+	store := newStoreWithIncidents(t,
+		&storage.IncidentRecord{ID: "db", OrgID: "acme", CreatedAt: now.Add(-5 * time.Minute)},
+		&storage.IncidentRecord{ID: "dup", OrgID: "acme", CreatedAt: now.Add(-6 * time.Hour), MergedInto: "db"},
+		&storage.IncidentRecord{ID: "foreign", OrgID: "globex", CreatedAt: now.Add(-4 * time.Minute), ParentID: "db"},
+	)
+	tool := RecentIncidents{Store: store, OrgID: "acme"}
+
+	res, err := tool.Invoke(context.Background(), mustArgs(t, recentIncidentsArgs{IncidentID: "db"}))
+	if err != nil {
+		t.Fatalf("Invoke: %v", err)
+	}
+	if cluster := res.Data["cluster"].([]recentIncidentItem); len(cluster) != 1 || cluster[0].ID != "dup" {
+		t.Fatalf("cluster = %+v, want [dup]", cluster)
+	}
+
+	res, err = RecentIncidents{Store: store, OrgID: "globex"}.Invoke(context.Background(), mustArgs(t, recentIncidentsArgs{IncidentID: "db"}))
+	if err != nil {
+		t.Fatalf("Invoke: %v", err)
+	}
+	if cluster := res.Data["cluster"].([]recentIncidentItem); len(cluster) != 0 {
+		t.Fatalf("cluster of another org's incident = %+v, want none", cluster)
+	}
+}
@@ -32,6 +32,13 @@ func newSignalReaderAdapter(sources []core.SignalSource) analyzetools.SignalRead
 		if s == nil {
 			continue
 		}
+		// A listener (a push receiver, a Kafka group member) only holds
+		// what arrived since the worker last drained it; there is no
+		// history to read back, and a second group member would take
+		// partitions from the worker's.
+		if _, push := s.(core.SourceListener); push {
+			continue
+		}
 		name := s.Name()
 		if _, dup := m[name]; dup {
 			continue
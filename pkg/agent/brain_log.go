@@ -108,7 +108,12 @@ func (b *logBrain) Group(ctx context.Context, batch []core.Signal) ([]core.Obser
 		}
 		bk := buckets[id]
 		if bk == nil {
-			svc := b.services.Extract(sig.Message)
+			// A source that knows the service (the OTLP receiver's
+			// service.name) stamps it; otherwise detect it from the message.
+			svc, _ := sig.Fields[core.FieldService].(string)
+			if svc == "" {
+				svc = b.services.Extract(sig.Message)
+			}
 			if svc == "" {
 				svc = "_unknown"
 			}
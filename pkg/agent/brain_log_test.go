@@ -88,6 +88,22 @@ func TestLogBrain_GroupClustersAndExtractsService(t *testing.T) {
 	}
 }
 
+// TestLogBrain_GroupPrefersStampedService attributes a signal to the service
+// its source stamped (the OTLP receiver's service.name) over the one the
+// message patterns would detect.
+func TestLogBrain_GroupPrefersStampedService(t *testing.T) {
+	b, _ := newLogBrainForTest(t, config.AgentCatalogConfig{})
+	obs, err := b.Group(context.Background(), []core.Signal{
+		{Message: "service=api request failed id=1", Fields: map[string]interface{}{core.FieldService: "checkout"}},
+	})
+	if err != nil {
+		t.Fatalf("Group: %v", err)
+	}
+	if len(obs) != 1 || obs[0].Service != "checkout" {
+		t.Fatalf("observations = %+v, want one attributed to checkout", obs)
+	}
+}
+
 func TestLogBrain_GroupRespectsRegexFilter(t *testing.T) {
 	c, err := LoadCatalog(storage.NewMemory())
 	if err != nil {
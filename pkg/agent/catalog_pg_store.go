@@ -285,6 +285,11 @@ type pgCatalogStore struct {
 	// unchanged; it is threaded on the write path only via SetSampleScrubber.
 	scrub core.Scrubber
 
+	// keys seals the samples ring at rest with the backend's keyring (see
+	// storage.KeyringHolder). nil stores it in the clear; set via
+	// SetSampleKeyring.
+	keys *storage.Keyring
+
 	// mu guards markedKnown only (the *sql.DB is concurrency-safe on its own).
 	mu sync.Mutex
 	// markedKnown caps the auto-promotion churn: the brain re-issues MarkKnown
@@ -324,6 +329,14 @@ func (s *pgCatalogStore) SetSampleScrubber(scrub core.Scrubber) {
 	s.scrub = scrub
 }
 
+// SetSampleKeyring seals each pattern's samples ring with keys on Persist
+// and opens it on every read, matching the encryption the Provider applies
+// to its own records. Must be called before the first Load so sealed rings
+// are read back; a nil keyring leaves both paths unchanged.
+func (s *pgCatalogStore) SetSampleKeyring(keys *storage.Keyring) {
+	s.keys = keys
+}
+
 // Load returns this instance's partition working set (its own learned log
 // rows), with the curated root columns folded in so the brain sees fleet-wide
 // curation (verdict / tombstone-suppression / service attribution) from boot.
@@ -373,7 +386,7 @@ func (s *pgCatalogStore) Load() (map[string]*Pattern, map[string]*ServiceInfo, e
 			Source:            source,
 			Service:           service,
 			Tags:              decodeStringSlice(tagsRaw),
-			Samples:           decodeStringSlice(samplesRaw),
+			Samples:           s.decodeSamples(id, samplesRaw),
 		}
 		// Fold a tombstone onto the brain's suppression verdict so a deleted
 		// pattern is not re-alerted even while live mining re-learns it.
@@ -440,7 +453,7 @@ func (s *pgCatalogStore) Persist(patterns map[string]*Pattern, services map[stri
 			s.orgID, id, s.instanceIndex, p.Template, nullIfEmpty(p.Source),
 			nullIfEmpty(p.RuleName), int64(p.Count), p.BaselineFrequency, p.BaselineVariance,
 			p.BaselineAvg, p.SpikeBaselineMode, encodeSeasonal(p.Seasonal),
-			encodeStringSlice(rescrubSamples(p.Samples, s.scrub)),
+			s.encodeSamples(id, rescrubSamples(p.Samples, s.scrub)),
 			utcOrNow(p.FirstSeen), utcOrNow(p.LastSeen),
 		); err != nil {
 			return fmt.Errorf("agent: pg catalog persist log %q: %w", id, err)
@@ -511,7 +524,7 @@ func (s *pgCatalogStore) Snapshot() ([]*Pattern, map[string]ServiceInfo, error)
 			Source:            source,
 			Service:           service,
 			Tags:              decodeStringSlice(tagsRaw),
-			Samples:           decodeStringSlice(samplesRaw),
+			Samples:           s.decodeSamples(id, samplesRaw),
 		})
 	}
 	if err := rows.Err(); err != nil {
@@ -593,7 +606,7 @@ func (s *pgCatalogStore) ListPatternsPage(opts CatalogPageOptions) ([]*Pattern,
 			Source:            source,
 			Service:           service,
 			Tags:              decodeStringSlice(tagsRaw),
-			Samples:           decodeStringSlice(samplesRaw),
+			Samples:           s.decodeSamples(id, samplesRaw),
 		})
 	}
 	if err := rows.Err(); err != nil {
@@ -841,6 +854,37 @@ func decodeStringSlice(raw []byte) []string {
 	return out
 }
 
+// encodeSamples renders a samples ring for the JSONB column. With a sealing
+// keyring the ring is stored as a one-element array holding the sealed
+// token, so the column keeps its array shape; if sealing fails the ring is
+// dropped rather than written in the clear (samples are refilled by
+// learning).
+func (s *pgCatalogStore) encodeSamples(id string, samples []string) []byte {
+	raw := encodeStringSlice(samples)
+	if s.keys.ActiveKeyID() == "" || len(samples) == 0 {
+		return raw
+	}
+	token, err := s.keys.SealBytes(storage.PatternSamplesAAD(id), raw)
+	if err != nil {
+		return []byte("[]")
+	}
+	return encodeStringSlice([]string{string(token)})
+}
+
+// decodeSamples reverses encodeSamples. A ring that cannot be opened (its
+// key was retired) decodes as empty, like any other undecodable payload.
+func (s *pgCatalogStore) decodeSamples(id string, raw []byte) []string {
+	samples := decodeStringSlice(raw)
+	if len(samples) != 1 || !storage.IsSealed([]byte(samples[0])) {
+		return samples
+	}
+	plain, err := s.keys.OpenBytes(storage.PatternSamplesAAD(id), []byte(samples[0]))
+	if err != nil {
+		return nil
+	}
+	return decodeStringSlice(plain)
+}
+
 // encodeSeasonal marshals the seasonal EWMA buckets to their JSONB payload,
 // mirroring the enterprise vs_metrics/vs_traces seasonal encoding (an array of
 // {mean,variance,count}). An empty/nil slice emits "[]" so the column never
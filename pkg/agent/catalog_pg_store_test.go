@@ -84,6 +84,35 @@ func TestNewPostgresCatalogStore_OrgNormalized(t *testing.T) {
 	}
 }
 
+// TestPGCatalog_SamplesSealed proves the samples ring is stored as one sealed
+// token when a keyring is set, opens under the same pattern id only, and a
+// clear ring from before encryption still decodes.
+func TestPGCatalog_SamplesSealed(t *testing.T) {
+	keys, err := storage.NewKeyring(map[string][]byte{"k1": []byte(strings.Repeat("k", storage.EncryptionKeySize))}, "k1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewPostgresCatalogStore(nil, "", 0).(*pgCatalogStore)
+	s.SetSampleKeyring(keys)
+
+	raw := s.encodeSamples("p1", []string{"user 4242 failed login"})
+	if strings.Contains(string(raw), "4242") || !strings.HasPrefix(string(raw), `["vsenc:`) {
+		t.Fatalf("sealed ring = %s", raw)
+	}
+	if got := s.decodeSamples("p1", raw); len(got) != 1 || got[0] != "user 4242 failed login" {
+		t.Fatalf("decodeSamples = %v", got)
+	}
+	if got := s.decodeSamples("p2", raw); got != nil {
+		t.Fatalf("ring opened under another pattern id: %v", got)
+	}
+	if got := s.decodeSamples("p1", []byte(`["clear sample"]`)); len(got) != 1 || got[0] != "clear sample" {
+		t.Fatalf("clear ring = %v", got)
+	}
+	if raw := s.encodeSamples("p1", nil); string(raw) != "[]" {
+		t.Fatalf("empty ring = %s", raw)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Live-Postgres lifecycle round-trip (gated on TEST_POSTGRES_DSN)
 // ---------------------------------------------------------------------------
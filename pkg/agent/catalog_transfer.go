@@ -0,0 +1,100 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/VersusControl/versus-incident/pkg/storage"
+)
+
+// catalog_transfer.go — whole-catalog reads and writes against an explicit
+// storage backend, for the migrate-storage command. The running server
+// selects its catalog path once at boot through the process-wide
+// CatalogStore slot; a migration holds two backends in one process, so these
+// helpers pick the path per backend instead of reading the slot: the typed
+// Postgres tables when the backend is a storage.SQLAccessor, the "patterns"
+// blob otherwise. That is the same switch cmd/main.go uses at boot.
+
+// transferCatalogStore builds the typed catalog store over acc, sealing
+// samples with the backend's keyring as the running server does.
+func transferCatalogStore(acc storage.SQLAccessor) *pgCatalogStore {
+	s := NewPostgresCatalogStore(acc.DB(), storage.DefaultOrgID, 0).(*pgCatalogStore)
+	if kh, ok := acc.(storage.KeyringHolder); ok {
+		s.SetSampleKeyring(kh.Keyring())
+	}
+	return s
+}
+
+// ReadCatalog returns every learned pattern and service stored in store.
+// Either map is empty (never nil) when the backend holds no catalog.
+func ReadCatalog(store storage.Provider) (map[string]*Pattern, map[string]*ServiceInfo, error) {
+	if acc, ok := store.(storage.SQLAccessor); ok {
+		patterns, services, err := transferCatalogStore(acc).Load()
+		if err != nil {
+			return nil, nil, err
+		}
+		return patterns, services, nil
+	}
+	patterns := make(map[string]*Pattern)
+	services := make(map[string]*ServiceInfo)
+	data, err := store.ReadBlob(config.CatalogBlobName)
+	if err != nil || len(data) == 0 {
+		return patterns, services, err
+	}
+	var f catalogFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, nil, fmt.Errorf("parse catalog: %w", err)
+	}
+	for id, p := range f.Patterns {
+		patterns[id] = p
+	}
+	for name, s := range f.Services {
+		services[name] = s
+	}
+	return patterns, services, nil
+}
+
+// WriteCatalog stores patterns and services in store, replacing entries with
+// the same id or name and leaving every other entry alone. On Postgres the
+// working-set columns go through Persist and each operator verdict and tag
+// set through a label edit, since Persist never writes the curated columns.
+func WriteCatalog(store storage.Provider, patterns map[string]*Pattern, services map[string]*ServiceInfo) error {
+	if acc, ok := store.(storage.SQLAccessor); ok {
+		s := transferCatalogStore(acc)
+		if err := s.Persist(patterns, services); err != nil {
+			return err
+		}
+		for id, p := range patterns {
+			if p == nil || (p.Verdict == "" && len(p.Tags) == 0) {
+				continue
+			}
+			verdict := p.Verdict
+			if err := s.Curate(CatalogEdit{Kind: CatalogEditLabel, PatternID: id, Verdict: &verdict, Tags: p.Tags}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	existingPatterns, existingServices, err := ReadCatalog(store)
+	if err != nil {
+		return err
+	}
+	for id, p := range patterns {
+		existingPatterns[id] = p
+	}
+	for name, s := range services {
+		existingServices[name] = s
+	}
+	data, err := json.MarshalIndent(catalogFile{
+		Version:   catalogFileVersion,
+		UpdatedAt: time.Now().UTC(),
+		Patterns:  existingPatterns,
+		Services:  existingServices,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal catalog: %w", err)
+	}
+	return store.WriteBlob(config.CatalogBlobName, data)
+}
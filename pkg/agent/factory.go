@@ -18,6 +18,12 @@ func BuildSources(cfg config.AgentConfig) ([]core.SignalSource, []error) {
 		if !s.Enable {
 			continue
 		}
+		if signalsources.MultilineEnabled(s.Multiline) {
+			if _, err := signalsources.NewMultiline(s.Multiline); err != nil {
+				errs = append(errs, fmt.Errorf("source %s: %w", s.Name, err))
+				continue
+			}
+		}
 		switch s.Type {
 		case "elasticsearch":
 			es, err := signalsources.NewElasticsearchSource(s.Name, s.Elasticsearch)
@@ -68,6 +74,41 @@ func BuildSources(cfg config.AgentConfig) ([]core.SignalSource, []error) {
 				continue
 			}
 			sources = append(sources, sz)
+		case "otlp":
+			ot, err := signalsources.NewOTLPSource(s.Name, s.OTLP)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("source %s: %w", s.Name, err))
+				continue
+			}
+			sources = append(sources, ot)
+		case "syslog":
+			sl, err := signalsources.NewSyslogSource(s.Name, s.Syslog)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("source %s: %w", s.Name, err))
+				continue
+			}
+			sources = append(sources, sl)
+		case "kafka":
+			ks, err := signalsources.NewKafkaSource(s.Name, s.Kafka)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("source %s: %w", s.Name, err))
+				continue
+			}
+			sources = append(sources, ks)
+		case "kubernetes":
+			kc, err := signalsources.NewKubernetesSource(s.Name, s.Kubernetes)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("source %s: %w", s.Name, err))
+				continue
+			}
+			sources = append(sources, kc)
+		case "http":
+			hs, err := signalsources.NewHTTPIngestSource(s.Name, s.HTTP, cfg.SignalMaxBytes)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("source %s: %w", s.Name, err))
+				continue
+			}
+			sources = append(sources, hs)
 		default:
 			// Source types not built into OSS are resolved through the
 			// registration hook (signalsources.Register). The enterprise
@@ -81,3 +81,30 @@ func TestBuildSources_RegisteredTypeResolves(t *testing.T) {
 		t.Errorf("Options not threaded to factory: %v", gotOpts)
 	}
 }
+
+// TestBuildSources_OTLPIsListenerOnly builds an OTLP receiver and a Kafka
+// consumer, which start nothing until the worker starts them, and checks the
+// analyze-mode reader leaves them out: a second copy has nothing to read back,
+// and a second group member would take the worker's partitions.
+func TestBuildSources_OTLPIsListenerOnly(t *testing.T) {
+	cfg := config.AgentConfig{
+		Sources: []config.AgentSourceConfig{
+			{Name: "otel", Type: "otlp", Enable: true, OTLP: config.AgentOTLPSourceConfig{HTTPListen: "127.0.0.1:0"}},
+			{Name: "app", Type: "file", Enable: true, File: config.AgentFileSourceConfig{Path: t.TempDir() + "/app.log"}},
+			{Name: "bus", Type: "kafka", Enable: true, Kafka: config.AgentKafkaSourceConfig{Brokers: []string{"127.0.0.1:1"}, Topic: "logs", GroupID: "versus"}},
+		},
+	}
+	sources, errs := BuildSources(cfg)
+	if len(errs) != 0 || len(sources) != 3 {
+		t.Fatalf("BuildSources = %d sources, %v", len(sources), errs)
+	}
+	if _, ok := sources[0].(core.SourceListener); !ok || sources[0].Name() != "otlp:otel" {
+		t.Fatalf("sources[0] = %T %q, want the OTLP listener", sources[0], sources[0].Name())
+	}
+	if _, ok := sources[2].(core.SourceListener); !ok || sources[2].Name() != "kafka:bus" {
+		t.Fatalf("sources[2] = %T %q, want the Kafka listener", sources[2], sources[2].Name())
+	}
+	if got := newSignalReaderAdapter(sources).Sources(); len(got) != 1 || got[0] != "file:app" {
+		t.Fatalf("reader sources = %v, want only file:app", got)
+	}
+}
@@ -72,6 +72,14 @@ type Worker struct {
 	// matchAll (learn-all) for that kind. There is no per-source override.
 	metricsMatcher *RegexMatcher
 	tracesMatcher  *RegexMatcher
+	// multiline maps a source name to its multiline stage, for sources that
+	// configure one. Keyed by both the configured name and the "type:name"
+	// the built-in sources report, so tests and registered sources resolve
+	// the same way. Built once in NewWorker; each stage locks itself.
+	multiline map[string]*signalsources.Multiline
+	// keyValue maps a source name to its key=value stage, keyed like
+	// multiline. Stateless, so shared across ticks without a lock.
+	keyValue map[string]*signalsources.KeyValue
 
 	// Detect-mode dependencies. All three are nil-safe: when ai.Detect
 	// is nil the worker emits a deterministic templated alert instead of
@@ -197,6 +205,26 @@ func NewWorker(opt WorkerOptions) (*Worker, error) {
 		}
 		w.kindByName[s.Name] = signalsources.KindOf(s.Type)
 	}
+	w.multiline = make(map[string]*signalsources.Multiline)
+	w.keyValue = make(map[string]*signalsources.KeyValue)
+	for _, s := range opt.Cfg.Sources {
+		if !s.Enable {
+			continue
+		}
+		if kv := signalsources.NewKeyValue(s.KeyValue); kv != nil {
+			w.keyValue[s.Name] = kv
+			w.keyValue[s.Type+":"+s.Name] = kv
+		}
+		if !signalsources.MultilineEnabled(s.Multiline) {
+			continue
+		}
+		ml, err := signalsources.NewMultiline(s.Multiline)
+		if err != nil {
+			return nil, fmt.Errorf("source %q: %w", s.Name, err)
+		}
+		w.multiline[s.Name] = ml
+		w.multiline[s.Type+":"+s.Name] = ml
+	}
 
 	// Resolve per-type brains. In OSS this registers nothing (the log brain is
 	// the un-registered default); when Versus Enterprise is linked, its
@@ -275,6 +303,8 @@ func (w *Worker) Run(ctx context.Context) {
 	// is byte-for-byte unchanged.
 	go scheduler.NewFromRegistry().Run(ctx)
 
+	w.listenSources(ctx)
+
 	// Stagger initial pull so multiple sources don't hammer their backends
 	// at the same instant on startup.
 	tick := time.NewTicker(w.pollInterval)
@@ -293,6 +323,7 @@ func (w *Worker) Run(ctx context.Context) {
 		select {
 		case <-ctx.Done():
 			log.Printf("agent: worker stopping; flushing catalog…")
+			w.flushMultiline()
 			flushed := true
 			if err := w.catalog.Persist(); err != nil {
 				log.Printf("agent: final catalog flush failed: %v", err)
@@ -355,6 +386,21 @@ func (w *Worker) Run(ctx context.Context) {
 	}
 }
 
+// listenSources starts every listener source (core.SourceListener) for the
+// life of ctx. A source that cannot start is logged and left idle — its Pull
+// returns nothing — so one taken port does not stop the other sources.
+func (w *Worker) listenSources(ctx context.Context) {
+	for _, src := range w.sources {
+		l, ok := src.(core.SourceListener)
+		if !ok {
+			continue
+		}
+		if err := l.Listen(ctx); err != nil {
+			log.Printf("agent: %s: listen failed: %v (the source stays idle)", src.Name(), err)
+		}
+	}
+}
+
 // shutdownCommitTimeout bounds the post-flush commit on the shutdown path. A
 // container stop gives the process a limited grace period; overrunning it turns
 // a graceful stop into a kill, so the commit gets a short, explicit budget.
@@ -369,12 +415,23 @@ const shutdownCommitTimeout = 5 * time.Second
 // skipped, so it behaves exactly as it did before the seam existed. A failing
 // commit is logged and the loop continues — the pending ids stay pending and
 // the next commit retries them, so the cost is a possible replay, never a hole.
+//
+// A source whose multiline stage still holds the lines of an open event is
+// first told which (core.SourceCommitHolder), so its commit stops short of
+// them and a restart reads them again.
 func (w *Worker) commitSources(ctx context.Context) {
 	for _, src := range w.sources {
 		committer, ok := src.(core.SourceCommitter)
 		if !ok {
 			continue
 		}
+		if holder, ok := src.(core.SourceCommitHolder); ok {
+			var held []core.Signal
+			if ml := w.multiline[src.Name()]; ml != nil {
+				held = ml.Held()
+			}
+			holder.HoldCommit(held)
+		}
 		if err := committer.Commit(ctx); err != nil {
 			log.Printf("agent: %s: persisting the delivered-row set failed: %v (a restart before the next commit may re-emit up to one reorder window; no row is lost)", src.Name(), err)
 		}
@@ -429,6 +486,26 @@ func (w *Worker) tick(ctx context.Context) {
 	wg.Wait()
 }
 
+// flushMultiline completes the events every multiline stage still holds and
+// learns them, so a stop does not drop a trace whose last line arrived just
+// before it. It runs on the shutdown path, before the final catalog flush,
+// with a live context of its own: the worker's is already canceled.
+func (w *Worker) flushMultiline() {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownCommitTimeout)
+	defer cancel()
+	mode := w.effectiveMode(ctx)
+	for _, src := range w.sources {
+		ml := w.multiline[src.Name()]
+		if ml == nil {
+			continue
+		}
+		if signals := ml.Flush(); len(signals) > 0 {
+			since := w.loadCursor(ctx, src.Name())
+			w.learnSignals(ctx, src, mode, signals, since, since)
+		}
+	}
+}
+
 func (w *Worker) tickSource(ctx context.Context, src core.SignalSource, mode string) {
 	since := w.loadCursor(ctx, src.Name())
 
@@ -437,6 +514,24 @@ func (w *Worker) tickSource(ctx context.Context, src core.SignalSource, mode str
 		log.Printf("agent: pull from %s failed: %v", src.Name(), err)
 		return
 	}
+	// Split key=value messages first, so the multiline stage sees the
+	// message value. Then join multi-line events before anything counts or
+	// caps the batch: a stack trace is one signal from here on. Lines of an
+	// event still open are held by the stage and come out on a later tick;
+	// commitSources keeps the source's commit behind them, and shutdown
+	// flushes them.
+	if kv := w.keyValue[src.Name()]; kv != nil {
+		signals = kv.Apply(signals)
+	}
+	if ml := w.multiline[src.Name()]; ml != nil {
+		signals = ml.Apply(signals, time.Now())
+	}
+	w.learnSignals(ctx, src, mode, signals, since, newCursor)
+}
+
+// learnSignals runs one batch from src through the rest of the tick —
+// redaction, filtering, the brain and the mode tail — and saves newCursor.
+func (w *Worker) learnSignals(ctx context.Context, src core.SignalSource, mode string, signals []core.Signal, since, newCursor time.Time) {
 	if len(signals) == 0 {
 		// Persist a legitimately-advanced cursor even though this tick emitted
 		// nothing. A source that scanned a window and found no signals reports
@@ -0,0 +1,121 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/VersusControl/versus-incident/pkg/core"
+	"github.com/VersusControl/versus-incident/pkg/signalsources"
+	"github.com/VersusControl/versus-incident/pkg/storage"
+)
+
+// TestWorker_MultilineTraceSplitAcrossCommit pins the no-loss contract of the
+// multiline stage: while a stack trace is still being assembled, a commit
+// keeps the file cursor at its first line, so a restart reads the whole trace
+// again; once it completes the cursor moves past it; and the shutdown flush
+// learns the event still held instead of dropping it.
+func TestWorker_MultilineTraceSplitAcrossCommit(t *testing.T) {
+	SetCatalogStore(nil)
+	t.Cleanup(func() { SetCatalogStore(nil) })
+
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "app.log")
+	fileCfg := config.AgentFileSourceConfig{Path: logPath, FromBeginning: true, CursorPath: filepath.Join(dir, "cursor")}
+	if err := os.WriteFile(logPath, []byte(
+		"ERROR request failed\n"+
+			"java.lang.IllegalStateException: pool exhausted\n"+
+			"\tat com.acme.Pool.take(Pool.java:42)\n"), 0o644); err != nil {
+		t.Fatalf("write log: %v", err)
+	}
+	fs, err := signalsources.NewFileSource("app", fileCfg)
+	if err != nil {
+		t.Fatalf("NewFileSource: %v", err)
+	}
+
+	cat, err := LoadCatalog(storage.NewMemory())
+	if err != nil {
+		t.Fatalf("LoadCatalog: %v", err)
+	}
+	m, _ := NewRegexMatcher(config.AgentRegexConfig{DefaultPattern: ".*"})
+	svc, _ := NewServiceMatcher(nil)
+	w, err := NewWorker(WorkerOptions{
+		Cfg: config.AgentConfig{
+			Mode: "training", Lookback: "5m",
+			Sources: []config.AgentSourceConfig{{
+				Name: "app", Type: "file", Enable: true, File: fileCfg,
+				Multiline: config.AgentMultilineConfig{Preset: "java"},
+			}},
+		},
+		Sources:  []core.SignalSource{fs},
+		Cursors:  NewCursorStore(nil),
+		Matcher:  m,
+		Miner:    NewMiner(0.4, 4, 100),
+		Catalog:  cat,
+		Services: svc,
+	})
+	if err != nil {
+		t.Fatalf("NewWorker: %v", err)
+	}
+	ctx := context.Background()
+
+	// restartReads is what a fresh process would read from the committed
+	// cursor.
+	restartReads := func() []string {
+		t.Helper()
+		again, err := signalsources.NewFileSource("app", fileCfg)
+		if err != nil {
+			t.Fatalf("restart: %v", err)
+		}
+		sigs, _, err := again.Pull(ctx, time.Time{})
+		if err != nil {
+			t.Fatalf("restart pull: %v", err)
+		}
+		msgs := make([]string, len(sigs))
+		for i, s := range sigs {
+			msgs[i] = s.Message
+		}
+		return msgs
+	}
+
+	// Tick 1 reads the first half of the trace; the stage holds it.
+	w.tickSource(ctx, fs, "training")
+	if cat.Len() != 0 {
+		t.Fatalf("tick1 learned %d patterns from an open trace", cat.Len())
+	}
+	w.commitSources(ctx)
+	if got := restartReads(); len(got) != 3 || got[0] != "ERROR request failed" {
+		t.Fatalf("restart mid-trace reads %q, want the whole first half again", got)
+	}
+
+	// Tick 2 completes the trace; the next event is held in its turn.
+	f, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString("\tat com.acme.Handler.run(Handler.java:7)\nINFO recovered\n"); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	w.tickSource(ctx, fs, "training")
+	if cat.Len() != 1 {
+		t.Fatalf("tick2 patterns = %d, want the joined trace only", cat.Len())
+	}
+	w.commitSources(ctx)
+	if got := restartReads(); len(got) != 1 || got[0] != "INFO recovered" {
+		t.Fatalf("restart after the trace reads %q, want only the held line", got)
+	}
+
+	// Shutdown learns the held event, and the commit after it moves past.
+	w.flushMultiline()
+	if cat.Len() != 2 {
+		t.Fatalf("after shutdown flush patterns = %d, want 2", cat.Len())
+	}
+	w.commitSources(ctx)
+	if got := restartReads(); len(got) != 0 {
+		t.Fatalf("restart after shutdown reads %q, want nothing", got)
+	}
+}
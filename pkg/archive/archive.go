@@ -0,0 +1,400 @@
+// Package archive is the cold tier for incident history: incidents (with
+// their timelines) and analyses that have aged out of the primary store are
+// written to an object store as gzip-compressed NDJSON, one object per day
+// and batch:
+//
+//	<prefix>/incidents/dt=2026-01-05/<batch>.ndjson.gz
+//	<prefix>/analyses/dt=2026-01-05/<batch>.ndjson.gz
+//
+// The day is the record's UTC creation day (an analysis's requested_at), so
+// the layout is a Hive-style partition that Athena, Trino or DuckDB read
+// directly. Next to the data, a small index object per record
+//
+//	<prefix>/index/incidents/<id>
+//	<prefix>/index/analyses/<id>
+//
+// holds the key of the data object that record was last written to, which
+// is what lets a link to an archived incident still be opened with two GETs
+// instead of a scan.
+//
+// The archive only ever adds objects. A record written twice — a run that
+// uploaded a batch and then failed before deleting it from the primary
+// store — appears in two data objects; readers keep the newest copy.
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/storage"
+	"github.com/google/uuid"
+)
+
+// Record kinds, which are also the top-level directories of the layout.
+const (
+	KindIncidents = "incidents"
+	KindAnalyses  = "analyses"
+)
+
+// DataContentType is the media type of a data object. Objects are stored
+// with Content-Encoding unset so a download keeps the .gz bytes as written.
+const DataContentType = "application/x-ndjson"
+
+// MaxSearchDays bounds the day range one search scans.
+const MaxSearchDays = 366
+
+const (
+	dataExt   = ".ndjson.gz"
+	indexDir  = "index"
+	dayFormat = "2006-01-02"
+)
+
+var (
+	// ErrNotFound is returned for a record or object the archive does not
+	// hold.
+	ErrNotFound = errors.New("archive: not found")
+	// ErrRange is returned for a search whose day range is empty or longer
+	// than MaxSearchDays.
+	ErrRange = errors.New("archive: invalid day range")
+)
+
+// ObjectStore is the minimal object-store surface the archive needs. Get
+// and a missing key return ErrNotFound. List returns every key under prefix,
+// in lexical order.
+type ObjectStore interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	List(ctx context.Context, prefix string) ([]string, error)
+}
+
+// Incident is one archived incident: the record as the primary store held
+// it, plus its timeline, oldest first.
+type Incident struct {
+	storage.IncidentRecord
+	Timeline   []*storage.TimelineEntry `json:"timeline,omitempty"`
+	ArchivedAt time.Time                `json:"archived_at"`
+}
+
+// Analysis is one archived analysis.
+type Analysis struct {
+	storage.AnalysisRecord
+	ArchivedAt time.Time `json:"archived_at"`
+}
+
+// Archive reads and writes the layout above in one object store.
+type Archive struct {
+	store  ObjectStore
+	prefix string
+	now    func() time.Time
+}
+
+// New returns an archive rooted at prefix inside store. A prefix of "" puts
+// the layout at the root of the bucket.
+func New(store ObjectStore, prefix string) *Archive {
+	return &Archive{
+		store:  store,
+		prefix: strings.Trim(prefix, "/"),
+		now:    func() time.Time { return time.Now().UTC() },
+	}
+}
+
+func (a *Archive) key(parts ...string) string {
+	if a.prefix != "" {
+		parts = append([]string{a.prefix}, parts...)
+	}
+	return path.Join(parts...)
+}
+
+func (a *Archive) dayDir(kind string, day time.Time) string {
+	return a.key(kind, "dt="+day.UTC().Format(dayFormat)) + "/"
+}
+
+func (a *Archive) indexKey(kind, id string) string {
+	return a.key(indexDir, kind, id)
+}
+
+// batchName names one write: the time it ran, then a random suffix so two
+// replicas or two runs in the same second never collide.
+func (a *Archive) batchName() string {
+	return a.now().Format("20060102T150405Z") + "-" + uuid.NewString()[:8] + dataExt
+}
+
+// WriteIncidents archives recs, one data object per UTC creation day, then
+// points each record's index at its object. It returns once every object is
+// stored, so the caller may delete the records from the primary store.
+func (a *Archive) WriteIncidents(ctx context.Context, recs []*Incident) error {
+	at := a.now()
+	byDay := make(map[string][]any)
+	ids := make(map[string][]string)
+	for _, rec := range recs {
+		rec.ArchivedAt = at
+		dir := a.dayDir(KindIncidents, rec.CreatedAt)
+		byDay[dir] = append(byDay[dir], rec)
+		ids[dir] = append(ids[dir], rec.ID)
+	}
+	return a.write(ctx, KindIncidents, byDay, ids)
+}
+
+// WriteAnalyses is the analyses twin of WriteIncidents, partitioned by
+// requested_at.
+func (a *Archive) WriteAnalyses(ctx context.Context, recs []*Analysis) error {
+	at := a.now()
+	byDay := make(map[string][]any)
+	ids := make(map[string][]string)
+	for _, rec := range recs {
+		rec.ArchivedAt = at
+		dir := a.dayDir(KindAnalyses, rec.RequestedAt)
+		byDay[dir] = append(byDay[dir], rec)
+		ids[dir] = append(ids[dir], rec.ID)
+	}
+	return a.write(ctx, KindAnalyses, byDay, ids)
+}
+
+func (a *Archive) write(ctx context.Context, kind string, byDay map[string][]any, ids map[string][]string) error {
+	dirs := make([]string, 0, len(byDay))
+	for dir := range byDay {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		data, err := encodeNDJSON(byDay[dir])
+		if err != nil {
+			return fmt.Errorf("archive: encode %s: %w", dir, err)
+		}
+		key := dir + a.batchName()
+		if err := a.store.Put(ctx, key, data, DataContentType); err != nil {
+			return fmt.Errorf("archive: put %s: %w", key, err)
+		}
+		for _, id := range ids[dir] {
+			if err := a.store.Put(ctx, a.indexKey(kind, id), []byte(key), "text/plain"); err != nil {
+				return fmt.Errorf("archive: index %s %s: %w", kind, id, err)
+			}
+		}
+	}
+	return nil
+}
+
+// GetIncident returns the archived incident id, or ErrNotFound.
+func (a *Archive) GetIncident(ctx context.Context, id string) (*Incident, error) {
+	var out *Incident
+	err := a.lookup(ctx, KindIncidents, id, func(line []byte) (bool, error) {
+		var rec Incident
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return false, err
+		}
+		if rec.ID != id {
+			return false, nil
+		}
+		out = &rec
+		return true, nil
+	})
+	return out, err
+}
+
+// GetAnalysis returns the archived analysis id, or ErrNotFound.
+func (a *Archive) GetAnalysis(ctx context.Context, id string) (*Analysis, error) {
+	var out *Analysis
+	err := a.lookup(ctx, KindAnalyses, id, func(line []byte) (bool, error) {
+		var rec Analysis
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return false, err
+		}
+		if rec.ID != id {
+			return false, nil
+		}
+		out = &rec
+		return true, nil
+	})
+	return out, err
+}
+
+// lookup follows the index to the data object and feeds its lines to match
+// until one reports the record found.
+func (a *Archive) lookup(ctx context.Context, kind, id string, match func([]byte) (bool, error)) error {
+	if id == "" || id == "." || id == ".." || strings.Contains(id, "/") {
+		return ErrNotFound
+	}
+	ref, err := a.store.Get(ctx, a.indexKey(kind, id))
+	if err != nil {
+		return err
+	}
+	data, err := a.store.Get(ctx, strings.TrimSpace(string(ref)))
+	if err != nil {
+		return err
+	}
+	found := false
+	err = decodeNDJSON(data, func(line []byte) error {
+		ok, err := match(line)
+		if ok {
+			found = true
+			return errStop
+		}
+		return err
+	})
+	if err != nil && !errors.Is(err, errStop) {
+		return fmt.Errorf("archive: read %s %s: %w", kind, id, err)
+	}
+	if !found {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SearchIncidents scans the days from..to (inclusive, UTC) and returns the
+// incidents matching query in the syntax of storage.Searcher, best match
+// first. limit <= 0 returns every match.
+func (a *Archive) SearchIncidents(ctx context.Context, query string, from, to time.Time, limit int) ([]*Incident, error) {
+	byRec := make(map[*storage.IncidentRecord]*Incident)
+	var recs []*storage.IncidentRecord
+	seen := make(map[string]int)
+	err := a.scan(ctx, KindIncidents, from, to, func(line []byte) error {
+		rec := new(Incident)
+		if err := json.Unmarshal(line, rec); err != nil {
+			return err
+		}
+		// Keys sort by batch time, so a later copy of a record replaces
+		// the earlier one.
+		if i, ok := seen[rec.ID]; ok {
+			delete(byRec, recs[i])
+			recs[i] = &rec.IncidentRecord
+		} else {
+			seen[rec.ID] = len(recs)
+			recs = append(recs, &rec.IncidentRecord)
+		}
+		byRec[&rec.IncidentRecord] = rec
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(recs, func(i, j int) bool { return recs[i].CreatedAt.After(recs[j].CreatedAt) })
+	matched := storage.SearchIncidentRecords(recs, query)
+	if limit > 0 && len(matched) > limit {
+		matched = matched[:limit]
+	}
+	out := make([]*Incident, len(matched))
+	for i, rec := range matched {
+		out[i] = byRec[rec]
+	}
+	return out, nil
+}
+
+// SearchAnalyses is the analyses twin of SearchIncidents.
+func (a *Archive) SearchAnalyses(ctx context.Context, query string, from, to time.Time, limit int) ([]*Analysis, error) {
+	byRec := make(map[*storage.AnalysisRecord]*Analysis)
+	var recs []*storage.AnalysisRecord
+	seen := make(map[string]int)
+	err := a.scan(ctx, KindAnalyses, from, to, func(line []byte) error {
+		rec := new(Analysis)
+		if err := json.Unmarshal(line, rec); err != nil {
+			return err
+		}
+		if i, ok := seen[rec.ID]; ok {
+			delete(byRec, recs[i])
+			recs[i] = &rec.AnalysisRecord
+		} else {
+			seen[rec.ID] = len(recs)
+			recs = append(recs, &rec.AnalysisRecord)
+		}
+		byRec[&rec.AnalysisRecord] = rec
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(recs, func(i, j int) bool { return recs[i].RequestedAt.After(recs[j].RequestedAt) })
+	matched := storage.SearchAnalysisRecords(recs, query)
+	if limit > 0 && len(matched) > limit {
+		matched = matched[:limit]
+	}
+	out := make([]*Analysis, len(matched))
+	for i, rec := range matched {
+		out[i] = byRec[rec]
+	}
+	return out, nil
+}
+
+// scan feeds every line of every data object for the days from..to to fn,
+// oldest day first and, within a day, in batch order.
+func (a *Archive) scan(ctx context.Context, kind string, from, to time.Time, fn func([]byte) error) error {
+	from = truncateDay(from)
+	to = truncateDay(to)
+	if to.Before(from) || to.Sub(from) >= MaxSearchDays*24*time.Hour {
+		return fmt.Errorf("%w: %s to %s (at most %d days)", ErrRange, from.Format(dayFormat), to.Format(dayFormat), MaxSearchDays)
+	}
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		keys, err := a.store.List(ctx, a.dayDir(kind, day))
+		if err != nil {
+			return fmt.Errorf("archive: list %s: %w", day.Format(dayFormat), err)
+		}
+		for _, key := range keys {
+			if !strings.HasSuffix(key, dataExt) {
+				continue
+			}
+			data, err := a.store.Get(ctx, key)
+			if err != nil {
+				return fmt.Errorf("archive: get %s: %w", key, err)
+			}
+			if err := decodeNDJSON(data, fn); err != nil {
+				return fmt.Errorf("archive: read %s: %w", key, err)
+			}
+		}
+	}
+	return nil
+}
+
+func truncateDay(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// errStop ends a decodeNDJSON walk early without an error.
+var errStop = errors.New("stop")
+
+// maxLineBytes bounds one NDJSON line; an incident with a huge payload and
+// timeline still fits.
+const maxLineBytes = 64 << 20
+
+func encodeNDJSON(recs []any) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	enc := json.NewEncoder(zw)
+	for _, rec := range recs {
+		if err := enc.Encode(rec); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decodeNDJSON(data []byte, fn func([]byte) error) error {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	sc := bufio.NewScanner(zr)
+	sc.Buffer(make([]byte, 0, 64<<10), maxLineBytes)
+	for sc.Scan() {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if err := fn(line); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
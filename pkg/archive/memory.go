@@ -0,0 +1,50 @@
+package archive
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// memoryStore is an in-process ObjectStore, for tests and for running the
+// archive job without a bucket.
+type memoryStore struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+// NewMemoryStore returns an empty in-process ObjectStore.
+func NewMemoryStore() ObjectStore {
+	return &memoryStore{objects: make(map[string][]byte)}
+}
+
+func (m *memoryStore) Put(_ context.Context, key string, data []byte, _ string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[key] = append([]byte(nil), data...)
+	return nil
+}
+
+func (m *memoryStore) Get(_ context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.objects[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return append([]byte(nil), data...), nil
+}
+
+func (m *memoryStore) List(_ context.Context, prefix string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var keys []string
+	for key := range m.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
@@ -0,0 +1,348 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+)
+
+// amqpSource is the ingress label stamped on incidents delivered over AMQP
+// (the "incident_source" hint), so they show "amqp" as their source.
+const amqpSource = "amqp"
+
+// amqpConsumerTag identifies this process's consumer in the broker's
+// management UI.
+const amqpConsumerTag = "versus-incident"
+
+// amqpAttemptHeader counts the deliveries a message has failed. The listener
+// sets it when it republishes a failed message, so the count survives a
+// redelivery the broker makes for its own reasons (a dropped connection sets
+// Redelivered on messages that never reached the handler).
+const amqpAttemptHeader = "x-versus-attempts"
+
+// amqpMaxAttempts is how many times a message reaches the handler before a
+// failure dead-letters it: the first delivery and one retry.
+const amqpMaxAttempts = 2
+
+// amqpRepublishTimeout bounds the wait for the broker to confirm a
+// republished message.
+const amqpRepublishTimeout = 10 * time.Second
+
+const (
+	defaultAMQPPrefetch     = 10
+	defaultAMQPMinBackoff   = time.Second
+	defaultAMQPMaxBackoff   = 30 * time.Second
+	defaultAMQPExchangeType = "topic"
+)
+
+// AMQPListener consumes incident payloads from an AMQP 0-9-1 queue
+// (RabbitMQ). Every message is acknowledged manually, and only once the
+// incident has been created, so a crash between delivery and creation hands
+// the message back to the broker instead of losing it.
+//
+// A body that is not a JSON object can never become an incident: it is
+// rejected without requeue, which routes it to the queue's dead-letter
+// exchange when one is configured (and drops it otherwise). A handler
+// failure is retried once: the message is republished to the queue with its
+// attempt count in a header, confirmed by the broker, and only then acked.
+// If the retry fails too the message is dead-lettered the same way, so one
+// poison message cannot spin forever.
+type AMQPListener struct {
+	cfg        config.AMQPConfig
+	debugBody  bool
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+// NewAMQPListener validates cfg and returns a listener for it. Backoff
+// bounds that are empty fall back to 1s / 30s.
+func NewAMQPListener(cfg config.AMQPConfig, debugBody bool) (*AMQPListener, error) {
+	if cfg.URL == "" || cfg.Queue == "" {
+		return nil, fmt.Errorf("missing required AMQP configuration (url and queue)")
+	}
+
+	minBackoff, err := parseBackoff(cfg.ReconnectMinBackoff, defaultAMQPMinBackoff)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amqp.reconnect_min_backoff: %w", err)
+	}
+	maxBackoff, err := parseBackoff(cfg.ReconnectMaxBackoff, defaultAMQPMaxBackoff)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amqp.reconnect_max_backoff: %w", err)
+	}
+	if maxBackoff < minBackoff {
+		maxBackoff = minBackoff
+	}
+
+	if cfg.Prefetch <= 0 {
+		cfg.Prefetch = defaultAMQPPrefetch
+	}
+	if cfg.ExchangeType == "" {
+		cfg.ExchangeType = defaultAMQPExchangeType
+	}
+
+	return &AMQPListener{
+		cfg:        cfg,
+		debugBody:  debugBody,
+		minBackoff: minBackoff,
+		maxBackoff: maxBackoff,
+	}, nil
+}
+
+// SourceName implements core.QueueSourceNamer.
+func (l *AMQPListener) SourceName() string {
+	return amqpSource
+}
+
+// StartListening blocks for the life of the process. Whenever the
+// connection or channel drops it reconnects with exponential backoff; the
+// backoff resets once a session gets as far as consuming again.
+func (l *AMQPListener) StartListening(handler func(content *map[string]interface{}) error) error {
+	backoff := l.minBackoff
+	for {
+		consumed, err := l.consume(handler)
+		if consumed {
+			backoff = l.minBackoff
+		}
+		log.Printf("AMQP listener on queue %q disconnected: %v; reconnecting in %s", l.cfg.Queue, err, backoff)
+		time.Sleep(backoff)
+		backoff = nextBackoff(backoff, l.maxBackoff)
+	}
+}
+
+// consume runs one connection's worth of consumption. consumed reports
+// whether the session reached the point of receiving deliveries; the
+// returned error says why it ended.
+func (l *AMQPListener) consume(handler func(content *map[string]interface{}) error) (consumed bool, err error) {
+	conn, err := amqp.Dial(l.cfg.URL)
+	if err != nil {
+		return false, fmt.Errorf("dial: %w", err)
+	}
+	defer conn.Close()
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return false, fmt.Errorf("open channel: %w", err)
+	}
+	defer ch.Close()
+
+	if err := ch.Qos(l.cfg.Prefetch, 0, false); err != nil {
+		return false, fmt.Errorf("set prefetch: %w", err)
+	}
+	// Confirms make a republished retry safe to ack: the broker has it.
+	if err := ch.Confirm(false); err != nil {
+		return false, fmt.Errorf("enable publisher confirms: %w", err)
+	}
+	retry := func(p amqp.Publishing) error {
+		ctx, cancel := context.WithTimeout(context.Background(), amqpRepublishTimeout)
+		defer cancel()
+		dc, err := ch.PublishWithDeferredConfirmWithContext(ctx, "", l.cfg.Queue, false, false, p)
+		if err != nil {
+			return err
+		}
+		ok, err := dc.WaitContext(ctx)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("broker nacked the republish")
+		}
+		return nil
+	}
+
+	if l.cfg.Declare {
+		if err := l.declare(ch); err != nil {
+			return false, err
+		}
+	}
+
+	deliveries, err := ch.Consume(l.cfg.Queue, amqpConsumerTag, false, false, false, false, nil)
+	if err != nil {
+		return false, fmt.Errorf("consume %q: %w", l.cfg.Queue, err)
+	}
+
+	closed := conn.NotifyClose(make(chan *amqp.Error, 1))
+	log.Printf("AMQP listener consuming from queue %q", l.cfg.Queue)
+
+	for d := range deliveries {
+		l.handleDelivery(d, handler, retry)
+	}
+
+	// The delivery channel closes when the channel or connection does; the
+	// close notification carries the broker's reason when there is one.
+	select {
+	case cerr := <-closed:
+		if cerr != nil {
+			return true, cerr
+		}
+	default:
+	}
+	return true, errors.New("delivery channel closed")
+}
+
+// declare creates the queue, and the exchange plus its bindings when one is
+// configured. Declarations are idempotent, so this runs on every reconnect.
+func (l *AMQPListener) declare(ch *amqp.Channel) error {
+	if _, err := ch.QueueDeclare(l.cfg.Queue, l.cfg.Durable, false, false, false, amqpQueueArgs(l.cfg)); err != nil {
+		return fmt.Errorf("declare queue %q: %w", l.cfg.Queue, err)
+	}
+
+	if l.cfg.Exchange == "" {
+		return nil
+	}
+
+	if err := ch.ExchangeDeclare(l.cfg.Exchange, l.cfg.ExchangeType, l.cfg.Durable, false, false, false, nil); err != nil {
+		return fmt.Errorf("declare exchange %q: %w", l.cfg.Exchange, err)
+	}
+	for _, key := range amqpBindingKeys(l.cfg) {
+		if err := ch.QueueBind(l.cfg.Queue, key, l.cfg.Exchange, false, nil); err != nil {
+			return fmt.Errorf("bind queue %q to %q with key %q: %w", l.cfg.Queue, l.cfg.Exchange, key, err)
+		}
+	}
+	return nil
+}
+
+// handleDelivery turns one message into an incident and settles it with
+// the broker: ack on success, reject-to-DLX on a permanent parse error and
+// on a handler failure past amqpMaxAttempts. An earlier handler failure
+// hands the message to retry with its attempt count raised, then acks it;
+// when retry fails the message is requeued unchanged instead.
+func (l *AMQPListener) handleDelivery(d amqp.Delivery, handler func(content *map[string]interface{}) error, retry func(amqp.Publishing) error) {
+	if l.debugBody {
+		// Quoted: the body is third-party content, so an embedded newline
+		// would forge a log line.
+		fmt.Printf("Queue Message: %q\n", d.Body)
+	}
+
+	content := map[string]interface{}{}
+	if err := json.Unmarshal(d.Body, &content); err != nil || content == nil {
+		log.Printf("AMQP message %d rejected: body is not a JSON object", d.DeliveryTag)
+		if err := d.Reject(false); err != nil {
+			log.Printf("AMQP reject failed: %v", err)
+		}
+		return
+	}
+
+	if err := handler(&content); err != nil {
+		attempt := amqpAttempt(d)
+		if attempt >= amqpMaxAttempts {
+			log.Printf("AMQP message %d failed on attempt %d, dead-lettering: %v", d.DeliveryTag, attempt, err)
+			if err := d.Reject(false); err != nil {
+				log.Printf("AMQP reject failed: %v", err)
+			}
+			return
+		}
+		if rerr := retry(amqpRetryPublishing(d, attempt)); rerr != nil {
+			log.Printf("AMQP message %d failed (%v) and could not be republished, requeueing: %v", d.DeliveryTag, err, rerr)
+			if err := d.Nack(false, true); err != nil {
+				log.Printf("AMQP nack failed: %v", err)
+			}
+			return
+		}
+		log.Printf("AMQP message %d failed on attempt %d, republished for a retry: %v", d.DeliveryTag, attempt, err)
+		if err := d.Ack(false); err != nil {
+			log.Printf("AMQP ack failed: %v", err)
+		}
+		return
+	}
+
+	if err := d.Ack(false); err != nil {
+		log.Printf("AMQP ack failed: %v", err)
+	}
+}
+
+// amqpAttempt is the attempt d is on: one more than the failures its
+// attempt header records, 1 when it has none.
+func amqpAttempt(d amqp.Delivery) int {
+	switch n := d.Headers[amqpAttemptHeader].(type) {
+	case int32:
+		return int(n) + 1
+	case int64:
+		return int(n) + 1
+	case int:
+		return n + 1
+	}
+	return 1
+}
+
+// amqpRetryPublishing copies d for republishing with failures recorded in
+// its attempt header. UserId is dropped: the broker checks it against the
+// publishing connection, which need not be the original sender's.
+func amqpRetryPublishing(d amqp.Delivery, failures int) amqp.Publishing {
+	headers := make(amqp.Table, len(d.Headers)+1)
+	for k, v := range d.Headers {
+		headers[k] = v
+	}
+	headers[amqpAttemptHeader] = int32(failures)
+	return amqp.Publishing{
+		Headers:         headers,
+		ContentType:     d.ContentType,
+		ContentEncoding: d.ContentEncoding,
+		DeliveryMode:    d.DeliveryMode,
+		Priority:        d.Priority,
+		CorrelationId:   d.CorrelationId,
+		ReplyTo:         d.ReplyTo,
+		Expiration:      d.Expiration,
+		MessageId:       d.MessageId,
+		Timestamp:       d.Timestamp,
+		Type:            d.Type,
+		AppId:           d.AppId,
+		Body:            d.Body,
+	}
+}
+
+// amqpQueueArgs returns the x-arguments for the queue declaration: the
+// dead-letter routing, when configured.
+func amqpQueueArgs(cfg config.AMQPConfig) amqp.Table {
+	if cfg.DeadLetterExchange == "" {
+		return nil
+	}
+	args := amqp.Table{"x-dead-letter-exchange": cfg.DeadLetterExchange}
+	if cfg.DeadLetterRoutingKey != "" {
+		args["x-dead-letter-routing-key"] = cfg.DeadLetterRoutingKey
+	}
+	return args
+}
+
+// amqpBindingKeys returns the routing keys to bind with. With none
+// configured a topic exchange binds "#" (everything); other exchange types
+// bind the empty key, which fanout ignores and direct matches literally.
+func amqpBindingKeys(cfg config.AMQPConfig) []string {
+	if len(cfg.RoutingKeys) > 0 {
+		return cfg.RoutingKeys
+	}
+	if cfg.ExchangeType == "topic" {
+		return []string{"#"}
+	}
+	return []string{""}
+}
+
+// parseBackoff parses a duration string, using def when it is empty.
+func parseBackoff(raw string, def time.Duration) (time.Duration, error) {
+	if raw == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("must be positive, got %q", raw)
+	}
+	return d, nil
+}
+
+// nextBackoff doubles cur, capped at max.
+func nextBackoff(cur, max time.Duration) time.Duration {
+	next := cur * 2
+	if next > max {
+		return max
+	}
+	return next
+}
@@ -0,0 +1,205 @@
+package common
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+)
+
+// fakeAcknowledger records how a delivery was settled.
+type fakeAcknowledger struct {
+	acked    bool
+	nacked   bool
+	requeue  bool
+	rejected bool
+}
+
+func (f *fakeAcknowledger) Ack(tag uint64, multiple bool) error {
+	f.acked = true
+	return nil
+}
+
+func (f *fakeAcknowledger) Nack(tag uint64, multiple, requeue bool) error {
+	f.nacked, f.requeue = true, requeue
+	return nil
+}
+
+func (f *fakeAcknowledger) Reject(tag uint64, requeue bool) error {
+	f.rejected, f.requeue = true, requeue
+	return nil
+}
+
+// noRetry fails the test if a delivery is republished.
+func noRetry(t *testing.T) func(amqp.Publishing) error {
+	return func(amqp.Publishing) error {
+		t.Error("unexpected republish")
+		return nil
+	}
+}
+
+func newTestAMQPListener(t *testing.T) *AMQPListener {
+	t.Helper()
+	l, err := NewAMQPListener(config.AMQPConfig{URL: "amqp://localhost", Queue: "q"}, false)
+	if err != nil {
+		t.Fatalf("NewAMQPListener: %v", err)
+	}
+	return l
+}
+
+func TestAMQPListener_AcksAfterIncidentCreated(t *testing.T) {
+	l := newTestAMQPListener(t)
+	ack := &fakeAcknowledger{}
+
+	var got map[string]interface{}
+	l.handleDelivery(amqp.Delivery{Acknowledger: ack, Body: []byte(`{"title":"db down"}`)}, func(content *map[string]interface{}) error {
+		got = *content
+		if ack.acked {
+			t.Error("message acked before the incident was created")
+		}
+		return nil
+	}, noRetry(t))
+
+	if got["title"] != "db down" {
+		t.Errorf("handler got %v, want the decoded body", got)
+	}
+	if !ack.acked || ack.nacked || ack.rejected {
+		t.Errorf("want ack only, got %+v", ack)
+	}
+}
+
+func TestAMQPListener_RejectsUnparseableBodyWithoutRequeue(t *testing.T) {
+	l := newTestAMQPListener(t)
+
+	for _, body := range []string{`not json`, `[1,2]`, `null`} {
+		ack := &fakeAcknowledger{}
+		called := false
+		l.handleDelivery(amqp.Delivery{Acknowledger: ack, Body: []byte(body)}, func(*map[string]interface{}) error {
+			called = true
+			return nil
+		}, noRetry(t))
+		if called {
+			t.Errorf("body %q: handler must not run for an unparseable body", body)
+		}
+		if !ack.rejected || ack.requeue {
+			t.Errorf("body %q: want reject without requeue (dead-letter), got %+v", body, ack)
+		}
+	}
+}
+
+// TestAMQPListener_HandlerFailureRetriesOnceThenDeadLetters checks a failed
+// message is republished with its attempt count and acked, and dead-lettered
+// when the retry fails too. A broker redelivery alone does not count as a
+// failed attempt.
+func TestAMQPListener_HandlerFailureRetriesOnceThenDeadLetters(t *testing.T) {
+	l := newTestAMQPListener(t)
+	failing := func(*map[string]interface{}) error { return errors.New("storage down") }
+	var republished []amqp.Publishing
+	retry := func(p amqp.Publishing) error {
+		republished = append(republished, p)
+		return nil
+	}
+
+	// A message the broker redelivered after a dropped connection never
+	// failed in the handler: it still gets its retry.
+	first := &fakeAcknowledger{}
+	l.handleDelivery(amqp.Delivery{Acknowledger: first, Body: []byte(`{}`), Redelivered: true,
+		Headers: amqp.Table{"trace": "abc"}, MessageId: "m-1", DeliveryMode: amqp.Persistent}, failing, retry)
+	if !first.acked || first.nacked || first.rejected {
+		t.Errorf("first failure: want republish then ack, got %+v", first)
+	}
+	if len(republished) != 1 {
+		t.Fatalf("republished %d messages, want 1", len(republished))
+	}
+	p := republished[0]
+	if p.Headers[amqpAttemptHeader] != int32(1) || p.Headers["trace"] != "abc" || p.MessageId != "m-1" ||
+		p.DeliveryMode != amqp.Persistent || string(p.Body) != `{}` {
+		t.Errorf("republished = %+v, want the message with one failure recorded", p)
+	}
+
+	second := &fakeAcknowledger{}
+	l.handleDelivery(amqp.Delivery{Acknowledger: second, Body: p.Body, Headers: p.Headers}, failing, retry)
+	if !second.rejected || second.requeue || second.acked {
+		t.Errorf("failure on the retry: want reject without requeue, got %+v", second)
+	}
+	if len(republished) != 1 {
+		t.Errorf("the retry was republished again")
+	}
+}
+
+// TestAMQPListener_RepublishFailureRequeues checks a failed message whose
+// republish fails is handed back to the broker rather than acked.
+func TestAMQPListener_RepublishFailureRequeues(t *testing.T) {
+	l := newTestAMQPListener(t)
+	ack := &fakeAcknowledger{}
+	l.handleDelivery(amqp.Delivery{Acknowledger: ack, Body: []byte(`{}`)},
+		func(*map[string]interface{}) error { return errors.New("storage down") },
+		func(amqp.Publishing) error { return errors.New("channel closed") })
+	if !ack.nacked || !ack.requeue || ack.acked {
+		t.Errorf("want nack with requeue, got %+v", ack)
+	}
+}
+
+func TestNewAMQPListener_Validation(t *testing.T) {
+	if _, err := NewAMQPListener(config.AMQPConfig{Queue: "q"}, false); err == nil {
+		t.Error("expected an error for a missing url")
+	}
+	if _, err := NewAMQPListener(config.AMQPConfig{URL: "amqp://x"}, false); err == nil {
+		t.Error("expected an error for a missing queue")
+	}
+	if _, err := NewAMQPListener(config.AMQPConfig{URL: "amqp://x", Queue: "q", ReconnectMinBackoff: "soon"}, false); err == nil {
+		t.Error("expected an error for an unparseable backoff")
+	}
+
+	l, err := NewAMQPListener(config.AMQPConfig{URL: "amqp://x", Queue: "q", ReconnectMinBackoff: "5s", ReconnectMaxBackoff: "2s"}, false)
+	if err != nil {
+		t.Fatalf("NewAMQPListener: %v", err)
+	}
+	if l.maxBackoff != 5*time.Second {
+		t.Errorf("max backoff below min should be raised to min, got %s", l.maxBackoff)
+	}
+	if l.cfg.Prefetch != defaultAMQPPrefetch || l.cfg.ExchangeType != "topic" {
+		t.Errorf("defaults not applied: %+v", l.cfg)
+	}
+	if l.SourceName() != "amqp" {
+		t.Errorf("SourceName = %q, want amqp", l.SourceName())
+	}
+}
+
+func TestNextBackoff_DoublesUpToMax(t *testing.T) {
+	b := time.Second
+	var seen []time.Duration
+	for i := 0; i < 6; i++ {
+		b = nextBackoff(b, 10*time.Second)
+		seen = append(seen, b)
+	}
+	want := []time.Duration{2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second, 10 * time.Second}
+	for i := range want {
+		if seen[i] != want[i] {
+			t.Fatalf("backoff sequence = %v, want %v", seen, want)
+		}
+	}
+}
+
+func TestAMQPTopology(t *testing.T) {
+	if args := amqpQueueArgs(config.AMQPConfig{}); args != nil {
+		t.Errorf("no DLX configured: want nil args, got %v", args)
+	}
+	args := amqpQueueArgs(config.AMQPConfig{DeadLetterExchange: "dlx", DeadLetterRoutingKey: "dead"})
+	if args["x-dead-letter-exchange"] != "dlx" || args["x-dead-letter-routing-key"] != "dead" {
+		t.Errorf("DLX args = %v", args)
+	}
+
+	if keys := amqpBindingKeys(config.AMQPConfig{ExchangeType: "topic"}); len(keys) != 1 || keys[0] != "#" {
+		t.Errorf("topic default binding = %v, want [#]", keys)
+	}
+	if keys := amqpBindingKeys(config.AMQPConfig{ExchangeType: "fanout"}); len(keys) != 1 || keys[0] != "" {
+		t.Errorf("fanout default binding = %v, want [\"\"]", keys)
+	}
+	if keys := amqpBindingKeys(config.AMQPConfig{ExchangeType: "topic", RoutingKeys: []string{"alerts.*"}}); len(keys) != 1 || keys[0] != "alerts.*" {
+		t.Errorf("explicit binding = %v", keys)
+	}
+}
@@ -35,6 +35,14 @@ func (f *ListenerFactory) CreateListeners() ([]core.QueueListener, error) {
 		listeners = append(listeners, sqsListener)
 	}
 
+	if f.cfg.Queue.AMQP.Enable {
+		amqpListener, err := NewAMQPListener(f.cfg.Queue.AMQP, f.cfg.Queue.DebugBody)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create AMQP listener: %w", err)
+		}
+		listeners = append(listeners, amqpListener)
+	}
+
 	if f.cfg.Queue.PubSub.Enable {
 		return nil, fmt.Errorf("GCP Pub/Sub listener not implemented")
 	}
@@ -16,6 +16,11 @@ func NewSQSListener(cfg config.SQSConfig) *SQSListener {
 	}
 }
 
+// SourceName implements core.QueueSourceNamer.
+func (s *SQSListener) SourceName() string {
+	return "sqs"
+}
+
 func (s *SQSListener) StartListening(handler func(content *map[string]interface{}) error) error {
 	return fmt.Errorf("SQS not implemented")
 }
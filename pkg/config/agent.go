@@ -139,7 +139,7 @@ type AgentRegexConfig struct {
 
 type AgentSourceConfig struct {
 	Name           string                          `mapstructure:"name"`
-	Type           string                          `mapstructure:"type"` // "elasticsearch" | "file" | "loki" | "cloudwatchlogs" | "graylog" | "splunk" | "signoz" | <registered type, e.g. "prometheus"/"traces" via Versus Enterprise>
+	Type           string                          `mapstructure:"type"` // "elasticsearch" | "file" | "loki" | "cloudwatchlogs" | "graylog" | "splunk" | "signoz" | "otlp" | "syslog" | "kafka" | "kubernetes" | "http" | <registered type, e.g. "prometheus"/"traces" via Versus Enterprise>
 	Enable         bool                            `mapstructure:"enable"`
 	Elasticsearch  AgentElasticsearchSourceConfig  `mapstructure:"elasticsearch"`
 	File           AgentFileSourceConfig           `mapstructure:"file"`
@@ -148,6 +148,11 @@ type AgentSourceConfig struct {
 	Graylog        AgentGraylogSourceConfig        `mapstructure:"graylog"`
 	Splunk         AgentSplunkSourceConfig         `mapstructure:"splunk"`
 	Signoz         AgentSignozSourceConfig         `mapstructure:"signoz"`
+	OTLP           AgentOTLPSourceConfig           `mapstructure:"otlp"`
+	Syslog         AgentSyslogSourceConfig         `mapstructure:"syslog"`
+	Kafka          AgentKafkaSourceConfig          `mapstructure:"kafka"`
+	Kubernetes     AgentKubernetesSourceConfig     `mapstructure:"kubernetes"`
+	HTTP           AgentHTTPSourceConfig           `mapstructure:"http"`
 	// Options is a generic per-source settings block consumed by source
 	// types resolved through the runtime registration hook
 	// (signalsources.Register) rather than built into OSS — e.g. the
@@ -156,6 +161,62 @@ type AgentSourceConfig struct {
 	// ignore this field. The registered Factory decodes this map into its
 	// own concrete config struct.
 	Options map[string]interface{} `mapstructure:"options"`
+
+	// Multiline joins multi-line events (stack traces) into one signal
+	// before they are learned. Works on every source type; off when empty.
+	Multiline AgentMultilineConfig `mapstructure:"multiline"`
+	// KeyValue extracts logfmt key=value pairs from each message. Works on
+	// every source type; off unless enabled. The file, Kafka and Kubernetes
+	// sources can instead read lines with `format: logfmt`.
+	KeyValue AgentKeyValueConfig `mapstructure:"key_value"`
+}
+
+// AgentKeyValueConfig drives the key=value stage the worker runs on each
+// source's messages before the multiline stage. A message made only of
+// key=value pairs (`level=error msg="db timeout" svc=orders`) is split: the
+// message, severity, timestamp and service keys map onto the signal and the
+// rest go into Fields, so the miner clusters the message value alone. Other
+// messages pass through unchanged.
+type AgentKeyValueConfig struct {
+	Enable bool `mapstructure:"enable"`
+	// Each *Field names the one key to read. When empty the usual spellings
+	// are tried in order: "msg", "message"; "level", "lvl", "severity";
+	// "ts", "time", "timestamp"; "service", "svc".
+	MessageField   string `mapstructure:"message_field"`
+	SeverityField  string `mapstructure:"severity_field"`
+	TimestampField string `mapstructure:"timestamp_field"`
+	ServiceField   string `mapstructure:"service_field"`
+	// TimestampLayout is a Go time layout for the timestamp value. Default
+	// tries RFC3339Nano, RFC3339 and "2006-01-02 15:04:05"; Unix seconds or
+	// milliseconds are always accepted.
+	TimestampLayout string `mapstructure:"timestamp_layout"`
+}
+
+// AgentMultilineConfig drives the multiline stage the worker runs on each
+// source's lines, so a stack trace is learned as one signal whose message is
+// its first line rather than one pattern per frame.
+//
+// A line continues the event before it when it matches ContinuePattern, or,
+// with StartPattern set, when it does not match StartPattern. A line that
+// matches StartPattern always begins a new event. The stage is on when a
+// preset or either pattern is set.
+type AgentMultilineConfig struct {
+	// Preset supplies the patterns for a runtime's stack traces: "java",
+	// "python", "go" or "dotnet". StartPattern and ContinuePattern add to it.
+	Preset string `mapstructure:"preset"`
+	// StartPattern is a regex matching the first line of an event.
+	StartPattern string `mapstructure:"start_pattern"`
+	// ContinuePattern is a regex matching a line that belongs to the event
+	// before it.
+	ContinuePattern string `mapstructure:"continue_pattern"`
+	// MaxLines caps the lines of one event; later lines of it are dropped.
+	// Default 200.
+	MaxLines int `mapstructure:"max_lines"`
+	// MaxBytes caps the size of one event in the same way. Default 64 KiB.
+	MaxBytes int `mapstructure:"max_bytes"`
+	// FlushTimeout is how long after its last line was received an event is
+	// held for more lines. Default "5s".
+	FlushTimeout string `mapstructure:"flush_timeout"`
 }
 
 // AgentFileSourceConfig drives the file-tailing SignalSource.
@@ -165,16 +226,30 @@ type AgentSourceConfig struct {
 // fill up. It tracks its position in a sidecar cursor file so it survives
 // restarts and handles log rotation (file shrinks → start over from offset 0).
 type AgentFileSourceConfig struct {
-	// Path to the log file. Globs are NOT supported (one source = one file).
+	// Path to the log file, a glob, or a directory. Globs take `*`, `?` and
+	// `[...]` per path segment, and `**` as a whole segment for any number of
+	// directories ("/var/log/apps/**/*.log"). A directory means every file
+	// beneath it.
 	Path string `mapstructure:"path"`
-	// Format: "text" (default) or "json" (one JSON object per line).
+	// Paths adds more files, globs or directories to Path.
+	Paths []string `mapstructure:"paths"`
+	// Exclude skips files whose name or full path matches one of these globs
+	// (e.g. "*.1", "*.gz").
+	Exclude []string `mapstructure:"exclude"`
+	// Format: "text" (default), "json" (one JSON object per line) or
+	// "logfmt" (key=value pairs; see AgentKeyValueConfig for the keys read).
 	Format string `mapstructure:"format"`
 	// CursorPath overrides the default sidecar cursor file location
-	// (default: a ".versus-cursor-<source_name>" file next to the watched log file).
+	// (default: a ".versus-cursor-<source_name>" file in the directory of the
+	// watched file, or the deepest directory of Path without glob characters).
+	// It holds one byte offset per file, keyed by device and inode.
 	CursorPath string `mapstructure:"cursor_path"`
 	// FromBeginning controls behavior when there is no cursor yet.
-	// false (default) starts at the current end of the file (tail-like).
-	// true reads the whole file from the start (replay-like — useful for tests).
+	// false (default) starts at the current end of each file (tail-like).
+	// true reads every file from the start (replay-like — useful for tests),
+	// including `.gz` rotated files. Files that appear later are always read
+	// from the start, except `.gz` files, which are skipped as compressed
+	// copies of files already read.
 	FromBeginning bool `mapstructure:"from_beginning"`
 	// MaxLineBytes caps a single line's length to protect memory; longer
 	// lines are truncated. Default 64 KiB.
@@ -196,13 +271,222 @@ type AgentFileSourceConfig struct {
 	// RFC3339, then "2006-01-02 15:04:05".
 	TimestampLayout string `mapstructure:"timestamp_layout"`
 
-	// JSON-mode options ------------------------------------------------------
+	// JSON- and logfmt-mode options -------------------------------------------
+	// The defaults are for JSON. In logfmt mode an empty option tries the
+	// keys listed on AgentKeyValueConfig, and TimestampLayout applies to the
+	// timestamp value.
 
 	MessageField   string `mapstructure:"message_field"`   // default: "message"
 	TimestampField string `mapstructure:"timestamp_field"` // default: "@timestamp"
 	SeverityField  string `mapstructure:"severity_field"`  // default: "level"
 }
 
+// AgentOTLPSourceConfig drives the OTLP logs receiver SignalSource.
+//
+// Unlike every other source it does not poll: it listens for
+// ExportLogsServiceRequest on OTLP/HTTP (`POST /v1/logs`, protobuf or JSON,
+// optionally gzip) and OTLP/gRPC, and buffers the records in a bounded queue
+// that each tick drains. When the queue is full, exports are refused with HTTP
+// 429 / gRPC RESOURCE_EXHAUSTED so the sender retries later instead of the
+// agent dropping records.
+type AgentOTLPSourceConfig struct {
+	// HTTPListen is the OTLP/HTTP listen address, e.g. ":4318". Empty
+	// disables OTLP/HTTP.
+	HTTPListen string `mapstructure:"http_listen"`
+	// GRPCListen is the OTLP/gRPC listen address, e.g. ":4317". Empty
+	// disables OTLP/gRPC. At least one of the two is required.
+	GRPCListen string `mapstructure:"grpc_listen"`
+	// AuthToken, when set, must be presented as `Authorization: Bearer
+	// <token>` (an HTTP header or gRPC metadata). Empty accepts any sender.
+	AuthToken string `mapstructure:"auth_token"`
+	// QueueSize caps the records held between receipt and the tick that
+	// drains them. Default 10000.
+	QueueSize int `mapstructure:"queue_size"`
+	// QueueDir, when set, backs the queue with a file in this directory so
+	// records accepted but not yet learned survive a restart. A record leaves
+	// the file only after the catalog flush that learned it. Empty keeps the
+	// queue in memory.
+	QueueDir string `mapstructure:"queue_dir"`
+	// MaxPullRecords caps the records one Pull returns; the rest stay queued
+	// for the next tick. Default 1000.
+	MaxPullRecords int `mapstructure:"max_pull_records"`
+}
+
+// AgentHTTPSourceConfig drives the HTTP push SignalSource: senders that cannot
+// be polled (batch jobs, serverless functions) POST NDJSON or a JSON array of
+// records to `/api/agent/ingest/<source name>`. Records wait in a bounded
+// queue that each tick drains, exactly like the OTLP receiver's.
+type AgentHTTPSourceConfig struct {
+	// AuthToken, when set, is accepted as `Authorization: Bearer <token>`
+	// for this source only. The gateway secret (`X-Gateway-Secret`) is
+	// always accepted too, so the endpoint is never unauthenticated.
+	AuthToken string `mapstructure:"auth_token"`
+	// MaxRequestBytes caps one request body. Default and ceiling 4 MiB, the
+	// server's body limit.
+	MaxRequestBytes int `mapstructure:"max_request_bytes"`
+	// RateLimit is the records per second this source accepts; 0 means no
+	// limit. RateBurst is how many may arrive at once, and so the most one
+	// request may carry; default RateLimit rounded up.
+	RateLimit float64 `mapstructure:"rate_limit"`
+	RateBurst int     `mapstructure:"rate_burst"`
+	// QueueSize, QueueDir and MaxPullRecords work as on the OTLP receiver.
+	// Defaults 10000, in memory, and 1000.
+	QueueSize      int    `mapstructure:"queue_size"`
+	QueueDir       string `mapstructure:"queue_dir"`
+	MaxPullRecords int    `mapstructure:"max_pull_records"`
+	// MessageField, TimestampField and SeverityField name the record keys
+	// read into the signal. Defaults "message", "@timestamp" and "level".
+	MessageField   string `mapstructure:"message_field"`
+	TimestampField string `mapstructure:"timestamp_field"`
+	SeverityField  string `mapstructure:"severity_field"`
+	// ServiceField names the key holding the service name. Default
+	// "service".
+	ServiceField string `mapstructure:"service_field"`
+}
+
+// AgentSyslogSourceConfig drives the syslog receiver SignalSource.
+//
+// Like the OTLP receiver it listens instead of polling: RFC 5424 and RFC 3164
+// messages arrive over UDP, TCP or TLS and wait in a bounded queue that each
+// tick drains. TCP and TLS accept both octet-counting and newline framing
+// (RFC 6587). When the queue is full, TCP and TLS connections stop being read
+// until it drains, which pushes back on the sender; UDP has no way to push
+// back, so datagrams are dropped and counted.
+type AgentSyslogSourceConfig struct {
+	// UDPListen, TCPListen and TLSListen are listen addresses, e.g. ":514",
+	// ":601" and ":6514". Empty disables that transport; at least one is
+	// required.
+	UDPListen string `mapstructure:"udp_listen"`
+	TCPListen string `mapstructure:"tcp_listen"`
+	TLSListen string `mapstructure:"tls_listen"`
+	// TLSCertFile and TLSKeyFile are the PEM server certificate and key.
+	// Required with TLSListen.
+	TLSCertFile string `mapstructure:"tls_cert_file"`
+	TLSKeyFile  string `mapstructure:"tls_key_file"`
+	// TLSClientCAFile, when set, requires TLS clients to present a
+	// certificate signed by one of these CAs.
+	TLSClientCAFile string `mapstructure:"tls_client_ca_file"`
+	// Format is "auto" (default: RFC 5424 when the message carries its
+	// version field, RFC 3164 otherwise), "rfc5424" or "rfc3164".
+	Format string `mapstructure:"format"`
+	// MaxMessageBytes caps one message; longer ones are truncated. Default
+	// 64 KiB.
+	MaxMessageBytes int `mapstructure:"max_message_bytes"`
+	// MaxConnections caps the TCP and TLS connections served at once, across
+	// both listeners; a connection over it is closed on accept. Default 1000.
+	MaxConnections int `mapstructure:"max_connections"`
+	// IdleTimeout closes a TCP or TLS connection that sends nothing for this
+	// long, and bounds the TLS handshake. Default "5m".
+	IdleTimeout string `mapstructure:"idle_timeout"`
+	// QueueSize, QueueDir and MaxPullRecords work as on the OTLP receiver
+	// (AgentOTLPSourceConfig). Defaults 10000, in memory, 1000.
+	QueueSize      int    `mapstructure:"queue_size"`
+	QueueDir       string `mapstructure:"queue_dir"`
+	MaxPullRecords int    `mapstructure:"max_pull_records"`
+}
+
+// AgentKafkaSourceConfig drives the Kafka SignalSource.
+//
+// The source consumes one topic as a member of a consumer group, so replicas
+// sharing a group_id split the partitions between them. Each record value is
+// one log line, mapped with the same options as the file source (text with an
+// optional leading timestamp, or JSON with message/timestamp/severity fields).
+// Offsets are committed only after the catalog flush that learned the records,
+// so a crash re-delivers records rather than skipping them.
+type AgentKafkaSourceConfig struct {
+	// Brokers are the bootstrap brokers, e.g. ["kafka-0:9092"]. Required.
+	Brokers []string `mapstructure:"brokers"`
+	// Topic is the topic to consume. Required.
+	Topic string `mapstructure:"topic"`
+	// GroupID is the consumer group. Required.
+	GroupID string `mapstructure:"group_id"`
+	// ClientID identifies the agent to the brokers. Default "versus-agent".
+	ClientID string `mapstructure:"client_id"`
+	// StartOffset is where a group with no committed offset starts:
+	// "latest" (default, tail-like) or "earliest" (the whole retention).
+	StartOffset string `mapstructure:"start_offset"`
+	// RewindLookback is how far back (a Go duration, e.g. "1h") clearing the
+	// pattern catalog moves the group's offsets, so the worker re-learns the
+	// recent past. Empty → 1h default; an invalid value is a config error.
+	RewindLookback string `mapstructure:"rewind_lookback"`
+	// MaxPullRecords caps the records one tick takes. Default 1000; keep it
+	// at or below agent.batch_max.
+	MaxPullRecords int `mapstructure:"max_pull_records"`
+
+	// Format, TimestampLayout, MessageField, TimestampField and
+	// SeverityField map each record value exactly as on the file source
+	// (AgentFileSourceConfig).
+	Format          string `mapstructure:"format"`
+	TimestampLayout string `mapstructure:"timestamp_layout"`
+	MessageField    string `mapstructure:"message_field"`
+	TimestampField  string `mapstructure:"timestamp_field"`
+	SeverityField   string `mapstructure:"severity_field"`
+
+	TLS  AgentKafkaTLSConfig  `mapstructure:"tls"`
+	SASL AgentKafkaSASLConfig `mapstructure:"sasl"`
+}
+
+// AgentKafkaTLSConfig enables TLS to the brokers. CAFile adds a CA to the
+// system pool; CertFile and KeyFile present a client certificate (mTLS).
+type AgentKafkaTLSConfig struct {
+	Enable             bool   `mapstructure:"enable"`
+	CAFile             string `mapstructure:"ca_file"`
+	CertFile           string `mapstructure:"cert_file"`
+	KeyFile            string `mapstructure:"key_file"`
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify"`
+}
+
+// AgentKafkaSASLConfig authenticates to the brokers. Mechanism is empty (no
+// SASL), "plain", "scram-sha-256" or "scram-sha-512".
+type AgentKafkaSASLConfig struct {
+	Mechanism string `mapstructure:"mechanism"`
+	Username  string `mapstructure:"username"`
+	Password  string `mapstructure:"password"`
+}
+
+// AgentKubernetesSourceConfig drives the Kubernetes pod log SignalSource.
+//
+// It reads container logs straight from the API server (`pods/log`), for
+// clusters with no log stack. Each tick lists the pods matching the
+// namespaces and label selector and reads every container's new lines from a
+// per-container `sinceTime` cursor. Restarted containers have the rest of the
+// previous instance's log read first, and deleted pods are forgotten.
+type AgentKubernetesSourceConfig struct {
+	// Kubeconfig is the path of a kubeconfig file. Empty uses the pod's
+	// service account when running in a cluster, else $KUBECONFIG, else
+	// ~/.kube/config.
+	Kubeconfig string `mapstructure:"kubeconfig"`
+	// Context selects a kubeconfig context. Empty uses current-context.
+	Context string `mapstructure:"context"`
+	// Namespaces limits the pods to these namespaces. Empty means every
+	// namespace, which needs a ClusterRole.
+	Namespaces []string `mapstructure:"namespaces"`
+	// LabelSelector is a Kubernetes label selector, e.g.
+	// "app.kubernetes.io/part-of=shop,tier!=batch". Empty matches every pod.
+	LabelSelector string `mapstructure:"label_selector"`
+	// Containers, when set, reads only containers with these names.
+	Containers []string `mapstructure:"containers"`
+	// MaxPullRecords caps the lines one tick takes across all containers.
+	// Default 1000; keep it at or below agent.batch_max.
+	MaxPullRecords int `mapstructure:"max_pull_records"`
+	// MaxLinesPerContainer caps the lines one tick takes from one container,
+	// so a noisy container cannot starve the rest. Default 500.
+	MaxLinesPerContainer int `mapstructure:"max_lines_per_container"`
+	// MaxLineBytes truncates longer lines. Default 64 KiB.
+	MaxLineBytes int `mapstructure:"max_line_bytes"`
+	// InsecureSkipVerify disables API server certificate checks. Dev only.
+	InsecureSkipVerify bool `mapstructure:"insecure_skip_verify"`
+
+	// Format, TimestampLayout, MessageField, TimestampField and
+	// SeverityField map each line exactly as on the file source
+	// (AgentFileSourceConfig). The runtime's own timestamp is the fallback.
+	Format          string `mapstructure:"format"`
+	TimestampLayout string `mapstructure:"timestamp_layout"`
+	MessageField    string `mapstructure:"message_field"`
+	TimestampField  string `mapstructure:"timestamp_field"`
+	SeverityField   string `mapstructure:"severity_field"`
+}
+
 type AgentElasticsearchSourceConfig struct {
 	Addresses          []string `mapstructure:"addresses"`
 	Username           string   `mapstructure:"username"`
@@ -255,6 +539,13 @@ type AgentLokiSourceConfig struct {
 	// PageSize is the per-query limit (Loki caps this around 5000 by
 	// default). Default 500.
 	PageSize int `mapstructure:"page_size"`
+	// ReorderWindow is how far back (a Go duration, e.g. "1m") each tick
+	// re-reads below the cursor to catch entries that became queryable after
+	// the cursor passed their timestamp — ingester flush lag, clock skew,
+	// out-of-order writes. Entries already delivered are suppressed with a
+	// persisted id set. Unset keeps the strict `start = cursor + 1ns` scan.
+	// Entries arriving more than ReorderWindow late are not recovered.
+	ReorderWindow string `mapstructure:"reorder_window"`
 }
 
 // AgentCloudWatchLogsSourceConfig drives the AWS CloudWatch Logs SignalSource.
@@ -324,9 +615,17 @@ type AgentGraylogSourceConfig struct {
 	// must also appear in Fields (or Fields must be empty so the server
 	// returns the whole document).
 	ExtraFields []string `mapstructure:"extra_fields"`
-	// PageSize caps results per tick (Graylog default cap is 150).
+	// PageSize is the per-request limit (Graylog default cap is 150).
 	// Default 500.
 	PageSize int `mapstructure:"page_size"`
+	// ReorderWindow is how far back (a Go duration, e.g. "1m") each tick
+	// re-reads below the cursor to catch messages indexed after the cursor
+	// passed their timestamp — journal and index refresh lag, clock skew.
+	// Messages already delivered are suppressed by their `_id` with a
+	// persisted id set. Unset keeps the strict `timestamp > cursor` scan,
+	// which drops a message sharing the cursor's millisecond. Messages
+	// arriving more than ReorderWindow late are not recovered.
+	ReorderWindow string `mapstructure:"reorder_window"`
 }
 
 // AgentSplunkSourceConfig drives the Splunk SignalSource.
@@ -358,8 +657,16 @@ type AgentSplunkSourceConfig struct {
 	SeverityField string `mapstructure:"severity_field"`
 	// ExtraFields are copied into Signal.Fields.
 	ExtraFields []string `mapstructure:"extra_fields"`
-	// PageSize caps results per tick. Default 500.
+	// PageSize is the per-request `count`. Default 500.
 	PageSize int `mapstructure:"page_size"`
+	// ReorderWindow is how far back (a Go duration, e.g. "1m") each tick
+	// re-reads below the cursor to catch events indexed after the cursor
+	// passed their `_time` — forwarder queueing, indexing lag, clock skew.
+	// Events already delivered are suppressed by `_bkt` and `_cd` with a
+	// persisted id set. Unset keeps the strict `_time > cursor` scan, which
+	// drops an event sharing the cursor's millisecond. Events arriving more
+	// than ReorderWindow late are not recovered.
+	ReorderWindow string `mapstructure:"reorder_window"`
 }
 
 // AgentSignozSourceConfig drives the SigNoz logs SignalSource.
@@ -44,6 +44,9 @@ func cloneStorageConfig(src StorageConfig) StorageConfig {
 			Driver: src.Database.Driver,
 			DSN:    src.Database.DSN,
 		},
+		Retention:  src.Retention,
+		Encryption: src.Encryption,
+		Archive:    src.Archive,
 	}
 }
 
@@ -155,11 +158,13 @@ func cloneLarkConfig(src LarkConfig) LarkConfig {
 // Helper function to deep clone the QueueConfig struct
 func cloneQueueConfig(src QueueConfig) QueueConfig {
 	return QueueConfig{
-		Enable: src.Enable,
-		SNS:    cloneSNSConfig(src.SNS),
-		SQS:    cloneSQSConfig(src.SQS),
-		PubSub: clonePubSubConfig(src.PubSub),
-		AzBus:  cloneAzBusConfig(src.AzBus),
+		Enable:    src.Enable,
+		DebugBody: src.DebugBody,
+		SNS:       cloneSNSConfig(src.SNS),
+		SQS:       cloneSQSConfig(src.SQS),
+		AMQP:      cloneAMQPConfig(src.AMQP),
+		PubSub:    clonePubSubConfig(src.PubSub),
+		AzBus:     cloneAzBusConfig(src.AzBus),
 	}
 }
 
@@ -178,6 +183,13 @@ func cloneSQSConfig(src SQSConfig) SQSConfig {
 	}
 }
 
+// Helper function to deep clone the AMQPConfig struct
+func cloneAMQPConfig(src AMQPConfig) AMQPConfig {
+	dst := src
+	dst.RoutingKeys = append([]string(nil), src.RoutingKeys...)
+	return dst
+}
+
 // Helper function to deep clone the PubSubConfig struct
 func clonePubSubConfig(src PubSubConfig) PubSubConfig {
 	return PubSubConfig{
@@ -396,6 +408,7 @@ func cloneAgentConfig(src AgentConfig) AgentConfig {
 					Query:              s.Loki.Query,
 					SeverityField:      s.Loki.SeverityField,
 					PageSize:           s.Loki.PageSize,
+					ReorderWindow:      s.Loki.ReorderWindow,
 				},
 				CloudWatchLogs: AgentCloudWatchLogsSourceConfig{
 					Region:          s.CloudWatchLogs.Region,
@@ -416,6 +429,7 @@ func cloneAgentConfig(src AgentConfig) AgentConfig {
 					MessageField:       s.Graylog.MessageField,
 					SeverityField:      s.Graylog.SeverityField,
 					PageSize:           s.Graylog.PageSize,
+					ReorderWindow:      s.Graylog.ReorderWindow,
 				},
 				Splunk: AgentSplunkSourceConfig{
 					Address:            s.Splunk.Address,
@@ -428,6 +442,7 @@ func cloneAgentConfig(src AgentConfig) AgentConfig {
 					MessageField:       s.Splunk.MessageField,
 					SeverityField:      s.Splunk.SeverityField,
 					PageSize:           s.Splunk.PageSize,
+					ReorderWindow:      s.Splunk.ReorderWindow,
 				},
 				Signoz: AgentSignozSourceConfig{
 					Address:            s.Signoz.Address,
@@ -439,6 +454,14 @@ func cloneAgentConfig(src AgentConfig) AgentConfig {
 					PageSize:           s.Signoz.PageSize,
 					ReorderWindow:      s.Signoz.ReorderWindow,
 				},
+				OTLP:   s.OTLP,
+				Syslog: s.Syslog,
+				Kafka:  s.Kafka,
+
+				Kubernetes: s.Kubernetes,
+				HTTP:       s.HTTP,
+				Multiline:  s.Multiline,
+				KeyValue:   s.KeyValue,
 			}
 			if s.Elasticsearch.Addresses != nil {
 				c.Elasticsearch.Addresses = append([]string(nil), s.Elasticsearch.Addresses...)
@@ -461,6 +484,21 @@ func cloneAgentConfig(src AgentConfig) AgentConfig {
 			if s.Signoz.ExtraFields != nil {
 				c.Signoz.ExtraFields = append([]string(nil), s.Signoz.ExtraFields...)
 			}
+			if s.Kafka.Brokers != nil {
+				c.Kafka.Brokers = append([]string(nil), s.Kafka.Brokers...)
+			}
+			if s.File.Paths != nil {
+				c.File.Paths = append([]string(nil), s.File.Paths...)
+			}
+			if s.File.Exclude != nil {
+				c.File.Exclude = append([]string(nil), s.File.Exclude...)
+			}
+			if s.Kubernetes.Namespaces != nil {
+				c.Kubernetes.Namespaces = append([]string(nil), s.Kubernetes.Namespaces...)
+			}
+			if s.Kubernetes.Containers != nil {
+				c.Kubernetes.Containers = append([]string(nil), s.Kubernetes.Containers...)
+			}
 			if s.Options != nil {
 				c.Options = cloneAnyMap(s.Options)
 			}
@@ -137,6 +137,28 @@ func TestCloneConfigCarriesElasticsearchReorderWindow(t *testing.T) {
 	}
 }
 
+// TestCloneConfigCarriesTailReorderWindows asserts the Loki, Graylog and
+// Splunk reorder_window survives a cloneConfig round-trip. These blocks are
+// copied field by field, so a new field left out of the clone would be
+// silently reset on every per-request config.
+func TestCloneConfigCarriesTailReorderWindows(t *testing.T) {
+	src := &Config{}
+	src.Agent.Sources = []AgentSourceConfig{
+		{Name: "l", Type: "loki", Loki: AgentLokiSourceConfig{Address: "http://loki:3100", ReorderWindow: "1m"}},
+		{Name: "g", Type: "graylog", Graylog: AgentGraylogSourceConfig{Address: "http://graylog:9000", ReorderWindow: "2m"}},
+		{Name: "s", Type: "splunk", Splunk: AgentSplunkSourceConfig{Address: "https://splunk:8089", ReorderWindow: "3m"}},
+	}
+	dst := cloneConfig(src)
+	got := []string{
+		dst.Agent.Sources[0].Loki.ReorderWindow,
+		dst.Agent.Sources[1].Graylog.ReorderWindow,
+		dst.Agent.Sources[2].Splunk.ReorderWindow,
+	}
+	if !reflect.DeepEqual(got, []string{"1m", "2m", "3m"}) {
+		t.Fatalf("cloned reorder windows = %v", got)
+	}
+}
+
 // TestCloneConfigCarriesSignozSource asserts the SigNoz typed block survives a
 // full cloneConfig round-trip. Per-request configs are built by cloning the
 // base, so a field missing from the clone is silently dropped on every request —
@@ -179,3 +201,28 @@ func TestCloneConfigCarriesSignozSource(t *testing.T) {
 		t.Error("clone shares the ExtraFields slice with the source")
 	}
 }
+
+// TestCloneQueueConfigCarriesAMQP asserts the AMQP block survives a full
+// cloneConfig round-trip, so a per-request override keeps the listener's
+// settings, and that the routing keys are copied rather than shared.
+func TestCloneQueueConfigCarriesAMQP(t *testing.T) {
+	src := &Config{}
+	src.Queue = QueueConfig{
+		Enable:    true,
+		DebugBody: true,
+		AMQP: AMQPConfig{
+			Enable: true, URL: "amqps://mq:5671/", Queue: "alerts", Declare: true, Durable: true,
+			Exchange: "events", ExchangeType: "topic", RoutingKeys: []string{"alert.#", "page.#"}, Prefetch: 20,
+			DeadLetterExchange: "dlx", DeadLetterRoutingKey: "dead",
+			ReconnectMinBackoff: "1s", ReconnectMaxBackoff: "30s",
+		},
+	}
+	dst := cloneConfig(src)
+	if !reflect.DeepEqual(dst.Queue, src.Queue) {
+		t.Fatalf("queue block = %+v, want %+v", dst.Queue, src.Queue)
+	}
+	dst.Queue.AMQP.RoutingKeys[0] = "mutated"
+	if src.Queue.AMQP.RoutingKeys[0] != "alert.#" {
+		t.Fatal("clone shares the RoutingKeys slice with the source")
+	}
+}
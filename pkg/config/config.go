@@ -56,6 +56,71 @@ type StorageConfig struct {
 	Redis    StorageRedisConfig    `mapstructure:"redis"`
 	Database StorageDatabaseConfig `mapstructure:"database"`
 	Postgres StoragePostgresConfig `mapstructure:"postgres"`
+
+	Retention  StorageRetentionConfig  `mapstructure:"retention"`
+	Encryption StorageEncryptionConfig `mapstructure:"encryption"`
+	Archive    StorageArchiveConfig    `mapstructure:"archive"`
+}
+
+// StorageArchiveConfig drives the archive job, which moves incidents (with
+// their timelines) and analyses older than a per-domain age out of the
+// primary store into an S3-compatible bucket as gzip-compressed NDJSON,
+// partitioned by day. A zero age keeps that domain in the primary store.
+// Archived incidents stay readable by id and through the archive search.
+// Backends without storage.Lifecycle (file) cannot archive.
+type StorageArchiveConfig struct {
+	Enable   bool   `mapstructure:"enable"`   // env: STORAGE_ARCHIVE_ENABLE
+	Interval string `mapstructure:"interval"` // how often the job runs; default 24h
+
+	IncidentsDays int  `mapstructure:"incidents_days"` // incident history, with its timeline
+	ResolvedOnly  bool `mapstructure:"resolved_only"`  // archive only resolved incidents, aged by resolution
+	AnalysesDays  int  `mapstructure:"analyses_days"`  // analyze-mode runs
+
+	S3 StorageArchiveS3Config `mapstructure:"s3"`
+}
+
+// StorageArchiveS3Config is the archive bucket. Endpoint and
+// ForcePathStyle point it at MinIO or another S3-compatible server; without
+// an access key the default AWS credential chain is used.
+type StorageArchiveS3Config struct {
+	Bucket          string `mapstructure:"bucket"` // env: STORAGE_ARCHIVE_S3_BUCKET
+	Prefix          string `mapstructure:"prefix"` // key prefix inside the bucket
+	Region          string `mapstructure:"region"`
+	Endpoint        string `mapstructure:"endpoint"`          // env: STORAGE_ARCHIVE_S3_ENDPOINT
+	AccessKeyID     string `mapstructure:"access_key_id"`     // env: STORAGE_ARCHIVE_S3_ACCESS_KEY_ID
+	SecretAccessKey string `mapstructure:"secret_access_key"` // env: STORAGE_ARCHIVE_S3_SECRET_ACCESS_KEY
+	ForcePathStyle  bool   `mapstructure:"force_path_style"`
+}
+
+// StorageEncryptionConfig turns on encryption at rest: blob data, incident
+// payloads and analysis output are sealed with AES-256-GCM inside whichever
+// backend is configured. Keys are `<id>:<base64 32-byte key>` entries, read
+// from KeyFile (one per line) and/or Keys (comma separated, usually from
+// the env). New writes use ActiveKey, default the last key listed; every
+// listed key still opens what it sealed, which is how keys rotate.
+type StorageEncryptionConfig struct {
+	Enable    bool   `mapstructure:"enable"`     // env: STORAGE_ENCRYPTION_ENABLE
+	KeyFile   string `mapstructure:"key_file"`   // env: STORAGE_ENCRYPTION_KEY_FILE
+	Keys      string `mapstructure:"keys"`       // env: STORAGE_ENCRYPTION_KEYS
+	ActiveKey string `mapstructure:"active_key"` // env: STORAGE_ENCRYPTION_ACTIVE_KEY
+}
+
+// StorageRetentionConfig drives the background retention job, which purges
+// records older than a per-domain maximum age through storage.Lifecycle.
+// A zero age keeps that domain forever. Backends without Lifecycle (file)
+// ignore it.
+type StorageRetentionConfig struct {
+	Enable   bool   `mapstructure:"enable"`   // env: STORAGE_RETENTION_ENABLE
+	Interval string `mapstructure:"interval"` // how often the job runs; default 24h
+
+	IncidentsDays int  `mapstructure:"incidents_days"` // incident history
+	ResolvedOnly  bool `mapstructure:"resolved_only"`  // purge only resolved incidents, aged by resolution
+	AnalysesDays  int  `mapstructure:"analyses_days"`  // analyze-mode runs
+	// BlobsDays ages every blob by its last write — including the agent's
+	// learned catalog and generate-once secrets that are written once and
+	// never touched again. Leave it 0 unless nothing long-lived is stored
+	// as a blob.
+	BlobsDays int `mapstructure:"blobs_days"`
 }
 
 // StoragePostgresConfig is the Postgres backend options.
@@ -71,21 +136,24 @@ type StorageFileConfig struct {
 	MaxIncidents int `mapstructure:"max_incidents"` // rolling cap; default 1000
 }
 
-// StorageRedisConfig is the redis backend's options. Stub today.
+// StorageRedisConfig is the redis backend's options. Only consulted when
+// storage.type == "redis". TLS and cluster mode follow the top-level redis
+// block (RedisConfig.TLSEnabled / ClusterEnabled).
 type StorageRedisConfig struct {
 	Host               string `mapstructure:"host"`
 	Port               int    `mapstructure:"port"`
 	Password           string `mapstructure:"password"`
 	DB                 int    `mapstructure:"db"`
 	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify"`
-	KeyPrefix          string `mapstructure:"key_prefix"`
-	MaxIncidents       int    `mapstructure:"max_incidents"`
+	KeyPrefix          string `mapstructure:"key_prefix"`    // default versus:storage
+	MaxIncidents       int    `mapstructure:"max_incidents"` // rolling cap; default 1000
 }
 
-// StorageDatabaseConfig is the database backend's options. Stub today.
+// StorageDatabaseConfig is the database backend's options. Only the sqlite
+// driver is implemented; its DSN is the database file path.
 type StorageDatabaseConfig struct {
-	Driver string `mapstructure:"driver"` // postgres | mysql | sqlite
-	DSN    string `mapstructure:"dsn"`
+	Driver string `mapstructure:"driver"` // sqlite (default)
+	DSN    string `mapstructure:"dsn"`    // file path; default data/versus.db (env: DATABASE_DSN)
 }
 
 type ProxyConfig struct {
@@ -170,6 +238,7 @@ type QueueConfig struct {
 	DebugBody bool         `mapstructure:"debug_body"`
 	SNS       SNSConfig    `mapstructure:"sns"`
 	SQS       SQSConfig    `mapstructure:"sqs"`
+	AMQP      AMQPConfig   `mapstructure:"amqp"`
 	PubSub    PubSubConfig `mapstructure:"pubsub"`
 	AzBus     AzBusConfig  `mapstructure:"azbus"`
 }
@@ -186,6 +255,32 @@ type SQSConfig struct {
 	QueueURL string `mapstructure:"queue_url"`
 }
 
+// AMQPConfig is the AMQP 0-9-1 (RabbitMQ) inbound queue. When Declare is set
+// the listener declares the queue (and, if Exchange is set, the exchange and
+// one binding per routing key) on every connect; leave it off to consume a
+// queue whose topology is owned by someone else.
+type AMQPConfig struct {
+	Enable       bool     `mapstructure:"enable"`
+	URL          string   `mapstructure:"url"` // amqp:// or amqps://
+	Queue        string   `mapstructure:"queue"`
+	Declare      bool     `mapstructure:"declare"`
+	Durable      bool     `mapstructure:"durable"`
+	Exchange     string   `mapstructure:"exchange"`
+	ExchangeType string   `mapstructure:"exchange_type"` // direct | fanout | topic | headers (default: topic)
+	RoutingKeys  []string `mapstructure:"routing_keys"`
+	Prefetch     int      `mapstructure:"prefetch"`
+
+	// DeadLetterExchange receives messages that can never become an
+	// incident (unparseable bodies). It is set as the queue's
+	// x-dead-letter-exchange argument when the listener declares the queue;
+	// with Declare off it must already be configured on the broker.
+	DeadLetterExchange   string `mapstructure:"dead_letter_exchange"`
+	DeadLetterRoutingKey string `mapstructure:"dead_letter_routing_key"`
+
+	ReconnectMinBackoff string `mapstructure:"reconnect_min_backoff"` // e.g. "1s"
+	ReconnectMaxBackoff string `mapstructure:"reconnect_max_backoff"` // e.g. "30s"
+}
+
 type PubSubConfig struct {
 	Enable bool `mapstructure:"enable"`
 }
@@ -291,6 +386,13 @@ func LoadConfig(path string) error {
 	return err
 }
 
+// Load reads one config file through the same pipeline as LoadConfig but
+// returns it instead of installing it as the process config. It serves
+// commands that need two configs at once, such as migrate-storage.
+func Load(path string) (*Config, error) {
+	return loadConfigFromPath(path)
+}
+
 // loadConfigFromPath performs the full config load+merge+unmarshal pipeline for
 // a single config file and returns the resulting *Config. It is the unguarded
 // core that LoadConfig wraps in a sync.Once; factoring it out lets tests
@@ -365,6 +467,7 @@ func loadConfigFromPath(path string) (*Config, error) {
 	setEnableFromEnv("LARK_ENABLE", &loaded.Alert.Lark.Enable)
 	setEnableFromEnv("LARK_USE_PROXY", &loaded.Alert.Lark.UseProxy)
 	setEnableFromEnv("SNS_ENABLE", &loaded.Queue.SNS.Enable)
+	setEnableFromEnv("AMQP_ENABLE", &loaded.Queue.AMQP.Enable)
 
 	setEnableFromEnv("ONCALL_ENABLE", &loaded.OnCall.Enable)
 
@@ -397,6 +500,30 @@ func loadConfigFromPath(path string) (*Config, error) {
 	if val := os.Getenv("POSTGRES_DSN"); val != "" {
 		loaded.Storage.Postgres.DSN = val
 	}
+	setEnableFromEnv("STORAGE_RETENTION_ENABLE", &loaded.Storage.Retention.Enable)
+	setEnableFromEnv("STORAGE_ENCRYPTION_ENABLE", &loaded.Storage.Encryption.Enable)
+	if val := os.Getenv("STORAGE_ENCRYPTION_KEY_FILE"); val != "" {
+		loaded.Storage.Encryption.KeyFile = val
+	}
+	if val := os.Getenv("STORAGE_ENCRYPTION_KEYS"); val != "" {
+		loaded.Storage.Encryption.Keys = val
+	}
+	if val := os.Getenv("STORAGE_ENCRYPTION_ACTIVE_KEY"); val != "" {
+		loaded.Storage.Encryption.ActiveKey = val
+	}
+	setEnableFromEnv("STORAGE_ARCHIVE_ENABLE", &loaded.Storage.Archive.Enable)
+	if val := os.Getenv("STORAGE_ARCHIVE_S3_BUCKET"); val != "" {
+		loaded.Storage.Archive.S3.Bucket = val
+	}
+	if val := os.Getenv("STORAGE_ARCHIVE_S3_ENDPOINT"); val != "" {
+		loaded.Storage.Archive.S3.Endpoint = val
+	}
+	if val := os.Getenv("STORAGE_ARCHIVE_S3_ACCESS_KEY_ID"); val != "" {
+		loaded.Storage.Archive.S3.AccessKeyID = val
+	}
+	if val := os.Getenv("STORAGE_ARCHIVE_S3_SECRET_ACCESS_KEY"); val != "" {
+		loaded.Storage.Archive.S3.SecretAccessKey = val
+	}
 
 	// Agent mode env overrides
 	setEnableFromEnv("AGENT_ENABLE", &loaded.Agent.Enable)
@@ -56,6 +56,10 @@ var chartKeysNotInAppConfig = map[string]string{
 	// so the postgres sub-block only appears once storage.type=postgres.
 	"storage.postgres.dsn": "real StoragePostgresConfig field; the baseline ships the file backend",
 
+	// Real StorageDatabaseConfig fields, rendered only for storage.type=database.
+	"storage.database.driver": "real StorageDatabaseConfig field; the baseline ships the file backend",
+	"storage.database.dsn":    "real StorageDatabaseConfig field; the baseline ships the file backend",
+
 	// Redis client tuning rendered for operator visibility. These have no
 	// field in RedisConfig and no env override in the loader — they are
 	// inert today and kept only for backward compatibility with existing
@@ -96,6 +100,7 @@ var chartScenarios = []chartScenario{
 		"--set", "storage.type=postgres",
 		"--set", "storage.postgres.dsn=postgres://versus:pass@pg:5432/versus?sslmode=require",
 	}},
+	{name: "database", args: []string{"-f", chartDir + "/tests/18-storage-sqlite.yaml"}},
 	{name: "ha", args: []string{"-f", chartDir + "/tests/05-ha.yaml"}},
 	{name: "tools-secrets", args: []string{"-f", chartDir + "/tests/14-tools-secrets.yaml"}},
 }
@@ -8,9 +8,14 @@ import (
 
 	"github.com/VersusControl/versus-incident/pkg/core"
 	"github.com/VersusControl/versus-incident/pkg/services"
+	"github.com/VersusControl/versus-incident/pkg/storage"
 	"github.com/gofiber/fiber/v2"
 )
 
+// ackLinkActor is the timeline actor for an ack made through the signed
+// link: the link proves possession, not identity, so no operator is named.
+const ackLinkActor = "ack-link"
+
 func HandleAck(c *fiber.Ctx) error {
 	incidentID := c.Params("incidentID")
 
@@ -39,6 +44,17 @@ func HandleAck(c *fiber.Ctx) error {
 	if store := services.Storage(); store != nil {
 		if err := store.UpdateIncidentAck(incidentID, time.Now().UTC()); err != nil {
 			log.Printf("ack: persist warning: %v", err)
+		} else if rec, err := store.GetIncident(incidentID); err != nil {
+			log.Printf("ack: timeline warning: %v", err)
+		} else {
+			services.RecordTimeline(&storage.TimelineEntry{
+				OrgID:      rec.OrgID,
+				IncidentID: rec.ID,
+				Kind:       storage.TimelineStatusChange,
+				Actor:      ackLinkActor,
+				Body:       "Acknowledged via ack link",
+				Data:       map[string]interface{}{"to": storage.StatusAcknowledged},
+			})
 		}
 	}
 
@@ -8,6 +8,7 @@ import (
 
 	"github.com/VersusControl/versus-incident/pkg/core"
 	"github.com/VersusControl/versus-incident/pkg/services"
+	"github.com/VersusControl/versus-incident/pkg/storage"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -150,3 +151,33 @@ func TestHandleAck_TamperedTokenRejected(t *testing.T) {
 		t.Fatalf("expected %d for a tampered token, got %d", fiber.StatusUnauthorized, resp.StatusCode)
 	}
 }
+
+// TestHandleAck_TimelineEntryKeepsOrg checks the ack-link timeline entry is
+// attributed to the incident's organization, not the default one, so it
+// shows on that organization's timeline.
+func TestHandleAck_TimelineEntryKeepsOrg(t *testing.T) {
+	installAckKey(t)
+	pending := &pendingEscalations{keys: map[string]bool{"i-org": true}}
+	core.SetOnCallWorkflow(core.NewOnCallWorkflow(pending, nil))
+	t.Cleanup(func() { core.SetOnCallWorkflow(nil) })
+	st := storage.NewMemory()
+	services.SetStorage(st)
+	t.Cleanup(func() { services.SetStorage(nil) })
+	if err := st.SaveIncident(&storage.IncidentRecord{ID: "i-org", OrgID: "acme", CreatedAt: time.Now().UTC()}); err != nil {
+		t.Fatalf("SaveIncident: %v", err)
+	}
+
+	resp, err := ackTestApp().Test(httptest.NewRequest("GET", signedAckPath("i-org", time.Hour), nil))
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != fiber.StatusCreated {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, fiber.StatusCreated)
+	}
+
+	entries, _ := st.(storage.Timeline).ListTimeline("i-org", 0)
+	if len(entries) != 1 || entries[0].OrgID != "acme" {
+		t.Fatalf("timeline = %+v, want one entry in org acme", entries)
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/VersusControl/versus-incident/pkg/core"
 	"github.com/VersusControl/versus-incident/pkg/middleware"
 	"github.com/VersusControl/versus-incident/pkg/services"
+	"github.com/VersusControl/versus-incident/pkg/signalsources"
 	"github.com/VersusControl/versus-incident/pkg/storage"
 
 	"github.com/gofiber/fiber/v2"
@@ -141,7 +142,8 @@ func (a *AgentController) SetCatalogConfig(cat config.AgentCatalogConfig, poll t
 //	POST   /patterns/:id     update verdict / tags
 //	DELETE /patterns/:id     remove a pattern
 //	DELETE /patterns         wipe ALL learned log patterns (relearn fresh)
-//	GET    /status           lightweight status (catalog size, dirty flag)
+//	GET    /status           lightweight status (catalog size, dirty flag,
+//	                          http source ingest counts)
 //	GET    /shadow           list shadow-mode "would have alerted" events
 //	GET    /shadow/stats     aggregate counts for the shadow log
 //	DELETE /shadow           clear the shadow log
@@ -229,9 +231,29 @@ func (a *AgentController) getStatus(c *fiber.Ctx) error {
 		status["detect_events"] = a.detect.Len()
 		status["detect_dirty"] = a.detect.Dirty()
 	}
+	if ingest := a.ingestStats(); len(ingest) > 0 {
+		status["ingest"] = ingest
+	}
 	return c.JSON(status)
 }
 
+// ingestStats reports the accepted and dropped counts of every wired `http`
+// push source, keyed by source name.
+func (a *AgentController) ingestStats() map[string]signalsources.IngestStats {
+	var out map[string]signalsources.IngestStats
+	for _, src := range a.sources {
+		hs, ok := src.(*signalsources.HTTPIngestSource)
+		if !ok {
+			continue
+		}
+		if out == nil {
+			out = make(map[string]signalsources.IngestStats)
+		}
+		out[hs.Name()] = hs.Stats()
+	}
+	return out
+}
+
 func (a *AgentController) listPatterns(c *fiber.Ctx) error {
 	size := parsePatternPageSize(c.Query("page_size"))
 	offset, page := pageOffset(c.Query("page"), c.Query("offset"), size)
@@ -771,9 +793,9 @@ func (a *AgentController) serviceIncidentSummary(name, org string) fiber.Map {
 	return summary
 }
 
-// incidentSeverity reads the best-effort severity carried in the alert
-// payload through the shared extraction and collapses it to one of the fixed
-// report bands. Banding keeps the histogram to that closed set: the raw label
+// incidentSeverity reads the record's severity (an operator edit, else the
+// best-effort severity carried in the alert payload) and collapses it to one
+// of the fixed report bands. Banding keeps the histogram to that closed set: the raw label
 // is payload-supplied, so keying the response on it would both fragment the
 // counts the report shows and let a stream of distinct labels inflate the
 // response with unbounded keys.
@@ -781,7 +803,7 @@ func incidentSeverity(rec *storage.IncidentRecord) string {
 	if rec == nil {
 		return "unknown"
 	}
-	return services.SeverityBand(services.ExtractSeverity(rec.Content))
+	return services.SeverityBand(rec.SeverityLabel())
 }
 
 type serviceGraceRequest struct {
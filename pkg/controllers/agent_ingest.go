@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"strconv"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/VersusControl/versus-incident/pkg/core"
+	"github.com/VersusControl/versus-incident/pkg/middleware"
+	"github.com/VersusControl/versus-incident/pkg/signalsources"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// IngestController is the push endpoint of the agent's `http` sources. Senders
+// authenticate with the source's own auth_token (`Authorization: Bearer`) or
+// with the gateway secret (`X-Gateway-Secret`), so a batch job can be handed a
+// token that opens its own source and nothing else.
+type IngestController struct {
+	sources map[string]*signalsources.HTTPIngestSource
+}
+
+// NewIngestController indexes the `http` sources among sources by their
+// configured name. The others are ignored.
+func NewIngestController(sources []core.SignalSource) *IngestController {
+	ic := &IngestController{sources: make(map[string]*signalsources.HTTPIngestSource)}
+	for _, src := range sources {
+		if hs, ok := src.(*signalsources.HTTPIngestSource); ok {
+			ic.sources[hs.IngestName()] = hs
+		}
+	}
+	return ic
+}
+
+// Register attaches the endpoint under /api/agent.
+//
+//	POST /api/agent/ingest/:source   queue NDJSON or a JSON array of records
+//
+// It must be registered BEFORE AgentController: that controller's group
+// installs the gateway-secret middleware on every /api/agent path, and a token
+// sender would never get past it.
+func (ic *IngestController) Register(router fiber.Router) {
+	router.Post("/agent/ingest/:source", ic.ingest)
+}
+
+func (ic *IngestController) ingest(c *fiber.Ctx) error {
+	src := ic.sources[c.Params("source")]
+	// An unknown source and a wrong credential answer alike, so the endpoint
+	// does not reveal which source names exist.
+	if !ic.authorized(c, src) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+	if src == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "no http source with that name"})
+	}
+
+	res, err := src.Ingest(c.Body())
+	switch {
+	case err == nil:
+		return c.Status(fiber.StatusAccepted).JSON(res)
+	case errors.Is(err, signalsources.ErrIngestTooLarge), errors.Is(err, signalsources.ErrIngestOverBurst):
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, signalsources.ErrIngestRateLimited), errors.Is(err, signalsources.ErrIngestQueueFull):
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(signalsources.HTTPIngestRetryAfter.Seconds())))
+		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, signalsources.ErrIngestMalformed):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error(), "invalid": res.Invalid})
+	case errors.Is(err, signalsources.ErrIngestNotReady):
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": err.Error()})
+	}
+	log.Printf("agent: %s: ingest: %v", src.Name(), err)
+	return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "queue unavailable"})
+}
+
+// authorized accepts the source's auth_token, the gateway secret, or a
+// request an enterprise auth handler already authenticated.
+func (ic *IngestController) authorized(c *fiber.Ctx, src *signalsources.HTTPIngestSource) bool {
+	if src != nil && src.Authorized(c.Get(fiber.HeaderAuthorization)) {
+		return true
+	}
+	if middleware.RequestAuthorized(c) {
+		return true
+	}
+	expected := config.GetConfig().GatewaySecret
+	return expected != "" && secureEqual(c.Get("X-Gateway-Secret"), expected)
+}
@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/VersusControl/versus-incident/pkg/agent"
+	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/VersusControl/versus-incident/pkg/core"
+	"github.com/VersusControl/versus-incident/pkg/signalsources"
+	"github.com/VersusControl/versus-incident/pkg/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// TestIngest_AuthLimitsAndStatus drives POST /api/agent/ingest/:source through
+// the real route table, mounted the way main does — ahead of the agent admin
+// group — so a sender holding only its source token gets past the
+// gateway-secret middleware, and its accepted and dropped counts show up in
+// GET /api/agent/status.
+func TestIngest_AuthLimitsAndStatus(t *testing.T) {
+	agent.SetCatalogStore(nil)
+	const secret = "test-gateway-secret"
+	loadGatewayConfig(t, secret)
+	prevSecret := config.GetConfig().GatewaySecret
+	config.GetConfig().GatewaySecret = secret
+	t.Cleanup(func() { config.GetConfig().GatewaySecret = prevSecret })
+
+	src, err := signalsources.NewHTTPIngestSource("jobs", config.AgentHTTPSourceConfig{AuthToken: "job-token", RateLimit: 2}, 0)
+	if err != nil {
+		t.Fatalf("new source: %v", err)
+	}
+	if err := src.Listen(context.Background()); err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	sources := []core.SignalSource{src}
+
+	cat, err := agent.LoadCatalog(storage.NewMemory())
+	if err != nil {
+		t.Fatalf("LoadCatalog: %v", err)
+	}
+	app := fiber.New()
+	api := app.Group("/api")
+	NewIngestController(sources).Register(api)
+	NewAgentController(cat, nil, nil, nil, nil, false).SetSources(sources).Register(api)
+
+	// post returns the status code and the Retry-After header.
+	post := func(path, header, value, body string) (int, string) {
+		req := httptest.NewRequest("POST", path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-ndjson")
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("app.Test %s: %v", path, err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode, resp.Header.Get(fiber.HeaderRetryAfter)
+	}
+
+	if code, _ := post("/api/agent/ingest/jobs", fiber.HeaderAuthorization, "Bearer job-token", `{"message":"a"}`); code != fiber.StatusAccepted {
+		t.Fatalf("source token: code = %d, want 202", code)
+	}
+	if code, _ := post("/api/agent/ingest/jobs", "X-Gateway-Secret", secret, `{"message":"b"}`); code != fiber.StatusAccepted {
+		t.Fatalf("gateway secret: code = %d, want 202", code)
+	}
+	if code, _ := post("/api/agent/ingest/jobs", fiber.HeaderAuthorization, "Bearer wrong", `{"message":"c"}`); code != fiber.StatusUnauthorized {
+		t.Fatalf("wrong token: code = %d, want 401", code)
+	}
+	// The source token opens its own source only, and an unknown name looks
+	// the same as a wrong credential.
+	if code, _ := post("/api/agent/ingest/other", fiber.HeaderAuthorization, "Bearer job-token", `{"message":"d"}`); code != fiber.StatusUnauthorized {
+		t.Fatalf("unknown source with a source token: code = %d, want 401", code)
+	}
+	if code, _ := post("/api/agent/ingest/other", "X-Gateway-Secret", secret, `{"message":"d"}`); code != fiber.StatusNotFound {
+		t.Fatalf("unknown source with the gateway secret: code = %d, want 404", code)
+	}
+
+	// The two records above spent the burst of 2.
+	code, retryAfter := post("/api/agent/ingest/jobs", fiber.HeaderAuthorization, "Bearer job-token", `{"message":"e"}`)
+	if code != fiber.StatusTooManyRequests || retryAfter != "10" {
+		t.Fatalf("rate limited: code = %d, Retry-After = %q", code, retryAfter)
+	}
+
+	req := httptest.NewRequest("GET", "/api/agent/status", nil)
+	req.Header.Set("X-Gateway-Secret", secret)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	defer resp.Body.Close()
+	var status struct {
+		Ingest map[string]signalsources.IngestStats `json:"ingest"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		t.Fatalf("decode status: %v", err)
+	}
+	got := status.Ingest["http:jobs"]
+	if got.Accepted != 2 || got.Dropped.RateLimited != 1 {
+		t.Fatalf("status ingest = %+v", status.Ingest)
+	}
+}
@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/VersusControl/versus-incident/pkg/middleware"
+	"github.com/VersusControl/versus-incident/pkg/services"
+	"github.com/VersusControl/versus-incident/pkg/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxAnalyticsRange bounds an explicit from/to range so one query cannot
+// aggregate an unbounded history.
+const maxAnalyticsRange = 366 * 24 * time.Hour
+
+// defaultAnalyticsWindow is the window used when neither window nor from/to
+// is given.
+const defaultAnalyticsWindow = "7d"
+
+// analyticsGroups is the order /overview returns its groupings in.
+var analyticsGroups = []string{
+	storage.GroupByService,
+	storage.GroupByTeam,
+	storage.GroupBySource,
+	storage.GroupByOrigin,
+	storage.GroupBySeverity,
+}
+
+// AnalyticsAdminController exposes response-time analytics (MTTA, MTTR,
+// escalation rate) over the incident history. Same X-Gateway-Secret guard as
+// the rest of the admin surface.
+type AnalyticsAdminController struct{}
+
+// NewAnalyticsAdminController returns a controller. No state of its own;
+// storage is read via services.Storage().
+func NewAnalyticsAdminController() *AnalyticsAdminController {
+	return &AnalyticsAdminController{}
+}
+
+// Register attaches the endpoints under /api/admin/analytics. Both take the
+// range as ?window=today|24h|7d (default 7d) or as ?from=&to= (RFC 3339,
+// to optional), which wins over window.
+//
+//	GET /api/admin/analytics/response  overall stats, plus groups with ?group_by=service|team|source|origin|severity
+//	GET /api/admin/analytics/overview  overall stats and every grouping at once
+func (ac *AnalyticsAdminController) Register(router fiber.Router) {
+	g := router.Group("/admin/analytics", ac.authMiddleware)
+	g.Get("/response", ac.response)
+	g.Get("/overview", ac.overview)
+}
+
+// authMiddleware reuses the agent gateway secret (constant-time compare),
+// mirroring the incident admin surface.
+func (ac *AnalyticsAdminController) authMiddleware(c *fiber.Ctx) error {
+	if middleware.RequestAuthorized(c) {
+		return c.Next()
+	}
+	cfg := config.GetConfig()
+	expected := cfg.GatewaySecret
+	got := c.Get("X-Gateway-Secret")
+	if expected == "" || !secureEqual(got, expected) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+	return c.Next()
+}
+
+func (ac *AnalyticsAdminController) response(c *fiber.Ctx) error {
+	start, end, err := analyticsRange(c, time.Now().UTC())
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	groupBy := strings.TrimSpace(c.Query("group_by"))
+	if !storage.IsResponseGroupBy(groupBy) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid group_by (want service|team|source|origin|severity)"})
+	}
+	out, err := services.ResponseAnalyticsFor(services.Storage(), start, end, groupBy)
+	if err != nil {
+		return analyticsError(c, err)
+	}
+	return c.JSON(out)
+}
+
+func (ac *AnalyticsAdminController) overview(c *fiber.Ctx) error {
+	start, end, err := analyticsRange(c, time.Now().UTC())
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	out, err := services.ResponseOverviewFor(services.Storage(), start, end, analyticsGroups)
+	if err != nil {
+		return analyticsError(c, err)
+	}
+	return c.JSON(out)
+}
+
+func analyticsError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, services.ErrAnalyticsNoStorage) {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "storage not configured"})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
+
+// analyticsRange resolves the query's time range: from/to when from is set,
+// else the window (default 7d) ending now.
+func analyticsRange(c *fiber.Ctx, now time.Time) (start, end time.Time, err error) {
+	from := strings.TrimSpace(c.Query("from"))
+	if from == "" {
+		window, ok := validReportWindow(c.Query("window"))
+		if !ok {
+			return start, end, errors.New("invalid window (want today|24h|7d)")
+		}
+		if window == "" {
+			window = defaultAnalyticsWindow
+		}
+		start, end, _ = services.WindowBounds(window, now)
+		return start, end, nil
+	}
+	if start, err = time.Parse(time.RFC3339, from); err != nil {
+		return start, end, errors.New("from must be an RFC 3339 timestamp")
+	}
+	end = now
+	if to := strings.TrimSpace(c.Query("to")); to != "" {
+		if end, err = time.Parse(time.RFC3339, to); err != nil {
+			return start, end, errors.New("to must be an RFC 3339 timestamp")
+		}
+	}
+	start, end = start.UTC(), end.UTC()
+	if !end.After(start) {
+		return start, end, errors.New("to must be after from")
+	}
+	if end.Sub(start) > maxAnalyticsRange {
+		return start, end, errors.New("range must not exceed 366 days")
+	}
+	return start, end, nil
+}
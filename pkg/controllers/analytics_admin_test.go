@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/VersusControl/versus-incident/pkg/services"
+	"github.com/VersusControl/versus-incident/pkg/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func newAnalyticsApp(t *testing.T) *fiber.App {
+	t.Helper()
+	loadGatewayConfig(t, timelineSecret)
+	config.GetConfig().GatewaySecret = timelineSecret
+	st := storage.NewMemory()
+	services.SetStorage(st)
+	t.Cleanup(func() { services.SetStorage(nil) })
+
+	now := time.Now().UTC()
+	acked := now.Add(-50 * time.Minute)
+	resolved := now.Add(-30 * time.Minute)
+	for _, r := range []*storage.IncidentRecord{
+		{ID: "a", Service: "api", Source: "webhook", CreatedAt: now.Add(-time.Hour), AckedAt: &acked, Resolved: true, ResolvedAt: &resolved, OnCallTriggered: true},
+		{ID: "b", Service: "db", Source: "agent:detect", CreatedAt: now.Add(-2 * time.Hour)},
+	} {
+		if err := st.SaveIncident(r); err != nil {
+			t.Fatalf("SaveIncident: %v", err)
+		}
+	}
+
+	app := fiber.New()
+	NewAnalyticsAdminController().Register(app.Group("/api"))
+	return app
+}
+
+func analyticsGet(t *testing.T, app *fiber.App, path string, withSecret bool) (int, []byte) {
+	t.Helper()
+	req := httptest.NewRequest("GET", path, nil)
+	if withSecret {
+		req.Header.Set("X-Gateway-Secret", timelineSecret)
+	}
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("GET %s: %v", path, err)
+	}
+	defer resp.Body.Close()
+	out, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, out
+}
+
+func TestAnalytics_ResponseGroupedBySource(t *testing.T) {
+	app := newAnalyticsApp(t)
+
+	status, body := analyticsGet(t, app, "/api/admin/analytics/response?window=24h&group_by=origin", true)
+	if status != fiber.StatusOK {
+		t.Fatalf("status = %d, body %s", status, body)
+	}
+	var out services.ResponseAnalytics
+	if err := json.Unmarshal(body, &out); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if out.Overall.Incidents != 2 || out.Overall.MTTA.P50 != 600 || out.Overall.MTTR.P50 != 1800 || out.Overall.EscalationRate != 0.5 {
+		t.Fatalf("overall = %+v", out.Overall)
+	}
+	if len(out.Groups) != 2 || out.Groups[0].Key != storage.OriginAIDetect || out.Groups[1].Key != storage.OriginWebhook {
+		t.Fatalf("groups = %+v", out.Groups)
+	}
+}
+
+func TestAnalytics_OverviewAndValidation(t *testing.T) {
+	app := newAnalyticsApp(t)
+
+	status, body := analyticsGet(t, app, "/api/admin/analytics/overview", true)
+	var out struct {
+		Groups map[string][]storage.ResponseStats `json:"groups"`
+	}
+	_ = json.Unmarshal(body, &out)
+	if status != fiber.StatusOK || len(out.Groups) != 5 || len(out.Groups[storage.GroupByService]) != 2 {
+		t.Fatalf("overview: status %d body %s", status, body)
+	}
+
+	for _, q := range []string{
+		"?group_by=color",
+		"?window=30d",
+		"?from=yesterday",
+		"?from=2026-10-02T00:00:00Z&to=2026-10-01T00:00:00Z",
+		"?from=2020-01-01T00:00:00Z&to=2026-01-01T00:00:00Z",
+	} {
+		if status, _ := analyticsGet(t, app, "/api/admin/analytics/response"+q, true); status != fiber.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", q, status)
+		}
+	}
+	if status, _ := analyticsGet(t, app, "/api/admin/analytics/response", false); status != fiber.StatusUnauthorized {
+		t.Errorf("no secret: status = %d, want 401", status)
+	}
+}
@@ -0,0 +1,226 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/archive"
+	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/VersusControl/versus-incident/pkg/middleware"
+	"github.com/VersusControl/versus-incident/pkg/services"
+	"github.com/VersusControl/versus-incident/pkg/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ArchiveAdminController exposes the incident archive (storage.archive):
+// search over archived incidents and analyses, single-record reads, and a
+// dry run of the next archive run. Same X-Gateway-Secret guard as the rest
+// of the admin surface.
+type ArchiveAdminController struct{}
+
+// NewArchiveAdminController returns a controller. No state of its own; the
+// archive is read via services.Archive().
+func NewArchiveAdminController() *ArchiveAdminController {
+	return &ArchiveAdminController{}
+}
+
+// Default and maximum number of search results, and the default day range
+// when the request names none.
+const (
+	defaultArchiveSearchLimit = 100
+	maxArchiveSearchLimit     = 1000
+	defaultArchiveSearchDays  = 30
+)
+
+// Register attaches the endpoints under /api/admin/archive.
+//
+//	GET /api/admin/archive/search          search (?q=&kind=incidents|analyses&from=&to=&limit=NN)
+//	GET /api/admin/archive/incidents/:id   one archived incident, with its timeline
+//	GET /api/admin/archive/analyses/:id    one archived analysis
+//	GET /api/admin/archive/preview         would-move counts for the configured policy
+//
+// from and to are UTC days (YYYY-MM-DD), inclusive; to defaults to today
+// and from to 30 days before to.
+func (ac *ArchiveAdminController) Register(router fiber.Router) {
+	g := router.Group("/admin/archive", ac.authMiddleware)
+	g.Get("/search", ac.search)
+	g.Get("/incidents/:id", ac.getIncident)
+	g.Get("/analyses/:id", ac.getAnalysis)
+	g.Get("/preview", ac.preview)
+}
+
+// authMiddleware reuses the agent gateway secret (constant-time compare),
+// mirroring the incident admin surface.
+func (ac *ArchiveAdminController) authMiddleware(c *fiber.Ctx) error {
+	if middleware.RequestAuthorized(c) {
+		return c.Next()
+	}
+	cfg := config.GetConfig()
+	expected := cfg.GatewaySecret
+	got := c.Get("X-Gateway-Secret")
+	if expected == "" || !secureEqual(got, expected) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+	return c.Next()
+}
+
+// archiveDisabled is the response for every endpoint while no archive is
+// configured.
+func archiveDisabled(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusNotImplemented).JSON(fiber.Map{"error": "archive not enabled (storage.archive.enable)"})
+}
+
+func (ac *ArchiveAdminController) search(c *fiber.Ctx) error {
+	arc := services.Archive()
+	if arc == nil {
+		return archiveDisabled(c)
+	}
+	from, to, err := archiveSearchRange(c.Query("from"), c.Query("to"), time.Now().UTC())
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	limit := defaultArchiveSearchLimit
+	if raw := strings.TrimSpace(c.Query("limit")); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid limit"})
+		}
+		limit = min(n, maxArchiveSearchLimit)
+	}
+	query := c.Query("q")
+	resp := fiber.Map{"from": from.Format(time.DateOnly), "to": to.Format(time.DateOnly)}
+
+	switch kind := c.Query("kind", archive.KindIncidents); kind {
+	case archive.KindIncidents:
+		recs, err := arc.SearchIncidents(c.UserContext(), query, from, to, limit)
+		if err != nil {
+			return archiveError(c, err)
+		}
+		out := make([]fiber.Map, len(recs))
+		for i, rec := range recs {
+			out[i] = summarize(&rec.IncidentRecord)
+			out[i]["archived_at"] = rec.ArchivedAt
+		}
+		resp["kind"], resp["incidents"] = kind, out
+	case archive.KindAnalyses:
+		recs, err := arc.SearchAnalyses(c.UserContext(), query, from, to, limit)
+		if err != nil {
+			return archiveError(c, err)
+		}
+		resp["kind"], resp["analyses"] = kind, recs
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "kind must be incidents or analyses"})
+	}
+	return c.JSON(resp)
+}
+
+// archiveSearchRange parses the from/to days, defaulting to the 30 days up
+// to today.
+func archiveSearchRange(rawFrom, rawTo string, now time.Time) (from, to time.Time, err error) {
+	to = now
+	if rawTo = strings.TrimSpace(rawTo); rawTo != "" {
+		if to, err = time.Parse(time.DateOnly, rawTo); err != nil {
+			return from, to, errors.New("invalid to (want YYYY-MM-DD)")
+		}
+	}
+	from = to.AddDate(0, 0, -defaultArchiveSearchDays)
+	if rawFrom = strings.TrimSpace(rawFrom); rawFrom != "" {
+		if from, err = time.Parse(time.DateOnly, rawFrom); err != nil {
+			return from, to, errors.New("invalid from (want YYYY-MM-DD)")
+		}
+	}
+	return from, to, nil
+}
+
+// archiveError maps an archive error to a response.
+func archiveError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, archive.ErrNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "not found"})
+	case errors.Is(err, archive.ErrRange):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{"error": err.Error()})
+}
+
+func (ac *ArchiveAdminController) getIncident(c *fiber.Ctx) error {
+	arc := services.Archive()
+	if arc == nil {
+		return archiveDisabled(c)
+	}
+	rec, err := arc.GetIncident(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return archiveError(c, err)
+	}
+	return c.JSON(rec)
+}
+
+func (ac *ArchiveAdminController) getAnalysis(c *fiber.Ctx) error {
+	arc := services.Archive()
+	if arc == nil {
+		return archiveDisabled(c)
+	}
+	rec, err := arc.GetAnalysis(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return archiveError(c, err)
+	}
+	return c.JSON(rec)
+}
+
+func (ac *ArchiveAdminController) preview(c *fiber.Ctx) error {
+	store := services.Storage()
+	if store == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "storage not configured"})
+	}
+	cfg := config.GetConfig().Storage.Archive
+	policy := services.ArchivePolicyFrom(cfg)
+	now := time.Now().UTC()
+	_, supported := store.(storage.Lifecycle)
+	return c.JSON(fiber.Map{
+		"dry_run":       true,
+		"enabled":       cfg.Enable && services.Archive() != nil,
+		"supported":     supported,
+		"interval":      services.ArchiveInterval(cfg).String(),
+		"resolved_only": cfg.ResolvedOnly,
+		"as_of":         now,
+		"domains":       services.PreviewArchive(c.UserContext(), store, policy, now),
+	})
+}
+
+// archivedIncident is the read-through view of an incident that only the
+// archive holds: the record as archived, flagged so the UI can show it
+// read-only.
+type archivedIncident struct {
+	storage.IncidentRecord
+	Archived   bool      `json:"archived"`
+	ArchivedAt time.Time `json:"archived_at"`
+}
+
+// readArchivedIncident is the read-through fallback for an incident the
+// primary store does not hold. It returns archive.ErrNotFound when no
+// archive is configured or the archive does not hold id either.
+func readArchivedIncident(c *fiber.Ctx, id string) (*archive.Incident, error) {
+	arc := services.Archive()
+	if arc == nil {
+		return nil, archive.ErrNotFound
+	}
+	return arc.GetIncident(c.UserContext(), id)
+}
+
+// archivedTimelineWindow keeps the newest limit entries of an archived
+// timeline, as ListTimeline does for a live one.
+func archivedTimelineWindow(entries []*storage.TimelineEntry, limit int) []*storage.TimelineEntry {
+	if limit <= 0 {
+		limit = storage.DefaultTimelineLimit
+	}
+	if over := len(entries) - limit; over > 0 {
+		entries = entries[over:]
+	}
+	if entries == nil {
+		entries = []*storage.TimelineEntry{}
+	}
+	return entries
+}
@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/archive"
+	"github.com/VersusControl/versus-incident/pkg/services"
+	"github.com/VersusControl/versus-incident/pkg/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// TestArchive_ReadThroughAndSearch opens an archived incident through the
+// regular incident endpoints and finds it with the archive search.
+func TestArchive_ReadThroughAndSearch(t *testing.T) {
+	app := newAnalyticsApp(t)
+	NewIncidentAdminController().Register(app.Group("/api"))
+	NewArchiveAdminController().Register(app.Group("/api"))
+
+	if status, _ := analyticsGet(t, app, "/api/admin/archive/search?q=disk", true); status != fiber.StatusNotImplemented {
+		t.Fatalf("search without archive: status = %d, want 501", status)
+	}
+
+	arc := archive.New(archive.NewMemoryStore(), "")
+	services.SetArchive(arc)
+	t.Cleanup(func() { services.SetArchive(nil) })
+	created := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	if err := arc.WriteIncidents(context.Background(), []*archive.Incident{{
+		IncidentRecord: storage.IncidentRecord{ID: "cold", Title: "disk full", Service: "db", CreatedAt: created},
+		Timeline:       []*storage.TimelineEntry{{ID: "tl-1", IncidentID: "cold", Kind: storage.TimelineNote, Body: "archived note"}},
+	}}); err != nil {
+		t.Fatalf("WriteIncidents: %v", err)
+	}
+
+	status, body := analyticsGet(t, app, "/api/admin/incidents/cold", true)
+	var got struct {
+		ID       string `json:"id"`
+		Archived bool   `json:"archived"`
+	}
+	_ = json.Unmarshal(body, &got)
+	if status != fiber.StatusOK || got.ID != "cold" || !got.Archived {
+		t.Fatalf("read-through = %d %s", status, body)
+	}
+	// A live incident is served from the primary store, unflagged.
+	_, body = analyticsGet(t, app, "/api/admin/incidents/a", true)
+	got = struct {
+		ID       string `json:"id"`
+		Archived bool   `json:"archived"`
+	}{}
+	_ = json.Unmarshal(body, &got)
+	if got.ID != "a" || got.Archived {
+		t.Fatalf("live incident = %s", body)
+	}
+	if status, _ := analyticsGet(t, app, "/api/admin/incidents/missing", true); status != fiber.StatusNotFound {
+		t.Fatalf("missing: status = %d, want 404", status)
+	}
+
+	status, body = analyticsGet(t, app, "/api/admin/incidents/cold/timeline", true)
+	var tl struct {
+		Entries []storage.TimelineEntry `json:"entries"`
+	}
+	_ = json.Unmarshal(body, &tl)
+	if status != fiber.StatusOK || len(tl.Entries) != 1 || tl.Entries[0].Body != "archived note" {
+		t.Fatalf("archived timeline = %d %s", status, body)
+	}
+
+	status, body = analyticsGet(t, app, "/api/admin/archive/search?q=disk&from=2024-02-28&to=2024-03-02", true)
+	var found struct {
+		Incidents []struct {
+			ID string `json:"id"`
+		} `json:"incidents"`
+	}
+	_ = json.Unmarshal(body, &found)
+	if status != fiber.StatusOK || len(found.Incidents) != 1 || found.Incidents[0].ID != "cold" {
+		t.Fatalf("search = %d %s", status, body)
+	}
+	for _, q := range []string{"?from=2024-13-01", "?kind=blobs", "?limit=0", "?from=2020-01-01&to=2024-01-01"} {
+		if status, _ := analyticsGet(t, app, "/api/admin/archive/search"+q, true); status != fiber.StatusBadRequest {
+			t.Fatalf("%s: status = %d, want 400", q, status)
+		}
+	}
+	if status, _ := analyticsGet(t, app, "/api/admin/archive/incidents/cold", false); status != fiber.StatusUnauthorized {
+		t.Fatalf("no secret: status = %d, want 401", status)
+	}
+}
@@ -0,0 +1,163 @@
+package controllers
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/VersusControl/versus-incident/pkg/middleware"
+	"github.com/VersusControl/versus-incident/pkg/services"
+	"github.com/VersusControl/versus-incident/pkg/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Admin-audit actions for backup and restore; the target names the archive.
+const (
+	auditActionBackup  = "storage.backup"
+	auditActionRestore = "storage.restore"
+)
+
+// backupPassphraseHeader carries the archive passphrase, kept out of the URL
+// so it does not land in access logs.
+const backupPassphraseHeader = "X-Backup-Passphrase"
+
+// BackupAdminController snapshots and restores everything Versus stores.
+// Same X-Gateway-Secret guard as the rest of the admin surface.
+type BackupAdminController struct {
+	// dir holds the archives POST /restore may read.
+	dir string
+}
+
+// NewBackupAdminController returns a controller that restores from
+// <data dir>/backups.
+func NewBackupAdminController() *BackupAdminController {
+	return &BackupAdminController{dir: filepath.Join(storage.DefaultDataDir, "backups")}
+}
+
+// Register attaches the endpoints under /api/admin.
+//
+//	GET  /api/admin/backup   stream a backup archive (X-Backup-Passphrase encrypts it;
+//	                         ?include_secrets=true keeps secrets in an unencrypted one)
+//	POST /api/admin/restore  restore {"file": "<name>", "force": false} from the backups directory
+//
+// A restore reads an archive already on the server rather than an upload, so
+// its size is not bounded by the request body limit.
+func (bc *BackupAdminController) Register(router fiber.Router) {
+	g := router.Group("/admin", bc.authMiddleware)
+	g.Get("/backup", bc.backup)
+	g.Post("/restore", bc.restore)
+}
+
+// authMiddleware reuses the agent gateway secret (constant-time compare),
+// mirroring the incident admin surface.
+func (bc *BackupAdminController) authMiddleware(c *fiber.Ctx) error {
+	if middleware.RequestAuthorized(c) {
+		return c.Next()
+	}
+	cfg := config.GetConfig()
+	expected := cfg.GatewaySecret
+	got := c.Get("X-Gateway-Secret")
+	if expected == "" || !secureEqual(got, expected) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+	return c.Next()
+}
+
+// backup streams the archive as it is built, so an error mid-stream can only
+// be logged; the archive then lacks end.json and a restore rejects it.
+func (bc *BackupAdminController) backup(c *fiber.Ctx) error {
+	st := services.Storage()
+	if st == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "storage not configured"})
+	}
+	passphrase := c.Get(backupPassphraseHeader)
+	cfg := config.GetConfig()
+	opts := services.BackupOptions{
+		Passphrase:     passphrase,
+		Backend:        cfg.Storage.Type,
+		SealedAtRest:   cfg.Storage.Encryption.Enable,
+		IncludeSecrets: c.QueryBool("include_secrets"),
+	}
+	if err := services.CheckBackup(opts); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	name := fmt.Sprintf("versus-%s%s", time.Now().UTC().Format("20060102T150405Z"), services.BackupFileExt)
+	if passphrase != "" {
+		name += ".enc"
+	}
+
+	middleware.RecordAdminAudit(c, auditActionBackup, name, middleware.AdminAuditSuccess)
+	c.Set(fiber.HeaderContentType, services.BackupContentType)
+	if passphrase != "" {
+		c.Set(fiber.HeaderContentType, fiber.MIMEOctetStream)
+	}
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, name))
+	ctx := c.UserContext()
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if _, err := services.WriteBackup(ctx, st, w, opts); err != nil {
+			log.Printf("backup: %s stopped: %v", name, err)
+		}
+		_ = w.Flush()
+	})
+	return nil
+}
+
+type restoreRequest struct {
+	File  string `json:"file"`
+	Force bool   `json:"force"`
+}
+
+func (bc *BackupAdminController) restore(c *fiber.Ctx) error {
+	st := services.Storage()
+	if st == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "storage not configured"})
+	}
+	var body restoreRequest
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid JSON body"})
+	}
+	name := strings.TrimSpace(body.File)
+	if name == "" || name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "file must be the name of an archive in the backups directory"})
+	}
+	f, err := os.Open(filepath.Join(bc.dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "no such archive in the backups directory"})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	defer f.Close()
+
+	manifest, err := services.RestoreBackup(c.UserContext(), st, f, services.RestoreOptions{
+		Passphrase: c.Get(backupPassphraseHeader),
+		Force:      body.Force,
+	})
+	if err != nil {
+		status := fiber.StatusInternalServerError
+		switch {
+		case errors.Is(err, services.ErrBackupTargetNotEmpty):
+			status = fiber.StatusConflict
+		case errors.Is(err, services.ErrBackupFormat), errors.Is(err, services.ErrBackupVersion),
+			errors.Is(err, services.ErrBackupPassphrase), errors.Is(err, services.ErrBackupTruncated):
+			status = fiber.StatusUnprocessableEntity
+		}
+		middleware.RecordAdminAudit(c, auditActionRestore, name, middleware.AdminAuditFailed)
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+	middleware.RecordAdminAudit(c, auditActionRestore, name, middleware.AdminAuditSuccess)
+	return c.JSON(fiber.Map{
+		"file":     name,
+		"manifest": manifest,
+		// The running server caches settings, the catalog and teams in
+		// memory; restart it to pick up the restored state.
+		"restart_required": true,
+	})
+}
@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"bytes"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/VersusControl/versus-incident/pkg/services"
+	"github.com/VersusControl/versus-incident/pkg/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func backupRestore(t *testing.T, app *fiber.App, body string) (int, []byte) {
+	t.Helper()
+	req := httptest.NewRequest("POST", "/api/admin/restore", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gateway-Secret", timelineSecret)
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("POST restore: %v", err)
+	}
+	defer resp.Body.Close()
+	out, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, out
+}
+
+// TestBackupAdmin_BackupThenRestore downloads an archive, restores it into an
+// empty store from the backups directory, and checks a second restore into
+// the now non-empty store is refused.
+func TestBackupAdmin_BackupThenRestore(t *testing.T) {
+	app := newAnalyticsApp(t)
+	bc := &BackupAdminController{dir: t.TempDir()}
+	bc.Register(app.Group("/api"))
+
+	status, archive := analyticsGet(t, app, "/api/admin/backup", true)
+	if status != fiber.StatusOK || len(archive) == 0 {
+		t.Fatalf("backup = %d (%d bytes)", status, len(archive))
+	}
+	if status, _ := analyticsGet(t, app, "/api/admin/backup", false); status != fiber.StatusUnauthorized {
+		t.Fatalf("no secret: status = %d, want 401", status)
+	}
+	if err := os.WriteFile(filepath.Join(bc.dir, "snap.tar.zst"), archive, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	target := storage.NewMemory()
+	services.SetStorage(target)
+	if status, body := backupRestore(t, app, `{"file":"snap.tar.zst"}`); status != fiber.StatusOK {
+		t.Fatalf("restore = %d %s", status, body)
+	}
+	if list, _ := target.ListIncidents(0); len(list) != 2 {
+		t.Fatalf("restored %d incidents, want 2", len(list))
+	}
+	if status, _ := backupRestore(t, app, `{"file":"snap.tar.zst"}`); status != fiber.StatusConflict {
+		t.Fatalf("second restore = %d, want 409", status)
+	}
+	if status, _ := backupRestore(t, app, `{"file":"snap.tar.zst","force":true}`); status != fiber.StatusOK {
+		t.Fatalf("forced restore = %d, want 200", status)
+	}
+
+	for body, want := range map[string]int{
+		`{"file":"../snap.tar.zst"}`: fiber.StatusBadRequest,
+		`{"file":""}`:                fiber.StatusBadRequest,
+		`{"file":"missing.tar.zst"}`: fiber.StatusNotFound,
+	} {
+		if status, _ := backupRestore(t, app, body); status != want {
+			t.Errorf("%s: status = %d, want %d", body, status, want)
+		}
+	}
+}
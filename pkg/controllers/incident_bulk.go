@@ -0,0 +1,229 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/middleware"
+	"github.com/VersusControl/versus-incident/pkg/services"
+	"github.com/VersusControl/versus-incident/pkg/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Admin-audit actions for bulk incident operations. The target names the
+// selection ("ids:N" or "query:<q>") and the number of incidents matched.
+const (
+	auditActionBulkResolved = "incident.bulk.resolved"
+	auditActionBulkAssigned = "incident.bulk.assigned"
+	auditActionBulkTagged   = "incident.bulk.tagged"
+	auditActionBulkDeleted  = "incident.bulk.deleted"
+)
+
+// bulkRequest is the body of every /bulk/* route. The selection is either
+// IDs or Query (search semantics, narrowed to Origin), never both.
+//
+// A request without Confirm, or with DryRun set, is a dry run: it resolves
+// the selection and returns the matched count without changing anything.
+// To apply the change, send the same selection again with Confirm set to
+// that count; if the selection now matches a different number of incidents
+// the request is refused with 409, so an operator never acts on a set they
+// did not preview.
+type bulkRequest struct {
+	IDs     []string `json:"ids"`
+	Query   string   `json:"query"`
+	Origin  string   `json:"origin"`
+	DryRun  bool     `json:"dry_run"`
+	Confirm *int     `json:"confirm"`
+	Actor   string   `json:"actor"`
+
+	// Assign: same pointer semantics as the single-incident assign.
+	TeamID    *string   `json:"team_id"`
+	MemberIDs *[]string `json:"member_ids"`
+
+	// Tag: tags to add and to remove.
+	Add    []string `json:"add"`
+	Remove []string `json:"remove"`
+}
+
+// bulkApply performs one bulk operation on the selected ids and returns the
+// incidents it changed.
+type bulkApply func(store storage.Provider, ids []string, body *bulkRequest) ([]*storage.IncidentRecord, error)
+
+func (i *IncidentAdminController) bulkResolve(c *fiber.Ctx) error {
+	return runBulk(c, auditActionBulkResolved, nil, func(store storage.Provider, ids []string, body *bulkRequest) ([]*storage.IncidentRecord, error) {
+		recs, err := storage.ResolveIncidents(store, ids, time.Now().UTC())
+		for _, rec := range recs {
+			services.RecordTimeline(&storage.TimelineEntry{
+				OrgID:      rec.OrgID,
+				IncidentID: rec.ID,
+				Kind:       storage.TimelineStatusChange,
+				Actor:      timelineActor(body.Actor),
+				Body:       statusChangeBody(storage.StatusResolved),
+				Data:       map[string]interface{}{"to": storage.StatusResolved, "bulk": true},
+			})
+		}
+		return recs, err
+	})
+}
+
+func (i *IncidentAdminController) bulkTag(c *fiber.Ctx) error {
+	validate := func(body *bulkRequest) error {
+		add, err := storage.NormalizeTags(body.Add)
+		if err != nil {
+			return err
+		}
+		remove := uniqueIDs(body.Remove)
+		if len(add) == 0 && len(remove) == 0 {
+			return errors.New("add or remove must list at least one tag")
+		}
+		body.Add, body.Remove = add, remove
+		return nil
+	}
+	return runBulk(c, auditActionBulkTagged, validate, func(store storage.Provider, ids []string, body *bulkRequest) ([]*storage.IncidentRecord, error) {
+		recs, err := storage.TagIncidents(store, ids, body.Add, body.Remove)
+		for _, rec := range recs {
+			services.RecordTimeline(&storage.TimelineEntry{
+				OrgID:      rec.OrgID,
+				IncidentID: rec.ID,
+				Kind:       storage.TimelineFieldChange,
+				Actor:      timelineActor(body.Actor),
+				Body:       "Tags changed",
+				Data: map[string]interface{}{
+					"added":   body.Add,
+					"removed": body.Remove,
+					"tags":    rec.Tags,
+					"bulk":    true,
+				},
+			})
+		}
+		return recs, err
+	})
+}
+
+// bulkDelete permanently deletes the selected incidents; their timelines go
+// with them.
+func (i *IncidentAdminController) bulkDelete(c *fiber.Ctx) error {
+	return runBulk(c, auditActionBulkDeleted, nil, func(store storage.Provider, ids []string, _ *bulkRequest) ([]*storage.IncidentRecord, error) {
+		return storage.DeleteIncidents(store, ids)
+	})
+}
+
+// runBulk is the shared flow of the /bulk/* routes: parse and validate the
+// body, resolve the selection, answer a dry run with the count, and
+// otherwise check the confirmed count, apply, and audit.
+func runBulk(c *fiber.Ctx, action string, validate func(*bulkRequest) error, apply bulkApply) error {
+	store := services.Storage()
+	if store == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "storage not configured"})
+	}
+	var body bulkRequest
+	if err := c.BodyParser(&body); err != nil {
+		middleware.RecordAdminAudit(c, action, "bulk", middleware.AdminAuditDenied)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid json"})
+	}
+	target := bulkTarget(&body)
+	if validate != nil {
+		if err := validate(&body); err != nil {
+			middleware.RecordAdminAudit(c, action, target, middleware.AdminAuditDenied)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	ids, status, err := bulkSelection(store, &body)
+	if err != nil {
+		middleware.RecordAdminAudit(c, action, target, middleware.AdminAuditDenied)
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+	target = fmt.Sprintf("%s (%d matched)", target, len(ids))
+
+	if body.DryRun || body.Confirm == nil {
+		return c.JSON(fiber.Map{
+			"dry_run": true,
+			"matched": len(ids),
+			"ids":     ids,
+			"limit":   storage.MaxBulkIncidents,
+		})
+	}
+	if *body.Confirm != len(ids) {
+		middleware.RecordAdminAudit(c, action, target, middleware.AdminAuditDenied)
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   "selection changed since the dry run; review it and confirm the new count",
+			"matched": len(ids),
+		})
+	}
+
+	recs, err := apply(store, ids, &body)
+	if errors.Is(err, storage.ErrUnsupported) {
+		return c.Status(fiber.StatusNotImplemented).JSON(fiber.Map{"error": "not supported by the configured storage backend"})
+	}
+	if err != nil {
+		if len(recs) > 0 {
+			middleware.RecordAdminAudit(c, action, target, middleware.AdminAuditSuccess)
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error(), "changed": len(recs)})
+	}
+	middleware.RecordAdminAudit(c, action, target, middleware.AdminAuditSuccess)
+
+	changed := make([]string, 0, len(recs))
+	for _, rec := range recs {
+		changed = append(changed, rec.ID)
+	}
+	return c.JSON(fiber.Map{
+		"dry_run":   false,
+		"matched":   len(ids),
+		"changed":   changed,
+		"unchanged": len(ids) - len(changed),
+	})
+}
+
+// bulkTarget names the selection for the audit record.
+func bulkTarget(body *bulkRequest) string {
+	if q := strings.TrimSpace(body.Query); q != "" {
+		return "query:" + q
+	}
+	return fmt.Sprintf("ids:%d", len(body.IDs))
+}
+
+// bulkSelection resolves the request to incident ids, bounded by
+// storage.MaxBulkIncidents. An id list is taken as given (unknown ids are
+// skipped when applied); a query pages through the backend's
+// IncidentSearchPager with the same semantics as GET /search, so a backend
+// without one cannot select by query. On error it also returns the status
+// to answer with.
+func bulkSelection(store storage.Provider, body *bulkRequest) ([]string, int, error) {
+	query := strings.TrimSpace(body.Query)
+	if (query == "") == (len(body.IDs) == 0) {
+		return nil, fiber.StatusBadRequest, errors.New("give exactly one of ids or query")
+	}
+	if query == "" {
+		ids := uniqueIDs(body.IDs)
+		if len(ids) > storage.MaxBulkIncidents {
+			return nil, fiber.StatusBadRequest, fmt.Errorf("ids must list at most %d incidents", storage.MaxBulkIncidents)
+		}
+		return ids, 0, nil
+	}
+
+	sp, ok := store.(storage.IncidentSearchPager)
+	if !ok {
+		return nil, fiber.StatusNotImplemented, errors.New("search not supported by the configured storage backend")
+	}
+	ids := make([]string, 0)
+	for offset := 0; ; offset += storage.DefaultIncidentPageSize {
+		recs, err := sp.SearchIncidentsPage(query, body.Origin, offset, storage.DefaultIncidentPageSize)
+		if err != nil {
+			return nil, fiber.StatusInternalServerError, err
+		}
+		for _, rec := range recs {
+			ids = append(ids, rec.ID)
+		}
+		if len(ids) > storage.MaxBulkIncidents {
+			return nil, fiber.StatusBadRequest, fmt.Errorf("query matches more than %d incidents; narrow it", storage.MaxBulkIncidents)
+		}
+		if len(recs) < storage.DefaultIncidentPageSize {
+			return ids, 0, nil
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"errors"
+	"maps"
+	"reflect"
+	"slices"
+
+	"github.com/VersusControl/versus-incident/pkg/middleware"
+	"github.com/VersusControl/versus-incident/pkg/services"
+	"github.com/VersusControl/versus-incident/pkg/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// auditActionIncidentFields is the admin-audit action for an edit of an
+// incident's severity, priority, labels, tags or custom fields.
+const auditActionIncidentFields = "incident.fields.updated"
+
+// fieldsPayload is the body for PATCH /:id. Every field is a pointer so an
+// omitted field leaves the stored value alone while an explicit empty value
+// ("" / {} / []) clears it — the same convention as assignPayload. Labels,
+// tags and custom fields replace the stored set wholesale.
+type fieldsPayload struct {
+	Severity     *string                `json:"severity"`
+	Priority     *string                `json:"priority"`
+	Labels       *map[string]string     `json:"labels"`
+	Tags         *[]string              `json:"tags"`
+	CustomFields *[]storage.CustomField `json:"custom_fields"`
+	// Actor names the operator on the timeline's field_change entry.
+	Actor string `json:"actor"`
+}
+
+// updateFields edits the operator metadata on one incident. Every supplied
+// field is normalized before anything is written, so a request with one bad
+// field changes nothing. The changed fields are recorded on the timeline
+// with their before and after values.
+func (i *IncidentAdminController) updateFields(c *fiber.Ctx) error {
+	store := services.Storage()
+	if store == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "storage not configured"})
+	}
+	id := c.Params("id")
+
+	var p fieldsPayload
+	if err := c.BodyParser(&p); err != nil {
+		middleware.RecordAdminAudit(c, auditActionIncidentFields, id, middleware.AdminAuditDenied)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid json"})
+	}
+
+	rec, err := store.GetIncident(id)
+	if errors.Is(err, storage.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "not found"})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	next := *rec
+	if err := applyFieldsPayload(&next, p); err != nil {
+		middleware.RecordAdminAudit(c, auditActionIncidentFields, id, middleware.AdminAuditDenied)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	changes := fieldChanges(rec, &next)
+	if len(changes) > 0 {
+		if err := store.SaveIncident(&next); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+		services.RecordTimeline(&storage.TimelineEntry{
+			OrgID:      next.OrgID,
+			IncidentID: next.ID,
+			Kind:       storage.TimelineFieldChange,
+			Actor:      timelineActor(p.Actor),
+			Body:       "Fields changed",
+			Data:       changes,
+		})
+		middleware.RecordAdminAudit(c, auditActionIncidentFields, id, middleware.AdminAuditSuccess)
+	}
+
+	return c.JSON(fiber.Map{
+		"id":            next.ID,
+		"severity":      next.SeverityLabel(),
+		"priority":      next.Priority,
+		"labels":        next.Labels,
+		"tags":          next.Tags,
+		"custom_fields": next.CustomFields,
+		"changed":       len(changes) > 0,
+	})
+}
+
+// applyFieldsPayload normalizes each supplied field onto rec. It stops at the
+// first invalid field; the caller passes a copy so a failure leaves the
+// stored record untouched.
+func applyFieldsPayload(rec *storage.IncidentRecord, p fieldsPayload) error {
+	var err error
+	if p.Severity != nil {
+		if rec.Severity, err = storage.NormalizeSeverity(*p.Severity); err != nil {
+			return err
+		}
+	}
+	if p.Priority != nil {
+		if rec.Priority, err = storage.NormalizePriority(*p.Priority); err != nil {
+			return err
+		}
+	}
+	if p.Labels != nil {
+		if rec.Labels, err = storage.NormalizeLabels(*p.Labels); err != nil {
+			return err
+		}
+	}
+	if p.Tags != nil {
+		if rec.Tags, err = storage.NormalizeTags(*p.Tags); err != nil {
+			return err
+		}
+	}
+	if p.CustomFields != nil {
+		if rec.CustomFields, err = storage.NormalizeCustomFields(*p.CustomFields); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fieldChanges returns {field: {from, to}} for every edited field that
+// actually changed, or nil when the edit was a no-op.
+func fieldChanges(from, to *storage.IncidentRecord) map[string]interface{} {
+	changes := map[string]interface{}{}
+	add := func(name string, a, b interface{}) {
+		changes[name] = map[string]interface{}{"from": a, "to": b}
+	}
+	if from.Severity != to.Severity {
+		add("severity", from.Severity, to.Severity)
+	}
+	if from.Priority != to.Priority {
+		add("priority", from.Priority, to.Priority)
+	}
+	if !maps.Equal(from.Labels, to.Labels) {
+		add("labels", from.Labels, to.Labels)
+	}
+	if !slices.Equal(from.Tags, to.Tags) {
+		add("tags", from.Tags, to.Tags)
+	}
+	if !reflect.DeepEqual(from.CustomFields, to.CustomFields) {
+		add("custom_fields", from.CustomFields, to.CustomFields)
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	return changes
+}
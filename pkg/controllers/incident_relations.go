@@ -0,0 +1,303 @@
+package controllers
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/middleware"
+	"github.com/VersusControl/versus-incident/pkg/services"
+	"github.com/VersusControl/versus-incident/pkg/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Admin-audit actions for incident relationship changes.
+const (
+	auditActionIncidentMerged   = "incident.merged"
+	auditActionIncidentLinked   = "incident.linked"
+	auditActionIncidentUnlinked = "incident.unlinked"
+	auditActionIncidentParent   = "incident.parent.changed"
+)
+
+// maxMergeDuplicates bounds one merge request.
+const maxMergeDuplicates = 100
+
+// mergeRequest is the body for POST /:id/merge: the duplicates to close into
+// the :id primary.
+type mergeRequest struct {
+	DuplicateIDs []string `json:"duplicate_ids"`
+	Actor        string   `json:"actor"`
+}
+
+// linkRequest is the body for POST /:id/links.
+type linkRequest struct {
+	RelatedID string `json:"related_id"`
+	Actor     string `json:"actor"`
+}
+
+// parentRequest is the body for PUT /:id/parent. An empty ParentID clears
+// the parent.
+type parentRequest struct {
+	ParentID string `json:"parent_id"`
+	Actor    string `json:"actor"`
+}
+
+// relationError maps a storage error from a relationship change to its
+// response: 404 for an unknown incident, 409 for a relation the rules do not
+// allow, 500 otherwise.
+func relationError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "not found"})
+	case errors.Is(err, storage.ErrInvalidRelation):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+}
+
+// relationEntry builds a relation timeline entry for rec.
+func relationEntry(rec *storage.IncidentRecord, actor, body string, data map[string]interface{}) *storage.TimelineEntry {
+	return &storage.TimelineEntry{
+		OrgID:      rec.OrgID,
+		IncidentID: rec.ID,
+		Kind:       storage.TimelineRelation,
+		Actor:      timelineActor(actor),
+		Body:       body,
+		Data:       data,
+	}
+}
+
+// relations returns the incident's own references, the incidents that
+// point at it (children and merged duplicates), and the whole cluster
+// reachable through any relationship.
+func (i *IncidentAdminController) relations(c *fiber.Ctx) error {
+	store := services.Storage()
+	if store == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "storage not configured"})
+	}
+	id := c.Params("id")
+	rec, err := store.GetIncident(id)
+	if err != nil {
+		return relationError(c, err)
+	}
+	cluster, err := storage.RelatedIncidents(store, id)
+	if err != nil {
+		return relationError(c, err)
+	}
+	children := make([]string, 0)
+	duplicates := make([]string, 0)
+	summaries := make([]fiber.Map, 0, len(cluster))
+	for _, r := range cluster {
+		if r.ParentID == id {
+			children = append(children, r.ID)
+		}
+		if r.MergedInto == id {
+			duplicates = append(duplicates, r.ID)
+		}
+		summaries = append(summaries, summarize(r))
+	}
+	return c.JSON(fiber.Map{
+		"id":          rec.ID,
+		"merged_into": rec.MergedInto,
+		"parent_id":   rec.ParentID,
+		"related_ids": rec.RelatedIDs,
+		"children":    children,
+		"duplicates":  duplicates,
+		"cluster":     summaries,
+	})
+}
+
+// merge closes each duplicate into the :id primary. Every duplicate is
+// checked before anything is written, so one invalid duplicate fails the
+// whole request with nothing merged. Duplicates already merged into this
+// primary are reported as unchanged.
+func (i *IncidentAdminController) merge(c *fiber.Ctx) error {
+	store := services.Storage()
+	if store == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "storage not configured"})
+	}
+	id := c.Params("id")
+
+	var body mergeRequest
+	if err := c.BodyParser(&body); err != nil {
+		middleware.RecordAdminAudit(c, auditActionIncidentMerged, id, middleware.AdminAuditDenied)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid json"})
+	}
+	ids := uniqueIDs(body.DuplicateIDs)
+	if len(ids) == 0 || len(ids) > maxMergeDuplicates {
+		middleware.RecordAdminAudit(c, auditActionIncidentMerged, id, middleware.AdminAuditDenied)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "duplicate_ids must list 1-100 incidents"})
+	}
+
+	primary, err := store.GetIncident(id)
+	if err != nil {
+		return relationError(c, err)
+	}
+	now := time.Now().UTC()
+	type pending struct {
+		rec  *storage.IncidentRecord
+		from string
+	}
+	var toSave []pending
+	unchanged := make([]string, 0)
+	for _, dupID := range ids {
+		dup, err := store.GetIncident(dupID)
+		if err != nil {
+			middleware.RecordAdminAudit(c, auditActionIncidentMerged, id, middleware.AdminAuditDenied)
+			return relationError(c, err)
+		}
+		from := dup.EffectiveStatus(now)
+		changed, err := dup.MergeInto(primary, now)
+		if err != nil {
+			middleware.RecordAdminAudit(c, auditActionIncidentMerged, id, middleware.AdminAuditDenied)
+			return relationError(c, err)
+		}
+		if !changed {
+			unchanged = append(unchanged, dup.ID)
+			continue
+		}
+		toSave = append(toSave, pending{rec: dup, from: from})
+	}
+
+	merged := make([]string, 0, len(toSave))
+	for _, p := range toSave {
+		if err := store.SaveIncident(p.rec); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+		merged = append(merged, p.rec.ID)
+		services.RecordTimeline(relationEntry(p.rec, body.Actor, "Merged into "+primary.ID,
+			map[string]interface{}{"merged_into": primary.ID, "from": p.from}))
+	}
+	if len(merged) > 0 {
+		services.RecordTimeline(relationEntry(primary, body.Actor, "Merged duplicates",
+			map[string]interface{}{"duplicate_ids": merged}))
+		middleware.RecordAdminAudit(c, auditActionIncidentMerged, id, middleware.AdminAuditSuccess)
+	}
+	return c.JSON(fiber.Map{"id": primary.ID, "merged": merged, "unchanged": unchanged})
+}
+
+// link records a related link between :id and the body's related_id.
+func (i *IncidentAdminController) link(c *fiber.Ctx) error {
+	var body linkRequest
+	if err := c.BodyParser(&body); err != nil {
+		middleware.RecordAdminAudit(c, auditActionIncidentLinked, c.Params("id"), middleware.AdminAuditDenied)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid json"})
+	}
+	return i.changeLink(c, strings.TrimSpace(body.RelatedID), body.Actor, true)
+}
+
+// unlink removes the related link between :id and :related_id.
+func (i *IncidentAdminController) unlink(c *fiber.Ctx) error {
+	var body linkRequest
+	_ = c.BodyParser(&body)
+	return i.changeLink(c, c.Params("related_id"), body.Actor, false)
+}
+
+// changeLink adds or removes the symmetric link between :id and otherID,
+// saving both records and recording the change on both timelines.
+func (i *IncidentAdminController) changeLink(c *fiber.Ctx, otherID, actor string, add bool) error {
+	action, verb := auditActionIncidentUnlinked, "Unlinked from "
+	if add {
+		action, verb = auditActionIncidentLinked, "Linked to "
+	}
+	store := services.Storage()
+	if store == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "storage not configured"})
+	}
+	id := c.Params("id")
+	if otherID == "" {
+		middleware.RecordAdminAudit(c, action, id, middleware.AdminAuditDenied)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "related_id is required"})
+	}
+	a, err := store.GetIncident(id)
+	if err != nil {
+		return relationError(c, err)
+	}
+	b, err := store.GetIncident(otherID)
+	if err != nil {
+		middleware.RecordAdminAudit(c, action, id+" ↔ "+otherID, middleware.AdminAuditDenied)
+		return relationError(c, err)
+	}
+
+	var changed bool
+	if add {
+		changed, err = storage.LinkIncidents(a, b)
+	} else {
+		changed = storage.UnlinkIncidents(a, b)
+	}
+	if err != nil {
+		middleware.RecordAdminAudit(c, action, id+" ↔ "+otherID, middleware.AdminAuditDenied)
+		return relationError(c, err)
+	}
+	if changed {
+		for _, r := range []*storage.IncidentRecord{a, b} {
+			if err := store.SaveIncident(r); err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+			}
+		}
+		services.RecordTimeline(relationEntry(a, actor, verb+b.ID, map[string]interface{}{"related_id": b.ID}))
+		services.RecordTimeline(relationEntry(b, actor, verb+a.ID, map[string]interface{}{"related_id": a.ID}))
+		middleware.RecordAdminAudit(c, action, id+" ↔ "+otherID, middleware.AdminAuditSuccess)
+	}
+	return c.JSON(fiber.Map{"id": a.ID, "related_ids": a.RelatedIDs, "changed": changed})
+}
+
+// setParent makes the body's parent_id the parent of :id, or clears the
+// parent when it is empty. A parent that would form a cycle is a 409.
+func (i *IncidentAdminController) setParent(c *fiber.Ctx) error {
+	store := services.Storage()
+	if store == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "storage not configured"})
+	}
+	id := c.Params("id")
+	var body parentRequest
+	if err := c.BodyParser(&body); err != nil {
+		middleware.RecordAdminAudit(c, auditActionIncidentParent, id, middleware.AdminAuditDenied)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid json"})
+	}
+	parentID := strings.TrimSpace(body.ParentID)
+
+	rec, err := store.GetIncident(id)
+	if err != nil {
+		return relationError(c, err)
+	}
+	if parentID != "" {
+		if err := storage.CheckParent(store, rec, parentID); err != nil {
+			middleware.RecordAdminAudit(c, auditActionIncidentParent, id, middleware.AdminAuditDenied)
+			return relationError(c, err)
+		}
+	}
+
+	from := rec.ParentID
+	if from != parentID {
+		rec.ParentID = parentID
+		if err := store.SaveIncident(rec); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+		text := "Parent set to " + parentID
+		if parentID == "" {
+			text = "Parent cleared"
+		}
+		services.RecordTimeline(relationEntry(rec, body.Actor, text,
+			map[string]interface{}{"from": from, "to": parentID}))
+		middleware.RecordAdminAudit(c, auditActionIncidentParent, id, middleware.AdminAuditSuccess)
+	}
+	return c.JSON(fiber.Map{"id": rec.ID, "parent_id": rec.ParentID, "changed": from != parentID})
+}
+
+// uniqueIDs trims ids and drops blanks and repeats, keeping first-seen order.
+func uniqueIDs(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	out := make([]string, 0, len(ids))
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		out = append(out, id)
+	}
+	return out
+}
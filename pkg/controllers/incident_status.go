@@ -0,0 +1,207 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/core"
+	"github.com/VersusControl/versus-incident/pkg/middleware"
+	"github.com/VersusControl/versus-incident/pkg/services"
+	"github.com/VersusControl/versus-incident/pkg/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// auditActionIncidentStatus is the admin-audit action for every status
+// transition; the target is "<incident id> → <status>".
+const auditActionIncidentStatus = "incident.status.changed"
+
+// statusRequest is the optional body for the status transition routes.
+// Actor names the operator on the timeline entry. Until (RFC 3339) or
+// Duration (a Go duration such as "30m") sets the wake-up time for snooze
+// and is ignored elsewhere.
+type statusRequest struct {
+	Actor    string `json:"actor"`
+	Until    string `json:"until"`
+	Duration string `json:"duration"`
+}
+
+func (i *IncidentAdminController) ack(c *fiber.Ctx) error {
+	return i.transition(c, storage.StatusAcknowledged)
+}
+
+func (i *IncidentAdminController) investigate(c *fiber.Ctx) error {
+	return i.transition(c, storage.StatusInvestigating)
+}
+
+func (i *IncidentAdminController) mitigate(c *fiber.Ctx) error {
+	return i.transition(c, storage.StatusMitigated)
+}
+
+// resolve marks an incident as resolved. Idempotent: re-resolving an
+// already-resolved record is a no-op (no error, no timestamp drift).
+func (i *IncidentAdminController) resolve(c *fiber.Ctx) error {
+	return i.transition(c, storage.StatusResolved)
+}
+
+func (i *IncidentAdminController) reopen(c *fiber.Ctx) error {
+	return i.transition(c, storage.StatusReopened)
+}
+
+func (i *IncidentAdminController) snooze(c *fiber.Ctx) error {
+	return i.transition(c, storage.StatusSnoozed)
+}
+
+// transition moves one incident to state to through the storage state
+// machine, persists it, and records the change on the timeline. Moving to
+// the current state is an idempotent 200; an edge the state machine does not
+// allow is a 409.
+func (i *IncidentAdminController) transition(c *fiber.Ctx, to string) error {
+	store := services.Storage()
+	if store == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "storage not configured"})
+	}
+	id := c.Params("id")
+	target := id + " → " + to
+
+	// Body is optional; tolerate parse errors as "no body".
+	var body statusRequest
+	_ = c.BodyParser(&body)
+
+	now := time.Now().UTC()
+	var until *time.Time
+	if to == storage.StatusSnoozed {
+		u, err := snoozeUntil(body, now)
+		if err != nil {
+			middleware.RecordAdminAudit(c, auditActionIncidentStatus, target, middleware.AdminAuditDenied)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		until = &u
+	}
+
+	rec, err := store.GetIncident(id)
+	if errors.Is(err, storage.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "not found"})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	from := rec.EffectiveStatus(now)
+	changed, err := rec.Transition(to, now, until)
+	if errors.Is(err, storage.ErrInvalidTransition) {
+		middleware.RecordAdminAudit(c, auditActionIncidentStatus, target, middleware.AdminAuditDenied)
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error(), "status": from})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if changed {
+		if err := store.SaveIncident(rec); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+		data := map[string]interface{}{"from": from, "to": to}
+		if rec.SnoozedUntil != nil {
+			data["snoozed_until"] = rec.SnoozedUntil
+		}
+		services.RecordTimeline(&storage.TimelineEntry{
+			OrgID:      rec.OrgID,
+			IncidentID: rec.ID,
+			Kind:       storage.TimelineStatusChange,
+			Actor:      timelineActor(body.Actor),
+			Body:       statusChangeBody(to),
+			Data:       data,
+		})
+		middleware.RecordAdminAudit(c, auditActionIncidentStatus, target, middleware.AdminAuditSuccess)
+		if escalationStops(from, to) {
+			stopEscalation(rec.ID)
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"id":            rec.ID,
+		"status":        rec.EffectiveStatus(now),
+		"previous":      from,
+		"changed":       changed,
+		"resolved":      rec.Resolved,
+		"resolved_at":   rec.ResolvedAt,
+		"acked_at":      rec.AckedAt,
+		"snoozed_until": rec.SnoozedUntil,
+	})
+}
+
+// snoozeUntil resolves the wake-up time from the request: an absolute Until
+// wins over a relative Duration. Either must land in the future.
+func snoozeUntil(body statusRequest, now time.Time) (time.Time, error) {
+	switch {
+	case strings.TrimSpace(body.Until) != "":
+		u, err := time.Parse(time.RFC3339, strings.TrimSpace(body.Until))
+		if err != nil {
+			return time.Time{}, errors.New("until must be an RFC 3339 timestamp")
+		}
+		if !u.After(now) {
+			return time.Time{}, errors.New("until must be in the future")
+		}
+		return u.UTC(), nil
+	case strings.TrimSpace(body.Duration) != "":
+		d, err := time.ParseDuration(strings.TrimSpace(body.Duration))
+		if err != nil || d <= 0 {
+			return time.Time{}, errors.New("duration must be a positive Go duration such as 30m")
+		}
+		return now.Add(d), nil
+	default:
+		return time.Time{}, errors.New("snooze needs until or duration")
+	}
+}
+
+// statusChangeBody is the one-line timeline summary for a transition.
+func statusChangeBody(to string) string {
+	switch to {
+	case storage.StatusAcknowledged:
+		return "Acknowledged"
+	case storage.StatusInvestigating:
+		return "Investigating"
+	case storage.StatusMitigated:
+		return "Mitigated"
+	case storage.StatusResolved:
+		return "Resolved"
+	case storage.StatusReopened:
+		return "Reopened"
+	case storage.StatusSnoozed:
+		return "Snoozed"
+	default:
+		return "Status changed to " + to
+	}
+}
+
+// escalationStops reports whether moving from → to engages an incident that
+// was still paging: it leaves triggered, reopened or snoozed for a state in
+// which someone owns it (acknowledged, investigating, mitigated) or it is
+// closed (resolved).
+func escalationStops(from, to string) bool {
+	switch from {
+	case storage.StatusTriggered, storage.StatusReopened, storage.StatusSnoozed:
+	default:
+		return false
+	}
+	switch to {
+	case storage.StatusAcknowledged, storage.StatusInvestigating, storage.StatusMitigated, storage.StatusResolved:
+		return true
+	}
+	return false
+}
+
+// stopEscalation cancels a pending on-call escalation once an admin
+// transition engages the incident, the same effect the signed ack link has. Best-effort: on-call may be off,
+// or the incident may never have escalated.
+func stopEscalation(incidentID string) {
+	if !core.IsOnCallWorkflowInitialized() {
+		return
+	}
+	if err := core.GetOnCallWorkflow().Ack(incidentID); err != nil {
+		log.Printf("incident: on-call ack for %s: %v", incidentID, err)
+	}
+}
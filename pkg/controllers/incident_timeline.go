@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/middleware"
+	"github.com/VersusControl/versus-incident/pkg/services"
+	"github.com/VersusControl/versus-incident/pkg/storage"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// auditActionTimelineAppended is the admin-audit action for an operator note
+// or comment added to an incident timeline.
+const auditActionTimelineAppended = "incident.timeline.appended"
+
+// defaultTimelineActor names the operator on a timeline entry when the
+// request does not. OSS has no operator identity; the UI sends its own.
+const defaultTimelineActor = "operator"
+
+// maxTimelineBodyLen bounds one note or comment so a runaway paste cannot
+// bloat the timeline.
+const maxTimelineBodyLen = 16 * 1024
+
+// timelineRequest is the body for POST /:id/timeline. Kind defaults to a
+// note; only operator kinds (note, comment) are accepted.
+type timelineRequest struct {
+	Kind  string `json:"kind"`
+	Body  string `json:"body"`
+	Actor string `json:"actor"`
+}
+
+// timelineActor returns the trimmed actor, or defaultTimelineActor.
+func timelineActor(actor string) string {
+	if a := strings.TrimSpace(actor); a != "" {
+		return a
+	}
+	return defaultTimelineActor
+}
+
+// timelineStore returns the storage.Timeline capability, writing the 503 /
+// 501 response itself when it is unavailable.
+func timelineStore(c *fiber.Ctx) (storage.Provider, storage.Timeline, error) {
+	store := services.Storage()
+	if store == nil {
+		return nil, nil, c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "storage not configured"})
+	}
+	tl, ok := store.(storage.Timeline)
+	if !ok {
+		return nil, nil, c.Status(fiber.StatusNotImplemented).JSON(fiber.Map{"error": "timeline not supported by the storage backend"})
+	}
+	return store, tl, nil
+}
+
+// listTimeline returns the incident's timeline, oldest first (?limit=NN
+// keeps the newest NN entries).
+func (i *IncidentAdminController) listTimeline(c *fiber.Ctx) error {
+	store, tl, err := timelineStore(c)
+	if tl == nil {
+		return err
+	}
+	id := c.Params("id")
+	if _, err := store.GetIncident(id); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			// An archived incident carries its timeline with it.
+			old, aerr := readArchivedIncident(c, id)
+			if aerr != nil {
+				return archiveError(c, aerr)
+			}
+			return c.JSON(fiber.Map{"id": id, "entries": archivedTimelineWindow(old.Timeline, parseLimit(c.Query("limit"))), "archived": true})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	entries, err := tl.ListTimeline(id, parseLimit(c.Query("limit")))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"id": id, "entries": entries})
+}
+
+// appendTimeline adds an operator note or comment. Server-emitted kinds
+// (status changes, notifications, ...) cannot be forged through this route.
+func (i *IncidentAdminController) appendTimeline(c *fiber.Ctx) error {
+	store, tl, err := timelineStore(c)
+	if tl == nil {
+		return err
+	}
+	id := c.Params("id")
+
+	var body timelineRequest
+	if err := c.BodyParser(&body); err != nil {
+		middleware.RecordAdminAudit(c, auditActionTimelineAppended, id, middleware.AdminAuditDenied)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid json"})
+	}
+	kind := strings.TrimSpace(body.Kind)
+	if kind == "" {
+		kind = storage.TimelineNote
+	}
+	if !storage.IsOperatorTimelineKind(kind) {
+		middleware.RecordAdminAudit(c, auditActionTimelineAppended, id, middleware.AdminAuditDenied)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "kind must be note or comment"})
+	}
+	text := strings.TrimSpace(body.Body)
+	if text == "" || len(text) > maxTimelineBodyLen {
+		middleware.RecordAdminAudit(c, auditActionTimelineAppended, id, middleware.AdminAuditDenied)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "body must be 1-16384 bytes"})
+	}
+
+	rec, err := store.GetIncident(id)
+	if errors.Is(err, storage.ErrNotFound) {
+		middleware.RecordAdminAudit(c, auditActionTimelineAppended, id, middleware.AdminAuditDenied)
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "not found"})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	entry := &storage.TimelineEntry{
+		ID:         uuid.NewString(),
+		OrgID:      rec.OrgID,
+		IncidentID: rec.ID,
+		Kind:       kind,
+		Actor:      timelineActor(body.Actor),
+		Body:       text,
+		CreatedAt:  time.Now().UTC(),
+	}
+	if err := tl.AppendTimelineEntry(entry); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	middleware.RecordAdminAudit(c, auditActionTimelineAppended, id, middleware.AdminAuditSuccess)
+	return c.Status(fiber.StatusCreated).JSON(entry)
+}
+
+// analysisTimelineEntry records an analyze run on its incident's timeline.
+func analysisTimelineEntry(a *storage.AnalysisRecord) *storage.TimelineEntry {
+	data := map[string]interface{}{
+		"analysis_id": a.ID,
+		"status":      a.Status,
+		"duration_ms": a.DurationMs,
+	}
+	if a.Model != "" {
+		data["model"] = a.Model
+	}
+	if a.Error != "" {
+		data["error"] = a.Error
+	}
+	return &storage.TimelineEntry{
+		OrgID:      a.OrgID,
+		IncidentID: a.IncidentID,
+		Kind:       storage.TimelineAnalysis,
+		Actor:      timelineActor(a.RequestedBy),
+		Body:       "Analysis " + a.Status,
+		Data:       data,
+	}
+}
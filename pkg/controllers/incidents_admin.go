@@ -36,8 +36,25 @@ func NewIncidentAdminController() *IncidentAdminController {
 //	GET  /api/admin/incidents/counts          cheap per-origin × per-status tally
 //	GET  /api/admin/incidents/intake-settings  read intake settings
 //	PUT  /api/admin/incidents/intake-settings  update intake settings
-//	GET  /api/admin/incidents/:id             single record
+//	GET   /api/admin/incidents/:id            single record (falls back to the archive)
+//	PATCH /api/admin/incidents/:id            edit severity, priority, labels, tags, custom fields
+//	POST /api/admin/incidents/:id/ack         triggered/reopened/snoozed → acknowledged
+//	POST /api/admin/incidents/:id/investigate → investigating
+//	POST /api/admin/incidents/:id/mitigate    → mitigated
 //	POST /api/admin/incidents/:id/resolve     mark resolved (idempotent)
+//	POST /api/admin/incidents/:id/reopen      resolved → reopened
+//	POST /api/admin/incidents/:id/snooze      snooze until a time (?until / duration)
+//	GET  /api/admin/incidents/:id/timeline    timeline, oldest first (?limit=NN)
+//	POST /api/admin/incidents/:id/timeline    add an operator note or comment
+//	GET  /api/admin/incidents/:id/relations   references, children, duplicates and cluster
+//	POST /api/admin/incidents/:id/merge       close duplicates into this incident
+//	POST /api/admin/incidents/:id/links       link a related incident
+//	DELETE /api/admin/incidents/:id/links/:related_id  remove a related link
+//	PUT  /api/admin/incidents/:id/parent      set or clear the parent incident
+//	GET  /api/admin/incidents/export          stream history as csv, ndjson or parquet
+//	POST /api/admin/incidents/bulk/resolve    resolve many incidents (ids or query; dry run, then confirm)
+//	POST /api/admin/incidents/bulk/tag        add/remove tags on many incidents
+//	POST /api/admin/incidents/bulk/delete     delete many incidents
 func (i *IncidentAdminController) Register(router fiber.Router) {
 	// Capabilities probe — lets the UI enable/disable search depending on
 	// whether the active storage backend implements storage.Searcher.
@@ -56,10 +73,30 @@ func (i *IncidentAdminController) Register(router fiber.Router) {
 	// path is not captured as an incident id.
 	g.Get("/intake-settings", i.getIntakeSettings)
 	g.Put("/intake-settings", i.putIntakeSettings)
+	// /export likewise precedes /:id.
+	g.Get("/export", i.export)
+	// Bulk routes precede /:id for the same reason. Bulk assign lives on the
+	// teams controller, next to the single-incident assign.
+	g.Post("/bulk/resolve", i.bulkResolve)
+	g.Post("/bulk/tag", i.bulkTag)
+	g.Post("/bulk/delete", i.bulkDelete)
 	g.Get("/:id", i.get)
+	g.Patch("/:id", i.updateFields)
+	g.Post("/:id/ack", i.ack)
+	g.Post("/:id/investigate", i.investigate)
+	g.Post("/:id/mitigate", i.mitigate)
 	g.Post("/:id/resolve", i.resolve)
+	g.Post("/:id/reopen", i.reopen)
+	g.Post("/:id/snooze", i.snooze)
 	g.Post("/:id/analyze", i.analyze)
 	g.Get("/:id/analyses", i.listAnalyses)
+	g.Get("/:id/timeline", i.listTimeline)
+	g.Post("/:id/timeline", i.appendTimeline)
+	g.Get("/:id/relations", i.relations)
+	g.Post("/:id/merge", i.merge)
+	g.Post("/:id/links", i.link)
+	g.Delete("/:id/links/:related_id", i.unlink)
+	g.Put("/:id/parent", i.setParent)
 
 	a := router.Group("/admin/analyses", i.authMiddleware)
 	a.Get("/", i.listAllAnalyses)
@@ -210,9 +247,9 @@ func enabledAlertChannels(alert config.AlertConfig) []string {
 }
 
 // search runs server-side full-text search over stored incidents using
-// the optional storage.Searcher capability. Backends that do not
-// implement it (memory, file) return 501 so the UI can fall back to its
-// in-page client-side filter.
+// the optional storage.Searcher capability. A backend that does not
+// implement it (Redis) returns 501 so the UI can fall back to its in-page
+// client-side filter.
 func (i *IncidentAdminController) search(c *fiber.Ctx) error {
 	store := services.Storage()
 	if store == nil {
@@ -229,8 +266,8 @@ func (i *IncidentAdminController) search(c *fiber.Ctx) error {
 	origin := c.Query("origin")
 	// Preferred path: the backend implements the search pager, so a broad
 	// query against a large history returns the first page from one bounded
-	// query plus one count query — never the whole match set. Postgres
-	// implements it; it is the only unbounded Searcher.
+	// query plus one count query — never the whole match set. Every
+	// Searcher in this tree implements it.
 	if sp, ok := store.(storage.IncidentSearchPager); ok {
 		counts, err := sp.CountIncidentsMatchingByStatus(query)
 		if err != nil {
@@ -286,11 +323,21 @@ func (i *IncidentAdminController) get(c *fiber.Ctx) error {
 	}
 	rec, err := store.GetIncident(c.Params("id"))
 	if errors.Is(err, storage.ErrNotFound) {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "not found"})
+		// Read through to the archive, so a link to an incident that has
+		// aged out of the primary store still opens.
+		old, aerr := readArchivedIncident(c, c.Params("id"))
+		if aerr != nil {
+			return archiveError(c, aerr)
+		}
+		old.Status = old.EffectiveStatus(time.Now())
+		return c.JSON(archivedIncident{IncidentRecord: old.IncidentRecord, Archived: true, ArchivedAt: old.ArchivedAt})
 	}
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
+	// Report the effective state so legacy rows and lapsed snoozes read the
+	// same here as in the list and the counts.
+	rec.Status = rec.EffectiveStatus(time.Now())
 	return c.JSON(rec)
 }
 
@@ -304,6 +351,8 @@ func summarize(r *storage.IncidentRecord) fiber.Map {
 		"origin":              r.EffectiveOrigin(),
 		"service":             services.ServiceLabel(r),
 		"resolved":            r.Resolved,
+		"status":              r.EffectiveStatus(time.Now()),
+		"snoozed_until":       r.SnoozedUntil,
 		"channels_notified":   r.ChannelsNotified,
 		"oncall_triggered":    r.OnCallTriggered,
 		"notify_status":       r.NotifyStatus,
@@ -313,6 +362,12 @@ func summarize(r *storage.IncidentRecord) fiber.Map {
 		"resolved_at":         r.ResolvedAt,
 		"assigned_team_id":    r.AssignedTeamID,
 		"assigned_member_ids": r.AssignedMemberIDs,
+		"severity":            r.SeverityLabel(),
+		"priority":            r.Priority,
+		"labels":              r.Labels,
+		"tags":                r.Tags,
+		"merged_into":         r.MergedInto,
+		"parent_id":           r.ParentID,
 	}
 }
 
@@ -464,7 +519,9 @@ func perOriginMap(ai, webhook, total int) fiber.Map {
 
 // statusCountsMap renders the whole-set per-origin × per-status tally into the
 // nested by_status shape the UI consumes: one {ai_detect, webhook, total}
-// object per status bucket (open / acked / resolved / all). It is additive to
+// object per status bucket: the rollups (open / acked / resolved / all) plus
+// one per lifecycle state (triggered / acknowledged / investigating /
+// mitigated / reopened / snoozed). It is additive to
 // the existing {ai_detect, webhook, total} counts object — consumers that read
 // only the top-level unresolved counts are unaffected.
 func statusCountsMap(c storage.IncidentStatusCounts) fiber.Map {
@@ -473,6 +530,13 @@ func statusCountsMap(c storage.IncidentStatusCounts) fiber.Map {
 		"acked":    perOriginMap(c.AIDetect.Acked, c.Webhook.Acked, c.Total.Acked),
 		"resolved": perOriginMap(c.AIDetect.Resolved, c.Webhook.Resolved, c.Total.Resolved),
 		"all":      perOriginMap(c.AIDetect.Total, c.Webhook.Total, c.Total.Total),
+
+		storage.StatusTriggered:     perOriginMap(c.AIDetect.Triggered, c.Webhook.Triggered, c.Total.Triggered),
+		storage.StatusAcknowledged:  perOriginMap(c.AIDetect.Acknowledged, c.Webhook.Acknowledged, c.Total.Acknowledged),
+		storage.StatusInvestigating: perOriginMap(c.AIDetect.Investigating, c.Webhook.Investigating, c.Total.Investigating),
+		storage.StatusMitigated:     perOriginMap(c.AIDetect.Mitigated, c.Webhook.Mitigated, c.Total.Mitigated),
+		storage.StatusReopened:      perOriginMap(c.AIDetect.Reopened, c.Webhook.Reopened, c.Total.Reopened),
+		storage.StatusSnoozed:       perOriginMap(c.AIDetect.Snoozed, c.Webhook.Snoozed, c.Total.Snoozed),
 	}
 }
 
@@ -631,35 +695,6 @@ func incidentListResponse(recs []*storage.IncidentRecord, origin, pageParam, pag
 	return resp
 }
 
-// resolve marks an incident as resolved. Idempotent: re-resolving an
-// already-resolved record is a no-op (no error, no timestamp drift).
-func (i *IncidentAdminController) resolve(c *fiber.Ctx) error {
-	store := services.Storage()
-	if store == nil {
-		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "storage not configured"})
-	}
-	rec, err := store.GetIncident(c.Params("id"))
-	if errors.Is(err, storage.ErrNotFound) {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "not found"})
-	}
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
-	}
-	if !rec.Resolved {
-		now := time.Now().UTC()
-		rec.Resolved = true
-		rec.ResolvedAt = &now
-		if err := store.SaveIncident(rec); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
-		}
-	}
-	return c.JSON(fiber.Map{
-		"id":          rec.ID,
-		"resolved":    rec.Resolved,
-		"resolved_at": rec.ResolvedAt,
-	})
-}
-
 // getIntakeSettings returns the current runtime intake settings (or the
 // built-in defaults — auto-resolve ON — when none are stored). Same
 // X-Gateway-Secret guard as the other admin settings routes (the group's
@@ -755,6 +790,7 @@ func (i *IncidentAdminController) analyze(c *fiber.Ctx) error {
 	if saveErr := store.SaveAnalysis(analysis); saveErr != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": fmt.Sprintf("save: %v", saveErr)})
 	}
+	services.RecordTimeline(analysisTimelineEntry(analysis))
 
 	status := fiber.StatusOK
 	if runErr != nil {
@@ -848,14 +884,15 @@ func (i *IncidentAdminController) deleteAnalysis(c *fiber.Ctx) error {
 
 // snapshotFromIncident flattens a stored IncidentRecord into the
 // analyze agent's input contract. Service and severity go through the shared
-// extraction so the snapshot matches what the list and the detail show.
+// record helpers so the snapshot matches what the list and the detail show,
+// including an operator's severity edit.
 func snapshotFromIncident(rec *storage.IncidentRecord, requestedBy string) core.AnalyzeIncidentSnapshot {
 	return core.AnalyzeIncidentSnapshot{
 		IncidentID:  rec.ID,
 		Title:       rec.Title,
 		Service:     services.ServiceLabel(rec),
 		Source:      rec.Source,
-		Severity:    services.ExtractSeverity(rec.Content),
+		Severity:    rec.SeverityLabel(),
 		Resolved:    rec.Resolved,
 		CreatedAt:   rec.CreatedAt,
 		AckedAt:     rec.AckedAt,
@@ -0,0 +1,163 @@
+package controllers
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/services"
+	"github.com/VersusControl/versus-incident/pkg/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// titlePager adds IncidentSearchPager to a memory store, matching on a title
+// substring, so the query selection can be exercised without Postgres. It
+// passes Lifecycle through so bulk delete still works.
+type titlePager struct {
+	storage.Provider
+}
+
+func (p titlePager) PurgeOlderThan(domain string, cutoff time.Time) (int, error) {
+	return p.Provider.(storage.Lifecycle).PurgeOlderThan(domain, cutoff)
+}
+
+func (p titlePager) DeleteByID(domain, id string) error {
+	return p.Provider.(storage.Lifecycle).DeleteByID(domain, id)
+}
+
+func (p titlePager) CountIncidentsMatching(string) (storage.IncidentCounts, error) {
+	return storage.IncidentCounts{}, nil
+}
+
+func (p titlePager) CountIncidentsMatchingByStatus(string) (storage.IncidentStatusCounts, error) {
+	return storage.IncidentStatusCounts{}, nil
+}
+
+func (p titlePager) SearchIncidentsPage(query, origin string, offset, limit int) ([]*storage.IncidentRecord, error) {
+	all, err := p.ListIncidents(0)
+	if err != nil {
+		return nil, err
+	}
+	var out []*storage.IncidentRecord
+	for _, r := range filterByOrigin(all, origin) {
+		if strings.Contains(r.Title, query) {
+			out = append(out, r)
+		}
+	}
+	if offset >= len(out) {
+		return nil, nil
+	}
+	return out[offset:min(offset+limit, len(out))], nil
+}
+
+type bulkResult struct {
+	DryRun    bool     `json:"dry_run"`
+	Matched   int      `json:"matched"`
+	IDs       []string `json:"ids"`
+	Changed   []string `json:"changed"`
+	Unchanged int      `json:"unchanged"`
+}
+
+func bulkDo(t *testing.T, app *fiber.App, path, body string) (int, bulkResult) {
+	t.Helper()
+	status, out := timelineDo(t, app, "POST", path, body)
+	var res bulkResult
+	_ = json.Unmarshal(out, &res)
+	return status, res
+}
+
+// TestBulkResolve_DryRunThenConfirm previews a resolve, applies it with the
+// previewed count, and checks the incidents are resolved with a timeline
+// entry each.
+func TestBulkResolve_DryRunThenConfirm(t *testing.T) {
+	app, st := newTimelineApp(t)
+	seedRelations(t, st)
+
+	status, res := bulkDo(t, app, "/api/admin/incidents/bulk/resolve", `{"ids":["inc-1","inc-2","inc-2"]}`)
+	if status != fiber.StatusOK || !res.DryRun || res.Matched != 2 {
+		t.Fatalf("dry run = %d %+v, want a dry run matching 2", status, res)
+	}
+	if rec, _ := st.GetIncident("inc-1"); rec.Resolved {
+		t.Fatal("dry run resolved an incident")
+	}
+
+	status, res = bulkDo(t, app, "/api/admin/incidents/bulk/resolve", `{"ids":["inc-1","inc-2"],"confirm":2,"actor":"alice"}`)
+	if status != fiber.StatusOK || res.DryRun || len(res.Changed) != 2 {
+		t.Fatalf("resolve = %d %+v, want both changed", status, res)
+	}
+	for _, id := range []string{"inc-1", "inc-2"} {
+		rec, _ := st.GetIncident(id)
+		if rec.EffectiveStatus(time.Now()) != storage.StatusResolved {
+			t.Fatalf("%s status = %s, want resolved", id, rec.EffectiveStatus(time.Now()))
+		}
+		entries, _ := st.(storage.Timeline).ListTimeline(id, 0)
+		if len(entries) != 1 || entries[0].Kind != storage.TimelineStatusChange || entries[0].Actor != "alice" {
+			t.Fatalf("%s timeline = %+v, want one status change by alice", id, entries)
+		}
+	}
+
+	_, res = bulkDo(t, app, "/api/admin/incidents/bulk/resolve", `{"ids":["inc-1","inc-2"],"confirm":2}`)
+	if len(res.Changed) != 0 || res.Unchanged != 2 {
+		t.Fatalf("repeat resolve = %+v, want a no-op", res)
+	}
+}
+
+// TestBulk_ConfirmMismatchAndBadSelection covers the refusals: a confirm that
+// no longer matches, no selection, both selections, and a query on a backend
+// without a search pager.
+func TestBulk_ConfirmMismatchAndBadSelection(t *testing.T) {
+	app, st := newTimelineApp(t)
+	seedRelations(t, st)
+
+	if status, res := bulkDo(t, app, "/api/admin/incidents/bulk/delete", `{"ids":["inc-1","inc-2"],"confirm":3}`); status != fiber.StatusConflict || res.Matched != 2 {
+		t.Fatalf("stale confirm = %d %+v, want 409 reporting 2", status, res)
+	}
+	if _, err := st.GetIncident("inc-1"); err != nil {
+		t.Fatalf("refused delete removed inc-1: %v", err)
+	}
+	for _, body := range []string{`{}`, `{"ids":["inc-1"],"query":"DB"}`} {
+		if status, _ := bulkDo(t, app, "/api/admin/incidents/bulk/resolve", body); status != fiber.StatusBadRequest {
+			t.Fatalf("%s: status = %d, want 400", body, status)
+		}
+	}
+	services.SetStorage(plainStorage{st})
+	if status, _ := bulkDo(t, app, "/api/admin/incidents/bulk/resolve", `{"query":"DB"}`); status != fiber.StatusNotImplemented {
+		t.Fatalf("query without a pager: status = %d, want 501", status)
+	}
+}
+
+// TestBulkTagAndDelete_ByQuery selects by query through a search pager, tags
+// the matches, then deletes them.
+func TestBulkTagAndDelete_ByQuery(t *testing.T) {
+	app, st := newTimelineApp(t)
+	seedRelations(t, st)
+	services.SetStorage(titlePager{st})
+
+	_, res := bulkDo(t, app, "/api/admin/incidents/bulk/tag", `{"query":"inc-","add":["storm"]}`)
+	if res.Matched != 2 {
+		t.Fatalf("dry run = %+v, want inc-2 and inc-3", res)
+	}
+	status, res := bulkDo(t, app, "/api/admin/incidents/bulk/tag", `{"query":"inc-","add":["storm"],"confirm":2}`)
+	if status != fiber.StatusOK || len(res.Changed) != 2 {
+		t.Fatalf("tag = %d %+v", status, res)
+	}
+	if rec, _ := st.GetIncident("inc-3"); len(rec.Tags) != 1 || rec.Tags[0] != "storm" {
+		t.Fatalf("inc-3 tags = %v, want [storm]", rec.Tags)
+	}
+	if status, _ := bulkDo(t, app, "/api/admin/incidents/bulk/tag", `{"query":"inc-"}`); status != fiber.StatusBadRequest {
+		t.Fatalf("tag without tags: status = %d, want 400", status)
+	}
+
+	status, res = bulkDo(t, app, "/api/admin/incidents/bulk/delete", `{"query":"inc-","confirm":2}`)
+	if status != fiber.StatusOK || len(res.Changed) != 2 {
+		t.Fatalf("delete = %d %+v", status, res)
+	}
+	if _, err := st.GetIncident("inc-2"); err != storage.ErrNotFound {
+		t.Fatalf("inc-2 after delete: %v, want ErrNotFound", err)
+	}
+	if _, err := st.GetIncident("inc-1"); err != nil {
+		t.Fatalf("unmatched inc-1 deleted: %v", err)
+	}
+}
@@ -0,0 +1,33 @@
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/VersusControl/versus-incident/pkg/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// TestExport_StreamsCSV exports the seeded incident as CSV and checks the
+// refusals: an unknown format and a search on a backend without search.
+func TestExport_StreamsCSV(t *testing.T) {
+	app, st := newTimelineApp(t)
+
+	status, out := timelineDo(t, app, "GET", "/api/admin/incidents/export?window=24h", "")
+	if status != fiber.StatusOK {
+		t.Fatalf("export: status = %d, body %s", status, out)
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "id,created_at,") || !strings.HasPrefix(lines[1], "inc-1,") {
+		t.Fatalf("export body = %q, want a header and inc-1", out)
+	}
+
+	if status, _ := timelineDo(t, app, "GET", "/api/admin/incidents/export?format=xlsx", ""); status != fiber.StatusBadRequest {
+		t.Fatalf("unknown format: status = %d, want 400", status)
+	}
+	services.SetStorage(plainStorage{st})
+	if status, _ := timelineDo(t, app, "GET", "/api/admin/incidents/export?q=db", ""); status != fiber.StatusNotImplemented {
+		t.Fatalf("search without a pager: status = %d, want 501", status)
+	}
+}
@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/VersusControl/versus-incident/pkg/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// TestUpdateFields_EditsAndRecordsTimeline patches every editable field,
+// checks the stored record, and checks a second identical patch is a no-op
+// that adds no timeline entry.
+func TestUpdateFields_EditsAndRecordsTimeline(t *testing.T) {
+	app, st := newTimelineApp(t)
+
+	body := `{"severity":"critical","priority":"p1","labels":{"env":"prod"},"tags":["db","db","api"],
+		"custom_fields":[{"key":"customers","type":"number","value":42}],"actor":"alice"}`
+	status, out := timelineDo(t, app, "PATCH", "/api/admin/incidents/inc-1", body)
+	if status != fiber.StatusOK {
+		t.Fatalf("PATCH: status = %d, body %s", status, out)
+	}
+	rec, _ := st.GetIncident("inc-1")
+	if rec.Severity != "critical" || rec.Priority != storage.PriorityP1 ||
+		rec.Labels["env"] != "prod" || len(rec.Tags) != 2 || len(rec.CustomFields) != 1 {
+		t.Fatalf("stored record = %+v", rec)
+	}
+
+	entries, _ := st.(storage.Timeline).ListTimeline("inc-1", 0)
+	if len(entries) != 1 || entries[0].Kind != storage.TimelineFieldChange || entries[0].Actor != "alice" {
+		t.Fatalf("timeline = %+v, want one field_change by alice", entries)
+	}
+
+	status, out = timelineDo(t, app, "PATCH", "/api/admin/incidents/inc-1", body)
+	var resp struct {
+		Changed bool `json:"changed"`
+	}
+	_ = json.Unmarshal(out, &resp)
+	if status != fiber.StatusOK || resp.Changed {
+		t.Fatalf("repeat PATCH: status %d body %s, want 200 unchanged", status, out)
+	}
+	if entries, _ := st.(storage.Timeline).ListTimeline("inc-1", 0); len(entries) != 1 {
+		t.Fatalf("repeat PATCH added a timeline entry: %d entries", len(entries))
+	}
+
+	// An explicit empty value clears the field; an omitted one is kept.
+	timelineDo(t, app, "PATCH", "/api/admin/incidents/inc-1", `{"tags":[]}`)
+	rec, _ = st.GetIncident("inc-1")
+	if rec.Tags != nil || rec.Priority != storage.PriorityP1 {
+		t.Fatalf("after clearing tags: tags=%v priority=%q", rec.Tags, rec.Priority)
+	}
+}
+
+func TestUpdateFields_RejectsInvalidInput(t *testing.T) {
+	app, st := newTimelineApp(t)
+
+	for _, body := range []string{
+		`{"priority":"urgent"}`,
+		`{"labels":{"bad key":"x"}}`,
+		`{"custom_fields":[{"key":"n","type":"number","value":"many"}]}`,
+		// One bad field fails the whole request.
+		`{"severity":"high","custom_fields":[{"key":"x","type":"color","value":"red"}]}`,
+	} {
+		if status, out := timelineDo(t, app, "PATCH", "/api/admin/incidents/inc-1", body); status != fiber.StatusBadRequest {
+			t.Errorf("PATCH %s: status = %d (body %s), want 400", body, status, out)
+		}
+	}
+	if rec, _ := st.GetIncident("inc-1"); rec.Severity != "" {
+		t.Errorf("rejected request wrote severity %q", rec.Severity)
+	}
+	if status, _ := timelineDo(t, app, "PATCH", "/api/admin/incidents/nope", `{"priority":"P2"}`); status != fiber.StatusNotFound {
+		t.Errorf("PATCH unknown: status = %d, want 404", status)
+	}
+}
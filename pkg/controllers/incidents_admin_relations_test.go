@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// seedRelations adds inc-2 and inc-3 alongside newTimelineApp's inc-1.
+func seedRelations(t *testing.T, st storage.Provider) {
+	t.Helper()
+	for _, id := range []string{"inc-2", "inc-3"} {
+		if err := st.SaveIncident(&storage.IncidentRecord{ID: id, Title: id, CreatedAt: time.Now().UTC()}); err != nil {
+			t.Fatalf("SaveIncident: %v", err)
+		}
+	}
+}
+
+// TestMerge_ClosesDuplicatesIntoPrimary merges two incidents into inc-1 and
+// checks they are resolved with a merged_into reference, that the primary
+// lists them as duplicates, and that a repeat merge is a no-op.
+func TestMerge_ClosesDuplicatesIntoPrimary(t *testing.T) {
+	app, st := newTimelineApp(t)
+	seedRelations(t, st)
+
+	status, out := timelineDo(t, app, "POST", "/api/admin/incidents/inc-1/merge", `{"duplicate_ids":["inc-2","inc-3","inc-2"],"actor":"alice"}`)
+	if status != fiber.StatusOK {
+		t.Fatalf("merge: status = %d, body %s", status, out)
+	}
+	for _, id := range []string{"inc-2", "inc-3"} {
+		rec, _ := st.GetIncident(id)
+		if rec.MergedInto != "inc-1" || !rec.Resolved {
+			t.Fatalf("%s after merge = %+v, want resolved and merged into inc-1", id, rec)
+		}
+	}
+	if primary, _ := st.GetIncident("inc-1"); primary.Resolved {
+		t.Fatal("merge resolved the primary")
+	}
+
+	_, out = timelineDo(t, app, "GET", "/api/admin/incidents/inc-1/relations", "")
+	var rel struct {
+		Duplicates []string         `json:"duplicates"`
+		Cluster    []map[string]any `json:"cluster"`
+	}
+	_ = json.Unmarshal(out, &rel)
+	if len(rel.Duplicates) != 2 || len(rel.Cluster) != 2 {
+		t.Fatalf("relations = %s, want two duplicates in the cluster", out)
+	}
+
+	_, out = timelineDo(t, app, "POST", "/api/admin/incidents/inc-1/merge", `{"duplicate_ids":["inc-2"]}`)
+	var again struct {
+		Merged    []string `json:"merged"`
+		Unchanged []string `json:"unchanged"`
+	}
+	_ = json.Unmarshal(out, &again)
+	if len(again.Merged) != 0 || len(again.Unchanged) != 1 {
+		t.Fatalf("repeat merge = %s, want inc-2 unchanged", out)
+	}
+}
+
+func TestMerge_RejectsInvalidRequests(t *testing.T) {
+	app, st := newTimelineApp(t)
+	seedRelations(t, st)
+
+	cases := []struct {
+		path, body string
+		want       int
+	}{
+		{"/api/admin/incidents/inc-1/merge", `{"duplicate_ids":[]}`, fiber.StatusBadRequest},
+		{"/api/admin/incidents/inc-1/merge", `{"duplicate_ids":["inc-1"]}`, fiber.StatusConflict},
+		{"/api/admin/incidents/inc-1/merge", `{"duplicate_ids":["inc-2","nope"]}`, fiber.StatusNotFound},
+		{"/api/admin/incidents/nope/merge", `{"duplicate_ids":["inc-2"]}`, fiber.StatusNotFound},
+	}
+	for _, tc := range cases {
+		if status, out := timelineDo(t, app, "POST", tc.path, tc.body); status != tc.want {
+			t.Errorf("POST %s %s: status = %d (body %s), want %d", tc.path, tc.body, status, out, tc.want)
+		}
+	}
+	// The request naming an unknown duplicate merged nothing.
+	if rec, _ := st.GetIncident("inc-2"); rec.MergedInto != "" {
+		t.Fatalf("failed merge still merged inc-2 into %q", rec.MergedInto)
+	}
+}
+
+func TestLinksAndParent(t *testing.T) {
+	app, st := newTimelineApp(t)
+	seedRelations(t, st)
+
+	if status, out := timelineDo(t, app, "POST", "/api/admin/incidents/inc-1/links", `{"related_id":"inc-2"}`); status != fiber.StatusOK {
+		t.Fatalf("link: status = %d, body %s", status, out)
+	}
+	if rec, _ := st.GetIncident("inc-2"); len(rec.RelatedIDs) != 1 || rec.RelatedIDs[0] != "inc-1" {
+		t.Fatalf("inc-2 related_ids = %v, want the back-link to inc-1", rec.RelatedIDs)
+	}
+	if status, _ := timelineDo(t, app, "DELETE", "/api/admin/incidents/inc-2/links/inc-1", ""); status != fiber.StatusOK {
+		t.Fatalf("unlink: status = %d", status)
+	}
+	if rec, _ := st.GetIncident("inc-1"); rec.RelatedIDs != nil {
+		t.Fatalf("inc-1 related_ids after unlink = %v, want nil", rec.RelatedIDs)
+	}
+
+	if status, out := timelineDo(t, app, "PUT", "/api/admin/incidents/inc-2/parent", `{"parent_id":"inc-1"}`); status != fiber.StatusOK {
+		t.Fatalf("set parent: status = %d, body %s", status, out)
+	}
+	// inc-1 under its own child would be a cycle.
+	if status, _ := timelineDo(t, app, "PUT", "/api/admin/incidents/inc-1/parent", `{"parent_id":"inc-2"}`); status != fiber.StatusConflict {
+		t.Fatalf("cyclic parent: status = %d, want 409", status)
+	}
+	if status, _ := timelineDo(t, app, "PUT", "/api/admin/incidents/inc-2/parent", `{"parent_id":""}`); status != fiber.StatusOK {
+		t.Fatalf("clear parent: status = %d", status)
+	}
+	if rec, _ := st.GetIncident("inc-2"); rec.ParentID != "" {
+		t.Fatalf("parent after clear = %q", rec.ParentID)
+	}
+
+	entries, _ := st.(storage.Timeline).ListTimeline("inc-2", 0)
+	if len(entries) != 4 {
+		t.Errorf("inc-2 timeline has %d entries, want link, unlink, parent set and parent cleared", len(entries))
+	}
+}
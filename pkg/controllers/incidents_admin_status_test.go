@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/VersusControl/versus-incident/pkg/core"
+	"github.com/VersusControl/versus-incident/pkg/storage"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// TestStatusTransitions_DriveTheStateMachine walks an incident through every
+// admin transition route and checks the stored status after each one.
+func TestStatusTransitions_DriveTheStateMachine(t *testing.T) {
+	app, st := newTimelineApp(t)
+
+	steps := []struct {
+		route, body, want string
+	}{
+		{"ack", "", storage.StatusAcknowledged},
+		{"investigate", "", storage.StatusInvestigating},
+		{"mitigate", "", storage.StatusMitigated},
+		{"snooze", `{"duration":"30m"}`, storage.StatusSnoozed},
+		{"resolve", `{"actor":"alice"}`, storage.StatusResolved},
+		{"reopen", "", storage.StatusReopened},
+	}
+	for _, s := range steps {
+		status, body := timelineDo(t, app, "POST", "/api/admin/incidents/inc-1/"+s.route, s.body)
+		if status != fiber.StatusOK {
+			t.Fatalf("%s: status = %d, body %s", s.route, status, body)
+		}
+		var resp struct {
+			Status  string `json:"status"`
+			Changed bool   `json:"changed"`
+		}
+		_ = json.Unmarshal(body, &resp)
+		if resp.Status != s.want || !resp.Changed {
+			t.Fatalf("%s: response %s, want status %s changed", s.route, body, s.want)
+		}
+		rec, _ := st.GetIncident("inc-1")
+		if rec.Status != s.want {
+			t.Fatalf("%s: stored status = %q, want %q", s.route, rec.Status, s.want)
+		}
+	}
+
+	entries, _ := st.(storage.Timeline).ListTimeline("inc-1", 0)
+	if len(entries) != len(steps) {
+		t.Errorf("timeline has %d entries, want one per transition (%d)", len(entries), len(steps))
+	}
+}
+
+func TestStatusTransitions_RejectsInvalidRequests(t *testing.T) {
+	app, _ := newTimelineApp(t)
+
+	// reopen from triggered is not an edge.
+	if status, body := timelineDo(t, app, "POST", "/api/admin/incidents/inc-1/reopen", ""); status != fiber.StatusConflict {
+		t.Errorf("reopen triggered: status = %d, want 409 (body %s)", status, body)
+	}
+	// snooze needs a wake-up time in the future.
+	for _, body := range []string{"", `{"duration":"-5m"}`, `{"until":"2001-01-01T00:00:00Z"}`, `{"until":"tomorrow"}`} {
+		if status, _ := timelineDo(t, app, "POST", "/api/admin/incidents/inc-1/snooze", body); status != fiber.StatusBadRequest {
+			t.Errorf("snooze %q: status = %d, want 400", body, status)
+		}
+	}
+	if status, _ := timelineDo(t, app, "POST", "/api/admin/incidents/nope/ack", ""); status != fiber.StatusNotFound {
+		t.Errorf("ack unknown: status = %d, want 404", status)
+	}
+
+	// Acking twice is an idempotent no-op.
+	timelineDo(t, app, "POST", "/api/admin/incidents/inc-1/ack", "")
+	status, body := timelineDo(t, app, "POST", "/api/admin/incidents/inc-1/ack", "")
+	var resp struct {
+		Changed bool `json:"changed"`
+	}
+	_ = json.Unmarshal(body, &resp)
+	if status != fiber.StatusOK || resp.Changed {
+		t.Errorf("second ack: status %d body %s, want 200 unchanged", status, body)
+	}
+}
+
+// pendingEscalations stands in for the on-call workflow's Redis: a key per
+// incident still waiting to page. Only the calls Ack makes are implemented.
+type pendingEscalations struct {
+	redis.UniversalClient
+	keys map[string]bool
+}
+
+func (p *pendingEscalations) Exists(ctx context.Context, keys ...string) *redis.IntCmd {
+	cmd := redis.NewIntCmd(ctx)
+	var n int64
+	for _, k := range keys {
+		if p.keys[k] {
+			n++
+		}
+	}
+	cmd.SetVal(n)
+	return cmd
+}
+
+func (p *pendingEscalations) Del(ctx context.Context, keys ...string) *redis.IntCmd {
+	cmd := redis.NewIntCmd(ctx)
+	for _, k := range keys {
+		delete(p.keys, k)
+	}
+	cmd.SetVal(int64(len(keys)))
+	return cmd
+}
+
+// TestStatusTransitions_EngagingStopsEscalation checks that any transition
+// out of triggered into an engaged or closed state cancels the pending
+// on-call escalation, not only ack, and that a snooze leaves it pending.
+func TestStatusTransitions_EngagingStopsEscalation(t *testing.T) {
+	cases := []struct {
+		route, body string
+		stopped     bool
+	}{
+		{"resolve", "", true},
+		{"investigate", "", true},
+		{"mitigate", "", true},
+		{"ack", "", true},
+		{"snooze", `{"duration":"30m"}`, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.route, func(t *testing.T) {
+			app, _ := newTimelineApp(t)
+			pending := &pendingEscalations{keys: map[string]bool{"inc-1": true}}
+			core.SetOnCallWorkflow(core.NewOnCallWorkflow(pending, nil))
+			t.Cleanup(func() { core.SetOnCallWorkflow(nil) })
+
+			if status, body := timelineDo(t, app, "POST", "/api/admin/incidents/inc-1/"+tc.route, tc.body); status != fiber.StatusOK {
+				t.Fatalf("status = %d, body %s", status, body)
+			}
+			if stopped := !pending.keys["inc-1"]; stopped != tc.stopped {
+				t.Errorf("escalation stopped = %v, want %v", stopped, tc.stopped)
+			}
+		})
+	}
+}
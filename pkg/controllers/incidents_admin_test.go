@@ -36,9 +36,16 @@ func TestResolveRouteRegistered(t *testing.T) {
 		{"GET", "/api/admin/incidents/intake-settings"},
 		{"PUT", "/api/admin/incidents/intake-settings"},
 		{"GET", "/api/admin/incidents/:id"},
+		{"POST", "/api/admin/incidents/:id/ack"},
+		{"POST", "/api/admin/incidents/:id/investigate"},
+		{"POST", "/api/admin/incidents/:id/mitigate"},
 		{"POST", "/api/admin/incidents/:id/resolve"},
+		{"POST", "/api/admin/incidents/:id/reopen"},
+		{"POST", "/api/admin/incidents/:id/snooze"},
 		{"POST", "/api/admin/incidents/:id/analyze"},
 		{"GET", "/api/admin/incidents/:id/analyses"},
+		{"GET", "/api/admin/incidents/:id/timeline"},
+		{"POST", "/api/admin/incidents/:id/timeline"},
 		{"GET", "/api/admin/capabilities/"},
 		{"GET", "/api/admin/analyses/"},
 		{"GET", "/api/admin/analyses/:analysis_id"},
@@ -58,6 +65,12 @@ func TestResolveRouteRegistered(t *testing.T) {
 	}
 }
 
+// plainStorage hides every optional capability of the wrapped backend, like
+// the Redis backend, which has no search.
+type plainStorage struct {
+	storage.Provider
+}
+
 // searcherStorage is a storage.Provider that also implements the optional
 // storage.Searcher capability. The embedded interface satisfies Provider;
 // only the two search methods are exercised.
@@ -76,8 +89,8 @@ func (s searcherStorage) SearchAnalyses(string, int) ([]*storage.AnalysisRecord,
 
 // TestCapabilitiesReflectsSearcher verifies the capabilities probe reports
 // search support exactly when the active storage backend implements
-// storage.Searcher — true for a searcher-capable backend, false for the
-// memory backend (the same path file storage takes).
+// storage.Searcher — true for a searcher-capable backend, including the
+// memory backend, false for one without search.
 func TestCapabilitiesReflectsSearcher(t *testing.T) {
 	t.Cleanup(func() { services.SetStorage(nil) })
 	// capabilities reads the global config for the report block, so the global
@@ -94,7 +107,8 @@ func TestCapabilitiesReflectsSearcher(t *testing.T) {
 		prov storage.Provider
 		want bool
 	}{
-		{"memory backend has no search", storage.NewMemory(), false},
+		{"backend without search", plainStorage{storage.NewMemory()}, false},
+		{"memory backend has search", storage.NewMemory(), true},
 		{"searcher backend has search", searcherStorage{Provider: storage.NewMemory()}, true},
 	}
 	for _, tc := range cases {
@@ -305,7 +319,7 @@ func TestSearchUnsupportedReturns501(t *testing.T) {
 	app := fiber.New()
 	app.Get("/search", ctrl.search)
 
-	services.SetStorage(storage.NewMemory())
+	services.SetStorage(plainStorage{storage.NewMemory()})
 	resp, err := app.Test(httptest.NewRequest("GET", "/search?q=db", nil))
 	if err != nil {
 		t.Fatalf("app.Test: %v", err)
@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/VersusControl/versus-incident/pkg/services"
+	"github.com/VersusControl/versus-incident/pkg/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const timelineSecret = "test-gateway-secret"
+
+func newTimelineApp(t *testing.T) (*fiber.App, storage.Provider) {
+	t.Helper()
+	loadGatewayConfig(t, timelineSecret)
+	config.GetConfig().GatewaySecret = timelineSecret
+	st := storage.NewMemory()
+	services.SetStorage(st)
+	t.Cleanup(func() { services.SetStorage(nil) })
+	if err := st.SaveIncident(&storage.IncidentRecord{ID: "inc-1", Title: "DB down", CreatedAt: time.Now().UTC()}); err != nil {
+		t.Fatalf("SaveIncident: %v", err)
+	}
+
+	app := fiber.New()
+	api := app.Group("/api")
+	NewIncidentAdminController().Register(api)
+	return app, st
+}
+
+func timelineDo(t *testing.T, app *fiber.App, method, path, body string) (int, []byte) {
+	t.Helper()
+	var r io.Reader
+	if body != "" {
+		r = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, path, r)
+	req.Header.Set("X-Gateway-Secret", timelineSecret)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	out, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, out
+}
+
+// TestTimeline_NoteAndResolveAppearInOrder posts a note, resolves the
+// incident, and reads the timeline back: both entries are present, oldest
+// first, with the actors the requests named.
+func TestTimeline_NoteAndResolveAppearInOrder(t *testing.T) {
+	app, _ := newTimelineApp(t)
+
+	status, body := timelineDo(t, app, "POST", "/api/admin/incidents/inc-1/timeline", `{"body":"restarting the primary","actor":"alice"}`)
+	if status != fiber.StatusCreated {
+		t.Fatalf("POST status = %d, body %s", status, body)
+	}
+	var created storage.TimelineEntry
+	_ = json.Unmarshal(body, &created)
+	if created.Kind != storage.TimelineNote || created.Actor != "alice" || created.ID == "" {
+		t.Fatalf("created = %+v, want a note by alice with an id", created)
+	}
+
+	if status, body := timelineDo(t, app, "POST", "/api/admin/incidents/inc-1/resolve", `{"actor":"bob"}`); status != fiber.StatusOK {
+		t.Fatalf("resolve status = %d, body %s", status, body)
+	}
+
+	status, body = timelineDo(t, app, "GET", "/api/admin/incidents/inc-1/timeline", "")
+	if status != fiber.StatusOK {
+		t.Fatalf("GET status = %d, body %s", status, body)
+	}
+	var got struct {
+		Entries []storage.TimelineEntry `json:"entries"`
+	}
+	_ = json.Unmarshal(body, &got)
+	if len(got.Entries) != 2 {
+		t.Fatalf("entries = %+v, want 2", got.Entries)
+	}
+	if got.Entries[0].Kind != storage.TimelineNote || got.Entries[0].Body != "restarting the primary" {
+		t.Errorf("first entry = %+v, want the note", got.Entries[0])
+	}
+	if got.Entries[1].Kind != storage.TimelineStatusChange || got.Entries[1].Actor != "bob" || got.Entries[1].Data["to"] != "resolved" {
+		t.Errorf("second entry = %+v, want bob's resolve", got.Entries[1])
+	}
+}
+
+// TestTimeline_RejectsServerKindsAndBadInput proves operators can only write
+// notes and comments, never forge a server-emitted event.
+func TestTimeline_RejectsServerKindsAndBadInput(t *testing.T) {
+	app, _ := newTimelineApp(t)
+
+	cases := []struct {
+		name, path, body string
+		want             int
+	}{
+		{"server kind", "/api/admin/incidents/inc-1/timeline", `{"kind":"status_change","body":"resolved"}`, fiber.StatusBadRequest},
+		{"empty body", "/api/admin/incidents/inc-1/timeline", `{"kind":"comment","body":"  "}`, fiber.StatusBadRequest},
+		{"invalid json", "/api/admin/incidents/inc-1/timeline", `{`, fiber.StatusBadRequest},
+		{"unknown incident", "/api/admin/incidents/nope/timeline", `{"body":"hi"}`, fiber.StatusNotFound},
+	}
+	for _, tc := range cases {
+		if status, body := timelineDo(t, app, "POST", tc.path, tc.body); status != tc.want {
+			t.Errorf("%s: status = %d, want %d (body %s)", tc.name, status, tc.want, body)
+		}
+	}
+
+	if status, _ := timelineDo(t, app, "GET", "/api/admin/incidents/nope/timeline", ""); status != fiber.StatusNotFound {
+		t.Errorf("GET unknown incident status = %d, want 404", status)
+	}
+}
+
+// TestTimeline_UnsupportedBackend returns 501 when the backend lacks the
+// storage.Timeline capability instead of pretending the timeline is empty.
+func TestTimeline_UnsupportedBackend(t *testing.T) {
+	app, st := newTimelineApp(t)
+	services.SetStorage(searcherStorage{Provider: st})
+
+	if status, _ := timelineDo(t, app, "GET", "/api/admin/incidents/inc-1/timeline", ""); status != fiber.StatusNotImplemented {
+		t.Errorf("status = %d, want 501", status)
+	}
+}
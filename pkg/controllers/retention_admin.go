@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/VersusControl/versus-incident/pkg/middleware"
+	"github.com/VersusControl/versus-incident/pkg/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RetentionAdminController exposes a dry run of the storage.retention
+// policy: how many records the next purge would delete, per domain. It never
+// deletes. Same X-Gateway-Secret guard as the rest of the admin surface.
+type RetentionAdminController struct{}
+
+// NewRetentionAdminController returns a controller. No state of its own; the
+// policy is read from config and storage via services.Storage().
+func NewRetentionAdminController() *RetentionAdminController {
+	return &RetentionAdminController{}
+}
+
+// Register attaches the endpoint under /api/admin/retention.
+//
+//	GET /api/admin/retention/preview  would-delete counts for the configured policy
+//
+// ?incidents_days=, ?analyses_days=, ?blobs_days= and ?resolved_only= override
+// the configured policy for this preview only, so an operator can size a
+// change before making it.
+func (rc *RetentionAdminController) Register(router fiber.Router) {
+	g := router.Group("/admin/retention", rc.authMiddleware)
+	g.Get("/preview", rc.preview)
+}
+
+// authMiddleware reuses the agent gateway secret (constant-time compare),
+// mirroring the incident admin surface.
+func (rc *RetentionAdminController) authMiddleware(c *fiber.Ctx) error {
+	if middleware.RequestAuthorized(c) {
+		return c.Next()
+	}
+	cfg := config.GetConfig()
+	expected := cfg.GatewaySecret
+	got := c.Get("X-Gateway-Secret")
+	if expected == "" || !secureEqual(got, expected) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+	return c.Next()
+}
+
+func (rc *RetentionAdminController) preview(c *fiber.Ctx) error {
+	store := services.Storage()
+	if store == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "storage not configured"})
+	}
+	cfg := config.GetConfig().Storage.Retention
+	if err := retentionOverrides(c, &cfg); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	policy := services.RetentionPolicyFrom(cfg)
+	now := time.Now().UTC()
+	results, err := services.PreviewRetention(store, policy, now)
+	if errors.Is(err, services.ErrRetentionUnsupported) {
+		return c.Status(fiber.StatusNotImplemented).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{
+		"dry_run":       true,
+		"enabled":       config.GetConfig().Storage.Retention.Enable,
+		"interval":      services.RetentionInterval(cfg).String(),
+		"resolved_only": cfg.ResolvedOnly,
+		"as_of":         now,
+		"domains":       results,
+	})
+}
+
+// retentionOverrides applies the preview's query overrides to cfg.
+func retentionOverrides(c *fiber.Ctx, cfg *config.StorageRetentionConfig) error {
+	for key, dst := range map[string]*int{
+		"incidents_days": &cfg.IncidentsDays,
+		"analyses_days":  &cfg.AnalysesDays,
+		"blobs_days":     &cfg.BlobsDays,
+	} {
+		raw := strings.TrimSpace(c.Query(key))
+		if raw == "" {
+			continue
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			return errors.New("invalid " + key + " (want a whole number of days, 0 to keep forever)")
+		}
+		*dst = n
+	}
+	if raw := strings.TrimSpace(c.Query("resolved_only")); raw != "" {
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return errors.New("invalid resolved_only (want true or false)")
+		}
+		cfg.ResolvedOnly = b
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/VersusControl/versus-incident/pkg/services"
+	"github.com/VersusControl/versus-incident/pkg/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type retentionPreview struct {
+	DryRun  bool `json:"dry_run"`
+	Enabled bool `json:"enabled"`
+	Domains []struct {
+		Domain string `json:"domain"`
+		Count  int    `json:"count"`
+	} `json:"domains"`
+}
+
+// TestRetentionPreview_CountsWithoutDeleting previews the configured policy
+// and a query override, and checks nothing is deleted.
+func TestRetentionPreview_CountsWithoutDeleting(t *testing.T) {
+	app := newAnalyticsApp(t)
+	NewRetentionAdminController().Register(app.Group("/api"))
+	st := services.Storage()
+	if err := st.SaveIncident(&storage.IncidentRecord{ID: "ancient", CreatedAt: time.Now().UTC().Add(-90 * 24 * time.Hour)}); err != nil {
+		t.Fatalf("SaveIncident: %v", err)
+	}
+	config.GetConfig().Storage.Retention = config.StorageRetentionConfig{IncidentsDays: 30}
+	t.Cleanup(func() { config.GetConfig().Storage.Retention = config.StorageRetentionConfig{} })
+
+	status, body := analyticsGet(t, app, "/api/admin/retention/preview", true)
+	var got retentionPreview
+	_ = json.Unmarshal(body, &got)
+	if status != fiber.StatusOK || !got.DryRun || got.Enabled || len(got.Domains) != 1 ||
+		got.Domains[0].Domain != storage.DomainIncidents || got.Domains[0].Count != 1 {
+		t.Fatalf("preview = %d %s", status, body)
+	}
+	if _, err := st.GetIncident("ancient"); err != nil {
+		t.Fatalf("preview deleted ancient: %v", err)
+	}
+
+	_, body = analyticsGet(t, app, "/api/admin/retention/preview?incidents_days=0&analyses_days=7", true)
+	got = retentionPreview{}
+	_ = json.Unmarshal(body, &got)
+	if len(got.Domains) != 1 || got.Domains[0].Domain != storage.DomainAnalyses {
+		t.Fatalf("override preview = %s, want analyses only", body)
+	}
+
+	for _, q := range []string{"?incidents_days=-1", "?blobs_days=x", "?resolved_only=maybe"} {
+		if status, _ := analyticsGet(t, app, "/api/admin/retention/preview"+q, true); status != fiber.StatusBadRequest {
+			t.Fatalf("%s: status = %d, want 400", q, status)
+		}
+	}
+	if status, _ := analyticsGet(t, app, "/api/admin/retention/preview", false); status != fiber.StatusUnauthorized {
+		t.Fatalf("no secret: status = %d, want 401", status)
+	}
+}
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"errors"
+	"slices"
 	"time"
 
 	"github.com/VersusControl/versus-incident/pkg/config"
@@ -42,6 +43,7 @@ func NewTeamsAdminController(s *teams.Store) *TeamsAdminController {
 //	DELETE /api/admin/teams/:id            delete
 //
 //	POST   /api/admin/incidents/:id/assign assign team + members
+//	POST   /api/admin/incidents/bulk/assign assign many incidents (ids or query; dry run, then confirm)
 func (c *TeamsAdminController) Register(router fiber.Router) {
 	m := router.Group("/admin/members", c.authMiddleware, c.requireStore)
 	m.Get("/", c.listMembers)
@@ -59,6 +61,8 @@ func (c *TeamsAdminController) Register(router fiber.Router) {
 
 	// Mounted as a sibling of /admin/incidents so the incidents admin
 	// controller can stay focused on read-only history.
+	// bulk/assign MUST precede :id/assign so "bulk" is not taken as an id.
+	router.Post("/admin/incidents/bulk/assign", c.authMiddleware, c.requireStore, c.bulkAssignIncidents)
 	router.Post("/admin/incidents/:id/assign", c.authMiddleware, c.requireStore, c.assignIncident)
 }
 
@@ -217,6 +221,8 @@ type assignPayload struct {
 	// existing assignment alone) from `"team_id": ""` / `[]` (clear).
 	TeamID    *string   `json:"team_id"`
 	MemberIDs *[]string `json:"member_ids"`
+	// Actor names the operator on the timeline's assignment entry.
+	Actor string `json:"actor"`
 }
 
 func (c *TeamsAdminController) assignIncident(ctx *fiber.Ctx) error {
@@ -251,6 +257,7 @@ func (c *TeamsAdminController) assignIncident(ctx *fiber.Ctx) error {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
+	fromTeam, fromMembers := rec.AssignedTeamID, rec.AssignedMemberIDs
 	if p.TeamID != nil {
 		rec.AssignedTeamID = *p.TeamID
 	}
@@ -266,6 +273,21 @@ func (c *TeamsAdminController) assignIncident(ctx *fiber.Ctx) error {
 	if err := store.SaveIncident(rec); err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
+	if rec.AssignedTeamID != fromTeam || !slices.Equal(rec.AssignedMemberIDs, fromMembers) {
+		services.RecordTimeline(&storage.TimelineEntry{
+			OrgID:      rec.OrgID,
+			IncidentID: rec.ID,
+			Kind:       storage.TimelineAssignment,
+			Actor:      timelineActor(p.Actor),
+			Body:       "Assignment changed",
+			Data: map[string]interface{}{
+				"from_team_id":    fromTeam,
+				"from_member_ids": fromMembers,
+				"to_team_id":      rec.AssignedTeamID,
+				"to_member_ids":   rec.AssignedMemberIDs,
+			},
+		})
+	}
 	return ctx.JSON(fiber.Map{
 		"id":                  rec.ID,
 		"assigned_team_id":    rec.AssignedTeamID,
@@ -274,6 +296,42 @@ func (c *TeamsAdminController) assignIncident(ctx *fiber.Ctx) error {
 	})
 }
 
+// bulkAssignIncidents applies one assignment to many incidents through the
+// shared bulk flow (see runBulk). References are validated up front, as for
+// the single-incident assign.
+func (c *TeamsAdminController) bulkAssignIncidents(ctx *fiber.Ctx) error {
+	validate := func(body *bulkRequest) error {
+		if body.TeamID == nil && body.MemberIDs == nil {
+			return errors.New("team_id or member_ids is required")
+		}
+		if body.TeamID != nil && *body.TeamID != "" && !c.store.TeamExists(*body.TeamID) {
+			return errors.New("unknown team_id")
+		}
+		if body.MemberIDs != nil {
+			return c.store.ValidateMemberIDs(*body.MemberIDs)
+		}
+		return nil
+	}
+	return runBulk(ctx, auditActionBulkAssigned, validate, func(store storage.Provider, ids []string, body *bulkRequest) ([]*storage.IncidentRecord, error) {
+		recs, err := storage.AssignIncidents(store, ids, body.TeamID, body.MemberIDs)
+		for _, rec := range recs {
+			services.RecordTimeline(&storage.TimelineEntry{
+				OrgID:      rec.OrgID,
+				IncidentID: rec.ID,
+				Kind:       storage.TimelineAssignment,
+				Actor:      timelineActor(body.Actor),
+				Body:       "Assignment changed",
+				Data: map[string]interface{}{
+					"to_team_id":    rec.AssignedTeamID,
+					"to_member_ids": rec.AssignedMemberIDs,
+					"bulk":          true,
+				},
+			})
+		}
+		return recs, err
+	})
+}
+
 // --- helpers ---------------------------------------------------------------
 
 func mapStoreErr(ctx *fiber.Ctx, err error) error {
@@ -110,6 +110,42 @@ type SourceCommitter interface {
 	Commit(ctx context.Context) error
 }
 
+// SourceCommitHolder is the OPTIONAL capability of a SourceCommitter whose
+// delivery state is a READ POSITION — a partition offset, a byte offset in a
+// file or a disk queue — rather than a set of ids.
+//
+// A pipeline stage can keep signals a Pull returned without passing them on
+// yet: the multiline stage holds the lines of an event that is still being
+// assembled. Committing the position past those lines would make a restart
+// skip them. So before each Commit the worker calls HoldCommit with the
+// signals still held — the first one of each open event — and Commit stops
+// short of the earliest of them. An empty held lifts the hold. A restart
+// then re-reads the held lines, plus whatever was read after them: bounded
+// duplicates, never loss.
+//
+// Sources whose position is the worker's poll cursor do not implement this.
+type SourceCommitHolder interface {
+	HoldCommit(held []Signal)
+}
+
+// SourceListener is the OPTIONAL capability of a SignalSource that must be
+// STARTED once before it delivers anything: a PUSH-based source that receives
+// signals over the network (the OTLP and syslog receivers), or a consumer that
+// joins a group and has records streamed to it (Kafka). Pull only drains what
+// arrived in between.
+//
+// Listen binds the source's endpoints (or joins its group) and serves them
+// until ctx is done; it returns once they are up, or with the error that kept
+// them from starting. The worker calls it once, before its first tick.
+// Construction never starts anything, so a source built for another purpose —
+// the analyze-mode log reader builds its own copy of every source — cannot
+// collide with the worker's listener or take partitions from its group member,
+// and the reader skips listeners because there is no history to read back
+// from them.
+type SourceListener interface {
+	Listen(ctx context.Context) error
+}
+
 // AgentVerdict is the classification a Detector pipeline assigns to a batch
 // of signals that share a fingerprint.
 type AgentVerdict int
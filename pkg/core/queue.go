@@ -3,3 +3,10 @@ package core
 type QueueListener interface {
 	StartListening(handler func(content *map[string]interface{}) error) error
 }
+
+// QueueSourceNamer is implemented by listeners that name the transport their
+// messages arrive on. The name becomes the incident's "incident_source"
+// hint, so incidents record e.g. "sqs" or "amqp" as their source.
+type QueueSourceNamer interface {
+	SourceName() string
+}
@@ -2,6 +2,8 @@ package core
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"time"
 )
 
@@ -40,6 +42,62 @@ type NotableIncident struct {
 	CreatedAt time.Time
 }
 
+// ResponseMetrics is the response-time headline of a report window: the
+// median and p90 time to acknowledge (MTTA) and to resolve (MTTR), and the
+// share of incidents that paged on-call. Acked and Resolved count the
+// incidents each duration is computed over; a zero count means no such
+// incident in the window, and the durations are then zero.
+type ResponseMetrics struct {
+	Acked          int
+	MTTAP50        time.Duration
+	MTTAP90        time.Duration
+	Resolved       int
+	MTTRP50        time.Duration
+	MTTRP90        time.Duration
+	EscalationRate float64 // 0..1
+}
+
+// Summary renders the metrics as one short line, e.g.
+// "MTTA p50 4m (p90 12m) · MTTR p50 1h20m (p90 3h) · 10% escalated". A
+// metric with nothing to measure is left out; an empty window yields "".
+func (m ResponseMetrics) Summary() string {
+	var parts []string
+	if m.Acked > 0 {
+		parts = append(parts, fmt.Sprintf("MTTA p50 %s (p90 %s)", shortDuration(m.MTTAP50), shortDuration(m.MTTAP90)))
+	}
+	if m.Resolved > 0 {
+		parts = append(parts, fmt.Sprintf("MTTR p50 %s (p90 %s)", shortDuration(m.MTTRP50), shortDuration(m.MTTRP90)))
+	}
+	if len(parts) > 0 || m.EscalationRate > 0 {
+		parts = append(parts, fmt.Sprintf("%.0f%% escalated", m.EscalationRate*100))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// shortDuration renders d to the two largest units: 45s, 4m, 1h20m, 2d3h.
+func shortDuration(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Round(time.Second)/time.Second))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Round(time.Minute)/time.Minute))
+	case d < 24*time.Hour:
+		d = d.Round(time.Minute)
+		h, m := int(d/time.Hour), int(d%time.Hour/time.Minute)
+		if m == 0 {
+			return fmt.Sprintf("%dh", h)
+		}
+		return fmt.Sprintf("%dh%dm", h, m)
+	default:
+		d = d.Round(time.Hour)
+		days, h := int(d/(24*time.Hour)), int(d%(24*time.Hour)/time.Hour)
+		if h == 0 {
+			return fmt.Sprintf("%dd", days)
+		}
+		return fmt.Sprintf("%dd%dh", days, h)
+	}
+}
+
 // ReportModel is the channel-agnostic, ALREADY-REDACTED aggregate summary of
 // every incident in a time window. It is assembled by querying the incident
 // history over the window (both ai_detect and webhook) and handed to a
@@ -71,6 +129,10 @@ type ReportModel struct {
 	Open         int
 	CriticalHigh int // count of critical + high severity incidents
 
+	// Response is the MTTA / MTTR / escalation headline, computed the same
+	// way as the /api/admin/analytics endpoints.
+	Response ResponseMetrics
+
 	// Chart series (hand-drawn, no chart lib).
 	BySeverity []Bucket // ordered critical..unknown (category breakdown)
 	Trend      []Bucket // hourly (today/24h) or daily (7d) counts
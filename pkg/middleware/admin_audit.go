@@ -34,6 +34,9 @@ const (
 	// AdminAuditDenied — the mutation was rejected (validation, precondition,
 	// conflict, or a gate).
 	AdminAuditDenied = "denied"
+	// AdminAuditFailed — the action was attempted but did not complete
+	// (a storage error in a background job).
+	AdminAuditFailed = "failed"
 )
 
 // AdminAuditEvent describes one state-changing admin action for the audit
@@ -74,3 +77,30 @@ func RecordAdminAudit(c *fiber.Ctx, action, target, result string) {
 		h(c, AdminAuditEvent{Action: action, Target: target, Result: result})
 	}
 }
+
+// SystemAuditHook records one state-changing action the server takes on its
+// own — a scheduled job such as the retention purge — where there is no
+// request, and so no operator, behind it. OSS ships none (community no-op).
+type SystemAuditHook func(ev AdminAuditEvent)
+
+// systemAuditSlot holds the registered system hook (see adminAuditSlot).
+var systemAuditSlot atomic.Value // SystemAuditHook
+
+// SetSystemAuditHook registers the hook invoked for every state-changing
+// action a background job takes. Passing nil clears it. Call at boot.
+func SetSystemAuditHook(h SystemAuditHook) {
+	if h == nil {
+		systemAuditSlot = atomic.Value{}
+		return
+	}
+	systemAuditSlot.Store(h)
+}
+
+// RecordSystemAudit emits one system audit event to the registered hook; a
+// no-op with none registered. It is RecordAdminAudit for callers with no
+// request ctx.
+func RecordSystemAudit(action, target, result string) {
+	if h, ok := systemAuditSlot.Load().(SystemAuditHook); ok && h != nil {
+		h(AdminAuditEvent{Action: action, Target: target, Result: result})
+	}
+}
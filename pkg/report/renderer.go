@@ -189,6 +189,9 @@ func (r *Renderer) Render(ctx context.Context, m core.ReportModel) (*core.Report
 	drawString(img, fc.title, colText, padding, y, title)
 	y += 30
 	sub := fmt.Sprintf("%s → %s %s", fmtTime(m.WindowStart), fmtTime(m.WindowEnd), tzLabelOf(m))
+	if resp := m.Response.Summary(); resp != "" {
+		sub += "   ·   " + resp
+	}
 	drawString(img, fc.body, colTextMuted, padding, y, sub)
 	y += 26
 	divider(img, padding, y, contentRight)
@@ -31,5 +31,9 @@ func SetupRoutes(app *fiber.App, teamsStore *teams.Store) {
 	controllers.NewConfigAdminController().Register(api)
 	controllers.NewTeamsAdminController(teamsStore).Register(api)
 	controllers.NewReportsAdminController().Register(api)
+	controllers.NewAnalyticsAdminController().Register(api)
+	controllers.NewRetentionAdminController().Register(api)
+	controllers.NewArchiveAdminController().Register(api)
+	controllers.NewBackupAdminController().Register(api)
 	controllers.NewSpikeAdminController().Register(api)
 }
@@ -0,0 +1,247 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/storage"
+)
+
+// analytics.go — response-time analytics (MTTA, MTTR, escalation rate) over
+// a time range, grouped by service, team, source, origin or severity.
+//
+// The computation has two paths that return the same numbers. A backend
+// implementing storage.ResponseAggregator (Postgres) aggregates in SQL; for
+// any other backend, or a grouping the backend declines, the records in the
+// range are loaded (through storage.RangeLister when available) and
+// aggregated here by ComputeResponseStats.
+
+// ErrAnalyticsNoStorage is returned when no storage backend is configured,
+// so the admin API can map it to 503.
+var ErrAnalyticsNoStorage = errors.New("analytics: storage not configured")
+
+// ResponseAnalytics is the result of one analytics query: the overall stats
+// for the range and, when a grouping was requested, one row per group.
+type ResponseAnalytics struct {
+	Start   time.Time               `json:"start"`
+	End     time.Time               `json:"end"`
+	GroupBy string                  `json:"group_by,omitempty"`
+	Overall storage.ResponseStats   `json:"overall"`
+	Groups  []storage.ResponseStats `json:"groups,omitempty"`
+}
+
+// ResponseAnalyticsFor computes the analytics for incidents created in
+// [start, end), grouped by groupBy (storage.GroupByNone for the overall
+// stats only). groupBy must satisfy storage.IsResponseGroupBy.
+func ResponseAnalyticsFor(st storage.Provider, start, end time.Time, groupBy string) (ResponseAnalytics, error) {
+	out := ResponseAnalytics{Start: start, End: end, GroupBy: groupBy}
+	if st == nil {
+		return out, ErrAnalyticsNoStorage
+	}
+	rs := &responseStatsRange{st: st, start: start, end: end}
+	var err error
+	if out.Overall, err = rs.overall(); err != nil {
+		return out, err
+	}
+	if groupBy != storage.GroupByNone {
+		if out.Groups, err = rs.stats(groupBy); err != nil {
+			return out, err
+		}
+	}
+	return out, nil
+}
+
+// ResponseOverview is the overall stats for a range and every grouping of
+// it at once, keyed by grouping.
+type ResponseOverview struct {
+	Start   time.Time                          `json:"start"`
+	End     time.Time                          `json:"end"`
+	Overall storage.ResponseStats              `json:"overall"`
+	Groups  map[string][]storage.ResponseStats `json:"groups"`
+}
+
+// ResponseOverviewFor computes the overall stats for [start, end) once, then
+// each of groups. On the fallback path the range is loaded once for all of
+// them.
+func ResponseOverviewFor(st storage.Provider, start, end time.Time, groups []string) (ResponseOverview, error) {
+	out := ResponseOverview{Start: start, End: end, Groups: make(map[string][]storage.ResponseStats, len(groups))}
+	if st == nil {
+		return out, ErrAnalyticsNoStorage
+	}
+	rs := &responseStatsRange{st: st, start: start, end: end}
+	var err error
+	if out.Overall, err = rs.overall(); err != nil {
+		return out, err
+	}
+	for _, g := range groups {
+		if out.Groups[g], err = rs.stats(g); err != nil {
+			return out, err
+		}
+	}
+	return out, nil
+}
+
+// responseStatsRange computes stats for one range, in the backend when it
+// aggregates and from the records otherwise. The records are loaded at most
+// once, however many groupings fall back.
+type responseStatsRange struct {
+	st         storage.Provider
+	start, end time.Time
+	recs       []*storage.IncidentRecord
+	loaded     bool
+}
+
+func (r *responseStatsRange) stats(groupBy string) ([]storage.ResponseStats, error) {
+	if agg, ok := r.st.(storage.ResponseAggregator); ok {
+		rows, err := agg.AggregateResponseStats(r.start, r.end, groupBy)
+		if !errors.Is(err, storage.ErrGroupNotAggregated) {
+			return rows, err
+		}
+	}
+	if !r.loaded {
+		recs, err := incidentsInWindow(r.st, r.start, r.end)
+		if err != nil {
+			return nil, err
+		}
+		r.recs, r.loaded = recs, true
+	}
+	return ComputeResponseStats(r.recs, groupBy), nil
+}
+
+func (r *responseStatsRange) overall() (storage.ResponseStats, error) {
+	rows, err := r.stats(storage.GroupByNone)
+	if err != nil || len(rows) == 0 {
+		return storage.ResponseStats{}, err
+	}
+	return rows[0], nil
+}
+
+// ComputeResponseStats aggregates recs into one ResponseStats per group,
+// largest group first (ties by key). GroupByNone yields exactly one row,
+// keyed "", even for no records.
+func ComputeResponseStats(recs []*storage.IncidentRecord, groupBy string) []storage.ResponseStats {
+	type acc struct {
+		stats    storage.ResponseStats
+		tta, ttr []float64
+	}
+	groups := map[string]*acc{}
+	if groupBy == storage.GroupByNone {
+		groups[""] = &acc{}
+	}
+	for _, rec := range recs {
+		if rec == nil {
+			continue
+		}
+		key := responseGroupKey(rec, groupBy)
+		a := groups[key]
+		if a == nil {
+			a = &acc{}
+			groups[key] = a
+		}
+		a.stats.Incidents++
+		if rec.OnCallTriggered {
+			a.stats.Escalated++
+		}
+		if rec.AckedAt != nil {
+			a.stats.Acknowledged++
+			if d := rec.AckedAt.Sub(rec.CreatedAt); d >= 0 {
+				a.tta = append(a.tta, d.Seconds())
+			}
+		}
+		if rec.Resolved {
+			a.stats.Resolved++
+			if rec.ResolvedAt != nil && rec.MergedInto == "" {
+				if d := rec.ResolvedAt.Sub(rec.CreatedAt); d >= 0 {
+					a.ttr = append(a.ttr, d.Seconds())
+				}
+			}
+		}
+	}
+
+	out := make([]storage.ResponseStats, 0, len(groups))
+	for key, a := range groups {
+		s := a.stats
+		s.Key = key
+		if s.Incidents > 0 {
+			s.EscalationRate = float64(s.Escalated) / float64(s.Incidents)
+		}
+		s.MTTA = durationStats(a.tta)
+		s.MTTR = durationStats(a.ttr)
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Incidents != out[j].Incidents {
+			return out[i].Incidents > out[j].Incidents
+		}
+		return out[i].Key < out[j].Key
+	})
+	return out
+}
+
+// responseGroupKey is the group one record falls in. Service and severity
+// go through the shared record helpers so a group matches what the list
+// and the report show; severity is banded so the groups stay a closed set.
+func responseGroupKey(rec *storage.IncidentRecord, groupBy string) string {
+	switch groupBy {
+	case storage.GroupByService:
+		if s := rec.ServiceLabel(); s != "" {
+			return s
+		}
+		return "unknown"
+	case storage.GroupByTeam:
+		switch {
+		case rec.AssignedTeamID != "":
+			return rec.AssignedTeamID
+		case rec.TeamID != "":
+			return rec.TeamID
+		default:
+			return "unassigned"
+		}
+	case storage.GroupBySource:
+		if rec.Source != "" {
+			return rec.Source
+		}
+		return "unknown"
+	case storage.GroupByOrigin:
+		return rec.EffectiveOrigin()
+	case storage.GroupBySeverity:
+		return SeverityBand(rec.SeverityLabel())
+	default:
+		return ""
+	}
+}
+
+// durationStats summarizes seconds. Percentiles interpolate linearly
+// between the closest ranks, like Postgres percentile_cont, so the SQL and
+// Go paths agree.
+func durationStats(secs []float64) storage.DurationStats {
+	if len(secs) == 0 {
+		return storage.DurationStats{}
+	}
+	sort.Float64s(secs)
+	var sum float64
+	for _, s := range secs {
+		sum += s
+	}
+	return storage.DurationStats{
+		Count: len(secs),
+		Mean:  sum / float64(len(secs)),
+		P50:   percentile(secs, 0.50),
+		P90:   percentile(secs, 0.90),
+		P95:   percentile(secs, 0.95),
+		P99:   percentile(secs, 0.99),
+	}
+}
+
+// percentile returns the p-th percentile of sorted, non-empty values.
+func percentile(sorted []float64, p float64) float64 {
+	pos := p * float64(len(sorted)-1)
+	lo := int(math.Floor(pos))
+	hi := int(math.Ceil(pos))
+	if lo == hi {
+		return sorted[lo]
+	}
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(pos-float64(lo))
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/storage"
+)
+
+// responseRec builds a record created at base with optional ack / resolve
+// delays (a negative delay leaves the field unset).
+func responseRec(id, service string, base time.Time, ack, resolve time.Duration, escalated bool) *storage.IncidentRecord {
+	r := &storage.IncidentRecord{ID: id, Service: service, Source: "webhook", CreatedAt: base, OnCallTriggered: escalated}
+	if ack >= 0 {
+		t := base.Add(ack)
+		r.AckedAt = &t
+	}
+	if resolve >= 0 {
+		t := base.Add(resolve)
+		r.Resolved = true
+		r.ResolvedAt = &t
+	}
+	return r
+}
+
+func TestComputeResponseStats_PercentilesAndRates(t *testing.T) {
+	base := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	recs := []*storage.IncidentRecord{
+		responseRec("a", "api", base, time.Minute, time.Hour, true),
+		responseRec("b", "api", base, 2*time.Minute, 2*time.Hour, false),
+		responseRec("c", "api", base, 3*time.Minute, -1, false),
+		responseRec("d", "db", base, 4*time.Minute, 4*time.Hour, true),
+		responseRec("e", "db", base, -1, -1, false),
+	}
+	// A duplicate closed by a merge counts as resolved but not toward MTTR.
+	dup := responseRec("f", "db", base, -1, 10*time.Hour, false)
+	dup.MergedInto = "d"
+	recs = append(recs, dup)
+
+	overall := ComputeResponseStats(recs, storage.GroupByNone)
+	if len(overall) != 1 {
+		t.Fatalf("overall rows = %d, want 1", len(overall))
+	}
+	s := overall[0]
+	if s.Incidents != 6 || s.Acknowledged != 4 || s.Resolved != 4 || s.Escalated != 2 {
+		t.Fatalf("counts = %+v", s)
+	}
+	if s.EscalationRate != 2.0/6.0 {
+		t.Errorf("EscalationRate = %v, want 1/3", s.EscalationRate)
+	}
+	// MTTA over 60,120,180,240s: p50 interpolates to 150s, p90 to 222s.
+	if s.MTTA.Count != 4 || s.MTTA.Mean != 150 || s.MTTA.P50 != 150 || s.MTTA.P90 != 222 {
+		t.Errorf("MTTA = %+v", s.MTTA)
+	}
+	// MTTR over 1h,2h,4h (the merged duplicate excluded): p50 is 2h.
+	if s.MTTR.Count != 3 || s.MTTR.P50 != 7200 {
+		t.Errorf("MTTR = %+v", s.MTTR)
+	}
+
+	byService := ComputeResponseStats(recs, storage.GroupByService)
+	if len(byService) != 2 || byService[0].Key != "api" || byService[0].Incidents != 3 || byService[1].Key != "db" {
+		t.Fatalf("by service = %+v", byService)
+	}
+}
+
+func TestComputeResponseStats_EmptyOverallHasOneZeroRow(t *testing.T) {
+	rows := ComputeResponseStats(nil, storage.GroupByNone)
+	if len(rows) != 1 || rows[0].Incidents != 0 || rows[0].MTTA.Count != 0 {
+		t.Fatalf("rows = %+v, want one zero row", rows)
+	}
+	if rows := ComputeResponseStats(nil, storage.GroupByService); len(rows) != 0 {
+		t.Fatalf("grouped rows = %+v, want none", rows)
+	}
+}
+
+// aggregatingStore is a memory store that also claims the
+// ResponseAggregator capability, answering only the overall grouping.
+type aggregatingStore struct {
+	storage.Provider
+	calls []string
+}
+
+func (a *aggregatingStore) AggregateResponseStats(_, _ time.Time, groupBy string) ([]storage.ResponseStats, error) {
+	a.calls = append(a.calls, groupBy)
+	if groupBy != storage.GroupByNone {
+		return nil, storage.ErrGroupNotAggregated
+	}
+	return []storage.ResponseStats{{Incidents: 99}}, nil
+}
+
+// TestResponseAnalyticsFor_UsesAggregatorThenFallsBack proves the backend's
+// SQL aggregation is used when it answers, and a grouping it declines is
+// computed from the records in the range instead.
+func TestResponseAnalyticsFor_UsesAggregatorThenFallsBack(t *testing.T) {
+	now := time.Now().UTC()
+	mem := storage.NewMemory()
+	for _, r := range []*storage.IncidentRecord{
+		responseRec("in", "api", now.Add(-time.Hour), time.Minute, -1, false),
+		responseRec("old", "api", now.Add(-48*time.Hour), time.Minute, -1, false),
+	} {
+		if err := mem.SaveIncident(r); err != nil {
+			t.Fatalf("SaveIncident: %v", err)
+		}
+	}
+	st := &aggregatingStore{Provider: mem}
+
+	out, err := ResponseAnalyticsFor(st, now.Add(-24*time.Hour), now, storage.GroupBySeverity)
+	if err != nil {
+		t.Fatalf("ResponseAnalyticsFor: %v", err)
+	}
+	if out.Overall.Incidents != 99 {
+		t.Errorf("overall = %+v, want the aggregator's row", out.Overall)
+	}
+	if len(out.Groups) != 1 || out.Groups[0].Key != "unknown" || out.Groups[0].Incidents != 1 {
+		t.Errorf("groups = %+v, want the one in-range incident in the unknown band", out.Groups)
+	}
+	if strings.Join(st.calls, ",") != ","+storage.GroupBySeverity {
+		t.Errorf("aggregator calls = %q", st.calls)
+	}
+
+	if _, err := ResponseAnalyticsFor(nil, now, now, ""); !errors.Is(err, ErrAnalyticsNoStorage) {
+		t.Errorf("nil store: err = %v, want ErrAnalyticsNoStorage", err)
+	}
+}
+
+// countingRangeStore is an aggregatingStore that also lists a range,
+// counting the loads.
+type countingRangeStore struct {
+	*aggregatingStore
+	loads int
+}
+
+func (c *countingRangeStore) ListIncidentsInRange(start, end time.Time, limit int) ([]*storage.IncidentRecord, error) {
+	c.loads++
+	return c.ListIncidents(limit)
+}
+
+// TestResponseOverviewFor_OverallOnceOneLoad proves the overview asks for
+// the overall stats once and loads the range at most once, however many
+// groupings the backend declines.
+func TestResponseOverviewFor_OverallOnceOneLoad(t *testing.T) {
+	now := time.Now().UTC()
+	mem := storage.NewMemory()
+	if err := mem.SaveIncident(responseRec("a", "api", now.Add(-time.Hour), time.Minute, -1, false)); err != nil {
+		t.Fatalf("SaveIncident: %v", err)
+	}
+	st := &countingRangeStore{aggregatingStore: &aggregatingStore{Provider: mem}}
+	groups := []string{storage.GroupByService, storage.GroupByTeam, storage.GroupBySeverity}
+
+	out, err := ResponseOverviewFor(st, now.Add(-24*time.Hour), now, groups)
+	if err != nil {
+		t.Fatalf("ResponseOverviewFor: %v", err)
+	}
+	if out.Overall.Incidents != 99 || len(out.Groups) != len(groups) {
+		t.Fatalf("overview = %+v", out)
+	}
+	if g := out.Groups[storage.GroupByService]; len(g) != 1 || g[0].Key != "api" {
+		t.Errorf("service groups = %+v", g)
+	}
+	if got := strings.Join(st.calls, ","); got != ",service,team,severity" {
+		t.Errorf("aggregator calls = %q, want the overall once then each grouping", got)
+	}
+	if st.loads != 1 {
+		t.Errorf("range loaded %d times, want 1", st.loads)
+	}
+}
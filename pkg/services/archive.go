@@ -0,0 +1,319 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/archive"
+	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/VersusControl/versus-incident/pkg/middleware"
+	"github.com/VersusControl/versus-incident/pkg/scheduler"
+	"github.com/VersusControl/versus-incident/pkg/storage"
+)
+
+// archive.go — the archive job. It moves incidents (with their timelines)
+// and analyses older than the storage.archive ages out of the primary store
+// into the object-store archive (pkg/archive), then deletes them through
+// storage.Lifecycle. Records are uploaded before they are deleted, so a
+// failed run leaves them in the primary store and the next run retries; a
+// run that fails between the two steps archives a record twice, which the
+// archive readers tolerate.
+
+// ArchiveJobName is the ownership key the archive job gates on, so under
+// HA exactly one replica moves records (see RetentionJobName).
+const ArchiveJobName = "storage-archive"
+
+// auditActionArchiveMoved is the system-audit action for one domain's
+// scheduled archive run; the target is "<domain> older than <cutoff>: <n>".
+const auditActionArchiveMoved = "storage.archive.moved"
+
+// ErrArchiveUnsupported is returned when the backend cannot delete what
+// it archived (no storage.Lifecycle).
+var ErrArchiveUnsupported = errors.New("archive: not supported by the configured storage backend")
+
+// archiveTier is the process-wide archive, set once at startup when
+// storage.archive is enabled. Nil means no archive.
+var archiveTier *archive.Archive
+
+// SetArchive installs the archive read-through and search use. Pass nil to
+// disable.
+func SetArchive(a *archive.Archive) { archiveTier = a }
+
+// Archive returns the installed archive, or nil.
+func Archive() *archive.Archive { return archiveTier }
+
+// OpenArchive builds the archive cfg describes. It returns nil, nil when
+// the archive is disabled.
+func OpenArchive(ctx context.Context, cfg config.StorageArchiveConfig) (*archive.Archive, error) {
+	if !cfg.Enable {
+		return nil, nil
+	}
+	objects, err := archive.NewS3Store(ctx, archive.S3Options{
+		Bucket:          cfg.S3.Bucket,
+		Region:          cfg.S3.Region,
+		Endpoint:        cfg.S3.Endpoint,
+		AccessKeyID:     cfg.S3.AccessKeyID,
+		SecretAccessKey: cfg.S3.SecretAccessKey,
+		ForcePathStyle:  cfg.S3.ForcePathStyle,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return archive.New(objects, cfg.S3.Prefix), nil
+}
+
+// ArchivePolicy is the per-domain age past which records move to the
+// archive. A zero age keeps the domain in the primary store.
+type ArchivePolicy struct {
+	Incidents    time.Duration
+	ResolvedOnly bool
+	Analyses     time.Duration
+}
+
+// ArchivePolicyFrom converts the config block (ages in days).
+func ArchivePolicyFrom(cfg config.StorageArchiveConfig) ArchivePolicy {
+	day := 24 * time.Hour
+	return ArchivePolicy{
+		Incidents:    time.Duration(max(cfg.IncidentsDays, 0)) * day,
+		ResolvedOnly: cfg.ResolvedOnly,
+		Analyses:     time.Duration(max(cfg.AnalysesDays, 0)) * day,
+	}
+}
+
+// plan lists the domains the policy archives, with cutoffs relative to now.
+func (p ArchivePolicy) plan(now time.Time) []retentionStep {
+	var steps []retentionStep
+	if p.Incidents > 0 {
+		domain := storage.DomainIncidents
+		if p.ResolvedOnly {
+			domain = storage.DomainResolvedIncidents
+		}
+		steps = append(steps, retentionStep{domain: domain, maxAge: p.Incidents, cutoff: now.Add(-p.Incidents)})
+	}
+	if p.Analyses > 0 {
+		steps = append(steps, retentionStep{domain: storage.DomainAnalyses, maxAge: p.Analyses, cutoff: now.Add(-p.Analyses)})
+	}
+	return steps
+}
+
+// ArchiveResult is one domain's outcome: the records moved, or for a dry
+// run the records that would be.
+type ArchiveResult struct {
+	Domain string    `json:"domain"`
+	MaxAge string    `json:"max_age"`
+	Cutoff time.Time `json:"cutoff"`
+	Count  int       `json:"count"`
+	Error  string    `json:"error,omitempty"`
+}
+
+// archivePageSize is how many records one archive write and delete round
+// covers.
+const archivePageSize = defaultMigrationPageSize
+
+// errArchiveWalkDone ends a walk once it reaches records newer than the
+// cutoff.
+var errArchiveWalkDone = errors.New("archive: walk done")
+
+// PreviewArchive counts, per domain, what RunArchive would move at now,
+// without writing or deleting anything.
+func PreviewArchive(ctx context.Context, store storage.Provider, policy ArchivePolicy, now time.Time) []ArchiveResult {
+	return runArchive(ctx, store, nil, policy, now)
+}
+
+// RunArchive moves, per domain, everything older than the policy allows
+// into arc. A domain that fails is reported in its result, with the count
+// moved before the failure, and does not stop the others.
+func RunArchive(ctx context.Context, store storage.Provider, arc *archive.Archive, policy ArchivePolicy, now time.Time) ([]ArchiveResult, error) {
+	if _, ok := store.(storage.Lifecycle); !ok {
+		return nil, ErrArchiveUnsupported
+	}
+	return runArchive(ctx, store, arc, policy, now), nil
+}
+
+// runArchive walks each planned domain oldest first. A nil arc counts only.
+func runArchive(ctx context.Context, store storage.Provider, arc *archive.Archive, policy ArchivePolicy, now time.Time) []ArchiveResult {
+	out := make([]ArchiveResult, 0, 2)
+	for _, s := range policy.plan(now) {
+		res := ArchiveResult{Domain: s.domain, MaxAge: retentionAge(s.maxAge), Cutoff: s.cutoff}
+		var err error
+		if s.domain == storage.DomainAnalyses {
+			res.Count, err = archiveAnalyses(ctx, store, arc, s.cutoff)
+		} else {
+			res.Count, err = archiveIncidents(ctx, store, arc, s.cutoff, s.domain == storage.DomainResolvedIncidents)
+		}
+		if err != nil {
+			res.Error = err.Error()
+		}
+		out = append(out, res)
+	}
+	return out
+}
+
+// archiveIncidents moves the incidents retention would purge at cutoff —
+// storage.IncidentExpired for the domain, so both jobs pick the same set —
+// one page at a time. The walk stops at the first incident created at or
+// after cutoff: nothing newer can qualify, since an incident resolves after
+// it is created.
+func archiveIncidents(ctx context.Context, store storage.Provider, arc *archive.Archive, cutoff time.Time, resolvedOnly bool) (int, error) {
+	tl, _ := store.(storage.Timeline)
+	domain := storage.DomainIncidents
+	if resolvedOnly {
+		domain = storage.DomainResolvedIncidents
+	}
+	moved := 0
+	err := walkIncidentsOldestFirst(store, archivePageSize, func(page []*storage.IncidentRecord) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var batch []*archive.Incident
+		done := false
+		for _, rec := range page {
+			if !rec.CreatedAt.Before(cutoff) {
+				done = true
+				break
+			}
+			if !storage.IncidentExpired(rec, domain, cutoff) {
+				continue
+			}
+			batch = append(batch, &archive.Incident{IncidentRecord: *rec})
+		}
+		if arc == nil {
+			moved += len(batch)
+		} else if len(batch) > 0 {
+			if tl != nil {
+				for _, inc := range batch {
+					entries, err := tl.ListTimeline(inc.ID, migrationTimelineLimit)
+					if err != nil {
+						return fmt.Errorf("timeline %s: %w", inc.ID, err)
+					}
+					inc.Timeline = entries
+				}
+			}
+			if err := arc.WriteIncidents(ctx, batch); err != nil {
+				return err
+			}
+			lc := store.(storage.Lifecycle)
+			for _, inc := range batch {
+				if err := lc.DeleteByID(storage.DomainIncidents, inc.ID); err != nil && !errors.Is(err, storage.ErrNotFound) {
+					return err
+				}
+				moved++
+			}
+		}
+		if done {
+			return errArchiveWalkDone
+		}
+		return nil
+	})
+	if errors.Is(err, errArchiveWalkDone) {
+		err = nil
+	}
+	return moved, err
+}
+
+// archiveAnalyses is the analyses twin of archiveIncidents, aged by
+// requested_at.
+func archiveAnalyses(ctx context.Context, store storage.Provider, arc *archive.Archive, cutoff time.Time) (int, error) {
+	moved := 0
+	err := walkAnalysesOldestFirst(store, archivePageSize, func(page []*storage.AnalysisRecord) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var batch []*archive.Analysis
+		done := false
+		for _, rec := range page {
+			if !rec.RequestedAt.Before(cutoff) {
+				done = true
+				break
+			}
+			batch = append(batch, &archive.Analysis{AnalysisRecord: *rec})
+		}
+		if arc == nil {
+			moved += len(batch)
+		} else if len(batch) > 0 {
+			if err := arc.WriteAnalyses(ctx, batch); err != nil {
+				return err
+			}
+			lc := store.(storage.Lifecycle)
+			for _, a := range batch {
+				if err := lc.DeleteByID(storage.DomainAnalyses, a.ID); err != nil && !errors.Is(err, storage.ErrNotFound) {
+					return err
+				}
+				moved++
+			}
+		}
+		if done {
+			return errArchiveWalkDone
+		}
+		return nil
+	})
+	if errors.Is(err, errArchiveWalkDone) {
+		err = nil
+	}
+	return moved, err
+}
+
+// ArchiveInterval parses the configured job period, with the same default
+// and floor as RetentionInterval.
+func ArchiveInterval(cfg config.StorageArchiveConfig) time.Duration {
+	d, err := time.ParseDuration(strings.TrimSpace(cfg.Interval))
+	if err != nil || d <= 0 {
+		return defaultRetentionInterval
+	}
+	return max(d, minRetentionInterval)
+}
+
+// StartArchiveScheduler runs the archive job once at start and then every
+// interval until ctx is done. It does nothing when arc is nil, no domain has
+// an age, or the backend cannot delete. Each run is gated behind
+// scheduler.Owns(ArchiveJobName), so under HA one replica moves records.
+func StartArchiveScheduler(ctx context.Context, store storage.Provider, arc *archive.Archive, cfg config.StorageArchiveConfig) {
+	policy := ArchivePolicyFrom(cfg)
+	if arc == nil || len(policy.plan(time.Now())) == 0 {
+		return
+	}
+	if _, ok := store.(storage.Lifecycle); !ok {
+		log.Printf("archive: enabled but the storage backend cannot delete; archived incidents stay readable, nothing is moved")
+		return
+	}
+	interval := ArchiveInterval(cfg)
+	log.Printf("archive: moving every %s (incidents=%s resolved_only=%t analyses=%s)",
+		interval, retentionAge(policy.Incidents), policy.ResolvedOnly, retentionAge(policy.Analyses))
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if scheduler.Owns(ArchiveJobName) {
+				runArchiveOnce(ctx, store, arc, policy, time.Now().UTC())
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// runArchiveOnce runs the job and logs and audits each domain.
+func runArchiveOnce(ctx context.Context, store storage.Provider, arc *archive.Archive, policy ArchivePolicy, now time.Time) {
+	results, err := RunArchive(ctx, store, arc, policy, now)
+	if err != nil {
+		log.Printf("archive: %v", err)
+		return
+	}
+	for _, r := range results {
+		target := fmt.Sprintf("%s older than %s: %d", r.Domain, r.Cutoff.Format(time.RFC3339), r.Count)
+		if r.Error != "" {
+			log.Printf("archive: %s failed after %d: %s", r.Domain, r.Count, r.Error)
+			middleware.RecordSystemAudit(auditActionArchiveMoved, target, middleware.AdminAuditFailed)
+			continue
+		}
+		log.Printf("archive: moved %d %s older than %s", r.Count, r.Domain, r.MaxAge)
+		middleware.RecordSystemAudit(auditActionArchiveMoved, target, middleware.AdminAuditSuccess)
+	}
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/archive"
+	"github.com/VersusControl/versus-incident/pkg/storage"
+)
+
+// TestArchive_MovesAgedRecords archives incidents and analyses past the
+// policy ages, and checks they leave the primary store with their timeline
+// and stay readable from the archive.
+func TestArchive_MovesAgedRecords(t *testing.T) {
+	ctx := context.Background()
+	st := storage.NewMemory()
+	now := time.Now().UTC()
+	ago := func(days int) time.Time { return now.Add(-time.Duration(days) * 24 * time.Hour) }
+	resolvedAt := ago(80)
+	for _, r := range []*storage.IncidentRecord{
+		{ID: "old-resolved", Title: "old", CreatedAt: ago(100), Resolved: true, ResolvedAt: &resolvedAt},
+		{ID: "old-open", Title: "still open", CreatedAt: ago(95)},
+		{ID: "recent", Title: "recent", CreatedAt: ago(5)},
+	} {
+		if err := st.SaveIncident(r); err != nil {
+			t.Fatalf("SaveIncident: %v", err)
+		}
+	}
+	if err := st.(storage.Timeline).AppendTimelineEntry(&storage.TimelineEntry{
+		ID: "tl-1", IncidentID: "old-resolved", Kind: storage.TimelineNote, Body: "fixed", CreatedAt: ago(90),
+	}); err != nil {
+		t.Fatalf("AppendTimelineEntry: %v", err)
+	}
+	for _, a := range []*storage.AnalysisRecord{
+		{ID: "an-old", IncidentID: "old-resolved", RequestedAt: ago(40), Status: "ok"},
+		{ID: "an-new", IncidentID: "recent", RequestedAt: ago(1), Status: "ok"},
+	} {
+		if err := st.SaveAnalysis(a); err != nil {
+			t.Fatalf("SaveAnalysis: %v", err)
+		}
+	}
+	arc := archive.New(archive.NewMemoryStore(), "")
+
+	resolvedOnly := ArchivePolicy{Incidents: 30 * 24 * time.Hour, ResolvedOnly: true, Analyses: 30 * 24 * time.Hour}
+	preview := PreviewArchive(ctx, st, resolvedOnly, now)
+	if len(preview) != 2 || preview[0].Count != 1 || preview[1].Count != 1 {
+		t.Fatalf("preview = %+v", preview)
+	}
+	if _, err := st.GetIncident("old-resolved"); err != nil {
+		t.Fatalf("preview moved old-resolved: %v", err)
+	}
+
+	results, err := RunArchive(ctx, st, arc, resolvedOnly, now)
+	if err != nil {
+		t.Fatalf("RunArchive: %v", err)
+	}
+	for _, r := range results {
+		if r.Error != "" || r.Count != 1 {
+			t.Fatalf("result = %+v, want 1 moved", r)
+		}
+	}
+	if _, err := st.GetIncident("old-resolved"); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("old-resolved still in the primary store: %v", err)
+	}
+	if _, err := st.GetIncident("old-open"); err != nil {
+		t.Fatalf("resolved_only moved old-open: %v", err)
+	}
+	got, err := arc.GetIncident(ctx, "old-resolved")
+	if err != nil || len(got.Timeline) != 1 || got.Timeline[0].Body != "fixed" {
+		t.Fatalf("archived incident = %+v, %v", got, err)
+	}
+	if _, err := arc.GetAnalysis(ctx, "an-old"); err != nil {
+		t.Fatalf("archived analysis: %v", err)
+	}
+	if _, err := st.GetAnalysis("an-new"); err != nil {
+		t.Fatalf("an-new moved: %v", err)
+	}
+
+	// Without resolved_only every aged incident moves; a second run finds
+	// nothing left.
+	all := ArchivePolicy{Incidents: 30 * 24 * time.Hour}
+	if results, _ := RunArchive(ctx, st, arc, all, now); len(results) != 1 || results[0].Count != 1 {
+		t.Fatalf("second run = %+v, want old-open moved", results)
+	}
+	if results, _ := RunArchive(ctx, st, arc, all, now); results[0].Count != 0 {
+		t.Fatalf("third run = %+v, want nothing left", results)
+	}
+	if list, _ := st.ListIncidents(0); len(list) != 1 || list[0].ID != "recent" {
+		t.Fatalf("primary store = %d incidents, want only recent", len(list))
+	}
+}
+
+// TestArchive_ResolvedOnlyMatchesRetention checks resolved_only archiving
+// picks exactly what retention's resolved-incidents purge would, including a
+// resolved incident with no ResolvedAt, which both age by created_at.
+func TestArchive_ResolvedOnlyMatchesRetention(t *testing.T) {
+	ctx := context.Background()
+	st := storage.NewMemory()
+	now := time.Now().UTC()
+	ago := func(days int) time.Time { return now.Add(-time.Duration(days) * 24 * time.Hour) }
+	lateResolve := ago(10)
+	for _, r := range []*storage.IncidentRecord{
+		{ID: "legacy-resolved", CreatedAt: ago(100), Resolved: true},
+		{ID: "resolved-late", CreatedAt: ago(100), Resolved: true, ResolvedAt: &lateResolve},
+		{ID: "open", CreatedAt: ago(100)},
+	} {
+		if err := st.SaveIncident(r); err != nil {
+			t.Fatalf("SaveIncident: %v", err)
+		}
+	}
+	cutoff := now.Add(-30 * 24 * time.Hour)
+	want, err := st.(storage.LifecycleCounter).CountOlderThan(storage.DomainResolvedIncidents, cutoff)
+	if err != nil {
+		t.Fatalf("CountOlderThan: %v", err)
+	}
+	arc := archive.New(archive.NewMemoryStore(), "")
+	results, err := RunArchive(ctx, st, arc, ArchivePolicy{Incidents: 30 * 24 * time.Hour, ResolvedOnly: true}, now)
+	if err != nil {
+		t.Fatalf("RunArchive: %v", err)
+	}
+	if want != 1 || results[0].Count != want {
+		t.Fatalf("archived %d, retention would purge %d; want 1 each", results[0].Count, want)
+	}
+	if _, err := arc.GetIncident(ctx, "legacy-resolved"); err != nil {
+		t.Fatalf("legacy-resolved not archived: %v", err)
+	}
+}
+
+// TestArchive_RequiresLifecycle refuses to archive from a backend that
+// cannot delete, so nothing is ever left in both places by design.
+func TestArchive_RequiresLifecycle(t *testing.T) {
+	st, err := storage.NewFile(storage.FileOptions{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewFile: %v", err)
+	}
+	arc := archive.New(archive.NewMemoryStore(), "")
+	if _, err := RunArchive(context.Background(), st, arc, ArchivePolicy{Incidents: time.Hour}, time.Now()); !errors.Is(err, ErrArchiveUnsupported) {
+		t.Fatalf("RunArchive(file) = %v, want ErrArchiveUnsupported", err)
+	}
+}
@@ -0,0 +1,562 @@
+package services
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/agent"
+	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/VersusControl/versus-incident/pkg/storage"
+	"github.com/klauspost/compress/zstd"
+)
+
+// backup.go — snapshot and restore of everything Versus stores: incidents
+// with their timelines, analyses, the pattern catalog, and every blob (shadow
+// and detect logs, service overrides, members and teams, runbooks with their
+// embeddings, settings, learned model state and generated secrets).
+//
+// Secrets leave the box in an archive only under a passphrase, or when the
+// caller asks for them explicitly (IncludeSecrets): otherwise the generated
+// secrets/ blobs are left out, and a store encrypted at rest, whose payloads
+// the backup reads decrypted, is refused.
+//
+// An archive is a zstd-compressed tar, optionally encrypted (backup_crypt.go).
+// manifest.json comes first so a restore checks the format version before it
+// writes anything; end.json comes last with the per-domain counts, so a
+// truncated archive is reported rather than silently half-restored. Records
+// are stored as NDJSON parts of one page each, so neither side holds the
+// whole history in memory.
+
+const (
+	// BackupFormat names the archive format in the manifest.
+	BackupFormat = "versus-backup"
+	// BackupVersion is the archive layout version this build writes. A
+	// restore accepts this version and older ones.
+	BackupVersion = 1
+	// BackupContentType is the media type of an unencrypted archive.
+	BackupContentType = "application/zstd"
+	// BackupFileExt is the file extension of an archive.
+	BackupFileExt = ".tar.zst"
+)
+
+// Archive entry names.
+const (
+	backupManifestEntry = "manifest.json"
+	backupEndEntry      = "end.json"
+	backupCatalogEntry  = "catalog.json"
+	backupIncidentsDir  = "incidents/"
+	backupTimelineDir   = "timeline/"
+	backupAnalysesDir   = "analyses/"
+	backupBlobsDir      = "blobs/"
+)
+
+// backupSecretsPrefix holds blobs the server generates at boot, so their
+// presence alone does not make a restore target non-empty.
+const backupSecretsPrefix = "secrets/"
+
+var (
+	// ErrBackupFormat is returned for a file that is not a Versus backup, or
+	// whose manifest is missing or unreadable.
+	ErrBackupFormat = errors.New("backup: not a Versus backup archive")
+	// ErrBackupVersion is returned for an archive written by a newer build.
+	ErrBackupVersion = errors.New("backup: archive version not supported")
+	// ErrBackupTargetNotEmpty is returned when the restore target already
+	// holds data and the restore is not forced.
+	ErrBackupTargetNotEmpty = errors.New("backup: restore target is not empty")
+	// ErrBackupTruncated is returned when an archive ends before end.json,
+	// or its record counts disagree with end.json.
+	ErrBackupTruncated = errors.New("backup: archive incomplete")
+	// ErrBackupSecrets is returned for an unencrypted backup of a store
+	// encrypted at rest, unless secrets are explicitly included.
+	ErrBackupSecrets = errors.New("backup: storage is encrypted at rest; set a passphrase, or include secrets explicitly, to write its data")
+)
+
+// BackupManifest describes one archive. Counts is filled from end.json and
+// is empty until the whole archive has been written or read.
+type BackupManifest struct {
+	Format    string    `json:"format"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	Backend   string    `json:"backend,omitempty"`
+	Encrypted bool      `json:"encrypted"`
+	// SecretsOmitted is set when the generated secrets/ blobs were left out.
+	SecretsOmitted bool           `json:"secrets_omitted,omitempty"`
+	Counts         map[string]int `json:"counts,omitempty"`
+}
+
+// BackupOptions tunes WriteBackup.
+type BackupOptions struct {
+	// Passphrase encrypts the archive when set.
+	Passphrase string
+	// Backend is recorded in the manifest (the storage type).
+	Backend string
+	// PageSize is the number of incidents or analyses per archive part. <= 0
+	// uses defaultMigrationPageSize.
+	PageSize int
+	// SealedAtRest says the store encrypts payloads at rest. The backup
+	// reads them decrypted, so without a passphrase it is refused unless
+	// IncludeSecrets.
+	SealedAtRest bool
+	// IncludeSecrets writes secrets into an archive without a passphrase:
+	// the generated secrets/ blobs, and the decrypted payloads of a store
+	// SealedAtRest. An encrypted archive always includes them.
+	IncludeSecrets bool
+}
+
+// CheckBackup reports, before any output is written, whether opts may be
+// written: an unencrypted backup of a store sealed at rest needs
+// IncludeSecrets.
+func CheckBackup(opts BackupOptions) error {
+	if opts.Passphrase == "" && opts.SealedAtRest && !opts.IncludeSecrets {
+		return ErrBackupSecrets
+	}
+	return nil
+}
+
+// omitSecrets reports whether the secrets/ blobs stay out of the archive.
+func (o BackupOptions) omitSecrets() bool {
+	return o.Passphrase == "" && !o.IncludeSecrets
+}
+
+// RestoreOptions tunes RestoreBackup.
+type RestoreOptions struct {
+	// Passphrase decrypts an encrypted archive.
+	Passphrase string
+	// Force restores into a target that already holds data. Records with
+	// the same id are replaced; other records are kept.
+	Force bool
+}
+
+// WriteBackup streams a snapshot of store to w. Records are read one page at
+// a time while the server keeps running, so each record is consistent but
+// the archive is not a single point in time; stop writes first when that
+// matters. It refuses what CheckBackup refuses, before writing anything.
+func WriteBackup(ctx context.Context, store storage.Provider, w io.Writer, opts BackupOptions) (*BackupManifest, error) {
+	if err := CheckBackup(opts); err != nil {
+		return nil, err
+	}
+	if opts.PageSize <= 0 {
+		opts.PageSize = defaultMigrationPageSize
+	}
+	manifest := &BackupManifest{
+		Format:         BackupFormat,
+		Version:        BackupVersion,
+		CreatedAt:      time.Now().UTC(),
+		Backend:        opts.Backend,
+		Encrypted:      opts.Passphrase != "",
+		SecretsOmitted: opts.omitSecrets(),
+		Counts:         make(map[string]int),
+	}
+
+	out := w
+	var enc *backupEncrypter
+	if opts.Passphrase != "" {
+		var err error
+		if enc, err = newBackupEncrypter(w, opts.Passphrase); err != nil {
+			return nil, err
+		}
+		out = enc
+	}
+	zw, err := zstd.NewWriter(out)
+	if err != nil {
+		return nil, err
+	}
+	bw := &backupWriter{tw: tar.NewWriter(zw), modTime: manifest.CreatedAt}
+
+	if err := bw.json(backupManifestEntry, manifest); err != nil {
+		return nil, err
+	}
+	if err := bw.records(ctx, store, opts.PageSize, opts.omitSecrets(), manifest.Counts); err != nil {
+		return nil, err
+	}
+	if err := bw.json(backupEndEntry, manifest); err != nil {
+		return nil, err
+	}
+	if err := bw.tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	if enc != nil {
+		if err := enc.Close(); err != nil {
+			return nil, err
+		}
+	}
+	return manifest, nil
+}
+
+// backupWriter writes the archive entries.
+type backupWriter struct {
+	tw      *tar.Writer
+	modTime time.Time
+	parts   map[string]int
+}
+
+func (bw *backupWriter) entry(name string, data []byte) error {
+	if err := bw.tw.WriteHeader(&tar.Header{
+		Name:     name,
+		Mode:     0o600,
+		Size:     int64(len(data)),
+		ModTime:  bw.modTime,
+		Typeflag: tar.TypeReg,
+	}); err != nil {
+		return err
+	}
+	_, err := bw.tw.Write(data)
+	return err
+}
+
+func (bw *backupWriter) json(name string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return bw.entry(name, data)
+}
+
+// part writes one NDJSON part under dir, numbered in write order.
+func (bw *backupWriter) part(dir string, n int, encode func(*json.Encoder) error) error {
+	if n == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	if err := encode(json.NewEncoder(&buf)); err != nil {
+		return err
+	}
+	if bw.parts == nil {
+		bw.parts = make(map[string]int)
+	}
+	bw.parts[dir]++
+	return bw.entry(fmt.Sprintf("%s%06d.ndjson", dir, bw.parts[dir]), buf.Bytes())
+}
+
+// records writes every domain, tallying counts as it goes. omitSecrets
+// leaves the secrets/ blobs out.
+func (bw *backupWriter) records(ctx context.Context, store storage.Provider, pageSize int, omitSecrets bool, counts map[string]int) error {
+	tl, hasTimeline := store.(storage.Timeline)
+	err := walkIncidentsOldestFirst(store, pageSize, func(page []*storage.IncidentRecord) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := bw.part(backupIncidentsDir, len(page), func(enc *json.Encoder) error {
+			for _, rec := range page {
+				if err := enc.Encode(rec); err != nil {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		counts[MigrationDomainIncidents] += len(page)
+		if !hasTimeline {
+			return nil
+		}
+		var entries []*storage.TimelineEntry
+		for _, rec := range page {
+			got, err := tl.ListTimeline(rec.ID, migrationTimelineLimit)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, got...)
+		}
+		counts[MigrationDomainTimeline] += len(entries)
+		return bw.part(backupTimelineDir, len(entries), func(enc *json.Encoder) error {
+			for _, e := range entries {
+				if err := enc.Encode(e); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("backup: incidents: %w", err)
+	}
+
+	err = walkAnalysesOldestFirst(store, pageSize, func(page []*storage.AnalysisRecord) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		counts[MigrationDomainAnalyses] += len(page)
+		return bw.part(backupAnalysesDir, len(page), func(enc *json.Encoder) error {
+			for _, rec := range page {
+				if err := enc.Encode(rec); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("backup: analyses: %w", err)
+	}
+
+	blobs, err := store.ListBlobs("")
+	if err != nil {
+		return fmt.Errorf("backup: blobs: %w", err)
+	}
+	for _, b := range blobs {
+		if b.Name == config.CatalogBlobName || (omitSecrets && strings.HasPrefix(b.Name, backupSecretsPrefix)) {
+			continue
+		}
+		if err := bw.entry(backupBlobsDir+b.Name, b.Data); err != nil {
+			return fmt.Errorf("backup: blob %s: %w", b.Name, err)
+		}
+		counts[MigrationDomainBlobs]++
+	}
+
+	patterns, services, err := agent.ReadCatalog(store)
+	if err != nil {
+		return fmt.Errorf("backup: catalog: %w", err)
+	}
+	counts[MigrationDomainPatterns] = len(patterns)
+	counts[MigrationDomainServices] = len(services)
+	return bw.json(backupCatalogEntry, backupCatalog{Patterns: patterns, Services: services})
+}
+
+// backupCatalog is the catalog.json entry.
+type backupCatalog struct {
+	Patterns map[string]*agent.Pattern     `json:"patterns"`
+	Services map[string]*agent.ServiceInfo `json:"services"`
+}
+
+// RestoreBackup reads an archive from r into store. It checks the manifest
+// and, unless opts.Force, that store holds no incidents, analyses, learned
+// patterns or blobs other than generated secrets, before it writes anything.
+// Records are upserted by id, so rerunning a restore that failed part way is
+// safe. The returned manifest carries the counts restored.
+func RestoreBackup(ctx context.Context, store storage.Provider, r io.Reader, opts RestoreOptions) (*BackupManifest, error) {
+	br := bufio.NewReader(r)
+	var in io.Reader = br
+	encrypted := isEncryptedBackup(br)
+	if encrypted {
+		if opts.Passphrase == "" {
+			return nil, ErrBackupPassphrase
+		}
+		dec, err := newBackupDecrypter(br, opts.Passphrase)
+		if err != nil {
+			return nil, err
+		}
+		in = dec
+	}
+	zr, err := zstd.NewReader(in)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBackupFormat, err)
+	}
+	defer zr.Close()
+	tr := tar.NewReader(zr)
+
+	manifest, err := readBackupManifest(tr)
+	if err != nil {
+		return nil, err
+	}
+	if !opts.Force {
+		if err := checkRestoreTargetEmpty(store); err != nil {
+			return manifest, err
+		}
+	}
+
+	restored := make(map[string]int)
+	var end *BackupManifest
+	for {
+		if err := ctx.Err(); err != nil {
+			return manifest, err
+		}
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return manifest, fmt.Errorf("%w: %v", ErrBackupTruncated, err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if hdr.Name == backupEndEntry {
+			end = &BackupManifest{}
+			if err := json.NewDecoder(tr).Decode(end); err != nil {
+				return manifest, fmt.Errorf("%w: end.json: %v", ErrBackupTruncated, err)
+			}
+			continue
+		}
+		if err := restoreBackupEntry(store, hdr.Name, tr, restored); err != nil {
+			return manifest, fmt.Errorf("backup: restore %s: %w", hdr.Name, err)
+		}
+	}
+	manifest.Counts = restored
+	if end == nil {
+		return manifest, fmt.Errorf("%w: end.json missing", ErrBackupTruncated)
+	}
+	var short []string
+	for domain, want := range end.Counts {
+		if restored[domain] != want {
+			short = append(short, fmt.Sprintf("%s: %d of %d", domain, restored[domain], want))
+		}
+	}
+	if len(short) > 0 {
+		return manifest, fmt.Errorf("%w: %s", ErrBackupTruncated, strings.Join(short, "; "))
+	}
+	return manifest, nil
+}
+
+func readBackupManifest(tr *tar.Reader) (*BackupManifest, error) {
+	hdr, err := tr.Next()
+	if errors.Is(err, ErrBackupPassphrase) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBackupFormat, err)
+	}
+	if hdr.Name != backupManifestEntry {
+		return nil, fmt.Errorf("%w: first entry is %q, want %s", ErrBackupFormat, hdr.Name, backupManifestEntry)
+	}
+	var m BackupManifest
+	if err := json.NewDecoder(tr).Decode(&m); err != nil {
+		return nil, fmt.Errorf("%w: manifest: %v", ErrBackupFormat, err)
+	}
+	if m.Format != BackupFormat {
+		return nil, fmt.Errorf("%w: format %q", ErrBackupFormat, m.Format)
+	}
+	if m.Version < 1 || m.Version > BackupVersion {
+		return nil, fmt.Errorf("%w: version %d, this build reads up to %d", ErrBackupVersion, m.Version, BackupVersion)
+	}
+	return &m, nil
+}
+
+// checkRestoreTargetEmpty returns ErrBackupTargetNotEmpty when store holds
+// any incident, analysis, learned pattern, or blob other than a generated
+// secret.
+func checkRestoreTargetEmpty(store storage.Provider) error {
+	if recs, err := store.ListIncidents(1); err != nil {
+		return err
+	} else if len(recs) > 0 {
+		return fmt.Errorf("%w: it holds incidents (use force to restore anyway)", ErrBackupTargetNotEmpty)
+	}
+	if recs, err := store.ListAnalyses(1); err != nil {
+		return err
+	} else if len(recs) > 0 {
+		return fmt.Errorf("%w: it holds analyses (use force to restore anyway)", ErrBackupTargetNotEmpty)
+	}
+	if patterns, _, err := agent.ReadCatalog(store); err != nil {
+		return err
+	} else if len(patterns) > 0 {
+		return fmt.Errorf("%w: it holds learned patterns (use force to restore anyway)", ErrBackupTargetNotEmpty)
+	}
+	blobs, err := store.ListBlobs("")
+	if err != nil {
+		return err
+	}
+	for _, b := range blobs {
+		if !strings.HasPrefix(b.Name, backupSecretsPrefix) {
+			return fmt.Errorf("%w: it holds blob %q (use force to restore anyway)", ErrBackupTargetNotEmpty, b.Name)
+		}
+	}
+	return nil
+}
+
+// restoreBackupEntry writes one archive entry into store.
+func restoreBackupEntry(store storage.Provider, name string, r io.Reader, restored map[string]int) error {
+	switch {
+	case strings.HasPrefix(name, backupIncidentsDir):
+		return decodeBackupPart(r, func(rec *storage.IncidentRecord) error {
+			restored[MigrationDomainIncidents]++
+			return store.SaveIncident(rec)
+		})
+	case strings.HasPrefix(name, backupTimelineDir):
+		tl, ok := store.(storage.Timeline)
+		if !ok {
+			return errors.New("the storage backend has no timeline")
+		}
+		have := make(map[string]map[string]bool)
+		return decodeBackupPart(r, func(e *storage.TimelineEntry) error {
+			restored[MigrationDomainTimeline]++
+			if have[e.IncidentID] == nil {
+				ids, err := timelineIDs(tl, e.IncidentID)
+				if err != nil {
+					return err
+				}
+				have[e.IncidentID] = ids
+			}
+			if have[e.IncidentID][e.ID] {
+				return nil
+			}
+			return tl.AppendTimelineEntry(e)
+		})
+	case strings.HasPrefix(name, backupAnalysesDir):
+		return decodeBackupPart(r, func(rec *storage.AnalysisRecord) error {
+			restored[MigrationDomainAnalyses]++
+			return store.SaveAnalysis(rec)
+		})
+	case strings.HasPrefix(name, backupBlobsDir):
+		blob := strings.TrimPrefix(name, backupBlobsDir)
+		if !validBackupBlobName(blob) {
+			return fmt.Errorf("invalid blob name %q", blob)
+		}
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		restored[MigrationDomainBlobs]++
+		return store.WriteBlob(blob, data)
+	case name == backupCatalogEntry:
+		var cat backupCatalog
+		if err := json.NewDecoder(r).Decode(&cat); err != nil {
+			return err
+		}
+		restored[MigrationDomainPatterns] += len(cat.Patterns)
+		restored[MigrationDomainServices] += len(cat.Services)
+		if len(cat.Patterns)+len(cat.Services) == 0 {
+			return nil
+		}
+		return agent.WriteCatalog(store, cat.Patterns, cat.Services)
+	default:
+		// An entry this build does not know; a later minor layout may add
+		// one without bumping the version.
+		return nil
+	}
+}
+
+// decodeBackupPart feeds each NDJSON record in r to fn.
+func decodeBackupPart[T any](r io.Reader, fn func(*T) error) error {
+	dec := json.NewDecoder(r)
+	for {
+		rec := new(T)
+		if err := dec.Decode(rec); errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+}
+
+// validBackupBlobName rejects blob names that could escape the file
+// backend's data directory or shadow its record files.
+func validBackupBlobName(name string) bool {
+	if name == "" || strings.HasPrefix(name, "/") || strings.Contains(name, `\`) || path.Clean(name) != name {
+		return false
+	}
+	for _, elem := range strings.Split(name, "/") {
+		if elem == ".." || elem == "." {
+			return false
+		}
+	}
+	switch name {
+	case "incidents", "analyses", "timeline":
+		return false
+	}
+	return true
+}
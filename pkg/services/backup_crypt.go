@@ -0,0 +1,215 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// backup_crypt.go — the optional passphrase encryption around a backup
+// archive. The compressed archive is cut into chunks sealed with AES-256-GCM
+// under a key derived from the passphrase with PBKDF2-SHA256. Each chunk's
+// nonce is the header nonce with the chunk counter folded into its tail, and
+// the last chunk is flagged and authenticated as last, so a reordered or
+// truncated file fails to decrypt instead of restoring part of an install.
+//
+// Layout: magic | salt (16) | PBKDF2 iterations (uint32) | nonce (12), then
+// chunks of uint32 length (top bit set on the last) | ciphertext.
+
+const (
+	backupCryptMagic      = "VSBKENC1"
+	backupCryptSaltSize   = 16
+	backupCryptChunkSize  = 64 << 10
+	backupCryptIterations = 600_000
+	// backupCryptMaxIterations caps the work factor read from a header so a
+	// crafted file cannot pin a CPU.
+	backupCryptMaxIterations = 10_000_000
+	backupCryptLastChunk     = 1 << 31
+)
+
+// ErrBackupPassphrase is returned when an encrypted archive is restored
+// without a passphrase or with the wrong one.
+var ErrBackupPassphrase = errors.New("backup: archive is encrypted; passphrase missing or wrong")
+
+func backupKey(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, iterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func backupChunkNonce(base []byte, counter uint64) []byte {
+	nonce := bytes.Clone(base)
+	tail := binary.BigEndian.Uint64(nonce[len(nonce)-8:]) ^ counter
+	binary.BigEndian.PutUint64(nonce[len(nonce)-8:], tail)
+	return nonce
+}
+
+// backupEncrypter seals everything written to it onto w. Close writes the
+// last chunk; without it the archive does not decrypt.
+type backupEncrypter struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	nonce   []byte
+	counter uint64
+	buf     []byte
+}
+
+func newBackupEncrypter(w io.Writer, passphrase string) (*backupEncrypter, error) {
+	salt := make([]byte, backupCryptSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	aead, err := backupKey(passphrase, salt, backupCryptIterations)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	header := append([]byte(backupCryptMagic), salt...)
+	header = binary.BigEndian.AppendUint32(header, backupCryptIterations)
+	header = append(header, nonce...)
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return &backupEncrypter{w: w, aead: aead, nonce: nonce, buf: make([]byte, 0, backupCryptChunkSize)}, nil
+}
+
+func (e *backupEncrypter) Write(p []byte) (int, error) {
+	n := 0
+	for len(p) > 0 {
+		take := min(backupCryptChunkSize-len(e.buf), len(p))
+		e.buf = append(e.buf, p[:take]...)
+		p, n = p[take:], n+take
+		if len(e.buf) == backupCryptChunkSize {
+			if err := e.seal(false); err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// Close seals the buffered tail as the last chunk. It does not close w.
+func (e *backupEncrypter) Close() error { return e.seal(true) }
+
+func (e *backupEncrypter) seal(last bool) error {
+	flag := []byte{0}
+	if last {
+		flag[0] = 1
+	}
+	sealed := e.aead.Seal(nil, backupChunkNonce(e.nonce, e.counter), e.buf, flag)
+	e.counter++
+	e.buf = e.buf[:0]
+	length := uint32(len(sealed))
+	if last {
+		length |= backupCryptLastChunk
+	}
+	if err := binary.Write(e.w, binary.BigEndian, length); err != nil {
+		return err
+	}
+	_, err := e.w.Write(sealed)
+	return err
+}
+
+// backupDecrypter opens the chunks a backupEncrypter wrote.
+type backupDecrypter struct {
+	r       io.Reader
+	aead    cipher.AEAD
+	nonce   []byte
+	counter uint64
+	plain   []byte
+	done    bool
+}
+
+// isEncryptedBackup reports whether r starts with the encryption magic,
+// without consuming it.
+func isEncryptedBackup(r *bufio.Reader) bool {
+	head, err := r.Peek(len(backupCryptMagic))
+	return err == nil && string(head) == backupCryptMagic
+}
+
+func newBackupDecrypter(r io.Reader, passphrase string) (*backupDecrypter, error) {
+	header := make([]byte, len(backupCryptMagic)+backupCryptSaltSize+4)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("backup: read encryption header: %w", err)
+	}
+	salt := header[len(backupCryptMagic) : len(backupCryptMagic)+backupCryptSaltSize]
+	iterations := int(binary.BigEndian.Uint32(header[len(header)-4:]))
+	if iterations <= 0 || iterations > backupCryptMaxIterations {
+		return nil, fmt.Errorf("backup: encryption header: bad iteration count %d", iterations)
+	}
+	aead, err := backupKey(passphrase, salt, iterations)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(r, nonce); err != nil {
+		return nil, fmt.Errorf("backup: read encryption header: %w", err)
+	}
+	return &backupDecrypter{r: r, aead: aead, nonce: nonce}, nil
+}
+
+func (d *backupDecrypter) Read(p []byte) (int, error) {
+	for len(d.plain) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		if err := d.open(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.plain)
+	d.plain = d.plain[n:]
+	return n, nil
+}
+
+func (d *backupDecrypter) open() error {
+	var length uint32
+	if err := binary.Read(d.r, binary.BigEndian, &length); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return fmt.Errorf("backup: encrypted archive truncated: %w", err)
+	}
+	last := length&backupCryptLastChunk != 0
+	length &^= backupCryptLastChunk
+	if int(length) > backupCryptChunkSize+d.aead.Overhead() {
+		return fmt.Errorf("backup: encrypted chunk of %d bytes is too large", length)
+	}
+	sealed := make([]byte, length)
+	if _, err := io.ReadFull(d.r, sealed); err != nil {
+		return fmt.Errorf("backup: encrypted archive truncated: %w", err)
+	}
+	flag := []byte{0}
+	if last {
+		flag[0] = 1
+	}
+	plain, err := d.aead.Open(nil, backupChunkNonce(d.nonce, d.counter), sealed, flag)
+	if err != nil {
+		// The first chunk is where a wrong key shows; later ones can only
+		// fail from damage.
+		if d.counter == 0 {
+			return ErrBackupPassphrase
+		}
+		return fmt.Errorf("backup: encrypted chunk %d failed authentication; the archive is corrupted", d.counter)
+	}
+	d.counter++
+	d.plain, d.done = plain, last
+	return nil
+}
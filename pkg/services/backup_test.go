@@ -0,0 +1,229 @@
+package services
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/VersusControl/versus-incident/pkg/agent"
+	"github.com/VersusControl/versus-incident/pkg/storage"
+	"github.com/klauspost/compress/zstd"
+)
+
+func writeTestBackup(t *testing.T, src storage.Provider, opts BackupOptions) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	opts.Backend, opts.PageSize = "file", 2
+	if _, err := WriteBackup(context.Background(), src, &buf, opts); err != nil {
+		t.Fatalf("WriteBackup: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// TestBackup_RoundTripIntoSQLite restores a file-backend archive into an
+// empty SQLite store and checks every domain arrives.
+func TestBackup_RoundTripIntoSQLite(t *testing.T) {
+	src := migrationSource(t)
+	_ = src.WriteBlob("secrets/ack-signing-key", []byte(`"k"`))
+	archive := writeTestBackup(t, src, BackupOptions{IncludeSecrets: true})
+
+	dst, err := storage.NewSQLite(storage.SQLiteOptions{Path: filepath.Join(t.TempDir(), "versus.db")})
+	if err != nil {
+		t.Fatalf("NewSQLite: %v", err)
+	}
+	defer dst.Close()
+	// A fresh server generates its signing key at boot; that alone must not
+	// block a restore.
+	_ = dst.WriteBlob("secrets/ack-signing-key", []byte(`"fresh"`))
+
+	m, err := RestoreBackup(context.Background(), dst, bytes.NewReader(archive), RestoreOptions{})
+	if err != nil {
+		t.Fatalf("RestoreBackup: %v", err)
+	}
+	want := map[string]int{
+		MigrationDomainIncidents: 5, MigrationDomainTimeline: 5, MigrationDomainAnalyses: 2,
+		MigrationDomainBlobs: 3, MigrationDomainPatterns: 1, MigrationDomainServices: 1,
+	}
+	for domain, n := range want {
+		if m.Counts[domain] != n {
+			t.Errorf("restored %s = %d, want %d", domain, m.Counts[domain], n)
+		}
+	}
+	if list, _ := dst.ListIncidents(0); len(list) != 5 || list[0].ID != "inc-4" {
+		t.Fatalf("incidents = %d", len(list))
+	}
+	if tl, _ := dst.(storage.Timeline).ListTimeline("inc-3", 0); len(tl) != 1 || tl[0].Body != "looking" {
+		t.Fatalf("timeline = %+v", tl)
+	}
+	if b, _ := dst.ReadBlob("models/default/intel/svc"); string(b) != `{"k":1}` {
+		t.Fatalf("namespaced blob = %q", b)
+	}
+	if b, _ := dst.ReadBlob("secrets/ack-signing-key"); string(b) != `"k"` {
+		t.Fatalf("signing key = %q, want the archived one", b)
+	}
+	patterns, services, err := agent.ReadCatalog(dst)
+	if err != nil || patterns["p1"] == nil || patterns["p1"].Verdict != "known" || services["api"] == nil {
+		t.Fatalf("catalog = %v %v %v", patterns, services, err)
+	}
+
+	// The target now holds data: a second restore is refused unless forced,
+	// and a forced one replaces records in place.
+	if _, err := RestoreBackup(context.Background(), dst, bytes.NewReader(archive), RestoreOptions{}); !errors.Is(err, ErrBackupTargetNotEmpty) {
+		t.Fatalf("second restore err = %v, want ErrBackupTargetNotEmpty", err)
+	}
+	if _, err := RestoreBackup(context.Background(), dst, bytes.NewReader(archive), RestoreOptions{Force: true}); err != nil {
+		t.Fatalf("forced restore: %v", err)
+	}
+	if tl, _ := dst.(storage.Timeline).ListTimeline("inc-3", 0); len(tl) != 1 {
+		t.Fatalf("forced restore duplicated the timeline: %d entries", len(tl))
+	}
+}
+
+// TestBackup_Encrypted needs the passphrase to restore, and rejects a wrong
+// one before writing anything.
+func TestBackup_Encrypted(t *testing.T) {
+	archive := writeTestBackup(t, migrationSource(t), BackupOptions{Passphrase: "correct horse"})
+	if bytes.Contains(archive, []byte("disk full")) {
+		t.Fatal("encrypted archive holds plaintext")
+	}
+
+	for _, pass := range []string{"", "wrong"} {
+		dst := storage.NewMemory()
+		if _, err := RestoreBackup(context.Background(), dst, bytes.NewReader(archive), RestoreOptions{Passphrase: pass}); !errors.Is(err, ErrBackupPassphrase) {
+			t.Fatalf("passphrase %q: err = %v, want ErrBackupPassphrase", pass, err)
+		}
+		if list, _ := dst.ListIncidents(0); len(list) != 0 {
+			t.Fatalf("passphrase %q: restored %d incidents", pass, len(list))
+		}
+	}
+
+	dst := storage.NewMemory()
+	if _, err := RestoreBackup(context.Background(), dst, bytes.NewReader(archive), RestoreOptions{Passphrase: "correct horse"}); err != nil {
+		t.Fatalf("RestoreBackup: %v", err)
+	}
+	if list, _ := dst.ListIncidents(0); len(list) != 5 {
+		t.Fatalf("incidents = %d, want 5", len(list))
+	}
+
+	if _, err := RestoreBackup(context.Background(), storage.NewMemory(), bytes.NewReader(archive[:len(archive)-10]), RestoreOptions{Passphrase: "correct horse"}); err == nil {
+		t.Fatal("truncated encrypted archive restored without error")
+	}
+}
+
+// TestBackup_SecretsNeedPassphraseOrFlag leaves the generated secrets out of
+// an unencrypted archive by default, and refuses one of a store encrypted at
+// rest unless secrets are explicitly included.
+func TestBackup_SecretsNeedPassphraseOrFlag(t *testing.T) {
+	src := migrationSource(t)
+	_ = src.WriteBlob("secrets/ack-signing-key", []byte(`"signing-key-bytes"`))
+
+	var buf bytes.Buffer
+	m, err := WriteBackup(context.Background(), src, &buf, BackupOptions{})
+	if err != nil {
+		t.Fatalf("WriteBackup: %v", err)
+	}
+	if !m.SecretsOmitted || m.Counts[MigrationDomainBlobs] != 2 {
+		t.Fatalf("manifest = %+v, want secrets omitted", m)
+	}
+	if bytes.Contains(decompressBackup(t, buf.Bytes()), []byte("signing-key-bytes")) {
+		t.Fatal("unencrypted archive holds a generated secret")
+	}
+	dst := storage.NewMemory()
+	if _, err := RestoreBackup(context.Background(), dst, bytes.NewReader(buf.Bytes()), RestoreOptions{}); err != nil {
+		t.Fatalf("RestoreBackup: %v", err)
+	}
+	if b, _ := dst.ReadBlob("secrets/ack-signing-key"); b != nil {
+		t.Fatalf("restored a secret that was left out: %q", b)
+	}
+
+	sealed := BackupOptions{SealedAtRest: true}
+	buf.Reset()
+	if _, err := WriteBackup(context.Background(), src, &buf, sealed); !errors.Is(err, ErrBackupSecrets) {
+		t.Fatalf("sealed store without passphrase: err = %v, want ErrBackupSecrets", err)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("a refused backup wrote %d bytes", buf.Len())
+	}
+	for _, opts := range []BackupOptions{
+		{SealedAtRest: true, Passphrase: "correct horse"},
+		{SealedAtRest: true, IncludeSecrets: true},
+	} {
+		buf.Reset()
+		m, err := WriteBackup(context.Background(), src, &buf, opts)
+		if err != nil || m.SecretsOmitted || m.Counts[MigrationDomainBlobs] != 3 {
+			t.Fatalf("opts %+v: manifest = %+v, err = %v; want secrets included", opts, m, err)
+		}
+	}
+}
+
+// decompressBackup returns the tar stream of an unencrypted archive.
+func decompressBackup(t *testing.T, archive []byte) []byte {
+	t.Helper()
+	zr, err := zstd.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		t.Fatalf("zstd: %v", err)
+	}
+	defer zr.Close()
+	var out bytes.Buffer
+	if _, err := out.ReadFrom(zr); err != nil {
+		t.Fatalf("decompress: %v", err)
+	}
+	return out.Bytes()
+}
+
+// TestBackup_RejectsBadArchives covers a newer version, a missing end.json,
+// and a blob name that escapes the data directory.
+func TestBackup_RejectsBadArchives(t *testing.T) {
+	build := func(entries ...[2]string) []byte {
+		var buf bytes.Buffer
+		zw, _ := zstd.NewWriter(&buf)
+		tw := tar.NewWriter(zw)
+		for _, e := range entries {
+			_ = tw.WriteHeader(&tar.Header{Name: e[0], Mode: 0o600, Size: int64(len(e[1])), Typeflag: tar.TypeReg})
+			_, _ = tw.Write([]byte(e[1]))
+		}
+		_ = tw.Close()
+		_ = zw.Close()
+		return buf.Bytes()
+	}
+	manifest := func(version int) [2]string {
+		b, _ := json.Marshal(BackupManifest{Format: BackupFormat, Version: version})
+		return [2]string{backupManifestEntry, string(b)}
+	}
+	end := [2]string{backupEndEntry, `{"format":"versus-backup","version":1,"counts":{"blobs":1}}`}
+
+	cases := []struct {
+		name    string
+		archive []byte
+		want    error
+	}{
+		{"not an archive", []byte("hello"), ErrBackupFormat},
+		{"newer version", build(manifest(BackupVersion+1), end), ErrBackupVersion},
+		{"manifest not first", build([2]string{"blobs/teams", "{}"}, manifest(1), end), ErrBackupFormat},
+		{"truncated", build(manifest(1), [2]string{"blobs/teams", "{}"}), ErrBackupTruncated},
+		{"count mismatch", build(manifest(1), end), ErrBackupTruncated},
+	}
+	for _, tc := range cases {
+		if _, err := RestoreBackup(context.Background(), storage.NewMemory(), bytes.NewReader(tc.archive), RestoreOptions{}); !errors.Is(err, tc.want) {
+			t.Errorf("%s: err = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+
+	dir := t.TempDir()
+	dst, err := storage.NewFile(storage.FileOptions{DataDir: filepath.Join(dir, "data")})
+	if err != nil {
+		t.Fatalf("NewFile: %v", err)
+	}
+	defer dst.Close()
+	evil := build(manifest(1), [2]string{"blobs/../escaped", "{}"}, end)
+	if _, err := RestoreBackup(context.Background(), dst, bytes.NewReader(evil), RestoreOptions{}); err == nil {
+		t.Fatal("blob name with .. restored without error")
+	}
+	if blobs, _ := dst.ListBlobs(""); len(blobs) != 0 {
+		t.Fatalf("escaping blob written: %v", blobs)
+	}
+}
@@ -0,0 +1,465 @@
+package services
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/storage"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// export.go — streaming incident export (CSV, NDJSON, Parquet).
+//
+// Incidents are read one bounded page at a time, newest first, and written
+// to the output as each page arrives, so an export never holds more than
+// one page of records. A backend with storage.RangeLister is walked by
+// keyset on created_at, each page bounded by the requested range, so
+// incidents created while the export runs neither shift nor repeat pages.
+// A search query goes through the IncidentSearchPager, and a backend with
+// neither capability is paged through IncidentPager; both stop at the
+// first incident older than the range. Without any pager the export falls
+// back to incidentsInWindow.
+
+// Export formats.
+const (
+	ExportCSV     = "csv"
+	ExportNDJSON  = "ndjson"
+	ExportParquet = "parquet"
+)
+
+// exportPageSize is the number of incidents read per page.
+const exportPageSize = 500
+
+// exportRowGroupSize bounds the rows a Parquet row group buffers before it
+// is flushed to the output.
+const exportRowGroupSize = 5000
+
+// ErrExportNoStorage is returned when no storage backend is configured.
+var ErrExportNoStorage = errors.New("export: storage not configured")
+
+// ErrExportSearchUnsupported is returned when an export filters by search
+// query but the backend has no IncidentSearchPager.
+var ErrExportSearchUnsupported = errors.New("export: search not supported by the configured storage backend")
+
+// IsExportFormat reports whether f is one of the export formats.
+func IsExportFormat(f string) bool {
+	switch f {
+	case ExportCSV, ExportNDJSON, ExportParquet:
+		return true
+	}
+	return false
+}
+
+// ExportContentType is the response content type for format f.
+func ExportContentType(f string) string {
+	switch f {
+	case ExportCSV:
+		return "text/csv; charset=utf-8"
+	case ExportNDJSON:
+		return "application/x-ndjson"
+	default:
+		return "application/vnd.apache.parquet"
+	}
+}
+
+// ExportFilter selects the incidents to export: created in [Start, End)
+// (a zero End is open), optionally narrowed by origin, service (the
+// record's service label, case-insensitive) and a search query.
+type ExportFilter struct {
+	Start   time.Time
+	End     time.Time
+	Origin  string
+	Service string
+	Query   string
+}
+
+// CheckExport reports, before any output is written, whether st can serve
+// filter: it needs a backend, and a search query needs an
+// IncidentSearchPager.
+func CheckExport(st storage.Provider, filter ExportFilter) error {
+	if st == nil {
+		return ErrExportNoStorage
+	}
+	if filter.Query != "" {
+		if _, ok := st.(storage.IncidentSearchPager); !ok {
+			return ErrExportSearchUnsupported
+		}
+	}
+	return nil
+}
+
+// ExportIncidents writes every incident matching filter to w in format,
+// returning the number written. When scrub is true the payload content and
+// title go through the report scrubber first, so no unredacted payload
+// leaves the box. Call CheckExport first; errors after the first byte can
+// only be logged by the caller.
+func ExportIncidents(st storage.Provider, w io.Writer, format string, filter ExportFilter, scrub bool) (int, error) {
+	if err := CheckExport(st, filter); err != nil {
+		return 0, err
+	}
+	enc, err := newExportEncoder(w, format)
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now().UTC()
+	n := 0
+	err = eachExportIncident(st, filter, func(rec *storage.IncidentRecord) error {
+		row, err := newExportRow(rec, now, scrub)
+		if err != nil {
+			return err
+		}
+		n++
+		return enc.write(row)
+	})
+	if cerr := enc.close(); err == nil {
+		err = cerr
+	}
+	return n, err
+}
+
+// eachExportIncident calls fn for every incident matching filter, newest
+// first, reading one page at a time.
+func eachExportIncident(st storage.Provider, filter ExportFilter, fn func(*storage.IncidentRecord) error) error {
+	if rl, ok := st.(storage.RangeLister); ok && filter.Query == "" {
+		return eachExportInRange(rl, filter, fn)
+	}
+	var page func(offset int) ([]*storage.IncidentRecord, error)
+	if sp, ok := st.(storage.IncidentSearchPager); ok && filter.Query != "" {
+		page = func(offset int) ([]*storage.IncidentRecord, error) {
+			return sp.SearchIncidentsPage(filter.Query, filter.Origin, offset, exportPageSize)
+		}
+	} else if ip, ok := st.(storage.IncidentPager); ok && filter.Query == "" {
+		page = func(offset int) ([]*storage.IncidentRecord, error) {
+			return ip.ListIncidentsPage(filter.Origin, offset, exportPageSize)
+		}
+	}
+	if page == nil {
+		recs, err := incidentsInWindow(st, filter.Start, filter.End)
+		if err != nil {
+			return err
+		}
+		for _, rec := range recs {
+			if rec != nil && exportMatches(rec, filter) {
+				if err := fn(rec); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	// Incidents created while the export runs shift later pages down, so
+	// the last page's ids are remembered to skip the repeats.
+	prev := map[string]bool{}
+	for offset := 0; ; offset += exportPageSize {
+		recs, err := page(offset)
+		if err != nil {
+			return err
+		}
+		seen := make(map[string]bool, len(recs))
+		for _, rec := range recs {
+			if rec == nil || prev[rec.ID] {
+				continue
+			}
+			seen[rec.ID] = true
+			created := rec.CreatedAt.UTC()
+			if created.Before(filter.Start) {
+				return nil
+			}
+			if !filter.End.IsZero() && !created.Before(filter.End) {
+				continue
+			}
+			if !exportMatches(rec, filter) {
+				continue
+			}
+			if err := fn(rec); err != nil {
+				return err
+			}
+		}
+		if len(recs) < exportPageSize {
+			return nil
+		}
+		prev = seen
+	}
+}
+
+// exportKeysetTick is the step from the oldest incident of one page to the
+// exclusive end of the next. It is the coarsest timestamp grain among the
+// backends (Postgres keeps microseconds), so the next page starts at that
+// incident's own tick; the ids already written there are skipped.
+const exportKeysetTick = time.Microsecond
+
+// eachExportInRange walks [filter.Start, filter.End) by keyset on
+// created_at: each page ends just after the oldest incident of the one
+// before it. A page holding nothing new — more incidents share one tick
+// than fit in it — is read again at twice the size.
+func eachExportInRange(rl storage.RangeLister, filter ExportFilter, fn func(*storage.IncidentRecord) error) error {
+	end := filter.End
+	limit := exportPageSize
+	written := map[string]time.Time{} // ids written at or after the cursor's tick
+	for {
+		recs, err := rl.ListIncidentsInRange(filter.Start, end, limit)
+		if err != nil {
+			return err
+		}
+		var oldest time.Time
+		fresh := 0
+		for _, rec := range recs {
+			if rec == nil {
+				continue
+			}
+			created := rec.CreatedAt.UTC()
+			if oldest.IsZero() || created.Before(oldest) {
+				oldest = created
+			}
+			if _, ok := written[rec.ID]; ok {
+				continue
+			}
+			fresh++
+			written[rec.ID] = created
+			if !exportMatches(rec, filter) {
+				continue
+			}
+			if err := fn(rec); err != nil {
+				return err
+			}
+		}
+		if len(recs) < limit {
+			return nil
+		}
+		if fresh == 0 {
+			limit *= 2
+			continue
+		}
+		limit = exportPageSize
+		cursor := oldest.Truncate(exportKeysetTick)
+		end = cursor.Add(exportKeysetTick)
+		for id, created := range written {
+			if created.Before(cursor) {
+				delete(written, id)
+			}
+		}
+	}
+}
+
+// exportMatches applies the filters the page source does not: origin (the
+// plain list only narrows the two known origins) and service.
+func exportMatches(rec *storage.IncidentRecord, filter ExportFilter) bool {
+	if filter.Origin != "" && rec.EffectiveOrigin() != filter.Origin {
+		return false
+	}
+	return filter.Service == "" || strings.EqualFold(rec.ServiceLabel(), filter.Service)
+}
+
+// exportRow is one exported incident. Lists are native in NDJSON and
+// Parquet and ";"-joined in CSV; labels, custom fields and the payload are
+// JSON in every format.
+type exportRow struct {
+	ID                string          `json:"id" parquet:"id"`
+	CreatedAt         time.Time       `json:"created_at" parquet:"created_at,timestamp(millisecond)"`
+	Origin            string          `json:"origin" parquet:"origin"`
+	Source            string          `json:"source,omitempty" parquet:"source"`
+	Service           string          `json:"service,omitempty" parquet:"service"`
+	Title             string          `json:"title,omitempty" parquet:"title"`
+	Severity          string          `json:"severity,omitempty" parquet:"severity"`
+	Priority          string          `json:"priority,omitempty" parquet:"priority"`
+	Status            string          `json:"status" parquet:"status"`
+	Resolved          bool            `json:"resolved" parquet:"resolved"`
+	AckedAt           *time.Time      `json:"acked_at,omitempty" parquet:"acked_at,optional,timestamp(millisecond)"`
+	ResolvedAt        *time.Time      `json:"resolved_at,omitempty" parquet:"resolved_at,optional,timestamp(millisecond)"`
+	OrgID             string          `json:"org_id,omitempty" parquet:"org_id"`
+	TeamID            string          `json:"team_id,omitempty" parquet:"team_id"`
+	AssignedTeamID    string          `json:"assigned_team_id,omitempty" parquet:"assigned_team_id"`
+	AssignedMemberIDs []string        `json:"assigned_member_ids,omitempty" parquet:"assigned_member_ids,list"`
+	Tags              []string        `json:"tags,omitempty" parquet:"tags,list"`
+	Labels            json.RawMessage `json:"labels,omitempty" parquet:"labels,optional,json"`
+	CustomFields      json.RawMessage `json:"custom_fields,omitempty" parquet:"custom_fields,optional,json"`
+	MergedInto        string          `json:"merged_into,omitempty" parquet:"merged_into"`
+	ParentID          string          `json:"parent_id,omitempty" parquet:"parent_id"`
+	RelatedIDs        []string        `json:"related_ids,omitempty" parquet:"related_ids,list"`
+	OnCallTriggered   bool            `json:"oncall_triggered" parquet:"oncall_triggered"`
+	NotifyStatus      string          `json:"notify_status,omitempty" parquet:"notify_status"`
+	Content           json.RawMessage `json:"content,omitempty" parquet:"content,optional,json"`
+}
+
+// exportColumns is the CSV header, in exportRow field order.
+var exportColumns = []string{
+	"id", "created_at", "origin", "source", "service", "title", "severity",
+	"priority", "status", "resolved", "acked_at", "resolved_at", "org_id",
+	"team_id", "assigned_team_id", "assigned_member_ids", "tags", "labels",
+	"custom_fields", "merged_into", "parent_id", "related_ids",
+	"oncall_triggered", "notify_status", "content",
+}
+
+func newExportRow(rec *storage.IncidentRecord, now time.Time, scrub bool) (*exportRow, error) {
+	title, content := rec.Title, rec.Content
+	if scrub {
+		s := reportScrubber()
+		title = s.Scrub(title)
+		content = scrubContent(s.Scrub, content)
+	}
+	row := &exportRow{
+		ID:                rec.ID,
+		CreatedAt:         rec.CreatedAt.UTC(),
+		Origin:            rec.EffectiveOrigin(),
+		Source:            rec.Source,
+		Service:           rec.ServiceLabel(),
+		Title:             title,
+		Severity:          rec.SeverityLabel(),
+		Priority:          rec.Priority,
+		Status:            rec.EffectiveStatus(now),
+		Resolved:          rec.Resolved,
+		AckedAt:           rec.AckedAt,
+		ResolvedAt:        rec.ResolvedAt,
+		OrgID:             rec.OrgID,
+		TeamID:            rec.TeamID,
+		AssignedTeamID:    rec.AssignedTeamID,
+		AssignedMemberIDs: rec.AssignedMemberIDs,
+		Tags:              rec.Tags,
+		MergedInto:        rec.MergedInto,
+		ParentID:          rec.ParentID,
+		RelatedIDs:        rec.RelatedIDs,
+		OnCallTriggered:   rec.OnCallTriggered,
+		NotifyStatus:      rec.NotifyStatus,
+	}
+	var err error
+	if len(rec.Labels) > 0 {
+		if row.Labels, err = json.Marshal(rec.Labels); err != nil {
+			return nil, err
+		}
+	}
+	if len(rec.CustomFields) > 0 {
+		if row.CustomFields, err = json.Marshal(rec.CustomFields); err != nil {
+			return nil, err
+		}
+	}
+	if len(content) > 0 {
+		if row.Content, err = json.Marshal(content); err != nil {
+			return nil, err
+		}
+	}
+	return row, nil
+}
+
+// scrubContent returns a copy of content with every string value, at any
+// depth, passed through scrub. Keys are kept as they are.
+func scrubContent(scrub func(string) string, content map[string]interface{}) map[string]interface{} {
+	if content == nil {
+		return nil
+	}
+	out := make(map[string]interface{}, len(content))
+	for k, v := range content {
+		out[k] = scrubValue(scrub, v)
+	}
+	return out
+}
+
+func scrubValue(scrub func(string) string, v interface{}) interface{} {
+	switch t := v.(type) {
+	case string:
+		return scrub(t)
+	case map[string]interface{}:
+		return scrubContent(scrub, t)
+	case []interface{}:
+		out := make([]interface{}, len(t))
+		for i, e := range t {
+			out[i] = scrubValue(scrub, e)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// exportEncoder writes rows in one format.
+type exportEncoder interface {
+	write(row *exportRow) error
+	close() error
+}
+
+func newExportEncoder(w io.Writer, format string) (exportEncoder, error) {
+	switch format {
+	case ExportCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(exportColumns); err != nil {
+			return nil, err
+		}
+		return &csvExportEncoder{w: cw}, nil
+	case ExportNDJSON:
+		bw := bufio.NewWriter(w)
+		return &ndjsonExportEncoder{bw: bw, enc: json.NewEncoder(bw)}, nil
+	case ExportParquet:
+		return &parquetExportEncoder{w: parquet.NewGenericWriter[exportRow](w,
+			parquet.MaxRowsPerRowGroup(exportRowGroupSize),
+			parquet.CreatedBy("versus-incident", "", ""),
+		)}, nil
+	default:
+		return nil, fmt.Errorf("export: unknown format %q", format)
+	}
+}
+
+type csvExportEncoder struct{ w *csv.Writer }
+
+func (e *csvExportEncoder) write(r *exportRow) error {
+	return e.w.Write([]string{
+		r.ID, csvTime(&r.CreatedAt), r.Origin, csvCell(r.Source),
+		csvCell(r.Service), csvCell(r.Title), csvCell(r.Severity), r.Priority,
+		r.Status, strconv.FormatBool(r.Resolved), csvTime(r.AckedAt),
+		csvTime(r.ResolvedAt), csvCell(r.OrgID), csvCell(r.TeamID),
+		csvCell(r.AssignedTeamID), csvCell(strings.Join(r.AssignedMemberIDs, ";")),
+		csvCell(strings.Join(r.Tags, ";")), csvCell(string(r.Labels)),
+		csvCell(string(r.CustomFields)), r.MergedInto, r.ParentID,
+		strings.Join(r.RelatedIDs, ";"), strconv.FormatBool(r.OnCallTriggered),
+		r.NotifyStatus, csvCell(string(r.Content)),
+	})
+}
+
+func (e *csvExportEncoder) close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// csvCell neutralizes a value a spreadsheet would evaluate as a formula.
+// Payload fields are attacker-influenced, so a leading = + - @ (or a tab or
+// carriage return) is prefixed with a single quote.
+func csvCell(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+func csvTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+type ndjsonExportEncoder struct {
+	bw  *bufio.Writer
+	enc *json.Encoder
+}
+
+func (e *ndjsonExportEncoder) write(r *exportRow) error { return e.enc.Encode(r) }
+
+func (e *ndjsonExportEncoder) close() error { return e.bw.Flush() }
+
+type parquetExportEncoder struct {
+	w *parquet.GenericWriter[exportRow]
+}
+
+func (e *parquetExportEncoder) write(r *exportRow) error {
+	_, err := e.w.Write([]exportRow{*r})
+	return err
+}
+
+func (e *parquetExportEncoder) close() error { return e.w.Close() }
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/storage"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// secretScrubber redacts the literal "hunter2".
+type secretScrubber struct{}
+
+func (secretScrubber) Scrub(s string) string { return strings.ReplaceAll(s, "hunter2", "[REDACTED]") }
+
+func exportStore(t *testing.T, base time.Time) storage.Provider {
+	t.Helper()
+	st := storage.NewMemory()
+	for _, r := range []*storage.IncidentRecord{
+		{ID: "old", CreatedAt: base.Add(-48 * time.Hour), Service: "api"},
+		{ID: "api-1", CreatedAt: base.Add(time.Hour), Service: "api", Title: "=cmd()", Tags: []string{"a", "b"},
+			Content: map[string]interface{}{"password": "hunter2", "nested": []interface{}{map[string]interface{}{"k": "hunter2"}}}},
+		{ID: "db-1", CreatedAt: base.Add(2 * time.Hour), Service: "db", Origin: storage.OriginAIDetect, Labels: map[string]string{"env": "prod"}},
+		{ID: "future", CreatedAt: base.Add(48 * time.Hour), Service: "api"},
+	} {
+		if err := st.SaveIncident(r); err != nil {
+			t.Fatalf("SaveIncident: %v", err)
+		}
+	}
+	return st
+}
+
+func TestExportIncidents_CSVFiltersAndScrubs(t *testing.T) {
+	SetReportRedactor(secretScrubber{})
+	t.Cleanup(func() { SetReportRedactor(nil) })
+	base := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	st := exportStore(t, base)
+	filter := ExportFilter{Start: base, End: base.Add(24 * time.Hour)}
+
+	var buf bytes.Buffer
+	n, err := ExportIncidents(st, &buf, ExportCSV, filter, true)
+	if err != nil || n != 2 {
+		t.Fatalf("ExportIncidents = %d, %v; want the 2 incidents in range", n, err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("csv: %v", err)
+	}
+	if len(rows) != 3 || rows[0][0] != "id" || len(rows[0]) != len(exportColumns) {
+		t.Fatalf("csv rows = %v", rows)
+	}
+	api := rows[2]
+	if api[0] != "api-1" || api[5] != "'=cmd()" || api[16] != "a;b" {
+		t.Fatalf("api-1 row = %v; want the formula title quoted and tags joined", api)
+	}
+	content := api[len(api)-1]
+	if strings.Contains(content, "hunter2") || !strings.Contains(content, "[REDACTED]") {
+		t.Fatalf("content = %s, want the secret scrubbed at every depth", content)
+	}
+
+	buf.Reset()
+	_, _ = ExportIncidents(st, &buf, ExportCSV, filter, false)
+	if !strings.Contains(buf.String(), "hunter2") {
+		t.Fatal("scrub=false still scrubbed the payload")
+	}
+
+	buf.Reset()
+	filter.Service = "DB"
+	if n, _ := ExportIncidents(st, &buf, ExportCSV, filter, true); n != 1 || !strings.Contains(buf.String(), "db-1") {
+		t.Fatalf("service filter exported %d: %s", n, buf.String())
+	}
+}
+
+func TestExportIncidents_NDJSONAndParquet(t *testing.T) {
+	base := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	st := exportStore(t, base)
+	filter := ExportFilter{Start: base, End: base.Add(24 * time.Hour), Origin: storage.OriginAIDetect}
+
+	var buf bytes.Buffer
+	if n, err := ExportIncidents(st, &buf, ExportNDJSON, filter, true); err != nil || n != 1 {
+		t.Fatalf("ndjson = %d, %v", n, err)
+	}
+	var row map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &row); err != nil {
+		t.Fatalf("ndjson line: %v (%s)", err, buf.String())
+	}
+	if row["id"] != "db-1" || row["labels"].(map[string]interface{})["env"] != "prod" {
+		t.Fatalf("ndjson row = %v", row)
+	}
+
+	buf.Reset()
+	filter.Origin = ""
+	if n, err := ExportIncidents(st, &buf, ExportParquet, filter, true); err != nil || n != 2 {
+		t.Fatalf("parquet = %d, %v", n, err)
+	}
+	got, err := parquet.Read[exportRow](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("parquet.Read: %v", err)
+	}
+	if len(got) != 2 || got[0].ID != "db-1" || got[1].ID != "api-1" || len(got[1].Tags) != 2 {
+		t.Fatalf("parquet rows = %+v", got)
+	}
+}
+
+// TestExportIncidents_PagesAndStopsAtRange exports across several pages and
+// checks nothing is repeated or lost and the walk ends at the range start.
+func TestExportIncidents_PagesAndStopsAtRange(t *testing.T) {
+	base := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	st := storage.NewMemory()
+	const total = exportPageSize*2 + 7
+	for i := 0; i < total; i++ {
+		rec := &storage.IncidentRecord{ID: fmt.Sprintf("inc-%04d", i), CreatedAt: base.Add(time.Duration(i) * time.Minute)}
+		if err := st.SaveIncident(rec); err != nil {
+			t.Fatalf("SaveIncident: %v", err)
+		}
+	}
+	seen := map[string]bool{}
+	err := eachExportIncident(st, ExportFilter{Start: base.Add(10 * time.Minute)}, func(r *storage.IncidentRecord) error {
+		if seen[r.ID] {
+			t.Fatalf("%s exported twice", r.ID)
+		}
+		seen[r.ID] = true
+		return nil
+	})
+	if err != nil || len(seen) != total-10 {
+		t.Fatalf("exported %d, %v; want %d", len(seen), err, total-10)
+	}
+}
+
+// keysetStore is a memory store with a RangeLister that honours limit, so
+// the export walks it by keyset. Each call is logged, and onPage runs after
+// it to simulate writes landing mid-export.
+type keysetStore struct {
+	storage.Provider
+	ends   []time.Time
+	onPage func()
+}
+
+func (k *keysetStore) ListIncidentsInRange(start, end time.Time, limit int) ([]*storage.IncidentRecord, error) {
+	k.ends = append(k.ends, end)
+	all, err := k.ListIncidents(0)
+	if err != nil {
+		return nil, err
+	}
+	var out []*storage.IncidentRecord
+	for _, r := range all {
+		if !r.CreatedAt.Before(start) && (end.IsZero() || r.CreatedAt.Before(end)) {
+			out = append(out, r)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	if k.onPage != nil {
+		k.onPage()
+	}
+	return out, nil
+}
+
+// TestExportIncidents_KeysetOverRangeLister exports through a RangeLister:
+// a run of incidents sharing one timestamp larger than a page is neither
+// lost nor repeated, incidents created while the export runs at a time the
+// walk has passed do not shift it, and every page stays inside the range.
+func TestExportIncidents_KeysetOverRangeLister(t *testing.T) {
+	base := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	mem := storage.NewMemory()
+	save := func(id string, at time.Time) {
+		t.Helper()
+		if err := mem.SaveIncident(&storage.IncidentRecord{ID: id, CreatedAt: at}); err != nil {
+			t.Fatalf("SaveIncident: %v", err)
+		}
+	}
+	want := 0
+	for i := 0; i < exportPageSize+3; i++ {
+		save(fmt.Sprintf("tie-%04d", i), base.Add(time.Hour))
+		want++
+	}
+	for i := 0; i < exportPageSize; i++ {
+		save(fmt.Sprintf("inc-%04d", i), base.Add(time.Duration(i)*time.Second))
+		want++
+	}
+	save("before", base.Add(-time.Minute))
+	save("after", base.Add(2*time.Hour))
+
+	late := 0
+	st := &keysetStore{Provider: mem}
+	st.onPage = func() {
+		late++
+		save(fmt.Sprintf("late-%d", late), base.Add(90*time.Minute))
+	}
+	end := base.Add(2 * time.Hour)
+	seen := map[string]bool{}
+	err := eachExportIncident(st, ExportFilter{Start: base, End: end}, func(r *storage.IncidentRecord) error {
+		if seen[r.ID] {
+			t.Fatalf("%s exported twice", r.ID)
+		}
+		seen[r.ID] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if seen["before"] || seen["after"] {
+		t.Errorf("exported an incident outside the range")
+	}
+	if seen["late-1"] {
+		t.Errorf("an incident newer than the cursor was exported")
+	}
+	for i := 0; i < exportPageSize+3; i++ {
+		if !seen[fmt.Sprintf("tie-%04d", i)] {
+			t.Fatalf("tie-%04d lost across the page boundary", i)
+		}
+	}
+	for i := 0; i < exportPageSize; i++ {
+		if !seen[fmt.Sprintf("inc-%04d", i)] {
+			t.Fatalf("inc-%04d lost", i)
+		}
+	}
+	if len(seen) != want {
+		t.Errorf("exported %d, want %d", len(seen), want)
+	}
+	for _, e := range st.ends {
+		if e.IsZero() || e.After(end) {
+			t.Fatalf("page end %v escapes the range end %v", e, end)
+		}
+	}
+}
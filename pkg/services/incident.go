@@ -144,6 +144,7 @@ func CreateIncident(teamID string, content *map[string]interface{}, params ...*m
 		if err := store.SaveIncident(rec); err != nil {
 			log.Printf("incident: persist status warning: %v", err)
 		}
+		RecordTimeline(notificationTimelineEntry(rec))
 	}
 
 	// On-call escalation. We still kick this off even when *some*
@@ -164,6 +165,7 @@ func CreateIncident(teamID string, content *map[string]interface{}, params ...*m
 				if err := store.SaveIncident(rec); err != nil {
 					log.Printf("incident: persist oncall status warning: %v", err)
 				}
+				RecordTimeline(oncallTimelineEntry(rec, "On-call skipped: workflow not initialized", nil))
 			}
 		} else {
 			workflow := core.GetOnCallWorkflow()
@@ -179,7 +181,10 @@ func CreateIncident(teamID string, content *map[string]interface{}, params ...*m
 					if err := store.SaveIncident(rec); err != nil {
 						log.Printf("incident: persist oncall status warning: %v", err)
 					}
+					RecordTimeline(oncallTimelineEntry(rec, "On-call escalation failed", err))
 				}
+			} else if rec != nil {
+				RecordTimeline(oncallTimelineEntry(rec, "On-call escalation started", nil))
 			}
 		}
 	}
@@ -190,12 +195,20 @@ func CreateIncident(teamID string, content *map[string]interface{}, params ...*m
 	// leaves the open list — the same load / set resolved+resolved_at / save
 	// the admin resolve endpoint performs.
 	if autoResolve && store != nil && rec != nil {
-		now := time.Now().UTC()
-		rec.Resolved = true
-		rec.ResolvedAt = &now
+		if _, err := rec.Transition(storage.StatusResolved, time.Now().UTC(), nil); err != nil {
+			log.Printf("incident: auto-resolve warning: %v", err)
+		}
 		if err := store.SaveIncident(rec); err != nil {
 			log.Printf("incident: persist auto-resolve warning: %v", err)
 		}
+		RecordTimeline(&storage.TimelineEntry{
+			OrgID:      rec.OrgID,
+			IncidentID: rec.ID,
+			Kind:       storage.TimelineStatusChange,
+			Actor:      TimelineActorSystem,
+			Body:       "Auto-resolved on intake",
+			Data:       map[string]interface{}{"from": storage.StatusTriggered, "to": storage.StatusResolved},
+		})
 	}
 
 	switch {
@@ -209,6 +222,42 @@ func CreateIncident(teamID string, content *map[string]interface{}, params ...*m
 	return nil
 }
 
+// notificationTimelineEntry summarizes the fan-out outcome stamped on rec.
+func notificationTimelineEntry(rec *storage.IncidentRecord) *storage.TimelineEntry {
+	data := map[string]interface{}{
+		"status":            rec.NotifyStatus,
+		"channels_enabled":  rec.ChannelsEnabled,
+		"channels_notified": rec.ChannelsNotified,
+	}
+	if rec.NotifyError != "" {
+		data["error"] = rec.NotifyError
+	}
+	return &storage.TimelineEntry{
+		OrgID:      rec.OrgID,
+		IncidentID: rec.ID,
+		Kind:       storage.TimelineNotification,
+		Actor:      TimelineActorSystem,
+		Body:       "Notification " + rec.NotifyStatus,
+		Data:       data,
+	}
+}
+
+// oncallTimelineEntry records an on-call escalation attempt on rec.
+func oncallTimelineEntry(rec *storage.IncidentRecord, body string, err error) *storage.TimelineEntry {
+	data := map[string]interface{}{"triggered": err == nil && rec.OnCallTriggered}
+	if err != nil {
+		data["error"] = err.Error()
+	}
+	return &storage.TimelineEntry{
+		OrgID:      rec.OrgID,
+		IncidentID: rec.ID,
+		Kind:       storage.TimelineOnCall,
+		Actor:      TimelineActorSystem,
+		Body:       body,
+		Data:       data,
+	}
+}
+
 // buildIncidentRecord copies the alert into a durable IncidentRecord.
 // ChannelsEnabled snapshots the channels configured at fire time;
 // ChannelsNotified stays empty here and is filled in after the
@@ -236,11 +285,15 @@ func buildIncidentRecord(incident *m.Incident, cfg *config.Config, content map[s
 		Source:          resolveSource(content, hint),
 		Origin:          origin,
 		Resolved:        resolved,
+		Status:          storage.StatusTriggered,
 		ChannelsEnabled: enabledChannels(cfg),
 		OnCallTriggered: !resolved && cfg.OnCall.Enable,
 		CreatedAt:       time.Now().UTC(),
 		Content:         content,
 	}
+	if resolved {
+		rec.Status = storage.StatusResolved
+	}
 	return rec
 }
 
@@ -349,9 +349,26 @@ func BuildAggregateReportModel(recs []*storage.IncidentRecord, window string, st
 	}
 
 	model.Notable = notable
+	model.Response = responseMetrics(recs)
 	return model
 }
 
+// responseMetrics condenses the window's overall response stats into the
+// report's headline metrics.
+func responseMetrics(recs []*storage.IncidentRecord) core.ResponseMetrics {
+	s := ComputeResponseStats(recs, storage.GroupByNone)[0]
+	secs := func(v float64) time.Duration { return time.Duration(v * float64(time.Second)) }
+	return core.ResponseMetrics{
+		Acked:          s.MTTA.Count,
+		MTTAP50:        secs(s.MTTA.P50),
+		MTTAP90:        secs(s.MTTA.P90),
+		Resolved:       s.MTTR.Count,
+		MTTRP50:        secs(s.MTTR.P50),
+		MTTRP90:        secs(s.MTTR.P90),
+		EscalationRate: s.EscalationRate,
+	}
+}
+
 // buildTrend buckets incidents into the trend series over [start, end): one
 // bucket per hour (unit "hour") or per day (unit "day"), bounded to ≤24 / ≤7
 // buckets. Each bucket carries the per-origin split for a stacked bar. recs
@@ -410,9 +427,10 @@ func buildTrend(recs []*storage.IncidentRecord, start, end time.Time, unit strin
 	return buckets
 }
 
-// reportSeverity extracts a best-effort severity for one record from its
-// content through the shared extraction, which reads the SAME key set as the
-// UI's severityFromContent (severity.ts). Raw — the caller bands it.
+// reportSeverity resolves the severity for one record: the operator-set
+// severity when an operator edited it, else the payload's, read through the
+// shared extraction with the SAME key set as the UI's severityFromContent
+// (severity.ts). Raw — the caller bands it.
 //
 // When the payload names no real severity, the record's agent verdict is used
 // purely to tint the chart. That fallback is report-local on purpose: a verdict
@@ -422,7 +440,7 @@ func reportSeverity(rec *storage.IncidentRecord) string {
 	if rec == nil {
 		return ""
 	}
-	if s := ExtractSeverity(rec.Content); s != "" {
+	if s := rec.SeverityLabel(); s != "" {
 		return s
 	}
 	return utils.PayloadString(rec.Content, "Verdict", "verdict")
@@ -662,6 +680,10 @@ func reportCaption(model core.ReportModel) string {
 	b.WriteString(fmt.Sprintf(" (%d AI-detect, %d webhook)", ai, wh))
 	b.WriteString("\n")
 	b.WriteString(fmt.Sprintf("%d open · %d resolved · %d critical/high", model.Open, model.Resolved, model.CriticalHigh))
+	if resp := model.Response.Summary(); resp != "" {
+		b.WriteString("\n")
+		b.WriteString(resp)
+	}
 	b.WriteString("\nWindow ")
 	b.WriteString(model.WindowStart.Format("2006-01-02 15:04"))
 	b.WriteString(" → ")
@@ -165,6 +165,29 @@ func TestBuildAggregateReportModel_NestedAndSynonymSeverities(t *testing.T) {
 	}
 }
 
+// TestBuildAggregateReportModel_SeverityEditWins proves an operator's severity
+// edit overrides the payload's in the report: the payload says low, the edit
+// says critical, and the incident lands in the critical band.
+func TestBuildAggregateReportModel_SeverityEditWins(t *testing.T) {
+	start := time.Date(2026, 8, 9, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 8, 9, 12, 0, 0, 0, time.UTC)
+	recs := []*storage.IncidentRecord{
+		{ID: "e1", Title: "edited", Service: "payments", Origin: storage.OriginWebhook, Source: "webhook",
+			CreatedAt: end.Add(-time.Hour), Severity: "critical", Content: map[string]interface{}{"severity": "low"}},
+		{ID: "u1", Title: "unedited", Service: "payments", Origin: storage.OriginWebhook, Source: "webhook",
+			CreatedAt: end.Add(-2 * time.Hour), Content: map[string]interface{}{"severity": "low"}},
+	}
+
+	m := BuildAggregateReportModel(recs, "today", start, end, "hour", testScrubber(t), true, time.UTC)
+
+	want := map[string]int{"critical": 1, "high": 0, "medium": 0, "low": 1, "unknown": 0}
+	for _, b := range m.BySeverity {
+		if b.Count != want[b.Label] {
+			t.Fatalf("severity %q = %d, want %d", b.Label, b.Count, want[b.Label])
+		}
+	}
+}
+
 // --- window resolution -----------------------------------------------------
 
 func TestWindowBounds(t *testing.T) {
@@ -323,6 +346,27 @@ func TestReportCaption_UsesConfiguredTitle(t *testing.T) {
 	}
 }
 
+// TestReportModel_CarriesResponseMetrics: the model's response headline
+// matches the analytics computation and reaches the caption.
+func TestReportModel_CarriesResponseMetrics(t *testing.T) {
+	start := time.Date(2026, 8, 9, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 8, 9, 12, 0, 0, 0, time.UTC)
+	recs := []*storage.IncidentRecord{
+		responseRec("a", "api", end.Add(-3*time.Hour), 4*time.Minute, 80*time.Minute, true),
+		responseRec("b", "api", end.Add(-2*time.Hour), -1, -1, false),
+	}
+
+	m := BuildAggregateReportModel(recs, "today", start, end, "hour", testScrubber(t), true, time.UTC)
+
+	r := m.Response
+	if r.Acked != 1 || r.MTTAP50 != 4*time.Minute || r.Resolved != 1 || r.MTTRP50 != 80*time.Minute || r.EscalationRate != 0.5 {
+		t.Fatalf("Response = %+v", r)
+	}
+	if cap := reportCaption(m); !strings.Contains(cap, "MTTA p50 4m (p90 4m) · MTTR p50 1h20m (p90 1h20m) · 50% escalated") {
+		t.Fatalf("caption missing response line: %q", cap)
+	}
+}
+
 // TestBuildWindowModel_CarriesConfiguredTitle: the settings Title flows onto
 // the built model so the renderer and caption draw it.
 func TestBuildWindowModel_CarriesConfiguredTitle(t *testing.T) {
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/VersusControl/versus-incident/pkg/middleware"
+	"github.com/VersusControl/versus-incident/pkg/scheduler"
+	"github.com/VersusControl/versus-incident/pkg/storage"
+)
+
+// retention.go — the data-retention job. It turns the storage.retention
+// config block into per-domain cutoffs and purges through the backend's
+// storage.Lifecycle capability on a schedule. The same plan backs the admin
+// dry run, which counts through storage.LifecycleCounter instead of
+// deleting.
+
+// RetentionJobName is the ownership key the retention purge gates on, so
+// under HA exactly one replica purges (see ReportScheduleJobName).
+const RetentionJobName = "storage-retention"
+
+// auditActionRetentionPurged is the system-audit action for one domain's
+// scheduled purge; the target is "<domain> older than <cutoff>: <n>".
+const auditActionRetentionPurged = "storage.retention.purged"
+
+// defaultRetentionInterval is the job period when the config leaves it
+// unset; minRetentionInterval keeps a typo from hammering the database.
+const (
+	defaultRetentionInterval = 24 * time.Hour
+	minRetentionInterval     = time.Minute
+)
+
+// ErrRetentionUnsupported is returned when the backend cannot purge
+// (no storage.Lifecycle) or, for a dry run, cannot count
+// (no storage.LifecycleCounter).
+var ErrRetentionUnsupported = errors.New("retention: not supported by the configured storage backend")
+
+// RetentionPolicy is the per-domain maximum age. A zero age keeps the
+// domain forever.
+type RetentionPolicy struct {
+	Incidents    time.Duration
+	ResolvedOnly bool
+	Analyses     time.Duration
+	Blobs        time.Duration
+}
+
+// RetentionPolicyFrom converts the config block (ages in days).
+func RetentionPolicyFrom(cfg config.StorageRetentionConfig) RetentionPolicy {
+	day := 24 * time.Hour
+	return RetentionPolicy{
+		Incidents:    time.Duration(max(cfg.IncidentsDays, 0)) * day,
+		ResolvedOnly: cfg.ResolvedOnly,
+		Analyses:     time.Duration(max(cfg.AnalysesDays, 0)) * day,
+		Blobs:        time.Duration(max(cfg.BlobsDays, 0)) * day,
+	}
+}
+
+// RetentionResult is one domain's outcome: the records deleted, or for a
+// dry run the records that would be.
+type RetentionResult struct {
+	Domain string    `json:"domain"`
+	MaxAge string    `json:"max_age"`
+	Cutoff time.Time `json:"cutoff"`
+	Count  int       `json:"count"`
+	Error  string    `json:"error,omitempty"`
+}
+
+// retentionStep is one domain with its cutoff.
+type retentionStep struct {
+	domain string
+	maxAge time.Duration
+	cutoff time.Time
+}
+
+// plan lists the domains the policy purges, with cutoffs relative to now.
+func (p RetentionPolicy) plan(now time.Time) []retentionStep {
+	var steps []retentionStep
+	add := func(domain string, age time.Duration) {
+		if age > 0 {
+			steps = append(steps, retentionStep{domain: domain, maxAge: age, cutoff: now.Add(-age)})
+		}
+	}
+	if p.ResolvedOnly {
+		add(storage.DomainResolvedIncidents, p.Incidents)
+	} else {
+		add(storage.DomainIncidents, p.Incidents)
+	}
+	add(storage.DomainAnalyses, p.Analyses)
+	add(storage.DomainBlobs, p.Blobs)
+	return steps
+}
+
+// PreviewRetention counts, per domain, what ApplyRetention would delete at
+// now, without deleting anything.
+func PreviewRetention(st storage.Provider, policy RetentionPolicy, now time.Time) ([]RetentionResult, error) {
+	lcc, ok := st.(storage.LifecycleCounter)
+	if !ok {
+		return nil, ErrRetentionUnsupported
+	}
+	return runRetention(policy, now, lcc.CountOlderThan), nil
+}
+
+// ApplyRetention purges, per domain, everything older than the policy
+// allows. A domain that fails is reported in its result and does not stop
+// the others.
+func ApplyRetention(st storage.Provider, policy RetentionPolicy, now time.Time) ([]RetentionResult, error) {
+	lc, ok := st.(storage.Lifecycle)
+	if !ok {
+		return nil, ErrRetentionUnsupported
+	}
+	return runRetention(policy, now, lc.PurgeOlderThan), nil
+}
+
+func runRetention(policy RetentionPolicy, now time.Time, op func(string, time.Time) (int, error)) []RetentionResult {
+	out := make([]RetentionResult, 0, 3)
+	for _, s := range policy.plan(now) {
+		res := RetentionResult{Domain: s.domain, MaxAge: retentionAge(s.maxAge), Cutoff: s.cutoff}
+		n, err := op(s.domain, s.cutoff)
+		res.Count = n
+		if err != nil {
+			res.Error = err.Error()
+		}
+		out = append(out, res)
+	}
+	return out
+}
+
+// retentionAge renders a whole-day age as "90d".
+func retentionAge(d time.Duration) string {
+	return fmt.Sprintf("%dd", int(d/(24*time.Hour)))
+}
+
+// RetentionInterval parses the configured job period, defaulting to 24h and
+// never running more often than once a minute.
+func RetentionInterval(cfg config.StorageRetentionConfig) time.Duration {
+	d, err := time.ParseDuration(strings.TrimSpace(cfg.Interval))
+	if err != nil || d <= 0 {
+		return defaultRetentionInterval
+	}
+	return max(d, minRetentionInterval)
+}
+
+// StartRetentionScheduler runs the retention purge once at start and then
+// every interval until ctx is done. It does nothing when retention is
+// disabled, no domain has an age, or the backend cannot purge — the file
+// backend keeps its own rolling cap. Each run is gated behind
+// scheduler.Owns(RetentionJobName), so under HA one replica purges.
+func StartRetentionScheduler(ctx context.Context, store storage.Provider, cfg config.StorageRetentionConfig) {
+	policy := RetentionPolicyFrom(cfg)
+	if !cfg.Enable || len(policy.plan(time.Now())) == 0 {
+		return
+	}
+	if _, ok := store.(storage.Lifecycle); !ok {
+		log.Printf("retention: enabled but the storage backend cannot purge; ignoring")
+		return
+	}
+	interval := RetentionInterval(cfg)
+	log.Printf("retention: purging every %s (incidents=%s resolved_only=%t analyses=%s blobs=%s)",
+		interval, retentionAge(policy.Incidents), policy.ResolvedOnly, retentionAge(policy.Analyses), retentionAge(policy.Blobs))
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if scheduler.Owns(RetentionJobName) {
+				runRetentionOnce(store, policy, time.Now().UTC())
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// runRetentionOnce applies the policy and logs and audits each domain.
+func runRetentionOnce(store storage.Provider, policy RetentionPolicy, now time.Time) {
+	results, err := ApplyRetention(store, policy, now)
+	if err != nil {
+		log.Printf("retention: %v", err)
+		return
+	}
+	for _, r := range results {
+		target := fmt.Sprintf("%s older than %s: %d", r.Domain, r.Cutoff.Format(time.RFC3339), r.Count)
+		if r.Error != "" {
+			log.Printf("retention: purge %s failed after %d: %s", r.Domain, r.Count, r.Error)
+			middleware.RecordSystemAudit(auditActionRetentionPurged, target, middleware.AdminAuditFailed)
+			continue
+		}
+		log.Printf("retention: purged %d %s older than %s", r.Count, r.Domain, r.MaxAge)
+		middleware.RecordSystemAudit(auditActionRetentionPurged, target, middleware.AdminAuditSuccess)
+	}
+}
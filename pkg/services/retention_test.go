@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/VersusControl/versus-incident/pkg/middleware"
+	"github.com/VersusControl/versus-incident/pkg/scheduler"
+	"github.com/VersusControl/versus-incident/pkg/storage"
+)
+
+func retentionStore(t *testing.T, now time.Time) storage.Provider {
+	t.Helper()
+	st := storage.NewMemory()
+	resolvedAt := now.Add(-40 * 24 * time.Hour)
+	for _, r := range []*storage.IncidentRecord{
+		{ID: "old-open", CreatedAt: now.Add(-60 * 24 * time.Hour)},
+		{ID: "old-resolved", CreatedAt: now.Add(-60 * 24 * time.Hour), Resolved: true, ResolvedAt: &resolvedAt},
+		{ID: "new", CreatedAt: now.Add(-time.Hour)},
+	} {
+		if err := st.SaveIncident(r); err != nil {
+			t.Fatalf("SaveIncident: %v", err)
+		}
+	}
+	return st
+}
+
+// TestRetention_PreviewThenApply checks the dry run counts exactly what the
+// purge then deletes, and that resolved-only spares open incidents.
+func TestRetention_PreviewThenApply(t *testing.T) {
+	now := time.Now().UTC()
+	st := retentionStore(t, now)
+
+	policy := RetentionPolicyFrom(config.StorageRetentionConfig{IncidentsDays: 30, ResolvedOnly: true})
+	preview, err := PreviewRetention(st, policy, now)
+	if err != nil || len(preview) != 1 || preview[0].Domain != storage.DomainResolvedIncidents || preview[0].Count != 1 {
+		t.Fatalf("preview = %+v, %v; want one resolved incident", preview, err)
+	}
+	if _, err := st.GetIncident("old-resolved"); err != nil {
+		t.Fatalf("preview deleted: %v", err)
+	}
+
+	applied, err := ApplyRetention(st, policy, now)
+	if err != nil || applied[0].Count != 1 || applied[0].MaxAge != "30d" {
+		t.Fatalf("apply = %+v, %v", applied, err)
+	}
+	if _, err := st.GetIncident("old-resolved"); err != storage.ErrNotFound {
+		t.Fatalf("old-resolved after purge: %v, want ErrNotFound", err)
+	}
+	if _, err := st.GetIncident("old-open"); err != nil {
+		t.Fatalf("resolved_only purged an open incident: %v", err)
+	}
+
+	policy.ResolvedOnly = false
+	if applied, _ := ApplyRetention(st, policy, now); applied[0].Domain != storage.DomainIncidents || applied[0].Count != 1 {
+		t.Fatalf("apply all = %+v, want old-open purged", applied)
+	}
+}
+
+func TestRetention_PolicyAndInterval(t *testing.T) {
+	if steps := RetentionPolicyFrom(config.StorageRetentionConfig{IncidentsDays: -1}).plan(time.Now()); len(steps) != 0 {
+		t.Fatalf("plan = %+v, want nothing for zero/negative ages", steps)
+	}
+	for in, want := range map[string]time.Duration{"": 24 * time.Hour, "junk": 24 * time.Hour, "6h": 6 * time.Hour, "1s": time.Minute} {
+		if got := RetentionInterval(config.StorageRetentionConfig{Interval: in}); got != want {
+			t.Fatalf("RetentionInterval(%q) = %s, want %s", in, got, want)
+		}
+	}
+	if _, err := PreviewRetention(struct{ storage.Provider }{storage.NewMemory()}, RetentionPolicy{Incidents: time.Hour}, time.Now()); err != ErrRetentionUnsupported {
+		t.Fatalf("preview without LifecycleCounter = %v, want ErrRetentionUnsupported", err)
+	}
+}
+
+// TestRetention_SchedulerAuditsAndRespectsOwnership runs the job once on a
+// replica that owns it and once on one that does not.
+func TestRetention_SchedulerAuditsAndRespectsOwnership(t *testing.T) {
+	var mu sync.Mutex
+	var events []middleware.AdminAuditEvent
+	middleware.SetSystemAuditHook(func(ev middleware.AdminAuditEvent) {
+		mu.Lock()
+		events = append(events, ev)
+		mu.Unlock()
+	})
+	t.Cleanup(func() { middleware.SetSystemAuditHook(nil) })
+	t.Cleanup(func() { scheduler.SetOwnership(nil) })
+
+	cfg := config.StorageRetentionConfig{Enable: true, IncidentsDays: 30}
+	now := time.Now().UTC()
+
+	scheduler.SetOwnership(func(string) bool { return false })
+	notOwner := retentionStore(t, now)
+	ctx, cancel := context.WithCancel(context.Background())
+	StartRetentionScheduler(ctx, notOwner, cfg)
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	if _, err := notOwner.GetIncident("old-open"); err != nil {
+		t.Fatalf("non-owner purged: %v", err)
+	}
+
+	scheduler.SetOwnership(nil)
+	owner := retentionStore(t, now)
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	StartRetentionScheduler(ctx, owner, cfg)
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		mu.Lock()
+		n := len(events)
+		mu.Unlock()
+		if n > 0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 1 || events[0].Action != auditActionRetentionPurged || events[0].Result != middleware.AdminAuditSuccess ||
+		!strings.HasSuffix(events[0].Target, ": 2") {
+		t.Fatalf("audit events = %+v, want one successful purge of 2", events)
+	}
+}
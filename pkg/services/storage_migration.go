@@ -0,0 +1,483 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/VersusControl/versus-incident/pkg/agent"
+	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/VersusControl/versus-incident/pkg/storage"
+)
+
+// storage_migration.go — copies every storage domain from one backend to
+// another for the migrate-storage command: incidents with their timelines,
+// analyses, every blob, and the learned pattern catalog. The copy is an
+// upsert keyed by record id, and a record already identical in the
+// destination is skipped, so a rerun after an interruption picks up where
+// the last one stopped. Records are written oldest first, so a capped
+// destination (file, redis) keeps the newest when the source holds more.
+
+// Storage migration domains, in the order they are copied.
+const (
+	MigrationDomainIncidents = "incidents"
+	MigrationDomainTimeline  = "timeline"
+	MigrationDomainAnalyses  = "analyses"
+	MigrationDomainBlobs     = "blobs"
+	MigrationDomainPatterns  = "patterns"
+	MigrationDomainServices  = "services"
+)
+
+// defaultMigrationPageSize is the page read from the source per round trip
+// when the options leave it unset.
+const defaultMigrationPageSize = 500
+
+// migrationTimelineLimit is the per-incident read bound for timelines. It is
+// far above any backend's own cap, so the whole timeline moves.
+const migrationTimelineLimit = 1 << 20
+
+// ErrStorageMigrationVerify is returned when the destination holds fewer
+// records than the source after the copy.
+var ErrStorageMigrationVerify = errors.New("storage migration: verification failed")
+
+// StorageMigrationOptions tunes one MigrateStorage run.
+type StorageMigrationOptions struct {
+	// DryRun reads the source and destination and reports what would be
+	// copied, without writing anything.
+	DryRun bool
+	// PageSize is the number of incidents or analyses read per page. <= 0
+	// uses defaultMigrationPageSize.
+	PageSize int
+	// Logf receives progress lines. nil uses log.Printf.
+	Logf func(format string, args ...interface{})
+}
+
+// StorageMigrationResult is one domain's outcome.
+type StorageMigrationResult struct {
+	Domain string `json:"domain"`
+	// Source is the number of records in the source.
+	Source int `json:"source"`
+	// Copied is the number written to the destination, or that would be for
+	// a dry run.
+	Copied int `json:"copied"`
+	// Skipped is the number already identical in the destination.
+	Skipped int `json:"skipped"`
+	// Dest is the number of source records the destination holds after the
+	// copy (before it, for a dry run).
+	Dest int `json:"dest"`
+}
+
+// StorageMigrationReport is the outcome of one MigrateStorage run, one
+// result per domain in copy order.
+type StorageMigrationReport struct {
+	DryRun  bool                     `json:"dry_run"`
+	Results []StorageMigrationResult `json:"results"`
+}
+
+// storageMigration carries one run's state across the domain steps.
+type storageMigration struct {
+	ctx      context.Context
+	src, dst storage.Provider
+	opts     StorageMigrationOptions
+	// incidentIDs lists every source incident, for the timeline copy and
+	// the verification pass.
+	incidentIDs []string
+}
+
+// MigrateStorage copies every domain from src to dst and then verifies the
+// destination holds every source record. A verification shortfall returns
+// the full report together with an error wrapping ErrStorageMigrationVerify;
+// any other error stops the run at the failing domain, and the report covers
+// the domains finished before it.
+func MigrateStorage(ctx context.Context, src, dst storage.Provider, opts StorageMigrationOptions) (*StorageMigrationReport, error) {
+	if opts.PageSize <= 0 {
+		opts.PageSize = defaultMigrationPageSize
+	}
+	if opts.Logf == nil {
+		opts.Logf = log.Printf
+	}
+	m := &storageMigration{ctx: ctx, src: src, dst: dst, opts: opts}
+	report := &StorageMigrationReport{DryRun: opts.DryRun}
+
+	steps := []func() (StorageMigrationResult, error){
+		m.migrateIncidents,
+		m.migrateTimeline,
+		m.migrateAnalyses,
+		m.migrateBlobs,
+	}
+	for _, step := range steps {
+		res, err := step()
+		if err != nil {
+			return report, fmt.Errorf("storage migration: %s: %w", res.Domain, err)
+		}
+		m.logResult(res)
+		report.Results = append(report.Results, res)
+	}
+	patterns, services, err := m.migrateCatalog()
+	if err != nil {
+		return report, fmt.Errorf("storage migration: catalog: %w", err)
+	}
+	m.logResult(patterns)
+	m.logResult(services)
+	report.Results = append(report.Results, patterns, services)
+
+	if opts.DryRun {
+		return report, nil
+	}
+	var short []string
+	for _, res := range report.Results {
+		if res.Dest < res.Source {
+			short = append(short, fmt.Sprintf("%s: %d of %d in destination", res.Domain, res.Dest, res.Source))
+		}
+	}
+	if len(short) > 0 {
+		return report, fmt.Errorf("%w: %s", ErrStorageMigrationVerify, strings.Join(short, "; "))
+	}
+	return report, nil
+}
+
+func (m *storageMigration) logResult(res StorageMigrationResult) {
+	verb := "copied"
+	if m.opts.DryRun {
+		verb = "would copy"
+	}
+	m.opts.Logf("migrate-storage: %-9s source=%d %s=%d skipped=%d destination=%d",
+		res.Domain, res.Source, verb, res.Copied, res.Skipped, res.Dest)
+}
+
+// sameJSON reports whether a and b encode identically, the "already
+// migrated" test for records that have no version or timestamp to compare.
+func sameJSON(a, b interface{}) bool {
+	ja, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	jb, err := json.Marshal(b)
+	return err == nil && bytes.Equal(ja, jb)
+}
+
+// sameIncident is sameJSON after the normalization backends apply on save:
+// a typed backend stores the effective origin and org of a legacy record.
+func sameIncident(a, b *storage.IncidentRecord) bool {
+	ca, cb := *a, *b
+	ca.Origin, cb.Origin = a.EffectiveOrigin(), b.EffectiveOrigin()
+	ca.OrgID, cb.OrgID = storage.NormalizeOrgID(a.OrgID), storage.NormalizeOrgID(b.OrgID)
+	return sameJSON(&ca, &cb)
+}
+
+// migrateIncidents copies every incident, oldest first, one page at a time.
+func (m *storageMigration) migrateIncidents() (StorageMigrationResult, error) {
+	res := StorageMigrationResult{Domain: MigrationDomainIncidents}
+	err := walkIncidentsOldestFirst(m.src, m.opts.PageSize, func(page []*storage.IncidentRecord) error {
+		if err := m.ctx.Err(); err != nil {
+			return err
+		}
+		for _, rec := range page {
+			res.Source++
+			m.incidentIDs = append(m.incidentIDs, rec.ID)
+			existing, err := m.dst.GetIncident(rec.ID)
+			switch {
+			case err == nil && sameIncident(existing, rec):
+				res.Skipped++
+				continue
+			case err != nil && !errors.Is(err, storage.ErrNotFound):
+				return err
+			}
+			res.Copied++
+			if m.opts.DryRun {
+				continue
+			}
+			if err := m.dst.SaveIncident(rec); err != nil {
+				return fmt.Errorf("save %s: %w", rec.ID, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return res, err
+	}
+	for _, id := range m.incidentIDs {
+		if _, err := m.dst.GetIncident(id); err == nil {
+			res.Dest++
+		}
+	}
+	return res, nil
+}
+
+// walkIncidentsOldestFirst feeds every incident in src to fn, oldest first,
+// in pages of at most pageSize. A backend with storage.IncidentPager is paged
+// from its oldest end; one without is listed whole.
+func walkIncidentsOldestFirst(src storage.Provider, pageSize int, fn func([]*storage.IncidentRecord) error) error {
+	if pager, ok := src.(storage.IncidentPager); ok {
+		counts, err := pager.CountIncidentsByStatus()
+		if err != nil {
+			return err
+		}
+		for end := counts.Total.Total; end > 0; end -= pageSize {
+			offset := max(end-pageSize, 0)
+			page, err := pager.ListIncidentsPage("", offset, end-offset)
+			if err != nil {
+				return err
+			}
+			slices.Reverse(page)
+			if err := fn(page); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	all, err := src.ListIncidents(0)
+	if err != nil {
+		return err
+	}
+	slices.Reverse(all)
+	for len(all) > 0 {
+		n := min(pageSize, len(all))
+		if err := fn(all[:n]); err != nil {
+			return err
+		}
+		all = all[n:]
+	}
+	return nil
+}
+
+// migrateTimeline copies each incident's timeline entries the destination
+// does not hold yet, matched by entry id. It is a no-op unless both
+// backends implement storage.Timeline.
+func (m *storageMigration) migrateTimeline() (StorageMigrationResult, error) {
+	res := StorageMigrationResult{Domain: MigrationDomainTimeline}
+	srcTL, ok := m.src.(storage.Timeline)
+	if !ok {
+		return res, nil
+	}
+	dstTL, ok := m.dst.(storage.Timeline)
+	if !ok {
+		return res, nil
+	}
+	for _, id := range m.incidentIDs {
+		if err := m.ctx.Err(); err != nil {
+			return res, err
+		}
+		entries, err := srcTL.ListTimeline(id, migrationTimelineLimit)
+		if err != nil {
+			return res, err
+		}
+		if len(entries) == 0 {
+			continue
+		}
+		have, err := timelineIDs(dstTL, id)
+		if err != nil {
+			return res, err
+		}
+		for _, e := range entries {
+			res.Source++
+			if have[e.ID] {
+				res.Skipped++
+				continue
+			}
+			res.Copied++
+			if m.opts.DryRun {
+				continue
+			}
+			// An incident the destination's cap dropped has nowhere to put
+			// its timeline; verification reports the shortfall.
+			if err := dstTL.AppendTimelineEntry(e); err != nil && !errors.Is(err, storage.ErrNotFound) {
+				return res, fmt.Errorf("append %s/%s: %w", id, e.ID, err)
+			}
+		}
+		if !m.opts.DryRun {
+			if have, err = timelineIDs(dstTL, id); err != nil {
+				return res, err
+			}
+		}
+		for _, e := range entries {
+			if have[e.ID] {
+				res.Dest++
+			}
+		}
+	}
+	return res, nil
+}
+
+// timelineIDs returns the ids of the entries tl holds for one incident. A
+// destination that does not hold the incident yet has none.
+func timelineIDs(tl storage.Timeline, incidentID string) (map[string]bool, error) {
+	entries, err := tl.ListTimeline(incidentID, migrationTimelineLimit)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return nil, err
+	}
+	ids := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		ids[e.ID] = true
+	}
+	return ids, nil
+}
+
+// migrateAnalyses copies every analysis, oldest first, one page at a time.
+func (m *storageMigration) migrateAnalyses() (StorageMigrationResult, error) {
+	res := StorageMigrationResult{Domain: MigrationDomainAnalyses}
+	var ids []string
+	err := walkAnalysesOldestFirst(m.src, m.opts.PageSize, func(page []*storage.AnalysisRecord) error {
+		if err := m.ctx.Err(); err != nil {
+			return err
+		}
+		for _, rec := range page {
+			res.Source++
+			ids = append(ids, rec.ID)
+			existing, err := m.dst.GetAnalysis(rec.ID)
+			switch {
+			case err == nil && sameJSON(existing, rec):
+				res.Skipped++
+				continue
+			case err != nil && !errors.Is(err, storage.ErrNotFound):
+				return err
+			}
+			res.Copied++
+			if m.opts.DryRun {
+				continue
+			}
+			if err := m.dst.SaveAnalysis(rec); err != nil {
+				return fmt.Errorf("save %s: %w", rec.ID, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return res, err
+	}
+	for _, id := range ids {
+		if _, err := m.dst.GetAnalysis(id); err == nil {
+			res.Dest++
+		}
+	}
+	return res, nil
+}
+
+// walkAnalysesOldestFirst is the analyses twin of walkIncidentsOldestFirst,
+// paging through storage.AnalysisPager when the backend has it.
+func walkAnalysesOldestFirst(src storage.Provider, pageSize int, fn func([]*storage.AnalysisRecord) error) error {
+	if pager, ok := src.(storage.AnalysisPager); ok {
+		total, err := pager.CountAnalyses()
+		if err != nil {
+			return err
+		}
+		for end := total; end > 0; end -= pageSize {
+			offset := max(end-pageSize, 0)
+			page, err := pager.ListAnalysesPage(offset, end-offset)
+			if err != nil {
+				return err
+			}
+			slices.Reverse(page)
+			if err := fn(page); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	all, err := src.ListAnalyses(0)
+	if err != nil {
+		return err
+	}
+	slices.Reverse(all)
+	for len(all) > 0 {
+		n := min(pageSize, len(all))
+		if err := fn(all[:n]); err != nil {
+			return err
+		}
+		all = all[n:]
+	}
+	return nil
+}
+
+// migrateBlobs copies every blob except the catalog, which moves through
+// migrateCatalog because Postgres keeps it in typed tables instead.
+func (m *storageMigration) migrateBlobs() (StorageMigrationResult, error) {
+	res := StorageMigrationResult{Domain: MigrationDomainBlobs}
+	blobs, err := m.src.ListBlobs("")
+	if err != nil {
+		return res, err
+	}
+	for _, b := range blobs {
+		if b.Name == config.CatalogBlobName {
+			continue
+		}
+		res.Source++
+		existing, err := m.dst.ReadBlob(b.Name)
+		if err != nil {
+			return res, err
+		}
+		if existing != nil && bytes.Equal(existing, b.Data) {
+			res.Skipped++
+			res.Dest++
+			continue
+		}
+		res.Copied++
+		if m.opts.DryRun {
+			if existing != nil {
+				res.Dest++
+			}
+			continue
+		}
+		if err := m.dst.WriteBlob(b.Name, b.Data); err != nil {
+			return res, fmt.Errorf("write %s: %w", b.Name, err)
+		}
+		if got, err := m.dst.ReadBlob(b.Name); err == nil && bytes.Equal(got, b.Data) {
+			res.Dest++
+		}
+	}
+	return res, nil
+}
+
+// migrateCatalog copies the learned patterns and services, replacing
+// entries with the same id or name in the destination.
+func (m *storageMigration) migrateCatalog() (patterns, services StorageMigrationResult, err error) {
+	patterns = StorageMigrationResult{Domain: MigrationDomainPatterns}
+	services = StorageMigrationResult{Domain: MigrationDomainServices}
+	srcPatterns, srcServices, err := agent.ReadCatalog(m.src)
+	if err != nil {
+		return patterns, services, err
+	}
+	dstPatterns, dstServices, err := agent.ReadCatalog(m.dst)
+	if err != nil {
+		return patterns, services, err
+	}
+	patterns.Source, services.Source = len(srcPatterns), len(srcServices)
+	for id, p := range srcPatterns {
+		if sameJSON(dstPatterns[id], p) {
+			patterns.Skipped++
+		} else {
+			patterns.Copied++
+		}
+	}
+	for name, s := range srcServices {
+		if sameJSON(dstServices[name], s) {
+			services.Skipped++
+		} else {
+			services.Copied++
+		}
+	}
+	if !m.opts.DryRun && patterns.Copied+services.Copied > 0 {
+		if err := agent.WriteCatalog(m.dst, srcPatterns, srcServices); err != nil {
+			return patterns, services, err
+		}
+		if dstPatterns, dstServices, err = agent.ReadCatalog(m.dst); err != nil {
+			return patterns, services, err
+		}
+	}
+	for id := range srcPatterns {
+		if _, ok := dstPatterns[id]; ok {
+			patterns.Dest++
+		}
+	}
+	for name := range srcServices {
+		if _, ok := dstServices[name]; ok {
+			services.Dest++
+		}
+	}
+	return patterns, services, nil
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/agent"
+	"github.com/VersusControl/versus-incident/pkg/storage"
+)
+
+// migrationSource seeds a file backend with every domain the migration
+// copies: incidents with timelines, analyses, plain blobs and a catalog.
+func migrationSource(t *testing.T) storage.Provider {
+	t.Helper()
+	src, err := storage.NewFile(storage.FileOptions{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewFile: %v", err)
+	}
+	t.Cleanup(func() { _ = src.Close() })
+	base := time.Now().UTC().Add(-time.Hour)
+	for i := 0; i < 5; i++ {
+		id := fmt.Sprintf("inc-%d", i)
+		if err := src.SaveIncident(&storage.IncidentRecord{ID: id, Title: "disk full", Tags: []string{"db"}, CreatedAt: base.Add(time.Duration(i) * time.Minute)}); err != nil {
+			t.Fatalf("SaveIncident: %v", err)
+		}
+		if err := src.(storage.Timeline).AppendTimelineEntry(&storage.TimelineEntry{ID: "note-" + id, IncidentID: id, Kind: storage.TimelineNote, Body: "looking"}); err != nil {
+			t.Fatalf("AppendTimelineEntry: %v", err)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if err := src.SaveAnalysis(&storage.AnalysisRecord{ID: fmt.Sprintf("an-%d", i), IncidentID: "inc-0", RequestedAt: base}); err != nil {
+			t.Fatalf("SaveAnalysis: %v", err)
+		}
+	}
+	_ = src.WriteBlob("teams", []byte(`{"teams":[]}`))
+	_ = src.WriteBlob("models/default/intel/svc", []byte(`{"k":1}`))
+	if err := agent.WriteCatalog(src,
+		map[string]*agent.Pattern{"p1": {ID: "p1", Template: "disk <*> full", Count: 3, Verdict: "known"}},
+		map[string]*agent.ServiceInfo{"api": {FirstSeen: base, Manual: true}},
+	); err != nil {
+		t.Fatalf("WriteCatalog: %v", err)
+	}
+	return src
+}
+
+func migrationResults(rep *StorageMigrationReport) map[string]StorageMigrationResult {
+	out := make(map[string]StorageMigrationResult)
+	for _, r := range rep.Results {
+		out[r.Domain] = r
+	}
+	return out
+}
+
+// TestMigrateStorage_FileToSQLite copies every domain, verifies it, and
+// checks a rerun skips everything.
+func TestMigrateStorage_FileToSQLite(t *testing.T) {
+	src := migrationSource(t)
+	dst, err := storage.NewSQLite(storage.SQLiteOptions{Path: filepath.Join(t.TempDir(), "versus.db")})
+	if err != nil {
+		t.Fatalf("NewSQLite: %v", err)
+	}
+	defer dst.Close()
+
+	rep, err := MigrateStorage(context.Background(), src, dst, StorageMigrationOptions{PageSize: 2})
+	if err != nil {
+		t.Fatalf("MigrateStorage: %v", err)
+	}
+	want := map[string]int{
+		MigrationDomainIncidents: 5, MigrationDomainTimeline: 5, MigrationDomainAnalyses: 2,
+		MigrationDomainBlobs: 2, MigrationDomainPatterns: 1, MigrationDomainServices: 1,
+	}
+	got := migrationResults(rep)
+	for domain, n := range want {
+		if r := got[domain]; r.Source != n || r.Copied != n || r.Dest != n {
+			t.Errorf("%s = %+v, want %d copied and verified", domain, r, n)
+		}
+	}
+
+	list, _ := dst.ListIncidents(0)
+	if len(list) != 5 || list[0].ID != "inc-4" || list[0].Title != "disk full" {
+		t.Fatalf("destination incidents = %d, newest %+v", len(list), list[0])
+	}
+	if tl, _ := dst.(storage.Timeline).ListTimeline("inc-2", 0); len(tl) != 1 || tl[0].Body != "looking" {
+		t.Fatalf("timeline not migrated: %+v", tl)
+	}
+	if b, _ := dst.ReadBlob("teams"); string(b) != `{"teams":[]}` {
+		t.Fatalf("blob teams = %q", b)
+	}
+	if b, _ := dst.ReadBlob("incidents"); b != nil {
+		t.Fatalf("the file backend's incident history was copied as a blob")
+	}
+	patterns, services, err := agent.ReadCatalog(dst)
+	if err != nil || patterns["p1"] == nil || patterns["p1"].Verdict != "known" || services["api"] == nil || !services["api"].Manual {
+		t.Fatalf("catalog not migrated: %v %v %v", patterns, services, err)
+	}
+
+	rep, err = MigrateStorage(context.Background(), src, dst, StorageMigrationOptions{})
+	if err != nil {
+		t.Fatalf("rerun: %v", err)
+	}
+	for _, r := range rep.Results {
+		if r.Copied != 0 || r.Skipped != r.Source {
+			t.Errorf("rerun %s = %+v, want everything skipped", r.Domain, r)
+		}
+	}
+}
+
+// TestMigrateStorage_DryRunWritesNothing reports the plan and leaves the
+// destination empty.
+func TestMigrateStorage_DryRunWritesNothing(t *testing.T) {
+	src := migrationSource(t)
+	dst := storage.NewMemory()
+
+	rep, err := MigrateStorage(context.Background(), src, dst, StorageMigrationOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("MigrateStorage(dry run): %v", err)
+	}
+	if r := migrationResults(rep)[MigrationDomainIncidents]; r.Copied != 5 || r.Dest != 0 {
+		t.Fatalf("dry-run incidents = %+v, want 5 to copy and none present", r)
+	}
+	if list, _ := dst.ListIncidents(0); len(list) != 0 {
+		t.Fatalf("dry run wrote %d incidents", len(list))
+	}
+	if blobs, _ := dst.ListBlobs(""); len(blobs) != 0 {
+		t.Fatalf("dry run wrote %d blobs", len(blobs))
+	}
+}
+
+// TestMigrateStorage_VerifyCatchesCappedDestination fails verification when
+// the destination's rolling cap drops incidents, keeping the newest.
+func TestMigrateStorage_VerifyCatchesCappedDestination(t *testing.T) {
+	src := migrationSource(t)
+	dst, err := storage.NewFile(storage.FileOptions{DataDir: t.TempDir(), MaxIncidents: 3})
+	if err != nil {
+		t.Fatalf("NewFile: %v", err)
+	}
+	defer dst.Close()
+
+	rep, err := MigrateStorage(context.Background(), src, dst, StorageMigrationOptions{})
+	if !errors.Is(err, ErrStorageMigrationVerify) {
+		t.Fatalf("err = %v, want ErrStorageMigrationVerify", err)
+	}
+	if r := migrationResults(rep)[MigrationDomainIncidents]; r.Dest != 3 {
+		t.Fatalf("incidents = %+v, want 3 kept", r)
+	}
+	if _, err := dst.GetIncident("inc-4"); err != nil {
+		t.Fatalf("newest incident dropped: %v", err)
+	}
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/storage"
+	"github.com/google/uuid"
+)
+
+// TimelineActorSystem is the actor stamped on timeline entries the server
+// records on its own behalf (fan-out outcome, on-call, auto-resolve).
+const TimelineActorSystem = "system"
+
+// RecordTimeline appends e to its incident's timeline, stamping an id and
+// creation time when they are unset. It is best-effort: the timeline is an
+// audit aid, never a gate, so a nil store, a backend without the
+// storage.Timeline capability, or a write error is logged and swallowed.
+func RecordTimeline(e *storage.TimelineEntry) {
+	if store == nil || e == nil {
+		return
+	}
+	tl, ok := store.(storage.Timeline)
+	if !ok {
+		return
+	}
+	if e.ID == "" {
+		e.ID = uuid.NewString()
+	}
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now().UTC()
+	}
+	if err := tl.AppendTimelineEntry(e); err != nil {
+		log.Printf("incident: timeline %s warning for %s: %v", e.Kind, e.IncidentID, err)
+	}
+}
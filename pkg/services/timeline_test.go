@@ -0,0 +1,46 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/VersusControl/versus-incident/pkg/storage"
+)
+
+// TestCreateIncident_RecordsTimeline checks the fan-out outcome and the
+// webhook auto-resolve land on the incident's timeline as system events.
+func TestCreateIncident_RecordsTimeline(t *testing.T) {
+	autoResolveTestConfig(t)
+
+	rec := onlyIncident(t, map[string]interface{}{"title": "disk full"})
+	entries, err := Storage().(storage.Timeline).ListTimeline(rec.ID, 0)
+	if err != nil {
+		t.Fatalf("ListTimeline: %v", err)
+	}
+
+	kinds := map[string]*storage.TimelineEntry{}
+	for _, e := range entries {
+		kinds[e.Kind] = e
+		if e.Actor != TimelineActorSystem {
+			t.Errorf("%s entry actor = %q, want %q", e.Kind, e.Actor, TimelineActorSystem)
+		}
+	}
+	if n := kinds[storage.TimelineNotification]; n == nil || n.Data["status"] != rec.NotifyStatus {
+		t.Errorf("notification entry = %+v, want status %q", n, rec.NotifyStatus)
+	}
+	if s := kinds[storage.TimelineStatusChange]; s == nil || s.Data["to"] != "resolved" {
+		t.Errorf("status change entry = %+v, want auto-resolve", s)
+	}
+}
+
+// TestRecordTimeline_NilSafe proves the helper never panics without a store
+// or on a backend lacking the capability.
+func TestRecordTimeline_NilSafe(t *testing.T) {
+	prev := Storage()
+	t.Cleanup(func() { SetStorage(prev) })
+
+	SetStorage(nil)
+	RecordTimeline(&storage.TimelineEntry{IncidentID: "x", Kind: storage.TimelineNote})
+
+	SetStorage(struct{ storage.Provider }{storage.NewMemory()})
+	RecordTimeline(&storage.TimelineEntry{IncidentID: "x", Kind: storage.TimelineNote})
+}
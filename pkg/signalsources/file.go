@@ -2,6 +2,7 @@ package signalsources
 
 import (
 	"bufio"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -9,6 +10,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -18,92 +20,110 @@ import (
 	"github.com/VersusControl/versus-incident/pkg/core"
 )
 
-// FileSource tails a log file on disk. It is intended primarily for local
-// testing and small-scale deployments — production users should prefer the
-// Elasticsearch source.
+// FileSource tails log files on disk: one file, a glob, or every file under a
+// directory.
 //
 // Behavior:
 //
-//   - Position is tracked as a byte offset stored in a sidecar cursor file
-//     so it survives process restarts.
-//   - If the file shrinks between ticks (truncate / rotate / re-create) the
-//     source reads from the start.
-//   - The Pull `since` argument is ignored: the byte offset is the source of
-//     truth. The returned cursor timestamp is just `time.Now()` so the
+//   - Each file has its own byte offset, keyed by device and inode and
+//     stored in a sidecar cursor file so it survives process restarts. Pull
+//     only advances the offsets in memory; Commit writes the sidecar after
+//     the catalog flush that learned the lines (core.SourceCommitter), and
+//     never past a line a pipeline stage still holds (HoldCommit).
+//   - The globs are re-expanded every tick, so files created later are
+//     picked up and read from their start.
+//   - A file that is renamed away (logrotate's default `create` mode) is
+//     still held open and is drained to EOF before it is dropped. A renamed
+//     file that still matches keeps its offset.
+//   - If a file shrinks below its offset (truncate, copytruncate) it is read
+//     from the start.
+//   - `.gz` files are read only on the first start with from_beginning, as
+//     backfill. A `.gz` file that appears later is a compressed copy of a
+//     rotated file already read, so it is recorded and skipped.
+//   - Files are read oldest modification time first, within one shared
+//     MaxLinesPerPull budget.
+//   - The Pull `since` argument is ignored: the byte offsets are the source
+//     of truth. The returned cursor timestamp is just `time.Now()` so the
 //     worker has something to log.
 //   - Lines longer than MaxLineBytes are truncated (with a marker).
 //   - Empty / whitespace-only lines are skipped.
 type FileSource struct {
-	name string
-	cfg  config.AgentFileSourceConfig
+	name     string
+	cfg      config.AgentFileSourceConfig
+	patterns []string // Path, then Paths
+
+	mu       sync.Mutex
+	files    map[string]*tailedFile // by fileKey
+	held     map[string]int64       // by fileKey: offset of the first held line
+	cursorFP string
+	decoder  lineDecoder
+}
 
-	mu        sync.Mutex
-	offset    int64 // current read position
-	cursorFP  string
-	tsLayouts []string
+// tailedFile is one file the source is reading.
+type tailedFile struct {
+	key     string
+	path    string // where it was last matched
+	offset  int64  // bytes consumed; decompressed bytes for gzip
+	gzip    bool
+	done    bool     // gzip read to its end, or skipped
+	f       *os.File // plain files stay open so a renamed file can be drained
+	matched bool     // matched by the latest scan
+	modTime time.Time
 }
 
 // Defaults applied when the corresponding option is empty / zero.
 const (
-	defaultFileFormat          = "text"
 	defaultFileMaxLineBytes    = 64 * 1024
 	defaultFileMaxLinesPerPull = 1000
-	defaultJSONMessageField    = "message"
-	defaultJSONTimestampField  = "@timestamp"
-	defaultJSONSeverityField   = "level"
 )
 
-// defaultTextTimestampLayouts are tried in order when TimestampLayout is empty.
-var defaultTextTimestampLayouts = []string{
-	time.RFC3339Nano,
-	time.RFC3339,
-	"2006-01-02T15:04:05.000Z",
-	"2006-01-02 15:04:05",
-}
-
 // NewFileSource validates configuration and locates the cursor sidecar file.
-// It does NOT open the log file; that happens lazily inside Pull so a missing
-// file at startup doesn't crash the worker (the file may appear later).
+// It does NOT open the log files; that happens lazily inside Pull so a missing
+// file at startup doesn't crash the worker (the file may appear later). With
+// no sidecar yet it notes the files that already exist, at their end unless
+// from_beginning is set.
 func NewFileSource(name string, cfg config.AgentFileSourceConfig) (*FileSource, error) {
-	if cfg.Path == "" {
+	var patterns []string
+	for _, p := range append([]string{cfg.Path}, cfg.Paths...) {
+		if p != "" {
+			patterns = append(patterns, p)
+		}
+	}
+	if len(patterns) == 0 {
 		return nil, fmt.Errorf("file source %q: path is required", name)
 	}
+	for _, p := range append(append([]string(nil), patterns...), cfg.Exclude...) {
+		if err := validateGlob(p); err != nil {
+			return nil, fmt.Errorf("file source %q: bad pattern %q: %w", name, p, err)
+		}
+	}
 	switch cfg.Format {
-	case "", "text", "json":
+	case "", "text", "json", "logfmt":
 		// ok
 	default:
-		return nil, fmt.Errorf("file source %q: unknown format %q (want \"text\" or \"json\")", name, cfg.Format)
+		return nil, fmt.Errorf("file source %q: unknown format %q (want \"text\", \"json\" or \"logfmt\")", name, cfg.Format)
 	}
 
 	cursorPath := cfg.CursorPath
 	if cursorPath == "" {
-		// Sit next to the log file by default — keeps everything self-contained
-		// for local testing.
-		dir := filepath.Dir(cfg.Path)
-		cursorPath = filepath.Join(dir, ".versus-cursor-"+sanitizeName(name))
+		// Sit next to the log files by default — keeps everything
+		// self-contained for local testing.
+		cursorPath = filepath.Join(globStaticDir(patterns[0]), ".versus-cursor-"+sanitizeName(name))
 	}
 
 	s := &FileSource{
 		name:     name,
 		cfg:      cfg,
+		patterns: patterns,
+		files:    make(map[string]*tailedFile),
 		cursorFP: cursorPath,
 	}
-	if cfg.TimestampLayout != "" {
-		s.tsLayouts = []string{cfg.TimestampLayout}
-	} else {
-		s.tsLayouts = defaultTextTimestampLayouts
-	}
+	s.decoder = newLineDecoder(s.Name(), cfg.Format, cfg.TimestampLayout, cfg.MessageField, cfg.TimestampField, cfg.SeverityField)
 
-	if off, err := s.loadOffset(); err == nil {
-		s.offset = off
-	} else if !os.IsNotExist(err) {
+	if err := s.loadCursor(); os.IsNotExist(err) {
+		s.track(s.scan(), true)
+	} else if err != nil {
 		return nil, fmt.Errorf("file source %q: read cursor %s: %w", name, cursorPath, err)
-	} else if !cfg.FromBeginning {
-		// First ever run with from_beginning=false: jump to current EOF so we
-		// don't pick up history.
-		if fi, statErr := os.Stat(cfg.Path); statErr == nil {
-			s.offset = fi.Size()
-		}
 	}
 
 	return s, nil
@@ -113,121 +133,331 @@ func (s *FileSource) Name() string { return "file:" + s.name }
 
 // Rewind resets the read position to what a brand-new FileSource would use when
 // it finds no persisted sidecar cursor: offset 0 when from_beginning is set (so
-// the whole file is re-read), else the current EOF (so history the operator
-// chose to skip stays skipped). It also removes the sidecar so a restart
-// mid-rewind starts from the same place.
+// every file is re-read), else the current EOF (so history the operator chose
+// to skip stays skipped). It also removes the sidecar so a restart mid-rewind
+// starts from the same place.
 //
-// This implements core.SourceRewinder. The file source's byte offset is its own
-// cursor of truth and it ignores the worker's `since` cursor, so a catalog clear
-// that only rewinds the worker cursor would leave this source pinned at EOF and
-// unable to re-emit already-consumed lines. Rewind reconciles the two so a clear
-// makes the SAME running worker re-read the file in place — the in-memory
-// equivalent of recreating the container.
+// This implements core.SourceRewinder. The file source's byte offsets are its
+// own cursor of truth and it ignores the worker's `since` cursor, so a catalog
+// clear that only rewinds the worker cursor would leave this source pinned at
+// EOF and unable to re-emit already-consumed lines. Rewind reconciles the two
+// so a clear makes the SAME running worker re-read the files in place — the
+// in-memory equivalent of recreating the container.
 func (s *FileSource) Rewind(_ context.Context) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	// Drop the persisted byte offset so this matches a fresh process with no
+	// Drop the persisted offsets so this matches a fresh process with no
 	// sidecar yet. A missing sidecar is not an error.
 	if err := os.Remove(s.cursorFP); err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("file source %q: reset cursor %s: %w", s.name, s.cursorFP, err)
 	}
-
-	if s.cfg.FromBeginning {
-		s.offset = 0
-		return nil
-	}
-	// from_beginning=false: a fresh start jumps to the current EOF so the
-	// backlog the operator opted out of is not replayed by a clear.
-	if fi, err := os.Stat(s.cfg.Path); err == nil {
-		s.offset = fi.Size()
-	} else {
-		s.offset = 0
+	for _, tf := range s.files {
+		tf.close()
 	}
+	s.files = make(map[string]*tailedFile)
+	s.track(s.scan(), true)
 	return nil
 }
 
-// Pull reads new content from the file since the last recorded byte offset.
-// Errors from a single tick are returned (worker logs and continues); the
-// offset is only advanced for content that was successfully read.
+// Pull reads new content from every matched file since its recorded byte
+// offset. Errors from a single file are logged and the rest are still read;
+// an offset is only advanced for content that was successfully read.
 func (s *FileSource) Pull(_ context.Context, _ time.Time) ([]core.Signal, time.Time, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	cursor := time.Now().UTC()
+	s.track(s.scan(), false)
 
-	f, err := os.Open(s.cfg.Path)
-	if err != nil {
-		if os.IsNotExist(err) {
-			// File not there yet — that's fine, just nothing to do.
-			return nil, cursor, nil
+	maxLine := s.cfg.MaxLineBytes
+	if maxLine <= 0 {
+		maxLine = defaultFileMaxLineBytes
+	}
+	maxLines := s.cfg.MaxLinesPerPull
+	if maxLines <= 0 {
+		maxLines = defaultFileMaxLinesPerPull
+	}
+
+	order := make([]*tailedFile, 0, len(s.files))
+	for _, tf := range s.files {
+		if !tf.done {
+			order = append(order, tf)
 		}
-		return nil, cursor, fmt.Errorf("open %s: %w", s.cfg.Path, err)
 	}
-	defer f.Close()
+	sort.Slice(order, func(i, j int) bool {
+		if !order[i].modTime.Equal(order[j].modTime) {
+			return order[i].modTime.Before(order[j].modTime)
+		}
+		return order[i].path < order[j].path
+	})
 
-	fi, err := f.Stat()
-	if err != nil {
-		return nil, cursor, fmt.Errorf("stat %s: %w", s.cfg.Path, err)
+	var signals []core.Signal
+	for _, tf := range order {
+		budget := maxLines - len(signals)
+		if budget <= 0 {
+			break
+		}
+		sigs, err := s.readFile(tf, maxLine, budget)
+		signals = append(signals, sigs...)
+		if err != nil {
+			log.Printf("file source %s: read %s: %v", s.name, tf.path, err)
+		}
 	}
+	return signals, cursor, nil
+}
 
-	// Detect rotation / truncation: if the file is smaller than where we
-	// stopped reading, assume it was rotated and start over.
-	if fi.Size() < s.offset {
-		s.offset = 0
+// HoldCommit records, per file, the offset of the first held line, so the
+// next Commit saves no offset past it. It implements core.SourceCommitHolder.
+func (s *FileSource) HoldCommit(held []core.Signal) {
+	hold := make(map[string]int64)
+	for _, sig := range held {
+		key, ok := sig.Fields[FieldFileKey].(string)
+		off, ok2 := sig.Fields[FieldFileOffset].(int64)
+		if !ok || !ok2 {
+			continue
+		}
+		if cur, seen := hold[key]; !seen || off < cur {
+			hold[key] = off
+		}
 	}
+	s.mu.Lock()
+	s.held = hold
+	s.mu.Unlock()
+}
 
-	if _, err := f.Seek(s.offset, io.SeekStart); err != nil {
-		return nil, cursor, fmt.Errorf("seek %s: %w", s.cfg.Path, err)
+// Commit writes the offsets read so far to the sidecar. It implements
+// core.SourceCommitter; a failed write is retried by the next Commit.
+func (s *FileSource) Commit(_ context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.saveCursor(); err != nil {
+		return fmt.Errorf("file source %q: save cursor: %w", s.name, err)
 	}
+	return nil
+}
 
-	maxLine := s.cfg.MaxLineBytes
-	if maxLine <= 0 {
-		maxLine = defaultFileMaxLineBytes
+// Fields the file source stamps on every line.
+const (
+	// FieldFilePath names the file a signal was read from, so lines of
+	// different files stay apart downstream (the multiline stage keys on
+	// it).
+	FieldFilePath = "log.file.path"
+	// FieldFileOffset is the byte offset of the line in its file; in a
+	// `.gz` file, in the decompressed content.
+	FieldFileOffset = "log.offset"
+	// FieldFileKey identifies the file across renames: its device and
+	// inode, where the platform has them.
+	FieldFileKey = "log.file.key"
+)
+
+// stampFileOrigin sets the file fields on a copy of the signal's fields: a
+// JSON line's Fields is the same map as its Raw, which must stay as read.
+func stampFileOrigin(sig *core.Signal, tf *tailedFile, offset int64) {
+	fields := make(map[string]any, len(sig.Fields)+3)
+	for k, v := range sig.Fields {
+		fields[k] = v
+	}
+	fields[FieldFilePath] = tf.path
+	fields[FieldFileOffset] = offset
+	fields[FieldFileKey] = tf.key
+	sig.Fields = fields
+}
+
+// fileMatch is one file found by a scan.
+type fileMatch struct {
+	path string
+	info os.FileInfo
+}
+
+// scan expands the patterns into the files they match now, by file key. The
+// sidecar itself and excluded files are left out.
+func (s *FileSource) scan() map[string]fileMatch {
+	cursorAbs, _ := filepath.Abs(s.cursorFP)
+	out := make(map[string]fileMatch)
+	for _, pattern := range s.patterns {
+		for _, path := range expandGlob(pattern) {
+			if abs, err := filepath.Abs(path); err == nil && (abs == cursorAbs || abs == cursorAbs+".tmp") {
+				continue
+			}
+			if s.excluded(path) {
+				continue
+			}
+			fi, err := os.Stat(path)
+			if err != nil {
+				continue
+			}
+			key := fileKey(path, fi)
+			if _, dup := out[key]; !dup {
+				out[key] = fileMatch{path: path, info: fi}
+			}
+		}
 	}
-	maxLines := s.cfg.MaxLinesPerPull
-	if maxLines <= 0 {
-		maxLines = defaultFileMaxLinesPerPull
+	return out
+}
+
+// excluded reports whether an exclude pattern matches the file's name or its
+// whole path.
+func (s *FileSource) excluded(path string) bool {
+	for _, ex := range s.cfg.Exclude {
+		if ok, _ := filepath.Match(ex, filepath.Base(path)); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(ex, path); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// track reconciles the tracked files with a scan. initial is true when there
+// is no saved position: existing files then start at their end, or at their
+// start with from_beginning. Otherwise a new plain file is read from its
+// start and a new `.gz` file is skipped. A tracked file the scan no longer
+// matches is kept while its handle is open, to be drained; otherwise it is
+// forgotten.
+func (s *FileSource) track(matches map[string]fileMatch, initial bool) {
+	for _, tf := range s.files {
+		tf.matched = false
+	}
+	for key, m := range matches {
+		tf := s.files[key]
+		if tf == nil {
+			tf = &tailedFile{key: key, gzip: strings.HasSuffix(m.path, ".gz")}
+			switch {
+			case tf.gzip:
+				tf.done = !(initial && s.cfg.FromBeginning)
+			case initial && !s.cfg.FromBeginning:
+				tf.offset = m.info.Size()
+			}
+			s.files[key] = tf
+		}
+		tf.path = m.path
+		tf.modTime = m.info.ModTime()
+		tf.matched = true
+	}
+	for key, tf := range s.files {
+		switch {
+		case tf.matched:
+			// Still in place.
+		case tf.f == nil:
+			delete(s.files, key)
+		default:
+			if fi, err := tf.f.Stat(); err == nil {
+				tf.modTime = fi.ModTime()
+			}
+		}
 	}
+}
 
-	signals, bytesRead, err := s.readSignals(f, maxLine, maxLines)
+// readFile reads up to budget lines from one file and advances its offset.
+func (s *FileSource) readFile(tf *tailedFile, maxLine, budget int) ([]core.Signal, error) {
+	if tf.gzip {
+		return s.readGzip(tf, maxLine, budget)
+	}
+	if tf.f == nil {
+		f, err := os.Open(tf.path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				// Gone since the scan — nothing to do.
+				return nil, nil
+			}
+			return nil, err
+		}
+		// The path may have been rotated between the scan and the open.
+		if fi, err := f.Stat(); err != nil || fileKey(tf.path, fi) != tf.key {
+			f.Close()
+			return nil, nil
+		}
+		tf.f = f
+	}
+
+	fi, err := tf.f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	// Detect truncation: if the file is smaller than where we stopped
+	// reading, it was truncated in place; start over.
+	if fi.Size() < tf.offset {
+		tf.offset = 0
+	}
+	if _, err := tf.f.Seek(tf.offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	signals, bytesRead, err := s.readSignals(tf, tf.f, maxLine, budget)
 	// Advance offset by what we successfully consumed even if we hit a
 	// read error mid-stream — so we don't infinitely re-read a bad line.
-	s.offset += bytesRead
-	if persistErr := s.saveOffset(s.offset); persistErr != nil {
-		// Non-fatal: offset will be re-saved next tick.
-		log.Printf("file source %s: cursor save failed: %v", s.name, persistErr)
+	tf.offset += bytesRead
+	if !tf.matched && err == nil && len(signals) < budget {
+		// A rotated-away file read to EOF: it is finished.
+		tf.close()
+		delete(s.files, tf.key)
+	}
+	return signals, err
+}
+
+// readGzip reads a compressed file from its decompressed offset. It is
+// reopened on each read, and marked done once read to its end.
+func (s *FileSource) readGzip(tf *tailedFile, maxLine, budget int) ([]core.Signal, error) {
+	f, err := os.Open(tf.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
 	}
-	if err != nil && err != io.EOF {
-		return signals, cursor, fmt.Errorf("read %s: %w", s.cfg.Path, err)
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		tf.done = true
+		return nil, err
+	}
+	defer zr.Close()
+	if _, err := io.CopyN(io.Discard, zr, tf.offset); err != nil {
+		tf.done = true
+		if err == io.EOF {
+			return nil, nil
+		}
+		return nil, err
 	}
-	return signals, cursor, nil
+	signals, bytesRead, err := s.readSignals(tf, zr, maxLine, budget)
+	tf.offset += bytesRead
+	if err != nil || len(signals) < budget {
+		// At its end, or corrupt past this point: either way it is finished.
+		tf.done = true
+	}
+	return signals, err
 }
 
-// readSignals reads complete lines from r and converts them to signals.
+func (tf *tailedFile) close() {
+	if tf.f != nil {
+		tf.f.Close()
+		tf.f = nil
+	}
+}
+
+// readSignals reads complete lines of tf from r, which is positioned at
+// tf.offset, and converts them to signals stamped with where they came from.
 // It returns the number of bytes consumed (so the caller can advance the
 // persistent offset by exactly that much) plus any non-EOF error. When
 // maxLines > 0 the loop stops after that many lines have been emitted as
 // signals, leaving the rest for the next Pull (the byte offset only
 // advances over the lines this call actually consumed).
-func (s *FileSource) readSignals(r io.Reader, maxLine, maxLines int) ([]core.Signal, int64, error) {
+func (s *FileSource) readSignals(tf *tailedFile, r io.Reader, maxLine, maxLines int) ([]core.Signal, int64, error) {
 	br := bufio.NewReaderSize(r, 64*1024)
 	var signals []core.Signal
 	var consumed int64
-	format := s.cfg.Format
-	if format == "" {
-		format = defaultFileFormat
-	}
 
 	for {
 		line, err := readLineLimited(br, maxLine)
 		if len(line) > 0 {
+			at := tf.offset + consumed
 			consumed += int64(len(line))
 			text := strings.TrimRight(line, "\r\n")
 			if strings.TrimSpace(text) != "" {
-				if sig, ok := s.lineToSignal(text, format); ok {
-					signals = append(signals, sig)
-				}
+				sig := s.decoder.decode(text, time.Time{})
+				stampFileOrigin(&sig, tf, at)
+				signals = append(signals, sig)
 			}
 		}
 		if err != nil {
@@ -275,160 +505,67 @@ func readLineLimited(br *bufio.Reader, maxLine int) (string, error) {
 	}
 }
 
-func (s *FileSource) lineToSignal(line, format string) (core.Signal, bool) {
-	switch format {
-	case "json":
-		return s.jsonLineToSignal(line)
-	default:
-		return s.textLineToSignal(line)
-	}
-}
+// -----------------------------------------------------------------------------
+// cursor sidecar file
+// -----------------------------------------------------------------------------
 
-func (s *FileSource) textLineToSignal(line string) (core.Signal, bool) {
-	ts, rest, ok := s.tryParseLeadingTimestamp(line)
-	if !ok {
-		ts = time.Now().UTC()
-		rest = line
-	}
-	return core.Signal{
-		Source:    s.Name(),
-		Timestamp: ts,
-		// Message is the line WITHOUT the leading timestamp so identical
-		// messages with different timestamps cluster together. Raw keeps the
-		// full original line for debugging.
-		Message: rest,
-		Raw:     map[string]interface{}{"line": line},
-	}, true
+// fileCursor is the sidecar's content: each tracked file's offset by file key.
+type fileCursor struct {
+	Files map[string]fileCursorEntry `json:"files"`
 }
 
-// tryParseLeadingTimestamp tests each configured layout against the start of
-// the line. We accept either a bare timestamp followed by whitespace or a
-// timestamp wrapped in brackets like "[2024-01-02T15:04:05Z] ...". On success
-// it also returns the remainder of the line (with the timestamp + any
-// trailing bracket / whitespace stripped).
-//
-// The lookup splits on the first whitespace and tries to parse the prefix.
-// We do NOT key off `len(layout)` because Go layouts contain reference
-// strings like `Z07:00` that are longer than the actual rendered timestamp
-// (e.g. RFC3339 is 25 chars but a `…Z` timestamp is only 20). Splitting on
-// the first space is robust to any layout the user configures.
-func (s *FileSource) tryParseLeadingTimestamp(line string) (time.Time, string, bool) {
-	candidate := strings.TrimLeft(line, "[")
-	leadingBracket := len(line) - len(candidate)
-
-	// Find the first whitespace — that's the end of the timestamp token.
-	// Falls back to the whole string if the line has no whitespace.
-	end := strings.IndexAny(candidate, " \t")
-	if end < 0 {
-		end = len(candidate)
-	}
-	head := candidate[:end]
-	// If the timestamp was wrapped in brackets the closing `]` will be at
-	// the end of `head`; strip it before parsing.
-	if leadingBracket > 0 && strings.HasSuffix(head, "]") {
-		head = head[:len(head)-1]
-	}
-
-	for _, layout := range s.tsLayouts {
-		if t, err := time.Parse(layout, head); err == nil {
-			rest := candidate[end:]
-			if leadingBracket > 0 {
-				rest = strings.TrimPrefix(rest, "]")
-			}
-			rest = strings.TrimLeft(rest, " \t")
-			return t.UTC(), rest, true
-		}
-	}
-	return time.Time{}, "", false
+type fileCursorEntry struct {
+	Path   string `json:"path"`
+	Offset int64  `json:"offset"`
+	Done   bool   `json:"done,omitempty"`
 }
 
-func (s *FileSource) jsonLineToSignal(line string) (core.Signal, bool) {
-	var m map[string]interface{}
-	if err := json.Unmarshal([]byte(line), &m); err != nil {
-		// Fall back to text behavior so a malformed line isn't lost.
-		return s.textLineToSignal(line)
-	}
-
-	msgField := s.cfg.MessageField
-	if msgField == "" {
-		msgField = defaultJSONMessageField
-	}
-	tsField := s.cfg.TimestampField
-	if tsField == "" {
-		tsField = defaultJSONTimestampField
+// loadCursor restores the tracked files from the sidecar. Files that do not
+// match any more are dropped on the next scan. An older sidecar holding a
+// single byte offset is applied to Path when it names one file.
+func (s *FileSource) loadCursor() error {
+	b, err := os.ReadFile(s.cursorFP)
+	if err != nil {
+		return err
 	}
-	sevField := s.cfg.SeverityField
-	if sevField == "" {
-		sevField = defaultJSONSeverityField
+	text := strings.TrimSpace(string(b))
+	if off, perr := strconv.ParseInt(text, 10, 64); perr == nil {
+		if fi, err := os.Stat(s.cfg.Path); err == nil && !hasGlobMeta(s.cfg.Path) && fi.Mode().IsRegular() {
+			key := fileKey(s.cfg.Path, fi)
+			s.files[key] = &tailedFile{key: key, path: s.cfg.Path, offset: max(off, 0), modTime: fi.ModTime()}
+		}
+		return nil
 	}
-
-	msg, _ := m[msgField].(string)
-	if msg == "" {
-		// No usable message — emit the whole line so the operator sees it.
-		msg = line
+	var c fileCursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return fmt.Errorf("invalid cursor file %s: %w", s.cursorFP, err)
 	}
-	severity, _ := m[sevField].(string)
-
-	ts := time.Now().UTC()
-	if v, ok := m[tsField]; ok {
-		if parsed, ok := parseJSONTimestamp(v); ok {
-			ts = parsed
-		}
+	for key, e := range c.Files {
+		s.files[key] = &tailedFile{key: key, path: e.Path, offset: max(e.Offset, 0), done: e.Done, gzip: strings.HasSuffix(e.Path, ".gz")}
 	}
-
-	return core.Signal{
-		Source:    s.Name(),
-		Timestamp: ts,
-		Severity:  severity,
-		Message:   msg,
-		Fields:    m,
-		Raw:       m,
-	}, true
+	return nil
 }
 
-func parseJSONTimestamp(v interface{}) (time.Time, bool) {
-	switch t := v.(type) {
-	case string:
-		for _, layout := range []string{time.RFC3339Nano, time.RFC3339, "2006-01-02 15:04:05"} {
-			if parsed, err := time.Parse(layout, t); err == nil {
-				return parsed.UTC(), true
-			}
+// saveCursor writes each tracked file's offset, or the offset of its first
+// held line when that is earlier, so a restart reads held lines again.
+func (s *FileSource) saveCursor() error {
+	c := fileCursor{Files: make(map[string]fileCursorEntry, len(s.files))}
+	for key, tf := range s.files {
+		e := fileCursorEntry{Path: tf.path, Offset: tf.offset, Done: tf.done}
+		if h, ok := s.held[key]; ok && h < e.Offset {
+			e.Offset, e.Done = h, false
 		}
-	case float64:
-		// Heuristic: > 1e12 is millis, otherwise seconds.
-		if t > 1e12 {
-			return time.UnixMilli(int64(t)).UTC(), true
-		}
-		return time.Unix(int64(t), 0).UTC(), true
-	}
-	return time.Time{}, false
-}
-
-// -----------------------------------------------------------------------------
-// cursor sidecar file
-// -----------------------------------------------------------------------------
-
-func (s *FileSource) loadOffset() (int64, error) {
-	b, err := os.ReadFile(s.cursorFP)
-	if err != nil {
-		return 0, err
+		c.Files[key] = e
 	}
-	off, err := strconv.ParseInt(strings.TrimSpace(string(b)), 10, 64)
+	b, err := json.Marshal(c)
 	if err != nil {
-		return 0, fmt.Errorf("invalid cursor file %s: %w", s.cursorFP, err)
-	}
-	if off < 0 {
-		return 0, nil
+		return err
 	}
-	return off, nil
-}
-
-func (s *FileSource) saveOffset(off int64) error {
 	if err := os.MkdirAll(filepath.Dir(s.cursorFP), 0o755); err != nil {
 		return err
 	}
 	tmp := s.cursorFP + ".tmp"
-	if err := os.WriteFile(tmp, []byte(strconv.FormatInt(off, 10)), 0o644); err != nil {
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
 		return err
 	}
 	return os.Rename(tmp, s.cursorFP)
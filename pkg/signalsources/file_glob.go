@@ -0,0 +1,130 @@
+package signalsources
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// hasGlobMeta reports whether a path segment holds a glob pattern.
+func hasGlobMeta(s string) bool {
+	return strings.ContainsAny(s, `*?[\`)
+}
+
+// globStaticDir returns the directory a pattern's matches all live under:
+// the leading segments without glob characters. A literal path yields its
+// parent directory.
+func globStaticDir(pattern string) string {
+	clean := filepath.Clean(pattern)
+	if !hasGlobMeta(clean) {
+		if fi, err := os.Stat(clean); err == nil && fi.IsDir() {
+			return clean
+		}
+		return filepath.Dir(clean)
+	}
+	segs := strings.Split(clean, string(filepath.Separator))
+	i := 0
+	for i < len(segs) && !hasGlobMeta(segs[i]) {
+		i++
+	}
+	dir := strings.Join(segs[:i], string(filepath.Separator))
+	switch {
+	case dir == "" && filepath.IsAbs(clean):
+		return string(filepath.Separator)
+	case dir == "":
+		return "."
+	}
+	return dir
+}
+
+// validateGlob checks a pattern's syntax without touching the disk.
+func validateGlob(pattern string) error {
+	for _, seg := range strings.Split(filepath.Clean(pattern), string(filepath.Separator)) {
+		if seg == "**" {
+			continue
+		}
+		if _, err := filepath.Match(seg, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expandGlob returns the regular files a pattern matches. `**` as a whole
+// segment matches any number of directories, and a directory matches every
+// file beneath it. A pattern that matches nothing, or whose directory does
+// not exist yet, returns no paths and no error.
+func expandGlob(pattern string) []string {
+	clean := filepath.Clean(pattern)
+	if !hasGlobMeta(clean) {
+		fi, err := os.Stat(clean)
+		switch {
+		case err != nil:
+			return nil
+		case fi.IsDir():
+			clean = filepath.Join(clean, "**")
+		default:
+			return []string{clean}
+		}
+	}
+	if !strings.Contains(clean, "**") {
+		matches, _ := filepath.Glob(clean)
+		return regularFiles(matches)
+	}
+
+	patSegs := strings.Split(clean, string(filepath.Separator))
+	root := globStaticDir(clean)
+	var out []string
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// An unreadable directory is skipped, not fatal.
+			if d != nil && d.IsDir() && path != root {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if matchGlobSegments(patSegs, strings.Split(filepath.Clean(path), string(filepath.Separator))) {
+			out = append(out, path)
+		}
+		return nil
+	})
+	return regularFiles(out)
+}
+
+// matchGlobSegments matches a path against a pattern segment by segment,
+// with `**` standing for zero or more segments.
+func matchGlobSegments(pat, path []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			for i := 0; i <= len(path); i++ {
+				if matchGlobSegments(pat[1:], path[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(path) == 0 {
+			return false
+		}
+		if ok, _ := filepath.Match(pat[0], path[0]); !ok {
+			return false
+		}
+		pat, path = pat[1:], path[1:]
+	}
+	return len(path) == 0
+}
+
+// regularFiles keeps the paths that are regular files, following symlinks.
+func regularFiles(paths []string) []string {
+	out := paths[:0]
+	for _, p := range paths {
+		if fi, err := os.Stat(p); err == nil && fi.Mode().IsRegular() {
+			out = append(out, p)
+		}
+	}
+	return out
+}
@@ -0,0 +1,243 @@
+package signalsources
+
+import (
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+)
+
+func pullMessages(t *testing.T, src *FileSource) string {
+	t.Helper()
+	sigs, _, err := src.Pull(context.Background(), time.Time{})
+	if err != nil {
+		t.Fatalf("pull: %v", err)
+	}
+	return strings.Join(messages(sigs), ",")
+}
+
+// setModTime pins a file's modification time so the read order is fixed.
+func setModTime(t *testing.T, p string, at time.Time) {
+	t.Helper()
+	if err := os.Chtimes(p, at, at); err != nil {
+		t.Fatalf("chtimes %s: %v", p, err)
+	}
+}
+
+func writeGzip(t *testing.T, p, s string) {
+	t.Helper()
+	f, err := os.Create(p)
+	if err != nil {
+		t.Fatalf("create %s: %v", p, err)
+	}
+	zw := gzip.NewWriter(f)
+	if _, err := zw.Write([]byte(s)); err != nil {
+		t.Fatalf("gzip %s: %v", p, err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("gzip close %s: %v", p, err)
+	}
+	f.Close()
+}
+
+func TestFileSource_GlobRecursiveAndExclude(t *testing.T) {
+	dir := t.TempDir()
+	for _, d := range []string{"a", "b/c"} {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := time.Now().Add(-time.Hour)
+	writeFile(t, filepath.Join(dir, "a", "x.log"), "from a\n")
+	setModTime(t, filepath.Join(dir, "a", "x.log"), old)
+	writeFile(t, filepath.Join(dir, "b", "c", "y.log"), "from c\n")
+	setModTime(t, filepath.Join(dir, "b", "c", "y.log"), old.Add(time.Minute))
+	writeFile(t, filepath.Join(dir, "b", "debug.log"), "excluded\n")
+	writeFile(t, filepath.Join(dir, "notes.txt"), "not a log\n")
+
+	src, err := NewFileSource("g", config.AgentFileSourceConfig{
+		Path:          filepath.Join(dir, "**", "*.log"),
+		Exclude:       []string{"debug.*"},
+		FromBeginning: true,
+	})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	if got := pullMessages(t, src); got != "from a,from c" {
+		t.Fatalf("first pull = %q", got)
+	}
+
+	// A file created after the start is read from its beginning.
+	writeFile(t, filepath.Join(dir, "b", "new.log"), "late one\nlate two\n")
+	if got := pullMessages(t, src); got != "late one,late two" {
+		t.Fatalf("new file = %q", got)
+	}
+
+	// The default sidecar sits in the glob's static directory and is never
+	// read as a log.
+	if err := src.Commit(context.Background()); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".versus-cursor-g")); err != nil {
+		t.Fatalf("sidecar: %v", err)
+	}
+}
+
+func TestFileSource_DirectoryPathIsRecursive(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "nested"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(dir, "nested", "app.log"), "deep\n")
+	src, err := NewFileSource("d", config.AgentFileSourceConfig{Path: dir, FromBeginning: true})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	if got := pullMessages(t, src); got != "deep" {
+		t.Fatalf("pull = %q", got)
+	}
+}
+
+// TestFileSource_RenameRotationDrainsOldFile covers logrotate's create mode:
+// the file is renamed away and a new one of a similar size takes its name.
+// The renamed file's tail is drained, then the new file is read whole.
+func TestFileSource_RenameRotationDrainsOldFile(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "app.log")
+	writeFile(t, logPath, "first\n")
+	src, err := NewFileSource("r", config.AgentFileSourceConfig{Path: logPath, FromBeginning: true, CursorPath: filepath.Join(dir, "cursor")})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	if got := pullMessages(t, src); got != "first" {
+		t.Fatalf("pull1 = %q", got)
+	}
+
+	appendFile(t, logPath, "last words\n")
+	base := time.Now().Add(-time.Minute)
+	setModTime(t, logPath, base)
+	if err := os.Rename(logPath, logPath+".1"); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, logPath, "fresh start here\n")
+	setModTime(t, logPath, base.Add(time.Second))
+
+	if got := pullMessages(t, src); got != "last words,fresh start here" {
+		t.Fatalf("after rotation = %q", got)
+	}
+	if len(src.files) != 1 {
+		t.Fatalf("drained file still tracked: %d files", len(src.files))
+	}
+	if got := pullMessages(t, src); got != "" {
+		t.Fatalf("replayed = %q", got)
+	}
+}
+
+// TestFileSource_RenamedFileKeepsOffset checks a rotated file that still
+// matches the glob is not read again under its new name, also across a
+// restart.
+func TestFileSource_RenamedFileKeepsOffset(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "app.log")
+	cfg := config.AgentFileSourceConfig{Path: logPath + "*", FromBeginning: true, CursorPath: filepath.Join(dir, "cursor")}
+	writeFile(t, logPath, "one\ntwo\n")
+	src, err := NewFileSource("k", cfg)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	if got := pullMessages(t, src); got != "one,two" {
+		t.Fatalf("pull1 = %q", got)
+	}
+	if err := os.Rename(logPath, logPath+".1"); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, logPath, "three\n")
+	if got := pullMessages(t, src); got != "three" {
+		t.Fatalf("after rename = %q", got)
+	}
+
+	appendFile(t, logPath, "four\n")
+	if err := src.Commit(context.Background()); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	restarted, err := NewFileSource("k", cfg)
+	if err != nil {
+		t.Fatalf("restart: %v", err)
+	}
+	if got := pullMessages(t, restarted); got != "four" {
+		t.Fatalf("after restart = %q", got)
+	}
+}
+
+func TestFileSource_GzipBackfillOnlyFromBeginning(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "app.log")
+	old := time.Now().Add(-time.Hour)
+	writeGzip(t, logPath+".2.gz", "oldest\n")
+	setModTime(t, logPath+".2.gz", old)
+	writeFile(t, logPath+".1", "older\n")
+	setModTime(t, logPath+".1", old.Add(time.Minute))
+	writeFile(t, logPath, "current\n")
+
+	src, err := NewFileSource("z", config.AgentFileSourceConfig{Path: logPath + "*", FromBeginning: true, CursorPath: filepath.Join(dir, "cursor")})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	if got := pullMessages(t, src); got != "oldest,older,current" {
+		t.Fatalf("backfill = %q", got)
+	}
+
+	// The next rotation compresses app.log.1; the copy is not read again.
+	writeGzip(t, logPath+".1.gz", "older\n")
+	if got := pullMessages(t, src); got != "" {
+		t.Fatalf("compressed copy read = %q", got)
+	}
+
+	tail, err := NewFileSource("tail", config.AgentFileSourceConfig{Path: logPath + "*", CursorPath: filepath.Join(dir, "tail-cursor")})
+	if err != nil {
+		t.Fatalf("new tail: %v", err)
+	}
+	if got := pullMessages(t, tail); got != "" {
+		t.Fatalf("tail mode read history = %q", got)
+	}
+}
+
+// TestFileSource_LegacyCursor checks the single-offset sidecar of earlier
+// versions is applied to the file it was written for.
+func TestFileSource_LegacyCursor(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "app.log")
+	cursorPath := filepath.Join(dir, "cursor")
+	writeFile(t, logPath, "read before\nnot yet\n")
+	writeFile(t, cursorPath, "12")
+
+	src, err := NewFileSource("l", config.AgentFileSourceConfig{Path: logPath, FromBeginning: true, CursorPath: cursorPath})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	if got := pullMessages(t, src); got != "not yet" {
+		t.Fatalf("pull = %q", got)
+	}
+}
+
+func TestMatchGlobSegments(t *testing.T) {
+	for _, tc := range []struct {
+		pat, path string
+		want      bool
+	}{
+		{"var/log/**/*.log", "var/log/app.log", true},
+		{"var/log/**/*.log", "var/log/a/b/app.log", true},
+		{"var/log/**/*.log", "var/log/a/app.txt", false},
+		{"var/**", "var/x/y", true},
+		{"var/*/app.log", "var/a/b/app.log", false},
+	} {
+		if got := matchGlobSegments(strings.Split(tc.pat, "/"), strings.Split(tc.path, "/")); got != tc.want {
+			t.Errorf("%s ~ %s = %v, want %v", tc.pat, tc.path, got, tc.want)
+		}
+	}
+}
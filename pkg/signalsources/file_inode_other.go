@@ -0,0 +1,17 @@
+//go:build !unix
+
+package signalsources
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// fileKey identifies a file by its absolute path where inodes are not
+// available. A renamed file is then seen as a new one.
+func fileKey(path string, _ os.FileInfo) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return "path:" + abs
+	}
+	return "path:" + path
+}
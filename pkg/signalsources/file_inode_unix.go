@@ -0,0 +1,18 @@
+//go:build unix
+
+package signalsources
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// fileKey identifies a file by device and inode, so a rotated file keeps its
+// cursor when it is renamed.
+func fileKey(_ string, fi os.FileInfo) string {
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		return fmt.Sprintf("%d:%d", st.Dev, st.Ino)
+	}
+	return ""
+}
@@ -61,7 +61,13 @@ func TestFileSource_FromBeginningReadsAllAndAdvances(t *testing.T) {
 		t.Errorf("unexpected messages: %+v", signals)
 	}
 
-	// Cursor must have been written.
+	// Pull only moves the offset in memory; Commit writes the cursor.
+	if _, err := os.Stat(cursorPath); !os.IsNotExist(err) {
+		t.Fatalf("cursor written before Commit: %v", err)
+	}
+	if err := src.Commit(context.Background()); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
 	if _, err := os.Stat(cursorPath); err != nil {
 		t.Fatalf("cursor not persisted: %v", err)
 	}
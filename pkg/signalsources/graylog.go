@@ -7,9 +7,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"net/url"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/VersusControl/versus-incident/pkg/config"
@@ -25,12 +27,37 @@ import (
 // Cursor contract: the source asks for `from = since` (Graylog `from`
 // is INCLUSIVE) and filters the response client-side to messages with
 // timestamp > since. The cursor returned is the maximum timestamp seen.
+//
+// With a re-read span below the cursor — `reorder_window` plus the catalog
+// persist interval the agent adds (ApplyTailReplaySpan) — `from` moves down by
+// that span instead, and the messages already delivered inside it are
+// suppressed by their Graylog `_id` with a persisted id set (TailDedup), the
+// convention the Elasticsearch, CloudWatch Logs and SigNoz tails follow.
 type GraylogSource struct {
 	name   string
 	cfg    config.AgentGraylogSourceConfig
 	client *http.Client
+
+	// reorderWindow is the configured lateness budget.
+	reorderWindow time.Duration
+	// replaySpan is added to reorderWindow to size what a tick re-reads. See
+	// ApplyTailReplaySpan.
+	replaySpan time.Duration
+
+	// nowFn is the wall clock that upper-bounds the scan and clamps the
+	// cursor. Overridable in tests; nil ⇒ time.Now.
+	nowFn func() time.Time
+
+	// mu guards the tick so a concurrent Rewind cannot interleave and leave
+	// stale dedup state behind.
+	mu    sync.Mutex
+	dedup *TailDedup
 }
 
+// graylogMaxPagesPerTick caps the pages one tick walks; the rest of a long
+// span is read next tick.
+const graylogMaxPagesPerTick = 20
+
 // NewGraylogSource validates the config and constructs a ready source.
 func NewGraylogSource(name string, cfg config.AgentGraylogSourceConfig) (*GraylogSource, error) {
 	if cfg.Address == "" {
@@ -52,38 +79,160 @@ func NewGraylogSource(name string, cfg config.AgentGraylogSourceConfig) (*Graylo
 	tr := &http.Transport{
 		TLSClientConfig: &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify},
 	}
-	return &GraylogSource{
+	src := &GraylogSource{
 		name:   name,
 		cfg:    cfg,
 		client: &http.Client{Transport: tr, Timeout: 30 * time.Second},
-	}, nil
+	}
+	if cfg.ReorderWindow != "" {
+		if d, err := time.ParseDuration(cfg.ReorderWindow); err == nil && d > 0 {
+			src.reorderWindow = d
+		}
+	}
+	src.dedup = NewTailDedup(src.Name())
+	return src, nil
 }
 
 func (s *GraylogSource) Name() string { return "graylog:" + s.name }
 
-// Pull issues a `search/universal/absolute` request between (since, now)
-// and returns every message strictly newer than `since`. The cursor is
-// the max message timestamp seen this tick — when zero messages match,
-// the cursor is unchanged so the next tick re-asks for the same window.
+// SetTailReplaySpan widens what each tick re-reads so it also covers the
+// messages a killed process learned but never flushed. It implements
+// TailReplaySpanSetter.
+func (s *GraylogSource) SetTailReplaySpan(span time.Duration) (time.Duration, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if span > 0 {
+		s.replaySpan = span
+	}
+	return s.reorderWindow, s.scanWindow()
+}
+
+// scanWindow is how far below the cursor a tick re-reads. Callers hold s.mu.
+func (s *GraylogSource) scanWindow() time.Duration { return s.reorderWindow + s.replaySpan }
+
+// SetTailDedupBackend makes this source's boundary dedup set durable. It
+// implements TailDedupBinder.
+func (s *GraylogSource) SetTailDedupBackend(b TailDedupBackend) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dedup.SetBackend(b)
+}
+
+// Rewind clears the boundary dedup set so a catalog clear makes this source
+// re-emit its whole window. It implements core.SourceRewinder.
+func (s *GraylogSource) Rewind(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dedup.Clear(ctx)
+}
+
+// Commit makes the ids this source has delivered durable, after the worker has
+// flushed the messages they describe. It implements core.SourceCommitter.
+func (s *GraylogSource) Commit(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dedup.Commit(ctx)
+}
+
+func (s *GraylogSource) now() time.Time {
+	if s.nowFn != nil {
+		return s.nowFn().UTC()
+	}
+	return time.Now().UTC()
+}
+
+// Pull issues `search/universal/absolute` requests between (since, now) —
+// or [since - scanWindow, now] when a re-read span applies — and returns
+// every message not delivered before. A full page is followed by another
+// from the newest timestamp it held, so a span holding more than PageSize
+// messages is still walked to its end. The cursor is the max message
+// timestamp seen, never below `since` and never past `now`; when zero
+// messages match it is unchanged so the next tick re-asks for the same
+// window.
 func (s *GraylogSource) Pull(ctx context.Context, since time.Time) ([]core.Signal, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if since.After(now) {
+		since = now
+	}
 	cursor := since
-	from := since.UTC()
+	lower := since
+	window := s.scanWindow()
+	if window > 0 && !since.IsZero() {
+		lower = since.Add(-window)
+	}
+	from := lower.UTC()
 	if from.IsZero() {
 		// Cold start: pull the last 5 minutes so the first tick has
 		// something to look at instead of replaying the full retention.
-		from = time.Now().UTC().Add(-5 * time.Minute)
+		from = now.Add(-5 * time.Minute)
 	}
-	to := time.Now().UTC()
-	if !to.After(from) {
+	if !now.After(from) {
 		return nil, cursor, nil
 	}
 
+	if err := s.dedup.Load(ctx); err != nil {
+		log.Printf("agent: %s: loading the persisted dedup set failed: %v (this tick may re-emit up to one reorder window)", s.Name(), err)
+	}
+
+	var signals []core.Signal
+	var seen []DedupRow
+	tick := make(map[string]bool)
+	for page := 0; page < graylogMaxPagesPerTick; page++ {
+		out, err := s.search(ctx, from, now)
+		if err != nil {
+			return signals, ClampCursor(cursor, since, now), err
+		}
+		var pageMax time.Time
+		for _, m := range out.Messages {
+			ts := parseGraylogTimestamp(m.Message["timestamp"])
+			if ts.IsZero() {
+				continue
+			}
+			if ts.After(pageMax) {
+				pageMax = ts
+			}
+			if window == 0 && !ts.After(since) {
+				// Graylog `from` is inclusive; drop anything we already saw.
+				continue
+			}
+			id := graylogMessageID(m, ts)
+			if tick[id] {
+				continue
+			}
+			tick[id] = true
+			if ts.After(cursor) {
+				cursor = ts
+			}
+			seen = append(seen, DedupRow{ID: id, TS: ts})
+			if s.dedup.Has(id) {
+				continue
+			}
+			signals = append(signals, s.signalFromMessage(m, ts))
+		}
+		if len(out.Messages) < s.cfg.PageSize || !pageMax.After(from) {
+			break
+		}
+		from = pageMax
+	}
+	cursor = ClampCursor(cursor, since, now)
+
+	// Stage the ids in memory so the next tick does not re-deliver them; they
+	// become durable in Commit, after the worker has flushed the messages.
+	s.dedup.Stage(seen, lower)
+	return signals, cursor, nil
+}
+
+// search issues one `search/universal/absolute` request over [from, to].
+func (s *GraylogSource) search(ctx context.Context, from, to time.Time) (*graylogSearchResponse, error) {
 	q := url.Values{}
 	q.Set("query", s.cfg.Query)
 	// Graylog accepts RFC3339 with ms precision. Use UTC explicitly so
 	// we never accidentally send a non-Zulu offset.
-	q.Set("from", from.Format("2006-01-02T15:04:05.000Z"))
-	q.Set("to", to.Format("2006-01-02T15:04:05.000Z"))
+	q.Set("from", from.UTC().Format("2006-01-02T15:04:05.000Z"))
+	q.Set("to", to.UTC().Format("2006-01-02T15:04:05.000Z"))
 	q.Set("limit", strconv.Itoa(s.cfg.PageSize))
 	q.Set("sort", "timestamp:asc")
 	if s.cfg.StreamID != "" {
@@ -91,10 +240,16 @@ func (s *GraylogSource) Pull(ctx context.Context, since time.Time) ([]core.Signa
 	}
 	if len(s.cfg.Fields) > 0 {
 		// Graylog returns this comma-separated subset (plus the
-		// always-present "message" + "timestamp" fields).
+		// always-present "message" + "timestamp" fields). `_id` is
+		// always asked for: it is what the dedup set keys on.
 		fields := s.cfg.Fields[0]
+		hasID := fields == "_id"
 		for _, f := range s.cfg.Fields[1:] {
 			fields += "," + f
+			hasID = hasID || f == "_id"
+		}
+		if !hasID {
+			fields += ",_id"
 		}
 		q.Set("fields", fields)
 	}
@@ -102,7 +257,7 @@ func (s *GraylogSource) Pull(ctx context.Context, since time.Time) ([]core.Signa
 	u := s.cfg.Address + "/api/search/universal/absolute?" + q.Encode()
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
 	if err != nil {
-		return nil, cursor, err
+		return nil, err
 	}
 	req.Header.Set("Accept", "application/json")
 	// Graylog requires this header on every API call (CSRF defense).
@@ -111,53 +266,49 @@ func (s *GraylogSource) Pull(ctx context.Context, since time.Time) ([]core.Signa
 
 	resp, err := s.client.Do(req)
 	if err != nil {
-		return nil, cursor, err
+		return nil, err
 	}
 	defer resp.Body.Close()
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, cursor, err
+		return nil, err
 	}
 	if resp.StatusCode >= 400 {
-		return nil, cursor, fmt.Errorf("graylog %s: %d %s", u, resp.StatusCode, truncate(string(body), 256))
+		return nil, fmt.Errorf("graylog %s: %d %s", u, resp.StatusCode, truncate(string(body), 256))
 	}
 
 	var out graylogSearchResponse
 	if err := json.Unmarshal(body, &out); err != nil {
-		return nil, cursor, fmt.Errorf("decode graylog response: %w", err)
+		return nil, fmt.Errorf("decode graylog response: %w", err)
 	}
+	return &out, nil
+}
 
-	signals := make([]core.Signal, 0, len(out.Messages))
-	for _, m := range out.Messages {
-		ts := parseGraylogTimestamp(m.Message["timestamp"])
-		if ts.IsZero() || !ts.After(since) {
-			// Graylog `from` is inclusive; drop anything we already saw.
-			continue
-		}
-		if ts.After(cursor) {
-			cursor = ts
-		}
-
-		msgText := stringField(m.Message, s.cfg.MessageField)
-		sev := stringField(m.Message, s.cfg.SeverityField)
-
-		fields := make(map[string]interface{})
-		for _, f := range s.cfg.ExtraFields {
-			if v, ok := m.Message[f]; ok {
-				fields[f] = v
-			}
+func (s *GraylogSource) signalFromMessage(m graylogSearchHit, ts time.Time) core.Signal {
+	fields := make(map[string]interface{})
+	for _, f := range s.cfg.ExtraFields {
+		if v, ok := m.Message[f]; ok {
+			fields[f] = v
 		}
+	}
+	return core.Signal{
+		Source:    s.Name(),
+		Timestamp: ts,
+		Severity:  stringField(m.Message, s.cfg.SeverityField),
+		Message:   stringField(m.Message, s.cfg.MessageField),
+		Fields:    fields,
+		Raw:       m.Message,
+	}
+}
 
-		signals = append(signals, core.Signal{
-			Source:    s.Name(),
-			Timestamp: ts,
-			Severity:  sev,
-			Message:   msgText,
-			Fields:    fields,
-			Raw:       m.Message,
-		})
+// graylogMessageID is the dedup key for one message: Graylog's `_id`, unique
+// per message. The hashed fallback keeps dedup working when a `fields`
+// projection or an older server leaves it out.
+func graylogMessageID(m graylogSearchHit, ts time.Time) string {
+	if id, ok := m.Message["_id"].(string); ok && id != "" {
+		return id
 	}
-	return signals, cursor, nil
+	return HashedRowID(m.Index, ts.Format(time.RFC3339Nano), stringField(m.Message, "source"), stringField(m.Message, "message"))
 }
 
 // applyAuth wires up Graylog auth in priority order:
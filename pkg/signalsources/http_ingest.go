@@ -0,0 +1,365 @@
+package signalsources
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/VersusControl/versus-incident/pkg/core"
+	"golang.org/x/time/rate"
+)
+
+// HTTPIngestSource receives log records POSTed to the agent's HTTP server at
+// `/api/agent/ingest/<name>`, for senders that cannot be polled — batch jobs,
+// serverless functions. Like the OTLP receiver it is push-based: each request
+// is queued whole or refused whole, and each tick's Pull drains the queue, so
+// the records go through redaction, the regex filter, the miner and the
+// catalog exactly like polled ones.
+//
+// Behavior:
+//
+//   - A body is NDJSON (one JSON object per line) or one JSON array of
+//     objects. A record that is not a JSON object is skipped and counted;
+//     the rest of the request is still accepted.
+//   - A request over max_request_bytes, or carrying more records than
+//     rate_burst, is refused with 413. A request the rate limit or the full
+//     queue cannot take is refused with 429 and Retry-After, and the sender
+//     is expected to resend.
+//   - A record over the agent's signal_max_bytes is kept, cut down: its
+//     message is truncated and its fields and raw document are dropped
+//     except the service.
+//   - Every refusal is counted per reason (IngestStats), and
+//     `/api/agent/status` reports the counts.
+//   - The queue opens in Listen (core.SourceListener); until the worker has
+//     called it, requests are refused with 503.
+//   - With queue_dir set, a record stays on disk until the catalog flush that
+//     learned it (core.SourceCommitter).
+type HTTPIngestSource struct {
+	name           string
+	cfg            config.AgentHTTPSourceConfig
+	queue          *receiverQueue
+	decoder        lineDecoder
+	limiter        *rate.Limiter // nil ⇒ no rate limit
+	signalMaxBytes int           // 0 ⇒ no per-record cap
+
+	mu        sync.Mutex
+	listening bool
+
+	accepted    atomic.Int64
+	truncated   atomic.Int64
+	rateLimited atomic.Int64
+	queueFull   atomic.Int64
+	tooLarge    atomic.Int64
+	invalid     atomic.Int64
+}
+
+// Defaults applied when the corresponding option is empty / zero.
+const (
+	defaultHTTPIngestQueueSize      = 10000
+	defaultHTTPIngestMaxPullRecords = 1000
+	defaultHTTPIngestServiceField   = "service"
+)
+
+// HTTPIngestMaxRequestBytes is the ceiling of max_request_bytes: the HTTP
+// server's body limit, above which a request never reaches the source.
+const HTTPIngestMaxRequestBytes = 4 << 20
+
+// HTTPIngestRetryAfter is the back-off a refused sender is told to wait. The
+// queue drains once per tick, so retrying sooner mostly gets refused again.
+const HTTPIngestRetryAfter = 10 * time.Second
+
+// Errors Ingest refuses a request with. The ingest endpoint maps each to its
+// HTTP status.
+var (
+	ErrIngestNotReady    = errors.New("source is not listening yet")
+	ErrIngestTooLarge    = errors.New("request too large")
+	ErrIngestOverBurst   = errors.New("request carries more records than rate_burst")
+	ErrIngestRateLimited = errors.New("rate limit exceeded; retry later")
+	ErrIngestQueueFull   = errors.New("agent is behind; retry later")
+	ErrIngestMalformed   = errors.New("body is neither NDJSON nor a JSON array of objects")
+)
+
+// IngestResult is what one accepted request added.
+type IngestResult struct {
+	Accepted  int `json:"accepted"`
+	Invalid   int `json:"invalid"`
+	Truncated int `json:"truncated"`
+}
+
+// IngestStats are an HTTPIngestSource's counters since the process started.
+type IngestStats struct {
+	Accepted  int64         `json:"accepted"`
+	Truncated int64         `json:"truncated"`
+	Dropped   IngestDropped `json:"dropped"`
+}
+
+// IngestDropped counts what was not queued, by reason. TooLarge counts
+// requests, because an oversized body is refused unread; the others count
+// records.
+type IngestDropped struct {
+	RateLimited int64 `json:"rate_limited"`
+	QueueFull   int64 `json:"queue_full"`
+	TooLarge    int64 `json:"too_large"`
+	Invalid     int64 `json:"invalid"`
+}
+
+// NewHTTPIngestSource validates configuration. signalMaxBytes is the agent's
+// signal_max_bytes; 0 leaves records uncapped. It does not open the queue
+// file; Listen does.
+func NewHTTPIngestSource(name string, cfg config.AgentHTTPSourceConfig, signalMaxBytes int) (*HTTPIngestSource, error) {
+	if name == "" || strings.ContainsAny(name, "/?#% ") {
+		return nil, fmt.Errorf("http source %q: name must be non-empty and usable as a URL path segment", name)
+	}
+	if cfg.MaxRequestBytes < 0 || cfg.MaxRequestBytes > HTTPIngestMaxRequestBytes {
+		return nil, fmt.Errorf("http source %q: max_request_bytes must be between 0 and %d", name, HTTPIngestMaxRequestBytes)
+	}
+	if cfg.RateLimit < 0 || cfg.RateBurst < 0 {
+		return nil, fmt.Errorf("http source %q: rate_limit and rate_burst must not be negative", name)
+	}
+	if cfg.MaxRequestBytes == 0 {
+		cfg.MaxRequestBytes = HTTPIngestMaxRequestBytes
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaultHTTPIngestQueueSize
+	}
+	if cfg.MaxPullRecords <= 0 {
+		cfg.MaxPullRecords = defaultHTTPIngestMaxPullRecords
+	}
+	if cfg.ServiceField == "" {
+		cfg.ServiceField = defaultHTTPIngestServiceField
+	}
+	s := &HTTPIngestSource{
+		name:           name,
+		cfg:            cfg,
+		queue:          newReceiverQueue(cfg.QueueSize, cfg.QueueDir, "http", name),
+		signalMaxBytes: max(signalMaxBytes, 0),
+	}
+	s.decoder = newLineDecoder(s.Name(), "json", "", cfg.MessageField, cfg.TimestampField, cfg.SeverityField)
+	if cfg.RateLimit > 0 {
+		burst := cfg.RateBurst
+		if burst == 0 {
+			burst = int(math.Ceil(cfg.RateLimit))
+		}
+		s.limiter = rate.NewLimiter(rate.Limit(cfg.RateLimit), burst)
+	}
+	return s, nil
+}
+
+func (s *HTTPIngestSource) Name() string { return "http:" + s.name }
+
+// IngestName is the configured name, the `:source` of the ingest endpoint.
+func (s *HTTPIngestSource) IngestName() string { return s.name }
+
+// Listen opens the queue. It implements core.SourceListener; the HTTP route
+// itself is served by the agent's API server.
+func (s *HTTPIngestSource) Listen(_ context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listening {
+		return fmt.Errorf("http source %q: already listening", s.name)
+	}
+	if err := s.queue.open(); err != nil {
+		return fmt.Errorf("http source %q: open queue: %w", s.name, err)
+	}
+	s.listening = true
+	return nil
+}
+
+// Pull drains up to max_pull_records queued records, oldest first.
+func (s *HTTPIngestSource) Pull(_ context.Context, _ time.Time) ([]core.Signal, time.Time, error) {
+	cursor := time.Now().UTC()
+	sigs, err := s.queue.pop(s.cfg.MaxPullRecords, s.Name())
+	if err != nil {
+		return sigs, cursor, fmt.Errorf("http source %q: %w", s.name, err)
+	}
+	return sigs, cursor, nil
+}
+
+// Commit releases the records handed out by earlier Pulls from the disk queue.
+// It implements core.SourceCommitter; it is a no-op for the in-memory queue.
+func (s *HTTPIngestSource) Commit(_ context.Context) error {
+	if err := s.queue.commit(); err != nil {
+		return fmt.Errorf("http source %q: %w", s.name, err)
+	}
+	return nil
+}
+
+// HoldCommit keeps the next Commit from releasing the first held record or
+// any after it. It implements core.SourceCommitHolder.
+func (s *HTTPIngestSource) HoldCommit(held []core.Signal) {
+	s.queue.hold(held)
+}
+
+// Authorized checks an Authorization header value against auth_token. It is
+// false when no auth_token is set; the endpoint then takes the gateway secret
+// only.
+func (s *HTTPIngestSource) Authorized(header string) bool {
+	if s.cfg.AuthToken == "" {
+		return false
+	}
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.cfg.AuthToken)) == 1
+}
+
+// Stats returns the source's counters.
+func (s *HTTPIngestSource) Stats() IngestStats {
+	return IngestStats{
+		Accepted:  s.accepted.Load(),
+		Truncated: s.truncated.Load(),
+		Dropped: IngestDropped{
+			RateLimited: s.rateLimited.Load(),
+			QueueFull:   s.queueFull.Load(),
+			TooLarge:    s.tooLarge.Load(),
+			Invalid:     s.invalid.Load(),
+		},
+	}
+}
+
+// Ingest decodes one request body and queues its records. It returns one of
+// the ErrIngest* errors when the request is refused, or a queue error.
+func (s *HTTPIngestSource) Ingest(body []byte) (IngestResult, error) {
+	s.mu.Lock()
+	listening := s.listening
+	s.mu.Unlock()
+	if !listening {
+		return IngestResult{}, ErrIngestNotReady
+	}
+	if len(body) > s.cfg.MaxRequestBytes {
+		s.tooLarge.Add(1)
+		return IngestResult{}, ErrIngestTooLarge
+	}
+
+	records, invalid, err := decodeIngestBody(body)
+	if err != nil {
+		s.invalid.Add(1)
+		return IngestResult{}, err
+	}
+	s.invalid.Add(int64(invalid))
+	res := IngestResult{Invalid: invalid}
+	if len(records) == 0 {
+		if invalid > 0 {
+			return res, ErrIngestMalformed
+		}
+		return res, nil
+	}
+
+	if s.limiter != nil {
+		if len(records) > s.limiter.Burst() {
+			s.tooLarge.Add(1)
+			return res, ErrIngestOverBurst
+		}
+		if !s.limiter.AllowN(time.Now(), len(records)) {
+			s.rateLimited.Add(int64(len(records)))
+			return res, ErrIngestRateLimited
+		}
+	}
+
+	now := time.Now().UTC()
+	sigs := make([]core.Signal, 0, len(records))
+	for _, rec := range records {
+		sig := s.decoder.jsonObjectToSignal(rec.doc, string(rec.raw), now)
+		if svc, _ := rec.doc[s.cfg.ServiceField].(string); svc != "" {
+			sig.Fields[core.FieldService] = svc
+		}
+		if s.signalMaxBytes > 0 && len(rec.raw) > s.signalMaxBytes {
+			sig = capIngestSignal(sig, len(rec.raw), s.signalMaxBytes)
+			res.Truncated++
+		}
+		sigs = append(sigs, sig)
+	}
+	if err := s.queue.push(sigs); err != nil {
+		if errors.Is(err, errReceiverQueueFull) {
+			s.queueFull.Add(int64(len(sigs)))
+			return res, ErrIngestQueueFull
+		}
+		return res, fmt.Errorf("http source %q: queue: %w", s.name, err)
+	}
+	res.Accepted = len(sigs)
+	s.accepted.Add(int64(res.Accepted))
+	s.truncated.Add(int64(res.Truncated))
+	return res, nil
+}
+
+// ingestRecord is one decoded record and its encoded form.
+type ingestRecord struct {
+	doc map[string]interface{}
+	raw []byte
+}
+
+// decodeIngestBody splits a body into its records. A body starting with `[`
+// is one JSON array and must parse as a whole; anything else is NDJSON, read
+// line by line. invalid counts elements or lines that are not JSON objects.
+func decodeIngestBody(body []byte) (records []ingestRecord, invalid int, err error) {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return nil, 0, nil
+	}
+	if trimmed[0] == '[' {
+		var elems []json.RawMessage
+		if err := json.Unmarshal(trimmed, &elems); err != nil {
+			return nil, 0, ErrIngestMalformed
+		}
+		for _, e := range elems {
+			if rec, ok := decodeIngestRecord(e); ok {
+				records = append(records, rec)
+			} else {
+				invalid++
+			}
+		}
+		return records, invalid, nil
+	}
+	for _, line := range bytes.Split(trimmed, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		if rec, ok := decodeIngestRecord(line); ok {
+			records = append(records, rec)
+		} else {
+			invalid++
+		}
+	}
+	return records, invalid, nil
+}
+
+func decodeIngestRecord(raw []byte) (ingestRecord, bool) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil || doc == nil {
+		return ingestRecord{}, false
+	}
+	return ingestRecord{doc: doc, raw: raw}, true
+}
+
+// capIngestSignal cuts a record larger than signal_max_bytes down to it: the
+// message is truncated to the cap, and Fields and Raw, which hold the whole
+// record, keep only the service and the record's original size.
+func capIngestSignal(sig core.Signal, size, limit int) core.Signal {
+	if len(sig.Message) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(sig.Message[cut]) {
+			cut--
+		}
+		sig.Message = sig.Message[:cut] + "…[truncated]"
+	}
+	fields := map[string]interface{}{FieldIngestTruncatedBytes: size}
+	if svc, ok := sig.Fields[core.FieldService]; ok {
+		fields[core.FieldService] = svc
+	}
+	sig.Fields = fields
+	sig.Raw = nil
+	return sig
+}
+
+// FieldIngestTruncatedBytes is the Signal.Fields key, set only on a record
+// cut down to signal_max_bytes, holding the record's original size in bytes.
+const FieldIngestTruncatedBytes = "ingest.truncated_bytes"
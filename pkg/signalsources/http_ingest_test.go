@@ -0,0 +1,199 @@
+package signalsources
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/VersusControl/versus-incident/pkg/core"
+)
+
+func newListeningIngest(t *testing.T, cfg config.AgentHTTPSourceConfig, signalMaxBytes int) *HTTPIngestSource {
+	t.Helper()
+	src, err := NewHTTPIngestSource("jobs", cfg, signalMaxBytes)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	if err := src.Listen(context.Background()); err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	return src
+}
+
+func TestHTTPIngest_NDJSONAndArray(t *testing.T) {
+	src := newListeningIngest(t, config.AgentHTTPSourceConfig{}, 0)
+
+	res, err := src.Ingest([]byte(`{"message":"backup failed","level":"error","service":"nightly","@timestamp":"2026-10-19T02:00:00Z"}` + "\n\n" +
+		"not json\n" +
+		`{"message":"backup done"}` + "\n"))
+	if err != nil {
+		t.Fatalf("ndjson: %v", err)
+	}
+	if res != (IngestResult{Accepted: 2, Invalid: 1}) {
+		t.Fatalf("ndjson result = %+v", res)
+	}
+	if _, err := src.Ingest([]byte(`[{"message":"one"}, 7, {"message":"two"}]`)); err != nil {
+		t.Fatalf("array: %v", err)
+	}
+
+	sigs, _, err := src.Pull(context.Background(), time.Time{})
+	if err != nil {
+		t.Fatalf("pull: %v", err)
+	}
+	if got := messages(sigs); !reflect.DeepEqual(got, []string{"backup failed", "backup done", "one", "two"}) {
+		t.Fatalf("messages = %v", got)
+	}
+	first := sigs[0]
+	if first.Source != "http:jobs" || first.Severity != "error" || first.Fields[core.FieldService] != "nightly" {
+		t.Fatalf("first = %+v", first)
+	}
+	if !first.Timestamp.Equal(time.Date(2026, 10, 19, 2, 0, 0, 0, time.UTC)) {
+		t.Fatalf("timestamp = %v", first.Timestamp)
+	}
+
+	if _, err := src.Ingest([]byte(`[{"message":`)); !errors.Is(err, ErrIngestMalformed) {
+		t.Fatalf("broken array: err = %v", err)
+	}
+	if _, err := src.Ingest([]byte("plain text\n")); !errors.Is(err, ErrIngestMalformed) {
+		t.Fatalf("no records: err = %v", err)
+	}
+	if got := src.Stats(); got.Accepted != 4 || got.Dropped.Invalid != 4 {
+		t.Fatalf("stats = %+v", got)
+	}
+}
+
+func TestHTTPIngest_Limits(t *testing.T) {
+	src := newListeningIngest(t, config.AgentHTTPSourceConfig{MaxRequestBytes: 64, RateLimit: 2, QueueSize: 3}, 0)
+
+	if _, err := src.Ingest([]byte(strings.Repeat("x", 65))); !errors.Is(err, ErrIngestTooLarge) {
+		t.Fatalf("oversized body: err = %v", err)
+	}
+	if _, err := src.Ingest([]byte(`{"message":"a"}` + "\n" + `{"message":"b"}` + "\n" + `{"message":"c"}`)); !errors.Is(err, ErrIngestOverBurst) {
+		t.Fatalf("over burst: err = %v", err)
+	}
+	if _, err := src.Ingest([]byte(`[{"message":"a"},{"message":"b"}]`)); err != nil {
+		t.Fatalf("within burst: %v", err)
+	}
+	if _, err := src.Ingest([]byte(`[{"message":"c"},{"message":"d"}]`)); !errors.Is(err, ErrIngestRateLimited) {
+		t.Fatalf("burst spent: err = %v", err)
+	}
+
+	want := IngestDropped{RateLimited: 2, TooLarge: 2}
+	if got := src.Stats().Dropped; got != want {
+		t.Fatalf("dropped = %+v, want %+v", got, want)
+	}
+
+	full := newListeningIngest(t, config.AgentHTTPSourceConfig{QueueSize: 1}, 0)
+	if _, err := full.Ingest([]byte(`[{"message":"a"},{"message":"b"}]`)); !errors.Is(err, ErrIngestQueueFull) {
+		t.Fatalf("queue full: err = %v", err)
+	}
+	if got := full.Stats().Dropped.QueueFull; got != 2 {
+		t.Fatalf("queue_full = %d", got)
+	}
+}
+
+// TestHTTPIngest_CapsToSignalMaxBytes checks a record over signal_max_bytes
+// is kept, with its message truncated and only the service left in its
+// fields, so one oversized document cannot blow up the pipeline.
+func TestHTTPIngest_CapsToSignalMaxBytes(t *testing.T) {
+	src := newListeningIngest(t, config.AgentHTTPSourceConfig{}, 32)
+	big := `{"message":"` + strings.Repeat("é", 40) + `","service":"etl","blob":"` + strings.Repeat("z", 100) + `"}`
+	res, err := src.Ingest([]byte(big + "\n" + `{"message":"small"}`))
+	if err != nil {
+		t.Fatalf("ingest: %v", err)
+	}
+	if res.Truncated != 1 || res.Accepted != 2 {
+		t.Fatalf("result = %+v", res)
+	}
+	sigs, _, _ := src.Pull(context.Background(), time.Time{})
+	capped := sigs[0]
+	if !strings.HasSuffix(capped.Message, "…[truncated]") || len(capped.Message) > 32+len("…[truncated]") {
+		t.Fatalf("message = %q", capped.Message)
+	}
+	if !strings.HasPrefix(capped.Message, strings.Repeat("é", 16)) {
+		t.Fatalf("message cut inside a rune: %q", capped.Message)
+	}
+	want := map[string]any{core.FieldService: "etl", FieldIngestTruncatedBytes: len(big)}
+	if !reflect.DeepEqual(capped.Fields, want) || capped.Raw != nil {
+		t.Fatalf("fields = %v, raw = %v", capped.Fields, capped.Raw)
+	}
+	if sigs[1].Message != "small" || sigs[1].Raw == nil {
+		t.Fatalf("small record changed: %+v", sigs[1])
+	}
+}
+
+func TestHTTPIngest_AuthAndReadiness(t *testing.T) {
+	src, err := NewHTTPIngestSource("jobs", config.AgentHTTPSourceConfig{AuthToken: "s3cret"}, 0)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	if _, err := src.Ingest([]byte(`{"message":"early"}`)); !errors.Is(err, ErrIngestNotReady) {
+		t.Fatalf("before listen: err = %v", err)
+	}
+	if !src.Authorized("Bearer s3cret") || src.Authorized("Bearer nope") || src.Authorized("s3cret") {
+		t.Fatal("bearer check wrong")
+	}
+	open, _ := NewHTTPIngestSource("open", config.AgentHTTPSourceConfig{}, 0)
+	if open.Authorized("Bearer ") {
+		t.Fatal("a source without auth_token accepted an empty bearer")
+	}
+
+	for _, tc := range []struct {
+		name string
+		cfg  config.AgentHTTPSourceConfig
+	}{
+		{"a/b", config.AgentHTTPSourceConfig{}},
+		{"", config.AgentHTTPSourceConfig{}},
+		{"x", config.AgentHTTPSourceConfig{MaxRequestBytes: HTTPIngestMaxRequestBytes + 1}},
+		{"x", config.AgentHTTPSourceConfig{RateLimit: -1}},
+	} {
+		if _, err := NewHTTPIngestSource(tc.name, tc.cfg, 0); err == nil {
+			t.Errorf("%q %+v: want error", tc.name, tc.cfg)
+		}
+	}
+}
+
+// TestHTTPIngest_HoldCommitKeepsDiskQueueRecords checks a commit stops at the
+// first record a stage still holds, across a compaction of the queue file, so
+// a restart hands that record and the ones after it over again.
+func TestHTTPIngest_HoldCommitKeepsDiskQueueRecords(t *testing.T) {
+	cfg := config.AgentHTTPSourceConfig{QueueDir: t.TempDir()}
+	src := newListeningIngest(t, cfg, 0)
+	ctx := context.Background()
+	if _, err := src.Ingest([]byte(`[{"message":"a"},{"message":"b"}]`)); err != nil {
+		t.Fatalf("ingest: %v", err)
+	}
+	sigs, _, _ := src.Pull(ctx, time.Time{})
+	src.Commit(ctx) // both learned: the file is emptied
+
+	if _, err := src.Ingest([]byte(`[{"message":"c"},{"message":"d"},{"message":"e"}]`)); err != nil {
+		t.Fatalf("ingest: %v", err)
+	}
+	sigs, _, _ = src.Pull(ctx, time.Time{})
+	if got := messages(sigs); !reflect.DeepEqual(got, []string{"c", "d", "e"}) {
+		t.Fatalf("pull = %v", got)
+	}
+	src.HoldCommit(sigs[1:2])
+	if err := src.Commit(ctx); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	restarted := newListeningIngest(t, cfg, 0)
+	again, _, _ := restarted.Pull(ctx, time.Time{})
+	if got := messages(again); !reflect.DeepEqual(got, []string{"d", "e"}) {
+		t.Fatalf("after restart = %v, want the held record and the one after it", got)
+	}
+
+	src.HoldCommit(nil)
+	if err := src.Commit(ctx); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	restarted = newListeningIngest(t, cfg, 0)
+	if again, _, _ := restarted.Pull(ctx, time.Time{}); len(again) != 0 {
+		t.Fatalf("after releasing the hold = %v", messages(again))
+	}
+}
@@ -0,0 +1,449 @@
+package signalsources
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+	"github.com/twmb/franz-go/pkg/sasl/plain"
+	"github.com/twmb/franz-go/pkg/sasl/scram"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/VersusControl/versus-incident/pkg/core"
+)
+
+// KafkaSource consumes a Kafka topic as a member of a consumer group. Each
+// record value is one log line, decoded like a file source line (lineDecoder).
+//
+// Behavior:
+//
+//   - Nothing connects until Listen (core.SourceListener), which joins the
+//     group. A source built for another purpose — the analyze-mode log reader
+//     builds its own copy — never joins, so it cannot steal partitions from
+//     the worker's member.
+//   - Auto-commit is off. Pull only stages the next offset of each partition
+//     it handed over; Commit writes them to the group after the catalog flush
+//     that learned the records (core.SourceCommitter). A crash in between
+//     re-delivers those records from the last commit: bounded duplicates,
+//     never a skipped record.
+//   - HoldCommit (core.SourceCommitHolder) keeps a partition's commit at the
+//     first record a pipeline stage still holds, so a restart re-reads a
+//     stack trace that was being assembled.
+//   - Partitions revoked in a rebalance drop their staged offsets, so this
+//     member never commits over the new owner.
+//   - Rewind (core.SourceRewinder) moves the group to the first offsets at or
+//     after now - rewind_lookback.
+//   - The Pull `since` argument is ignored; the group's offsets are the
+//     position.
+type KafkaSource struct {
+	name     string
+	cfg      config.AgentKafkaSourceConfig
+	decoder  lineDecoder
+	lookback time.Duration
+
+	// mu serialises Pull, Commit and Rewind; the client is set once by Listen.
+	mu      sync.Mutex
+	client  *kgo.Client
+	stopped context.Context // Listen's ctx; done once the worker stops
+	closed  bool
+
+	// pendMu guards pending on its own, because the rebalance callbacks run on
+	// the client's goroutines, possibly while Commit holds mu.
+	pendMu  sync.Mutex
+	pending map[string]map[int32]kgo.EpochOffset
+	// held is the offset of the first record a stage still holds, per
+	// partition; Commit does not go past it. Set by HoldCommit.
+	held map[string]map[int32]int64
+}
+
+// Defaults applied when the corresponding option is empty / zero.
+const (
+	defaultKafkaClientID       = "versus-agent"
+	defaultKafkaRewindLookback = time.Hour
+	defaultKafkaMaxPullRecords = 1000
+)
+
+// kafkaPollWait bounds how long a Pull waits for records when none are
+// buffered, so an idle topic does not hold up the tick.
+const kafkaPollWait = 500 * time.Millisecond
+
+// NewKafkaSource validates configuration. It does not connect, read the TLS
+// files or join the group; Listen does.
+func NewKafkaSource(name string, cfg config.AgentKafkaSourceConfig) (*KafkaSource, error) {
+	if len(cfg.Brokers) == 0 {
+		return nil, fmt.Errorf("kafka source %q: brokers is required", name)
+	}
+	if cfg.Topic == "" {
+		return nil, fmt.Errorf("kafka source %q: topic is required", name)
+	}
+	if cfg.GroupID == "" {
+		return nil, fmt.Errorf("kafka source %q: group_id is required", name)
+	}
+	switch cfg.StartOffset {
+	case "", "latest", "earliest":
+	default:
+		return nil, fmt.Errorf("kafka source %q: unknown start_offset %q (want \"latest\" or \"earliest\")", name, cfg.StartOffset)
+	}
+	switch cfg.Format {
+	case "", "text", "json", "logfmt":
+	default:
+		return nil, fmt.Errorf("kafka source %q: unknown format %q (want \"text\", \"json\" or \"logfmt\")", name, cfg.Format)
+	}
+	switch cfg.SASL.Mechanism {
+	case "":
+	case "plain", "scram-sha-256", "scram-sha-512":
+		if cfg.SASL.Username == "" {
+			return nil, fmt.Errorf("kafka source %q: sasl.username is required with sasl.mechanism", name)
+		}
+	default:
+		return nil, fmt.Errorf("kafka source %q: unknown sasl.mechanism %q (want \"plain\", \"scram-sha-256\" or \"scram-sha-512\")", name, cfg.SASL.Mechanism)
+	}
+	if (cfg.TLS.CertFile == "") != (cfg.TLS.KeyFile == "") {
+		return nil, fmt.Errorf("kafka source %q: tls.cert_file and tls.key_file go together", name)
+	}
+	if cfg.MaxPullRecords <= 0 {
+		cfg.MaxPullRecords = defaultKafkaMaxPullRecords
+	}
+	if cfg.ClientID == "" {
+		cfg.ClientID = defaultKafkaClientID
+	}
+
+	lookback := defaultKafkaRewindLookback
+	if cfg.RewindLookback != "" {
+		d, err := time.ParseDuration(cfg.RewindLookback)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("kafka source %q: invalid rewind_lookback %q (want a positive duration, e.g. \"1h\")", name, cfg.RewindLookback)
+		}
+		lookback = d
+	}
+
+	s := &KafkaSource{
+		name:     name,
+		cfg:      cfg,
+		lookback: lookback,
+		pending:  map[string]map[int32]kgo.EpochOffset{},
+	}
+	s.decoder = newLineDecoder(s.Name(), cfg.Format, cfg.TimestampLayout, cfg.MessageField, cfg.TimestampField, cfg.SeverityField)
+	return s, nil
+}
+
+func (s *KafkaSource) Name() string { return "kafka:" + s.name }
+
+// Listen creates the client and joins the consumer group. It implements
+// core.SourceListener. Brokers that are down are not an error: the client
+// keeps retrying in the background and Pull returns nothing meanwhile.
+//
+// The client outlives ctx on purpose. The worker's shutdown commit runs after
+// ctx is done, and it must still reach the group; that commit then closes the
+// client, which leaves the group so the partitions move without waiting out
+// the session timeout.
+func (s *KafkaSource) Listen(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.client != nil {
+		return fmt.Errorf("kafka source %q: already listening", s.name)
+	}
+	opts, err := s.clientOpts()
+	if err != nil {
+		return fmt.Errorf("kafka source %q: %w", s.name, err)
+	}
+	cl, err := kgo.NewClient(opts...)
+	if err != nil {
+		return fmt.Errorf("kafka source %q: %w", s.name, err)
+	}
+	s.client, s.stopped = cl, ctx
+	log.Printf("kafka source %s: consuming topic %s as group %s", s.name, s.cfg.Topic, s.cfg.GroupID)
+	return nil
+}
+
+func (s *KafkaSource) clientOpts() ([]kgo.Opt, error) {
+	start := kgo.NewOffset().AtEnd()
+	if s.cfg.StartOffset == "earliest" {
+		start = kgo.NewOffset().AtStart()
+	}
+	opts := []kgo.Opt{
+		kgo.SeedBrokers(s.cfg.Brokers...),
+		kgo.ClientID(s.cfg.ClientID),
+		kgo.ConsumerGroup(s.cfg.GroupID),
+		kgo.ConsumeTopics(s.cfg.Topic),
+		kgo.ConsumeResetOffset(start),
+		kgo.DisableAutoCommit(),
+		kgo.OnPartitionsRevoked(s.dropPending),
+		kgo.OnPartitionsLost(s.dropPending),
+	}
+	if s.cfg.TLS.Enable {
+		tlsCfg, err := s.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, kgo.DialTLSConfig(tlsCfg))
+	}
+	switch s.cfg.SASL.Mechanism {
+	case "plain":
+		opts = append(opts, kgo.SASL(plain.Auth{User: s.cfg.SASL.Username, Pass: s.cfg.SASL.Password}.AsMechanism()))
+	case "scram-sha-256":
+		opts = append(opts, kgo.SASL(scram.Auth{User: s.cfg.SASL.Username, Pass: s.cfg.SASL.Password}.AsSha256Mechanism()))
+	case "scram-sha-512":
+		opts = append(opts, kgo.SASL(scram.Auth{User: s.cfg.SASL.Username, Pass: s.cfg.SASL.Password}.AsSha512Mechanism()))
+	}
+	return opts, nil
+}
+
+func (s *KafkaSource) tlsConfig() (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: s.cfg.TLS.InsecureSkipVerify}
+	if s.cfg.TLS.CAFile != "" {
+		pem, err := os.ReadFile(s.cfg.TLS.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read tls.ca_file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("tls.ca_file %s holds no PEM certificate", s.cfg.TLS.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+	if s.cfg.TLS.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(s.cfg.TLS.CertFile, s.cfg.TLS.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load tls.cert_file/tls.key_file: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// Pull takes up to max_pull_records buffered records, waiting at most
+// kafkaPollWait when none are buffered, and stages the offset after each
+// partition's last record for Commit.
+func (s *KafkaSource) Pull(ctx context.Context, _ time.Time) ([]core.Signal, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cursor := time.Now().UTC()
+	if s.client == nil || s.closed {
+		return nil, cursor, nil
+	}
+
+	pollCtx, cancel := context.WithTimeout(ctx, kafkaPollWait)
+	fetches := s.client.PollRecords(pollCtx, s.cfg.MaxPullRecords)
+	cancel()
+
+	var fetchErr error
+	fetches.EachError(func(topic string, partition int32, err error) {
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			return
+		}
+		if fetchErr == nil {
+			fetchErr = fmt.Errorf("kafka source %q: fetch %s[%d]: %w", s.name, topic, partition, err)
+		}
+	})
+
+	var signals []core.Signal
+	s.pendMu.Lock()
+	fetches.EachRecord(func(r *kgo.Record) {
+		signals = append(signals, s.recordToSignal(r))
+		ps := s.pending[r.Topic]
+		if ps == nil {
+			ps = map[int32]kgo.EpochOffset{}
+			s.pending[r.Topic] = ps
+		}
+		ps[r.Partition] = kgo.EpochOffset{Epoch: r.LeaderEpoch, Offset: r.Offset + 1}
+	})
+	s.pendMu.Unlock()
+	return signals, cursor, fetchErr
+}
+
+// recordToSignal decodes the record value and adds where it came from. A
+// line without its own timestamp takes the record's.
+func (s *KafkaSource) recordToSignal(r *kgo.Record) core.Signal {
+	sig := s.decoder.decode(strings.TrimRight(string(r.Value), "\r\n"), r.Timestamp.UTC())
+	fields := make(map[string]any, len(sig.Fields)+4)
+	for k, v := range sig.Fields {
+		fields[k] = v
+	}
+	fields["kafka.topic"] = r.Topic
+	fields["kafka.partition"] = int64(r.Partition)
+	fields["kafka.offset"] = r.Offset
+	if len(r.Key) > 0 {
+		fields["kafka.key"] = string(r.Key)
+	}
+	sig.Fields = fields
+	return sig
+}
+
+// Commit writes the staged offsets to the group. It implements
+// core.SourceCommitter and runs only after the catalog flush that learned the
+// records. Offsets that fail to commit stay staged for the next Commit. After
+// the worker has stopped it is the last call, so it also closes the client.
+func (s *KafkaSource) Commit(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.client == nil || s.closed {
+		return nil
+	}
+	err := s.commitPending(ctx)
+	if s.stopped.Err() != nil {
+		s.client.Close()
+		s.closed = true
+	}
+	return err
+}
+
+// HoldCommit records, per partition, the first of the held records, so the
+// next Commit stops there. It implements core.SourceCommitHolder.
+func (s *KafkaSource) HoldCommit(held []core.Signal) {
+	hold := map[string]map[int32]int64{}
+	for _, sig := range held {
+		topic, ok1 := sig.Fields["kafka.topic"].(string)
+		partition, ok2 := sig.Fields["kafka.partition"].(int64)
+		offset, ok3 := sig.Fields["kafka.offset"].(int64)
+		if !ok1 || !ok2 || !ok3 {
+			continue
+		}
+		if hold[topic] == nil {
+			hold[topic] = map[int32]int64{}
+		}
+		p := int32(partition)
+		if cur, ok := hold[topic][p]; !ok || offset < cur {
+			hold[topic][p] = offset
+		}
+	}
+	s.pendMu.Lock()
+	s.held = hold
+	s.pendMu.Unlock()
+}
+
+func (s *KafkaSource) commitPending(ctx context.Context) error {
+	s.pendMu.Lock()
+	staged := make(map[string]map[int32]kgo.EpochOffset, len(s.pending))
+	for t, ps := range s.pending {
+		staged[t] = make(map[int32]kgo.EpochOffset, len(ps))
+		for p, o := range ps {
+			// A held record is read again after a restart. Its leader
+			// epoch is not known here, so the commit carries none.
+			if h, ok := s.held[t][p]; ok && h < o.Offset {
+				o = kgo.EpochOffset{Epoch: -1, Offset: h}
+			}
+			staged[t][p] = o
+		}
+	}
+	s.pendMu.Unlock()
+	if len(staged) == 0 {
+		return nil
+	}
+
+	if err := s.commitOffsets(ctx, staged); err != nil {
+		return err
+	}
+
+	// Unstage what was committed, unless a Pull or a revoke changed it since.
+	// A partition committed short of its staged offset stays staged.
+	s.pendMu.Lock()
+	defer s.pendMu.Unlock()
+	for t, ps := range staged {
+		for p, o := range ps {
+			if cur, ok := s.pending[t][p]; ok && cur == o {
+				delete(s.pending[t], p)
+			}
+		}
+		if len(s.pending[t]) == 0 {
+			delete(s.pending, t)
+		}
+	}
+	return nil
+}
+
+// commitOffsets commits offsets as this group member and reports the first
+// request or partition error.
+func (s *KafkaSource) commitOffsets(ctx context.Context, offsets map[string]map[int32]kgo.EpochOffset) error {
+	var commitErr error
+	s.client.CommitOffsetsSync(ctx, offsets, func(_ *kgo.Client, _ *kmsg.OffsetCommitRequest, resp *kmsg.OffsetCommitResponse, err error) {
+		if err != nil {
+			commitErr = err
+			return
+		}
+		for _, t := range resp.Topics {
+			for _, p := range t.Partitions {
+				if err := kerr.ErrorForCode(p.ErrorCode); err != nil && commitErr == nil {
+					commitErr = fmt.Errorf("%s[%d]: %w", t.Topic, p.Partition, err)
+				}
+			}
+		}
+	})
+	if commitErr != nil {
+		return fmt.Errorf("kafka source %q: commit offsets: %w", s.name, commitErr)
+	}
+	return nil
+}
+
+// dropPending forgets the staged offsets of partitions this member no longer
+// owns. Their records are redelivered to the new owner from the last commit.
+func (s *KafkaSource) dropPending(_ context.Context, _ *kgo.Client, lost map[string][]int32) {
+	s.pendMu.Lock()
+	defer s.pendMu.Unlock()
+	for t, ps := range lost {
+		for _, p := range ps {
+			delete(s.pending[t], p)
+		}
+		if len(s.pending[t]) == 0 {
+			delete(s.pending, t)
+		}
+	}
+}
+
+// Rewind moves the group to the first offset at or after now -
+// rewind_lookback on every partition of the topic, so a catalog clear
+// re-learns that window. It implements core.SourceRewinder.
+//
+// The offsets are committed for the whole topic, which is what a restart or a
+// rebalance resumes from, and this member's own partitions are repositioned
+// in place. Staged offsets are dropped: committing them later would undo the
+// rewind. Before Listen there is no group membership to rewind.
+func (s *KafkaSource) Rewind(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.client == nil || s.closed {
+		return nil
+	}
+	at := time.Now().Add(-s.lookback).UnixMilli()
+	listed, err := kadm.NewClient(s.client).ListOffsetsAfterMilli(ctx, at, s.cfg.Topic)
+	if err == nil {
+		err = listed.Error()
+	}
+	if err != nil {
+		return fmt.Errorf("kafka source %q: list offsets at -%s: %w", s.name, s.lookback, err)
+	}
+	target := map[string]map[int32]kgo.EpochOffset{}
+	listed.Each(func(o kadm.ListedOffset) {
+		if o.Offset < 0 {
+			return
+		}
+		if target[o.Topic] == nil {
+			target[o.Topic] = map[int32]kgo.EpochOffset{}
+		}
+		target[o.Topic][o.Partition] = kgo.EpochOffset{Epoch: o.LeaderEpoch, Offset: o.Offset}
+	})
+
+	s.pendMu.Lock()
+	s.pending = map[string]map[int32]kgo.EpochOffset{}
+	s.pendMu.Unlock()
+
+	if err := s.commitOffsets(ctx, target); err != nil {
+		return err
+	}
+	s.client.SetOffsets(target)
+	return nil
+}
@@ -0,0 +1,199 @@
+package signalsources
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/VersusControl/versus-incident/pkg/core"
+)
+
+// newKafkaTestCluster starts an in-process broker with topic "logs" and
+// returns its addresses and a producer for it.
+func newKafkaTestCluster(t *testing.T) ([]string, func(ts time.Time, values ...string)) {
+	t.Helper()
+	c, err := kfake.NewCluster(kfake.NumBrokers(1), kfake.SeedTopics(1, "logs"))
+	if err != nil {
+		t.Fatalf("kfake: %v", err)
+	}
+	t.Cleanup(c.Close)
+	addrs := c.ListenAddrs()
+	producer, err := kgo.NewClient(kgo.SeedBrokers(addrs...))
+	if err != nil {
+		t.Fatalf("producer: %v", err)
+	}
+	t.Cleanup(producer.Close)
+	produce := func(ts time.Time, values ...string) {
+		t.Helper()
+		for _, v := range values {
+			if err := producer.ProduceSync(context.Background(), &kgo.Record{Topic: "logs", Key: []byte("orders"), Value: []byte(v), Timestamp: ts}).FirstErr(); err != nil {
+				t.Fatalf("produce: %v", err)
+			}
+		}
+	}
+	return addrs, produce
+}
+
+func startKafkaSource(t *testing.T, cfg config.AgentKafkaSourceConfig) *KafkaSource {
+	t.Helper()
+	src, err := NewKafkaSource("logs", cfg)
+	if err != nil {
+		t.Fatalf("NewKafkaSource: %v", err)
+	}
+	if err := src.Listen(context.Background()); err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	t.Cleanup(func() { src.client.Close() })
+	return src
+}
+
+// pullNothing checks that src delivers nothing over a few polls.
+func pullNothing(t *testing.T, src core.SignalSource) {
+	t.Helper()
+	for i := 0; i < 3; i++ {
+		sigs, _, err := src.Pull(context.Background(), time.Time{})
+		if err != nil || len(sigs) != 0 {
+			t.Fatalf("Pull = %d signals, %v; want none", len(sigs), err)
+		}
+	}
+}
+
+// TestKafkaSource_CommitsOnlyAfterCommit checks the no-loss contract: records
+// pulled but never committed come back to the next member of the group, and
+// committed ones do not.
+func TestKafkaSource_CommitsOnlyAfterCommit(t *testing.T) {
+	brokers, produce := newKafkaTestCluster(t)
+	cfg := config.AgentKafkaSourceConfig{Brokers: brokers, Topic: "logs", GroupID: "versus", StartOffset: "earliest", Format: "json"}
+	produce(time.Now(), `{"message":"db timeout","level":"error","service":"orders"}`, `{"message":"retrying","level":"warn"}`)
+
+	first := startKafkaSource(t, cfg)
+	got := pullUntil(t, first, 2)
+	if got[0].Source != "kafka:logs" || got[0].Message != "db timeout" || got[0].Severity != "error" ||
+		got[0].Fields[core.FieldService] != "orders" || got[0].Fields["kafka.topic"] != "logs" ||
+		got[0].Fields["kafka.offset"] != int64(0) || got[0].Fields["kafka.key"] != "orders" {
+		t.Fatalf("signal = %+v", got[0])
+	}
+	// Crash before the catalog flush: nothing was committed.
+	first.client.Close()
+
+	second := startKafkaSource(t, cfg)
+	if got := pullUntil(t, second, 2); got[1].Message != "retrying" {
+		t.Fatalf("redelivered = %+v", got)
+	}
+	if err := second.Commit(context.Background()); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	second.client.Close()
+
+	third := startKafkaSource(t, cfg)
+	produce(time.Now(), `{"message":"recovered","level":"info"}`)
+	if got := pullUntil(t, third, 1); got[0].Message != "recovered" {
+		t.Fatalf("after commit = %+v", got)
+	}
+	pullNothing(t, third)
+}
+
+// TestKafkaSource_TextRecordsTakeRecordTime checks text mode and that a line
+// without a leading timestamp gets the record's.
+func TestKafkaSource_TextRecordsTakeRecordTime(t *testing.T) {
+	brokers, produce := newKafkaTestCluster(t)
+	at := time.Date(2025, 1, 3, 10, 0, 0, 0, time.UTC)
+	produce(at, "connection refused\n", "2025-01-03T09:00:00Z disk full")
+
+	src := startKafkaSource(t, config.AgentKafkaSourceConfig{Brokers: brokers, Topic: "logs", GroupID: "versus", StartOffset: "earliest"})
+	got := pullUntil(t, src, 2)
+	if got[0].Message != "connection refused" || !got[0].Timestamp.Equal(at) {
+		t.Fatalf("first = %+v", got[0])
+	}
+	if got[1].Message != "disk full" || !got[1].Timestamp.Equal(at.Add(-time.Hour)) {
+		t.Fatalf("second = %+v", got[1])
+	}
+}
+
+// TestKafkaSource_Rewind checks Rewind moves the group back to the lookback
+// window, and that staged offsets do not undo it.
+func TestKafkaSource_Rewind(t *testing.T) {
+	brokers, produce := newKafkaTestCluster(t)
+	now := time.Now()
+	produce(now.Add(-3*time.Hour), "old")
+	produce(now.Add(-30*time.Minute), "recent 1", "recent 2")
+
+	cfg := config.AgentKafkaSourceConfig{Brokers: brokers, Topic: "logs", GroupID: "versus", StartOffset: "earliest", RewindLookback: "1h"}
+	src := startKafkaSource(t, cfg)
+	pullUntil(t, src, 3)
+	if err := src.Rewind(context.Background()); err != nil {
+		t.Fatalf("Rewind: %v", err)
+	}
+	if err := src.Commit(context.Background()); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	got := pullUntil(t, src, 2)
+	if got[0].Message != "recent 1" || got[1].Message != "recent 2" {
+		t.Fatalf("after rewind = %+v", got)
+	}
+
+	// The rewound position is committed, so a restart starts there too.
+	src.client.Close()
+	again := startKafkaSource(t, cfg)
+	if got := pullUntil(t, again, 2); got[0].Message != "recent 1" {
+		t.Fatalf("after restart = %+v", got)
+	}
+}
+
+// TestKafkaSource_ShutdownCommitLeavesGroup checks the commit after the worker
+// stops still reaches the group, then closes the client.
+func TestKafkaSource_ShutdownCommitLeavesGroup(t *testing.T) {
+	brokers, produce := newKafkaTestCluster(t)
+	cfg := config.AgentKafkaSourceConfig{Brokers: brokers, Topic: "logs", GroupID: "versus", StartOffset: "earliest"}
+	produce(time.Now(), "one", "two")
+
+	src, err := NewKafkaSource("logs", cfg)
+	if err != nil {
+		t.Fatalf("NewKafkaSource: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := src.Listen(ctx); err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	pullUntil(t, src, 2)
+	cancel()
+	if err := src.Commit(context.Background()); err != nil {
+		t.Fatalf("shutdown Commit: %v", err)
+	}
+	pullNothing(t, src)
+
+	next := startKafkaSource(t, cfg)
+	produce(time.Now(), "three")
+	if got := pullUntil(t, next, 1); got[0].Message != "three" {
+		t.Fatalf("after shutdown = %+v", got)
+	}
+}
+
+func TestNewKafkaSource_Validation(t *testing.T) {
+	base := config.AgentKafkaSourceConfig{Brokers: []string{"k:9092"}, Topic: "logs", GroupID: "versus"}
+	for name, mutate := range map[string]func(*config.AgentKafkaSourceConfig){
+		"no brokers":        func(c *config.AgentKafkaSourceConfig) { c.Brokers = nil },
+		"no topic":          func(c *config.AgentKafkaSourceConfig) { c.Topic = "" },
+		"no group":          func(c *config.AgentKafkaSourceConfig) { c.GroupID = "" },
+		"bad start offset":  func(c *config.AgentKafkaSourceConfig) { c.StartOffset = "middle" },
+		"bad lookback":      func(c *config.AgentKafkaSourceConfig) { c.RewindLookback = "an hour" },
+		"negative lookback": func(c *config.AgentKafkaSourceConfig) { c.RewindLookback = "-1h" },
+		"bad format":        func(c *config.AgentKafkaSourceConfig) { c.Format = "xml" },
+		"bad sasl":          func(c *config.AgentKafkaSourceConfig) { c.SASL.Mechanism = "gssapi" },
+		"sasl without user": func(c *config.AgentKafkaSourceConfig) { c.SASL.Mechanism = "plain" },
+		"cert without key":  func(c *config.AgentKafkaSourceConfig) { c.TLS.CertFile = "c.pem" },
+	} {
+		cfg := base
+		mutate(&cfg)
+		if _, err := NewKafkaSource("x", cfg); err == nil {
+			t.Errorf("%s: NewKafkaSource succeeded", name)
+		}
+	}
+	if _, err := NewKafkaSource("x", base); err != nil {
+		t.Fatalf("valid config: %v", err)
+	}
+}
@@ -80,6 +80,11 @@ func init() {
 		"graylog",
 		"splunk",
 		"signoz",
+		"otlp",
+		"syslog",
+		"kafka",
+		"kubernetes",
+		"http",
 	} {
 		RegisterKind(t, KindLogs)
 	}
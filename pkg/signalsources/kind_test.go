@@ -9,7 +9,7 @@ import "testing"
 // types are registered here in-test to keep this OSS test OSS-only.
 func TestKindOf_DefaultsAndRegistered(t *testing.T) {
 	// Built-in OSS log types (registered by this package's init()).
-	for _, typ := range []string{"elasticsearch", "file", "loki", "cloudwatchlogs", "graylog", "splunk", "signoz"} {
+	for _, typ := range []string{"elasticsearch", "file", "loki", "cloudwatchlogs", "graylog", "splunk", "signoz", "otlp", "syslog", "kafka", "kubernetes", "http"} {
 		if got := KindOf(typ); got != KindLogs {
 			t.Errorf("KindOf(%q) = %q, want %q", typ, got, KindLogs)
 		}
@@ -0,0 +1,500 @@
+package signalsources
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/VersusControl/versus-incident/pkg/core"
+)
+
+// KubernetesSource reads container logs straight from the Kubernetes API
+// server (`GET .../pods/{pod}/log`), for clusters that ship logs nowhere else.
+//
+// Behavior:
+//
+//   - Each tick lists the pods matching the namespaces and label selector,
+//     then reads every container's log with `timestamps=true` from its own
+//     cursor. A container seen for the first time starts at the `since` the
+//     worker passed in.
+//   - `sinceTime` has second precision, so each read re-fetches the rest of
+//     the cursor's second. Lines older than the cursor are dropped, and so
+//     are as many lines stamped exactly at the cursor as were already taken.
+//   - A container whose restartCount went up has the rest of its previous
+//     instance's log (`previous=true`) read before the new instance's.
+//   - Containers of pods that no longer match are forgotten.
+//   - Containers are read oldest cursor first, at most max_lines_per_container
+//     each and max_pull_records in all, so a noisy container cannot starve
+//     the rest.
+//   - The returned cursor is the oldest point any live container is read up
+//     to, so a restarted agent re-reads from there: bounded duplicates, no
+//     gaps for pods that outlive the restart.
+type KubernetesSource struct {
+	name       string
+	cfg        config.AgentKubernetesSourceConfig
+	decoder    lineDecoder
+	endpoint   *k8sEndpoint
+	client     *http.Client
+	containers map[string]bool // empty: every container
+
+	// mu serialises Pull and Rewind.
+	mu      sync.Mutex
+	cursors map[string]*k8sContainerCursor // by pod UID + "/" + container
+}
+
+// k8sContainerCursor is one container's read position.
+type k8sContainerCursor struct {
+	last     time.Time // timestamp of the last line taken
+	atLast   int       // lines taken that are stamped exactly last
+	restarts int32     // restartCount whose previous instance is read; -1 before the first tick
+	scanned  time.Time // every line before this is taken
+}
+
+// Defaults applied when the corresponding option is empty / zero.
+const (
+	defaultK8sMaxPullRecords       = 1000
+	defaultK8sMaxLinesPerContainer = 500
+	defaultK8sMaxLineBytes         = 64 * 1024
+	k8sPodListLimit                = 500
+)
+
+// k8sLogSlack is how far behind the request time a fully read container is
+// considered read. The runtime stamps a line before the kubelet can serve
+// it, so lines from the last instant may not be visible yet.
+const k8sLogSlack = 2 * time.Second
+
+// cronJobSuffix matches the scheduled-time suffix a CronJob gives its Jobs.
+var cronJobSuffix = regexp.MustCompile(`-\d{8,}$`)
+
+// NewKubernetesSource validates configuration and resolves the API server
+// credentials. It reads the kubeconfig or service account files but does not
+// contact the API server.
+func NewKubernetesSource(name string, cfg config.AgentKubernetesSourceConfig) (*KubernetesSource, error) {
+	switch cfg.Format {
+	case "", "text", "json", "logfmt":
+		// ok
+	default:
+		return nil, fmt.Errorf("kubernetes source %q: unknown format %q (want \"text\", \"json\" or \"logfmt\")", name, cfg.Format)
+	}
+	if cfg.MaxPullRecords <= 0 {
+		cfg.MaxPullRecords = defaultK8sMaxPullRecords
+	}
+	if cfg.MaxLinesPerContainer <= 0 {
+		cfg.MaxLinesPerContainer = defaultK8sMaxLinesPerContainer
+	}
+	if cfg.MaxLineBytes <= 0 {
+		cfg.MaxLineBytes = defaultK8sMaxLineBytes
+	}
+	ep, err := resolveK8sEndpoint(cfg.Kubeconfig, cfg.Context, cfg.InsecureSkipVerify)
+	if err != nil {
+		return nil, fmt.Errorf("kubernetes source %q: %w", name, err)
+	}
+	s := &KubernetesSource{
+		name:       name,
+		cfg:        cfg,
+		endpoint:   ep,
+		client:     &http.Client{Transport: ep.transport, Timeout: 30 * time.Second},
+		containers: make(map[string]bool, len(cfg.Containers)),
+		cursors:    make(map[string]*k8sContainerCursor),
+	}
+	for _, c := range cfg.Containers {
+		s.containers[c] = true
+	}
+	s.decoder = newLineDecoder(s.Name(), cfg.Format, cfg.TimestampLayout, cfg.MessageField, cfg.TimestampField, cfg.SeverityField)
+	return s, nil
+}
+
+func (s *KubernetesSource) Name() string { return "kubernetes:" + s.name }
+
+// k8sTarget is one container to read this tick.
+type k8sTarget struct {
+	key       string
+	namespace string
+	pod       string
+	container string
+	restarts  int32
+	fields    map[string]any // stamped on every signal
+	service   string
+}
+
+func (s *KubernetesSource) Pull(ctx context.Context, since time.Time) ([]core.Signal, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	pods, err := s.listPods(ctx)
+	if err != nil {
+		return nil, since, err
+	}
+
+	live := make(map[string]bool)
+	var targets []k8sTarget
+	for i := range pods {
+		pod := &pods[i]
+		statuses := make(map[string]k8sContainerStatus, len(pod.Status.ContainerStatuses))
+		for _, st := range pod.Status.ContainerStatuses {
+			statuses[st.Name] = st
+		}
+		kind, workload := podWorkload(pod)
+		for _, c := range pod.Spec.Containers {
+			if len(s.containers) > 0 && !s.containers[c.Name] {
+				continue
+			}
+			key := pod.Metadata.UID + "/" + c.Name
+			live[key] = true
+			cur := s.cursors[key]
+			if cur == nil {
+				cur = &k8sContainerCursor{last: since, restarts: -1, scanned: since}
+				s.cursors[key] = cur
+			}
+			st, ok := statuses[c.Name]
+			if !ok || (st.State.Waiting != nil && st.RestartCount == 0) {
+				// Never started, so there is no log to read yet.
+				cur.scanned = now.Add(-k8sLogSlack)
+				continue
+			}
+			targets = append(targets, k8sTarget{
+				key:       key,
+				namespace: pod.Metadata.Namespace,
+				pod:       pod.Metadata.Name,
+				container: c.Name,
+				restarts:  st.RestartCount,
+				fields:    k8sFields(pod, c.Name, kind, workload),
+				service:   workload,
+			})
+		}
+	}
+	for key := range s.cursors {
+		if !live[key] {
+			delete(s.cursors, key)
+		}
+	}
+
+	// Oldest cursor first, so containers cut short by max_pull_records go
+	// first next tick.
+	sort.SliceStable(targets, func(i, j int) bool {
+		a, b := s.cursors[targets[i].key].last, s.cursors[targets[j].key].last
+		if !a.Equal(b) {
+			return a.Before(b)
+		}
+		return targets[i].key < targets[j].key
+	})
+
+	var signals []core.Signal
+	for _, t := range targets {
+		budget := s.cfg.MaxPullRecords - len(signals)
+		if budget <= 0 {
+			break
+		}
+		if budget > s.cfg.MaxLinesPerContainer {
+			budget = s.cfg.MaxLinesPerContainer
+		}
+		signals = append(signals, s.readContainer(ctx, t, s.cursors[t.key], budget, now)...)
+	}
+	sort.SliceStable(signals, func(i, j int) bool { return signals[i].Timestamp.Before(signals[j].Timestamp) })
+
+	cursor := now.Add(-k8sLogSlack)
+	for _, cur := range s.cursors {
+		if cur.scanned.Before(cursor) {
+			cursor = cur.scanned
+		}
+	}
+	return signals, ClampCursor(cursor, since, now), nil
+}
+
+// Rewind forgets every container's cursor, so the next Pull reads each one
+// from the worker's rewound `since`. It implements core.SourceRewinder.
+func (s *KubernetesSource) Rewind(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cursors = make(map[string]*k8sContainerCursor)
+	return nil
+}
+
+// readContainer takes up to budget lines from one container. A failed read
+// is logged and leaves the cursor where it was, so one pod being deleted
+// mid-tick does not fail the whole source.
+func (s *KubernetesSource) readContainer(ctx context.Context, t k8sTarget, cur *k8sContainerCursor, budget int, now time.Time) []core.Signal {
+	var out []core.Signal
+	if cur.restarts < t.restarts && t.restarts > 0 {
+		sigs, done, err := s.readLog(ctx, t, cur, true, budget)
+		out = append(out, sigs...)
+		if err != nil {
+			// The kubelet keeps only one previous instance; there is nothing
+			// to retry once it is gone.
+			log.Printf("kubernetes source %q: previous log of %s/%s %s: %v", s.name, t.namespace, t.pod, t.container, err)
+		} else if !done {
+			cur.scanned = cur.last
+			return out
+		}
+		budget -= len(sigs)
+	}
+	cur.restarts = t.restarts
+	if budget <= 0 {
+		cur.scanned = cur.last
+		return out
+	}
+
+	sigs, done, err := s.readLog(ctx, t, cur, false, budget)
+	out = append(out, sigs...)
+	switch {
+	case err != nil:
+		log.Printf("kubernetes source %q: log of %s/%s %s: %v", s.name, t.namespace, t.pod, t.container, err)
+		cur.scanned = cur.last
+	case done:
+		cur.scanned = now.Add(-k8sLogSlack)
+	default:
+		cur.scanned = cur.last
+	}
+	return out
+}
+
+// readLog streams one container instance's log from the cursor and advances
+// the cursor past each line it takes. done reports that the log was read to
+// its end rather than cut off at budget.
+func (s *KubernetesSource) readLog(ctx context.Context, t k8sTarget, cur *k8sContainerCursor, previous bool, budget int) ([]core.Signal, bool, error) {
+	q := url.Values{}
+	q.Set("container", t.container)
+	q.Set("timestamps", "true")
+	q.Set("sinceTime", cur.last.UTC().Truncate(time.Second).Format(time.RFC3339))
+	if previous {
+		q.Set("previous", "true")
+	}
+	resp, err := s.get(ctx, "/api/v1/namespaces/"+url.PathEscape(t.namespace)+"/pods/"+url.PathEscape(t.pod)+"/log?"+q.Encode())
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	var out []core.Signal
+	skipAtLast, skipped := cur.atLast, 0
+	br := bufio.NewReader(resp.Body)
+	for {
+		line, rerr := readLineLimited(br, s.cfg.MaxLineBytes)
+		if ts, msg, ok := splitK8sLogLine(strings.TrimRight(line, "\r\n")); ok && !ts.Before(cur.last) {
+			take := true
+			if ts.Equal(cur.last) {
+				if skipped < skipAtLast {
+					skipped++
+					take = false
+				} else {
+					cur.atLast++
+				}
+			} else {
+				cur.last, cur.atLast = ts, 1
+			}
+			if take && strings.TrimSpace(msg) != "" {
+				out = append(out, s.toSignal(t, msg, ts))
+			}
+		}
+		if rerr != nil {
+			if rerr == io.EOF {
+				return out, true, nil
+			}
+			return out, false, rerr
+		}
+		if len(out) >= budget {
+			return out, false, nil
+		}
+	}
+}
+
+// splitK8sLogLine splits a `timestamps=true` line into the runtime's
+// RFC 3339 timestamp and the line the container wrote.
+func splitK8sLogLine(line string) (time.Time, string, bool) {
+	head, rest, _ := strings.Cut(line, " ")
+	ts, err := time.Parse(time.RFC3339Nano, head)
+	if err != nil {
+		return time.Time{}, "", false
+	}
+	return ts.UTC(), rest, true
+}
+
+func (s *KubernetesSource) toSignal(t k8sTarget, msg string, ts time.Time) core.Signal {
+	sig := s.decoder.decode(msg, ts)
+	fields := make(map[string]any, len(sig.Fields)+len(t.fields)+1)
+	for k, v := range sig.Fields {
+		fields[k] = v
+	}
+	for k, v := range t.fields {
+		fields[k] = v
+	}
+	// A service the line names itself wins over the workload.
+	if _, ok := fields[core.FieldService]; !ok {
+		fields[core.FieldService] = t.service
+	}
+	sig.Fields = fields
+	return sig
+}
+
+// k8sWorkloadFields maps a workload kind to its OpenTelemetry resource key.
+var k8sWorkloadFields = map[string]string{
+	"Deployment":  "k8s.deployment.name",
+	"ReplicaSet":  "k8s.replicaset.name",
+	"StatefulSet": "k8s.statefulset.name",
+	"DaemonSet":   "k8s.daemonset.name",
+	"Job":         "k8s.job.name",
+	"CronJob":     "k8s.cronjob.name",
+}
+
+func k8sFields(pod *k8sPod, container, kind, workload string) map[string]any {
+	f := map[string]any{
+		"k8s.namespace.name": pod.Metadata.Namespace,
+		"k8s.pod.name":       pod.Metadata.Name,
+		"k8s.pod.uid":        pod.Metadata.UID,
+		"k8s.container.name": container,
+		"k8s.workload.kind":  kind,
+		"k8s.workload.name":  workload,
+	}
+	if pod.Spec.NodeName != "" {
+		f["k8s.node.name"] = pod.Spec.NodeName
+	}
+	if key, ok := k8sWorkloadFields[kind]; ok {
+		f[key] = workload
+	}
+	return f
+}
+
+// podWorkload names the workload that owns a pod, following the controller
+// reference. A ReplicaSet stamped with the pod-template-hash label belongs
+// to the Deployment of the same name minus the hash, and a Job with a
+// scheduled-time suffix to a CronJob. A pod with no controller is its own
+// workload.
+func podWorkload(pod *k8sPod) (kind, name string) {
+	for _, ref := range pod.Metadata.OwnerReferences {
+		if ref.Controller == nil || !*ref.Controller {
+			continue
+		}
+		switch ref.Kind {
+		case "ReplicaSet":
+			if hash := pod.Metadata.Labels["pod-template-hash"]; hash != "" && strings.HasSuffix(ref.Name, "-"+hash) {
+				return "Deployment", strings.TrimSuffix(ref.Name, "-"+hash)
+			}
+		case "Job":
+			if loc := cronJobSuffix.FindStringIndex(ref.Name); loc != nil && loc[0] > 0 {
+				return "CronJob", ref.Name[:loc[0]]
+			}
+		}
+		return ref.Kind, ref.Name
+	}
+	return "Pod", pod.Metadata.Name
+}
+
+// listPods lists the matching pods of every configured namespace, or of the
+// whole cluster, following the list's continue token.
+func (s *KubernetesSource) listPods(ctx context.Context) ([]k8sPod, error) {
+	paths := []string{"/api/v1/pods"}
+	if len(s.cfg.Namespaces) > 0 {
+		paths = paths[:0]
+		for _, ns := range s.cfg.Namespaces {
+			paths = append(paths, "/api/v1/namespaces/"+url.PathEscape(ns)+"/pods")
+		}
+	}
+	var pods []k8sPod
+	for _, path := range paths {
+		cont := ""
+		for {
+			q := url.Values{}
+			if s.cfg.LabelSelector != "" {
+				q.Set("labelSelector", s.cfg.LabelSelector)
+			}
+			q.Set("limit", strconv.Itoa(k8sPodListLimit))
+			if cont != "" {
+				q.Set("continue", cont)
+			}
+			resp, err := s.get(ctx, path+"?"+q.Encode())
+			if err != nil {
+				return nil, err
+			}
+			var list k8sPodList
+			err = json.NewDecoder(resp.Body).Decode(&list)
+			resp.Body.Close()
+			if err != nil {
+				return nil, fmt.Errorf("decode kubernetes pod list: %w", err)
+			}
+			pods = append(pods, list.Items...)
+			if cont = list.Metadata.Continue; cont == "" {
+				break
+			}
+		}
+	}
+	return pods, nil
+}
+
+// get issues an authenticated GET against the API server. A response with an
+// error status is returned as an error, with the body closed.
+func (s *KubernetesSource) get(ctx context.Context, path string) (*http.Response, error) {
+	u := s.endpoint.server + path
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if err := s.endpoint.authorize(req); err != nil {
+		return nil, fmt.Errorf("kubernetes source %q: %w", s.name, err)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		resp.Body.Close()
+		return nil, fmt.Errorf("kubernetes %s: %d %s", u, resp.StatusCode, truncate(string(body), 256))
+	}
+	return resp, nil
+}
+
+// -----------------------------------------------------------------------------
+// response shape
+// -----------------------------------------------------------------------------
+
+type k8sPodList struct {
+	Metadata struct {
+		Continue string `json:"continue"`
+	} `json:"metadata"`
+	Items []k8sPod `json:"items"`
+}
+
+type k8sPod struct {
+	Metadata struct {
+		Name            string            `json:"name"`
+		Namespace       string            `json:"namespace"`
+		UID             string            `json:"uid"`
+		Labels          map[string]string `json:"labels"`
+		OwnerReferences []struct {
+			Kind       string `json:"kind"`
+			Name       string `json:"name"`
+			Controller *bool  `json:"controller"`
+		} `json:"ownerReferences"`
+	} `json:"metadata"`
+	Spec struct {
+		NodeName   string `json:"nodeName"`
+		Containers []struct {
+			Name string `json:"name"`
+		} `json:"containers"`
+	} `json:"spec"`
+	Status struct {
+		ContainerStatuses []k8sContainerStatus `json:"containerStatuses"`
+	} `json:"status"`
+}
+
+type k8sContainerStatus struct {
+	Name         string `json:"name"`
+	RestartCount int32  `json:"restartCount"`
+	State        struct {
+		Waiting *struct{} `json:"waiting"`
+	} `json:"state"`
+}
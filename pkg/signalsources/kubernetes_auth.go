@@ -0,0 +1,258 @@
+package signalsources
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// inClusterDir holds the service account credentials the kubelet mounts into
+// every pod. A var so tests can point it elsewhere.
+var inClusterDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// k8sEndpoint is a resolved API server: its base URL, a transport carrying
+// the TLS settings, and how to authenticate each request.
+type k8sEndpoint struct {
+	server    string
+	transport *http.Transport
+
+	token     string // static bearer token
+	tokenFile string // re-read on every tick; projected tokens rotate
+	username  string // basic auth
+	password  string
+}
+
+// authorize sets the request's credentials. The token file is read here, not
+// at startup, because service account tokens are rotated in place.
+func (e *k8sEndpoint) authorize(req *http.Request) error {
+	switch {
+	case e.tokenFile != "":
+		b, err := os.ReadFile(e.tokenFile)
+		if err != nil {
+			return fmt.Errorf("read token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(b)))
+	case e.token != "":
+		req.Header.Set("Authorization", "Bearer "+e.token)
+	case e.username != "":
+		req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(e.username+":"+e.password)))
+	}
+	return nil
+}
+
+// resolveK8sEndpoint picks the credentials the way kubectl does: an explicit
+// kubeconfig path, else the pod's service account when running in a cluster,
+// else $KUBECONFIG, else ~/.kube/config.
+func resolveK8sEndpoint(kubeconfig, context string, insecure bool) (*k8sEndpoint, error) {
+	if kubeconfig == "" && os.Getenv("KUBERNETES_SERVICE_HOST") != "" {
+		return inClusterEndpoint(insecure)
+	}
+	if kubeconfig == "" {
+		kubeconfig = defaultKubeconfigPath()
+	}
+	if kubeconfig == "" {
+		return nil, errors.New("not running in a cluster and no kubeconfig found")
+	}
+	return kubeconfigEndpoint(kubeconfig, context, insecure)
+}
+
+func defaultKubeconfigPath() string {
+	if env := os.Getenv("KUBECONFIG"); env != "" {
+		// kubectl merges a list; the first file is enough for one context.
+		return filepath.SplitList(env)[0]
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		p := filepath.Join(home, ".kube", "config")
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+	}
+	return ""
+}
+
+func inClusterEndpoint(insecure bool) (*k8sEndpoint, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if port == "" {
+		port = "443"
+	}
+	tokenFile := filepath.Join(inClusterDir, "token")
+	if _, err := os.Stat(tokenFile); err != nil {
+		return nil, fmt.Errorf("in-cluster token: %w", err)
+	}
+	tlsCfg := &tls.Config{InsecureSkipVerify: insecure}
+	if !insecure {
+		ca, err := os.ReadFile(filepath.Join(inClusterDir, "ca.crt"))
+		if err != nil {
+			return nil, fmt.Errorf("in-cluster CA: %w", err)
+		}
+		if tlsCfg.RootCAs, err = k8sCertPool(ca); err != nil {
+			return nil, err
+		}
+	}
+	return &k8sEndpoint{
+		server:    "https://" + net.JoinHostPort(host, port),
+		transport: &http.Transport{Proxy: http.ProxyFromEnvironment, TLSClientConfig: tlsCfg},
+		tokenFile: tokenFile,
+	}, nil
+}
+
+// kubeconfigFile is the subset of a kubeconfig this source reads.
+type kubeconfigFile struct {
+	CurrentContext string `yaml:"current-context"`
+	Clusters       []struct {
+		Name    string `yaml:"name"`
+		Cluster struct {
+			Server                   string `yaml:"server"`
+			CertificateAuthority     string `yaml:"certificate-authority"`
+			CertificateAuthorityData string `yaml:"certificate-authority-data"`
+			InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify"`
+			TLSServerName            string `yaml:"tls-server-name"`
+		} `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Users []struct {
+		Name string `yaml:"name"`
+		User struct {
+			Token                 string    `yaml:"token"`
+			TokenFile             string    `yaml:"tokenFile"`
+			ClientCertificate     string    `yaml:"client-certificate"`
+			ClientCertificateData string    `yaml:"client-certificate-data"`
+			ClientKey             string    `yaml:"client-key"`
+			ClientKeyData         string    `yaml:"client-key-data"`
+			Username              string    `yaml:"username"`
+			Password              string    `yaml:"password"`
+			Exec                  yaml.Node `yaml:"exec"`
+			AuthProvider          yaml.Node `yaml:"auth-provider"`
+		} `yaml:"user"`
+	} `yaml:"users"`
+	Contexts []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Cluster string `yaml:"cluster"`
+			User    string `yaml:"user"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+}
+
+func kubeconfigEndpoint(path, context string, insecure bool) (*k8sEndpoint, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read kubeconfig: %w", err)
+	}
+	var kc kubeconfigFile
+	if err := yaml.Unmarshal(b, &kc); err != nil {
+		return nil, fmt.Errorf("parse kubeconfig %s: %w", path, err)
+	}
+	if context == "" {
+		context = kc.CurrentContext
+	}
+	// Relative file references are relative to the kubeconfig itself.
+	dir := filepath.Dir(path)
+	rel := func(p string) string {
+		if p == "" || filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(dir, p)
+	}
+
+	var clusterName, userName string
+	found := false
+	for _, c := range kc.Contexts {
+		if c.Name == context {
+			clusterName, userName, found = c.Context.Cluster, c.Context.User, true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("kubeconfig %s: context %q not found", path, context)
+	}
+
+	e := &k8sEndpoint{}
+	tlsCfg := &tls.Config{InsecureSkipVerify: insecure}
+	found = false
+	for _, c := range kc.Clusters {
+		if c.Name != clusterName {
+			continue
+		}
+		found = true
+		e.server = strings.TrimRight(c.Cluster.Server, "/")
+		tlsCfg.ServerName = c.Cluster.TLSServerName
+		if c.Cluster.InsecureSkipTLSVerify {
+			tlsCfg.InsecureSkipVerify = true
+		}
+		ca, err := kubeconfigBytes(c.Cluster.CertificateAuthorityData, rel(c.Cluster.CertificateAuthority))
+		if err != nil {
+			return nil, fmt.Errorf("kubeconfig cluster %q CA: %w", clusterName, err)
+		}
+		if ca != nil {
+			if tlsCfg.RootCAs, err = k8sCertPool(ca); err != nil {
+				return nil, err
+			}
+		}
+		break
+	}
+	if !found || e.server == "" {
+		return nil, fmt.Errorf("kubeconfig %s: cluster %q has no server", path, clusterName)
+	}
+
+	for _, u := range kc.Users {
+		if u.Name != userName {
+			continue
+		}
+		if !u.User.Exec.IsZero() || !u.User.AuthProvider.IsZero() {
+			return nil, fmt.Errorf("kubeconfig user %q: exec and auth-provider credentials are not supported; use a token, token file or client certificate", userName)
+		}
+		e.token = u.User.Token
+		e.tokenFile = rel(u.User.TokenFile)
+		e.username, e.password = u.User.Username, u.User.Password
+		cert, err := kubeconfigBytes(u.User.ClientCertificateData, rel(u.User.ClientCertificate))
+		if err != nil {
+			return nil, fmt.Errorf("kubeconfig user %q certificate: %w", userName, err)
+		}
+		key, err := kubeconfigBytes(u.User.ClientKeyData, rel(u.User.ClientKey))
+		if err != nil {
+			return nil, fmt.Errorf("kubeconfig user %q key: %w", userName, err)
+		}
+		if cert != nil || key != nil {
+			pair, err := tls.X509KeyPair(cert, key)
+			if err != nil {
+				return nil, fmt.Errorf("kubeconfig user %q client certificate: %w", userName, err)
+			}
+			tlsCfg.Certificates = []tls.Certificate{pair}
+		}
+		break
+	}
+	e.transport = &http.Transport{Proxy: http.ProxyFromEnvironment, TLSClientConfig: tlsCfg}
+	return e, nil
+}
+
+// kubeconfigBytes returns the base64 `-data` form when set, else the file's
+// contents, else nil.
+func kubeconfigBytes(data, file string) ([]byte, error) {
+	if data != "" {
+		return base64.StdEncoding.DecodeString(data)
+	}
+	if file != "" {
+		return os.ReadFile(file)
+	}
+	return nil, nil
+}
+
+func k8sCertPool(pem []byte) (*x509.CertPool, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("no certificates in the API server CA")
+	}
+	return pool, nil
+}
@@ -0,0 +1,308 @@
+package signalsources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/VersusControl/versus-incident/pkg/core"
+)
+
+type fakeK8sLine struct {
+	ts  time.Time
+	msg string
+}
+
+// fakeK8sAPI serves the pod list and pod log endpoints the way the API
+// server does, including sinceTime's second precision.
+type fakeK8sAPI struct {
+	mu       sync.Mutex
+	pods     []map[string]any
+	logs     map[string][]fakeK8sLine // "ns/pod/container", + "/previous"
+	auth     []string
+	selector []string
+}
+
+func (f *fakeK8sAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.auth = append(f.auth, r.Header.Get("Authorization"))
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case r.URL.Path == "/api/v1/pods" || (len(parts) == 5 && parts[4] == "pods"):
+		f.selector = append(f.selector, r.URL.Query().Get("labelSelector"))
+		json.NewEncoder(w).Encode(map[string]any{"items": f.pods})
+	case len(parts) == 7 && parts[6] == "log":
+		q := r.URL.Query()
+		key := parts[3] + "/" + parts[5] + "/" + q.Get("container")
+		if q.Get("previous") == "true" {
+			key += "/previous"
+		}
+		lines, ok := f.logs[key]
+		if !ok {
+			http.Error(w, `{"message":"container not found"}`, http.StatusBadRequest)
+			return
+		}
+		since, _ := time.Parse(time.RFC3339, q.Get("sinceTime"))
+		for _, l := range lines {
+			if !l.ts.Before(since) {
+				fmt.Fprintf(w, "%s %s\n", l.ts.Format(time.RFC3339Nano), l.msg)
+			}
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeK8sAPI) setPods(pods ...map[string]any) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pods = pods
+}
+
+func (f *fakeK8sAPI) addLog(key string, lines ...fakeK8sLine) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.logs[key] = append(f.logs[key], lines...)
+}
+
+// fakeK8sPod builds a pod owned by the Deployment "orders" unless owner is
+// set, with one container "app".
+func fakeK8sPod(name, uid string, restarts int, owner map[string]any) map[string]any {
+	if owner == nil {
+		owner = map[string]any{"kind": "ReplicaSet", "name": "orders-7d9f8", "controller": true}
+	}
+	return map[string]any{
+		"metadata": map[string]any{
+			"name": name, "namespace": "shop", "uid": uid,
+			"labels":          map[string]string{"app": "orders", "pod-template-hash": "7d9f8"},
+			"ownerReferences": []any{owner},
+		},
+		"spec":   map[string]any{"nodeName": "node-1", "containers": []any{map[string]any{"name": "app"}}},
+		"status": map[string]any{"containerStatuses": []any{map[string]any{"name": "app", "restartCount": restarts, "state": map[string]any{"running": map[string]any{}}}}},
+	}
+}
+
+// newFakeK8sSource starts a fake API server and a source reading it through
+// a token kubeconfig.
+func newFakeK8sSource(t *testing.T, cfg config.AgentKubernetesSourceConfig) (*fakeK8sAPI, *KubernetesSource) {
+	t.Helper()
+	api := &fakeK8sAPI{logs: map[string][]fakeK8sLine{}}
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+	cfg.Kubeconfig = writeTestKubeconfig(t, srv.URL, "token: s3cret")
+	src, err := NewKubernetesSource("cluster", cfg)
+	if err != nil {
+		t.Fatalf("NewKubernetesSource: %v", err)
+	}
+	return api, src
+}
+
+func writeTestKubeconfig(t *testing.T, server, user string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config")
+	kc := fmt.Sprintf(`apiVersion: v1
+kind: Config
+current-context: test
+clusters:
+- name: test
+  cluster:
+    server: %s
+users:
+- name: test
+  user:
+    %s
+contexts:
+- name: test
+  context:
+    cluster: test
+    user: test
+`, server, user)
+	if err := os.WriteFile(path, []byte(kc), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func messages(sigs []core.Signal) []string {
+	out := make([]string, len(sigs))
+	for i, s := range sigs {
+		out[i] = s.Message
+	}
+	return out
+}
+
+func TestKubernetesSource_ReadsAndStamps(t *testing.T) {
+	api, src := newFakeK8sSource(t, config.AgentKubernetesSourceConfig{
+		Namespaces: []string{"shop"}, LabelSelector: "app=orders", Format: "json",
+	})
+	base := time.Now().UTC().Add(-time.Minute).Truncate(time.Second)
+	api.setPods(fakeK8sPod("orders-7d9f8-abcde", "u1", 0, nil))
+	api.addLog("shop/orders-7d9f8-abcde/app",
+		fakeK8sLine{base.Add(-time.Hour), `{"message":"too old"}`},
+		fakeK8sLine{base.Add(time.Second), `{"message":"db timeout","level":"error"}`},
+		fakeK8sLine{base.Add(2 * time.Second), `{"message":"paid","service":"payments"}`},
+	)
+
+	sigs, cursor, err := src.Pull(context.Background(), base)
+	if err != nil {
+		t.Fatalf("Pull: %v", err)
+	}
+	if got := messages(sigs); len(got) != 2 || got[0] != "db timeout" || got[1] != "paid" {
+		t.Fatalf("messages = %q", got)
+	}
+	s := sigs[0]
+	if s.Source != "kubernetes:cluster" || s.Severity != "error" || !s.Timestamp.Equal(base.Add(time.Second)) ||
+		s.Fields["k8s.namespace.name"] != "shop" || s.Fields["k8s.pod.name"] != "orders-7d9f8-abcde" ||
+		s.Fields["k8s.container.name"] != "app" || s.Fields["k8s.node.name"] != "node-1" ||
+		s.Fields["k8s.deployment.name"] != "orders" || s.Fields[core.FieldService] != "orders" {
+		t.Fatalf("signal = %+v", s)
+	}
+	if sigs[1].Fields[core.FieldService] != "payments" {
+		t.Fatalf("line's own service overridden: %+v", sigs[1].Fields)
+	}
+	if !cursor.After(base.Add(2 * time.Second)) {
+		t.Fatalf("cursor = %s, want past the last line", cursor)
+	}
+	if api.auth[0] != "Bearer s3cret" || api.selector[0] != "app=orders" {
+		t.Fatalf("auth = %q, selector = %q", api.auth, api.selector)
+	}
+}
+
+// TestKubernetesSource_SubSecondCursor checks that re-reading the cursor's
+// second neither repeats nor drops lines, including lines sharing one
+// timestamp split by the per-container cap.
+func TestKubernetesSource_SubSecondCursor(t *testing.T) {
+	api, src := newFakeK8sSource(t, config.AgentKubernetesSourceConfig{MaxLinesPerContainer: 2})
+	base := time.Now().UTC().Add(-time.Minute).Truncate(time.Second)
+	at := base.Add(100 * time.Millisecond)
+	api.setPods(fakeK8sPod("web", "u1", 0, map[string]any{"kind": "StatefulSet", "name": "web", "controller": true}))
+	api.addLog("shop/web/app", fakeK8sLine{at, "a"}, fakeK8sLine{at, "b"}, fakeK8sLine{at, "c"})
+
+	var got []string
+	for i := 0; i < 3; i++ {
+		if i == 1 {
+			api.addLog("shop/web/app", fakeK8sLine{base.Add(300 * time.Millisecond), "d"})
+		}
+		sigs, _, err := src.Pull(context.Background(), base)
+		if err != nil {
+			t.Fatalf("Pull: %v", err)
+		}
+		got = append(got, messages(sigs)...)
+	}
+	if strings.Join(got, ",") != "a,b,c,d" {
+		t.Fatalf("messages = %q", got)
+	}
+}
+
+// TestKubernetesSource_RestartReadsPrevious checks a restart reads the rest of
+// the crashed instance before the new one.
+func TestKubernetesSource_RestartReadsPrevious(t *testing.T) {
+	api, src := newFakeK8sSource(t, config.AgentKubernetesSourceConfig{})
+	base := time.Now().UTC().Add(-time.Minute).Truncate(time.Second)
+	api.setPods(fakeK8sPod("orders-7d9f8-abcde", "u1", 0, nil))
+	api.addLog("shop/orders-7d9f8-abcde/app", fakeK8sLine{base.Add(time.Second), "serving"})
+	if _, _, err := src.Pull(context.Background(), base); err != nil {
+		t.Fatalf("Pull: %v", err)
+	}
+
+	api.setPods(fakeK8sPod("orders-7d9f8-abcde", "u1", 1, nil))
+	api.logs["shop/orders-7d9f8-abcde/app/previous"] = []fakeK8sLine{
+		{base.Add(time.Second), "serving"},
+		{base.Add(2 * time.Second), "panic: nil map"},
+	}
+	api.logs["shop/orders-7d9f8-abcde/app"] = []fakeK8sLine{{base.Add(5 * time.Second), "booted"}}
+	sigs, _, err := src.Pull(context.Background(), base)
+	if err != nil {
+		t.Fatalf("Pull: %v", err)
+	}
+	if got := strings.Join(messages(sigs), ","); got != "panic: nil map,booted" {
+		t.Fatalf("messages = %q", got)
+	}
+
+	// The previous instance is read once per restart.
+	if sigs, _, _ := src.Pull(context.Background(), base); len(sigs) != 0 {
+		t.Fatalf("re-read = %q", messages(sigs))
+	}
+}
+
+// TestKubernetesSource_PodChurn checks deleted pods are forgotten and new ones
+// are read from since.
+func TestKubernetesSource_PodChurn(t *testing.T) {
+	api, src := newFakeK8sSource(t, config.AgentKubernetesSourceConfig{})
+	base := time.Now().UTC().Add(-time.Minute).Truncate(time.Second)
+	api.setPods(fakeK8sPod("orders-7d9f8-aaaaa", "u1", 0, nil))
+	api.addLog("shop/orders-7d9f8-aaaaa/app", fakeK8sLine{base.Add(time.Second), "old pod"})
+	if _, _, err := src.Pull(context.Background(), base); err != nil {
+		t.Fatalf("Pull: %v", err)
+	}
+
+	api.setPods(fakeK8sPod("orders-7d9f8-bbbbb", "u2", 0, nil))
+	api.addLog("shop/orders-7d9f8-bbbbb/app", fakeK8sLine{base.Add(2 * time.Second), "new pod"})
+	sigs, _, err := src.Pull(context.Background(), base)
+	if err != nil {
+		t.Fatalf("Pull: %v", err)
+	}
+	if got := strings.Join(messages(sigs), ","); got != "new pod" {
+		t.Fatalf("messages = %q", got)
+	}
+	if _, ok := src.cursors["u1/app"]; ok || len(src.cursors) != 1 {
+		t.Fatalf("cursors = %v", src.cursors)
+	}
+}
+
+func TestPodWorkload(t *testing.T) {
+	yes := true
+	for _, tc := range []struct {
+		kind, name, hash   string
+		wantKind, wantName string
+	}{
+		{"ReplicaSet", "orders-7d9f8", "7d9f8", "Deployment", "orders"},
+		{"ReplicaSet", "legacy", "", "ReplicaSet", "legacy"},
+		{"Job", "report-28391040", "", "CronJob", "report"},
+		{"Job", "migrate", "", "Job", "migrate"},
+		{"DaemonSet", "fluent", "", "DaemonSet", "fluent"},
+		{"", "", "", "Pod", "solo"},
+	} {
+		var pod k8sPod
+		pod.Metadata.Name = "solo"
+		pod.Metadata.Labels = map[string]string{"pod-template-hash": tc.hash}
+		if tc.kind != "" {
+			pod.Metadata.OwnerReferences = append(pod.Metadata.OwnerReferences, struct {
+				Kind       string `json:"kind"`
+				Name       string `json:"name"`
+				Controller *bool  `json:"controller"`
+			}{tc.kind, tc.name, &yes})
+		}
+		if kind, name := podWorkload(&pod); kind != tc.wantKind || name != tc.wantName {
+			t.Errorf("%s %s: got %s %s, want %s %s", tc.kind, tc.name, kind, name, tc.wantKind, tc.wantName)
+		}
+	}
+}
+
+func TestNewKubernetesSource_Validation(t *testing.T) {
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	good := writeTestKubeconfig(t, "https://k8s:6443", "token: x")
+	for name, cfg := range map[string]config.AgentKubernetesSourceConfig{
+		"bad format":      {Kubeconfig: good, Format: "xml"},
+		"unknown context": {Kubeconfig: good, Context: "prod"},
+		"missing file":    {Kubeconfig: filepath.Join(t.TempDir(), "nope")},
+		"exec plugin":     {Kubeconfig: writeTestKubeconfig(t, "https://k8s:6443", "exec: {command: aws}")},
+	} {
+		if _, err := NewKubernetesSource("x", cfg); err == nil {
+			t.Errorf("%s: NewKubernetesSource succeeded", name)
+		}
+	}
+	if _, err := NewKubernetesSource("x", config.AgentKubernetesSourceConfig{Kubeconfig: good}); err != nil {
+		t.Fatalf("valid config: %v", err)
+	}
+}
@@ -0,0 +1,224 @@
+package signalsources
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/core"
+)
+
+// Defaults applied when the corresponding decoder option is empty.
+const (
+	defaultLineFormat         = "text"
+	defaultJSONMessageField   = "message"
+	defaultJSONTimestampField = "@timestamp"
+	defaultJSONSeverityField  = "level"
+)
+
+// defaultTextTimestampLayouts are tried in order when TimestampLayout is empty.
+var defaultTextTimestampLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02T15:04:05.000Z",
+	"2006-01-02 15:04:05",
+}
+
+// lineDecoder turns one log line into a Signal. It holds the file source's
+// field-mapping options — `format`, `timestamp_layout` for text and
+// `message_field` / `timestamp_field` / `severity_field` for JSON and
+// logfmt — so every
+// source that reads raw lines (the file source, Kafka record values) maps
+// them the same way.
+type lineDecoder struct {
+	source    string // Signal.Source
+	format    string // "text", "json" or "logfmt"
+	tsLayouts []string
+	msgField  string
+	tsField   string
+	sevField  string
+	kv        keyValueMapping // logfmt only
+}
+
+// newLineDecoder applies the defaults to the mapping options. format must
+// already be validated as "", "text", "json" or "logfmt".
+func newLineDecoder(source, format, tsLayout, msgField, tsField, sevField string) lineDecoder {
+	d := lineDecoder{
+		source:    source,
+		format:    format,
+		tsLayouts: defaultTextTimestampLayouts,
+		msgField:  msgField,
+		tsField:   tsField,
+		sevField:  sevField,
+		kv:        newKeyValueMapping(msgField, sevField, tsField, "", tsLayout),
+	}
+	if d.format == "" {
+		d.format = defaultLineFormat
+	}
+	if tsLayout != "" {
+		d.tsLayouts = []string{tsLayout}
+	}
+	if d.msgField == "" {
+		d.msgField = defaultJSONMessageField
+	}
+	if d.tsField == "" {
+		d.tsField = defaultJSONTimestampField
+	}
+	if d.sevField == "" {
+		d.sevField = defaultJSONSeverityField
+	}
+	return d
+}
+
+// decode maps one non-empty line, without its line terminator. fallback is
+// the timestamp of a line that carries none of its own; zero means the read
+// time.
+func (d lineDecoder) decode(line string, fallback time.Time) core.Signal {
+	if fallback.IsZero() {
+		fallback = time.Now().UTC()
+	}
+	switch d.format {
+	case "json":
+		return d.jsonLineToSignal(line, fallback)
+	case "logfmt":
+		return d.logfmtLineToSignal(line, fallback)
+	}
+	return d.textLineToSignal(line, fallback)
+}
+
+// logfmtLineToSignal maps a key=value line, optionally behind a leading
+// timestamp as some loggers prefix one. A line that is not logfmt is read
+// as text so it isn't lost.
+func (d lineDecoder) logfmtLineToSignal(line string, fallback time.Time) core.Signal {
+	ts, rest := fallback, line
+	pairs, ok := parseLogfmt(line)
+	if !ok {
+		if lead, after, found := d.tryParseLeadingTimestamp(line); found {
+			ts, rest = lead, after
+			pairs, ok = parseLogfmt(after)
+		}
+	}
+	if !ok {
+		return d.textLineToSignal(line, fallback)
+	}
+	sig := core.Signal{
+		Source:    d.source,
+		Timestamp: ts,
+		Message:   rest,
+		Raw:       map[string]interface{}{"line": line},
+	}
+	d.kv.apply(&sig, pairs)
+	return sig
+}
+
+func (d lineDecoder) textLineToSignal(line string, fallback time.Time) core.Signal {
+	ts, rest, ok := d.tryParseLeadingTimestamp(line)
+	if !ok {
+		ts = fallback
+		rest = line
+	}
+	return core.Signal{
+		Source:    d.source,
+		Timestamp: ts,
+		// Message is the line WITHOUT the leading timestamp so identical
+		// messages with different timestamps cluster together. Raw keeps the
+		// full original line for debugging.
+		Message: rest,
+		Raw:     map[string]interface{}{"line": line},
+	}
+}
+
+// tryParseLeadingTimestamp tests each configured layout against the start of
+// the line. We accept either a bare timestamp followed by whitespace or a
+// timestamp wrapped in brackets like "[2024-01-02T15:04:05Z] ...". On success
+// it also returns the remainder of the line (with the timestamp + any
+// trailing bracket / whitespace stripped).
+//
+// The lookup splits on the first whitespace and tries to parse the prefix.
+// We do NOT key off `len(layout)` because Go layouts contain reference
+// strings like `Z07:00` that are longer than the actual rendered timestamp
+// (e.g. RFC3339 is 25 chars but a `…Z` timestamp is only 20). Splitting on
+// the first space is robust to any layout the user configures.
+func (d lineDecoder) tryParseLeadingTimestamp(line string) (time.Time, string, bool) {
+	candidate := strings.TrimLeft(line, "[")
+	leadingBracket := len(line) - len(candidate)
+
+	// Find the first whitespace — that's the end of the timestamp token.
+	// Falls back to the whole string if the line has no whitespace.
+	end := strings.IndexAny(candidate, " \t")
+	if end < 0 {
+		end = len(candidate)
+	}
+	head := candidate[:end]
+	// If the timestamp was wrapped in brackets the closing `]` will be at
+	// the end of `head`; strip it before parsing.
+	if leadingBracket > 0 && strings.HasSuffix(head, "]") {
+		head = head[:len(head)-1]
+	}
+
+	for _, layout := range d.tsLayouts {
+		if t, err := time.Parse(layout, head); err == nil {
+			rest := candidate[end:]
+			if leadingBracket > 0 {
+				rest = strings.TrimPrefix(rest, "]")
+			}
+			rest = strings.TrimLeft(rest, " \t")
+			return t.UTC(), rest, true
+		}
+	}
+	return time.Time{}, "", false
+}
+
+func (d lineDecoder) jsonLineToSignal(line string, fallback time.Time) core.Signal {
+	var m map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &m); err != nil {
+		// Fall back to text behavior so a malformed line isn't lost.
+		return d.textLineToSignal(line, fallback)
+	}
+	return d.jsonObjectToSignal(m, line, fallback)
+}
+
+// jsonObjectToSignal maps one decoded JSON object. line is its encoded form,
+// the message of an object that has no message field.
+func (d lineDecoder) jsonObjectToSignal(m map[string]interface{}, line string, fallback time.Time) core.Signal {
+	msg, _ := m[d.msgField].(string)
+	if msg == "" {
+		// No usable message — emit the whole line so the operator sees it.
+		msg = line
+	}
+	severity, _ := m[d.sevField].(string)
+
+	ts := fallback
+	if v, ok := m[d.tsField]; ok {
+		if parsed, ok := parseJSONTimestamp(v); ok {
+			ts = parsed
+		}
+	}
+
+	return core.Signal{
+		Source:    d.source,
+		Timestamp: ts,
+		Severity:  severity,
+		Message:   msg,
+		Fields:    m,
+		Raw:       m,
+	}
+}
+
+func parseJSONTimestamp(v interface{}) (time.Time, bool) {
+	switch t := v.(type) {
+	case string:
+		for _, layout := range []string{time.RFC3339Nano, time.RFC3339, "2006-01-02 15:04:05"} {
+			if parsed, err := time.Parse(layout, t); err == nil {
+				return parsed.UTC(), true
+			}
+		}
+	case float64:
+		// Heuristic: > 1e12 is millis, otherwise seconds.
+		if t > 1e12 {
+			return time.UnixMilli(int64(t)).UTC(), true
+		}
+		return time.Unix(int64(t), 0).UTC(), true
+	}
+	return time.Time{}, false
+}
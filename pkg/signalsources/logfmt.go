@@ -0,0 +1,207 @@
+package signalsources
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/VersusControl/versus-incident/pkg/core"
+)
+
+// Keys tried, in order, when the corresponding key=value option is empty.
+// They cover logfmt as Go's slog, logrus, go-kit and zap write it.
+var (
+	defaultKVMessageKeys   = []string{"msg", "message"}
+	defaultKVSeverityKeys  = []string{"level", "lvl", "severity"}
+	defaultKVTimestampKeys = []string{"ts", "time", "timestamp"}
+	defaultKVServiceKeys   = []string{"service", "svc"}
+)
+
+// logfmtPair is one key=value of a logfmt line.
+type logfmtPair struct {
+	key, value string
+}
+
+// parseLogfmt splits a logfmt line into its pairs, in order. Values may be
+// bare or double-quoted with Go escapes. ok is false unless every token is a
+// key=value pair, so free text that happens to contain an `=` is not
+// mistaken for logfmt.
+func parseLogfmt(line string) (pairs []logfmtPair, ok bool) {
+	i := 0
+	for {
+		for i < len(line) && (line[i] == ' ' || line[i] == '\t') {
+			i++
+		}
+		if i == len(line) {
+			return pairs, len(pairs) > 0
+		}
+		start := i
+		for i < len(line) && line[i] != '=' && line[i] != ' ' && line[i] != '\t' && line[i] != '"' {
+			i++
+		}
+		if i == start || i == len(line) || line[i] != '=' {
+			return nil, false
+		}
+		key := line[start:i]
+		i++ // '='
+
+		var value string
+		if i < len(line) && line[i] == '"' {
+			end := i + 1
+			for end < len(line) && line[end] != '"' {
+				if line[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(line) {
+				return nil, false
+			}
+			v, err := strconv.Unquote(line[i : end+1])
+			if err != nil {
+				return nil, false
+			}
+			value = v
+			i = end + 1
+			if i < len(line) && line[i] != ' ' && line[i] != '\t' {
+				return nil, false
+			}
+		} else {
+			start = i
+			for i < len(line) && line[i] != ' ' && line[i] != '\t' {
+				i++
+			}
+			value = line[start:i]
+		}
+		pairs = append(pairs, logfmtPair{key: key, value: value})
+	}
+}
+
+// keyValueMapping says which extracted keys become the signal's message,
+// severity, timestamp and service. Each role takes the first of its keys
+// that the line carries.
+type keyValueMapping struct {
+	msgKeys   []string
+	sevKeys   []string
+	tsKeys    []string
+	svcKeys   []string
+	tsLayouts []string
+}
+
+// newKeyValueMapping applies the defaults: an empty key option means the
+// usual logfmt spellings, an empty layout the text-mode layouts.
+func newKeyValueMapping(msgKey, sevKey, tsKey, svcKey, tsLayout string) keyValueMapping {
+	pick := func(key string, defaults []string) []string {
+		if key != "" {
+			return []string{key}
+		}
+		return defaults
+	}
+	m := keyValueMapping{
+		msgKeys:   pick(msgKey, defaultKVMessageKeys),
+		sevKeys:   pick(sevKey, defaultKVSeverityKeys),
+		tsKeys:    pick(tsKey, defaultKVTimestampKeys),
+		svcKeys:   pick(svcKey, defaultKVServiceKeys),
+		tsLayouts: defaultTextTimestampLayouts,
+	}
+	if tsLayout != "" {
+		m.tsLayouts = []string{tsLayout}
+	}
+	return m
+}
+
+// apply maps pairs onto sig. The message key replaces Message so the miner
+// clusters the message alone, not a line full of variable keys; the service
+// key lands in Fields under core.FieldService. Every other pair goes into a
+// copy of Fields. A line without a message key keeps its Message.
+func (m keyValueMapping) apply(sig *core.Signal, pairs []logfmtPair) {
+	values := make(map[string]string, len(pairs))
+	for _, p := range pairs {
+		if _, dup := values[p.key]; !dup {
+			values[p.key] = p.value
+		}
+	}
+	used := make(map[string]bool, 4)
+	first := func(keys []string) (string, bool) {
+		for _, k := range keys {
+			if v, ok := values[k]; ok {
+				used[k] = true
+				return v, true
+			}
+		}
+		return "", false
+	}
+
+	fields := make(map[string]any, len(sig.Fields)+len(pairs))
+	for k, v := range sig.Fields {
+		fields[k] = v
+	}
+	if v, ok := first(m.msgKeys); ok && v != "" {
+		sig.Message = v
+	}
+	if v, ok := first(m.sevKeys); ok {
+		sig.Severity = v
+	}
+	if v, ok := first(m.tsKeys); ok {
+		if ts, ok := parseKeyValueTimestamp(v, m.tsLayouts); ok {
+			sig.Timestamp = ts
+		}
+	}
+	if v, ok := first(m.svcKeys); ok && v != "" {
+		fields[core.FieldService] = v
+	}
+	for _, p := range pairs {
+		if !used[p.key] {
+			fields[p.key] = p.value
+		}
+	}
+	sig.Fields = fields
+}
+
+// parseKeyValueTimestamp reads a timestamp value: one of layouts, or Unix
+// seconds / milliseconds as logfmt loggers write `ts=1718000000.123`.
+func parseKeyValueTimestamp(v string, layouts []string) (time.Time, bool) {
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t.UTC(), true
+		}
+	}
+	if f, err := strconv.ParseFloat(v, 64); err == nil && f > 0 {
+		if f > 1e12 {
+			return time.UnixMilli(int64(f)).UTC(), true
+		}
+		sec := int64(f)
+		return time.Unix(sec, int64((f-float64(sec))*1e9)).UTC(), true
+	}
+	return time.Time{}, false
+}
+
+// KeyValue is the key=value extraction stage for sources whose messages are
+// logfmt but that do not read raw lines themselves (Loki, Elasticsearch,
+// syslog, ...). The worker runs it on each pull. A message that is not
+// logfmt passes through unchanged.
+type KeyValue struct {
+	mapping keyValueMapping
+}
+
+// NewKeyValue returns the stage for cfg; nil when cfg is not enabled.
+func NewKeyValue(cfg config.AgentKeyValueConfig) *KeyValue {
+	if !cfg.Enable {
+		return nil
+	}
+	return &KeyValue{mapping: newKeyValueMapping(cfg.MessageField, cfg.SeverityField, cfg.TimestampField, cfg.ServiceField, cfg.TimestampLayout)}
+}
+
+// Apply extracts the pairs of each logfmt message in place and returns
+// signals.
+func (kv *KeyValue) Apply(signals []core.Signal) []core.Signal {
+	for i := range signals {
+		pairs, ok := parseLogfmt(strings.TrimSpace(signals[i].Message))
+		if !ok {
+			continue
+		}
+		kv.mapping.apply(&signals[i], pairs)
+	}
+	return signals
+}
@@ -0,0 +1,96 @@
+package signalsources
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/VersusControl/versus-incident/pkg/core"
+)
+
+func TestParseLogfmt(t *testing.T) {
+	for _, tc := range []struct {
+		line string
+		want []logfmtPair
+		ok   bool
+	}{
+		{`level=error msg="db timeout" svc=orders`, []logfmtPair{{"level", "error"}, {"msg", "db timeout"}, {"svc", "orders"}}, true},
+		{`msg="say \"hi\"" empty= n=1`, []logfmtPair{{"msg", `say "hi"`}, {"empty", ""}, {"n", "1"}}, true},
+		{`  a=b  `, []logfmtPair{{"a", "b"}}, true},
+		{`user id=5 failed`, nil, false},
+		{`msg="unterminated`, nil, false},
+		{`msg="a"b c=d`, nil, false},
+		{`plain text line`, nil, false},
+		{``, nil, false},
+	} {
+		got, ok := parseLogfmt(tc.line)
+		if ok != tc.ok || !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q = %v, %v; want %v, %v", tc.line, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestFileSource_LogfmtFormat(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "app.log")
+	writeFile(t, logPath, `ts=2026-10-19T12:00:00Z level=error msg="db timeout" svc=orders attempt=3`+"\n"+
+		`2026-10-19T12:00:01Z level=info msg=started`+"\n"+
+		"not logfmt at all\n")
+
+	src, err := NewFileSource("lf", config.AgentFileSourceConfig{Path: logPath, Format: "logfmt", FromBeginning: true})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	sigs, _, err := src.Pull(t.Context(), time.Time{})
+	if err != nil {
+		t.Fatalf("pull: %v", err)
+	}
+	if got := messages(sigs); !reflect.DeepEqual(got, []string{"db timeout", "started", "not logfmt at all"}) {
+		t.Fatalf("messages = %v", got)
+	}
+	first := sigs[0]
+	if first.Severity != "error" || !first.Timestamp.Equal(time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)) {
+		t.Fatalf("first = %+v", first)
+	}
+	if first.Fields[core.FieldService] != "orders" || first.Fields["attempt"] != "3" {
+		t.Fatalf("fields = %v", first.Fields)
+	}
+	if _, ok := first.Fields["msg"]; ok {
+		t.Fatalf("message key kept in fields: %v", first.Fields)
+	}
+	if !sigs[1].Timestamp.Equal(time.Date(2026, 10, 19, 12, 0, 1, 0, time.UTC)) {
+		t.Fatalf("leading timestamp = %v", sigs[1].Timestamp)
+	}
+
+	if _, err := NewFileSource("bad", config.AgentFileSourceConfig{Path: logPath, Format: "xml"}); err == nil {
+		t.Fatal("unknown format accepted")
+	}
+}
+
+func TestKeyValue_Apply(t *testing.T) {
+	if NewKeyValue(config.AgentKeyValueConfig{}) != nil {
+		t.Fatal("disabled stage built")
+	}
+	kv := NewKeyValue(config.AgentKeyValueConfig{Enable: true, ServiceField: "app", SeverityField: "lvl"})
+	in := []core.Signal{
+		{Message: `lvl=warn msg="cache miss" app=cart ts=1760875200.5 key=user:42`, Fields: map[string]any{"stream": "stdout"}},
+		{Message: "java.lang.IllegalStateException: boom"},
+	}
+	out := kv.Apply(in)
+	got := out[0]
+	if got.Message != "cache miss" || got.Severity != "warn" {
+		t.Fatalf("got %+v", got)
+	}
+	if !got.Timestamp.Equal(time.Unix(1760875200, 5e8).UTC()) {
+		t.Fatalf("timestamp = %v", got.Timestamp)
+	}
+	want := map[string]any{"stream": "stdout", core.FieldService: "cart", "key": "user:42"}
+	if !reflect.DeepEqual(got.Fields, want) {
+		t.Fatalf("fields = %v", got.Fields)
+	}
+	if out[1].Message != "java.lang.IllegalStateException: boom" || out[1].Fields != nil {
+		t.Fatalf("non-logfmt changed: %+v", out[1])
+	}
+}
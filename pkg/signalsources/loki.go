@@ -7,9 +7,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/VersusControl/versus-incident/pkg/config"
@@ -19,16 +23,46 @@ import (
 // LokiSource pulls log entries from Grafana Loki using the
 // `query_range` HTTP endpoint with `direction=forward`. Loki returns
 // entries grouped by stream (label set); this source flattens them into
-// a single timestamp-sorted batch and tracks the maximum timestamp seen
-// as the cursor for the next tick.
+// a single batch and tracks the maximum timestamp seen as the cursor for
+// the next tick.
 //
 // Loki entry timestamps are nanoseconds since epoch encoded as a string.
+//
+// Loki accepts out-of-order writes within a stream and ingesters flush on
+// their own schedule, so an entry can become queryable after the cursor has
+// passed its timestamp. Each tick therefore re-reads an inclusive span below
+// the cursor and suppresses the entries already delivered inside it with a
+// persisted id set (TailDedup) — the convention the Elasticsearch, CloudWatch
+// Logs and SigNoz tails follow. Loki assigns entries no id, so the id is a hash
+// of the stream labels, the timestamp, the line and the entry's index among
+// the stream's entries at that timestamp, so identical lines logged in the
+// same nanosecond stay distinct. `reorder_window` sets the lateness part of
+// the span; the agent adds one catalog persist interval.
 type LokiSource struct {
 	name   string
 	cfg    config.AgentLokiSourceConfig
 	client *http.Client
+
+	// reorderWindow is the configured lateness budget.
+	reorderWindow time.Duration
+	// replaySpan is added to reorderWindow to size what a tick re-reads. See
+	// ApplyTailReplaySpan.
+	replaySpan time.Duration
+
+	// nowFn is the wall clock that upper-bounds the scan and clamps the
+	// cursor. Overridable in tests; nil ⇒ time.Now.
+	nowFn func() time.Time
+
+	// mu guards the tick so a concurrent Rewind cannot interleave and leave
+	// stale dedup state behind.
+	mu    sync.Mutex
+	dedup *TailDedup
 }
 
+// lokiMaxPagesPerTick caps the pages one tick walks so a span that keeps
+// returning full pages cannot hold a tick forever; the rest is read next tick.
+const lokiMaxPagesPerTick = 20
+
 // NewLokiSource validates config and returns a ready source.
 func NewLokiSource(name string, cfg config.AgentLokiSourceConfig) (*LokiSource, error) {
 	if cfg.Address == "" {
@@ -43,31 +77,178 @@ func NewLokiSource(name string, cfg config.AgentLokiSourceConfig) (*LokiSource,
 	tr := &http.Transport{
 		TLSClientConfig: &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify},
 	}
-	return &LokiSource{
+	src := &LokiSource{
 		name:   name,
 		cfg:    cfg,
 		client: &http.Client{Transport: tr, Timeout: 30 * time.Second},
-	}, nil
+	}
+	if cfg.ReorderWindow != "" {
+		if d, err := time.ParseDuration(cfg.ReorderWindow); err == nil && d > 0 {
+			src.reorderWindow = d
+		}
+	}
+	src.dedup = NewTailDedup(src.Name())
+	return src, nil
 }
 
 func (s *LokiSource) Name() string { return "loki:" + s.name }
 
-// Pull issues a `query_range` request with `start = since + 1ns` (Loki's
-// `start` is inclusive) and `end = now`. Results are returned forward
-// (oldest first) so the cursor at the end is the max timestamp seen.
+// SetTailReplaySpan widens what each tick re-reads so it also covers the
+// entries a killed process learned but never flushed. It implements
+// TailReplaySpanSetter.
+func (s *LokiSource) SetTailReplaySpan(span time.Duration) (time.Duration, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if span > 0 {
+		s.replaySpan = span
+	}
+	return s.reorderWindow, s.scanWindow()
+}
+
+// scanWindow is how far below the cursor a tick re-reads. Callers hold s.mu.
+func (s *LokiSource) scanWindow() time.Duration { return s.reorderWindow + s.replaySpan }
+
+// SetTailDedupBackend makes this source's boundary dedup set durable. It
+// implements TailDedupBinder.
+func (s *LokiSource) SetTailDedupBackend(b TailDedupBackend) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dedup.SetBackend(b)
+}
+
+// Rewind clears the boundary dedup set so a catalog clear makes this source
+// re-emit its whole window. It implements core.SourceRewinder.
+func (s *LokiSource) Rewind(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dedup.Clear(ctx)
+}
+
+// Commit makes the ids this source has delivered durable, after the worker has
+// flushed the entries they describe. It implements core.SourceCommitter.
+func (s *LokiSource) Commit(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dedup.Commit(ctx)
+}
+
+func (s *LokiSource) now() time.Time {
+	if s.nowFn != nil {
+		return s.nowFn().UTC()
+	}
+	return time.Now().UTC()
+}
+
+// Pull reads `[since - scanWindow, now]` forward (oldest first), or
+// `(since, now]` as `start = since + 1ns` when no window applies — Loki's
+// `start` is inclusive. A full page is followed by another starting at the
+// newest timestamp it held, so a span holding more than PageSize entries is
+// still walked to its end; entries a page boundary returns twice are dropped
+// within the tick, and entries delivered by earlier ticks by the dedup set.
+// Every page starts on a timestamp boundary, so an entry's index within its
+// (stream, timestamp) group is the same on every page and tick that reads it.
+// A full page whose entries all share one timestamp cannot be walked past by
+// timestamp; the walk then steps 1ns on and logs the entries it skipped.
 //
-// We do not paginate: Loki caps the result set at PageSize. If the cap
-// is hit we still advance the cursor to the last entry's timestamp so
-// the next tick continues from there. This is the standard pattern for
-// streaming pulls.
+// The cursor is the max timestamp seen, never below `since` and never past
+// `now` (ClampCursor).
 func (s *LokiSource) Pull(ctx context.Context, since time.Time) ([]core.Signal, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if since.After(now) {
+		since = now
+	}
 	cursor := since
+	lower := since
 	startNs := since.UTC().UnixNano() + 1
-	endNs := time.Now().UTC().UnixNano()
+	if window := s.scanWindow(); window > 0 && !since.IsZero() {
+		lower = since.Add(-window)
+		startNs = lower.UTC().UnixNano()
+	}
+	endNs := now.UnixNano()
 	if endNs <= startNs {
 		return nil, cursor, nil
 	}
 
+	if err := s.dedup.Load(ctx); err != nil {
+		log.Printf("agent: %s: loading the persisted dedup set failed: %v (this tick may re-emit up to one reorder window)", s.Name(), err)
+	}
+
+	var signals []core.Signal
+	var seen []DedupRow
+	tick := make(map[string]bool)
+	for page := 0; page < lokiMaxPagesPerTick; page++ {
+		streams, err := s.queryRange(ctx, startNs, endNs)
+		if err != nil {
+			return signals, ClampCursor(cursor, since, now), err
+		}
+		entries := 0
+		var pageMax int64
+		for _, stream := range streams {
+			labels := lokiLabelKey(stream.Stream)
+			var groupTS string
+			var groupIdx int
+			for _, entry := range stream.Values {
+				if len(entry) < 2 {
+					continue
+				}
+				entries++
+				tsNs, perr := strconv.ParseInt(entry[0], 10, 64)
+				if perr != nil {
+					continue
+				}
+				if tsNs > pageMax {
+					pageMax = tsNs
+				}
+				if entry[0] != groupTS {
+					groupTS, groupIdx = entry[0], 0
+				}
+				id := HashedRowID(labels, entry[0], entry[1], strconv.Itoa(groupIdx))
+				groupIdx++
+				if tick[id] {
+					continue
+				}
+				tick[id] = true
+				sig := s.signalFromEntry(stream, time.Unix(0, tsNs).UTC(), entry[1])
+				if sig.Timestamp.After(cursor) {
+					cursor = sig.Timestamp
+				}
+				seen = append(seen, DedupRow{ID: id, TS: sig.Timestamp})
+				if s.dedup.Has(id) {
+					continue
+				}
+				signals = append(signals, sig)
+			}
+		}
+		// A short page is the end of the span.
+		if entries < s.cfg.PageSize {
+			break
+		}
+		if pageMax <= startNs {
+			// Every entry shares one timestamp, so restarting at pageMax
+			// would return the same page again. Step past the nanosecond;
+			// the rest of its entries are not read.
+			log.Printf("agent: %s: more than page_size (%d) entries at timestamp %d; skipping the rest of that nanosecond (raise page_size to read them)", s.Name(), s.cfg.PageSize, startNs)
+			startNs++
+		} else {
+			startNs = pageMax
+		}
+		if startNs > endNs {
+			break
+		}
+	}
+	cursor = ClampCursor(cursor, since, now)
+
+	// Stage the ids in memory so the next tick does not re-deliver them; they
+	// become durable in Commit, after the worker has flushed the entries.
+	s.dedup.Stage(seen, lower)
+	return signals, cursor, nil
+}
+
+// queryRange issues one forward `query_range` request.
+func (s *LokiSource) queryRange(ctx context.Context, startNs, endNs int64) ([]lokiStreamResult, error) {
 	q := url.Values{}
 	q.Set("query", s.cfg.Query)
 	q.Set("start", strconv.FormatInt(startNs, 10))
@@ -78,71 +259,75 @@ func (s *LokiSource) Pull(ctx context.Context, since time.Time) ([]core.Signal,
 	u := s.cfg.Address + "/loki/api/v1/query_range?" + q.Encode()
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
 	if err != nil {
-		return nil, cursor, err
+		return nil, err
 	}
 	req.Header.Set("Accept", "application/json")
 	s.applyAuth(req)
 
 	resp, err := s.client.Do(req)
 	if err != nil {
-		return nil, cursor, err
+		return nil, err
 	}
 	defer resp.Body.Close()
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, cursor, err
+		return nil, err
 	}
 	if resp.StatusCode >= 400 {
-		return nil, cursor, fmt.Errorf("loki %s: %d %s", u, resp.StatusCode, truncate(string(body), 256))
+		return nil, fmt.Errorf("loki %s: %d %s", u, resp.StatusCode, truncate(string(body), 256))
 	}
 
 	var out lokiQueryRangeResponse
 	if err := json.Unmarshal(body, &out); err != nil {
-		return nil, cursor, fmt.Errorf("decode loki response: %w", err)
+		return nil, fmt.Errorf("decode loki response: %w", err)
 	}
+	return out.Data.Result, nil
+}
 
-	var signals []core.Signal
-	for _, stream := range out.Data.Result {
-		sev := ""
-		if s.cfg.SeverityField != "" {
-			sev = stream.Stream[s.cfg.SeverityField]
-		}
-		fields := make(map[string]interface{}, len(s.cfg.ExtraLabels))
-		for _, lbl := range s.cfg.ExtraLabels {
-			if v, ok := stream.Stream[lbl]; ok {
-				fields[lbl] = v
-			}
-		}
-		for _, entry := range stream.Values {
-			if len(entry) < 2 {
-				continue
-			}
-			tsNs, perr := strconv.ParseInt(entry[0], 10, 64)
-			if perr != nil {
-				continue
-			}
-			ts := time.Unix(0, tsNs).UTC()
-			if ts.After(cursor) {
-				cursor = ts
-			}
-			// Copy labels into Raw so downstream consumers can keep stream
-			// context without it bleeding into Fields by default.
-			raw := make(map[string]interface{}, len(stream.Stream)+1)
-			for k, v := range stream.Stream {
-				raw[k] = v
-			}
-			raw["message"] = entry[1]
-			signals = append(signals, core.Signal{
-				Source:    s.Name(),
-				Timestamp: ts,
-				Severity:  sev,
-				Message:   entry[1],
-				Fields:    fields,
-				Raw:       raw,
-			})
+func (s *LokiSource) signalFromEntry(stream lokiStreamResult, ts time.Time, line string) core.Signal {
+	sev := ""
+	if s.cfg.SeverityField != "" {
+		sev = stream.Stream[s.cfg.SeverityField]
+	}
+	fields := make(map[string]interface{}, len(s.cfg.ExtraLabels))
+	for _, lbl := range s.cfg.ExtraLabels {
+		if v, ok := stream.Stream[lbl]; ok {
+			fields[lbl] = v
 		}
 	}
-	return signals, cursor, nil
+	// Copy labels into Raw so downstream consumers can keep stream
+	// context without it bleeding into Fields by default.
+	raw := make(map[string]interface{}, len(stream.Stream)+1)
+	for k, v := range stream.Stream {
+		raw[k] = v
+	}
+	raw["message"] = line
+	return core.Signal{
+		Source:    s.Name(),
+		Timestamp: ts,
+		Severity:  sev,
+		Message:   line,
+		Fields:    fields,
+		Raw:       raw,
+	}
+}
+
+// lokiLabelKey renders a stream's label set in a stable order, the stream part
+// of an entry's id.
+func lokiLabelKey(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(labels[k])
+		b.WriteByte(',')
+	}
+	return b.String()
 }
 
 func (s *LokiSource) applyAuth(req *http.Request) {
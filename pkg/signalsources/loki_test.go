@@ -126,6 +126,38 @@ func TestLoki_AdvancesStartByOneNs(t *testing.T) {
 	}
 }
 
+// TestLoki_FullPageAtOneTimestampStepsPast checks a page filled by entries
+// sharing one nanosecond is stepped past instead of ending the tick, so the
+// entries after it are still read.
+func TestLoki_FullPageAtOneTimestampStepsPast(t *testing.T) {
+	base := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	store := &fakeTailStore{}
+	for i := 0; i < 5; i++ {
+		store.add(strconv.Itoa(i), "burst", base)
+	}
+	store.add("after", "after", base.Add(time.Second))
+
+	src, err := NewLokiSource("t", config.AgentLokiSourceConfig{
+		Address: newFakeLoki(t, store).URL, Query: `{app="api"}`, PageSize: 3,
+	})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	src.nowFn = func() time.Time { return base.Add(time.Minute) }
+
+	sigs, cur, err := src.Pull(context.Background(), base.Add(-time.Second))
+	if err != nil {
+		t.Fatalf("pull: %v", err)
+	}
+	got := messages(sigs)
+	if len(got) != 4 || got[3] != "after" {
+		t.Fatalf("got %v, want the first page of the burst then the entry after it", got)
+	}
+	if want := base.Add(time.Second); !cur.Equal(want) {
+		t.Errorf("cursor = %v, want %v", cur, want)
+	}
+}
+
 func TestLoki_BearerAuthOverridesBasic(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		auth := r.Header.Get("Authorization")
@@ -0,0 +1,318 @@
+package signalsources
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/VersusControl/versus-incident/pkg/core"
+)
+
+// Fields the multiline stage stamps on an assembled event.
+const (
+	// FieldMultilineText holds the whole event, its lines joined by "\n".
+	FieldMultilineText = "multiline.text"
+	// FieldMultilineLines is the number of lines joined.
+	FieldMultilineLines = "multiline.lines"
+	// FieldMultilineTruncated is set when max_lines or max_bytes cut the event.
+	FieldMultilineTruncated = "multiline.truncated"
+)
+
+// Defaults applied when the corresponding option is empty / zero.
+const (
+	defaultMultilineMaxLines     = 200
+	defaultMultilineMaxBytes     = 64 * 1024
+	defaultMultilineFlushTimeout = 5 * time.Second
+)
+
+// multilinePreset is one runtime's stack trace shape.
+type multilinePreset struct {
+	continuePattern string
+	// headerLast, when it matches an event's first line, makes the event's
+	// last line its message: a bare Python traceback names the exception at
+	// the end.
+	headerLast string
+}
+
+// qualifiedException matches a line naming a package-qualified exception
+// type, as Java and .NET print the cause of a trace.
+const qualifiedException = `^\s*(?:Unhandled exception\. )?(?:[A-Za-z_$][\w$]*\.)+[A-Za-z_$][\w$]*(?:Exception|Error|Throwable)\b`
+
+var multilinePresets = map[string]multilinePreset{
+	"java": {continuePattern: strings.Join([]string{
+		`^\s+at\s`,
+		`^\s+\.\.\. \d+ (?:more|common frames omitted)`,
+		`^\s*Caused by:`,
+		`^\s*Suppressed:`,
+		qualifiedException,
+	}, "|")},
+	"python": {
+		continuePattern: strings.Join([]string{
+			`^\s+`,
+			`^Traceback \(most recent call last\):`,
+			`^During handling of the above exception, another exception occurred:`,
+			`^The above exception was the direct cause of the following exception:`,
+			`^(?:[A-Za-z_]\w*\.)*[A-Za-z_]\w*(?:Error|Exception|Warning|Exit|Interrupt)\b`,
+		}, "|"),
+		headerLast: `^Traceback \(most recent call last\):`,
+	},
+	"go": {continuePattern: strings.Join([]string{
+		`^\s*$`, // the blank line between a panic and its goroutines
+		`^\t`,
+		`^goroutine \d+ \[`,
+		`^\[signal `,
+		`^created by `,
+		`^exit status \d+`,
+		`^[\w./*()-]+\(.*\)$`,
+	}, "|")},
+	"dotnet": {continuePattern: strings.Join([]string{
+		`^\s+at\s`,
+		`^\s*--- End of`,
+		`^\s*---> `,
+		qualifiedException,
+	}, "|")},
+}
+
+// multilineStreamFields identify the stream a line came from, so lines of
+// different files, containers or partitions that interleave in one pull are
+// never joined.
+var multilineStreamFields = []string{
+	FieldFilePath,
+	"k8s.pod.uid", "k8s.container.name",
+	"kafka.topic", "kafka.partition",
+	"hostname", "app_name", "procid",
+	"host.name", "service.instance.id",
+	core.FieldService,
+}
+
+// MultilineEnabled reports whether a source asks for the multiline stage.
+func MultilineEnabled(cfg config.AgentMultilineConfig) bool {
+	return cfg.Preset != "" || cfg.StartPattern != "" || cfg.ContinuePattern != ""
+}
+
+// Multiline joins the lines of multi-line events — stack traces above all —
+// into one signal per event. One instance serves one source, across ticks:
+// an event whose last line arrived at the end of a pull is held until
+// flush_timeout has passed since the stage received that line, so a trace
+// split between two pulls is still joined. The timeout runs on the clock
+// Apply is given, not on the lines' own timestamps, so a source reading a
+// backlog or a skewed clock neither splits nor strands an event. Held lines
+// are in memory only; the worker keeps the source's commit behind them (Held)
+// and completes them on shutdown (Flush).
+//
+// The assembled signal is the event's first line — its Message, Timestamp,
+// Severity and Fields — so it clusters by the exception header. The whole
+// event is kept in Fields under FieldMultilineText.
+type Multiline struct {
+	start      *regexp.Regexp
+	cont       *regexp.Regexp
+	headerLast *regexp.Regexp
+	maxLines   int
+	maxBytes   int
+	timeout    time.Duration
+
+	mu      sync.Mutex
+	pending map[string]*multilineEvent // by stream
+}
+
+type multilineEvent struct {
+	first     core.Signal
+	lines     []string
+	bytes     int
+	truncated bool
+	// lastSeen is when the stage received the event's latest line.
+	lastSeen time.Time
+}
+
+// NewMultiline validates the configuration and returns an empty stage.
+func NewMultiline(cfg config.AgentMultilineConfig) (*Multiline, error) {
+	m := &Multiline{
+		maxLines: cfg.MaxLines,
+		maxBytes: cfg.MaxBytes,
+		timeout:  defaultMultilineFlushTimeout,
+		pending:  make(map[string]*multilineEvent),
+	}
+	if m.maxLines <= 0 {
+		m.maxLines = defaultMultilineMaxLines
+	}
+	if m.maxBytes <= 0 {
+		m.maxBytes = defaultMultilineMaxBytes
+	}
+	if cfg.FlushTimeout != "" {
+		d, err := time.ParseDuration(cfg.FlushTimeout)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("multiline: invalid flush_timeout %q", cfg.FlushTimeout)
+		}
+		m.timeout = d
+	}
+
+	var conts []string
+	if cfg.Preset != "" {
+		p, ok := multilinePresets[cfg.Preset]
+		if !ok {
+			return nil, fmt.Errorf("multiline: unknown preset %q (want java, python, go or dotnet)", cfg.Preset)
+		}
+		conts = append(conts, p.continuePattern)
+		if p.headerLast != "" {
+			m.headerLast = regexp.MustCompile(p.headerLast)
+		}
+	}
+	if cfg.ContinuePattern != "" {
+		conts = append(conts, cfg.ContinuePattern)
+	}
+	if len(conts) > 0 {
+		re, err := regexp.Compile("(?:" + strings.Join(conts, ")|(?:") + ")")
+		if err != nil {
+			return nil, fmt.Errorf("multiline: continue_pattern: %w", err)
+		}
+		m.cont = re
+	}
+	if cfg.StartPattern != "" {
+		re, err := regexp.Compile(cfg.StartPattern)
+		if err != nil {
+			return nil, fmt.Errorf("multiline: start_pattern: %w", err)
+		}
+		m.start = re
+	}
+	if m.start == nil && m.cont == nil {
+		return nil, fmt.Errorf("multiline: set a preset, start_pattern or continue_pattern")
+	}
+	return m, nil
+}
+
+// Apply feeds one pull's signals through the stage and returns the events
+// it completed. now is the time the lines are received. Events still open
+// are held for the next call, and flushed once now is flush_timeout past the
+// call that added their last line.
+func (m *Multiline) Apply(signals []core.Signal, now time.Time) []core.Signal {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var out []core.Signal
+	for _, sig := range signals {
+		key := multilineStream(sig)
+		ev := m.pending[key]
+		if ev != nil && m.continues(sig.Message) {
+			ev.add(sig, now, m.maxLines, m.maxBytes)
+			continue
+		}
+		if ev != nil {
+			out = append(out, m.assemble(ev))
+		}
+		ev = &multilineEvent{first: sig}
+		ev.add(sig, now, m.maxLines, m.maxBytes)
+		m.pending[key] = ev
+	}
+
+	var done []string
+	for key, ev := range m.pending {
+		if now.Sub(ev.lastSeen) >= m.timeout {
+			done = append(done, key)
+		}
+	}
+	return append(out, m.emit(done)...)
+}
+
+// Flush completes every event still held, whatever its age, and returns them.
+// The worker calls it on shutdown, so an event whose last line arrived just
+// before the stop is learned rather than dropped with the process.
+func (m *Multiline) Flush() []core.Signal {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keys := make([]string, 0, len(m.pending))
+	for key := range m.pending {
+		keys = append(keys, key)
+	}
+	return m.emit(keys)
+}
+
+// Held returns the first line of every event still held, as the source
+// delivered it. The worker hands them to a core.SourceCommitHolder so the
+// source's commit stops short of them.
+func (m *Multiline) Held() []core.Signal {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	held := make([]core.Signal, 0, len(m.pending))
+	for _, ev := range m.pending {
+		held = append(held, ev.first)
+	}
+	return held
+}
+
+// emit assembles and forgets the pending events under keys. It flushes in
+// first-line order so the output stays chronological per stream, whatever
+// the map order. m.mu must be held.
+func (m *Multiline) emit(keys []string) []core.Signal {
+	sort.Slice(keys, func(i, j int) bool {
+		return m.pending[keys[i]].first.Timestamp.Before(m.pending[keys[j]].first.Timestamp)
+	})
+	out := make([]core.Signal, 0, len(keys))
+	for _, key := range keys {
+		out = append(out, m.assemble(m.pending[key]))
+		delete(m.pending, key)
+	}
+	return out
+}
+
+func (m *Multiline) continues(line string) bool {
+	if m.start != nil && m.start.MatchString(line) {
+		return false
+	}
+	if m.cont != nil && m.cont.MatchString(line) {
+		return true
+	}
+	return m.start != nil
+}
+
+func (ev *multilineEvent) add(sig core.Signal, now time.Time, maxLines, maxBytes int) {
+	ev.lastSeen = now
+	if ev.truncated {
+		return
+	}
+	if len(ev.lines) >= maxLines || ev.bytes+len(sig.Message) > maxBytes {
+		ev.truncated = true
+		return
+	}
+	ev.lines = append(ev.lines, sig.Message)
+	ev.bytes += len(sig.Message) + 1
+}
+
+// assemble turns an event into its signal. A single line passes through
+// untouched.
+func (m *Multiline) assemble(ev *multilineEvent) core.Signal {
+	sig := ev.first
+	if len(ev.lines) == 1 && !ev.truncated {
+		return sig
+	}
+	fields := make(map[string]any, len(sig.Fields)+3)
+	for k, v := range sig.Fields {
+		fields[k] = v
+	}
+	fields[FieldMultilineText] = strings.Join(ev.lines, "\n")
+	fields[FieldMultilineLines] = len(ev.lines)
+	if ev.truncated {
+		fields[FieldMultilineTruncated] = true
+	}
+	sig.Fields = fields
+	if m.headerLast != nil && m.headerLast.MatchString(ev.lines[0]) && len(ev.lines) > 1 {
+		sig.Message = ev.lines[len(ev.lines)-1]
+	}
+	return sig
+}
+
+// multilineStream keys a signal by its source and the identity fields it
+// carries.
+func multilineStream(sig core.Signal) string {
+	var b strings.Builder
+	b.WriteString(sig.Source)
+	for _, f := range multilineStreamFields {
+		if v, ok := sig.Fields[f]; ok {
+			fmt.Fprintf(&b, "\x00%s=%v", f, v)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,210 @@
+package signalsources
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/VersusControl/versus-incident/pkg/core"
+)
+
+// lineSignals turns lines into signals one millisecond apart, starting at t0.
+func lineSignals(t0 time.Time, fields map[string]any, lines ...string) []core.Signal {
+	out := make([]core.Signal, len(lines))
+	for i, l := range lines {
+		out[i] = core.Signal{Source: "file:app", Timestamp: t0.Add(time.Duration(i) * time.Millisecond), Message: l, Fields: fields}
+	}
+	return out
+}
+
+// drain feeds signals through m, then advances the clock past flush_timeout
+// so the events still held are emitted too.
+func drain(m *Multiline, signals []core.Signal) []core.Signal {
+	now := time.Now()
+	out := m.Apply(signals, now)
+	return append(out, m.Apply(nil, now.Add(m.timeout))...)
+}
+
+func newTestMultiline(t *testing.T, cfg config.AgentMultilineConfig) *Multiline {
+	t.Helper()
+	m, err := NewMultiline(cfg)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	return m
+}
+
+func TestMultiline_JavaPreset(t *testing.T) {
+	m := newTestMultiline(t, config.AgentMultilineConfig{Preset: "java"})
+	t0 := time.Now().Add(-time.Minute)
+	got := drain(m, lineSignals(t0, nil,
+		"2026-10-19 12:00:00 ERROR request failed",
+		"java.lang.IllegalStateException: pool exhausted",
+		"\tat com.acme.Pool.take(Pool.java:42)",
+		"\tat com.acme.Handler.run(Handler.java:7)",
+		"Caused by: java.net.SocketTimeoutException: connect timed out",
+		"\t... 12 more",
+		"2026-10-19 12:00:01 INFO recovered",
+	))
+
+	if len(got) != 2 {
+		t.Fatalf("events = %d, want 2: %v", len(got), messages(got))
+	}
+	if got[0].Message != "2026-10-19 12:00:00 ERROR request failed" {
+		t.Fatalf("header = %q", got[0].Message)
+	}
+	if n := got[0].Fields[FieldMultilineLines]; n != 6 {
+		t.Fatalf("lines = %v", n)
+	}
+	if text, _ := got[0].Fields[FieldMultilineText].(string); !strings.Contains(text, "Caused by: java.net.SocketTimeoutException") {
+		t.Fatalf("text = %q", text)
+	}
+	if got[1].Message != "2026-10-19 12:00:01 INFO recovered" || got[1].Fields != nil {
+		t.Fatalf("single line changed: %+v", got[1])
+	}
+}
+
+func TestMultiline_PythonHeaderIsException(t *testing.T) {
+	m := newTestMultiline(t, config.AgentMultilineConfig{Preset: "python"})
+	got := drain(m, lineSignals(time.Now().Add(-time.Minute), nil,
+		"Traceback (most recent call last):",
+		`  File "app.py", line 3, in <module>`,
+		"    main()",
+		"KeyError: 'user_id'",
+	))
+	if len(got) != 1 || got[0].Message != "KeyError: 'user_id'" {
+		t.Fatalf("got %v", messages(got))
+	}
+}
+
+func TestMultiline_GoPanic(t *testing.T) {
+	m := newTestMultiline(t, config.AgentMultilineConfig{Preset: "go"})
+	got := drain(m, lineSignals(time.Now().Add(-time.Minute), nil,
+		"panic: runtime error: invalid memory address or nil pointer dereference",
+		"[signal SIGSEGV: segmentation violation code=0x1 addr=0x0 pc=0x4a2b3c]",
+		"",
+		"goroutine 1 [running]:",
+		"main.handler(0x0)",
+		"\t/src/main.go:12 +0x1c",
+		"exit status 2",
+	))
+	if len(got) != 1 || got[0].Fields[FieldMultilineLines] != 7 {
+		t.Fatalf("got %v", messages(got))
+	}
+}
+
+func TestMultiline_StartPattern(t *testing.T) {
+	m := newTestMultiline(t, config.AgentMultilineConfig{StartPattern: `^\d{4}-\d{2}-\d{2} `})
+	got := drain(m, lineSignals(time.Now().Add(-time.Minute), nil,
+		"2026-10-19 12:00:00 ERROR query failed:",
+		"SELECT *",
+		"  FROM orders",
+		"2026-10-19 12:00:01 INFO ok",
+	))
+	if len(got) != 2 || got[0].Fields[FieldMultilineLines] != 3 {
+		t.Fatalf("got %v", messages(got))
+	}
+}
+
+// TestMultiline_HeldAcrossPulls checks a trace split between two pulls is
+// joined, and only flushed once flush_timeout has passed since its last line
+// was received.
+func TestMultiline_HeldAcrossPulls(t *testing.T) {
+	m := newTestMultiline(t, config.AgentMultilineConfig{Preset: "java", FlushTimeout: "5s"})
+	now := time.Now()
+	first := lineSignals(now.Add(-time.Second), nil,
+		"ERROR boom",
+		"java.lang.RuntimeException: boom",
+	)
+	if got := m.Apply(first, now); len(got) != 0 {
+		t.Fatalf("flushed early: %v", messages(got))
+	}
+	rest := lineSignals(now, nil, "\tat com.acme.Main.main(Main.java:1)")
+	if got := m.Apply(rest, now.Add(2*time.Second)); len(got) != 0 {
+		t.Fatalf("flushed before timeout: %v", messages(got))
+	}
+	if got := m.Apply(nil, now.Add(6*time.Second)); len(got) != 0 {
+		t.Fatalf("flushed before timeout since the last line: %v", messages(got))
+	}
+	got := m.Apply(nil, now.Add(7*time.Second))
+	if len(got) != 1 || got[0].Fields[FieldMultilineLines] != 3 {
+		t.Fatalf("got %v", messages(got))
+	}
+}
+
+// TestMultiline_BacklogHeldAcrossPulls checks the timeout runs on arrival,
+// not on the lines' timestamps: lines stamped an hour ago, as a lagging source
+// or a replayed backlog delivers them, are still joined across two pulls, and
+// a line stamped in the future is flushed on time.
+func TestMultiline_BacklogHeldAcrossPulls(t *testing.T) {
+	m := newTestMultiline(t, config.AgentMultilineConfig{Preset: "java", FlushTimeout: "5s"})
+	now := time.Now()
+	old := now.Add(-time.Hour)
+	if got := m.Apply(lineSignals(old, nil, "ERROR boom", "java.lang.RuntimeException: boom"), now); len(got) != 0 {
+		t.Fatalf("backlog flushed in the pull that read it: %v", messages(got))
+	}
+	if got := m.Apply(lineSignals(old.Add(time.Second), nil, "\tat com.acme.Main.main(Main.java:1)"), now.Add(time.Second)); len(got) != 0 {
+		t.Fatalf("flushed before timeout: %v", messages(got))
+	}
+	got := m.Apply(nil, now.Add(6*time.Second))
+	if len(got) != 1 || got[0].Fields[FieldMultilineLines] != 3 {
+		t.Fatalf("got %v, want one event of 3 lines", messages(got))
+	}
+
+	if got := m.Apply(lineSignals(now.Add(time.Hour), nil, "ERROR skewed"), now); len(got) != 0 {
+		t.Fatalf("flushed early: %v", messages(got))
+	}
+	if got := m.Apply(nil, now.Add(5*time.Second)); len(got) != 1 || len(m.Held()) != 0 {
+		t.Fatalf("future-stamped line not flushed after the timeout: %v, held %d", messages(got), len(m.Held()))
+	}
+}
+
+func TestMultiline_StreamsStayApart(t *testing.T) {
+	m := newTestMultiline(t, config.AgentMultilineConfig{Preset: "java"})
+	a := map[string]any{FieldFilePath: "/var/log/a.log"}
+	b := map[string]any{FieldFilePath: "/var/log/b.log"}
+	t0 := time.Now().Add(-time.Minute)
+	in := []core.Signal{
+		lineSignals(t0, a, "ERROR in a")[0],
+		lineSignals(t0, b, "ERROR in b")[0],
+		lineSignals(t0, a, "\tat a.A.run(A.java:1)")[0],
+		lineSignals(t0, b, "\tat b.B.run(B.java:1)")[0],
+	}
+	got := drain(m, in)
+	if len(got) != 2 {
+		t.Fatalf("got %v", messages(got))
+	}
+	for _, sig := range got {
+		if sig.Fields[FieldMultilineLines] != 2 {
+			t.Fatalf("%q joined %v lines", sig.Message, sig.Fields[FieldMultilineLines])
+		}
+	}
+}
+
+func TestMultiline_MaxLinesTruncates(t *testing.T) {
+	m := newTestMultiline(t, config.AgentMultilineConfig{Preset: "java", MaxLines: 2})
+	got := drain(m, lineSignals(time.Now().Add(-time.Minute), nil,
+		"ERROR deep",
+		"\tat a.A.a(A.java:1)",
+		"\tat a.A.b(A.java:2)",
+		"\tat a.A.c(A.java:3)",
+	))
+	if len(got) != 1 || got[0].Fields[FieldMultilineLines] != 2 || got[0].Fields[FieldMultilineTruncated] != true {
+		t.Fatalf("got %+v", got)
+	}
+}
+
+func TestNewMultiline_Validation(t *testing.T) {
+	for _, cfg := range []config.AgentMultilineConfig{
+		{Preset: "ruby"},
+		{StartPattern: "("},
+		{ContinuePattern: "[", Preset: "java"},
+		{Preset: "go", FlushTimeout: "soon"},
+		{MaxLines: 5},
+	} {
+		if _, err := NewMultiline(cfg); err == nil {
+			t.Errorf("%+v: want error", cfg)
+		}
+	}
+}
@@ -0,0 +1,537 @@
+package signalsources
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/VersusControl/versus-incident/pkg/core"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	_ "google.golang.org/grpc/encoding/gzip" // accept gzip-compressed OTLP/gRPC exports
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// OTLPSource receives OpenTelemetry logs pushed over OTLP/HTTP and OTLP/gRPC.
+// It is the one source that does not poll a backend: exporters — an
+// OpenTelemetry SDK or Collector — send ExportLogsServiceRequest to it, the
+// records are buffered in a bounded queue, and each tick's Pull drains it.
+//
+// Behavior:
+//
+//   - Nothing binds until Listen (core.SourceListener), which the worker calls
+//     once before its first tick.
+//   - An export is queued whole or refused whole. When it does not fit, the
+//     sender gets HTTP 429 or gRPC RESOURCE_EXHAUSTED with a retry delay, the
+//     OTLP signal to back off and resend, so a slow agent delays records
+//     instead of dropping them.
+//   - The Pull `since` argument is ignored; the queue is the position. The
+//     returned cursor is just `time.Now()` so the worker has something to log.
+//   - With queue_dir set, a record stays on disk until the catalog flush that
+//     learned it (core.SourceCommitter), so a restart re-delivers rather than
+//     loses it.
+type OTLPSource struct {
+	name  string
+	cfg   config.AgentOTLPSourceConfig
+	queue *receiverQueue
+
+	mu        sync.Mutex
+	listening bool
+	httpAddr  net.Addr
+	grpcAddr  net.Addr
+}
+
+// Defaults applied when the corresponding option is empty / zero.
+const (
+	defaultOTLPQueueSize      = 10000
+	defaultOTLPMaxPullRecords = 1000
+)
+
+// otlpMaxRequestBytes caps one export, after decompression — the gRPC default
+// receive limit, applied to OTLP/HTTP as well.
+const otlpMaxRequestBytes = 4 << 20
+
+// otlpRetryAfter is the back-off a refused exporter is told to wait. The queue
+// drains once per tick, so retrying sooner mostly gets refused again.
+const otlpRetryAfter = 10 * time.Second
+
+// NewOTLPSource validates configuration. It neither binds a port nor opens the
+// queue file; Listen does both.
+func NewOTLPSource(name string, cfg config.AgentOTLPSourceConfig) (*OTLPSource, error) {
+	if cfg.HTTPListen == "" && cfg.GRPCListen == "" {
+		return nil, fmt.Errorf("otlp source %q: http_listen or grpc_listen is required", name)
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaultOTLPQueueSize
+	}
+	if cfg.MaxPullRecords <= 0 {
+		cfg.MaxPullRecords = defaultOTLPMaxPullRecords
+	}
+	return &OTLPSource{
+		name:  name,
+		cfg:   cfg,
+		queue: newReceiverQueue(cfg.QueueSize, cfg.QueueDir, "otlp", name),
+	}, nil
+}
+
+func (s *OTLPSource) Name() string { return "otlp:" + s.name }
+
+// Listen opens the queue and binds the configured endpoints, serving them until
+// ctx is done. It implements core.SourceListener.
+func (s *OTLPSource) Listen(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listening {
+		return fmt.Errorf("otlp source %q: already listening", s.name)
+	}
+	if err := s.queue.open(); err != nil {
+		return fmt.Errorf("otlp source %q: open queue: %w", s.name, err)
+	}
+
+	var httpLn, grpcLn net.Listener
+	var err error
+	if s.cfg.HTTPListen != "" {
+		if httpLn, err = net.Listen("tcp", s.cfg.HTTPListen); err != nil {
+			return fmt.Errorf("otlp source %q: http_listen: %w", s.name, err)
+		}
+	}
+	if s.cfg.GRPCListen != "" {
+		if grpcLn, err = net.Listen("tcp", s.cfg.GRPCListen); err != nil {
+			if httpLn != nil {
+				httpLn.Close()
+			}
+			return fmt.Errorf("otlp source %q: grpc_listen: %w", s.name, err)
+		}
+	}
+
+	if httpLn != nil {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/v1/logs", s.serveHTTP)
+		srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+		go func() {
+			if err := srv.Serve(httpLn); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Printf("otlp source %s: http: %v", s.name, err)
+			}
+		}()
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_ = srv.Shutdown(shutdownCtx)
+		}()
+		s.httpAddr = httpLn.Addr()
+		log.Printf("otlp source %s: OTLP/HTTP listening on %s", s.name, s.httpAddr)
+	}
+	if grpcLn != nil {
+		srv := grpc.NewServer(grpc.MaxRecvMsgSize(otlpMaxRequestBytes))
+		collogspb.RegisterLogsServiceServer(srv, &otlpLogsService{src: s})
+		go func() {
+			if err := srv.Serve(grpcLn); err != nil {
+				log.Printf("otlp source %s: grpc: %v", s.name, err)
+			}
+		}()
+		go func() {
+			<-ctx.Done()
+			stopped := make(chan struct{})
+			go func() { srv.GracefulStop(); close(stopped) }()
+			select {
+			case <-stopped:
+			case <-time.After(5 * time.Second):
+				srv.Stop()
+			}
+		}()
+		s.grpcAddr = grpcLn.Addr()
+		log.Printf("otlp source %s: OTLP/gRPC listening on %s", s.name, s.grpcAddr)
+	}
+	s.listening = true
+	return nil
+}
+
+// Pull drains up to max_pull_records queued records, oldest first.
+func (s *OTLPSource) Pull(_ context.Context, _ time.Time) ([]core.Signal, time.Time, error) {
+	cursor := time.Now().UTC()
+	sigs, err := s.queue.pop(s.cfg.MaxPullRecords, s.Name())
+	if err != nil {
+		return sigs, cursor, fmt.Errorf("otlp source %q: %w", s.name, err)
+	}
+	return sigs, cursor, nil
+}
+
+// Commit releases the records handed out by earlier Pulls from the disk queue,
+// now that the worker has made them durable. It implements
+// core.SourceCommitter; it is a no-op for the in-memory queue.
+func (s *OTLPSource) Commit(_ context.Context) error {
+	if err := s.queue.commit(); err != nil {
+		return fmt.Errorf("otlp source %q: %w", s.name, err)
+	}
+	return nil
+}
+
+// HoldCommit keeps the next Commit from releasing the first held record or
+// any after it. It implements core.SourceCommitHolder.
+func (s *OTLPSource) HoldCommit(held []core.Signal) {
+	s.queue.hold(held)
+}
+
+// authorized checks an Authorization header value against auth_token.
+func (s *OTLPSource) authorized(header string) bool {
+	if s.cfg.AuthToken == "" {
+		return true
+	}
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.cfg.AuthToken)) == 1
+}
+
+// accept maps and queues one export request.
+func (s *OTLPSource) accept(req *collogspb.ExportLogsServiceRequest) error {
+	return s.queue.push(otlpRequestToSignals(s.Name(), req, time.Now().UTC()))
+}
+
+// serveHTTP implements OTLP/HTTP for logs: a POST of ExportLogsServiceRequest
+// as binary protobuf or JSON, optionally gzip-encoded, answered in the same
+// encoding.
+func (s *OTLPSource) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	var unmarshal func([]byte, proto.Message) error
+	var marshal func(proto.Message) ([]byte, error)
+	switch mediaType {
+	case "application/x-protobuf":
+		unmarshal, marshal = proto.Unmarshal, proto.Marshal
+	case "application/json":
+		unmarshal = unmarshalOTLPJSON
+		marshal = protojson.Marshal
+	default:
+		http.Error(w, "unsupported content type (want application/x-protobuf or application/json)", http.StatusUnsupportedMediaType)
+		return
+	}
+	reply := func(code int, msg proto.Message) {
+		body, err := marshal(msg)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", mediaType)
+		w.WriteHeader(code)
+		_, _ = w.Write(body)
+	}
+	fail := func(code int, msg string) {
+		reply(code, &spb.Status{Code: int32(httpToGRPCCode(code)), Message: msg})
+	}
+
+	if !s.authorized(r.Header.Get("Authorization")) {
+		fail(http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var body io.Reader = http.MaxBytesReader(w, r.Body, otlpMaxRequestBytes)
+	switch r.Header.Get("Content-Encoding") {
+	case "", "identity":
+	case "gzip":
+		zr, err := gzip.NewReader(body)
+		if err != nil {
+			fail(http.StatusBadRequest, "invalid gzip body")
+			return
+		}
+		defer zr.Close()
+		body = zr
+	default:
+		fail(http.StatusUnsupportedMediaType, "unsupported content encoding")
+		return
+	}
+	raw, err := io.ReadAll(io.LimitReader(body, otlpMaxRequestBytes+1))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			fail(http.StatusRequestEntityTooLarge, "request too large")
+			return
+		}
+		fail(http.StatusBadRequest, "read body: "+err.Error())
+		return
+	}
+	if len(raw) > otlpMaxRequestBytes {
+		fail(http.StatusRequestEntityTooLarge, "request too large")
+		return
+	}
+	req := &collogspb.ExportLogsServiceRequest{}
+	if err := unmarshal(raw, req); err != nil {
+		fail(http.StatusBadRequest, "invalid ExportLogsServiceRequest: "+err.Error())
+		return
+	}
+	if err := s.accept(req); err != nil {
+		if errors.Is(err, errReceiverQueueFull) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(otlpRetryAfter/time.Second)))
+			fail(http.StatusTooManyRequests, "agent is behind; retry later")
+			return
+		}
+		log.Printf("otlp source %s: queue: %v", s.name, err)
+		fail(http.StatusServiceUnavailable, "queue unavailable")
+		return
+	}
+	reply(http.StatusOK, &collogspb.ExportLogsServiceResponse{})
+}
+
+// unmarshalOTLPJSON decodes OTLP/JSON. It differs from protojson in one place:
+// OTLP/JSON writes traceId and spanId as hex, not base64, so those are
+// re-encoded before decoding.
+func unmarshalOTLPJSON(raw []byte, msg proto.Message) error {
+	var doc any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return err
+	}
+	otlpHexIDsToBase64(doc)
+	fixed, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(fixed, msg)
+}
+
+func otlpHexIDsToBase64(v any) {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, e := range t {
+			switch k {
+			case "traceId", "spanId", "trace_id", "span_id":
+				if str, ok := e.(string); ok {
+					if id, err := hex.DecodeString(str); err == nil {
+						t[k] = base64.StdEncoding.EncodeToString(id)
+					}
+				}
+			default:
+				otlpHexIDsToBase64(e)
+			}
+		}
+	case []any:
+		for _, e := range t {
+			otlpHexIDsToBase64(e)
+		}
+	}
+}
+
+// httpToGRPCCode picks the status code carried in an OTLP/HTTP error body.
+func httpToGRPCCode(code int) codes.Code {
+	switch code {
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	}
+	return codes.InvalidArgument
+}
+
+// otlpLogsService is the OTLP/gRPC LogsService of one OTLPSource.
+type otlpLogsService struct {
+	collogspb.UnimplementedLogsServiceServer
+	src *OTLPSource
+}
+
+func (g *otlpLogsService) Export(ctx context.Context, req *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	var header string
+	if v := md.Get("authorization"); len(v) > 0 {
+		header = v[0]
+	}
+	if !g.src.authorized(header) {
+		return nil, status.Error(codes.Unauthenticated, "unauthorized")
+	}
+	if err := g.src.accept(req); err != nil {
+		if errors.Is(err, errReceiverQueueFull) {
+			// OTLP exporters only retry RESOURCE_EXHAUSTED when the status
+			// carries RetryInfo; without it the batch is dropped.
+			st, derr := status.New(codes.ResourceExhausted, "agent is behind; retry later").
+				WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(otlpRetryAfter)})
+			if derr != nil {
+				return nil, status.Error(codes.ResourceExhausted, "agent is behind; retry later")
+			}
+			return nil, st.Err()
+		}
+		log.Printf("otlp source %s: queue: %v", g.src.name, err)
+		return nil, status.Error(codes.Unavailable, "queue unavailable")
+	}
+	return &collogspb.ExportLogsServiceResponse{}, nil
+}
+
+// otlpRequestToSignals flattens an export into one Signal per log record.
+//
+// Fields holds the resource attributes, then the record's attributes (which
+// win on a clash) under their OTLP keys, e.g. "service.name" or
+// "http.route", plus "severity_number" and "otel.scope.name" when set.
+// Fields[core.FieldService] is the resource's service.name, falling back to
+// the record's. Severity is severity_text, or the short name of
+// severity_number when the text is empty.
+func otlpRequestToSignals(source string, req *collogspb.ExportLogsServiceRequest, now time.Time) []core.Signal {
+	var out []core.Signal
+	for _, rl := range req.GetResourceLogs() {
+		resAttrs := otlpAttributes(rl.GetResource().GetAttributes())
+		for _, sl := range rl.GetScopeLogs() {
+			scope := sl.GetScope().GetName()
+			for _, rec := range sl.GetLogRecords() {
+				out = append(out, otlpRecordToSignal(source, resAttrs, scope, rec, now))
+			}
+		}
+	}
+	return out
+}
+
+func otlpRecordToSignal(source string, resAttrs map[string]any, scope string, rec *logspb.LogRecord, now time.Time) core.Signal {
+	fields := make(map[string]any, len(resAttrs)+len(rec.GetAttributes())+3)
+	for k, v := range resAttrs {
+		fields[k] = v
+	}
+	for k, v := range otlpAttributes(rec.GetAttributes()) {
+		fields[k] = v
+	}
+	if svc, _ := resAttrs["service.name"].(string); svc != "" {
+		fields[core.FieldService] = svc
+	} else if svc, _ := fields["service.name"].(string); svc != "" {
+		fields[core.FieldService] = svc
+	}
+	if scope != "" {
+		fields["otel.scope.name"] = scope
+	}
+	if n := rec.GetSeverityNumber(); n != logspb.SeverityNumber_SEVERITY_NUMBER_UNSPECIFIED {
+		fields["severity_number"] = int64(n)
+	}
+	if tid := rec.GetTraceId(); len(tid) > 0 {
+		fields["trace_id"] = hex.EncodeToString(tid)
+	}
+	if sid := rec.GetSpanId(); len(sid) > 0 {
+		fields["span_id"] = hex.EncodeToString(sid)
+	}
+
+	ts := now
+	if n := rec.GetTimeUnixNano(); n > 0 {
+		ts = time.Unix(0, int64(n)).UTC()
+	} else if n := rec.GetObservedTimeUnixNano(); n > 0 {
+		ts = time.Unix(0, int64(n)).UTC()
+	}
+	severity := rec.GetSeverityText()
+	if severity == "" {
+		severity = otlpSeverityName(rec.GetSeverityNumber())
+	}
+	body := otlpValue(rec.GetBody())
+
+	raw := map[string]any{"body": body}
+	if rec.GetEventName() != "" {
+		raw["event_name"] = rec.GetEventName()
+	}
+	return core.Signal{
+		Source:    source,
+		Timestamp: ts,
+		Severity:  severity,
+		Message:   otlpMessage(body, rec.GetEventName()),
+		Fields:    fields,
+		Raw:       raw,
+	}
+}
+
+// otlpMessage renders a record's body as the signal message. A structured
+// body (a map) contributes its "message" or "msg" entry when it has one and is
+// JSON-encoded otherwise; an empty body falls back to the event name.
+func otlpMessage(body any, eventName string) string {
+	switch b := body.(type) {
+	case nil:
+		return eventName
+	case string:
+		return b
+	case map[string]any:
+		for _, k := range []string{"message", "msg"} {
+			if m, ok := b[k].(string); ok && m != "" {
+				return m
+			}
+		}
+	}
+	enc, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Sprint(body)
+	}
+	return string(enc)
+}
+
+// otlpSeverityName maps a SeverityNumber to its short name (the OpenTelemetry
+// log data model ranges: 1-4 TRACE … 21-24 FATAL).
+func otlpSeverityName(n logspb.SeverityNumber) string {
+	switch {
+	case n <= 0:
+		return ""
+	case n <= 4:
+		return "TRACE"
+	case n <= 8:
+		return "DEBUG"
+	case n <= 12:
+		return "INFO"
+	case n <= 16:
+		return "WARN"
+	case n <= 20:
+		return "ERROR"
+	}
+	return "FATAL"
+}
+
+func otlpAttributes(kvs []*commonpb.KeyValue) map[string]any {
+	out := make(map[string]any, len(kvs))
+	for _, kv := range kvs {
+		if kv.GetKey() == "" {
+			continue
+		}
+		out[kv.GetKey()] = otlpValue(kv.GetValue())
+	}
+	return out
+}
+
+// otlpValue converts an AnyValue into the plain Go value Signal.Fields holds.
+func otlpValue(v *commonpb.AnyValue) any {
+	switch x := v.GetValue().(type) {
+	case *commonpb.AnyValue_StringValue:
+		return x.StringValue
+	case *commonpb.AnyValue_BoolValue:
+		return x.BoolValue
+	case *commonpb.AnyValue_IntValue:
+		return x.IntValue
+	case *commonpb.AnyValue_DoubleValue:
+		return x.DoubleValue
+	case *commonpb.AnyValue_BytesValue:
+		return hex.EncodeToString(x.BytesValue)
+	case *commonpb.AnyValue_ArrayValue:
+		vals := x.ArrayValue.GetValues()
+		out := make([]any, len(vals))
+		for i, e := range vals {
+			out[i] = otlpValue(e)
+		}
+		return out
+	case *commonpb.AnyValue_KvlistValue:
+		return otlpAttributes(x.KvlistValue.GetValues())
+	}
+	return nil
+}
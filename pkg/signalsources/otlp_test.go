@@ -0,0 +1,259 @@
+package signalsources
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/VersusControl/versus-incident/pkg/core"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func otlpStr(s string) *commonpb.AnyValue {
+	return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: s}}
+}
+
+// otlpExport builds a request from one service with the given records.
+func otlpExport(service string, recs ...*logspb.LogRecord) *collogspb.ExportLogsServiceRequest {
+	return &collogspb.ExportLogsServiceRequest{ResourceLogs: []*logspb.ResourceLogs{{
+		Resource: &resourcepb.Resource{Attributes: []*commonpb.KeyValue{
+			{Key: "service.name", Value: otlpStr(service)},
+			{Key: "k8s.namespace.name", Value: otlpStr("prod")},
+		}},
+		ScopeLogs: []*logspb.ScopeLogs{{
+			Scope:      &commonpb.InstrumentationScope{Name: "app.logger"},
+			LogRecords: recs,
+		}},
+	}}}
+}
+
+func listenOTLP(t *testing.T, cfg config.AgentOTLPSourceConfig) *OTLPSource {
+	t.Helper()
+	if cfg.HTTPListen == "" {
+		cfg.HTTPListen = "127.0.0.1:0"
+	}
+	if cfg.GRPCListen == "" {
+		cfg.GRPCListen = "127.0.0.1:0"
+	}
+	src, err := NewOTLPSource("otel", cfg)
+	if err != nil {
+		t.Fatalf("NewOTLPSource: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	if err := src.Listen(ctx); err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	return src
+}
+
+func postOTLP(t *testing.T, src *OTLPSource, contentType, encoding, token string, body []byte) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPost, "http://"+src.httpAddr.String()+"/v1/logs", bytes.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	if encoding != "" {
+		req.Header.Set("Content-Encoding", encoding)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST /v1/logs: %v", err)
+	}
+	resp.Body.Close()
+	return resp
+}
+
+func dialOTLP(t *testing.T, src *OTLPSource) collogspb.LogsServiceClient {
+	t.Helper()
+	conn, err := grpc.NewClient(src.grpcAddr.String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("grpc dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return collogspb.NewLogsServiceClient(conn)
+}
+
+// TestOTLPSource_MapsRecords sends the same kind of record over protobuf,
+// gzip-compressed JSON and gRPC, and checks each lands as a mapped Signal.
+func TestOTLPSource_MapsRecords(t *testing.T) {
+	src := listenOTLP(t, config.AgentOTLPSourceConfig{})
+	ts := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	pb, _ := proto.Marshal(otlpExport("checkout", &logspb.LogRecord{
+		TimeUnixNano:   uint64(ts.UnixNano()),
+		SeverityNumber: logspb.SeverityNumber_SEVERITY_NUMBER_ERROR,
+		Body:           otlpStr("payment declined"),
+		Attributes:     []*commonpb.KeyValue{{Key: "http.status_code", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: 502}}}},
+		TraceId:        []byte{0x5b, 0x8e, 0xff, 0xf7, 0x98, 0x03, 0x81, 0x03, 0xd2, 0x69, 0xb6, 0x33, 0x81, 0x3f, 0xc6, 0x0c},
+	}))
+	if resp := postOTLP(t, src, "application/x-protobuf", "", "", pb); resp.StatusCode != http.StatusOK {
+		t.Fatalf("protobuf export = %d", resp.StatusCode)
+	}
+
+	jsonBody := `{"resourceLogs":[{"resource":{"attributes":[{"key":"service.name","value":{"stringValue":"search"}}]},
+		"scopeLogs":[{"logRecords":[{"observedTimeUnixNano":"1746100800000000000","severityText":"warn",
+		"traceId":"5b8efff798038103d269b633813fc60c",
+		"body":{"kvlistValue":{"values":[{"key":"msg","value":{"stringValue":"slow query"}}]}}}]}]}]}`
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	_, _ = zw.Write([]byte(jsonBody))
+	zw.Close()
+	if resp := postOTLP(t, src, "application/json", "gzip", "", gz.Bytes()); resp.StatusCode != http.StatusOK {
+		t.Fatalf("json export = %d", resp.StatusCode)
+	}
+
+	if _, err := dialOTLP(t, src).Export(context.Background(), otlpExport("cart", &logspb.LogRecord{Body: otlpStr("cart emptied")})); err != nil {
+		t.Fatalf("grpc export: %v", err)
+	}
+
+	sigs, _, err := src.Pull(context.Background(), time.Time{})
+	if err != nil || len(sigs) != 3 {
+		t.Fatalf("Pull = %d signals, %v; want 3", len(sigs), err)
+	}
+	got := sigs[0]
+	if got.Source != "otlp:otel" || got.Message != "payment declined" || got.Severity != "ERROR" || !got.Timestamp.Equal(ts) {
+		t.Fatalf("protobuf signal = %+v", got)
+	}
+	if got.Fields[core.FieldService] != "checkout" || got.Fields["k8s.namespace.name"] != "prod" ||
+		got.Fields["http.status_code"] != int64(502) || got.Fields["severity_number"] != int64(17) ||
+		got.Fields["otel.scope.name"] != "app.logger" || got.Fields["trace_id"] != "5b8efff798038103d269b633813fc60c" {
+		t.Fatalf("protobuf fields = %+v", got.Fields)
+	}
+	if got := sigs[1]; got.Message != "slow query" || got.Severity != "warn" || got.Fields[core.FieldService] != "search" ||
+		got.Fields["trace_id"] != "5b8efff798038103d269b633813fc60c" || got.Timestamp.Unix() != 1746100800 {
+		t.Fatalf("json signal = %+v", got)
+	}
+	if got := sigs[2]; got.Message != "cart emptied" || got.Fields[core.FieldService] != "cart" {
+		t.Fatalf("grpc signal = %+v", got)
+	}
+	if sigs, _, _ := src.Pull(context.Background(), time.Time{}); len(sigs) != 0 {
+		t.Fatalf("second Pull = %d signals, want the queue drained", len(sigs))
+	}
+
+	if resp := postOTLP(t, src, "text/plain", "", "", []byte("hi")); resp.StatusCode != http.StatusUnsupportedMediaType {
+		t.Fatalf("text/plain = %d, want 415", resp.StatusCode)
+	}
+	if resp := postOTLP(t, src, "application/x-protobuf", "", "", []byte{0xff, 0xff}); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("garbage = %d, want 400", resp.StatusCode)
+	}
+}
+
+// TestOTLPSource_Backpressure fills the queue and checks both protocols refuse
+// the next export with a retryable status, then accept again once Pull drains.
+func TestOTLPSource_Backpressure(t *testing.T) {
+	src := listenOTLP(t, config.AgentOTLPSourceConfig{QueueSize: 2, MaxPullRecords: 1})
+	two, _ := proto.Marshal(otlpExport("api", &logspb.LogRecord{Body: otlpStr("a")}, &logspb.LogRecord{Body: otlpStr("b")}))
+	if resp := postOTLP(t, src, "application/x-protobuf", "", "", two); resp.StatusCode != http.StatusOK {
+		t.Fatalf("first export = %d", resp.StatusCode)
+	}
+	one, _ := proto.Marshal(otlpExport("api", &logspb.LogRecord{Body: otlpStr("c")}))
+	resp := postOTLP(t, src, "application/x-protobuf", "", "", one)
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") == "" {
+		t.Fatalf("full queue over HTTP = %d (Retry-After %q), want 429", resp.StatusCode, resp.Header.Get("Retry-After"))
+	}
+	_, err := dialOTLP(t, src).Export(context.Background(), otlpExport("api", &logspb.LogRecord{Body: otlpStr("c")}))
+	st := status.Convert(err)
+	if st.Code() != codes.ResourceExhausted {
+		t.Fatalf("full queue over gRPC = %v, want RESOURCE_EXHAUSTED", err)
+	}
+	if len(st.Details()) != 1 {
+		t.Fatalf("RESOURCE_EXHAUSTED details = %v, want RetryInfo", st.Details())
+	}
+	if _, ok := st.Details()[0].(*errdetails.RetryInfo); !ok {
+		t.Fatalf("RESOURCE_EXHAUSTED detail = %T, want RetryInfo", st.Details()[0])
+	}
+
+	if sigs, _, _ := src.Pull(context.Background(), time.Time{}); len(sigs) != 1 || sigs[0].Message != "a" {
+		t.Fatalf("Pull = %+v, want only the oldest record", sigs)
+	}
+	if resp := postOTLP(t, src, "application/x-protobuf", "", "", one); resp.StatusCode != http.StatusOK {
+		t.Fatalf("export after a drain = %d", resp.StatusCode)
+	}
+}
+
+// TestOTLPSource_AuthToken refuses senders without the bearer token.
+func TestOTLPSource_AuthToken(t *testing.T) {
+	src := listenOTLP(t, config.AgentOTLPSourceConfig{AuthToken: "s3cret"})
+	body, _ := proto.Marshal(otlpExport("api", &logspb.LogRecord{Body: otlpStr("x")}))
+	if resp := postOTLP(t, src, "application/x-protobuf", "", "wrong", body); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("wrong token = %d, want 401", resp.StatusCode)
+	}
+	if resp := postOTLP(t, src, "application/x-protobuf", "", "s3cret", body); resp.StatusCode != http.StatusOK {
+		t.Fatalf("right token = %d, want 200", resp.StatusCode)
+	}
+	client := dialOTLP(t, src)
+	if _, err := client.Export(context.Background(), otlpExport("api")); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("gRPC without token = %v, want UNAUTHENTICATED", err)
+	}
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer s3cret")
+	if _, err := client.Export(ctx, otlpExport("api")); err != nil {
+		t.Fatalf("gRPC with token: %v", err)
+	}
+}
+
+// TestOTLPSource_DiskQueueRedeliversUncommitted restarts a disk-backed source
+// and checks records pulled but never committed come back, and committed ones
+// do not.
+func TestOTLPSource_DiskQueueRedeliversUncommitted(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.AgentOTLPSourceConfig{GRPCListen: "127.0.0.1:0", QueueDir: dir, MaxPullRecords: 2}
+	ctx := context.Background()
+
+	first := listenOTLP(t, cfg)
+	if _, err := dialOTLP(t, first).Export(ctx, otlpExport("api",
+		&logspb.LogRecord{Body: otlpStr("one"), Attributes: []*commonpb.KeyValue{{Key: "n", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: 1}}}}},
+		&logspb.LogRecord{Body: otlpStr("two")},
+		&logspb.LogRecord{Body: otlpStr("three")},
+	)); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	sigs, _, err := first.Pull(ctx, time.Time{})
+	if err != nil || len(sigs) != 2 || sigs[0].Fields["n"] != int64(1) || sigs[0].Fields[core.FieldService] != "api" {
+		t.Fatalf("Pull = %+v, %v", sigs, err)
+	}
+
+	// Restart without a commit: everything is delivered again.
+	second := listenOTLP(t, cfg)
+	sigs, _, _ = second.Pull(ctx, time.Time{})
+	if len(sigs) != 2 || sigs[0].Message != "one" {
+		t.Fatalf("after restart = %+v, want one and two again", sigs)
+	}
+	if err := second.Commit(ctx); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+
+	third := listenOTLP(t, cfg)
+	sigs, _, _ = third.Pull(ctx, time.Time{})
+	if len(sigs) != 1 || sigs[0].Message != "three" {
+		t.Fatalf("after commit and restart = %+v, want only three", sigs)
+	}
+	if err := third.Commit(ctx); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	if sigs, _, _ := listenOTLP(t, cfg).Pull(ctx, time.Time{}); len(sigs) != 0 {
+		t.Fatalf("drained queue = %+v, want empty", sigs)
+	}
+}
+
+func TestNewOTLPSource_RequiresAListener(t *testing.T) {
+	if _, err := NewOTLPSource("x", config.AgentOTLPSourceConfig{}); err == nil {
+		t.Fatal("NewOTLPSource without listeners succeeded")
+	}
+}
@@ -0,0 +1,389 @@
+package signalsources
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/core"
+)
+
+// errReceiverQueueFull is returned by push when the records would not fit; each
+// receiver turns it into its protocol's backpressure (HTTP 429, gRPC
+// RESOURCE_EXHAUSTED, a paused TCP read).
+var errReceiverQueueFull = errors.New("receiver queue full")
+
+// receiverQueueCompactBytes is how large the already-learned head of the queue
+// file may grow before commit rewrites the file without it.
+const receiverQueueCompactBytes = 8 << 20
+
+// receiverQueue buffers the records a push source (core.SourceListener)
+// received until a tick drains them. It is bounded by record count and held in
+// memory, or — with a directory — in an append-only NDJSON file plus a sidecar
+// holding the byte offset of the first record not yet learned.
+//
+// The file has three regions: [0, ack) is learned and only waits to be
+// compacted away, [ack, read) has been handed to the worker by pop but not yet
+// committed, and [read, size) is still queued. pop advances read; commit moves
+// ack up to read once the worker's catalog flush made those records durable.
+// A restart resumes at ack, so records handed over but never committed are
+// delivered again: duplicates, never loss. Records in flight count against the
+// capacity until they are committed.
+//
+// Each record pop hands over carries its position in FieldReceiverOffset, so
+// a stage that still holds some of them can keep commit behind the first
+// (hold).
+type receiverQueue struct {
+	capacity int
+
+	mu sync.Mutex
+
+	// In-memory mode.
+	mem []core.Signal
+
+	// Disk mode (path != "").
+	path     string
+	ackPath  string
+	opened   bool
+	ack      int64
+	read     int64
+	size     int64
+	queued   int     // records in [read, size)
+	inflight []int64 // position of each record in [ack, read)
+	// base is how many bytes compaction removed from the head of the file
+	// since open. A record's position is base plus its byte offset, so
+	// positions handed out stay valid across a compaction.
+	base int64
+	// held is the position commit must not pass; -1 when nothing is held.
+	held int64
+}
+
+// FieldReceiverOffset is the Signal.Fields key holding a disk-queued record's
+// position in its receiver queue (int64).
+const FieldReceiverOffset = "receiver.queue.offset"
+
+// queuedSignal is the on-disk form of one record. The source name is not
+// stored; pop stamps the receiver's.
+type queuedSignal struct {
+	Timestamp time.Time      `json:"ts"`
+	Severity  string         `json:"severity,omitempty"`
+	Message   string         `json:"message"`
+	Fields    map[string]any `json:"fields,omitempty"`
+	Raw       map[string]any `json:"raw,omitempty"`
+}
+
+// newReceiverQueue returns a queue of capacity records, in memory or, with
+// dir, in the file "<kind>-<name>.ndjson" there.
+func newReceiverQueue(capacity int, dir, kind, name string) *receiverQueue {
+	q := &receiverQueue{capacity: capacity, held: -1}
+	if dir != "" {
+		q.path = filepath.Join(dir, kind+"-"+sanitizeName(name)+".ndjson")
+		q.ackPath = q.path + ".ack"
+	}
+	return q
+}
+
+// open loads the disk queue left by a previous process. It is a no-op in
+// memory mode and on every call after the first.
+func (q *receiverQueue) open() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.path == "" || q.opened {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(q.path), 0o755); err != nil {
+		return err
+	}
+	if raw, err := os.ReadFile(q.ackPath); err == nil {
+		if q.ack, err = strconv.ParseInt(strings.TrimSpace(string(raw)), 10, 64); err != nil {
+			return fmt.Errorf("read %s: %w", q.ackPath, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	f, err := os.Open(q.path)
+	if os.IsNotExist(err) {
+		q.ack, q.read, q.size, q.opened = 0, 0, 0, true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if q.ack > fi.Size() {
+		q.ack = 0
+	}
+	if _, err := f.Seek(q.ack, io.SeekStart); err != nil {
+		return err
+	}
+	// Count the queued records, and cut a line a crash left half-written.
+	end, count := q.ack, 0
+	br := bufio.NewReaderSize(f, 64*1024)
+	for {
+		line, err := br.ReadBytes('\n')
+		if err == nil {
+			end += int64(len(line))
+			count++
+			continue
+		}
+		if err != io.EOF {
+			return err
+		}
+		break
+	}
+	if end < fi.Size() {
+		if err := os.Truncate(q.path, end); err != nil {
+			return err
+		}
+	}
+	q.read, q.size, q.queued, q.inflight, q.opened = q.ack, end, count, nil, true
+	return nil
+}
+
+// push queues sigs as a unit: either all fit or none is queued.
+func (q *receiverQueue) push(sigs []core.Signal) error {
+	if len(sigs) == 0 {
+		return nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.path == "" {
+		if len(q.mem)+len(sigs) > q.capacity {
+			return errReceiverQueueFull
+		}
+		q.mem = append(q.mem, sigs...)
+		return nil
+	}
+	if q.queued+len(q.inflight)+len(sigs) > q.capacity {
+		return errReceiverQueueFull
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, s := range sigs {
+		if err := enc.Encode(queuedSignal{
+			Timestamp: s.Timestamp, Severity: s.Severity, Message: s.Message, Fields: s.Fields, Raw: s.Raw,
+		}); err != nil {
+			return err
+		}
+	}
+	f, err := os.OpenFile(q.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(buf.Bytes())
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		// Drop whatever part of the write landed so the file stays aligned
+		// to whole records.
+		_ = os.Truncate(q.path, q.size)
+		return err
+	}
+	q.size += int64(buf.Len())
+	q.queued += len(sigs)
+	return nil
+}
+
+// pop hands over up to max queued records, oldest first, stamped with source.
+func (q *receiverQueue) pop(max int, source string) ([]core.Signal, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.path == "" {
+		n := min(max, len(q.mem))
+		out := make([]core.Signal, n)
+		copy(out, q.mem[:n])
+		q.mem = q.mem[n:]
+		if len(q.mem) == 0 {
+			q.mem = nil
+		}
+		return out, nil
+	}
+	if q.queued == 0 {
+		return nil, nil
+	}
+	f, err := os.Open(q.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if _, err := f.Seek(q.read, io.SeekStart); err != nil {
+		return nil, err
+	}
+	br := bufio.NewReaderSize(f, 64*1024)
+	var out []core.Signal
+	for taken := 0; taken < max && q.queued > 0; taken++ {
+		line, err := br.ReadBytes('\n')
+		if err != nil {
+			return out, fmt.Errorf("read %s: %w", q.path, err)
+		}
+		at := q.base + q.read
+		q.inflight = append(q.inflight, at)
+		q.read += int64(len(line))
+		q.queued--
+		var rec queuedSignal
+		dec := json.NewDecoder(bytes.NewReader(line))
+		dec.UseNumber()
+		if err := dec.Decode(&rec); err != nil {
+			log.Printf("%s: skipping unreadable queued record: %v", source, err)
+			continue
+		}
+		fields, _ := fromJSONNumbers(rec.Fields).(map[string]any)
+		if fields == nil {
+			fields = make(map[string]any, 1)
+		}
+		fields[FieldReceiverOffset] = at
+		raw, _ := fromJSONNumbers(rec.Raw).(map[string]any)
+		out = append(out, core.Signal{
+			Source: source, Timestamp: rec.Timestamp, Severity: rec.Severity, Message: rec.Message, Fields: fields, Raw: raw,
+		})
+	}
+	return out, nil
+}
+
+// hold keeps commit behind the first of the held records: their positions
+// are read from FieldReceiverOffset. An empty held lifts the hold.
+func (q *receiverQueue) hold(held []core.Signal) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.held = -1
+	for _, sig := range held {
+		if at, ok := sig.Fields[FieldReceiverOffset].(int64); ok && (q.held < 0 || at < q.held) {
+			q.held = at
+		}
+	}
+}
+
+// commit marks every record popped so far as learned, up to the held one if
+// any. The ack sidecar is written before the data file is rewritten, so a
+// crash in between replays learned records rather than skipping unlearned
+// ones.
+func (q *receiverQueue) commit() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.path == "" || len(q.inflight) == 0 {
+		return nil
+	}
+	n := len(q.inflight)
+	if q.held >= 0 {
+		n = sort.Search(len(q.inflight), func(i int) bool { return q.inflight[i] >= q.held })
+	}
+	if n == 0 {
+		return nil
+	}
+	if n < len(q.inflight) {
+		q.ack = q.inflight[n] - q.base
+	} else {
+		q.ack = q.read
+	}
+	q.inflight = q.inflight[n:]
+	switch {
+	case q.ack == q.size:
+		if err := q.saveAck(0); err != nil {
+			return err
+		}
+		if err := os.Truncate(q.path, 0); err != nil {
+			return err
+		}
+		q.base += q.size
+		q.ack, q.read, q.size = 0, 0, 0
+		return nil
+	case q.ack >= receiverQueueCompactBytes:
+		if err := q.saveAck(0); err != nil {
+			return err
+		}
+		if err := q.compact(); err != nil {
+			// The sidecar already says 0; a restart now replays the learned
+			// head, which is a duplicate, not a hole.
+			return err
+		}
+		q.base += q.ack
+		q.read -= q.ack
+		q.size -= q.ack
+		q.ack = 0
+		return nil
+	}
+	return q.saveAck(q.ack)
+}
+
+// compact rewrites the queue file without the learned head [0, ack).
+func (q *receiverQueue) compact() error {
+	src, err := os.Open(q.path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	if _, err := src.Seek(q.ack, io.SeekStart); err != nil {
+		return err
+	}
+	tmp := q.path + ".tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, src)
+	if err == nil {
+		err = dst.Sync()
+	}
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, q.path)
+}
+
+func (q *receiverQueue) saveAck(off int64) error {
+	tmp := q.ackPath + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strconv.FormatInt(off, 10)), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, q.ackPath)
+}
+
+// fromJSONNumbers turns the json.Number values a UseNumber decode leaves
+// behind into int64 where they are integral and float64 otherwise, so a
+// record read back from disk carries the same types as one that never left
+// memory.
+func fromJSONNumbers(v any) any {
+	switch t := v.(type) {
+	case json.Number:
+		if n, err := t.Int64(); err == nil {
+			return n
+		}
+		f, _ := t.Float64()
+		return f
+	case map[string]any:
+		for k, e := range t {
+			t[k] = fromJSONNumbers(e)
+		}
+		return t
+	case []any:
+		for i, e := range t {
+			t[i] = fromJSONNumbers(e)
+		}
+		return t
+	}
+	return v
+}
@@ -7,10 +7,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/VersusControl/versus-incident/pkg/config"
@@ -28,12 +30,38 @@ import (
 // `earliest_time` is INCLUSIVE — we drop any returned events whose
 // `_time` is not strictly after `since` to honor the >`since`
 // requirement. The returned cursor is the max `_time` observed.
+//
+// With a re-read span below the cursor — `reorder_window` plus the catalog
+// persist interval the agent adds (ApplyTailReplaySpan) — `earliest_time`
+// moves down by that span instead, and the events already delivered inside it
+// are suppressed by their bucket and offset (`_bkt`, `_cd`) with a persisted
+// id set (TailDedup), the convention the Elasticsearch, CloudWatch Logs and
+// SigNoz tails follow.
 type SplunkSource struct {
 	name   string
 	cfg    config.AgentSplunkSourceConfig
 	client *http.Client
+
+	// reorderWindow is the configured lateness budget.
+	reorderWindow time.Duration
+	// replaySpan is added to reorderWindow to size what a tick re-reads. See
+	// ApplyTailReplaySpan.
+	replaySpan time.Duration
+
+	// nowFn is the wall clock that upper-bounds the scan and clamps the
+	// cursor. Overridable in tests; nil ⇒ time.Now.
+	nowFn func() time.Time
+
+	// mu guards the tick so a concurrent Rewind cannot interleave and leave
+	// stale dedup state behind.
+	mu    sync.Mutex
+	dedup *TailDedup
 }
 
+// splunkMaxPagesPerTick caps the exports one tick issues; the rest of a long
+// span is read next tick.
+const splunkMaxPagesPerTick = 20
+
 // NewSplunkSource validates the config and constructs a ready source.
 func NewSplunkSource(name string, cfg config.AgentSplunkSourceConfig) (*SplunkSource, error) {
 	if cfg.Address == "" {
@@ -54,30 +82,151 @@ func NewSplunkSource(name string, cfg config.AgentSplunkSourceConfig) (*SplunkSo
 	tr := &http.Transport{
 		TLSClientConfig: &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify},
 	}
-	return &SplunkSource{
+	src := &SplunkSource{
 		name:   name,
 		cfg:    cfg,
 		client: &http.Client{Transport: tr, Timeout: 30 * time.Second},
-	}, nil
+	}
+	if cfg.ReorderWindow != "" {
+		if d, err := time.ParseDuration(cfg.ReorderWindow); err == nil && d > 0 {
+			src.reorderWindow = d
+		}
+	}
+	src.dedup = NewTailDedup(src.Name())
+	return src, nil
 }
 
 func (s *SplunkSource) Name() string { return "splunk:" + s.name }
 
-// Pull issues an `export` request over the (since, now) window. Results
-// are returned as one JSON object per line (output_mode=json); each line
-// has shape `{"preview":false,"offset":N,"result":{...}}`.
+// SetTailReplaySpan widens what each tick re-reads so it also covers the
+// events a killed process learned but never flushed. It implements
+// TailReplaySpanSetter.
+func (s *SplunkSource) SetTailReplaySpan(span time.Duration) (time.Duration, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if span > 0 {
+		s.replaySpan = span
+	}
+	return s.reorderWindow, s.scanWindow()
+}
+
+// scanWindow is how far below the cursor a tick re-reads. Callers hold s.mu.
+func (s *SplunkSource) scanWindow() time.Duration { return s.reorderWindow + s.replaySpan }
+
+// SetTailDedupBackend makes this source's boundary dedup set durable. It
+// implements TailDedupBinder.
+func (s *SplunkSource) SetTailDedupBackend(b TailDedupBackend) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dedup.SetBackend(b)
+}
+
+// Rewind clears the boundary dedup set so a catalog clear makes this source
+// re-emit its whole window. It implements core.SourceRewinder.
+func (s *SplunkSource) Rewind(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dedup.Clear(ctx)
+}
+
+// Commit makes the ids this source has delivered durable, after the worker has
+// flushed the events they describe. It implements core.SourceCommitter.
+func (s *SplunkSource) Commit(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dedup.Commit(ctx)
+}
+
+func (s *SplunkSource) now() time.Time {
+	if s.nowFn != nil {
+		return s.nowFn().UTC()
+	}
+	return time.Now().UTC()
+}
+
+// Pull issues `export` requests over the (since, now) window — or
+// [since - scanWindow, now] when a re-read span applies — and returns every
+// event not delivered before. A full page is followed by another from the
+// newest `_time` it held, so a span holding more than PageSize events is
+// still walked to its end. The cursor is the max `_time` seen, never below
+// `since` and never past `now`.
 func (s *SplunkSource) Pull(ctx context.Context, since time.Time) ([]core.Signal, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if since.After(now) {
+		since = now
+	}
 	cursor := since
-	earliest := since.UTC()
+	lower := since
+	window := s.scanWindow()
+	if window > 0 && !since.IsZero() {
+		lower = since.Add(-window)
+	}
+	earliest := lower.UTC()
 	if earliest.IsZero() {
 		// Cold start window — see GraylogSource for the same rationale.
-		earliest = time.Now().UTC().Add(-5 * time.Minute)
+		earliest = now.Add(-5 * time.Minute)
 	}
-	latest := time.Now().UTC()
-	if !latest.After(earliest) {
+	if !now.After(earliest) {
 		return nil, cursor, nil
 	}
 
+	if err := s.dedup.Load(ctx); err != nil {
+		log.Printf("agent: %s: loading the persisted dedup set failed: %v (this tick may re-emit up to one reorder window)", s.Name(), err)
+	}
+
+	var signals []core.Signal
+	var seen []DedupRow
+	tick := make(map[string]bool)
+	for page := 0; page < splunkMaxPagesPerTick; page++ {
+		results, err := s.export(ctx, earliest, now)
+		if err != nil {
+			return signals, ClampCursor(cursor, since, now), err
+		}
+		var pageMax time.Time
+		for _, r := range results {
+			ts := parseSplunkTime(r[s.cfg.TimeField])
+			if ts.IsZero() {
+				continue
+			}
+			if ts.After(pageMax) {
+				pageMax = ts
+			}
+			if window == 0 && !ts.After(since) {
+				continue
+			}
+			id := splunkEventID(r, ts)
+			if tick[id] {
+				continue
+			}
+			tick[id] = true
+			if ts.After(cursor) {
+				cursor = ts
+			}
+			seen = append(seen, DedupRow{ID: id, TS: ts})
+			if s.dedup.Has(id) {
+				continue
+			}
+			signals = append(signals, s.signalFromResult(r, ts))
+		}
+		if len(results) < s.cfg.PageSize || !pageMax.After(earliest) {
+			break
+		}
+		earliest = pageMax
+	}
+	cursor = ClampCursor(cursor, since, now)
+
+	// Stage the ids in memory so the next tick does not re-deliver them; they
+	// become durable in Commit, after the worker has flushed the events.
+	s.dedup.Stage(seen, lower)
+	return signals, cursor, nil
+}
+
+// export issues one `export` request over [earliest, latest] and returns its
+// results.
+func (s *SplunkSource) export(ctx context.Context, earliest, latest time.Time) ([]map[string]interface{}, error) {
 	// `search` is expected to start with the `search` operator; we
 	// don't try to be clever about user input here.
 	form := url.Values{}
@@ -90,7 +239,7 @@ func (s *SplunkSource) Pull(ctx context.Context, since time.Time) ([]core.Signal
 	u := strings.TrimRight(s.cfg.Address, "/") + "/services/search/v2/jobs/export"
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, strings.NewReader(form.Encode()))
 	if err != nil {
-		return nil, cursor, err
+		return nil, err
 	}
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
@@ -98,30 +247,24 @@ func (s *SplunkSource) Pull(ctx context.Context, since time.Time) ([]core.Signal
 
 	resp, err := s.client.Do(req)
 	if err != nil {
-		return nil, cursor, err
+		return nil, err
 	}
 	defer resp.Body.Close()
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, cursor, err
+		return nil, err
 	}
 	if resp.StatusCode >= 400 {
-		return nil, cursor, fmt.Errorf("splunk %s: %d %s", u, resp.StatusCode, truncate(string(body), 256))
+		return nil, fmt.Errorf("splunk %s: %d %s", u, resp.StatusCode, truncate(string(body), 256))
 	}
-
-	signals, maxTS := s.parseExport(body, since)
-	if maxTS.After(cursor) {
-		cursor = maxTS
-	}
-	return signals, cursor, nil
+	return parseSplunkExport(body), nil
 }
 
-// parseExport reads the newline-delimited JSON stream Splunk returns
+// parseSplunkExport reads the newline-delimited JSON stream Splunk returns
 // from /export. Lines without a `result` object (e.g. preview / final
 // status records) are silently skipped.
-func (s *SplunkSource) parseExport(body []byte, since time.Time) ([]core.Signal, time.Time) {
-	var signals []core.Signal
-	var maxTS time.Time
+func parseSplunkExport(body []byte) []map[string]interface{} {
+	var results []map[string]interface{}
 	dec := json.NewDecoder(strings.NewReader(string(body)))
 	for dec.More() {
 		var line splunkExportLine
@@ -131,37 +274,40 @@ func (s *SplunkSource) parseExport(body []byte, since time.Time) ([]core.Signal,
 			// same window for anything we missed.
 			break
 		}
-		if line.Result == nil {
-			continue
+		if line.Result != nil {
+			results = append(results, line.Result)
 		}
-		ts := parseSplunkTime(line.Result[s.cfg.TimeField])
-		if ts.IsZero() || !ts.After(since) {
-			continue
-		}
-		if ts.After(maxTS) {
-			maxTS = ts
-		}
-
-		msg := stringField(line.Result, s.cfg.MessageField)
-		sev := stringField(line.Result, s.cfg.SeverityField)
+	}
+	return results
+}
 
-		fields := make(map[string]interface{})
-		for _, f := range s.cfg.ExtraFields {
-			if v, ok := line.Result[f]; ok {
-				fields[f] = v
-			}
+func (s *SplunkSource) signalFromResult(r map[string]interface{}, ts time.Time) core.Signal {
+	fields := make(map[string]interface{})
+	for _, f := range s.cfg.ExtraFields {
+		if v, ok := r[f]; ok {
+			fields[f] = v
 		}
+	}
+	return core.Signal{
+		Source:    s.Name(),
+		Timestamp: ts,
+		Severity:  stringField(r, s.cfg.SeverityField),
+		Message:   stringField(r, s.cfg.MessageField),
+		Fields:    fields,
+		Raw:       r,
+	}
+}
 
-		signals = append(signals, core.Signal{
-			Source:    s.Name(),
-			Timestamp: ts,
-			Severity:  sev,
-			Message:   msg,
-			Fields:    fields,
-			Raw:       line.Result,
-		})
+// splunkEventID is the dedup key for one event: its index, bucket and offset
+// within the bucket (`index`, `_bkt`, `_cd`), which together name one event.
+// Transforming searches drop those fields, so the hashed fallback keeps dedup
+// working for them.
+func splunkEventID(r map[string]interface{}, ts time.Time) string {
+	bkt, cd := stringField(r, "_bkt"), stringField(r, "_cd")
+	if bkt != "" && cd != "" {
+		return stringField(r, "index") + "|" + bkt + "|" + cd
 	}
-	return signals, maxTS
+	return HashedRowID(ts.Format(time.RFC3339Nano), stringField(r, "host"), stringField(r, "source"), stringField(r, "sourcetype"), stringField(r, "_raw"))
 }
 
 // applyAuth wires up Splunk auth in priority order:
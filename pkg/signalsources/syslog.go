@@ -0,0 +1,437 @@
+package signalsources
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/VersusControl/versus-incident/pkg/core"
+)
+
+// SyslogSource receives syslog messages over UDP, TCP and TLS. Like the OTLP
+// receiver it is push-based: senders deliver to it, the messages wait in a
+// bounded queue, and each tick's Pull drains it.
+//
+// Behavior:
+//
+//   - Nothing binds until Listen (core.SourceListener).
+//   - RFC 5424 and RFC 3164 are parsed leniently; what does not parse stays
+//     in the message, so no message is dropped for its format.
+//   - TCP and TLS accept octet-counting and newline framing per message
+//     (RFC 6587). When the queue is full a connection is not read until it
+//     drains, so TCP flow control pushes back on the sender. UDP cannot push
+//     back: datagrams that do not fit are dropped and counted in the log.
+//   - At most max_connections TCP and TLS connections are served at once;
+//     one over the limit is closed on accept. A connection silent for
+//     idle_timeout is closed, and a TLS handshake must finish within it.
+//   - The Pull `since` argument is ignored; the queue is the position.
+//   - With queue_dir set, messages stay on disk until the catalog flush that
+//     learned them (core.SourceCommitter).
+type SyslogSource struct {
+	name  string
+	cfg   config.AgentSyslogSourceConfig
+	queue *receiverQueue
+	loc   *time.Location // zone of RFC 3164 timestamps, which carry none
+	conns chan struct{}  // one slot per TCP or TLS connection served
+	idle  time.Duration
+
+	mu        sync.Mutex
+	listening bool
+	udpAddr   net.Addr
+	tcpAddr   net.Addr
+	tlsAddr   net.Addr
+
+	dropped    atomic.Int64
+	lastDropLg atomic.Int64 // unix seconds of the last drop log line
+	refused    atomic.Int64
+	lastRefLg  atomic.Int64 // unix seconds of the last refused-connection log line
+}
+
+// Defaults applied when the corresponding option is empty / zero.
+const (
+	defaultSyslogMaxMessageBytes = 64 * 1024
+	defaultSyslogQueueSize       = 10000
+	defaultSyslogMaxPullRecords  = 1000
+	defaultSyslogMaxConnections  = 1000
+	defaultSyslogIdleTimeout     = 5 * time.Minute
+)
+
+// syslogBatchMax bounds how many messages one connection or the UDP reader
+// gathers before queueing them, so a burst costs one queue write (and one
+// fsync with queue_dir) instead of one per message.
+const syslogBatchMax = 256
+
+// syslogFullRetry is how long a stream waits before retrying a full queue.
+const syslogFullRetry = 200 * time.Millisecond
+
+// NewSyslogSource validates configuration. It neither binds a port, reads the
+// TLS files nor opens the queue file; Listen does.
+func NewSyslogSource(name string, cfg config.AgentSyslogSourceConfig) (*SyslogSource, error) {
+	if cfg.UDPListen == "" && cfg.TCPListen == "" && cfg.TLSListen == "" {
+		return nil, fmt.Errorf("syslog source %q: udp_listen, tcp_listen or tls_listen is required", name)
+	}
+	if cfg.TLSListen != "" && (cfg.TLSCertFile == "" || cfg.TLSKeyFile == "") {
+		return nil, fmt.Errorf("syslog source %q: tls_listen needs tls_cert_file and tls_key_file", name)
+	}
+	switch cfg.Format {
+	case "", "auto", "rfc5424", "rfc3164":
+	default:
+		return nil, fmt.Errorf("syslog source %q: unknown format %q (want \"auto\", \"rfc5424\" or \"rfc3164\")", name, cfg.Format)
+	}
+	if cfg.MaxMessageBytes <= 0 {
+		cfg.MaxMessageBytes = defaultSyslogMaxMessageBytes
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaultSyslogQueueSize
+	}
+	if cfg.MaxPullRecords <= 0 {
+		cfg.MaxPullRecords = defaultSyslogMaxPullRecords
+	}
+	if cfg.MaxConnections <= 0 {
+		cfg.MaxConnections = defaultSyslogMaxConnections
+	}
+	idle := defaultSyslogIdleTimeout
+	if cfg.IdleTimeout != "" {
+		d, err := time.ParseDuration(cfg.IdleTimeout)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("syslog source %q: invalid idle_timeout %q", name, cfg.IdleTimeout)
+		}
+		idle = d
+	}
+	return &SyslogSource{
+		name:  name,
+		cfg:   cfg,
+		queue: newReceiverQueue(cfg.QueueSize, cfg.QueueDir, "syslog", name),
+		loc:   time.Local,
+		conns: make(chan struct{}, cfg.MaxConnections),
+		idle:  idle,
+	}, nil
+}
+
+func (s *SyslogSource) Name() string { return "syslog:" + s.name }
+
+// Listen opens the queue and binds the configured transports, serving them
+// until ctx is done. It implements core.SourceListener.
+func (s *SyslogSource) Listen(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listening {
+		return fmt.Errorf("syslog source %q: already listening", s.name)
+	}
+	if err := s.queue.open(); err != nil {
+		return fmt.Errorf("syslog source %q: open queue: %w", s.name, err)
+	}
+	var tlsCfg *tls.Config
+	if s.cfg.TLSListen != "" {
+		var err error
+		if tlsCfg, err = s.tlsConfig(); err != nil {
+			return fmt.Errorf("syslog source %q: %w", s.name, err)
+		}
+	}
+
+	var closers []func()
+	fail := func(what string, err error) error {
+		for _, c := range closers {
+			c()
+		}
+		return fmt.Errorf("syslog source %q: %s: %w", s.name, what, err)
+	}
+	var udp net.PacketConn
+	var tcp, tlsLn net.Listener
+	if s.cfg.UDPListen != "" {
+		pc, err := net.ListenPacket("udp", s.cfg.UDPListen)
+		if err != nil {
+			return fail("udp_listen", err)
+		}
+		udp = pc
+		closers = append(closers, func() { pc.Close() })
+	}
+	if s.cfg.TCPListen != "" {
+		ln, err := net.Listen("tcp", s.cfg.TCPListen)
+		if err != nil {
+			return fail("tcp_listen", err)
+		}
+		tcp = ln
+		closers = append(closers, func() { ln.Close() })
+	}
+	if s.cfg.TLSListen != "" {
+		ln, err := net.Listen("tcp", s.cfg.TLSListen)
+		if err != nil {
+			return fail("tls_listen", err)
+		}
+		tlsLn = tls.NewListener(ln, tlsCfg)
+		closers = append(closers, func() { ln.Close() })
+	}
+	context.AfterFunc(ctx, func() {
+		for _, c := range closers {
+			c()
+		}
+	})
+
+	if udp != nil {
+		s.udpAddr = udp.LocalAddr()
+		go s.serveUDP(ctx, udp)
+		log.Printf("syslog source %s: UDP listening on %s", s.name, s.udpAddr)
+	}
+	if tcp != nil {
+		s.tcpAddr = tcp.Addr()
+		go s.serveStream(ctx, tcp)
+		log.Printf("syslog source %s: TCP listening on %s", s.name, s.tcpAddr)
+	}
+	if tlsLn != nil {
+		s.tlsAddr = tlsLn.Addr()
+		go s.serveStream(ctx, tlsLn)
+		log.Printf("syslog source %s: TLS listening on %s", s.name, s.tlsAddr)
+	}
+	s.listening = true
+	return nil
+}
+
+// tlsConfig loads the server certificate and, when configured, the client CAs.
+func (s *SyslogSource) tlsConfig() (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(s.cfg.TLSCertFile, s.cfg.TLSKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load tls_cert_file/tls_key_file: %w", err)
+	}
+	cfg := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	if s.cfg.TLSClientCAFile != "" {
+		pem, err := os.ReadFile(s.cfg.TLSClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read tls_client_ca_file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("tls_client_ca_file %s holds no PEM certificate", s.cfg.TLSClientCAFile)
+		}
+		cfg.ClientCAs, cfg.ClientAuth = pool, tls.RequireAndVerifyClientCert
+	}
+	return cfg, nil
+}
+
+// Pull drains up to max_pull_records queued messages, oldest first.
+func (s *SyslogSource) Pull(_ context.Context, _ time.Time) ([]core.Signal, time.Time, error) {
+	cursor := time.Now().UTC()
+	sigs, err := s.queue.pop(s.cfg.MaxPullRecords, s.Name())
+	if err != nil {
+		return sigs, cursor, fmt.Errorf("syslog source %q: %w", s.name, err)
+	}
+	return sigs, cursor, nil
+}
+
+// Commit releases the messages handed out by earlier Pulls from the disk
+// queue. It implements core.SourceCommitter; it is a no-op in memory.
+func (s *SyslogSource) Commit(_ context.Context) error {
+	if err := s.queue.commit(); err != nil {
+		return fmt.Errorf("syslog source %q: %w", s.name, err)
+	}
+	return nil
+}
+
+// HoldCommit keeps the next Commit from releasing the first held message or
+// any after it. It implements core.SourceCommitHolder.
+func (s *SyslogSource) HoldCommit(held []core.Signal) {
+	s.queue.hold(held)
+}
+
+// serveUDP reads one message per datagram. Datagrams are gathered into a
+// batch until the socket goes quiet or the batch is full.
+func (s *SyslogSource) serveUDP(ctx context.Context, pc net.PacketConn) {
+	buf := make([]byte, 64*1024)
+	var batch []core.Signal
+	for {
+		_ = pc.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+		n, addr, err := pc.ReadFrom(buf)
+		if n > 0 {
+			line := buf[:min(n, s.cfg.MaxMessageBytes)]
+			for len(line) > 0 && (line[len(line)-1] == '\n' || line[len(line)-1] == '\r' || line[len(line)-1] == 0) {
+				line = line[:len(line)-1]
+			}
+			if len(line) > 0 {
+				batch = append(batch, s.toSignal(line, addr, time.Now()))
+			}
+		}
+		var ne net.Error
+		quiet := errors.As(err, &ne) && ne.Timeout()
+		if len(batch) > 0 && (quiet || len(batch) >= syslogBatchMax || err != nil) {
+			if perr := s.queue.push(batch); perr != nil {
+				s.noteDropped(len(batch), perr)
+			}
+			batch = nil
+		}
+		if err != nil && !quiet {
+			if ctx.Err() == nil {
+				log.Printf("syslog source %s: udp: %v", s.name, err)
+			}
+			return
+		}
+	}
+}
+
+// noteDropped counts UDP messages the queue refused, logging at most once a
+// minute.
+func (s *SyslogSource) noteDropped(n int, err error) {
+	total := s.dropped.Add(int64(n))
+	now := time.Now().Unix()
+	if last := s.lastDropLg.Load(); now-last >= 60 && s.lastDropLg.CompareAndSwap(last, now) {
+		log.Printf("syslog source %s: dropped %d UDP message(s) so far: %v", s.name, total, err)
+	}
+}
+
+// noteRefused counts connections closed over max_connections, logging at
+// most once a minute.
+func (s *SyslogSource) noteRefused() {
+	total := s.refused.Add(1)
+	now := time.Now().Unix()
+	if last := s.lastRefLg.Load(); now-last >= 60 && s.lastRefLg.CompareAndSwap(last, now) {
+		log.Printf("syslog source %s: closed %d connection(s) over max_connections (%d) so far", s.name, total, s.cfg.MaxConnections)
+	}
+}
+
+func (s *SyslogSource) serveStream(ctx context.Context, ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() == nil && !errors.Is(err, net.ErrClosed) {
+				log.Printf("syslog source %s: accept: %v", s.name, err)
+			}
+			return
+		}
+		select {
+		case s.conns <- struct{}{}:
+		default:
+			conn.Close()
+			s.noteRefused()
+			continue
+		}
+		go func() {
+			defer func() { <-s.conns }()
+			s.handleStream(ctx, conn)
+		}()
+	}
+}
+
+// handleStream reads framed messages from one connection. It queues what it
+// has whenever the sender pauses, and while the queue is full it stops reading
+// so the backlog stays in the sender's socket, not in memory. Every read, the
+// TLS handshake included, must make progress within the idle timeout.
+func (s *SyslogSource) handleStream(ctx context.Context, conn net.Conn) {
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+	defer conn.Close()
+	if tc, ok := conn.(*tls.Conn); ok {
+		_ = tc.SetDeadline(time.Now().Add(s.idle))
+		if err := tc.HandshakeContext(ctx); err != nil {
+			return
+		}
+		_ = tc.SetDeadline(time.Time{})
+	}
+	br := bufio.NewReaderSize(idleReader{conn: conn, idle: s.idle}, 64*1024)
+	addr := conn.RemoteAddr()
+	var batch []core.Signal
+	for {
+		frame, err := readSyslogFrame(br, s.cfg.MaxMessageBytes)
+		if len(frame) > 0 {
+			batch = append(batch, s.toSignal(frame, addr, time.Now()))
+		}
+		if len(batch) > 0 && (err != nil || br.Buffered() == 0 || len(batch) >= syslogBatchMax) {
+			if !s.pushWaiting(ctx, batch) {
+				return
+			}
+			batch = nil
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// idleReader pushes the connection's read deadline out before every read, so
+// a read fails once the sender has been silent for idle. Time spent waiting
+// on a full queue is not read time and does not count.
+type idleReader struct {
+	conn net.Conn
+	idle time.Duration
+}
+
+func (r idleReader) Read(p []byte) (int, error) {
+	_ = r.conn.SetReadDeadline(time.Now().Add(r.idle))
+	return r.conn.Read(p)
+}
+
+// pushWaiting queues batch, waiting out a full queue. It reports false when
+// ctx ends first or the queue fails.
+func (s *SyslogSource) pushWaiting(ctx context.Context, batch []core.Signal) bool {
+	for {
+		err := s.queue.push(batch)
+		if err == nil {
+			return true
+		}
+		if !errors.Is(err, errReceiverQueueFull) {
+			log.Printf("syslog source %s: queue: %v", s.name, err)
+			return false
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(syslogFullRetry):
+		}
+	}
+}
+
+// toSignal parses one message. received stands in for a missing timestamp.
+//
+// Fields carries hostname, app_name, procid and msgid when present, facility
+// and the numeric priority when the message has a PRI, the sender's
+// remote_addr, and each structured-data parameter as "<SD-ID>.<PARAM>".
+// Fields[core.FieldService] is app_name; Severity is the RFC 5424 keyword of
+// the PRI's severity ("err", "warning", …).
+func (s *SyslogSource) toSignal(line []byte, from net.Addr, received time.Time) core.Signal {
+	msg := parseSyslog(line, s.cfg.Format, received, s.loc)
+	fields := map[string]any{}
+	for k, v := range map[string]string{"hostname": msg.Hostname, "app_name": msg.AppName, "procid": msg.ProcID, "msgid": msg.MsgID} {
+		if v != "" {
+			fields[k] = v
+		}
+	}
+	if msg.AppName != "" {
+		fields[core.FieldService] = msg.AppName
+	}
+	var severity string
+	if msg.Pri >= 0 {
+		severity = syslogSeverityNames[msg.Pri%8]
+		fields["facility"] = syslogFacilityNames[msg.Pri/8]
+		fields["priority"] = int64(msg.Pri)
+	}
+	if from != nil {
+		if host, _, err := net.SplitHostPort(from.String()); err == nil {
+			fields["remote_addr"] = host
+		}
+	}
+	for id, params := range msg.Structured {
+		for k, v := range params {
+			fields[id+"."+k] = v
+		}
+	}
+	ts := msg.Timestamp
+	if ts.IsZero() {
+		ts = received
+	}
+	return core.Signal{
+		Source:    s.Name(),
+		Timestamp: ts.UTC(),
+		Severity:  severity,
+		Message:   msg.Msg,
+		Fields:    fields,
+		Raw:       map[string]any{"message": string(line)},
+	}
+}
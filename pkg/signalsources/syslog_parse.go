@@ -0,0 +1,304 @@
+package signalsources
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// syslogMessage is one parsed syslog message. Pri is -1 when the message had
+// no PRI part; empty strings stand for RFC 5424's NILVALUE ("-").
+type syslogMessage struct {
+	Pri       int
+	Timestamp time.Time // zero when absent or unparseable
+	Hostname  string
+	AppName   string
+	ProcID    string
+	MsgID     string
+	// Structured holds RFC 5424 structured data, keyed SD-ID then PARAM-NAME.
+	Structured map[string]map[string]string
+	Msg        string
+}
+
+// syslogSeverityNames are the RFC 5424 severity keywords, indexed by the
+// severity code (PRI % 8).
+var syslogSeverityNames = [...]string{"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug"}
+
+// syslogFacilityNames are the facility keywords, indexed by PRI / 8.
+var syslogFacilityNames = [...]string{
+	"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news",
+	"uucp", "cron", "authpriv", "ftp", "ntp", "security", "console", "solaris-cron",
+	"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+}
+
+// parseSyslog parses one message in format "auto", "rfc5424" or "rfc3164".
+// It is lenient by design: whatever a sender emits is kept, and a part that
+// does not parse is left in Msg rather than dropping the message. now
+// resolves RFC 3164's year-less timestamps; loc is the zone they are read in.
+func parseSyslog(line []byte, format string, now time.Time, loc *time.Location) syslogMessage {
+	msg := syslogMessage{Pri: -1}
+	rest := string(line)
+	if pri, after, ok := parseSyslogPRI(rest); ok {
+		msg.Pri, rest = pri, after
+	}
+	switch format {
+	case "rfc5424":
+		if !parseRFC5424(&msg, rest) {
+			msg.Msg = rest
+		}
+	case "rfc3164":
+		parseRFC3164(&msg, rest, now, loc)
+	default:
+		if !isRFC5424(rest) || !parseRFC5424(&msg, rest) {
+			parseRFC3164(&msg, rest, now, loc)
+		}
+	}
+	return msg
+}
+
+// parseSyslogPRI reads a leading "<N>" with 0 <= N <= 191.
+func parseSyslogPRI(s string) (int, string, bool) {
+	if len(s) < 3 || s[0] != '<' {
+		return 0, s, false
+	}
+	end := strings.IndexByte(s, '>')
+	if end < 2 || end > 4 {
+		return 0, s, false
+	}
+	pri, err := strconv.Atoi(s[1:end])
+	if err != nil || pri < 0 || pri > 191 {
+		return 0, s, false
+	}
+	return pri, s[end+1:], true
+}
+
+// isRFC5424 reports whether the text after PRI starts with a VERSION field.
+func isRFC5424(s string) bool {
+	sp := strings.IndexByte(s, ' ')
+	if sp < 1 || sp > 3 {
+		return false
+	}
+	_, err := strconv.Atoi(s[:sp])
+	return err == nil && s[0] != '0'
+}
+
+// parseRFC5424 parses "VERSION SP TIMESTAMP SP HOSTNAME SP APP-NAME SP PROCID
+// SP MSGID SP STRUCTURED-DATA [SP MSG]". It reports false when the header is
+// too short to be RFC 5424.
+func parseRFC5424(msg *syslogMessage, s string) bool {
+	parts := strings.SplitN(s, " ", 7)
+	if len(parts) < 7 || !isRFC5424(s) {
+		return false
+	}
+	nil5424 := func(v string) string {
+		if v == "-" {
+			return ""
+		}
+		return v
+	}
+	if ts := parts[1]; ts != "-" {
+		if t, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+			msg.Timestamp = t
+		}
+	}
+	msg.Hostname = nil5424(parts[2])
+	msg.AppName = nil5424(parts[3])
+	msg.ProcID = nil5424(parts[4])
+	msg.MsgID = nil5424(parts[5])
+
+	rest := parts[6]
+	if strings.HasPrefix(rest, "-") {
+		rest = rest[1:]
+	} else {
+		sd, after, err := parseStructuredData(rest)
+		if err != nil {
+			// Keep the unparsed structured data in the message.
+			msg.Msg = rest
+			return true
+		}
+		msg.Structured, rest = sd, after
+	}
+	rest = strings.TrimPrefix(rest, " ")
+	msg.Msg = strings.TrimPrefix(rest, "\ufeff")
+	return true
+}
+
+// parseStructuredData reads one or more "[SD-ID PARAM="VALUE" ...]" elements.
+func parseStructuredData(s string) (map[string]map[string]string, string, error) {
+	out := map[string]map[string]string{}
+	for strings.HasPrefix(s, "[") {
+		s = s[1:]
+		end := strings.IndexAny(s, " ]")
+		if end <= 0 {
+			return nil, "", errors.New("structured data: missing SD-ID")
+		}
+		id := s[:end]
+		params := map[string]string{}
+		s = s[end:]
+		for {
+			s = strings.TrimLeft(s, " ")
+			if s == "" {
+				return nil, "", errors.New("structured data: unterminated element")
+			}
+			if s[0] == ']' {
+				s = s[1:]
+				break
+			}
+			eq := strings.IndexByte(s, '=')
+			if eq <= 0 || len(s) < eq+2 || s[eq+1] != '"' {
+				return nil, "", fmt.Errorf("structured data: bad param in %q", id)
+			}
+			name := s[:eq]
+			s = s[eq+2:]
+			var val strings.Builder
+			closed := false
+			for i := 0; i < len(s); i++ {
+				c := s[i]
+				if c == '\\' && i+1 < len(s) && strings.IndexByte(`"\]`, s[i+1]) >= 0 {
+					val.WriteByte(s[i+1])
+					i++
+					continue
+				}
+				if c == '"' {
+					s, closed = s[i+1:], true
+					break
+				}
+				val.WriteByte(c)
+			}
+			if !closed {
+				return nil, "", fmt.Errorf("structured data: unterminated value of %s.%s", id, name)
+			}
+			params[name] = val.String()
+		}
+		out[id] = params
+	}
+	return out, s, nil
+}
+
+// parseRFC3164 parses the BSD format "TIMESTAMP HOSTNAME TAG[PID]: MSG", with
+// each part optional as real senders make it: a missing timestamp leaves it
+// zero, a missing hostname is recognised by the tag's shape, and text with no
+// tag is all message. Besides "Mmm dd hh:mm:ss" it accepts an RFC 3339
+// timestamp, which rsyslog and syslog-ng send in their high-precision mode.
+func parseRFC3164(msg *syslogMessage, s string, now time.Time, loc *time.Location) {
+	if len(s) >= 15 {
+		if t, err := time.ParseInLocation(time.Stamp, s[:15], loc); err == nil {
+			msg.Timestamp = syslogYear(t, now)
+			s = strings.TrimPrefix(s[15:], " ")
+		}
+	}
+	if msg.Timestamp.IsZero() {
+		if tok, after, ok := strings.Cut(s, " "); ok {
+			if t, err := time.Parse(time.RFC3339Nano, tok); err == nil {
+				msg.Timestamp, s = t, after
+			}
+		}
+	}
+	// HOSTNAME, unless the first token is already the tag.
+	if tok, after, ok := strings.Cut(s, " "); ok && !msg.Timestamp.IsZero() && !isSyslogTag(tok) {
+		msg.Hostname, s = tok, after
+	}
+	if tok, after, ok := strings.Cut(s, " "); ok && isSyslogTag(tok) {
+		tag := strings.TrimSuffix(tok, ":")
+		if open := strings.IndexByte(tag, '['); open > 0 && strings.HasSuffix(tag, "]") {
+			msg.ProcID = tag[open+1 : len(tag)-1]
+			tag = tag[:open]
+		}
+		msg.AppName, s = tag, after
+	}
+	msg.Msg = s
+}
+
+// isSyslogTag reports whether tok looks like "app:" or "app[123]:".
+func isSyslogTag(tok string) bool {
+	if !strings.HasSuffix(tok, ":") || len(tok) < 2 || len(tok) > 64 {
+		return false
+	}
+	tag := tok[:len(tok)-1]
+	if open := strings.IndexByte(tag, '['); open >= 0 {
+		return open > 0 && strings.HasSuffix(tag, "]")
+	}
+	return !strings.ContainsAny(tag, "=\"")
+}
+
+// syslogYear gives an RFC 3164 timestamp, which has no year, the year that
+// puts it closest to now: December messages read in January belong to last
+// year.
+func syslogYear(t, now time.Time) time.Time {
+	t = t.AddDate(now.Year(), 0, 0)
+	if t.After(now.Add(24 * time.Hour)) {
+		t = t.AddDate(-1, 0, 0)
+	}
+	return t
+}
+
+// readSyslogFrame reads one message from a stream (RFC 6587). A frame shaped
+// "LEN SP <PRI>..." is octet-counted; anything else runs to the next newline.
+// Messages over max bytes are truncated and the rest of the frame is
+// discarded, so the stream stays aligned.
+func readSyslogFrame(br *bufio.Reader, max int) ([]byte, error) {
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			return nil, err
+		}
+		if b[0] != '\n' && b[0] != '\r' && b[0] != 0 {
+			break
+		}
+		_, _ = br.ReadByte()
+	}
+	if isOctetCounted(br) {
+		return readOctetCounted(br, max)
+	}
+	var out []byte
+	for {
+		chunk, err := br.ReadSlice('\n')
+		if room := max - len(out); room > 0 {
+			out = append(out, chunk[:min(len(chunk), room)]...)
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil && (err != io.EOF || len(out) == 0) {
+			return nil, err
+		}
+		return bytes.TrimRight(out, "\r\n"), nil
+	}
+}
+
+// isOctetCounted reports whether the next frame is "LEN SP <PRI>...". Checking
+// for the PRI as well as the digits keeps a newline-framed message that
+// happens to start with a number from being read as a length.
+func isOctetCounted(br *bufio.Reader) bool {
+	head, _ := br.Peek(12)
+	i := 0
+	for i < len(head) && head[i] >= '0' && head[i] <= '9' {
+		i++
+	}
+	return i > 0 && i < 10 && i+1 < len(head) && head[i] == ' ' && head[i+1] == '<'
+}
+
+func readOctetCounted(br *bufio.Reader, max int) ([]byte, error) {
+	head, err := br.ReadSlice(' ')
+	if err != nil {
+		return nil, fmt.Errorf("syslog frame: %w", err)
+	}
+	n, err := strconv.Atoi(string(head[:len(head)-1]))
+	if err != nil || n <= 0 || len(head) > 10 {
+		return nil, fmt.Errorf("syslog frame: bad length %q", head)
+	}
+	keep := min(n, max)
+	out := make([]byte, keep)
+	if _, err := io.ReadFull(br, out); err != nil {
+		return nil, err
+	}
+	if _, err := br.Discard(n - keep); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,322 @@
+package signalsources
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/VersusControl/versus-incident/pkg/core"
+)
+
+func TestParseSyslog(t *testing.T) {
+	now := time.Date(2025, 1, 3, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name, line, format string
+		want               syslogMessage
+	}{
+		{
+			name: "rfc5424 with structured data and BOM",
+			line: `<165>1 2025-01-03T10:00:00.123Z mymachine evntslog 42 ID47 [exampleSDID@32473 iut="3" eventSource="App \"x\""][origin ip="192.0.2.1"] ` + "\ufeff" + `An application event`,
+			want: syslogMessage{Pri: 165, Timestamp: time.Date(2025, 1, 3, 10, 0, 0, 123e6, time.UTC), Hostname: "mymachine", AppName: "evntslog", ProcID: "42", MsgID: "ID47",
+				Structured: map[string]map[string]string{"exampleSDID@32473": {"iut": "3", "eventSource": `App "x"`}, "origin": {"ip": "192.0.2.1"}},
+				Msg:        "An application event"},
+		},
+		{
+			name: "rfc5424 with nil values",
+			line: `<34>1 - - su - - - 'su root' failed`,
+			want: syslogMessage{Pri: 34, AppName: "su", Msg: "'su root' failed"},
+		},
+		{
+			name: "rfc3164 with host and tag",
+			line: `<86>Jan  3 11:59:00 web-1 sshd[1234]: Failed password for root`,
+			want: syslogMessage{Pri: 86, Timestamp: time.Date(2025, 1, 3, 11, 59, 0, 0, time.UTC), Hostname: "web-1", AppName: "sshd", ProcID: "1234", Msg: "Failed password for root"},
+		},
+		{
+			name: "rfc3164 from last year",
+			line: `<13>Dec 31 23:59:59 router kernel: link down`,
+			want: syslogMessage{Pri: 13, Timestamp: time.Date(2024, 12, 31, 23, 59, 59, 0, time.UTC), Hostname: "router", AppName: "kernel", Msg: "link down"},
+		},
+		{
+			name: "rfc3164 without hostname",
+			line: `<13>Jan  3 11:00:00 cron[7]: job done`,
+			want: syslogMessage{Pri: 13, Timestamp: time.Date(2025, 1, 3, 11, 0, 0, 0, time.UTC), AppName: "cron", ProcID: "7", Msg: "job done"},
+		},
+		{
+			name: "rfc3164 with an RFC 3339 timestamp",
+			line: `<11>2025-01-03T09:00:00+02:00 db postgres: out of memory`,
+			want: syslogMessage{Pri: 11, Timestamp: time.Date(2025, 1, 3, 7, 0, 0, 0, time.UTC), Hostname: "db", AppName: "postgres", Msg: "out of memory"},
+		},
+		{
+			name: "no PRI",
+			line: `just some text`,
+			want: syslogMessage{Pri: -1, Msg: "just some text"},
+		},
+		{
+			name:   "forced rfc5424 keeps what does not parse",
+			line:   `<14>hello world`,
+			format: "rfc5424",
+			want:   syslogMessage{Pri: 14, Msg: "hello world"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := parseSyslog([]byte(tc.line), tc.format, now, time.UTC)
+			if !got.Timestamp.Equal(tc.want.Timestamp) {
+				t.Errorf("Timestamp = %v, want %v", got.Timestamp, tc.want.Timestamp)
+			}
+			got.Timestamp, tc.want.Timestamp = time.Time{}, time.Time{}
+			if got.Pri != tc.want.Pri || got.Hostname != tc.want.Hostname || got.AppName != tc.want.AppName ||
+				got.ProcID != tc.want.ProcID || got.MsgID != tc.want.MsgID || got.Msg != tc.want.Msg {
+				t.Errorf("got %+v\nwant %+v", got, tc.want)
+			}
+			for id, params := range tc.want.Structured {
+				for k, v := range params {
+					if got.Structured[id][k] != v {
+						t.Errorf("SD %s.%s = %q, want %q", id, k, got.Structured[id][k], v)
+					}
+				}
+			}
+		})
+	}
+}
+
+// TestReadSyslogFrame mixes octet-counted and newline-framed messages on one
+// stream, as RFC 6587 allows, and checks an oversized frame is truncated
+// without losing alignment.
+func TestReadSyslogFrame(t *testing.T) {
+	long := "<13>" + strings.Repeat("x", 40)
+	stream := "17 <13>octet counted" +
+		"<14>newline framed\r\n" +
+		"12 apples\n" +
+		"44 " + long +
+		"<15>realigned\n" +
+		"<16>no newline"
+	br := bufio.NewReader(strings.NewReader(stream))
+	want := []string{"<13>octet counted", "<14>newline framed", "12 apples", long[:20], "<15>realigned", "<16>no newline"}
+	for i, w := range want {
+		got, err := readSyslogFrame(br, 20)
+		if err != nil || string(got) != w {
+			t.Fatalf("frame %d = %q, %v; want %q", i, got, err, w)
+		}
+	}
+	if _, err := readSyslogFrame(br, 20); err == nil {
+		t.Fatal("read past the end of the stream")
+	}
+}
+
+// pullUntil pulls until n signals arrived or the deadline passes.
+func pullUntil(t *testing.T, src core.SignalSource, n int) []core.Signal {
+	t.Helper()
+	var got []core.Signal
+	deadline := time.Now().Add(5 * time.Second)
+	for len(got) < n && time.Now().Before(deadline) {
+		sigs, _, err := src.Pull(context.Background(), time.Time{})
+		if err != nil {
+			t.Fatalf("Pull: %v", err)
+		}
+		got = append(got, sigs...)
+		if len(got) < n {
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+	if len(got) != n {
+		t.Fatalf("pulled %d signals, want %d", len(got), n)
+	}
+	return got
+}
+
+// TestSyslogSource_Transports sends one message over each of UDP, TCP and TLS
+// and checks the mapping onto Signal.
+func TestSyslogSource_Transports(t *testing.T) {
+	certFile, keyFile := writeTestCert(t)
+	src, err := NewSyslogSource("net", config.AgentSyslogSourceConfig{
+		UDPListen: "127.0.0.1:0", TCPListen: "127.0.0.1:0", TLSListen: "127.0.0.1:0",
+		TLSCertFile: certFile, TLSKeyFile: keyFile,
+	})
+	if err != nil {
+		t.Fatalf("NewSyslogSource: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := src.Listen(ctx); err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+
+	udp, err := net.Dial("udp", src.udpAddr.String())
+	if err != nil {
+		t.Fatalf("dial udp: %v", err)
+	}
+	defer udp.Close()
+	_, _ = udp.Write([]byte(`<165>1 2025-01-03T10:00:00Z fw-1 firewall - DROP [meta zone="dmz"] packet dropped`))
+	first := pullUntil(t, src, 1)[0]
+	if first.Source != "syslog:net" || first.Message != "packet dropped" || first.Severity != "notice" ||
+		first.Fields[core.FieldService] != "firewall" || first.Fields["hostname"] != "fw-1" || first.Fields["msgid"] != "DROP" ||
+		first.Fields["meta.zone"] != "dmz" || first.Fields["facility"] != "local4" || first.Fields["remote_addr"] != "127.0.0.1" {
+		t.Fatalf("udp signal = %+v", first)
+	}
+
+	tcp, err := net.Dial("tcp", src.tcpAddr.String())
+	if err != nil {
+		t.Fatalf("dial tcp: %v", err)
+	}
+	defer tcp.Close()
+	_, _ = tcp.Write([]byte("<11>Jan  3 11:59:00 db postgres[9]: out of memory\n"))
+	if got := pullUntil(t, src, 1)[0]; got.Severity != "err" || got.Fields[core.FieldService] != "postgres" || got.Message != "out of memory" {
+		t.Fatalf("tcp signal = %+v", got)
+	}
+
+	tlsConn, err := tls.Dial("tcp", src.tlsAddr.String(), &tls.Config{InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatalf("dial tls: %v", err)
+	}
+	defer tlsConn.Close()
+	_, _ = tlsConn.Write([]byte("31 <14>1 - - app - - - over tls ok"))
+	if got := pullUntil(t, src, 1)[0]; got.Message != "over tls ok" || got.Fields[core.FieldService] != "app" {
+		t.Fatalf("tls signal = %+v", got)
+	}
+}
+
+// TestSyslogSource_TCPBackpressure queues more than fits and checks TCP waits
+// for the queue instead of dropping.
+func TestSyslogSource_TCPBackpressure(t *testing.T) {
+	src, err := NewSyslogSource("bp", config.AgentSyslogSourceConfig{TCPListen: "127.0.0.1:0", QueueSize: 2, MaxPullRecords: 1})
+	if err != nil {
+		t.Fatalf("NewSyslogSource: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := src.Listen(ctx); err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	conn, err := net.Dial("tcp", src.tcpAddr.String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	for i := 0; i < 5; i++ {
+		_, _ = conn.Write([]byte("<14>app: message " + string(rune('a'+i)) + "\n"))
+		time.Sleep(10 * time.Millisecond)
+	}
+	got := pullUntil(t, src, 5)
+	for i, sig := range got {
+		if want := "message " + string(rune('a'+i)); sig.Message != want {
+			t.Fatalf("signal %d = %q, want %q", i, sig.Message, want)
+		}
+	}
+}
+
+// TestSyslogSource_ConnectionLimits checks a connection over max_connections
+// is closed, a silent connection is closed after idle_timeout and frees its
+// slot, and a TLS client that never handshakes is dropped within it too.
+func TestSyslogSource_ConnectionLimits(t *testing.T) {
+	certFile, keyFile := writeTestCert(t)
+	src, err := NewSyslogSource("lim", config.AgentSyslogSourceConfig{
+		TCPListen: "127.0.0.1:0", TLSListen: "127.0.0.1:0", TLSCertFile: certFile, TLSKeyFile: keyFile,
+		MaxConnections: 1, IdleTimeout: "300ms",
+	})
+	if err != nil {
+		t.Fatalf("NewSyslogSource: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := src.Listen(ctx); err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	// closedWithin reports whether the server closes conn before d.
+	closedWithin := func(conn net.Conn, d time.Duration) bool {
+		_ = conn.SetReadDeadline(time.Now().Add(d))
+		_, err := conn.Read(make([]byte, 1))
+		var ne net.Error
+		return err != nil && !(errors.As(err, &ne) && ne.Timeout())
+	}
+
+	first, err := net.Dial("tcp", src.tcpAddr.String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer first.Close()
+	_, _ = first.Write([]byte("<14>app: held open\n"))
+	pullUntil(t, src, 1)
+
+	second, err := net.Dial("tcp", src.tcpAddr.String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer second.Close()
+	if !closedWithin(second, 100*time.Millisecond) {
+		t.Fatal("a connection over max_connections was served")
+	}
+
+	if !closedWithin(first, 2*time.Second) {
+		t.Fatal("an idle connection was not closed")
+	}
+
+	// The slot is free again; a raw TCP client on the TLS port that never
+	// starts the handshake takes it and is dropped after the idle timeout.
+	raw, err := net.Dial("tcp", src.tlsAddr.String())
+	if err != nil {
+		t.Fatalf("dial tls port: %v", err)
+	}
+	defer raw.Close()
+	start := time.Now()
+	if !closedWithin(raw, 2*time.Second) {
+		t.Fatal("a stalled TLS handshake was not closed")
+	}
+	if time.Since(start) < 200*time.Millisecond {
+		t.Fatalf("the stalled handshake was closed after %v, before the idle timeout", time.Since(start))
+	}
+}
+
+func TestNewSyslogSource_Validation(t *testing.T) {
+	for name, cfg := range map[string]config.AgentSyslogSourceConfig{
+		"no listener":     {},
+		"tls without key": {TLSListen: ":6514", TLSCertFile: "cert.pem"},
+		"bad format":      {UDPListen: ":514", Format: "cef"},
+		"bad idle":        {TCPListen: ":601", IdleTimeout: "soon"},
+	} {
+		if _, err := NewSyslogSource("x", cfg); err == nil {
+			t.Errorf("%s: NewSyslogSource succeeded", name)
+		}
+	}
+}
+
+// writeTestCert writes a self-signed localhost certificate and its key.
+func writeTestCert(t *testing.T) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate: %v", err)
+	}
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	_ = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	_ = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+	return certFile, keyFile
+}
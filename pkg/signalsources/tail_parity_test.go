@@ -0,0 +1,349 @@
+package signalsources
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/config"
+	"github.com/VersusControl/versus-incident/pkg/core"
+)
+
+// tailEvent is one stored event of the fake Loki, Graylog and Splunk servers
+// below.
+type tailEvent struct {
+	id  string
+	msg string
+	ts  time.Time
+}
+
+// fakeTailStore holds events and answers "events in [from, to], oldest first,
+// at most limit" the way all three backends do.
+type fakeTailStore struct {
+	mu     sync.Mutex
+	events []tailEvent
+}
+
+func (f *fakeTailStore) add(id, msg string, ts time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.events = append(f.events, tailEvent{id: id, msg: msg, ts: ts})
+}
+
+func (f *fakeTailStore) query(from, to time.Time, limit int) []tailEvent {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []tailEvent
+	for _, e := range f.events {
+		if !e.ts.Before(from) && !e.ts.After(to) {
+			out = append(out, e)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].ts.Before(out[j].ts) })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+func newFakeLoki(t *testing.T, store *fakeTailStore) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		start, _ := strconv.ParseInt(q.Get("start"), 10, 64)
+		end, _ := strconv.ParseInt(q.Get("end"), 10, 64)
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		var values [][]string
+		for _, e := range store.query(time.Unix(0, start), time.Unix(0, end), limit) {
+			values = append(values, []string{itoa(e.ts.UnixNano()), e.msg})
+		}
+		w.Write(lokiBody(t, []lokiStreamResult{{Stream: map[string]string{"app": "api"}, Values: values}}))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newFakeGraylog(t *testing.T, store *fakeTailStore) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		from, _ := time.Parse("2006-01-02T15:04:05.000Z", q.Get("from"))
+		to, _ := time.Parse("2006-01-02T15:04:05.000Z", q.Get("to"))
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		var hits []graylogSearchHit
+		for _, e := range store.query(from, to, limit) {
+			hits = append(hits, graylogSearchHit{Index: "graylog_0", Message: map[string]interface{}{
+				"_id":       e.id,
+				"message":   e.msg,
+				"timestamp": e.ts.Format("2006-01-02T15:04:05.000Z"),
+			}})
+		}
+		w.Write(graylogBody(t, hits))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newFakeSplunk(t *testing.T, store *fakeTailStore) *httptest.Server {
+	t.Helper()
+	epoch := func(v string) time.Time {
+		f, _ := strconv.ParseFloat(v, 64)
+		return time.UnixMilli(int64(f*1000 + 0.5)).UTC()
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		form, err := parseForm(string(body))
+		if err != nil {
+			t.Fatalf("parse form: %v", err)
+		}
+		limit, _ := strconv.Atoi(form["count"])
+		var results []map[string]interface{}
+		for _, e := range store.query(epoch(form["earliest_time"]), epoch(form["latest_time"]), limit) {
+			results = append(results, map[string]interface{}{
+				"_bkt":  "main~1~ABC",
+				"_cd":   e.id,
+				"index": "main",
+				"_raw":  e.msg,
+				"_time": e.ts.Format(time.RFC3339Nano),
+			})
+		}
+		w.Write(splunkExportBody(t, results))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// tailParitySource is one of the three sources under test, built against its
+// fake server.
+type tailParitySource interface {
+	Pull(ctx context.Context, since time.Time) ([]core.Signal, time.Time, error)
+	Rewind(ctx context.Context) error
+	Commit(ctx context.Context) error
+	SetTailDedupBackend(b TailDedupBackend)
+}
+
+// tailParityNames are the sources tailParitySource builds.
+var tailParityNames = []string{"loki", "graylog", "splunk"}
+
+// tailParitySources returns a constructor per source name, each reading store
+// through its own fake server with the clock pinned at now.
+func tailParitySources(t *testing.T, store *fakeTailStore, now time.Time, window string, pageSize int) map[string]func() tailParitySource {
+	t.Helper()
+	clock := func() time.Time { return now }
+	lokiURL := newFakeLoki(t, store).URL
+	graylogURL := newFakeGraylog(t, store).URL
+	splunkURL := newFakeSplunk(t, store).URL
+	return map[string]func() tailParitySource{
+		"loki": func() tailParitySource {
+			src, err := NewLokiSource("p", config.AgentLokiSourceConfig{
+				Address: lokiURL, Query: `{app="api"}`, PageSize: pageSize, ReorderWindow: window,
+			})
+			if err != nil {
+				t.Fatalf("new loki: %v", err)
+			}
+			src.nowFn = clock
+			return src
+		},
+		"graylog": func() tailParitySource {
+			src, err := NewGraylogSource("p", config.AgentGraylogSourceConfig{
+				Address: graylogURL, PageSize: pageSize, ReorderWindow: window,
+			})
+			if err != nil {
+				t.Fatalf("new graylog: %v", err)
+			}
+			src.nowFn = clock
+			return src
+		},
+		"splunk": func() tailParitySource {
+			src, err := NewSplunkSource("p", config.AgentSplunkSourceConfig{
+				Address: splunkURL, Search: "index=main", PageSize: pageSize, ReorderWindow: window,
+			})
+			if err != nil {
+				t.Fatalf("new splunk: %v", err)
+			}
+			src.nowFn = clock
+			return src
+		},
+	}
+}
+
+// TestTailParity_ReorderWindowRecoversLateEventsOnce pins the contract the
+// Loki, Graylog and Splunk tails now share with Elasticsearch and CloudWatch
+// Logs: an event that lands late inside reorder_window below the cursor is
+// delivered on the next tick, the events re-read alongside it are not
+// delivered twice, and one further back than the window is not reached.
+func TestTailParity_ReorderWindowRecoversLateEventsOnce(t *testing.T) {
+	base := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	for _, name := range tailParityNames {
+		t.Run(name, func(t *testing.T) {
+			store := &fakeTailStore{}
+			store.add("1", "first", base)
+			store.add("2", "second", base.Add(20*time.Second))
+			src := tailParitySources(t, store, base.Add(time.Minute), "10s", 50)[name]()
+			ctx := context.Background()
+			sigs, cur, err := src.Pull(ctx, base.Add(-time.Second))
+			if err != nil {
+				t.Fatalf("tick1: %v", err)
+			}
+			if got := messages(sigs); len(got) != 2 {
+				t.Fatalf("tick1 = %v, want both events", got)
+			}
+			if want := base.Add(20 * time.Second); !cur.Equal(want) {
+				t.Fatalf("tick1 cursor = %v, want %v", cur, want)
+			}
+
+			store.add("in", "inside window", cur.Add(-5*time.Second))
+			store.add("out", "beyond window", cur.Add(-30*time.Second))
+
+			sigs, cur2, err := src.Pull(ctx, cur)
+			if err != nil {
+				t.Fatalf("tick2: %v", err)
+			}
+			if got := messages(sigs); len(got) != 1 || got[0] != "inside window" {
+				t.Fatalf("tick2 = %v, want only the late event inside the window", got)
+			}
+			if !cur2.Equal(cur) {
+				t.Errorf("tick2 cursor = %v, want it held at %v", cur2, cur)
+			}
+
+			sigs, _, err = src.Pull(ctx, cur2)
+			if err != nil {
+				t.Fatalf("tick3: %v", err)
+			}
+			if got := messages(sigs); len(got) != 0 {
+				t.Fatalf("tick3 re-delivered %v", got)
+			}
+		})
+	}
+}
+
+// TestTailParity_WalksPastOnePage checks a re-read span holding more events
+// than page_size is read to its end within the tick, instead of the first
+// page being re-read forever.
+func TestTailParity_WalksPastOnePage(t *testing.T) {
+	base := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	store := &fakeTailStore{}
+	for i := 0; i < 7; i++ {
+		store.add(strconv.Itoa(i), "event "+strconv.Itoa(i), base.Add(time.Duration(i)*time.Second))
+	}
+
+	for name, build := range tailParitySources(t, store, base.Add(time.Minute), "1m", 3) {
+		t.Run(name, func(t *testing.T) {
+			sigs, cur, err := build().Pull(context.Background(), base.Add(-time.Second))
+			if err != nil {
+				t.Fatalf("pull: %v", err)
+			}
+			if got := messages(sigs); len(got) != 7 {
+				t.Fatalf("got %v, want all 7 events", got)
+			}
+			if want := base.Add(6 * time.Second); !cur.Equal(want) {
+				t.Fatalf("cursor = %v, want %v", cur, want)
+			}
+		})
+	}
+}
+
+// TestTailParity_IdenticalLinesAtOneTimestamp checks events with the same
+// message and timestamp are each delivered once, across a page boundary that
+// splits them and on the tick that re-reads them.
+func TestTailParity_IdenticalLinesAtOneTimestamp(t *testing.T) {
+	base := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	store := &fakeTailStore{}
+	store.add("a", "before", base)
+	for i := 0; i < 3; i++ {
+		store.add("dup"+strconv.Itoa(i), "same line", base.Add(time.Second))
+	}
+	store.add("b", "after", base.Add(2*time.Second))
+
+	for name, build := range tailParitySources(t, store, base.Add(time.Minute), "1m", 4) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			src := build()
+			sigs, cur, err := src.Pull(ctx, base.Add(-time.Second))
+			if err != nil {
+				t.Fatalf("tick1: %v", err)
+			}
+			got := messages(sigs)
+			sort.Strings(got)
+			want := []string{"after", "before", "same line", "same line", "same line"}
+			if strings.Join(got, "|") != strings.Join(want, "|") {
+				t.Fatalf("tick1 = %v, want %v", got, want)
+			}
+
+			if sigs, _, err := src.Pull(ctx, cur); err != nil || len(sigs) != 0 {
+				t.Fatalf("tick2 = %v, %v; want nothing re-delivered", messages(sigs), err)
+			}
+		})
+	}
+}
+
+// TestTailParity_DedupSurvivesRestartAndRewind checks the ids are durable
+// only after Commit, so a restart neither re-delivers committed events nor
+// loses uncommitted ones, and that Rewind makes the window deliverable again.
+func TestTailParity_DedupSurvivesRestartAndRewind(t *testing.T) {
+	base := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	store := &fakeTailStore{}
+	store.add("1", "first", base)
+	store.add("2", "second", base.Add(time.Second))
+
+	for name, build := range tailParitySources(t, store, base.Add(time.Minute), "1m", 50) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			backend := newFakeDedupBackend()
+			since := base.Add(-time.Second)
+
+			a := build()
+			a.SetTailDedupBackend(backend)
+			sigs, cur, err := a.Pull(ctx, since)
+			if err != nil || len(sigs) != 2 {
+				t.Fatalf("first pull = %v, %v", messages(sigs), err)
+			}
+
+			// Killed before Commit: the restart delivers them again.
+			b := build()
+			b.SetTailDedupBackend(backend)
+			if sigs, _, _ := b.Pull(ctx, since); len(sigs) != 2 {
+				t.Fatalf("uncommitted pull after restart = %v, want both again", messages(sigs))
+			}
+			if err := b.Commit(ctx); err != nil {
+				t.Fatalf("commit: %v", err)
+			}
+
+			c := build()
+			c.SetTailDedupBackend(backend)
+			if sigs, _, _ := c.Pull(ctx, cur); len(sigs) != 0 {
+				t.Fatalf("committed pull after restart re-delivered %v", messages(sigs))
+			}
+
+			if err := c.Rewind(ctx); err != nil {
+				t.Fatalf("rewind: %v", err)
+			}
+			if sigs, _, _ := c.Pull(ctx, cur); len(sigs) != 2 {
+				t.Fatalf("pull after rewind = %v, want the window again", messages(sigs))
+			}
+		})
+	}
+}
+
+// TestSplunkEventID_PrefersBucketAndOffset pins the id Splunk events dedup
+// on, and the hashed fallback for transforming searches that drop it.
+func TestSplunkEventID_PrefersBucketAndOffset(t *testing.T) {
+	ts := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	got := splunkEventID(map[string]interface{}{"index": "main", "_bkt": "main~1~A", "_cd": "1:2"}, ts)
+	if got != "main|main~1~A|1:2" {
+		t.Errorf("id = %q", got)
+	}
+	a := splunkEventID(map[string]interface{}{"_raw": "x", "host": "h"}, ts)
+	b := splunkEventID(map[string]interface{}{"_raw": "x", "host": "h"}, ts)
+	c := splunkEventID(map[string]interface{}{"_raw": "y", "host": "h"}, ts)
+	if a != b || a == c || len(a) != 32 {
+		t.Errorf("fallback ids = %q, %q, %q", a, b, c)
+	}
+}
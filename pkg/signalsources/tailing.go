@@ -2,6 +2,8 @@ package signalsources
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"sort"
 	"strconv"
@@ -73,9 +75,9 @@ func ClampCursor(candidate, since, now time.Time) time.Time {
 //
 // TailDedup is that set, made durable. It is deliberately one type shared by
 // every tailing source rather than a copy per source: the sources differ only
-// in what they call the id (SigNoz `id`, Elasticsearch `_id`, CloudWatch
-// `eventId`), and a per-source copy is how the pruning rule and the Rewind
-// contract drift apart.
+// in what they call the id (SigNoz `id`, Elasticsearch and Graylog `_id`,
+// CloudWatch `eventId`, Splunk `_bkt`/`_cd`, a hash for Loki), and a
+// per-source copy is how the pruning rule and the Rewind contract drift apart.
 //
 // It stores IDS AND TIMESTAMPS ONLY — never a message body, never a field map.
 // Nothing that leaves this process through the backend can carry log payload.
@@ -88,6 +90,18 @@ type DedupRow struct {
 	TS time.Time
 }
 
+// HashedRowID derives a stable dedup id for a row whose backend assigns none
+// (a Loki entry) or omits it from a response, from the parts that identify the
+// row. The parts are hashed so that no log payload reaches the dedup backend.
+func HashedRowID(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
 // TailDedupBackend persists one source's boundary dedup set. It degrades the
 // same way the worker's CursorStore does: no backend means in-memory only, so
 // a development setup keeps working without Redis and behaves exactly as it
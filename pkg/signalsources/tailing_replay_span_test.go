@@ -51,16 +51,42 @@ func TestApplyTailReplaySpan_AddsThePersistIntervalToEveryTail(t *testing.T) {
 		t.Fatalf("new cloudwatchlogs source: %v", err)
 	}
 
-	sources := []core.SignalSource{es, sz, cw, nonTailingSource{}}
+	lk, err := NewLokiSource("lk", config.AgentLokiSourceConfig{
+		Address:       "http://localhost:3100",
+		Query:         `{app="x"}`,
+		ReorderWindow: "10s",
+	})
+	if err != nil {
+		t.Fatalf("new loki source: %v", err)
+	}
+	gl, err := NewGraylogSource("gl", config.AgentGraylogSourceConfig{
+		Address: "http://localhost:9000",
+	})
+	if err != nil {
+		t.Fatalf("new graylog source: %v", err)
+	}
+	sp, err := NewSplunkSource("sp", config.AgentSplunkSourceConfig{
+		Address:       "https://localhost:8089",
+		Search:        "index=main",
+		ReorderWindow: "1m",
+	})
+	if err != nil {
+		t.Fatalf("new splunk source: %v", err)
+	}
+
+	sources := []core.SignalSource{es, sz, cw, lk, gl, sp, nonTailingSource{}}
 	widened := ApplyTailReplaySpan(sources, 30*time.Second)
-	if len(widened) != 3 {
-		t.Fatalf("widened %d sources (%v), want the 3 tailing ones", len(widened), widened)
+	if len(widened) != 6 {
+		t.Fatalf("widened %d sources (%v), want the 6 tailing ones", len(widened), widened)
 	}
 
 	want := map[string]TailWindow{
 		"elasticsearch:es":  {Source: "elasticsearch:es", Configured: 10 * time.Second, Effective: 40 * time.Second},
 		"signoz:sz":         {Source: "signoz:sz", Configured: 10 * time.Second, Effective: 40 * time.Second},
 		"cloudwatchlogs:cw": {Source: "cloudwatchlogs:cw", Configured: 0, Effective: 30 * time.Second},
+		"loki:lk":           {Source: "loki:lk", Configured: 10 * time.Second, Effective: 40 * time.Second},
+		"graylog:gl":        {Source: "graylog:gl", Configured: 0, Effective: 30 * time.Second},
+		"splunk:sp":         {Source: "splunk:sp", Configured: time.Minute, Effective: 90 * time.Second},
 	}
 	for _, got := range widened {
 		exp, ok := want[got.Source]
@@ -44,6 +44,8 @@ func analysisPagerBackends() map[string]func(*testing.T) storage.Provider {
 		"memory":   newMemoryPager,
 		"file":     newFilePager,
 		"postgres": func(t *testing.T) storage.Provider { return newTestPostgres(t) },
+		"redis":    func(t *testing.T) storage.Provider { return newTestRedis(t) },
+		"sqlite":   func(t *testing.T) storage.Provider { return newTestSQLite(t) },
 	}
 }
 
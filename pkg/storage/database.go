@@ -1,15 +1,25 @@
 package storage
 
-// DatabaseOptions mirrors the database sub-block of `storage:`. The
-// database backend is config-only today — instantiating it returns
-// ErrUnsupported. The struct exists so the config layer can validate
-// its shape.
+import "fmt"
+
+// DatabaseOptions mirrors the database sub-block of `storage:`. Only the
+// sqlite driver is implemented; postgres has its own storage type, and any
+// other driver returns ErrUnsupported.
 type DatabaseOptions struct {
-	Driver string // postgres | mysql | sqlite
-	DSN    string
+	Driver string // sqlite (default) | postgres | mysql
+	DSN    string // sqlite: file path or file: URI; default DefaultDataDir/DefaultSQLiteFile
+	Keys   *Keyring
 }
 
-// NewDatabase is a placeholder that returns ErrUnsupported.
-func NewDatabase(_ DatabaseOptions) (Provider, error) {
-	return nil, ErrUnsupported
+// NewDatabase opens the configured SQL driver. sqlite (also the default
+// when Driver is empty) returns the SQLite backend.
+func NewDatabase(opts DatabaseOptions) (Provider, error) {
+	switch opts.Driver {
+	case "", "sqlite", "sqlite3":
+		return NewSQLite(SQLiteOptions{Path: opts.DSN, Keys: opts.Keys})
+	case "postgres":
+		return nil, fmt.Errorf("storage: database driver %q: %w (use storage.type: postgres)", opts.Driver, ErrUnsupported)
+	default:
+		return nil, fmt.Errorf("storage: database driver %q: %w", opts.Driver, ErrUnsupported)
+	}
 }
@@ -0,0 +1,422 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/VersusControl/versus-incident/pkg/core"
+	"github.com/VersusControl/versus-incident/pkg/utils"
+)
+
+// Encryption at rest: envelope encryption of the sensitive half of what a
+// backend stores. Blob data, IncidentRecord.Content and the model output of
+// an AnalysisRecord (tool calls, finding, raw response) are sealed with
+// AES-256-GCM on the way into a backend and opened on the way out, so every
+// caller above the Provider sees plaintext. The typed incident columns —
+// title, service, source, tags, status, timestamps — stay clear, so listing,
+// filtering, paging and the column half of search keep working; free text
+// inside a sealed payload is no longer searchable on Postgres or SQLite.
+//
+// A sealed value is a token
+//
+//	vsenc:1:<key id>:<base64 of nonce || ciphertext>
+//
+// bound (as GCM additional data) to what it belongs to — the blob name or
+// the incident or analysis id — so a sealed value copied onto another record
+// fails to open. The key id names the key that sealed it, which is what
+// makes rotation work: new writes use the active key, and any key still in
+// the keyring opens what it sealed. Values written before encryption was
+// enabled carry no token and read back unchanged.
+//
+// The memory backend keeps nothing at rest and ignores the keyring.
+
+// ErrEncryptionKey is returned when a sealed value cannot be opened: no
+// keyring is configured, the key id that sealed it is not in the keyring,
+// or the key does not authenticate it (a wrong key or tampered data).
+var ErrEncryptionKey = errors.New("storage: encryption key unavailable")
+
+// EncryptionKeySize is the length of every key in bytes (AES-256).
+const EncryptionKeySize = 32
+
+const (
+	sealedPrefix = "vsenc:1:"
+	// sealedContentKey holds the token inside a sealed Content map. The map
+	// keeps its JSON object shape so a JSONB column accepts it.
+	sealedContentKey = "_versus_sealed"
+)
+
+var encryptionKeyIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// EncryptionOptions configures encryption at rest. Keys come from KeyFile,
+// Keys, or both, one `<id>:<base64 key>` entry per line (Keys also accepts
+// commas); blank lines and lines starting with # are skipped. ActiveKey
+// names the key new writes are sealed with; empty means the last key
+// listed.
+//
+// With Enable false but keys present the keyring only opens: existing
+// sealed values stay readable and new writes are stored in the clear, which
+// is how encryption is turned off again.
+type EncryptionOptions struct {
+	Enable    bool
+	KeyFile   string
+	Keys      string
+	ActiveKey string
+}
+
+// Keyring holds the AES-GCM keys by id. A nil *Keyring seals nothing and
+// opens only plaintext, so backends call its methods unconditionally.
+type Keyring struct {
+	active string
+	aeads  map[string]cipher.AEAD
+}
+
+// NewKeyring builds a keyring from keys by id. active names the sealing key
+// and must be one of keys; an empty active gives an open-only keyring.
+func NewKeyring(keys map[string][]byte, active string) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("storage: encryption: no keys")
+	}
+	k := &Keyring{active: active, aeads: make(map[string]cipher.AEAD, len(keys))}
+	for id, key := range keys {
+		if !encryptionKeyIDPattern.MatchString(id) {
+			return nil, fmt.Errorf("storage: encryption: key id %q must be 1-64 letters, digits, '.', '_' or '-'", id)
+		}
+		if len(key) != EncryptionKeySize {
+			return nil, fmt.Errorf("storage: encryption: key %q is %d bytes, want %d", id, len(key), EncryptionKeySize)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("storage: encryption: key %q: %w", id, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("storage: encryption: key %q: %w", id, err)
+		}
+		k.aeads[id] = aead
+	}
+	if active != "" && k.aeads[active] == nil {
+		return nil, fmt.Errorf("storage: encryption: active key %q is not in the keyring", active)
+	}
+	return k, nil
+}
+
+// LoadKeyring reads the keys opts points at. It returns nil, nil when
+// encryption is off and no keys are configured.
+func LoadKeyring(opts EncryptionOptions) (*Keyring, error) {
+	text := opts.Keys
+	if opts.KeyFile != "" {
+		raw, err := os.ReadFile(opts.KeyFile) // #nosec G304 — operator-supplied key file
+		if err != nil {
+			return nil, fmt.Errorf("storage: encryption: read key file: %w", err)
+		}
+		text = string(raw) + "\n" + text
+	}
+	keys, last, err := parseEncryptionKeys(text)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		if opts.Enable {
+			return nil, errors.New("storage: encryption is enabled but no keys are configured (key_file or STORAGE_ENCRYPTION_KEYS)")
+		}
+		return nil, nil
+	}
+	active := ""
+	if opts.Enable {
+		active = opts.ActiveKey
+		if active == "" {
+			active = last
+		}
+	}
+	return NewKeyring(keys, active)
+}
+
+// parseEncryptionKeys parses `<id>:<base64 key>` entries separated by
+// newlines or commas and returns them with the id of the last one.
+func parseEncryptionKeys(text string) (map[string][]byte, string, error) {
+	keys := make(map[string][]byte)
+	last := ""
+	for _, line := range strings.FieldsFunc(text, func(r rune) bool { return r == '\n' || r == ',' }) {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		id, encoded, ok := strings.Cut(line, ":")
+		id = strings.TrimSpace(id)
+		if !ok || id == "" {
+			return nil, "", fmt.Errorf("storage: encryption: key entry must be <id>:<base64 key>")
+		}
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, "", fmt.Errorf("storage: encryption: key %q is not valid base64", id)
+		}
+		if _, dup := keys[id]; dup {
+			return nil, "", fmt.Errorf("storage: encryption: key id %q listed twice", id)
+		}
+		keys[id] = key
+		last = id
+	}
+	return keys, last, nil
+}
+
+// ActiveKeyID returns the id of the sealing key, or "" when the keyring
+// only opens (or is nil).
+func (k *Keyring) ActiveKeyID() string {
+	if k == nil {
+		return ""
+	}
+	return k.active
+}
+
+// KeyIDs returns every key id in the keyring, sorted.
+func (k *Keyring) KeyIDs() []string {
+	if k == nil {
+		return nil
+	}
+	ids := make([]string, 0, len(k.aeads))
+	for id := range k.aeads {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// IsSealed reports whether data is a sealed token.
+func IsSealed(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(sealedPrefix))
+}
+
+// SealBytes encrypts plain with the active key, bound to aad. Without an
+// active key it returns plain unchanged.
+func (k *Keyring) SealBytes(aad string, plain []byte) ([]byte, error) {
+	if k.ActiveKeyID() == "" {
+		return plain, nil
+	}
+	aead := k.aeads[k.active]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("storage: encryption nonce: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, plain, []byte(aad))
+	out := make([]byte, 0, len(sealedPrefix)+len(k.active)+1+base64.StdEncoding.EncodedLen(len(sealed)))
+	out = append(out, sealedPrefix...)
+	out = append(out, k.active...)
+	out = append(out, ':')
+	return base64.StdEncoding.AppendEncode(out, sealed), nil
+}
+
+// OpenBytes decrypts a token SealBytes produced for aad. Data that is not
+// a token is returned unchanged, so values written before encryption was
+// enabled stay readable.
+func (k *Keyring) OpenBytes(aad string, data []byte) ([]byte, error) {
+	if !IsSealed(data) {
+		return data, nil
+	}
+	id, encoded, ok := strings.Cut(string(data[len(sealedPrefix):]), ":")
+	if !ok {
+		return nil, fmt.Errorf("storage: open %s: malformed sealed value", aad)
+	}
+	if k == nil {
+		return nil, fmt.Errorf("storage: open %s: sealed with key %q but encryption is not configured: %w", aad, id, ErrEncryptionKey)
+	}
+	aead := k.aeads[id]
+	if aead == nil {
+		return nil, fmt.Errorf("storage: open %s: key %q is not in the keyring: %w", aad, id, ErrEncryptionKey)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("storage: open %s: malformed sealed value", aad)
+	}
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(aad))
+	if err != nil {
+		return nil, fmt.Errorf("storage: open %s: key %q does not authenticate it: %w", aad, id, ErrEncryptionKey)
+	}
+	return plain, nil
+}
+
+// The additional data each kind of sealed value is bound to.
+func blobAAD(name string) string   { return "blob:" + name }
+func incidentAAD(id string) string { return "incident:" + id }
+func analysisAAD(id string) string { return "analysis:" + id }
+
+// PatternSamplesAAD is the additional data a learned pattern's samples are
+// sealed with by stores that write them outside Provider (the Postgres
+// catalog store).
+func PatternSamplesAAD(id string) string { return "pattern:" + id }
+
+func (k *Keyring) sealBlob(name string, data []byte) ([]byte, error) {
+	return k.SealBytes(blobAAD(name), data)
+}
+
+func (k *Keyring) openBlob(name string, data []byte) ([]byte, error) {
+	return k.OpenBytes(blobAAD(name), data)
+}
+
+// openBlobs opens every blob in place.
+func (k *Keyring) openBlobs(blobs []Blob) error {
+	for i := range blobs {
+		data, err := k.openBlob(blobs[i].Name, blobs[i].Data)
+		if err != nil {
+			return err
+		}
+		blobs[i].Data = data
+	}
+	return nil
+}
+
+// sealIncident returns a copy of rec whose Content is sealed, or rec itself
+// when there is nothing to seal. The payload's severity stays clear beside
+// the token so severity filters and counts keep working on an unedited row.
+func (k *Keyring) sealIncident(rec *IncidentRecord) (*IncidentRecord, error) {
+	if k.ActiveKeyID() == "" || len(rec.Content) == 0 || sealedContent(rec.Content) != "" {
+		return rec, nil
+	}
+	plain, err := json.Marshal(rec.Content)
+	if err != nil {
+		return nil, fmt.Errorf("storage: marshal incident content: %w", err)
+	}
+	token, err := k.SealBytes(incidentAAD(rec.ID), plain)
+	if err != nil {
+		return nil, err
+	}
+	out := *rec
+	out.Content = map[string]interface{}{sealedContentKey: string(token)}
+	if sev := utils.ExtractSeverity(rec.Content); sev != "" {
+		out.Content["severity"] = sev
+	}
+	return &out, nil
+}
+
+// sealIncidents seals every record, returning a new slice only when one
+// of them changed.
+func (k *Keyring) sealIncidents(recs []*IncidentRecord) ([]*IncidentRecord, error) {
+	if k.ActiveKeyID() == "" {
+		return recs, nil
+	}
+	out := make([]*IncidentRecord, len(recs))
+	for i, rec := range recs {
+		sealed, err := k.sealIncident(rec)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = sealed
+	}
+	return out, nil
+}
+
+// sealedContent returns the token a sealed Content map carries, or "".
+func sealedContent(content map[string]interface{}) string {
+	token, _ := content[sealedContentKey].(string)
+	if !strings.HasPrefix(token, sealedPrefix) {
+		return ""
+	}
+	return token
+}
+
+// openIncident opens rec's Content in place.
+func (k *Keyring) openIncident(rec *IncidentRecord) error {
+	token := sealedContent(rec.Content)
+	if token == "" {
+		return nil
+	}
+	plain, err := k.OpenBytes(incidentAAD(rec.ID), []byte(token))
+	if err != nil {
+		return err
+	}
+	var content map[string]interface{}
+	if err := json.Unmarshal(plain, &content); err != nil {
+		return fmt.Errorf("storage: decode sealed content of %s: %w", rec.ID, err)
+	}
+	rec.Content = content
+	return nil
+}
+
+func (k *Keyring) openIncidents(recs []*IncidentRecord) error {
+	for _, rec := range recs {
+		if err := k.openIncident(rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// analysisOutput is the part of an AnalysisRecord that is sealed: what the
+// model saw and said, as opposed to the bookkeeping around the run.
+type analysisOutput struct {
+	ToolCalls   []AnalysisToolCall `json:"tool_calls,omitempty"`
+	Finding     *core.AIFinding    `json:"finding,omitempty"`
+	RawResponse string             `json:"raw_response,omitempty"`
+}
+
+// sealAnalysis returns a copy of rec with its model output moved into
+// Sealed, or rec itself when there is nothing to seal.
+func (k *Keyring) sealAnalysis(rec *AnalysisRecord) (*AnalysisRecord, error) {
+	if k.ActiveKeyID() == "" || rec.Sealed != "" ||
+		(len(rec.ToolCalls) == 0 && rec.Finding == nil && rec.RawResponse == "") {
+		return rec, nil
+	}
+	plain, err := json.Marshal(analysisOutput{ToolCalls: rec.ToolCalls, Finding: rec.Finding, RawResponse: rec.RawResponse})
+	if err != nil {
+		return nil, fmt.Errorf("storage: marshal analysis output: %w", err)
+	}
+	token, err := k.SealBytes(analysisAAD(rec.ID), plain)
+	if err != nil {
+		return nil, err
+	}
+	out := *rec
+	out.ToolCalls, out.Finding, out.RawResponse = nil, nil, ""
+	out.Sealed = string(token)
+	return &out, nil
+}
+
+func (k *Keyring) sealAnalyses(recs []*AnalysisRecord) ([]*AnalysisRecord, error) {
+	if k.ActiveKeyID() == "" {
+		return recs, nil
+	}
+	out := make([]*AnalysisRecord, len(recs))
+	for i, rec := range recs {
+		sealed, err := k.sealAnalysis(rec)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = sealed
+	}
+	return out, nil
+}
+
+// openAnalysis restores rec's model output from Sealed in place.
+func (k *Keyring) openAnalysis(rec *AnalysisRecord) error {
+	if rec.Sealed == "" {
+		return nil
+	}
+	plain, err := k.OpenBytes(analysisAAD(rec.ID), []byte(rec.Sealed))
+	if err != nil {
+		return err
+	}
+	var out analysisOutput
+	if err := json.Unmarshal(plain, &out); err != nil {
+		return fmt.Errorf("storage: decode sealed analysis %s: %w", rec.ID, err)
+	}
+	rec.ToolCalls, rec.Finding, rec.RawResponse = out.ToolCalls, out.Finding, out.RawResponse
+	rec.Sealed = ""
+	return nil
+}
+
+func (k *Keyring) openAnalyses(recs []*AnalysisRecord) error {
+	for _, rec := range recs {
+		if err := k.openAnalysis(rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,256 @@
+package storage_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/core"
+	"github.com/VersusControl/versus-incident/pkg/storage"
+)
+
+func testKey(b byte) []byte { return bytes.Repeat([]byte{b}, storage.EncryptionKeySize) }
+
+func testKeyring(t *testing.T, active string, ids ...string) *storage.Keyring {
+	t.Helper()
+	keys := make(map[string][]byte)
+	for i, id := range ids {
+		keys[id] = testKey(byte(i + 1))
+	}
+	k, err := storage.NewKeyring(keys, active)
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	return k
+}
+
+// TestKeyring_SealOpen covers the token round trip, its binding to the
+// additional data, and what a missing or unknown key reports.
+func TestKeyring_SealOpen(t *testing.T) {
+	k := testKeyring(t, "k1", "k1")
+	sealed, err := k.SealBytes("blob:teams", []byte(`{"a":1}`))
+	if err != nil || !storage.IsSealed(sealed) || bytes.Contains(sealed, []byte(`"a"`)) {
+		t.Fatalf("SealBytes = %q, %v", sealed, err)
+	}
+	if plain, err := k.OpenBytes("blob:teams", sealed); err != nil || string(plain) != `{"a":1}` {
+		t.Fatalf("OpenBytes = %q, %v", plain, err)
+	}
+	if _, err := k.OpenBytes("blob:members", sealed); !errors.Is(err, storage.ErrEncryptionKey) {
+		t.Fatalf("other aad: err = %v, want ErrEncryptionKey", err)
+	}
+	if plain, err := k.OpenBytes("blob:teams", []byte(`{"legacy":true}`)); err != nil || string(plain) != `{"legacy":true}` {
+		t.Fatalf("plaintext passes through = %q, %v", plain, err)
+	}
+
+	var none *storage.Keyring
+	if out, _ := none.SealBytes("blob:teams", []byte("x")); string(out) != "x" {
+		t.Fatalf("nil keyring sealed %q", out)
+	}
+	if _, err := none.OpenBytes("blob:teams", sealed); !errors.Is(err, storage.ErrEncryptionKey) {
+		t.Fatalf("nil keyring: err = %v, want ErrEncryptionKey", err)
+	}
+	if _, err := testKeyring(t, "k2", "k2").OpenBytes("blob:teams", sealed); !errors.Is(err, storage.ErrEncryptionKey) {
+		t.Fatalf("unknown key id: err = %v, want ErrEncryptionKey", err)
+	}
+	if out, _ := testKeyring(t, "", "k1").SealBytes("blob:teams", []byte("x")); string(out) != "x" {
+		t.Fatalf("open-only keyring sealed %q", out)
+	}
+}
+
+// TestLoadKeyring reads keys from a file and the env-style list, defaults
+// the active key to the last one, and rejects bad entries.
+func TestLoadKeyring(t *testing.T) {
+	enc := func(b byte) string { return base64.StdEncoding.EncodeToString(testKey(b)) }
+	file := filepath.Join(t.TempDir(), "keys")
+	if err := os.WriteFile(file, []byte("# rotated 2026-10\nk1:"+enc(1)+"\n\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	k, err := storage.LoadKeyring(storage.EncryptionOptions{Enable: true, KeyFile: file, Keys: "k2:" + enc(2)})
+	if err != nil || k.ActiveKeyID() != "k2" || strings.Join(k.KeyIDs(), ",") != "k1,k2" {
+		t.Fatalf("LoadKeyring = %v %v, %v", k.ActiveKeyID(), k.KeyIDs(), err)
+	}
+	if k, err := storage.LoadKeyring(storage.EncryptionOptions{Enable: true, Keys: "k1:" + enc(1) + ",k2:" + enc(2), ActiveKey: "k1"}); err != nil || k.ActiveKeyID() != "k1" {
+		t.Fatalf("explicit active = %v, %v", k.ActiveKeyID(), err)
+	}
+	if k, err := storage.LoadKeyring(storage.EncryptionOptions{Keys: "k1:" + enc(1)}); err != nil || k.ActiveKeyID() != "" {
+		t.Fatalf("disabled with keys = %v, %v; want an open-only keyring", k.ActiveKeyID(), err)
+	}
+	if k, err := storage.LoadKeyring(storage.EncryptionOptions{}); k != nil || err != nil {
+		t.Fatalf("nothing configured = %v, %v", k, err)
+	}
+
+	for name, opts := range map[string]storage.EncryptionOptions{
+		"no keys":        {Enable: true},
+		"short key":      {Enable: true, Keys: "k1:" + base64.StdEncoding.EncodeToString([]byte("short"))},
+		"not base64":     {Enable: true, Keys: "k1:???"},
+		"no id":          {Enable: true, Keys: enc(1)},
+		"bad id":         {Enable: true, Keys: "k 1:" + enc(1)},
+		"duplicate id":   {Enable: true, Keys: "k1:" + enc(1) + ",k1:" + enc(2)},
+		"unknown active": {Enable: true, Keys: "k1:" + enc(1), ActiveKey: "k9"},
+		"missing file":   {Enable: true, KeyFile: filepath.Join(t.TempDir(), "absent")},
+	} {
+		if _, err := storage.LoadKeyring(opts); err == nil {
+			t.Errorf("%s: LoadKeyring succeeded", name)
+		}
+	}
+}
+
+// runEncryption writes through a keyed backend and checks the records read
+// back in the clear, plaintext written before encryption stays readable,
+// nothing opens without the key, and rotation keeps old records readable
+// while new ones use the new key. open builds the backend over the same
+// store with the given keyring.
+func runEncryption(t *testing.T, open func(keys *storage.Keyring) (storage.Provider, error)) {
+	t.Helper()
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	mustOpen := func(keys *storage.Keyring) storage.Provider {
+		t.Helper()
+		p, err := open(keys)
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		return p
+	}
+
+	p := mustOpen(nil)
+	if err := p.SaveIncident(&storage.IncidentRecord{ID: "inc-legacy", Title: "Legacy", CreatedAt: now.Add(-time.Hour), Content: map[string]interface{}{"note": "clear"}}); err != nil {
+		t.Fatalf("SaveIncident legacy: %v", err)
+	}
+	_ = p.Close()
+
+	k1 := testKeyring(t, "k1", "k1")
+	p = mustOpen(k1)
+	content := map[string]interface{}{"customer": "acme-4242", "severity": "critical"}
+	if err := p.SaveIncident(&storage.IncidentRecord{ID: "inc-1", Title: "DB down", Service: "api", CreatedAt: now, Content: content}); err != nil {
+		t.Fatalf("SaveIncident: %v", err)
+	}
+	if err := p.WriteBlob("teams", []byte(`{"customer":"acme-4242"}`)); err != nil {
+		t.Fatalf("WriteBlob: %v", err)
+	}
+	if err := p.SaveAnalysis(&storage.AnalysisRecord{
+		ID: "an-1", IncidentID: "inc-1", RequestedAt: now, Status: "ok",
+		RawResponse: "acme-4242 is out of disk", Finding: &core.AIFinding{Summary: "disk full for acme-4242"},
+	}); err != nil {
+		t.Fatalf("SaveAnalysis: %v", err)
+	}
+
+	if got, err := p.GetIncident("inc-1"); err != nil || got.Content["customer"] != "acme-4242" || got.Title != "DB down" {
+		t.Fatalf("GetIncident = %+v, %v", got, err)
+	}
+	if got, err := p.GetIncident("inc-legacy"); err != nil || got.Content["note"] != "clear" {
+		t.Fatalf("legacy incident = %+v, %v", got, err)
+	}
+	if list, err := p.ListIncidents(0); err != nil || len(list) != 2 || list[0].Content["customer"] != "acme-4242" {
+		t.Fatalf("ListIncidents = %+v, %v", list, err)
+	}
+	if b, err := p.ReadBlob("teams"); err != nil || string(b) != `{"customer":"acme-4242"}` {
+		t.Fatalf("ReadBlob = %q, %v", b, err)
+	}
+	if blobs, err := p.ListBlobs("teams"); err != nil || len(blobs) != 1 || string(blobs[0].Data) != `{"customer":"acme-4242"}` {
+		t.Fatalf("ListBlobs = %+v, %v", blobs, err)
+	}
+	if a, err := p.GetAnalysis("an-1"); err != nil || a.RawResponse != "acme-4242 is out of disk" || a.Finding == nil || a.Sealed != "" {
+		t.Fatalf("GetAnalysis = %+v, %v", a, err)
+	}
+	if list, err := p.ListAnalysesByIncident("inc-1", 0); err != nil || len(list) != 1 || list[0].Finding == nil {
+		t.Fatalf("ListAnalysesByIncident = %+v, %v", list, err)
+	}
+	// The clear columns and the payload's severity still drive search.
+	if s, ok := p.(storage.Searcher); ok {
+		for _, q := range []string{"DB down", "service:api", "severity:critical"} {
+			if got, err := s.SearchIncidents(q, 0); err != nil || len(got) != 1 || got[0].ID != "inc-1" {
+				t.Errorf("SearchIncidents(%q) = %d, %v", q, len(got), err)
+			}
+		}
+	}
+	_ = p.Close()
+
+	// Without the key nothing sealed opens.
+	if p, err := open(nil); err == nil {
+		_, err = p.ReadBlob("teams")
+		if !errors.Is(err, storage.ErrEncryptionKey) {
+			t.Errorf("ReadBlob without key: err = %v, want ErrEncryptionKey", err)
+		}
+		_ = p.Close()
+	} else if !errors.Is(err, storage.ErrEncryptionKey) {
+		t.Fatalf("open without key: %v", err)
+	}
+
+	// Rotate: k2 seals new writes, k1 still opens old ones.
+	p = mustOpen(testKeyring(t, "k2", "k1", "k2"))
+	if got, err := p.GetIncident("inc-1"); err != nil || got.Content["customer"] != "acme-4242" {
+		t.Fatalf("after rotation GetIncident = %+v, %v", got, err)
+	}
+	if err := p.WriteBlob("members", []byte(`{"m":1}`)); err != nil {
+		t.Fatalf("WriteBlob after rotation: %v", err)
+	}
+	_ = p.Close()
+
+	// Retiring k1 without rewriting leaves k1's records unreadable, and
+	// only those.
+	k2only, err := storage.NewKeyring(map[string][]byte{"k2": testKey(2)}, "k2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err = open(k2only)
+	if err != nil {
+		if !errors.Is(err, storage.ErrEncryptionKey) {
+			t.Fatalf("open with k2 only: %v", err)
+		}
+		return
+	}
+	defer p.Close()
+	if b, err := p.ReadBlob("members"); err != nil || string(b) != `{"m":1}` {
+		t.Fatalf("k2 blob = %q, %v", b, err)
+	}
+	if _, err := p.ReadBlob("teams"); !errors.Is(err, storage.ErrEncryptionKey) {
+		t.Fatalf("k1 blob with k2 only: err = %v, want ErrEncryptionKey", err)
+	}
+}
+
+func TestFileEncryption(t *testing.T) {
+	dir := t.TempDir()
+	runEncryption(t, func(keys *storage.Keyring) (storage.Provider, error) {
+		return storage.NewFile(storage.FileOptions{DataDir: dir, Keys: keys})
+	})
+	// Nothing customer-identifying reached the disk.
+	_ = filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if raw, _ := os.ReadFile(path); bytes.Contains(raw, []byte("acme-4242")) {
+			t.Errorf("%s holds plaintext", filepath.Base(path))
+		}
+		return nil
+	})
+}
+
+func TestSQLiteEncryption(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "versus.db")
+	runEncryption(t, func(keys *storage.Keyring) (storage.Provider, error) {
+		return storage.NewSQLite(storage.SQLiteOptions{Path: path, Keys: keys})
+	})
+}
+
+func TestRedisEncryption(t *testing.T) {
+	opts := testRedisOptions(t)
+	runEncryption(t, func(keys *storage.Keyring) (storage.Provider, error) {
+		o := opts
+		o.Keys = keys
+		return storage.NewRedis(o)
+	})
+}
+
+func TestPostgresEncryption(t *testing.T) {
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	newTestPostgres(t) // skips without a DSN; starts from empty tables
+	runEncryption(t, func(keys *storage.Keyring) (storage.Provider, error) {
+		return storage.NewPostgres(storage.PostgresOptions{DSN: dsn, Keys: keys})
+	})
+}
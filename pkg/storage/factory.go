@@ -3,6 +3,7 @@ package storage
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 )
 
 // Config mirrors the root-level `storage:` block in config.yaml. It is
@@ -14,11 +15,17 @@ type Config struct {
 	Redis    RedisOptions
 	Database DatabaseOptions
 	Postgres PostgresOptions
+
+	// Encryption seals blobs, incident content and analysis output in
+	// whichever backend is chosen; see encryption.go.
+	Encryption EncryptionOptions
 }
 
-// New constructs the configured backend. The postgres backend stores
-// incidents, analyses, and blobs in a single database; the file and redis
-// backends keep blobs alongside their other records.
+// New constructs the configured backend. The postgres and database
+// (SQLite) backends store incidents, analyses, and blobs in a single
+// database; the file and redis backends keep blobs alongside their other
+// records. When c.Encryption configures keys, the chosen backend seals
+// blob data, incident content and analysis output with them.
 func New(c Config) (Provider, error) {
 	t := c.Type
 	if t == "" {
@@ -29,6 +36,16 @@ func New(c Config) (Provider, error) {
 	if t == "postgres" && c.Postgres.DSN == "" {
 		c.Postgres.DSN = os.Getenv("POSTGRES_DSN")
 	}
+	// DATABASE_DSN env var fallback for the database type.
+	if t == "database" && c.Database.DSN == "" {
+		c.Database.DSN = os.Getenv("DATABASE_DSN")
+	}
+
+	keys, err := LoadKeyring(c.Encryption)
+	if err != nil {
+		return nil, err
+	}
+	c.File.Keys, c.Redis.Keys, c.Database.Keys, c.Postgres.Keys = keys, keys, keys, keys
 
 	switch t {
 	case "file":
@@ -43,3 +60,37 @@ func New(c Config) (Provider, error) {
 		return nil, fmt.Errorf("storage: unknown type %q (expected file|redis|database|postgres)", t)
 	}
 }
+
+// Target names the store c points at, without credentials: the data
+// directory, the SQLite file, the Postgres host and database, or the Redis
+// address, database and key prefix. Two configs with the same Target share
+// one store.
+func (c Config) Target() string {
+	switch c.Type {
+	case "", "file":
+		dir := c.File.DataDir
+		if dir == "" {
+			dir = DefaultDataDir
+		}
+		return "file:" + dir
+	case "redis":
+		return fmt.Sprintf("redis:%s:%d/%d/%s", c.Redis.Host, c.Redis.Port, c.Redis.DB, c.Redis.KeyPrefix)
+	case "database":
+		dsn := c.Database.DSN
+		if dsn == "" {
+			dsn = os.Getenv("DATABASE_DSN")
+		}
+		if dsn == "" {
+			dsn = filepath.Join(DefaultDataDir, DefaultSQLiteFile)
+		}
+		return "database:" + dsn
+	case "postgres":
+		dsn := c.Postgres.DSN
+		if dsn == "" {
+			dsn = os.Getenv("POSTGRES_DSN")
+		}
+		return "postgres:" + redactDSN(dsn)
+	default:
+		return c.Type
+	}
+}
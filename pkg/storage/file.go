@@ -1,10 +1,13 @@
 package storage
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/fs"
+	"net/url"
 	"os"
 	"path/filepath"
 	"sort"
@@ -20,16 +23,19 @@ const MaxIncidentsDefault = 1000
 // FileOptions configures the file backend. Empty fields fall back to
 // sensible defaults.
 type FileOptions struct {
-	DataDir      string // default DefaultDataDir
-	MaxIncidents int    // default MaxIncidentsDefault
+	DataDir      string   // default DefaultDataDir
+	MaxIncidents int      // default MaxIncidentsDefault
+	Keys         *Keyring // seals blobs, incident content and analysis output; nil stores them clear
 }
 
 // fileProvider stores blobs as <DataDir>/<name>.json and incidents as
 // a single <DataDir>/incidents.json file kept in memory for fast list
-// queries. All writes are atomic (tmp + rename).
+// queries. All writes are atomic (tmp + rename), except timeline appends,
+// which add one line to the incident's log.
 type fileProvider struct {
 	dir          string
 	maxIncidents int
+	keys         *Keyring
 
 	mu        sync.RWMutex
 	incidents []*IncidentRecord // newest last; persisted as is
@@ -38,6 +44,9 @@ type fileProvider struct {
 	analysesMu     sync.RWMutex
 	analyses       []*AnalysisRecord // newest last
 	analysesLoaded bool
+
+	timelineMu sync.RWMutex
+	timeline   map[string][]*TimelineEntry // by incident, oldest first
 }
 
 // NewFile returns a Provider backed by the local filesystem.
@@ -53,13 +62,16 @@ func NewFile(opts FileOptions) (Provider, error) {
 	if max <= 0 {
 		max = MaxIncidentsDefault
 	}
-	p := &fileProvider{dir: dir, maxIncidents: max}
+	p := &fileProvider{dir: dir, maxIncidents: max, keys: opts.Keys}
 	if err := p.loadIncidents(); err != nil {
 		return nil, err
 	}
 	if err := p.loadAnalyses(); err != nil {
 		return nil, err
 	}
+	if err := p.loadTimeline(); err != nil {
+		return nil, err
+	}
 	return p, nil
 }
 
@@ -79,10 +91,14 @@ func (p *fileProvider) ReadBlob(name string) ([]byte, error) {
 	if err != nil {
 		return nil, fmt.Errorf("storage: read blob %s: %w", name, err)
 	}
-	return data, nil
+	return p.keys.openBlob(name, data)
 }
 
 func (p *fileProvider) WriteBlob(name string, data []byte) error {
+	data, err := p.keys.sealBlob(name, data)
+	if err != nil {
+		return err
+	}
 	target := p.blobPath(name)
 	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
 		return fmt.Errorf("storage: mkdir blob dir: %w", err)
@@ -102,6 +118,10 @@ func (p *fileProvider) WriteBlob(name string, data []byte) error {
 // untouched and the call returns written==false so the caller re-reads the
 // stored bytes via ReadBlob.
 func (p *fileProvider) CreateBlobIfAbsent(name string, data []byte) (bool, error) {
+	data, err := p.keys.sealBlob(name, data)
+	if err != nil {
+		return false, err
+	}
 	target := p.blobPath(name)
 	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
 		return false, fmt.Errorf("storage: mkdir blob dir: %w", err)
@@ -152,6 +172,11 @@ func (p *fileProvider) ListBlobs(prefix string) ([]Blob, error) {
 		if err != nil {
 			return err
 		}
+		// incidents.json, analyses.json and a legacy timeline.json hold
+		// this backend's records, not blobs.
+		if rel == incidentsFile || rel == analysesFile || rel == timelineFile {
+			return nil
+		}
 		name := strings.TrimSuffix(filepath.ToSlash(rel), ".json")
 		if !strings.HasPrefix(name, prefix) {
 			return nil
@@ -160,6 +185,9 @@ func (p *fileProvider) ListBlobs(prefix string) ([]Blob, error) {
 		if err != nil {
 			return fmt.Errorf("storage: read blob %s: %w", name, err)
 		}
+		if data, err = p.keys.openBlob(name, data); err != nil {
+			return err
+		}
 		out = append(out, Blob{Name: name, Data: data})
 		return nil
 	})
@@ -239,6 +267,9 @@ func (p *fileProvider) loadIncidents() error {
 	if err := json.Unmarshal(data, &f); err != nil {
 		return fmt.Errorf("storage: parse incidents: %w", err)
 	}
+	if err := p.keys.openIncidents(f.Incidents); err != nil {
+		return err
+	}
 	p.incidents = f.Incidents
 	// Defensive: sort by CreatedAt ascending so newest is last.
 	sort.SliceStable(p.incidents, func(i, j int) bool {
@@ -247,11 +278,17 @@ func (p *fileProvider) loadIncidents() error {
 	return nil
 }
 
+// persistIncidentsLocked writes every incident, sealing content on the way
+// out; p.incidents itself stays in the clear for in-process queries.
 func (p *fileProvider) persistIncidentsLocked() error {
+	incidents, err := p.keys.sealIncidents(p.incidents)
+	if err != nil {
+		return err
+	}
 	f := incidentsFileSchema{
 		Version:   incidentsFileVersion,
 		UpdatedAt: time.Now().UTC(),
-		Incidents: p.incidents,
+		Incidents: incidents,
 	}
 	data, err := json.MarshalIndent(f, "", "  ")
 	if err != nil {
@@ -282,10 +319,58 @@ func (p *fileProvider) SaveIncident(rec *IncidentRecord) error {
 
 	// Append + trim.
 	p.incidents = append(p.incidents, rec)
+	var evicted []*IncidentRecord
 	if over := len(p.incidents) - p.maxIncidents; over > 0 {
+		evicted = p.incidents[:over]
 		p.incidents = append([]*IncidentRecord(nil), p.incidents[over:]...)
 	}
-	return p.persistIncidentsLocked()
+	if err := p.persistIncidentsLocked(); err != nil {
+		return err
+	}
+	// The timeline goes with its incident, as the SQL cascade does.
+	for _, old := range evicted {
+		if err := p.dropTimeline(old.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BulkDeleteIncidents implements storage.BulkIncidentDeleter: the listed
+// incidents leave the document in one write, and their timelines go with
+// them as on eviction. Unknown ids are skipped.
+func (p *fileProvider) BulkDeleteIncidents(ids []string) ([]*IncidentRecord, error) {
+	want := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		want[id] = true
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var deleted []*IncidentRecord
+	kept := p.incidents[:0:0]
+	for _, rec := range p.incidents {
+		if want[rec.ID] {
+			deleted = append(deleted, rec)
+			continue
+		}
+		kept = append(kept, rec)
+	}
+	if len(deleted) == 0 {
+		return nil, nil
+	}
+	prev := p.incidents
+	p.incidents = kept
+	if err := p.persistIncidentsLocked(); err != nil {
+		p.incidents = prev
+		return nil, err
+	}
+	for _, rec := range deleted {
+		if err := p.dropTimeline(rec.ID); err != nil {
+			return deleted, err
+		}
+	}
+	return deleted, nil
 }
 
 func (p *fileProvider) UpdateIncidentAck(id string, ackedAt time.Time) error {
@@ -294,6 +379,9 @@ func (p *fileProvider) UpdateIncidentAck(id string, ackedAt time.Time) error {
 	for _, rec := range p.incidents {
 		if rec.ID == id {
 			t := ackedAt.UTC()
+			// Best-effort: a triggered, reopened or snoozed incident becomes
+			// acknowledged; one further along (or resolved) keeps its state.
+			_, _ = rec.Transition(StatusAcknowledged, t, nil)
 			rec.AckedAt = &t
 			return p.persistIncidentsLocked()
 		}
@@ -340,19 +428,7 @@ func (p *fileProvider) ListIncidents(limit int) ([]*IncidentRecord, error) {
 func (p *fileProvider) CountIncidents() (IncidentCounts, error) {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
-	var c IncidentCounts
-	for _, rec := range p.incidents {
-		if rec.Resolved {
-			continue
-		}
-		if rec.EffectiveOrigin() == OriginAIDetect {
-			c.AIDetect++
-		} else {
-			c.Webhook++
-		}
-	}
-	c.Total = c.AIDetect + c.Webhook
-	return c, nil
+	return incidentCountsOf(p.incidents), nil
 }
 
 // CountIncidentsByStatus implements the optional storage.IncidentPager
@@ -400,6 +476,66 @@ func (p *fileProvider) ListIncidentsPage(origin string, offset, limit int) ([]*I
 	return out, nil
 }
 
+// ---------------------------------------------------------------------------
+// Search (implements the optional storage.Searcher and
+// storage.IncidentSearchPager capabilities)
+// ---------------------------------------------------------------------------
+
+// SearchIncidents matches the query syntax of search_query.go in process over
+// the capped history: best match first, newest first among equals. An empty
+// query degrades to ListIncidents.
+func (p *fileProvider) SearchIncidents(query string, limit int) ([]*IncidentRecord, error) {
+	recs, err := p.ListIncidents(0)
+	if err != nil {
+		return nil, err
+	}
+	return limitIncidents(searchIncidentsInProcess(recs, query), limit), nil
+}
+
+// CountIncidentsMatching implements the optional storage.IncidentSearchPager
+// capability: the per-origin tally of UNRESOLVED matches.
+func (p *fileProvider) CountIncidentsMatching(query string) (IncidentCounts, error) {
+	recs, err := p.ListIncidents(0)
+	if err != nil {
+		return IncidentCounts{}, err
+	}
+	return incidentCountsOf(searchIncidentsInProcess(recs, query)), nil
+}
+
+// CountIncidentsMatchingByStatus implements the optional
+// storage.IncidentSearchPager capability: the search twin of
+// CountIncidentsByStatus.
+func (p *fileProvider) CountIncidentsMatchingByStatus(query string) (IncidentStatusCounts, error) {
+	recs, err := p.ListIncidents(0)
+	if err != nil {
+		return IncidentStatusCounts{}, err
+	}
+	return StatusCountsOf(searchIncidentsInProcess(recs, query)), nil
+}
+
+// SearchIncidentsPage implements the optional storage.IncidentSearchPager
+// capability: one page of the ranked matches, filtered to origin.
+func (p *fileProvider) SearchIncidentsPage(query, origin string, offset, limit int) ([]*IncidentRecord, error) {
+	if parseSearchQuery(query).empty() {
+		return p.ListIncidentsPage(origin, offset, limit)
+	}
+	recs, err := p.ListIncidents(0)
+	if err != nil {
+		return nil, err
+	}
+	return pageIncidents(searchIncidentsInProcess(recs, query), origin, offset, limit), nil
+}
+
+// SearchAnalyses matches the query against each analysis's JSON body, best
+// match first.
+func (p *fileProvider) SearchAnalyses(query string, limit int) ([]*AnalysisRecord, error) {
+	recs, err := p.ListAnalyses(0)
+	if err != nil {
+		return nil, err
+	}
+	return limitAnalyses(searchAnalysesInProcess(recs, query), limit), nil
+}
+
 func (p *fileProvider) Close() error { return nil }
 
 // ---------------------------------------------------------------------------
@@ -438,6 +574,9 @@ func (p *fileProvider) loadAnalyses() error {
 	if err := json.Unmarshal(data, &f); err != nil {
 		return fmt.Errorf("storage: parse analyses: %w", err)
 	}
+	if err := p.keys.openAnalyses(f.Analyses); err != nil {
+		return err
+	}
 	p.analyses = f.Analyses
 	sort.SliceStable(p.analyses, func(i, j int) bool {
 		return p.analyses[i].RequestedAt.Before(p.analyses[j].RequestedAt)
@@ -446,10 +585,14 @@ func (p *fileProvider) loadAnalyses() error {
 }
 
 func (p *fileProvider) persistAnalysesLocked() error {
+	analyses, err := p.keys.sealAnalyses(p.analyses)
+	if err != nil {
+		return err
+	}
 	f := analysesFileSchema{
 		Version:   analysesFileVersion,
 		UpdatedAt: time.Now().UTC(),
-		Analyses:  p.analyses,
+		Analyses:  analyses,
 	}
 	data, err := json.MarshalIndent(f, "", "  ")
 	if err != nil {
@@ -580,3 +723,223 @@ func (p *fileProvider) DeleteAnalysis(id string) error {
 	}
 	return ErrNotFound
 }
+
+// ---------------------------------------------------------------------------
+// Timeline (implements the optional storage.Timeline capability)
+// ---------------------------------------------------------------------------
+
+// Each incident's timeline is an append-only log of JSON lines,
+// <DataDir>/timeline/<incident id>.ndjson, so an append writes one line and
+// an evicted incident's entries go with one unlink.
+const (
+	timelineDir = "timeline"
+	// timelineFile is the single-file layout of earlier versions, split into
+	// per-incident logs on first open.
+	timelineFile        = "timeline.json"
+	timelineFileVersion = 1
+	// maxTimelinePerIncident caps the entries kept per incident. A log is
+	// rewritten with its newest entries once it holds half as many again,
+	// so the rewrite is paid once per maxTimelinePerIncident/2 appends.
+	maxTimelinePerIncident = 2000
+)
+
+type timelineFileSchema struct {
+	Version   int              `json:"version"`
+	UpdatedAt time.Time        `json:"updated_at"`
+	Entries   []*TimelineEntry `json:"entries"`
+}
+
+// timelinePath is the log of one incident. The id is path-escaped so it
+// can never name a file outside the timeline directory.
+func (p *fileProvider) timelinePath(incidentID string) string {
+	return filepath.Join(p.dir, timelineDir, url.PathEscape(incidentID)+".ndjson")
+}
+
+// loadTimeline reads every incident's log, removing those whose incident
+// has rolled out of the incident cap (while the process was down), and
+// splits a legacy timeline.json into logs. It runs after loadIncidents.
+func (p *fileProvider) loadTimeline() error {
+	p.mu.RLock()
+	known := make(map[string]bool, len(p.incidents))
+	for _, rec := range p.incidents {
+		known[rec.ID] = true
+	}
+	p.mu.RUnlock()
+
+	p.timelineMu.Lock()
+	defer p.timelineMu.Unlock()
+	p.timeline = make(map[string][]*TimelineEntry)
+	if err := os.MkdirAll(filepath.Join(p.dir, timelineDir), 0o755); err != nil {
+		return fmt.Errorf("storage: mkdir timeline dir: %w", err)
+	}
+	if err := p.migrateTimelineFileLocked(known); err != nil {
+		return err
+	}
+
+	files, err := os.ReadDir(filepath.Join(p.dir, timelineDir))
+	if err != nil {
+		return fmt.Errorf("storage: read timeline dir: %w", err)
+	}
+	for _, f := range files {
+		name := f.Name()
+		if f.IsDir() || !strings.HasSuffix(name, ".ndjson") {
+			continue
+		}
+		id, err := url.PathUnescape(strings.TrimSuffix(name, ".ndjson"))
+		if err != nil || !known[id] {
+			if err := os.Remove(filepath.Join(p.dir, timelineDir, name)); err != nil {
+				return fmt.Errorf("storage: drop orphaned timeline %s: %w", name, err)
+			}
+			continue
+		}
+		entries, err := readTimelineLog(p.timelinePath(id))
+		if err != nil {
+			return err
+		}
+		p.timeline[id] = entries
+	}
+	return nil
+}
+
+// readTimelineLog reads one incident's log in chronological order. A line
+// that does not parse — the torn tail of a write cut short by a crash — is
+// skipped.
+func readTimelineLog(path string) ([]*TimelineEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("storage: read timeline: %w", err)
+	}
+	var entries []*TimelineEntry
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 64*1024), len(data)+1)
+	for sc.Scan() {
+		var e TimelineEntry
+		if json.Unmarshal(sc.Bytes(), &e) == nil && e.ID != "" {
+			entries = insertTimelineSorted(entries, &e)
+		}
+	}
+	return entries, nil
+}
+
+// migrateTimelineFileLocked splits a legacy timeline.json into per-incident
+// logs, keeping only entries whose incident is still stored, then removes it.
+func (p *fileProvider) migrateTimelineFileLocked(known map[string]bool) error {
+	path := filepath.Join(p.dir, timelineFile)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("storage: read timeline: %w", err)
+	}
+	var f timelineFileSchema
+	if err := json.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("storage: parse timeline: %w", err)
+	}
+	byIncident := map[string][]*TimelineEntry{}
+	for _, e := range f.Entries {
+		if e != nil && known[e.IncidentID] {
+			byIncident[e.IncidentID] = insertTimelineSorted(byIncident[e.IncidentID], e)
+		}
+	}
+	for id, entries := range byIncident {
+		if err := p.writeTimelineLog(id, entries); err != nil {
+			return err
+		}
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("storage: remove migrated timeline: %w", err)
+	}
+	return nil
+}
+
+// writeTimelineLog replaces one incident's log with entries.
+func (p *fileProvider) writeTimelineLog(incidentID string, entries []*TimelineEntry) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return fmt.Errorf("storage: marshal timeline: %w", err)
+		}
+	}
+	if err := writeFileAtomicSync(p.timelinePath(incidentID), buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("storage: write timeline: %w", err)
+	}
+	return nil
+}
+
+// appendTimelineLog adds one line to an incident's log and syncs it.
+func (p *fileProvider) appendTimelineLog(e *TimelineEntry) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("storage: marshal timeline: %w", err)
+	}
+	f, err := os.OpenFile(p.timelinePath(e.IncidentID), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("storage: append timeline: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("storage: append timeline: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("storage: append timeline: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("storage: append timeline: %w", err)
+	}
+	return nil
+}
+
+// dropTimeline removes an incident's entries, in memory and on disk.
+func (p *fileProvider) dropTimeline(incidentID string) error {
+	p.timelineMu.Lock()
+	defer p.timelineMu.Unlock()
+	delete(p.timeline, incidentID)
+	if err := os.Remove(p.timelinePath(incidentID)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("storage: drop timeline: %w", err)
+	}
+	return nil
+}
+
+// AppendTimelineEntry holds the incident lock across the existence check and
+// the write, so an incident evicted meanwhile cannot leave an orphaned log.
+func (p *fileProvider) AppendTimelineEntry(e *TimelineEntry) error {
+	if err := e.validate(); err != nil {
+		return err
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	found := false
+	for _, rec := range p.incidents {
+		if rec.ID == e.IncidentID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return ErrNotFound
+	}
+	p.timelineMu.Lock()
+	defer p.timelineMu.Unlock()
+	cp := *e
+	if err := p.appendTimelineLog(&cp); err != nil {
+		return err
+	}
+	entries := insertTimelineSorted(p.timeline[cp.IncidentID], &cp)
+	if len(entries) >= maxTimelinePerIncident+maxTimelinePerIncident/2 {
+		entries = append([]*TimelineEntry(nil), entries[len(entries)-maxTimelinePerIncident:]...)
+		if err := p.writeTimelineLog(cp.IncidentID, entries); err != nil {
+			return err
+		}
+	}
+	p.timeline[cp.IncidentID] = entries
+	return nil
+}
+
+func (p *fileProvider) ListTimeline(incidentID string, limit int) ([]*TimelineEntry, error) {
+	p.timelineMu.RLock()
+	defer p.timelineMu.RUnlock()
+	return newestTimelineWindow(p.timeline[incidentID], limit), nil
+}
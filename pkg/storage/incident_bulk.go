@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+// Bulk incident operations: resolve, assign, tag and delete a set of
+// incidents by id. Each helper below uses the optional BulkIncidentUpdater
+// capability when the backend has it, so Postgres applies the change in one
+// set-based statement, and otherwise loads, changes and saves the records
+// one by one. Both paths return the records the operation actually changed;
+// unknown ids and records already in the requested state are skipped, so a
+// bulk call is idempotent.
+
+// MaxBulkIncidents bounds how many incidents one bulk operation may touch.
+const MaxBulkIncidents = 500
+
+// BulkIncidentUpdater is an optional capability a backend may implement on
+// top of Provider: apply one change to many incidents in a single statement
+// and return the changed records as they are after the change. The
+// semantics match the per-record fallbacks (ResolveIncidents,
+// AssignIncidents, TagIncidents, DeleteIncidents) exactly.
+type BulkIncidentUpdater interface {
+	BulkResolveIncidents(ids []string, at time.Time) ([]*IncidentRecord, error)
+	BulkAssignIncidents(ids []string, teamID *string, memberIDs *[]string) ([]*IncidentRecord, error)
+	BulkTagIncidents(ids []string, add, remove []string) ([]*IncidentRecord, error)
+	BulkIncidentDeleter
+}
+
+// BulkIncidentDeleter is the delete half of BulkIncidentUpdater, on its own
+// for a backend with no Lifecycle that can still delete incidents, such as
+// the file backend, which keeps them in one document.
+type BulkIncidentDeleter interface {
+	BulkDeleteIncidents(ids []string) ([]*IncidentRecord, error)
+}
+
+// ResolveIncidents moves every listed incident that is not yet resolved to
+// resolved at at, the same transition the single-incident resolve applies.
+func ResolveIncidents(p Provider, ids []string, at time.Time) ([]*IncidentRecord, error) {
+	if bu, ok := p.(BulkIncidentUpdater); ok {
+		return bu.BulkResolveIncidents(ids, at)
+	}
+	return updateEach(p, ids, func(r *IncidentRecord) bool {
+		changed, err := r.Transition(StatusResolved, at, nil)
+		return err == nil && changed
+	})
+}
+
+// AssignIncidents sets the assigned team and/or members on every listed
+// incident. A nil pointer leaves that half of the assignment alone; an empty
+// value clears it.
+func AssignIncidents(p Provider, ids []string, teamID *string, memberIDs *[]string) ([]*IncidentRecord, error) {
+	if bu, ok := p.(BulkIncidentUpdater); ok {
+		return bu.BulkAssignIncidents(ids, teamID, memberIDs)
+	}
+	var members []string
+	if memberIDs != nil && len(*memberIDs) > 0 {
+		members = slices.Clone(*memberIDs)
+	}
+	return updateEach(p, ids, func(r *IncidentRecord) bool {
+		changed := false
+		if teamID != nil && r.AssignedTeamID != *teamID {
+			r.AssignedTeamID = *teamID
+			changed = true
+		}
+		if memberIDs != nil && !slices.Equal(r.AssignedMemberIDs, members) {
+			r.AssignedMemberIDs = slices.Clone(members)
+			changed = true
+		}
+		return changed
+	})
+}
+
+// TagIncidents adds the add tags to, and removes the remove tags from, every
+// listed incident. add must already be normalized (NormalizeTags). An
+// incident the change would take past MaxIncidentTags is left unchanged.
+func TagIncidents(p Provider, ids []string, add, remove []string) ([]*IncidentRecord, error) {
+	if bu, ok := p.(BulkIncidentUpdater); ok {
+		return bu.BulkTagIncidents(ids, add, remove)
+	}
+	return updateEach(p, ids, func(r *IncidentRecord) bool {
+		next := slices.DeleteFunc(append(slices.Clone(r.Tags), add...), func(t string) bool {
+			return slices.Contains(remove, t)
+		})
+		tags, err := NormalizeTags(next)
+		if err != nil || slices.Equal(tags, r.Tags) {
+			return false
+		}
+		r.Tags = tags
+		return true
+	})
+}
+
+// DeleteIncidents deletes every listed incident, returning the records as
+// they were before deletion. The fallback deletes through the Lifecycle
+// capability; a backend with neither returns ErrUnsupported.
+func DeleteIncidents(p Provider, ids []string) ([]*IncidentRecord, error) {
+	if bd, ok := p.(BulkIncidentDeleter); ok {
+		return bd.BulkDeleteIncidents(ids)
+	}
+	lc, ok := p.(Lifecycle)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	var out []*IncidentRecord
+	for _, id := range ids {
+		rec, err := p.GetIncident(id)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return out, err
+		}
+		if err := lc.DeleteByID(DomainIncidents, id); err != nil {
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+			return out, err
+		}
+		out = append(out, rec)
+	}
+	return out, nil
+}
+
+// updateEach is the per-record fallback: load each incident, apply change,
+// and save it when change reports a difference. Unknown ids are skipped.
+func updateEach(p Provider, ids []string, change func(*IncidentRecord) bool) ([]*IncidentRecord, error) {
+	var out []*IncidentRecord
+	for _, id := range ids {
+		rec, err := p.GetIncident(id)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return out, err
+		}
+		if !change(rec) {
+			continue
+		}
+		if err := p.SaveIncident(rec); err != nil {
+			return out, err
+		}
+		out = append(out, rec)
+	}
+	return out, nil
+}
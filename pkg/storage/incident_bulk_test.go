@@ -0,0 +1,144 @@
+package storage_test
+
+// incident_bulk_test.go — the bulk helpers. The same scenario runs against
+// the memory backend (per-record fallback), the file backend (its own
+// BulkIncidentDeleter) and, when TEST_POSTGRES_DSN is set, Postgres
+// (set-based BulkIncidentUpdater), so every path agrees.
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/storage"
+)
+
+func TestBulkIncidents_Memory(t *testing.T) {
+	testBulkIncidents(t, storage.NewMemory())
+}
+
+func TestBulkIncidents_File(t *testing.T) {
+	dir := t.TempDir()
+	p, err := storage.NewFile(storage.FileOptions{DataDir: dir})
+	if err != nil {
+		t.Fatalf("NewFile: %v", err)
+	}
+	testBulkIncidents(t, p)
+
+	// The delete reached disk.
+	reopened, err := storage.NewFile(storage.FileOptions{DataDir: dir})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if _, err := reopened.GetIncident("bulk-a"); err != storage.ErrNotFound {
+		t.Fatalf("GetIncident after reopen = %v, want ErrNotFound", err)
+	}
+	if _, err := reopened.GetIncident("bulk-b"); err != nil {
+		t.Fatalf("GetIncident(bulk-b) after reopen: %v", err)
+	}
+}
+
+func TestBulkIncidents_Postgres(t *testing.T) {
+	p := newTestPostgres(t) // skips when TEST_POSTGRES_DSN is unset
+	if _, ok := p.(storage.BulkIncidentUpdater); !ok {
+		t.Fatal("postgres provider does not implement BulkIncidentUpdater")
+	}
+	testBulkIncidents(t, p)
+}
+
+func bulkIDs(recs []*storage.IncidentRecord) []string {
+	ids := make([]string, 0, len(recs))
+	for _, r := range recs {
+		ids = append(ids, r.ID)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func testBulkIncidents(t *testing.T, p storage.Provider) {
+	t.Helper()
+	now := time.Now().UTC().Truncate(time.Second)
+	at := func(d time.Duration) *time.Time { t := now.Add(d); return &t }
+	for _, r := range []*storage.IncidentRecord{
+		{ID: "bulk-a", CreatedAt: now, Tags: []string{"db"}},
+		{ID: "bulk-b", CreatedAt: now, Status: storage.StatusSnoozed, SnoozedUntil: at(time.Hour)},
+		{ID: "bulk-c", CreatedAt: now, Resolved: true, ResolvedAt: at(-time.Minute), Status: storage.StatusResolved, AssignedTeamID: "sre"},
+	} {
+		if err := p.SaveIncident(r); err != nil {
+			t.Fatalf("SaveIncident: %v", err)
+		}
+	}
+	all := []string{"bulk-a", "bulk-b", "bulk-c", "bulk-missing"}
+
+	resolved, err := storage.ResolveIncidents(p, all, now)
+	if err != nil {
+		t.Fatalf("ResolveIncidents: %v", err)
+	}
+	if got := bulkIDs(resolved); !reflect.DeepEqual(got, []string{"bulk-a", "bulk-b"}) {
+		t.Fatalf("resolved = %v, want the two open incidents", got)
+	}
+	b, _ := p.GetIncident("bulk-b")
+	if b.EffectiveStatus(now) != storage.StatusResolved || b.SnoozedUntil != nil || b.ResolvedAt == nil || !b.ResolvedAt.Equal(now) {
+		t.Fatalf("bulk-b after resolve = %+v", b)
+	}
+	if c, _ := p.GetIncident("bulk-c"); !c.ResolvedAt.Equal(now.Add(-time.Minute)) {
+		t.Fatalf("already-resolved bulk-c resolved_at moved to %v", c.ResolvedAt)
+	}
+
+	team := "sre"
+	assigned, err := storage.AssignIncidents(p, all, &team, nil)
+	if err != nil {
+		t.Fatalf("AssignIncidents: %v", err)
+	}
+	if got := bulkIDs(assigned); !reflect.DeepEqual(got, []string{"bulk-a", "bulk-b"}) {
+		t.Fatalf("assigned = %v, want the two incidents not yet on sre", got)
+	}
+	members := []string{"m1", "m2"}
+	if _, err := storage.AssignIncidents(p, []string{"bulk-a"}, nil, &members); err != nil {
+		t.Fatalf("AssignIncidents members: %v", err)
+	}
+	if a, _ := p.GetIncident("bulk-a"); a.AssignedTeamID != "sre" || !reflect.DeepEqual(a.AssignedMemberIDs, members) {
+		t.Fatalf("bulk-a assignment = %q %v", a.AssignedTeamID, a.AssignedMemberIDs)
+	}
+
+	tagged, err := storage.TagIncidents(p, all, []string{"Storm", "db"}, []string{"db"})
+	if err != nil {
+		t.Fatalf("TagIncidents: %v", err)
+	}
+	if got := bulkIDs(tagged); !reflect.DeepEqual(got, []string{"bulk-a", "bulk-b", "bulk-c"}) {
+		t.Fatalf("tagged = %v", got)
+	}
+	if a, _ := p.GetIncident("bulk-a"); !reflect.DeepEqual(a.Tags, []string{"Storm"}) {
+		t.Fatalf("bulk-a tags = %v, want [Storm]", a.Tags)
+	}
+	if again, _ := storage.TagIncidents(p, all, []string{"Storm"}, nil); len(again) != 0 {
+		t.Fatalf("repeat tag changed %v", bulkIDs(again))
+	}
+	cleared, _ := storage.TagIncidents(p, []string{"bulk-a"}, nil, []string{"Storm"})
+	if len(cleared) != 1 || cleared[0].Tags != nil {
+		t.Fatalf("removing the last tag = %+v, want nil tags", cleared)
+	}
+
+	tl, hasTimeline := p.(storage.Timeline)
+	if hasTimeline {
+		if err := tl.AppendTimelineEntry(&storage.TimelineEntry{ID: "tl-bulk-a", IncidentID: "bulk-a", Kind: storage.TimelineNote, Body: "looking"}); err != nil {
+			t.Fatalf("AppendTimelineEntry: %v", err)
+		}
+	}
+	deleted, err := storage.DeleteIncidents(p, []string{"bulk-a", "bulk-missing"})
+	if err != nil {
+		t.Fatalf("DeleteIncidents: %v", err)
+	}
+	if got := bulkIDs(deleted); !reflect.DeepEqual(got, []string{"bulk-a"}) {
+		t.Fatalf("deleted = %v", got)
+	}
+	if _, err := p.GetIncident("bulk-a"); err != storage.ErrNotFound {
+		t.Fatalf("GetIncident after delete = %v, want ErrNotFound", err)
+	}
+	if hasTimeline {
+		if entries, _ := tl.ListTimeline("bulk-a", 0); len(entries) != 0 {
+			t.Fatalf("timeline after delete = %d entries, want none", len(entries))
+		}
+	}
+}
@@ -45,6 +45,18 @@ func fullIncident() *storage.IncidentRecord {
 		Content:           map[string]interface{}{"summary": "elevated 5xx", "count": float64(42)},
 		AssignedTeamID:    "team-payments",
 		AssignedMemberIDs: []string{"u1", "u2", "u3"},
+		Severity:          "critical",
+		Priority:          storage.PriorityP1,
+		Labels:            map[string]string{"env": "prod", "region": "eu-west-1"},
+		Tags:              []string{"customer-facing", "payments"},
+		CustomFields: []storage.CustomField{
+			{Key: "customers_affected", Type: storage.CustomFieldNumber, Value: float64(1200)},
+			{Key: "postmortem", Type: storage.CustomFieldBool, Value: true},
+			{Key: "ticket", Type: storage.CustomFieldString, Value: "OPS-42"},
+		},
+		MergedInto: "inc-primary",
+		ParentID:   "inc-parent",
+		RelatedIDs: []string{"inc-a", "inc-b"},
 	}
 }
 
@@ -74,7 +86,11 @@ func runIncidentColumnRoundTrip(t *testing.T, p storage.Provider) {
 		got.OnCallError != want.OnCallError ||
 		got.NotifyStatus != want.NotifyStatus ||
 		got.NotifyError != want.NotifyError ||
-		got.AssignedTeamID != want.AssignedTeamID {
+		got.AssignedTeamID != want.AssignedTeamID ||
+		got.Severity != want.Severity ||
+		got.Priority != want.Priority ||
+		got.MergedInto != want.MergedInto ||
+		got.ParentID != want.ParentID {
 		t.Fatalf("scalar mismatch:\n got=%+v\nwant=%+v", got, want)
 	}
 	if !got.CreatedAt.Equal(want.CreatedAt) {
@@ -98,6 +114,18 @@ func runIncidentColumnRoundTrip(t *testing.T, p storage.Provider) {
 	if !reflect.DeepEqual(got.Content, want.Content) {
 		t.Fatalf("Content = %v, want %v", got.Content, want.Content)
 	}
+	if !reflect.DeepEqual(got.Labels, want.Labels) {
+		t.Fatalf("Labels = %v, want %v", got.Labels, want.Labels)
+	}
+	if !reflect.DeepEqual(got.Tags, want.Tags) {
+		t.Fatalf("Tags = %v, want %v", got.Tags, want.Tags)
+	}
+	if !reflect.DeepEqual(got.CustomFields, want.CustomFields) {
+		t.Fatalf("CustomFields = %v, want %v", got.CustomFields, want.CustomFields)
+	}
+	if !reflect.DeepEqual(got.RelatedIDs, want.RelatedIDs) {
+		t.Fatalf("RelatedIDs = %v, want %v", got.RelatedIDs, want.RelatedIDs)
+	}
 }
 
 func TestMemoryIncidentColumnRoundTrip(t *testing.T) {
@@ -0,0 +1,249 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/utils"
+)
+
+// Incident priorities, most urgent first. Priority is operator-set triage
+// order and is independent of severity: a low-severity alert on a critical
+// path can still be P1.
+const (
+	PriorityP1 = "P1"
+	PriorityP2 = "P2"
+	PriorityP3 = "P3"
+	PriorityP4 = "P4"
+	PriorityP5 = "P5"
+)
+
+// IsIncidentPriority reports whether p is one of the priorities.
+func IsIncidentPriority(p string) bool {
+	switch p {
+	case PriorityP1, PriorityP2, PriorityP3, PriorityP4, PriorityP5:
+		return true
+	}
+	return false
+}
+
+// Custom field types. A custom field's value is checked against its type on
+// every write, so readers can rely on the JSON shape: a string for
+// CustomFieldString and CustomFieldDate (RFC 3339), a number for
+// CustomFieldNumber and a bool for CustomFieldBool.
+const (
+	CustomFieldString = "string"
+	CustomFieldNumber = "number"
+	CustomFieldBool   = "bool"
+	CustomFieldDate   = "date"
+)
+
+// Limits on the operator-editable fields. They keep one record's labels,
+// tags and custom fields bounded, since every list row carries them.
+const (
+	MaxIncidentSeverityLen = 64
+	MaxIncidentLabels      = 64
+	MaxIncidentTags        = 64
+	MaxIncidentCustom      = 64
+	maxTagLen              = 64
+	maxFieldValueLen       = 1024
+)
+
+// ErrInvalidIncidentField is returned by the field normalizers when a
+// severity, priority, label, tag or custom field is malformed.
+var ErrInvalidIncidentField = errors.New("storage: invalid incident field")
+
+// fieldKeyRe bounds label and custom-field keys to a conservative identifier
+// set, so they read the same in every backend and in the search predicate.
+var fieldKeyRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.\-/]{0,62}$`)
+
+// CustomField is one operator-defined, typed field on an incident.
+type CustomField struct {
+	Key   string      `json:"key"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+// SeverityLabel returns the record's severity: the operator-set Severity,
+// else a best-effort pull from the payload. Callers that display, band or
+// aggregate by severity MUST use it rather than reading Severity directly,
+// so an edit shows up everywhere and an unedited row keeps its payload's
+// severity.
+func (r *IncidentRecord) SeverityLabel() string {
+	if r == nil {
+		return ""
+	}
+	if r.Severity != "" {
+		return r.Severity
+	}
+	return utils.ExtractSeverity(r.Content)
+}
+
+// NormalizeSeverity trims s and bounds its length. An empty result clears
+// the override so the payload's severity applies again.
+func NormalizeSeverity(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if len(s) > MaxIncidentSeverityLen {
+		return "", fmt.Errorf("%w: severity longer than %d bytes", ErrInvalidIncidentField, MaxIncidentSeverityLen)
+	}
+	return s, nil
+}
+
+// NormalizePriority upper-cases p and checks it is one of the priorities.
+// An empty p clears the priority.
+func NormalizePriority(p string) (string, error) {
+	p = strings.ToUpper(strings.TrimSpace(p))
+	if p != "" && !IsIncidentPriority(p) {
+		return "", fmt.Errorf("%w: priority must be one of P1-P5", ErrInvalidIncidentField)
+	}
+	return p, nil
+}
+
+// NormalizeLabels validates label keys and values. An empty map normalizes
+// to nil so the field is omitted.
+func NormalizeLabels(labels map[string]string) (map[string]string, error) {
+	if len(labels) == 0 {
+		return nil, nil
+	}
+	if len(labels) > MaxIncidentLabels {
+		return nil, fmt.Errorf("%w: more than %d labels", ErrInvalidIncidentField, MaxIncidentLabels)
+	}
+	out := make(map[string]string, len(labels))
+	for k, v := range labels {
+		if !fieldKeyRe.MatchString(k) {
+			return nil, fmt.Errorf("%w: label key %q", ErrInvalidIncidentField, k)
+		}
+		v = strings.TrimSpace(v)
+		if len(v) > maxFieldValueLen {
+			return nil, fmt.Errorf("%w: label %q value too long", ErrInvalidIncidentField, k)
+		}
+		out[k] = v
+	}
+	return out, nil
+}
+
+// NormalizeTags trims, de-duplicates and sorts tags, dropping empty ones. An
+// empty result normalizes to nil.
+func NormalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	var out []string
+	for _, t := range tags {
+		t = strings.TrimSpace(t)
+		if t == "" || seen[t] {
+			continue
+		}
+		if len(t) > maxTagLen {
+			return nil, fmt.Errorf("%w: tag %q longer than %d bytes", ErrInvalidIncidentField, t, maxTagLen)
+		}
+		seen[t] = true
+		out = append(out, t)
+	}
+	if len(out) > MaxIncidentTags {
+		return nil, fmt.Errorf("%w: more than %d tags", ErrInvalidIncidentField, MaxIncidentTags)
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// NormalizeCustomFields checks every field's key and type and coerces its
+// value to the type's JSON shape: a date is re-rendered as RFC 3339 UTC, and
+// a number or bool sent as a string is parsed. Keys must be unique. The
+// result is sorted by key; an empty result normalizes to nil.
+func NormalizeCustomFields(fields []CustomField) ([]CustomField, error) {
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	if len(fields) > MaxIncidentCustom {
+		return nil, fmt.Errorf("%w: more than %d custom fields", ErrInvalidIncidentField, MaxIncidentCustom)
+	}
+	seen := make(map[string]bool, len(fields))
+	out := make([]CustomField, 0, len(fields))
+	for _, f := range fields {
+		if !fieldKeyRe.MatchString(f.Key) {
+			return nil, fmt.Errorf("%w: custom field key %q", ErrInvalidIncidentField, f.Key)
+		}
+		if seen[f.Key] {
+			return nil, fmt.Errorf("%w: duplicate custom field %q", ErrInvalidIncidentField, f.Key)
+		}
+		seen[f.Key] = true
+		v, err := coerceCustomValue(f.Type, f.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: custom field %q: %v", ErrInvalidIncidentField, f.Key, err)
+		}
+		out = append(out, CustomField{Key: f.Key, Type: f.Type, Value: v})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out, nil
+}
+
+// coerceCustomValue converts v to the JSON shape of typ.
+func coerceCustomValue(typ string, v interface{}) (interface{}, error) {
+	switch typ {
+	case CustomFieldString:
+		s, ok := v.(string)
+		if !ok {
+			return nil, errors.New("value must be a string")
+		}
+		if len(s) > maxFieldValueLen {
+			return nil, errors.New("value too long")
+		}
+		return s, nil
+	case CustomFieldNumber:
+		var n float64
+		switch x := v.(type) {
+		case float64:
+			n = x
+		case int:
+			n = float64(x)
+		case int64:
+			n = float64(x)
+		case string:
+			f, err := strconv.ParseFloat(strings.TrimSpace(x), 64)
+			if err != nil {
+				return nil, errors.New("value must be a number")
+			}
+			n = f
+		default:
+			return nil, errors.New("value must be a number")
+		}
+		if math.IsNaN(n) || math.IsInf(n, 0) {
+			return nil, errors.New("value must be a finite number")
+		}
+		return n, nil
+	case CustomFieldBool:
+		switch x := v.(type) {
+		case bool:
+			return x, nil
+		case string:
+			switch strings.ToLower(strings.TrimSpace(x)) {
+			case "true":
+				return true, nil
+			case "false":
+				return false, nil
+			}
+		}
+		return nil, errors.New("value must be a bool")
+	case CustomFieldDate:
+		s, ok := v.(string)
+		if !ok {
+			return nil, errors.New("value must be an RFC 3339 date")
+		}
+		s = strings.TrimSpace(s)
+		if t, err := time.Parse(time.RFC3339, s); err == nil {
+			return t.UTC().Format(time.RFC3339), nil
+		}
+		if t, err := time.Parse(time.DateOnly, s); err == nil {
+			return t.UTC().Format(time.RFC3339), nil
+		}
+		return nil, errors.New("value must be an RFC 3339 date")
+	default:
+		return nil, fmt.Errorf("type must be one of %s, %s, %s, %s",
+			CustomFieldString, CustomFieldNumber, CustomFieldBool, CustomFieldDate)
+	}
+}
@@ -0,0 +1,72 @@
+package storage_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/VersusControl/versus-incident/pkg/storage"
+)
+
+func TestSeverityLabel_EditOverridesPayload(t *testing.T) {
+	rec := &storage.IncidentRecord{Content: map[string]interface{}{"severity": "warning"}}
+	if got := rec.SeverityLabel(); got != "warning" {
+		t.Fatalf("unedited SeverityLabel = %q, want the payload's warning", got)
+	}
+	rec.Severity = "critical"
+	if got := rec.SeverityLabel(); got != "critical" {
+		t.Fatalf("edited SeverityLabel = %q, want critical", got)
+	}
+}
+
+func TestNormalizePriorityAndTags(t *testing.T) {
+	if p, err := storage.NormalizePriority(" p2 "); err != nil || p != storage.PriorityP2 {
+		t.Fatalf("NormalizePriority(p2) = %q, %v", p, err)
+	}
+	if _, err := storage.NormalizePriority("urgent"); !errors.Is(err, storage.ErrInvalidIncidentField) {
+		t.Fatalf("NormalizePriority(urgent) err = %v, want ErrInvalidIncidentField", err)
+	}
+	tags, err := storage.NormalizeTags([]string{" db ", "api", "db", ""})
+	if err != nil || !reflect.DeepEqual(tags, []string{"api", "db"}) {
+		t.Fatalf("NormalizeTags = %v, %v; want [api db]", tags, err)
+	}
+	if tags, _ := storage.NormalizeTags([]string{" "}); tags != nil {
+		t.Fatalf("NormalizeTags(blank) = %v, want nil", tags)
+	}
+	if _, err := storage.NormalizeLabels(map[string]string{"bad key": "x"}); !errors.Is(err, storage.ErrInvalidIncidentField) {
+		t.Fatalf("NormalizeLabels(bad key) err = %v", err)
+	}
+}
+
+func TestNormalizeCustomFields_CoercesByType(t *testing.T) {
+	got, err := storage.NormalizeCustomFields([]storage.CustomField{
+		{Key: "when", Type: storage.CustomFieldDate, Value: "2026-10-19"},
+		{Key: "count", Type: storage.CustomFieldNumber, Value: "12.5"},
+		{Key: "paged", Type: storage.CustomFieldBool, Value: "TRUE"},
+		{Key: "owner", Type: storage.CustomFieldString, Value: "sre"},
+	})
+	if err != nil {
+		t.Fatalf("NormalizeCustomFields: %v", err)
+	}
+	want := []storage.CustomField{
+		{Key: "count", Type: storage.CustomFieldNumber, Value: 12.5},
+		{Key: "owner", Type: storage.CustomFieldString, Value: "sre"},
+		{Key: "paged", Type: storage.CustomFieldBool, Value: true},
+		{Key: "when", Type: storage.CustomFieldDate, Value: "2026-10-19T00:00:00Z"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("NormalizeCustomFields = %v, want %v", got, want)
+	}
+
+	for _, bad := range [][]storage.CustomField{
+		{{Key: "n", Type: storage.CustomFieldNumber, Value: "12abc"}},
+		{{Key: "b", Type: storage.CustomFieldBool, Value: float64(1)}},
+		{{Key: "d", Type: storage.CustomFieldDate, Value: "yesterday"}},
+		{{Key: "x", Type: "enum", Value: "a"}},
+		{{Key: "k", Type: storage.CustomFieldString, Value: "a"}, {Key: "k", Type: storage.CustomFieldString, Value: "b"}},
+	} {
+		if _, err := storage.NormalizeCustomFields(bad); !errors.Is(err, storage.ErrInvalidIncidentField) {
+			t.Errorf("NormalizeCustomFields(%v) err = %v, want ErrInvalidIncidentField", bad, err)
+		}
+	}
+}
@@ -7,7 +7,8 @@ package storage_test
 // render one page and compute counts.
 //
 // Memory and file backends run unconditionally (they implement the capability
-// over their capped in-memory slice); Postgres is gated on TEST_POSTGRES_DSN.
+// over their capped in-memory slice), as does SQLite; Postgres is gated on
+// TEST_POSTGRES_DSN and redis on TEST_REDIS_ADDR.
 
 import (
 	"strings"
@@ -80,6 +81,8 @@ func TestIncidentPagerCounts(t *testing.T) {
 		"memory":   newMemoryPager,
 		"file":     newFilePager,
 		"postgres": func(t *testing.T) storage.Provider { return newTestPostgres(t) },
+		"redis":    func(t *testing.T) storage.Provider { return newTestRedis(t) },
+		"sqlite":   func(t *testing.T) storage.Provider { return newTestSQLite(t) },
 	}
 	for name, mk := range backends {
 		t.Run(name, func(t *testing.T) {
@@ -119,6 +122,8 @@ func TestIncidentPagerPageNewestFirst(t *testing.T) {
 		"memory":   newMemoryPager,
 		"file":     newFilePager,
 		"postgres": func(t *testing.T) storage.Provider { return newTestPostgres(t) },
+		"redis":    func(t *testing.T) storage.Provider { return newTestRedis(t) },
+		"sqlite":   func(t *testing.T) storage.Provider { return newTestSQLite(t) },
 	}
 	for name, mk := range backends {
 		t.Run(name, func(t *testing.T) {
@@ -169,6 +174,8 @@ func TestIncidentPagerOriginFilter(t *testing.T) {
 		"memory":   newMemoryPager,
 		"file":     newFilePager,
 		"postgres": func(t *testing.T) storage.Provider { return newTestPostgres(t) },
+		"redis":    func(t *testing.T) storage.Provider { return newTestRedis(t) },
+		"sqlite":   func(t *testing.T) storage.Provider { return newTestSQLite(t) },
 	}
 	for name, mk := range backends {
 		t.Run(name, func(t *testing.T) {
@@ -314,7 +321,7 @@ func TestIncidentPagerLargeTablePostgres(t *testing.T) {
 
 // TestIncidentSearchPagerPostgres proves the Postgres search pager counts and
 // pages full-text matches without loading the whole match set. Gated on a real
-// Postgres (it is the only backend implementing IncidentSearchPager).
+// Postgres; the other backends have their own search tests.
 func TestIncidentSearchPagerPostgres(t *testing.T) {
 	p := newTestPostgres(t) // skips when TEST_POSTGRES_DSN is unset
 	seedPagerRecords(t, p)
@@ -0,0 +1,257 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Incident relationships. Three kinds are recorded on IncidentRecord:
+//
+//   - MergedInto: a duplicate points at the primary it was merged into. A
+//     merge closes the duplicate, so only the primary stays open.
+//   - ParentID: a child points at its parent, e.g. per-service symptoms of
+//     one database outage.
+//   - RelatedIDs: a symmetric "see also" link, stored on both records.
+//
+// The relationships are plain fields persisted through SaveIncident, so
+// every backend stores them with no extra capability. Finding the records
+// that point AT an incident (its children, its duplicates) is a reverse
+// lookup: RelatedIncidents uses the optional RelationLister capability when
+// the backend has it and a full scan otherwise.
+
+// MaxIncidentCluster bounds how many incidents one cluster walk visits, so a
+// pathological relationship graph cannot turn a lookup into a full scan of
+// the history.
+const MaxIncidentCluster = 200
+
+// maxParentDepth bounds the ancestor walk in CheckParent.
+const maxParentDepth = 64
+
+// ErrInvalidRelation is returned when a merge, link or parent change would
+// relate an incident to itself, form a parent cycle, or merge an incident
+// that is already merged elsewhere.
+var ErrInvalidRelation = errors.New("storage: invalid incident relation")
+
+// RelationLister is an optional capability a backend may implement on top
+// of Provider: return the incidents that reference id through MergedInto,
+// ParentID or RelatedIDs, newest first. It lets Postgres answer the reverse
+// lookup from indexed columns; RelatedIncidents falls back to a full
+// ListIncidents scan for a backend without it, the same way the report
+// falls back when RangeLister is missing.
+type RelationLister interface {
+	ListIncidentsReferencing(id string) ([]*IncidentRecord, error)
+}
+
+// errCrossOrg rejects a relationship between two organizations' incidents.
+var errCrossOrg = fmt.Errorf("%w: incidents belong to different organizations", ErrInvalidRelation)
+
+func sameOrg(a, b *IncidentRecord) bool {
+	return NormalizeOrgID(a.OrgID) == NormalizeOrgID(b.OrgID)
+}
+
+// references reports whether r points at id through any relationship.
+func (r *IncidentRecord) references(id string) bool {
+	return r.MergedInto == id || r.ParentID == id || slices.Contains(r.RelatedIDs, id)
+}
+
+// relationIDs returns every id r points at.
+func (r *IncidentRecord) relationIDs() []string {
+	var ids []string
+	if r.MergedInto != "" {
+		ids = append(ids, r.MergedInto)
+	}
+	if r.ParentID != "" {
+		ids = append(ids, r.ParentID)
+	}
+	return append(ids, r.RelatedIDs...)
+}
+
+// MergeInto marks r as a duplicate of primary and closes it: an unresolved
+// duplicate is resolved at at, and an already-resolved one keeps its
+// resolution. Merging again into the same primary is a no-op (changed ==
+// false). A duplicate already merged into a different incident, a primary
+// that is itself merged, and a self-merge return ErrInvalidRelation.
+func (r *IncidentRecord) MergeInto(primary *IncidentRecord, at time.Time) (changed bool, err error) {
+	switch {
+	case primary == nil || primary.ID == r.ID:
+		return false, fmt.Errorf("%w: an incident cannot be merged into itself", ErrInvalidRelation)
+	case !sameOrg(r, primary):
+		return false, errCrossOrg
+	case primary.MergedInto != "":
+		return false, fmt.Errorf("%w: %s is itself merged into %s", ErrInvalidRelation, primary.ID, primary.MergedInto)
+	case r.MergedInto == primary.ID:
+		return false, nil
+	case r.MergedInto != "":
+		return false, fmt.Errorf("%w: %s is already merged into %s", ErrInvalidRelation, r.ID, r.MergedInto)
+	}
+	if r.EffectiveStatus(at) != StatusResolved {
+		if _, err := r.Transition(StatusResolved, at, nil); err != nil {
+			return false, err
+		}
+	}
+	r.MergedInto = primary.ID
+	return true, nil
+}
+
+// LinkIncidents records a symmetric related link between a and b. Linking
+// an already-linked pair is a no-op (changed == false).
+func LinkIncidents(a, b *IncidentRecord) (changed bool, err error) {
+	if a.ID == b.ID {
+		return false, fmt.Errorf("%w: an incident cannot be linked to itself", ErrInvalidRelation)
+	}
+	if !sameOrg(a, b) {
+		return false, errCrossOrg
+	}
+	if slices.Contains(a.RelatedIDs, b.ID) && slices.Contains(b.RelatedIDs, a.ID) {
+		return false, nil
+	}
+	a.RelatedIDs = addRelatedID(a.RelatedIDs, b.ID)
+	b.RelatedIDs = addRelatedID(b.RelatedIDs, a.ID)
+	return true, nil
+}
+
+// UnlinkIncidents removes the related link between a and b from both sides.
+// Unlinking a pair that is not linked is a no-op (changed == false).
+func UnlinkIncidents(a, b *IncidentRecord) (changed bool) {
+	if !slices.Contains(a.RelatedIDs, b.ID) && !slices.Contains(b.RelatedIDs, a.ID) {
+		return false
+	}
+	a.RelatedIDs = removeRelatedID(a.RelatedIDs, b.ID)
+	b.RelatedIDs = removeRelatedID(b.RelatedIDs, a.ID)
+	return true
+}
+
+func addRelatedID(ids []string, id string) []string {
+	if slices.Contains(ids, id) {
+		return ids
+	}
+	ids = append(ids, id)
+	slices.Sort(ids)
+	return ids
+}
+
+func removeRelatedID(ids []string, id string) []string {
+	ids = slices.DeleteFunc(slices.Clone(ids), func(s string) bool { return s == id })
+	if len(ids) == 0 {
+		return nil
+	}
+	return ids
+}
+
+// CheckParent reports whether parentID may become the parent of child: the
+// parent must exist in the same organization, differ from the child, and
+// not already descend from the child, so the parent chain never forms a
+// cycle. An unknown parent returns ErrNotFound.
+func CheckParent(p Provider, child *IncidentRecord, parentID string) error {
+	if parentID == child.ID {
+		return fmt.Errorf("%w: an incident cannot be its own parent", ErrInvalidRelation)
+	}
+	id := parentID
+	for depth := 0; id != "" && depth < maxParentDepth; depth++ {
+		rec, err := p.GetIncident(id)
+		if err != nil {
+			return err
+		}
+		if depth == 0 && !sameOrg(child, rec) {
+			return errCrossOrg
+		}
+		if rec.ParentID == child.ID {
+			return fmt.Errorf("%w: %s already descends from %s", ErrInvalidRelation, parentID, child.ID)
+		}
+		id = rec.ParentID
+	}
+	if id != "" {
+		return fmt.Errorf("%w: parent chain deeper than %d", ErrInvalidRelation, maxParentDepth)
+	}
+	return nil
+}
+
+// RelatedIncidents returns the cluster around id: every incident reachable
+// from it through merges, parent/child edges and related links, in either
+// direction, excluding id itself, newest first. The walk stops after
+// MaxIncidentCluster incidents. An unknown id returns ErrNotFound.
+func RelatedIncidents(p Provider, id string) ([]*IncidentRecord, error) {
+	root, err := p.GetIncident(id)
+	if err != nil {
+		return nil, err
+	}
+	if rl, ok := p.(RelationLister); ok {
+		return walkCluster(root, p.GetIncident, rl.ListIncidentsReferencing)
+	}
+	all, err := p.ListIncidents(0)
+	if err != nil {
+		return nil, err
+	}
+	return IncidentCluster(all, root), nil
+}
+
+// IncidentCluster is RelatedIncidents over an already-loaded set of
+// records, for callers that hold the full list anyway.
+func IncidentCluster(recs []*IncidentRecord, root *IncidentRecord) []*IncidentRecord {
+	byID := make(map[string]*IncidentRecord, len(recs))
+	for _, r := range recs {
+		byID[r.ID] = r
+	}
+	get := func(id string) (*IncidentRecord, error) {
+		if r, ok := byID[id]; ok {
+			return r, nil
+		}
+		return nil, ErrNotFound
+	}
+	referencing := func(id string) ([]*IncidentRecord, error) {
+		var out []*IncidentRecord
+		for _, r := range recs {
+			if r.references(id) {
+				out = append(out, r)
+			}
+		}
+		return out, nil
+	}
+	out, _ := walkCluster(root, get, referencing)
+	return out
+}
+
+// walkCluster is a breadth-first walk over relationship edges in both
+// directions. A dangling reference (the other incident was purged) is
+// skipped rather than failing the walk.
+func walkCluster(root *IncidentRecord, get func(string) (*IncidentRecord, error), referencing func(string) ([]*IncidentRecord, error)) ([]*IncidentRecord, error) {
+	seen := map[string]bool{root.ID: true}
+	queue := []*IncidentRecord{root}
+	var out []*IncidentRecord
+	visit := func(r *IncidentRecord) {
+		if seen[r.ID] || len(out) >= MaxIncidentCluster {
+			return
+		}
+		seen[r.ID] = true
+		out = append(out, r)
+		queue = append(queue, r)
+	}
+	for len(queue) > 0 && len(out) < MaxIncidentCluster {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, id := range cur.relationIDs() {
+			if seen[id] {
+				continue
+			}
+			r, err := get(id)
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			visit(r)
+		}
+		refs, err := referencing(cur.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range refs {
+			visit(r)
+		}
+	}
+	slices.SortStableFunc(out, func(a, b *IncidentRecord) int { return b.CreatedAt.Compare(a.CreatedAt) })
+	return out, nil
+}
@@ -0,0 +1,118 @@
+package storage_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/storage"
+)
+
+func TestMergeInto_ClosesDuplicate(t *testing.T) {
+	now := time.Now().UTC()
+	primary := &storage.IncidentRecord{ID: "p"}
+	dup := &storage.IncidentRecord{ID: "d"}
+
+	changed, err := dup.MergeInto(primary, now)
+	if err != nil || !changed {
+		t.Fatalf("MergeInto = %v, %v; want changed", changed, err)
+	}
+	if dup.MergedInto != "p" || !dup.Resolved || dup.EffectiveStatus(now) != storage.StatusResolved {
+		t.Fatalf("duplicate after merge = %+v, want resolved and merged into p", dup)
+	}
+	if changed, err := dup.MergeInto(primary, now); err != nil || changed {
+		t.Fatalf("repeat MergeInto = %v, %v; want a no-op", changed, err)
+	}
+
+	other := &storage.IncidentRecord{ID: "o"}
+	for name, tc := range map[string]struct{ dup, primary *storage.IncidentRecord }{
+		"self":           {other, other},
+		"already merged": {dup, other},
+		"merged primary": {other, dup},
+		"different org":  {&storage.IncidentRecord{ID: "x", OrgID: "acme"}, primary},
+	} {
+		if _, err := tc.dup.MergeInto(tc.primary, now); !errors.Is(err, storage.ErrInvalidRelation) {
+			t.Errorf("%s: err = %v, want ErrInvalidRelation", name, err)
+		}
+	}
+}
+
+func TestLinkAndUnlinkIncidents_AreSymmetric(t *testing.T) {
+	a := &storage.IncidentRecord{ID: "a"}
+	b := &storage.IncidentRecord{ID: "b"}
+	if changed, err := storage.LinkIncidents(a, b); err != nil || !changed {
+		t.Fatalf("LinkIncidents = %v, %v", changed, err)
+	}
+	if !reflect.DeepEqual(a.RelatedIDs, []string{"b"}) || !reflect.DeepEqual(b.RelatedIDs, []string{"a"}) {
+		t.Fatalf("links = %v / %v, want each side pointing at the other", a.RelatedIDs, b.RelatedIDs)
+	}
+	if changed, _ := storage.LinkIncidents(a, b); changed {
+		t.Fatal("relinking a linked pair reported a change")
+	}
+	if !storage.UnlinkIncidents(a, b) || a.RelatedIDs != nil || b.RelatedIDs != nil {
+		t.Fatalf("after unlink: %v / %v, want both nil", a.RelatedIDs, b.RelatedIDs)
+	}
+}
+
+func TestCheckParent_RejectsCycles(t *testing.T) {
+	p := storage.NewMemory()
+	now := time.Now().UTC()
+	for _, r := range []*storage.IncidentRecord{
+		{ID: "root", CreatedAt: now},
+		{ID: "mid", ParentID: "root", CreatedAt: now},
+		{ID: "leaf", ParentID: "mid", CreatedAt: now},
+	} {
+		if err := p.SaveIncident(r); err != nil {
+			t.Fatalf("SaveIncident: %v", err)
+		}
+	}
+	root, _ := p.GetIncident("root")
+	if err := storage.CheckParent(p, root, "leaf"); !errors.Is(err, storage.ErrInvalidRelation) {
+		t.Fatalf("root under its own descendant: err = %v, want ErrInvalidRelation", err)
+	}
+	if err := storage.CheckParent(p, root, "root"); !errors.Is(err, storage.ErrInvalidRelation) {
+		t.Fatalf("self parent: err = %v, want ErrInvalidRelation", err)
+	}
+	if err := storage.CheckParent(p, root, "missing"); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("unknown parent: err = %v, want ErrNotFound", err)
+	}
+	leaf, _ := p.GetIncident("leaf")
+	if err := storage.CheckParent(p, leaf, "root"); err != nil {
+		t.Fatalf("reparenting leaf to root: %v", err)
+	}
+}
+
+// TestRelatedIncidents_WalksEveryEdge builds a cluster joined by each kind
+// of relationship, in both directions, and expects the whole of it back
+// from any member, newest first, with the unrelated incident left out.
+func TestRelatedIncidents_WalksEveryEdge(t *testing.T) {
+	p := storage.NewMemory()
+	now := time.Now().UTC()
+	for _, r := range []*storage.IncidentRecord{
+		{ID: "primary", CreatedAt: now.Add(-5 * time.Minute)},
+		{ID: "dup", MergedInto: "primary", CreatedAt: now.Add(-4 * time.Minute)},
+		{ID: "child", ParentID: "primary", CreatedAt: now.Add(-3 * time.Minute)},
+		{ID: "linked", RelatedIDs: []string{"child"}, CreatedAt: now.Add(-2 * time.Minute)},
+		{ID: "unrelated", CreatedAt: now.Add(-time.Minute)},
+	} {
+		if err := p.SaveIncident(r); err != nil {
+			t.Fatalf("SaveIncident: %v", err)
+		}
+	}
+
+	got, err := storage.RelatedIncidents(p, "dup")
+	if err != nil {
+		t.Fatalf("RelatedIncidents: %v", err)
+	}
+	var ids []string
+	for _, r := range got {
+		ids = append(ids, r.ID)
+	}
+	if want := []string{"linked", "child", "primary"}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("cluster = %v, want %v", ids, want)
+	}
+	if _, err := storage.RelatedIncidents(p, "missing"); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("unknown id: err = %v, want ErrNotFound", err)
+	}
+}
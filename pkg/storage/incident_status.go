@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Incident lifecycle states. An incident starts triggered and moves forward
+// through acknowledged → investigating → mitigated → resolved; a resolved
+// incident can be reopened, and any unresolved incident can be snoozed until
+// a point in time. Transition enforces the allowed edges.
+const (
+	StatusTriggered     = "triggered"
+	StatusAcknowledged  = "acknowledged"
+	StatusInvestigating = "investigating"
+	StatusMitigated     = "mitigated"
+	StatusResolved      = "resolved"
+	StatusReopened      = "reopened"
+	StatusSnoozed       = "snoozed"
+)
+
+// ErrInvalidTransition is returned by IncidentRecord.Transition when the
+// requested state cannot be reached from the current one.
+var ErrInvalidTransition = errors.New("storage: invalid incident status transition")
+
+// incidentTransitions lists, per state, the states it may move to. A lapsed
+// snooze reads as triggered (see EffectiveStatus) and so follows the
+// triggered row.
+var incidentTransitions = map[string][]string{
+	StatusTriggered:     {StatusAcknowledged, StatusInvestigating, StatusMitigated, StatusResolved, StatusSnoozed},
+	StatusReopened:      {StatusAcknowledged, StatusInvestigating, StatusMitigated, StatusResolved, StatusSnoozed},
+	StatusAcknowledged:  {StatusInvestigating, StatusMitigated, StatusResolved, StatusSnoozed},
+	StatusInvestigating: {StatusMitigated, StatusResolved, StatusSnoozed},
+	StatusMitigated:     {StatusInvestigating, StatusResolved, StatusSnoozed},
+	StatusSnoozed:       {StatusAcknowledged, StatusInvestigating, StatusMitigated, StatusResolved, StatusSnoozed},
+	StatusResolved:      {StatusReopened},
+}
+
+// IsIncidentStatus reports whether s is one of the lifecycle states.
+func IsIncidentStatus(s string) bool {
+	_, ok := incidentTransitions[s]
+	return ok
+}
+
+// CanTransition reports whether an incident in state from may move to to.
+func CanTransition(from, to string) bool {
+	for _, next := range incidentTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// EffectiveStatus returns the record's lifecycle state as of now. Records
+// persisted before Status existed derive it from the legacy fields
+// (Resolved, then AckedAt), and a snooze whose SnoozedUntil has passed reads
+// as triggered again, so nothing stays hidden after its snooze lapses.
+// Callers that classify or count by status MUST use this rather than reading
+// Status directly.
+func (r *IncidentRecord) EffectiveStatus(now time.Time) string {
+	switch {
+	case r.Status == StatusSnoozed:
+		if r.SnoozedUntil == nil || !now.Before(*r.SnoozedUntil) {
+			return StatusTriggered
+		}
+		return StatusSnoozed
+	case IsIncidentStatus(r.Status):
+		return r.Status
+	case r.Resolved:
+		return StatusResolved
+	case r.AckedAt != nil:
+		return StatusAcknowledged
+	default:
+		return StatusTriggered
+	}
+}
+
+// Transition moves the record to state to at time at, keeping the legacy
+// Resolved / ResolvedAt / AckedAt fields in step so every reader that
+// predates Status stays correct:
+//
+//   - acknowledged, investigating and mitigated stamp AckedAt if unset;
+//   - resolved sets Resolved and ResolvedAt;
+//   - reopened clears Resolved and ResolvedAt;
+//   - snoozed requires until to be in the future and records it.
+//
+// Moving to the current state is a no-op (changed == false), except for
+// snoozed, where it updates the wake-up time. An edge that is not allowed
+// returns ErrInvalidTransition and leaves the record untouched.
+func (r *IncidentRecord) Transition(to string, at time.Time, until *time.Time) (changed bool, err error) {
+	from := r.EffectiveStatus(at)
+	if from == to && to != StatusSnoozed {
+		return false, nil
+	}
+	if !CanTransition(from, to) {
+		return false, fmt.Errorf("%w: %s → %s", ErrInvalidTransition, from, to)
+	}
+	if to == StatusSnoozed && (until == nil || !until.After(at)) {
+		return false, fmt.Errorf("%w: snooze needs a wake-up time in the future", ErrInvalidTransition)
+	}
+
+	at = at.UTC()
+	r.Status = to
+	r.SnoozedUntil = nil
+	switch to {
+	case StatusAcknowledged, StatusInvestigating, StatusMitigated:
+		if r.AckedAt == nil {
+			r.AckedAt = &at
+		}
+	case StatusResolved:
+		r.Resolved = true
+		r.ResolvedAt = &at
+	case StatusReopened:
+		r.Resolved = false
+		r.ResolvedAt = nil
+	case StatusSnoozed:
+		u := until.UTC()
+		r.SnoozedUntil = &u
+	}
+	return true, nil
+}
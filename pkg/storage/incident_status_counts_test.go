@@ -85,6 +85,8 @@ func TestIncidentCountsByStatus(t *testing.T) {
 		"memory":   newMemoryPager,
 		"file":     newFilePager,
 		"postgres": func(t *testing.T) storage.Provider { return newTestPostgres(t) },
+		"redis":    func(t *testing.T) storage.Provider { return newTestRedis(t) },
+		"sqlite":   func(t *testing.T) storage.Provider { return newTestSQLite(t) },
 	}
 	for name, mk := range backends {
 		t.Run(name, func(t *testing.T) {
@@ -100,11 +102,13 @@ func TestIncidentCountsByStatus(t *testing.T) {
 				t.Fatalf("CountIncidentsByStatus: %v", err)
 			}
 
-			// Expected from the seed truth table.
+			// Expected from the seed truth table. The seed rows carry no
+			// explicit Status, so open ones derive as triggered and acked
+			// ones as acknowledged.
 			want := storage.IncidentStatusCounts{
-				AIDetect: storage.StatusCounts{Open: 3, Acked: 2, Resolved: 1, Total: 6},
-				Webhook:  storage.StatusCounts{Open: 2, Acked: 1, Resolved: 3, Total: 6},
-				Total:    storage.StatusCounts{Open: 5, Acked: 3, Resolved: 4, Total: 12},
+				AIDetect: storage.StatusCounts{Open: 3, Acked: 2, Resolved: 1, Total: 6, Triggered: 3, Acknowledged: 2},
+				Webhook:  storage.StatusCounts{Open: 2, Acked: 1, Resolved: 3, Total: 6, Triggered: 2, Acknowledged: 1},
+				Total:    storage.StatusCounts{Open: 5, Acked: 3, Resolved: 4, Total: 12, Triggered: 5, Acknowledged: 3},
 			}
 			if got != want {
 				t.Fatalf("CountIncidentsByStatus =\n%+v\nwant\n%+v", got, want)
@@ -0,0 +1,185 @@
+package storage_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/storage"
+)
+
+// TestIncidentTransition_ForwardPathAndLegacyFields walks the happy path and
+// checks the legacy Resolved / ResolvedAt / AckedAt fields stay in step.
+func TestIncidentTransition_ForwardPathAndLegacyFields(t *testing.T) {
+	now := time.Now().UTC()
+	rec := &storage.IncidentRecord{ID: "i", CreatedAt: now}
+
+	if got := rec.EffectiveStatus(now); got != storage.StatusTriggered {
+		t.Fatalf("new record status = %q, want triggered", got)
+	}
+	for step, to := range []string{storage.StatusAcknowledged, storage.StatusInvestigating, storage.StatusMitigated, storage.StatusResolved} {
+		at := now.Add(time.Duration(step+1) * time.Minute)
+		changed, err := rec.Transition(to, at, nil)
+		if err != nil || !changed {
+			t.Fatalf("→ %s: changed=%v err=%v", to, changed, err)
+		}
+		if rec.EffectiveStatus(at) != to {
+			t.Fatalf("after → %s status = %q", to, rec.EffectiveStatus(at))
+		}
+	}
+	if rec.AckedAt == nil || !rec.AckedAt.Equal(now.Add(time.Minute)) {
+		t.Errorf("AckedAt = %v, want the first ack time", rec.AckedAt)
+	}
+	if !rec.Resolved || rec.ResolvedAt == nil {
+		t.Errorf("resolve did not set Resolved/ResolvedAt: %+v", rec)
+	}
+
+	// Re-resolving is an idempotent no-op with no timestamp drift.
+	resolvedAt := *rec.ResolvedAt
+	if changed, err := rec.Transition(storage.StatusResolved, now.Add(time.Hour), nil); changed || err != nil {
+		t.Errorf("re-resolve: changed=%v err=%v, want a no-op", changed, err)
+	}
+	if !rec.ResolvedAt.Equal(resolvedAt) {
+		t.Errorf("re-resolve moved ResolvedAt")
+	}
+
+	// Reopen clears the resolution.
+	if _, err := rec.Transition(storage.StatusReopened, now.Add(2*time.Hour), nil); err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if rec.Resolved || rec.ResolvedAt != nil {
+		t.Errorf("reopen left the record resolved: %+v", rec)
+	}
+}
+
+func TestIncidentTransition_RejectsInvalidEdges(t *testing.T) {
+	now := time.Now().UTC()
+	cases := []struct{ from, to string }{
+		{storage.StatusTriggered, storage.StatusReopened},
+		{storage.StatusInvestigating, storage.StatusAcknowledged},
+		{storage.StatusResolved, storage.StatusAcknowledged},
+		{storage.StatusResolved, storage.StatusSnoozed},
+	}
+	for _, tc := range cases {
+		rec := &storage.IncidentRecord{ID: "i", Status: tc.from, Resolved: tc.from == storage.StatusResolved}
+		before := *rec
+		if _, err := rec.Transition(tc.to, now, nil); !errors.Is(err, storage.ErrInvalidTransition) {
+			t.Errorf("%s → %s: err = %v, want ErrInvalidTransition", tc.from, tc.to, err)
+		}
+		if rec.Status != before.Status || rec.Resolved != before.Resolved {
+			t.Errorf("%s → %s: rejected transition mutated the record", tc.from, tc.to)
+		}
+	}
+}
+
+// TestIncidentStatus_SnoozeLapsesToTriggered checks a snooze needs a future
+// wake-up time and reads as triggered once it passes.
+func TestIncidentStatus_SnoozeLapsesToTriggered(t *testing.T) {
+	now := time.Now().UTC()
+	rec := &storage.IncidentRecord{ID: "i"}
+
+	past := now.Add(-time.Minute)
+	if _, err := rec.Transition(storage.StatusSnoozed, now, &past); !errors.Is(err, storage.ErrInvalidTransition) {
+		t.Fatalf("snooze into the past: err = %v", err)
+	}
+	until := now.Add(time.Hour)
+	if _, err := rec.Transition(storage.StatusSnoozed, now, &until); err != nil {
+		t.Fatalf("snooze: %v", err)
+	}
+	if got := rec.EffectiveStatus(now); got != storage.StatusSnoozed {
+		t.Errorf("during snooze status = %q", got)
+	}
+	if got := rec.EffectiveStatus(until.Add(time.Second)); got != storage.StatusTriggered {
+		t.Errorf("after snooze status = %q, want triggered", got)
+	}
+
+	// Acking a snoozed incident clears the wake-up time.
+	if _, err := rec.Transition(storage.StatusAcknowledged, now, nil); err != nil || rec.SnoozedUntil != nil {
+		t.Errorf("ack while snoozed: err=%v snoozed_until=%v", err, rec.SnoozedUntil)
+	}
+}
+
+func TestIncidentStatus_LegacyDerivation(t *testing.T) {
+	now := time.Now()
+	acked := now
+	cases := []struct {
+		rec  storage.IncidentRecord
+		want string
+	}{
+		{storage.IncidentRecord{}, storage.StatusTriggered},
+		{storage.IncidentRecord{AckedAt: &acked}, storage.StatusAcknowledged},
+		{storage.IncidentRecord{Resolved: true, AckedAt: &acked}, storage.StatusResolved},
+	}
+	for _, tc := range cases {
+		if got := tc.rec.EffectiveStatus(now); got != tc.want {
+			t.Errorf("%+v: status = %q, want %q", tc.rec, got, tc.want)
+		}
+	}
+}
+
+// TestIncidentStatus_PersistedAndCounted round-trips every state through
+// each backend and checks CountIncidentsByStatus buckets them, and that the
+// ack path moves a triggered incident to acknowledged.
+func TestIncidentStatus_PersistedAndCounted(t *testing.T) {
+	backends := map[string]func(*testing.T) storage.Provider{
+		"memory":   newMemoryPager,
+		"file":     newFilePager,
+		"postgres": func(t *testing.T) storage.Provider { return newTestPostgres(t) },
+		"redis":    func(t *testing.T) storage.Provider { return newTestRedis(t) },
+		"sqlite":   func(t *testing.T) storage.Provider { return newTestSQLite(t) },
+	}
+	for name, mk := range backends {
+		t.Run(name, func(t *testing.T) {
+			p := mk(t)
+			now := time.Now().UTC().Truncate(time.Millisecond)
+			future := now.Add(time.Hour)
+			lapsed := now.Add(-time.Hour)
+			recs := []*storage.IncidentRecord{
+				{ID: "t", Status: storage.StatusTriggered},
+				{ID: "a", Status: storage.StatusAcknowledged, AckedAt: &now},
+				{ID: "i", Status: storage.StatusInvestigating, AckedAt: &now},
+				{ID: "m", Status: storage.StatusMitigated, AckedAt: &now},
+				{ID: "r", Status: storage.StatusReopened},
+				{ID: "s", Status: storage.StatusSnoozed, SnoozedUntil: &future},
+				{ID: "l", Status: storage.StatusSnoozed, SnoozedUntil: &lapsed, Origin: storage.OriginAIDetect},
+				{ID: "d", Status: storage.StatusResolved, Resolved: true, ResolvedAt: &now},
+				{ID: "ack-me", Status: storage.StatusTriggered},
+			}
+			for _, r := range recs {
+				r.CreatedAt = now
+				if err := p.SaveIncident(r); err != nil {
+					t.Fatalf("SaveIncident(%s): %v", r.ID, err)
+				}
+			}
+			if err := p.UpdateIncidentAck("ack-me", now); err != nil {
+				t.Fatalf("UpdateIncidentAck: %v", err)
+			}
+
+			got, err := p.GetIncident("s")
+			if err != nil {
+				t.Fatalf("GetIncident: %v", err)
+			}
+			if got.Status != storage.StatusSnoozed || got.SnoozedUntil == nil || !got.SnoozedUntil.Equal(future) {
+				t.Fatalf("snoozed round-trip = %+v", got)
+			}
+			if got, _ := p.GetIncident("ack-me"); got.Status != storage.StatusAcknowledged || got.AckedAt == nil {
+				t.Fatalf("ack path left %+v", got)
+			}
+
+			c, err := p.(storage.IncidentPager).CountIncidentsByStatus()
+			if err != nil {
+				t.Fatalf("CountIncidentsByStatus: %v", err)
+			}
+			want := storage.StatusCounts{
+				Triggered: 2, Acknowledged: 2, Investigating: 1, Mitigated: 1, Reopened: 1, Snoozed: 1, Resolved: 1,
+				Open: 4, Acked: 4, Total: 9,
+			}
+			if c.Total != want {
+				t.Fatalf("Total counts =\n%+v\nwant\n%+v", c.Total, want)
+			}
+			if c.AIDetect.Triggered != 1 || c.AIDetect.Total != 1 {
+				t.Errorf("lapsed ai_detect snooze should count as triggered: %+v", c.AIDetect)
+			}
+		})
+	}
+}
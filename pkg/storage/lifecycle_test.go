@@ -125,3 +125,47 @@ func TestLifecycle_UnknownDomain(t *testing.T) {
 		t.Fatalf("DeleteByID unknown domain = %v, want ErrUnknownDomain", err)
 	}
 }
+
+// TestLifecycle_ResolvedIncidentsAndCount purges only resolved incidents,
+// aged by resolution time, and checks CountOlderThan previews the same set.
+func TestLifecycle_ResolvedIncidentsAndCount(t *testing.T) {
+	p := storage.NewMemory()
+	defer p.Close()
+	lc := p.(storage.Lifecycle)
+	lcc, ok := p.(storage.LifecycleCounter)
+	if !ok {
+		t.Fatal("memory backend must implement storage.LifecycleCounter")
+	}
+
+	now := time.Now().UTC()
+	at := func(d time.Duration) *time.Time { t := now.Add(d); return &t }
+	for _, rec := range []*storage.IncidentRecord{
+		{ID: "open-old", CreatedAt: now.Add(-72 * time.Hour)},
+		{ID: "resolved-old", CreatedAt: now.Add(-72 * time.Hour), Resolved: true, ResolvedAt: at(-48 * time.Hour)},
+		{ID: "resolved-recently", CreatedAt: now.Add(-72 * time.Hour), Resolved: true, ResolvedAt: at(-time.Hour)},
+		{ID: "payload-resolved-old", CreatedAt: now.Add(-72 * time.Hour), Resolved: true},
+	} {
+		if err := p.SaveIncident(rec); err != nil {
+			t.Fatalf("SaveIncident: %v", err)
+		}
+	}
+	cutoff := now.Add(-24 * time.Hour)
+
+	if n, err := lcc.CountOlderThan(storage.DomainIncidents, cutoff); err != nil || n != 4 {
+		t.Fatalf("CountOlderThan(incidents) = %d, %v; want 4", n, err)
+	}
+	if n, err := lcc.CountOlderThan(storage.DomainResolvedIncidents, cutoff); err != nil || n != 2 {
+		t.Fatalf("CountOlderThan(resolved) = %d, %v; want 2", n, err)
+	}
+	if n, err := lc.PurgeOlderThan(storage.DomainResolvedIncidents, cutoff); err != nil || n != 2 {
+		t.Fatalf("PurgeOlderThan(resolved) = %d, %v; want 2", n, err)
+	}
+	for _, id := range []string{"open-old", "resolved-recently"} {
+		if _, err := p.GetIncident(id); err != nil {
+			t.Fatalf("%s should survive: %v", id, err)
+		}
+	}
+	if _, err := lcc.CountOlderThan("bogus", cutoff); !errors.Is(err, storage.ErrUnknownDomain) {
+		t.Fatalf("unknown domain err = %v, want ErrUnknownDomain", err)
+	}
+}
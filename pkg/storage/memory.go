@@ -14,6 +14,7 @@ type memoryProvider struct {
 	blobAt    map[string]time.Time // per-blob updated_at, for Lifecycle purge
 	incidents []*IncidentRecord
 	analyses  []*AnalysisRecord
+	timeline  []*TimelineEntry // oldest first
 }
 
 // NewMemory returns a Provider that keeps all state in memory. Intended
@@ -102,6 +103,9 @@ func (m *memoryProvider) UpdateIncidentAck(id string, ackedAt time.Time) error {
 	for _, rec := range m.incidents {
 		if rec.ID == id {
 			t := ackedAt.UTC()
+			// Best-effort: a triggered, reopened or snoozed incident becomes
+			// acknowledged; one further along (or resolved) keeps its state.
+			_, _ = rec.Transition(StatusAcknowledged, t, nil)
 			rec.AckedAt = &t
 			return nil
 		}
@@ -147,19 +151,7 @@ func (m *memoryProvider) ListIncidents(limit int) ([]*IncidentRecord, error) {
 func (m *memoryProvider) CountIncidents() (IncidentCounts, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
-	var c IncidentCounts
-	for _, rec := range m.incidents {
-		if rec.Resolved {
-			continue
-		}
-		if rec.EffectiveOrigin() == OriginAIDetect {
-			c.AIDetect++
-		} else {
-			c.Webhook++
-		}
-	}
-	c.Total = c.AIDetect + c.Webhook
-	return c, nil
+	return incidentCountsOf(m.incidents), nil
 }
 
 // CountIncidentsByStatus implements the optional storage.IncidentPager
@@ -207,6 +199,66 @@ func (m *memoryProvider) ListIncidentsPage(origin string, offset, limit int) ([]
 	return out, nil
 }
 
+// ---------------------------------------------------------------------------
+// Search (implements the optional storage.Searcher and
+// storage.IncidentSearchPager capabilities)
+// ---------------------------------------------------------------------------
+
+// SearchIncidents matches the query syntax of search_query.go in process over
+// the capped history: best match first, newest first among equals. An empty
+// query degrades to ListIncidents.
+func (m *memoryProvider) SearchIncidents(query string, limit int) ([]*IncidentRecord, error) {
+	recs, err := m.ListIncidents(0)
+	if err != nil {
+		return nil, err
+	}
+	return limitIncidents(searchIncidentsInProcess(recs, query), limit), nil
+}
+
+// CountIncidentsMatching implements the optional storage.IncidentSearchPager
+// capability: the per-origin tally of UNRESOLVED matches.
+func (m *memoryProvider) CountIncidentsMatching(query string) (IncidentCounts, error) {
+	recs, err := m.ListIncidents(0)
+	if err != nil {
+		return IncidentCounts{}, err
+	}
+	return incidentCountsOf(searchIncidentsInProcess(recs, query)), nil
+}
+
+// CountIncidentsMatchingByStatus implements the optional
+// storage.IncidentSearchPager capability: the search twin of
+// CountIncidentsByStatus.
+func (m *memoryProvider) CountIncidentsMatchingByStatus(query string) (IncidentStatusCounts, error) {
+	recs, err := m.ListIncidents(0)
+	if err != nil {
+		return IncidentStatusCounts{}, err
+	}
+	return StatusCountsOf(searchIncidentsInProcess(recs, query)), nil
+}
+
+// SearchIncidentsPage implements the optional storage.IncidentSearchPager
+// capability: one page of the ranked matches, filtered to origin.
+func (m *memoryProvider) SearchIncidentsPage(query, origin string, offset, limit int) ([]*IncidentRecord, error) {
+	if parseSearchQuery(query).empty() {
+		return m.ListIncidentsPage(origin, offset, limit)
+	}
+	recs, err := m.ListIncidents(0)
+	if err != nil {
+		return nil, err
+	}
+	return pageIncidents(searchIncidentsInProcess(recs, query), origin, offset, limit), nil
+}
+
+// SearchAnalyses matches the query against each analysis's JSON body, best
+// match first.
+func (m *memoryProvider) SearchAnalyses(query string, limit int) ([]*AnalysisRecord, error) {
+	recs, err := m.ListAnalyses(0)
+	if err != nil {
+		return nil, err
+	}
+	return limitAnalyses(searchAnalysesInProcess(recs, query), limit), nil
+}
+
 func (m *memoryProvider) Close() error { return nil }
 
 func (m *memoryProvider) SaveAnalysis(rec *AnalysisRecord) error {
@@ -325,6 +377,57 @@ func (m *memoryProvider) DeleteAnalysis(id string) error {
 	return ErrNotFound
 }
 
+// ---------------------------------------------------------------------------
+// Timeline (implements the optional storage.Timeline capability)
+// ---------------------------------------------------------------------------
+
+func (m *memoryProvider) AppendTimelineEntry(e *TimelineEntry) error {
+	if err := e.validate(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.hasIncidentLocked(e.IncidentID) {
+		return ErrNotFound
+	}
+	cp := *e
+	m.timeline = insertTimelineSorted(m.timeline, &cp)
+	return nil
+}
+
+func (m *memoryProvider) ListTimeline(incidentID string, limit int) ([]*TimelineEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var matched []*TimelineEntry
+	for _, e := range m.timeline {
+		if e.IncidentID == incidentID {
+			matched = append(matched, e)
+		}
+	}
+	return newestTimelineWindow(matched, limit), nil
+}
+
+func (m *memoryProvider) hasIncidentLocked(id string) bool {
+	for _, rec := range m.incidents {
+		if rec.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// dropOrphanTimelineLocked removes entries whose incident is gone, mirroring
+// the ON DELETE CASCADE the Postgres schema applies.
+func (m *memoryProvider) dropOrphanTimelineLocked() {
+	kept := m.timeline[:0]
+	for _, e := range m.timeline {
+		if m.hasIncidentLocked(e.IncidentID) {
+			kept = append(kept, e)
+		}
+	}
+	m.timeline = kept
+}
+
 // ---------------------------------------------------------------------------
 // Lifecycle (implements the optional storage.Lifecycle capability)
 // ---------------------------------------------------------------------------
@@ -333,17 +436,18 @@ func (m *memoryProvider) PurgeOlderThan(domain string, cutoff time.Time) (int, e
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	switch domain {
-	case DomainIncidents:
+	case DomainIncidents, DomainResolvedIncidents:
 		kept := make([]*IncidentRecord, 0, len(m.incidents))
 		n := 0
 		for _, rec := range m.incidents {
-			if rec.CreatedAt.Before(cutoff) {
+			if IncidentExpired(rec, domain, cutoff) {
 				n++
 				continue
 			}
 			kept = append(kept, rec)
 		}
 		m.incidents = kept
+		m.dropOrphanTimelineLocked()
 		return n, nil
 	case DomainAnalyses:
 		kept := make([]*AnalysisRecord, 0, len(m.analyses))
@@ -372,6 +476,55 @@ func (m *memoryProvider) PurgeOlderThan(domain string, cutoff time.Time) (int, e
 	}
 }
 
+// CountOlderThan implements the optional storage.LifecycleCounter
+// capability with the same predicates as PurgeOlderThan.
+func (m *memoryProvider) CountOlderThan(domain string, cutoff time.Time) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	n := 0
+	switch domain {
+	case DomainIncidents, DomainResolvedIncidents:
+		for _, rec := range m.incidents {
+			if IncidentExpired(rec, domain, cutoff) {
+				n++
+			}
+		}
+	case DomainAnalyses:
+		for _, rec := range m.analyses {
+			if rec.RequestedAt.Before(cutoff) {
+				n++
+			}
+		}
+	case DomainBlobs:
+		for _, at := range m.blobAt {
+			if at.Before(cutoff) {
+				n++
+			}
+		}
+	default:
+		return 0, ErrUnknownDomain
+	}
+	return n, nil
+}
+
+// IncidentExpired reports whether rec falls in an incident purge domain for
+// cutoff: created before it, or for DomainResolvedIncidents resolved before
+// it (created, when resolved without a ResolvedAt). It is the Go twin of the
+// SQL backends' lifecycle predicates, so retention and the archive job pick
+// the same incidents on every backend.
+func IncidentExpired(rec *IncidentRecord, domain string, cutoff time.Time) bool {
+	if domain != DomainResolvedIncidents {
+		return rec.CreatedAt.Before(cutoff)
+	}
+	if !rec.Resolved {
+		return false
+	}
+	if rec.ResolvedAt != nil {
+		return rec.ResolvedAt.Before(cutoff)
+	}
+	return rec.CreatedAt.Before(cutoff)
+}
+
 func (m *memoryProvider) DeleteByID(domain, id string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -380,6 +533,7 @@ func (m *memoryProvider) DeleteByID(domain, id string) error {
 		for i, rec := range m.incidents {
 			if rec.ID == id {
 				m.incidents = append(m.incidents[:i], m.incidents[i+1:]...)
+				m.dropOrphanTimelineLocked()
 				return nil
 			}
 		}
@@ -10,7 +10,7 @@ import (
 )
 
 // migrate.go — RunSQLMigrations: a fully FS- and table-agnostic
-// Postgres migration runner.
+// SQL migration runner (Postgres and SQLite).
 //
 // It is the ONE seam both the OSS backend and the out-of-tree enterprise
 // module use to apply their own `*.sql` migration sets against a shared
@@ -20,7 +20,8 @@ import (
 // it over its own `migrations/` under the `versus_schema_migrations` ledger;
 // the enterprise module runs its own set under a different ledger on the
 // same pool, so the two track independently and never re-apply each other's
-// files.
+// files. The SQLite backend runs its own `sqlite_migrations/` set through it
+// too.
 //
 // Guarantees:
 //   - each `*.sql` file is applied AT MOST ONCE — its filename is recorded
@@ -64,9 +65,10 @@ func isSafeSQLIdentifier(s string) bool {
 // RunSQLMigrations applies every `*.sql` file in fsys under dir, in lexical
 // filename order, exactly once each, tracking applied filenames in
 // ledgerTable so a re-run is a no-op. It is FS- and table-agnostic (see the
-// file header). db must be a Postgres *sql.DB (obtained via
-// storage.SQLAccessor.DB()); the ledger DDL and bookkeeping use Postgres
-// syntax.
+// file header). db is a Postgres *sql.DB (obtained via
+// storage.SQLAccessor.DB()) or the SQLite backend's own handle; the ledger
+// DDL and bookkeeping stick to syntax both accept ($N placeholders,
+// CURRENT_TIMESTAMP).
 //
 // Concurrency: RunSQLMigrations does NOT take the migration advisory lock
 // itself — the caller wraps it in WithMigrationLock when multiple processes
@@ -88,7 +90,7 @@ func RunSQLMigrations(db *sql.DB, fsys fs.FS, dir, ledgerTable string) error {
 	if _, err := db.ExecContext(ctx, fmt.Sprintf(`
 		CREATE TABLE IF NOT EXISTS %s (
 			filename   TEXT        PRIMARY KEY,
-			applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
 		)`, ledgerTable)); err != nil {
 		return fmt.Errorf("storage: RunSQLMigrations: create ledger %q: %w", ledgerTable, err)
 	}
@@ -0,0 +1,21 @@
+-- 009_incident_timeline.sql — append-only per-incident timeline.
+--
+-- One row per event: operator notes and comments, status changes,
+-- assignment changes, notification outcomes, on-call triggers and analysis
+-- runs. Rows are never updated. Deleting an incident (retention purge or
+-- DeleteByID) cascades to its timeline so no orphans are left behind.
+-- Safe to re-run: all statements use IF NOT EXISTS.
+
+CREATE TABLE IF NOT EXISTS vs_incident_timeline (
+    id          TEXT        PRIMARY KEY,
+    org_id      TEXT        NOT NULL DEFAULT 'default',
+    incident_id TEXT        NOT NULL REFERENCES vs_incidents (id) ON DELETE CASCADE,
+    kind        TEXT        NOT NULL,
+    actor       TEXT,
+    body        TEXT,
+    data        JSONB,
+    created_at  TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_incident_timeline_incident_created_at
+    ON vs_incident_timeline (incident_id, created_at DESC);
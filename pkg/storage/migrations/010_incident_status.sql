@@ -0,0 +1,14 @@
+-- 010_incident_status.sql — explicit incident lifecycle state.
+--
+-- `status` holds the state-machine value (triggered, acknowledged,
+-- investigating, mitigated, resolved, reopened, snoozed) and `snoozed_until`
+-- the wake-up time of a snoozed incident. Both are additive and nullable:
+-- rows written before this migration keep status NULL and are classified
+-- from the legacy resolved / acked_at columns at read time, so no backfill
+-- is needed. Safe to re-run: every statement uses IF NOT EXISTS.
+
+ALTER TABLE vs_incidents ADD COLUMN IF NOT EXISTS status        TEXT;
+ALTER TABLE vs_incidents ADD COLUMN IF NOT EXISTS snoozed_until TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_incidents_status_created_at
+    ON vs_incidents (status, created_at DESC);
@@ -0,0 +1,18 @@
+-- 011_incident_fields.sql — operator-editable incident metadata.
+--
+-- `severity` overrides the payload's severity (NULL means "use the
+-- payload"), `priority` is P1-P5, `labels` is a flat key→value object,
+-- `tags` a TEXT[] and `custom_fields` a JSON array of {key, type, value}.
+-- All are additive and nullable, so existing rows need no backfill. The GIN
+-- indexes serve exact tag and label lookups; free-text search still goes
+-- through the ILIKE predicate. Safe to re-run: every statement uses
+-- IF NOT EXISTS.
+
+ALTER TABLE vs_incidents ADD COLUMN IF NOT EXISTS severity      TEXT;
+ALTER TABLE vs_incidents ADD COLUMN IF NOT EXISTS priority      TEXT;
+ALTER TABLE vs_incidents ADD COLUMN IF NOT EXISTS labels        JSONB;
+ALTER TABLE vs_incidents ADD COLUMN IF NOT EXISTS tags          TEXT[];
+ALTER TABLE vs_incidents ADD COLUMN IF NOT EXISTS custom_fields JSONB;
+
+CREATE INDEX IF NOT EXISTS idx_incidents_tags   ON vs_incidents USING GIN (tags);
+CREATE INDEX IF NOT EXISTS idx_incidents_labels ON vs_incidents USING GIN (labels);
@@ -0,0 +1,16 @@
+-- 012_incident_relations.sql — merge, parent/child and related links.
+--
+-- `merged_into` is set on a duplicate closed by a merge, `parent_id` on a
+-- child incident, and `related_ids` on both sides of a related link. The
+-- indexes serve the reverse lookup (children, duplicates, links of one
+-- incident) behind ListIncidentsReferencing. All columns are additive and
+-- nullable, so existing rows need no backfill. Safe to re-run: every
+-- statement uses IF NOT EXISTS.
+
+ALTER TABLE vs_incidents ADD COLUMN IF NOT EXISTS merged_into TEXT;
+ALTER TABLE vs_incidents ADD COLUMN IF NOT EXISTS parent_id   TEXT;
+ALTER TABLE vs_incidents ADD COLUMN IF NOT EXISTS related_ids TEXT[];
+
+CREATE INDEX IF NOT EXISTS idx_incidents_merged_into ON vs_incidents (merged_into) WHERE merged_into IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_incidents_parent_id   ON vs_incidents (parent_id)   WHERE parent_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_incidents_related_ids ON vs_incidents USING GIN (related_ids);
@@ -0,0 +1,61 @@
+-- 013_incident_search.sql — full-text search over incidents and analyses.
+--
+-- `search_tsv` is a generated tsvector per row, served by a GIN index, so a
+-- search no longer scans every row with ILIKE. Text is lower-cased and split
+-- on anything that is not a letter or a digit before the 'simple' parser
+-- sees it, so the words match the in-process parser in search_query.go
+-- exactly: no stemming, no stop words, and "db-1" indexes as "db" and "1".
+-- Each field is capped at 100000 characters so a huge payload cannot
+-- overflow a tsvector.
+--
+-- Incident weights: title A; service, source and tags B; severity,
+-- priority, labels and custom fields C; the payload D. Analyses index their
+-- JSON body at D.
+--
+-- Adding a STORED generated column rewrites the table under an exclusive
+-- lock, so this migration takes a while on a large history. The functions
+-- are IMMUTABLE so a generated column may call them; array_to_string is
+-- only STABLE in general but is immutable over TEXT[]. Safe to re-run.
+
+CREATE OR REPLACE FUNCTION vs_search_words(doc TEXT, weight "char") RETURNS tsvector
+    LANGUAGE sql IMMUTABLE PARALLEL SAFE
+    AS $$
+        SELECT setweight(
+            to_tsvector('simple'::regconfig,
+                regexp_replace(lower(left(coalesce(doc, ''), 100000)), '[^[:alnum:]]+', ' ', 'g')),
+            weight)
+    $$;
+
+CREATE OR REPLACE FUNCTION vs_incident_search_tsv(
+    title TEXT, service TEXT, source TEXT, tags TEXT[],
+    severity TEXT, priority TEXT, labels JSONB, custom_fields JSONB, content JSONB
+) RETURNS tsvector
+    LANGUAGE sql IMMUTABLE PARALLEL SAFE
+    AS $$
+        SELECT vs_search_words(title, 'A')
+            || vs_search_words(service, 'B')
+            || vs_search_words(source, 'B')
+            || vs_search_words(array_to_string(tags, ' '), 'B')
+            || vs_search_words(severity, 'C')
+            || vs_search_words(priority, 'C')
+            || vs_search_words(labels::text, 'C')
+            || vs_search_words(custom_fields::text, 'C')
+            || vs_search_words(content::text, 'D')
+    $$;
+
+CREATE OR REPLACE FUNCTION vs_analysis_search_tsv(data JSONB) RETURNS tsvector
+    LANGUAGE sql IMMUTABLE PARALLEL SAFE
+    AS $$
+        SELECT vs_search_words(data::text, 'D')
+    $$;
+
+ALTER TABLE vs_incidents ADD COLUMN IF NOT EXISTS search_tsv tsvector
+    GENERATED ALWAYS AS (vs_incident_search_tsv(
+        title, service, source, tags, severity, priority, labels, custom_fields, content
+    )) STORED;
+
+ALTER TABLE vs_analyses ADD COLUMN IF NOT EXISTS search_tsv tsvector
+    GENERATED ALWAYS AS (vs_analysis_search_tsv(data)) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_incidents_search_tsv ON vs_incidents USING GIN (search_tsv);
+CREATE INDEX IF NOT EXISTS idx_analyses_search_tsv  ON vs_analyses  USING GIN (search_tsv);
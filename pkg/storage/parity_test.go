@@ -14,6 +14,7 @@ import (
 	"database/sql"
 	"errors"
 	"os"
+	"slices"
 	"testing"
 	"time"
 
@@ -401,6 +402,20 @@ func TestFileBlobListing(t *testing.T) {
 	}
 	defer p.Close()
 	runBlobListing(t, p)
+
+	// The record files sit next to the blobs but are not blobs.
+	if err := p.SaveIncident(&storage.IncidentRecord{ID: "inc-1"}); err != nil {
+		t.Fatalf("SaveIncident: %v", err)
+	}
+	all, err := p.ListBlobs("")
+	if err != nil {
+		t.Fatalf("ListBlobs(all): %v", err)
+	}
+	for _, b := range all {
+		if b.Name == "incidents" {
+			t.Fatalf("ListBlobs(all) lists the incident history file as a blob")
+		}
+	}
 }
 
 func TestPostgresBlobListing(t *testing.T) {
@@ -456,15 +471,32 @@ func TestPostgresBlobPerTable(t *testing.T) {
 }
 
 // ---------------------------------------------------------------------------
-// Postgres backend — search (the optional storage.Searcher capability)
+// Search (the optional storage.Searcher capability) — Postgres through the
+// tsvector index, memory and file in process; one suite, same answers.
 // ---------------------------------------------------------------------------
 
+func TestMemorySearch(t *testing.T) {
+	runSearch(t, storage.NewMemory())
+}
+
+func TestFileSearch(t *testing.T) {
+	p, err := storage.NewFile(storage.FileOptions{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewFile: %v", err)
+	}
+	defer p.Close()
+	runSearch(t, p)
+}
+
 func TestPostgresSearch(t *testing.T) {
-	p := newTestPostgres(t)
+	runSearch(t, newTestPostgres(t))
+}
 
+func runSearch(t *testing.T, p storage.Provider) {
+	t.Helper()
 	searcher, ok := p.(storage.Searcher)
 	if !ok {
-		t.Fatal("postgres backend must implement storage.Searcher")
+		t.Fatal("backend must implement storage.Searcher")
 	}
 
 	now := time.Now().UTC().Truncate(time.Millisecond)
@@ -527,4 +559,45 @@ func TestPostgresSearch(t *testing.T) {
 	if len(got) != 3 || got[0].ID != "s-3" {
 		t.Fatalf("SearchIncidents(empty) = %d records, newest %s; want 3 / s-3", len(got), got[0].ID)
 	}
+
+	// The query syntax: phrases, field filters, exclusions and ranking.
+	for query, want := range map[string][]string{
+		`"connection pool"`:   {"s-1"},
+		`"pool connection"`:   nil,
+		"payments -oomkilled": {"s-1"},
+		"service:checkout":    {"s-2"},
+		"-source:webhook":     {"s-2"},
+		"source:webhook pool": {"s-1"},
+		"pay":                 {"s-3", "s-1"},
+		"database":            {"s-1"},
+	} {
+		got, err := searcher.SearchIncidents(query, 0)
+		if err != nil {
+			t.Fatalf("SearchIncidents(%s): %v", query, err)
+		}
+		if ids := incidentIDs(got); !slices.Equal(ids, want) {
+			t.Errorf("SearchIncidents(%s) = %v, want %v", query, ids, want)
+		}
+	}
+	// A title match outranks a newer match in the service only.
+	if err := p.SaveIncident(&storage.IncidentRecord{ID: "s-4", Title: "Disk pressure", Service: "checkout-worker", CreatedAt: now}); err != nil {
+		t.Fatalf("SaveIncident: %v", err)
+	}
+	got, err = searcher.SearchIncidents("checkout", 0)
+	if err != nil {
+		t.Fatalf("SearchIncidents(checkout): %v", err)
+	}
+	if ids := incidentIDs(got); !slices.Equal(ids, []string{"s-4", "s-2"}) {
+		t.Fatalf("SearchIncidents(checkout) = %v, want newest first among equal ranks", ids)
+	}
+	if err := p.SaveIncident(&storage.IncidentRecord{ID: "s-5", Title: "Checkout errors", Service: "web", CreatedAt: now.Add(-time.Hour)}); err != nil {
+		t.Fatalf("SaveIncident: %v", err)
+	}
+	got, err = searcher.SearchIncidents("checkout", 0)
+	if err != nil {
+		t.Fatalf("SearchIncidents(checkout): %v", err)
+	}
+	if ids := incidentIDs(got); len(ids) != 3 || ids[0] != "s-5" {
+		t.Fatalf("SearchIncidents(checkout) = %v, want the title match s-5 first", ids)
+	}
 }
@@ -25,11 +25,13 @@ var migrationFiles embed.FS
 // empty in code when the factory will fall back to the POSTGRES_DSN
 // environment variable.
 type PostgresOptions struct {
-	DSN string // postgres connection string or DSN URL
+	DSN  string   // postgres connection string or DSN URL
+	Keys *Keyring // seals blobs, incident content and analysis output; nil stores them clear
 }
 
 type postgresProvider struct {
-	db *sql.DB
+	db   *sql.DB
+	keys *Keyring
 }
 
 // DB implements the optional storage.SQLAccessor capability: it
@@ -38,6 +40,11 @@ type postgresProvider struct {
 // pool. The pool is owned by this provider — callers MUST NOT Close it.
 func (p *postgresProvider) DB() *sql.DB { return p.db }
 
+// Keyring implements the optional storage.KeyringHolder capability, so the
+// Postgres catalog store seals the pattern samples it writes to the typed
+// signal tables with the same keys.
+func (p *postgresProvider) Keyring() *Keyring { return p.keys }
+
 // defaultConnectBudget bounds the total time NewPostgres will spend retrying
 // the initial Postgres reachability check before giving up. It is overridable
 // per deployment via the POSTGRES_CONNECT_TIMEOUT env var.
@@ -238,7 +245,7 @@ func NewPostgres(opts PostgresOptions) (Provider, error) {
 		time.Sleep(backoff)
 	}
 
-	p := &postgresProvider{db: db}
+	p := &postgresProvider{db: db, keys: opts.Keys}
 	if err := p.migrate(); err != nil {
 		if hint := pgSetupHint(err, dbName); hint != "" {
 			log.Printf("%s", hint)
@@ -365,10 +372,14 @@ func (p *postgresProvider) ReadBlob(name string) ([]byte, error) {
 	if err != nil {
 		return nil, fmt.Errorf("storage: read blob %q: %w", name, err)
 	}
-	return data, nil
+	return p.keys.openBlob(name, data)
 }
 
 func (p *postgresProvider) WriteBlob(name string, data []byte) error {
+	data, err := p.keys.sealBlob(name, data)
+	if err != nil {
+		return err
+	}
 	q := fmt.Sprintf(`
 		INSERT INTO %s (name, data, updated_at)
 		VALUES ($1, $2, NOW())
@@ -393,6 +404,10 @@ func (p *postgresProvider) WriteBlob(name string, data []byte) error {
 // name/data are bound as parameters, matching the package's parameterized-SQL
 // convention.
 func (p *postgresProvider) CreateBlobIfAbsent(name string, data []byte) (bool, error) {
+	data, err := p.keys.sealBlob(name, data)
+	if err != nil {
+		return false, err
+	}
 	ins := fmt.Sprintf(`
 		INSERT INTO %s (name, data, updated_at)
 		VALUES ($1, $2, NOW())
@@ -452,7 +467,7 @@ func (p *postgresProvider) ListBlobs(prefix string) ([]Blob, error) {
 			return nil, fmt.Errorf("storage: list blobs %s: %w", table, err)
 		}
 	}
-	return out, nil
+	return out, p.keys.openBlobs(out)
 }
 
 // escapeLike escapes the SQL LIKE metacharacters in s so it is matched as a
@@ -483,7 +498,22 @@ const incidentColumns = `id, created_at, acked_at, org_id, team_id, title,
 	to_jsonb(channels_notified)   AS channels_notified,
 	oncall_triggered, oncall_error, notify_status, notify_error,
 	resolved_at, content, assigned_team_id,
-	to_jsonb(assigned_member_ids) AS assigned_member_ids`
+	to_jsonb(assigned_member_ids) AS assigned_member_ids,
+	status, snoozed_until, severity, priority, labels,
+	to_jsonb(tags)                AS tags,
+	custom_fields, merged_into, parent_id,
+	to_jsonb(related_ids)         AS related_ids`
+
+// effectiveStatusSQL is IncidentRecord.EffectiveStatus as a SQL expression
+// over vs_incidents, so counts classify legacy rows (no status) and lapsed
+// snoozes exactly as the Go side does.
+const effectiveStatusSQL = `CASE
+		WHEN status = 'snoozed' AND (snoozed_until IS NULL OR snoozed_until <= now()) THEN 'triggered'
+		WHEN status IN ('triggered', 'acknowledged', 'investigating', 'mitigated', 'resolved', 'reopened', 'snoozed') THEN status
+		WHEN resolved THEN 'resolved'
+		WHEN acked_at IS NOT NULL THEN 'acknowledged'
+		ELSE 'triggered'
+	END`
 
 // rowScanner is satisfied by both *sql.Row and *sql.Rows, so scanIncidentRow
 // serves the single-row GetIncident path and the multi-row list/search paths
@@ -526,6 +556,19 @@ func marshalIncidentContent(m map[string]interface{}) (any, error) {
 	return b, nil
 }
 
+// marshalJSONColumn renders v for a nullable JSONB column. n is v's length;
+// an empty value binds as SQL NULL so it reads back as nil.
+func marshalJSONColumn(n int, v any) (any, error) {
+	if n == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
 // jsonStringSlice decodes a to_jsonb(TEXT[]) column back into a string slice.
 // A NULL column (raw is empty) yields a nil slice.
 func jsonStringSlice(raw []byte) ([]string, error) {
@@ -557,10 +600,22 @@ func (p *postgresProvider) SaveIncident(rec *IncidentRecord) error {
 		return fmt.Errorf("storage: SaveIncident: missing id")
 	}
 	rec.OrgID = NormalizeOrgID(rec.OrgID)
-	content, err := marshalIncidentContent(rec.Content)
+	sealed, err := p.keys.sealIncident(rec)
+	if err != nil {
+		return err
+	}
+	content, err := marshalIncidentContent(sealed.Content)
 	if err != nil {
 		return fmt.Errorf("storage: marshal incident content: %w", err)
 	}
+	labels, err := marshalJSONColumn(len(rec.Labels), rec.Labels)
+	if err != nil {
+		return fmt.Errorf("storage: marshal incident labels: %w", err)
+	}
+	custom, err := marshalJSONColumn(len(rec.CustomFields), rec.CustomFields)
+	if err != nil {
+		return fmt.Errorf("storage: marshal incident custom fields: %w", err)
+	}
 	// Full-column upsert: this is the one incident write path (create, resolve,
 	// and ack all funnel through it), so every column is (re)written from the
 	// record ON CONFLICT and the row never drifts. Origin is persisted as the
@@ -570,12 +625,16 @@ func (p *postgresProvider) SaveIncident(rec *IncidentRecord) error {
 			id, created_at, acked_at, org_id, team_id, title, source, service,
 			origin, resolved, channels_enabled, channels_notified,
 			oncall_triggered, oncall_error, notify_status, notify_error,
-			resolved_at, content, assigned_team_id, assigned_member_ids
+			resolved_at, content, assigned_team_id, assigned_member_ids,
+			status, snoozed_until, severity, priority, labels, tags,
+			custom_fields, merged_into, parent_id, related_ids
 		) VALUES (
 			$1, $2, $3, $4, $5, $6, $7, $8,
 			$9, $10, $11, $12,
 			$13, $14, $15, $16,
-			$17, $18, $19, $20
+			$17, $18, $19, $20,
+			NULLIF($21, ''), $22, NULLIF($23, ''), NULLIF($24, ''), $25, $26,
+			$27, NULLIF($28, ''), NULLIF($29, ''), $30
 		)
 		ON CONFLICT (id) DO UPDATE SET
 			created_at          = EXCLUDED.created_at,
@@ -596,7 +655,17 @@ func (p *postgresProvider) SaveIncident(rec *IncidentRecord) error {
 			resolved_at         = EXCLUDED.resolved_at,
 			content             = EXCLUDED.content,
 			assigned_team_id    = EXCLUDED.assigned_team_id,
-			assigned_member_ids = EXCLUDED.assigned_member_ids
+			assigned_member_ids = EXCLUDED.assigned_member_ids,
+			status              = EXCLUDED.status,
+			snoozed_until       = EXCLUDED.snoozed_until,
+			severity            = EXCLUDED.severity,
+			priority            = EXCLUDED.priority,
+			labels              = EXCLUDED.labels,
+			tags                = EXCLUDED.tags,
+			custom_fields       = EXCLUDED.custom_fields,
+			merged_into         = EXCLUDED.merged_into,
+			parent_id           = EXCLUDED.parent_id,
+			related_ids         = EXCLUDED.related_ids
 	`,
 		rec.ID, rec.CreatedAt.UTC(), utcPtr(rec.AckedAt), rec.OrgID, rec.TeamID,
 		rec.Title, rec.Source, rec.Service, rec.EffectiveOrigin(), rec.Resolved,
@@ -604,6 +673,9 @@ func (p *postgresProvider) SaveIncident(rec *IncidentRecord) error {
 		rec.OnCallTriggered, rec.OnCallError, rec.NotifyStatus, rec.NotifyError,
 		utcPtr(rec.ResolvedAt), content, rec.AssignedTeamID,
 		textArrayParam(rec.AssignedMemberIDs),
+		rec.Status, utcPtr(rec.SnoozedUntil), rec.Severity, rec.Priority,
+		labels, textArrayParam(rec.Tags), custom,
+		rec.MergedInto, rec.ParentID, textArrayParam(rec.RelatedIDs),
 	)
 	if err != nil {
 		return fmt.Errorf("storage: save incident: %w", err)
@@ -614,10 +686,18 @@ func (p *postgresProvider) SaveIncident(rec *IncidentRecord) error {
 	return nil
 }
 
+// UpdateIncidentAck stamps acked_at and, like IncidentRecord.Transition,
+// moves a triggered, reopened or snoozed incident to acknowledged; one
+// further along (or resolved) keeps its state.
 func (p *postgresProvider) UpdateIncidentAck(id string, ackedAt time.Time) error {
 	t := ackedAt.UTC()
-	res, err := p.db.Exec(
-		`UPDATE vs_incidents SET acked_at = $2 WHERE id = $1`, id, t,
+	res, err := p.db.Exec(`
+		UPDATE vs_incidents SET
+			acked_at      = $2,
+			status        = CASE WHEN `+effectiveStatusSQL+` IN ('triggered', 'reopened', 'snoozed')
+			                     THEN 'acknowledged' ELSE `+effectiveStatusSQL+` END,
+			snoozed_until = NULL
+		WHERE id = $1`, id, t,
 	)
 	if err != nil {
 		return fmt.Errorf("storage: ack update: %w", err)
@@ -640,6 +720,9 @@ func (p *postgresProvider) GetIncident(id string) (*IncidentRecord, error) {
 	if err != nil {
 		return nil, fmt.Errorf("storage: get incident: %w", err)
 	}
+	if err := p.keys.openIncident(rec); err != nil {
+		return nil, err
+	}
 	return rec, nil
 }
 
@@ -661,7 +744,7 @@ func (p *postgresProvider) ListIncidents(limit int) ([]*IncidentRecord, error) {
 		return nil, fmt.Errorf("storage: list incidents: %w", err)
 	}
 	defer rows.Close()
-	return scanIncidentRows(rows)
+	return scanIncidentRows(rows, p.keys)
 }
 
 // scanIncidentRow reads one row selected via incidentColumns into an
@@ -682,6 +765,16 @@ func scanIncidentRow(sc rowScanner) (*IncidentRecord, error) {
 		notifyStat  sql.NullString
 		notifyErr   sql.NullString
 		assignTeam  sql.NullString
+		status      sql.NullString
+		snoozedTill sql.NullTime
+		severity    sql.NullString
+		priority    sql.NullString
+		labels      []byte
+		tags        []byte
+		custom      []byte
+		mergedInto  sql.NullString
+		parentID    sql.NullString
+		relatedIDs  []byte
 		chEnabled   []byte
 		chNotified  []byte
 		assignedIDs []byte
@@ -693,6 +786,8 @@ func scanIncidentRow(sc rowScanner) (*IncidentRecord, error) {
 		&chEnabled, &chNotified,
 		&oncallTrig, &oncallErr, &notifyStat, &notifyErr,
 		&resolvedAt, &content, &assignTeam, &assignedIDs,
+		&status, &snoozedTill, &severity, &priority, &labels,
+		&tags, &custom, &mergedInto, &parentID, &relatedIDs,
 	); err != nil {
 		return nil, err
 	}
@@ -715,6 +810,15 @@ func scanIncidentRow(sc rowScanner) (*IncidentRecord, error) {
 	rec.NotifyStatus = notifyStat.String
 	rec.NotifyError = notifyErr.String
 	rec.AssignedTeamID = assignTeam.String
+	rec.Status = status.String
+	if snoozedTill.Valid {
+		t := snoozedTill.Time.UTC()
+		rec.SnoozedUntil = &t
+	}
+	rec.Severity = severity.String
+	rec.Priority = priority.String
+	rec.MergedInto = mergedInto.String
+	rec.ParentID = parentID.String
 
 	var err error
 	if rec.ChannelsEnabled, err = jsonStringSlice(chEnabled); err != nil {
@@ -729,10 +833,27 @@ func scanIncidentRow(sc rowScanner) (*IncidentRecord, error) {
 	if rec.Content, err = unmarshalIncidentContent(content); err != nil {
 		return nil, fmt.Errorf("decode content: %w", err)
 	}
+	if rec.Tags, err = jsonStringSlice(tags); err != nil {
+		return nil, fmt.Errorf("decode tags: %w", err)
+	}
+	if rec.RelatedIDs, err = jsonStringSlice(relatedIDs); err != nil {
+		return nil, fmt.Errorf("decode related_ids: %w", err)
+	}
+	if len(labels) > 0 {
+		if err := json.Unmarshal(labels, &rec.Labels); err != nil {
+			return nil, fmt.Errorf("decode labels: %w", err)
+		}
+	}
+	if len(custom) > 0 {
+		if err := json.Unmarshal(custom, &rec.CustomFields); err != nil {
+			return nil, fmt.Errorf("decode custom_fields: %w", err)
+		}
+	}
 	return &rec, nil
 }
 
-func scanIncidentRows(rows *sql.Rows) ([]*IncidentRecord, error) {
+// scanIncidentRows scans every row and opens sealed content with keys.
+func scanIncidentRows(rows *sql.Rows, keys *Keyring) ([]*IncidentRecord, error) {
 	var out []*IncidentRecord
 	for rows.Next() {
 		rec, err := scanIncidentRow(rows)
@@ -741,7 +862,27 @@ func scanIncidentRows(rows *sql.Rows) ([]*IncidentRecord, error) {
 		}
 		out = append(out, rec)
 	}
-	return out, rows.Err()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, keys.openIncidents(out)
+}
+
+// ListIncidentsReferencing implements the optional storage.RelationLister
+// capability: the incidents whose merged_into or parent_id is id, or whose
+// related_ids contain it, newest first, served by the relationship indexes.
+func (p *postgresProvider) ListIncidentsReferencing(id string) ([]*IncidentRecord, error) {
+	rows, err := p.db.Query(`
+		SELECT `+incidentColumns+` FROM vs_incidents
+		WHERE merged_into = $1 OR parent_id = $1 OR related_ids @> ARRAY[$1]::TEXT[]
+		ORDER BY created_at DESC
+		LIMIT $2`, id, MaxIncidentCluster,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("storage: list referencing incidents: %w", err)
+	}
+	defer rows.Close()
+	return scanIncidentRows(rows, p.keys)
 }
 
 // CountIncidents implements the optional storage.IncidentPager capability:
@@ -767,32 +908,55 @@ func (p *postgresProvider) CountIncidents() (IncidentCounts, error) {
 }
 
 // CountIncidentsByStatus implements the optional storage.IncidentPager
-// capability: the whole-set per-origin × per-status tally in ONE COUNT query,
-// without shipping a single row to Go. The per-status buckets read the
-// promoted resolved / acked_at columns and the per-origin split reads the
-// promoted origin column, so the whole query is index-friendly. Only the
-// whole-set totals and the ai_detect slice are counted in SQL; webhook is
+// capability: the whole-set per-origin × per-status tally in ONE grouped COUNT
+// query, without shipping a single incident row to Go. Each row is classified
+// by effectiveStatusSQL (the SQL twin of EffectiveStatus) and by the promoted
+// origin column. Only the whole-set totals and the ai_detect slice are
+// counted; webhook is
 // derived as (total − ai_detect) by AssembleStatusCounts so a legacy row with
 // an empty origin — which classifies as webhook via EffectiveOrigin — is
 // counted as webhook here too, keeping AIDetect + Webhook == Total.
 func (p *postgresProvider) CountIncidentsByStatus() (IncidentStatusCounts, error) {
-	const q = `
-		SELECT
-			COUNT(*) FILTER (WHERE resolved = false AND acked_at IS NULL)     AS open_total,
-			COUNT(*) FILTER (WHERE resolved = false AND acked_at IS NOT NULL) AS acked_total,
-			COUNT(*) FILTER (WHERE resolved = true)                           AS resolved_total,
-			COUNT(*)                                                          AS all_total,
-			COUNT(*) FILTER (WHERE origin = 'ai_detect' AND resolved = false AND acked_at IS NULL)     AS open_ai,
-			COUNT(*) FILTER (WHERE origin = 'ai_detect' AND resolved = false AND acked_at IS NOT NULL) AS acked_ai,
-			COUNT(*) FILTER (WHERE origin = 'ai_detect' AND resolved = true)                           AS resolved_ai,
-			COUNT(*) FILTER (WHERE origin = 'ai_detect')                                               AS all_ai
+	c, err := p.countByStatus("")
+	if err != nil {
+		return IncidentStatusCounts{}, fmt.Errorf("storage: count incidents by status: %w", err)
+	}
+	return c, nil
+}
+
+// countByStatus groups the rows matching where (all rows when empty) by
+// ai_detect-or-not × effective status and folds the groups into the
+// per-state buckets. At most 2 × 7 rows come back, whatever the table size.
+func (p *postgresProvider) countByStatus(where string, args ...any) (IncidentStatusCounts, error) {
+	q := `
+		SELECT origin = 'ai_detect' AS ai, ` + effectiveStatusSQL + ` AS st, COUNT(*)
 		FROM vs_incidents`
+	if where != "" {
+		q += ` WHERE (` + where + `)`
+	}
+	q += ` GROUP BY 1, 2`
+	rows, err := p.db.Query(q, args...)
+	if err != nil {
+		return IncidentStatusCounts{}, err
+	}
+	defer rows.Close()
 	var total, ai StatusCounts
-	if err := p.db.QueryRow(q).Scan(
-		&total.Open, &total.Acked, &total.Resolved, &total.Total,
-		&ai.Open, &ai.Acked, &ai.Resolved, &ai.Total,
-	); err != nil {
-		return IncidentStatusCounts{}, fmt.Errorf("storage: count incidents by status: %w", err)
+	for rows.Next() {
+		var (
+			isAI sql.NullBool
+			st   string
+			n    int
+		)
+		if err := rows.Scan(&isAI, &st, &n); err != nil {
+			return IncidentStatusCounts{}, err
+		}
+		total.add(st, n)
+		if isAI.Bool {
+			ai.add(st, n)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return IncidentStatusCounts{}, err
 	}
 	return AssembleStatusCounts(total, ai), nil
 }
@@ -831,7 +995,7 @@ func (p *postgresProvider) ListIncidentsPage(origin string, offset, limit int) (
 		return nil, fmt.Errorf("storage: list incidents page: %w", err)
 	}
 	defer rows.Close()
-	return scanIncidentRows(rows)
+	return scanIncidentRows(rows, p.keys)
 }
 
 // ---------------------------------------------------------------------------
@@ -843,7 +1007,11 @@ func (p *postgresProvider) SaveAnalysis(rec *AnalysisRecord) error {
 		return fmt.Errorf("storage: SaveAnalysis: missing id")
 	}
 	rec.OrgID = NormalizeOrgID(rec.OrgID)
-	data, err := json.Marshal(rec)
+	sealed, err := p.keys.sealAnalysis(rec)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(sealed)
 	if err != nil {
 		return fmt.Errorf("storage: marshal analysis: %w", err)
 	}
@@ -876,6 +1044,9 @@ func (p *postgresProvider) GetAnalysis(id string) (*AnalysisRecord, error) {
 	if err := json.Unmarshal(raw, &rec); err != nil {
 		return nil, fmt.Errorf("storage: unmarshal analysis: %w", err)
 	}
+	if err := p.keys.openAnalysis(&rec); err != nil {
+		return nil, err
+	}
 	return &rec, nil
 }
 
@@ -904,7 +1075,7 @@ func (p *postgresProvider) ListAnalysesByIncident(incidentID string, limit int)
 		return nil, fmt.Errorf("storage: list analyses by incident: %w", err)
 	}
 	defer rows.Close()
-	return scanAnalysisRows(rows)
+	return scanAnalysisRows(rows, p.keys)
 }
 
 func (p *postgresProvider) ListAnalyses(limit int) ([]*AnalysisRecord, error) {
@@ -925,7 +1096,7 @@ func (p *postgresProvider) ListAnalyses(limit int) ([]*AnalysisRecord, error) {
 		return nil, fmt.Errorf("storage: list analyses: %w", err)
 	}
 	defer rows.Close()
-	return scanAnalysisRows(rows)
+	return scanAnalysisRows(rows, p.keys)
 }
 
 // CountAnalyses implements the optional storage.AnalysisPager capability: the
@@ -958,10 +1129,11 @@ func (p *postgresProvider) ListAnalysesPage(offset, limit int) ([]*AnalysisRecor
 		return nil, fmt.Errorf("storage: list analyses page: %w", err)
 	}
 	defer rows.Close()
-	return scanAnalysisRows(rows)
+	return scanAnalysisRows(rows, p.keys)
 }
 
-func scanAnalysisRows(rows *sql.Rows) ([]*AnalysisRecord, error) {
+// scanAnalysisRows decodes every row and opens sealed output with keys.
+func scanAnalysisRows(rows *sql.Rows, keys *Keyring) ([]*AnalysisRecord, error) {
 	var out []*AnalysisRecord
 	for rows.Next() {
 		var raw []byte
@@ -974,7 +1146,10 @@ func scanAnalysisRows(rows *sql.Rows) ([]*AnalysisRecord, error) {
 		}
 		out = append(out, &rec)
 	}
-	return out, rows.Err()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, keys.openAnalyses(out)
 }
 
 func (p *postgresProvider) DeleteAnalysis(id string) error {
@@ -990,47 +1165,90 @@ func (p *postgresProvider) DeleteAnalysis(id string) error {
 }
 
 // ---------------------------------------------------------------------------
-// Search (implements the optional storage.Searcher capability)
+// Search (implements the optional storage.Searcher and
+// storage.IncidentSearchPager capabilities)
 // ---------------------------------------------------------------------------
 
-// SearchIncidents matches the query (case-insensitive) against the
-// title/service/source columns and, as a fallback, the content JSON body.
-// An empty query degrades to ListIncidents. Results are newest first.
+// postgresSeveritySQL is the severity a severity: clause compares: the
+// operator-set column, else the payload keys SeverityLabel reads most often.
+// It approximates SeverityLabel, which also folds key case and reads
+// CloudWatch dimensions.
+const postgresSeveritySQL = `lower(btrim(coalesce(nullif(severity, ''),
+		content->>'severity', content->>'Severity', content->>'level', content->>'priority',
+		content->'labels'->>'severity', content->'commonLabels'->>'severity', '')))`
+
+// postgresSearch renders a parsed query (see search_query.go) as a WHERE
+// predicate over the search_tsv column and the field columns, binding its
+// values from $first on. rank is the ORDER BY relevance term, or "" when the
+// query has no free text to rank by.
+func postgresSearch(q searchQuery, first int) (where, rank string, args []any) {
+	bind := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", first+len(args)-1)
+	}
+	var preds []string
+	if tsq := q.tsquery(); tsq != "" {
+		ph := bind(tsq)
+		preds = append(preds, `search_tsv @@ to_tsquery('simple', `+ph+`)`)
+		if q.ranked() {
+			rank = `ts_rank(search_tsv, to_tsquery('simple', ` + ph + `))`
+		}
+	}
+	for _, c := range q.clauses {
+		var col string
+		switch c.field {
+		case SearchFieldService:
+			col = `lower(btrim(coalesce(service, '')))`
+		case SearchFieldSource:
+			col = `lower(btrim(coalesce(source, '')))`
+		case SearchFieldSeverity:
+			col = postgresSeveritySQL
+		default:
+			continue
+		}
+		op := "="
+		if c.negate {
+			op = "<>"
+		}
+		preds = append(preds, col+` `+op+` `+bind(c.value))
+	}
+	return strings.Join(preds, ` AND `), rank, args
+}
+
+// postgresSearchOrder is the ORDER BY for a search: relevance first when the
+// query ranks, then newest first.
+func postgresSearchOrder(rank, newest string) string {
+	if rank == "" {
+		return newest + ` DESC`
+	}
+	return rank + ` DESC, ` + newest + ` DESC`
+}
+
+// SearchIncidents matches the query syntax of search_query.go against the
+// search_tsv index and the field columns, best match first and newest first
+// among equals. An empty query degrades to ListIncidents.
 func (p *postgresProvider) SearchIncidents(query string, limit int) ([]*IncidentRecord, error) {
-	if query == "" {
+	q := parseSearchQuery(query)
+	if q.empty() {
 		return p.ListIncidents(limit)
 	}
-	pattern := "%" + query + "%"
+	where, rank, args := postgresSearch(q, 1)
 	base := `
 		SELECT ` + incidentColumns + ` FROM vs_incidents
-		WHERE ` + searchIncidentsWhereSQL + `
-		ORDER BY created_at DESC`
-	var (
-		rows *sql.Rows
-		err  error
-	)
+		WHERE ` + where + `
+		ORDER BY ` + postgresSearchOrder(rank, "created_at")
 	if limit > 0 {
-		rows, err = p.db.Query(base+` LIMIT $2`, pattern, limit)
-	} else {
-		rows, err = p.db.Query(base, pattern)
+		args = append(args, limit)
+		base += fmt.Sprintf(` LIMIT $%d`, len(args))
 	}
+	rows, err := p.db.Query(base, args...)
 	if err != nil {
 		return nil, fmt.Errorf("storage: search incidents: %w", err)
 	}
 	defer rows.Close()
-	return scanIncidentRows(rows)
+	return scanIncidentRows(rows, p.keys)
 }
 
-// searchIncidentsWhereSQL is the shared ILIKE predicate for incident search:
-// it matches the query against the title/service/source columns and, as a
-// fallback, the content JSON body. The pattern binds as $1. Kept as one
-// constant so the count and page queries search the exact same columns as
-// SearchIncidents.
-const searchIncidentsWhereSQL = `title      ILIKE $1
-		   OR service    ILIKE $1
-		   OR source     ILIKE $1
-		   OR content::text ILIKE $1`
-
 // CountIncidentsMatching implements the optional storage.IncidentSearchPager
 // capability: the per-origin tally and total of UNRESOLVED (open) search
 // matches in one COUNT query, without materializing rows. Counts are
@@ -1038,20 +1256,21 @@ const searchIncidentsWhereSQL = `title      ILIKE $1
 // badge over a filtered feed still reflects open work. An empty query degrades
 // to counting every unresolved incident, matching CountIncidents.
 func (p *postgresProvider) CountIncidentsMatching(query string) (IncidentCounts, error) {
-	if query == "" {
+	q := parseSearchQuery(query)
+	if q.empty() {
 		return p.CountIncidents()
 	}
-	pattern := "%" + query + "%"
-	q := fmt.Sprintf(`
+	where, _, args := postgresSearch(q, 1)
+	stmt := fmt.Sprintf(`
 		SELECT
 			COUNT(*) FILTER (WHERE origin = 'ai_detect') AS ai,
 			COUNT(*) FILTER (WHERE origin = 'webhook')   AS webhook,
 			COUNT(*)                                      AS total
 		FROM vs_incidents
 		WHERE (%[1]s)
-		  AND resolved = false`, searchIncidentsWhereSQL)
+		  AND resolved = false`, where)
 	var c IncidentCounts
-	if err := p.db.QueryRow(q, pattern).Scan(&c.AIDetect, &c.Webhook, &c.Total); err != nil {
+	if err := p.db.QueryRow(stmt, args...).Scan(&c.AIDetect, &c.Webhook, &c.Total); err != nil {
 		return IncidentCounts{}, fmt.Errorf("storage: count matching incidents: %w", err)
 	}
 	return c, nil
@@ -1065,40 +1284,28 @@ func (p *postgresProvider) CountIncidentsMatching(query string) (IncidentCounts,
 // totals and the ai_detect slice are counted; webhook is the complement so
 // AIDetect + Webhook == Total holds over the match set too.
 func (p *postgresProvider) CountIncidentsMatchingByStatus(query string) (IncidentStatusCounts, error) {
-	if query == "" {
+	q := parseSearchQuery(query)
+	if q.empty() {
 		return p.CountIncidentsByStatus()
 	}
-	pattern := "%" + query + "%"
-	q := fmt.Sprintf(`
-		SELECT
-			COUNT(*) FILTER (WHERE resolved = false AND acked_at IS NULL)     AS open_total,
-			COUNT(*) FILTER (WHERE resolved = false AND acked_at IS NOT NULL) AS acked_total,
-			COUNT(*) FILTER (WHERE resolved = true)                           AS resolved_total,
-			COUNT(*)                                                          AS all_total,
-			COUNT(*) FILTER (WHERE origin = 'ai_detect' AND resolved = false AND acked_at IS NULL)     AS open_ai,
-			COUNT(*) FILTER (WHERE origin = 'ai_detect' AND resolved = false AND acked_at IS NOT NULL) AS acked_ai,
-			COUNT(*) FILTER (WHERE origin = 'ai_detect' AND resolved = true)                           AS resolved_ai,
-			COUNT(*) FILTER (WHERE origin = 'ai_detect')                                               AS all_ai
-		FROM vs_incidents
-		WHERE (%[1]s)`, searchIncidentsWhereSQL)
-	var total, ai StatusCounts
-	if err := p.db.QueryRow(q, pattern).Scan(
-		&total.Open, &total.Acked, &total.Resolved, &total.Total,
-		&ai.Open, &ai.Acked, &ai.Resolved, &ai.Total,
-	); err != nil {
+	where, _, args := postgresSearch(q, 1)
+	c, err := p.countByStatus(where, args...)
+	if err != nil {
 		return IncidentStatusCounts{}, fmt.Errorf("storage: count matching incidents by status: %w", err)
 	}
-	return AssembleStatusCounts(total, ai), nil
+	return c, nil
 }
 
 // SearchIncidentsPage implements the optional storage.IncidentSearchPager
-// capability: one bounded, newest-first page of search matches, with the
-// query, the origin filter, the ordering, and the LIMIT/OFFSET all pushed
-// into SQL so a broad query over a large history never loads the whole match
-// set. The page lists ALL matching incidents (resolved and open alike); only
-// the counts are unresolved-only. An empty query degrades to ListIncidentsPage.
+// capability: one bounded page of search matches, best match first and
+// newest first among equals, with the query, the origin filter, the ordering,
+// and the LIMIT/OFFSET all pushed into SQL so a broad query over a large
+// history never loads the whole match set. The page lists ALL matching
+// incidents (resolved and open alike); only the counts are unresolved-only.
+// An empty query degrades to ListIncidentsPage.
 func (p *postgresProvider) SearchIncidentsPage(query, origin string, offset, limit int) ([]*IncidentRecord, error) {
-	if query == "" {
+	q := parseSearchQuery(query)
+	if q.empty() {
 		return p.ListIncidentsPage(origin, offset, limit)
 	}
 	if limit <= 0 {
@@ -1107,59 +1314,325 @@ func (p *postgresProvider) SearchIncidentsPage(query, origin string, offset, lim
 	if offset < 0 {
 		offset = 0
 	}
-	pattern := "%" + query + "%"
+	where, rank, args := postgresSearch(q, 1)
 	if origin == OriginAIDetect || origin == OriginWebhook {
-		q := fmt.Sprintf(`
-			SELECT %[2]s FROM vs_incidents
-			WHERE (%[1]s)
-			  AND origin = $2
-			ORDER BY created_at DESC
-			LIMIT $3 OFFSET $4`, searchIncidentsWhereSQL, incidentColumns)
-		rows, err := p.db.Query(q, pattern, origin, limit, offset)
-		if err != nil {
-			return nil, fmt.Errorf("storage: search incidents page: %w", err)
-		}
-		defer rows.Close()
-		return scanIncidentRows(rows)
-	}
-	q := fmt.Sprintf(`
-		SELECT %[2]s FROM vs_incidents
-		WHERE %[1]s
-		ORDER BY created_at DESC
-		LIMIT $2 OFFSET $3`, searchIncidentsWhereSQL, incidentColumns)
-	rows, err := p.db.Query(q, pattern, limit, offset)
+		args = append(args, origin)
+		where = fmt.Sprintf(`(%s) AND origin = $%d`, where, len(args))
+	}
+	stmt := fmt.Sprintf(`
+		SELECT %s FROM vs_incidents
+		WHERE %s
+		ORDER BY %s
+		LIMIT $%d OFFSET $%d`, incidentColumns, where, postgresSearchOrder(rank, "created_at"), len(args)+1, len(args)+2)
+	rows, err := p.db.Query(stmt, append(args, limit, offset)...)
 	if err != nil {
 		return nil, fmt.Errorf("storage: search incidents page: %w", err)
 	}
 	defer rows.Close()
-	return scanIncidentRows(rows)
+	return scanIncidentRows(rows, p.keys)
 }
 
-// SearchAnalyses matches the query (case-insensitive) against the whole
-// analysis JSON body, newest first.
+// SearchAnalyses matches the query against the analysis search_tsv index
+// (the JSON body), best match first. A field clause matches its value as a
+// phrase there, as in search_query.go.
 func (p *postgresProvider) SearchAnalyses(query string, limit int) ([]*AnalysisRecord, error) {
-	if query == "" {
+	q := parseSearchQuery(query)
+	if q.empty() {
 		return p.ListAnalyses(limit)
 	}
-	pattern := "%" + query + "%"
+	where, rank, args := postgresSearch(q.asText(), 1)
 	base := `
 		SELECT data FROM vs_analyses
-		WHERE data::text ILIKE $1
-		ORDER BY requested_at DESC`
-	var (
-		rows *sql.Rows
-		err  error
-	)
+		WHERE ` + where + `
+		ORDER BY ` + postgresSearchOrder(rank, "requested_at")
 	if limit > 0 {
-		rows, err = p.db.Query(base+` LIMIT $2`, pattern, limit)
-	} else {
-		rows, err = p.db.Query(base, pattern)
+		args = append(args, limit)
+		base += fmt.Sprintf(` LIMIT $%d`, len(args))
 	}
+	rows, err := p.db.Query(base, args...)
 	if err != nil {
 		return nil, fmt.Errorf("storage: search analyses: %w", err)
 	}
 	defer rows.Close()
-	return scanAnalysisRows(rows)
+	return scanAnalysisRows(rows, p.keys)
+}
+
+// ---------------------------------------------------------------------------
+// Range listing and response analytics (implement the optional
+// storage.RangeLister and storage.ResponseAggregator capabilities)
+// ---------------------------------------------------------------------------
+
+// ListIncidentsInRange implements storage.RangeLister: the incidents created
+// in [start, end), newest first, with the bound pushed into SQL and served
+// by the created_at index. A zero end is an open upper bound.
+func (p *postgresProvider) ListIncidentsInRange(start, end time.Time, limit int) ([]*IncidentRecord, error) {
+	q := `SELECT ` + incidentColumns + ` FROM vs_incidents
+		WHERE created_at >= $1 AND ($2::timestamptz IS NULL OR created_at < $2)
+		ORDER BY created_at DESC`
+	args := []any{start.UTC(), rangeEndParam(end)}
+	if limit > 0 {
+		q += ` LIMIT $3`
+		args = append(args, limit)
+	}
+	rows, err := p.db.Query(q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("storage: list incidents in range: %w", err)
+	}
+	defer rows.Close()
+	return scanIncidentRows(rows, p.keys)
+}
+
+// rangeEndParam binds a zero end as SQL NULL (no upper bound).
+func rangeEndParam(end time.Time) any {
+	if end.IsZero() {
+		return nil
+	}
+	return end.UTC()
+}
+
+// responseGroupKeySQL maps a grouping to the SQL expression it groups on.
+// Each must match responseGroupKey in the services package exactly, or the
+// grouping is left out and the caller computes it from the records.
+//
+// Service groups on the stored column, which is what ServiceLabel returns
+// whenever it is set; a range holding a row without one falls back (see
+// AggregateResponseStats). Severity is not here: its column is only the
+// operator override, and the payload fallback SeverityLabel reads (key case
+// folding, CloudWatch dimensions) has no exact SQL form.
+var responseGroupKeySQL = map[string]string{
+	GroupByNone:    `''`,
+	GroupByService: `service`,
+	GroupByTeam:    `COALESCE(NULLIF(assigned_team_id, ''), NULLIF(team_id, ''), 'unassigned')`,
+	GroupBySource:  `COALESCE(NULLIF(source, ''), 'unknown')`,
+	GroupByOrigin:  `CASE WHEN origin = 'ai_detect' THEN 'ai_detect' ELSE 'webhook' END`,
+}
+
+// AggregateResponseStats implements storage.ResponseAggregator with one
+// GROUP BY over the range. Durations are computed per row in the inner
+// query (NULL when not applicable, which the aggregates skip), and the
+// percentiles use percentile_cont, the same interpolation as the Go path.
+func (p *postgresProvider) AggregateResponseStats(start, end time.Time, groupBy string) ([]ResponseStats, error) {
+	key, ok := responseGroupKeySQL[groupBy]
+	if !ok {
+		return nil, ErrGroupNotAggregated
+	}
+	if groupBy == GroupByService {
+		// A row without the column takes its service from the payload in Go.
+		var legacy bool
+		if err := p.db.QueryRow(`
+			SELECT EXISTS (SELECT 1 FROM vs_incidents
+				WHERE created_at >= $1 AND ($2::timestamptz IS NULL OR created_at < $2)
+					AND COALESCE(service, '') = '')`, start.UTC(), rangeEndParam(end)).Scan(&legacy); err != nil {
+			return nil, fmt.Errorf("storage: aggregate response stats: %w", err)
+		}
+		if legacy {
+			return nil, ErrGroupNotAggregated
+		}
+	}
+	q := fmt.Sprintf(`
+		SELECT key,
+			COUNT(*),
+			COUNT(*) FILTER (WHERE acked_at IS NOT NULL),
+			COUNT(*) FILTER (WHERE resolved),
+			COUNT(*) FILTER (WHERE oncall_triggered),
+			COUNT(tta),
+			COALESCE(AVG(tta), 0)::float8,
+			COALESCE(percentile_cont(0.50) WITHIN GROUP (ORDER BY tta), 0)::float8,
+			COALESCE(percentile_cont(0.90) WITHIN GROUP (ORDER BY tta), 0)::float8,
+			COALESCE(percentile_cont(0.95) WITHIN GROUP (ORDER BY tta), 0)::float8,
+			COALESCE(percentile_cont(0.99) WITHIN GROUP (ORDER BY tta), 0)::float8,
+			COUNT(ttr),
+			COALESCE(AVG(ttr), 0)::float8,
+			COALESCE(percentile_cont(0.50) WITHIN GROUP (ORDER BY ttr), 0)::float8,
+			COALESCE(percentile_cont(0.90) WITHIN GROUP (ORDER BY ttr), 0)::float8,
+			COALESCE(percentile_cont(0.95) WITHIN GROUP (ORDER BY ttr), 0)::float8,
+			COALESCE(percentile_cont(0.99) WITHIN GROUP (ORDER BY ttr), 0)::float8
+		FROM (
+			SELECT %s AS key, acked_at, resolved, COALESCE(oncall_triggered, false) AS oncall_triggered,
+				CASE WHEN acked_at >= created_at
+					THEN EXTRACT(EPOCH FROM acked_at - created_at)::float8 END AS tta,
+				CASE WHEN resolved AND resolved_at >= created_at AND merged_into IS NULL
+					THEN EXTRACT(EPOCH FROM resolved_at - created_at)::float8 END AS ttr
+			FROM vs_incidents
+			WHERE created_at >= $1 AND ($2::timestamptz IS NULL OR created_at < $2)
+		) t
+		GROUP BY key
+		ORDER BY COUNT(*) DESC, key`, key)
+	rows, err := p.db.Query(q, start.UTC(), rangeEndParam(end))
+	if err != nil {
+		return nil, fmt.Errorf("storage: aggregate response stats: %w", err)
+	}
+	defer rows.Close()
+	var out []ResponseStats
+	for rows.Next() {
+		var s ResponseStats
+		if err := rows.Scan(&s.Key, &s.Incidents, &s.Acknowledged, &s.Resolved, &s.Escalated,
+			&s.MTTA.Count, &s.MTTA.Mean, &s.MTTA.P50, &s.MTTA.P90, &s.MTTA.P95, &s.MTTA.P99,
+			&s.MTTR.Count, &s.MTTR.Mean, &s.MTTR.P50, &s.MTTR.P90, &s.MTTR.P95, &s.MTTR.P99,
+		); err != nil {
+			return nil, fmt.Errorf("storage: scan response stats: %w", err)
+		}
+		if s.Incidents > 0 {
+			s.EscalationRate = float64(s.Escalated) / float64(s.Incidents)
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// ---------------------------------------------------------------------------
+// Bulk updates (implement the optional storage.BulkIncidentUpdater capability)
+//
+// Each operation is one set-based statement over id = ANY($1) that only
+// touches rows the change actually alters and returns them, so a bulk call
+// costs one round trip however many incidents it covers.
+// ---------------------------------------------------------------------------
+
+// bulkTagsSQL is the tag set after adding $2 and removing $3, in the same
+// byte order NormalizeTags sorts by. An empty result is NULL, like a record
+// with no tags.
+const bulkTagsSQL = `(
+		SELECT array_agg(DISTINCT t COLLATE "C" ORDER BY t COLLATE "C")
+		FROM unnest(COALESCE(tags, '{}'::TEXT[]) || $2::TEXT[]) AS t
+		WHERE t <> ALL($3::TEXT[])
+	)`
+
+func (p *postgresProvider) BulkResolveIncidents(ids []string, at time.Time) ([]*IncidentRecord, error) {
+	return p.bulkUpdate("resolve", `
+		UPDATE vs_incidents SET
+			status        = 'resolved',
+			resolved      = TRUE,
+			resolved_at   = $2,
+			snoozed_until = NULL
+		WHERE id = ANY($1) AND `+effectiveStatusSQL+` <> 'resolved'
+		RETURNING `+incidentColumns, ids, at.UTC(),
+	)
+}
+
+func (p *postgresProvider) BulkAssignIncidents(ids []string, teamID *string, memberIDs *[]string) ([]*IncidentRecord, error) {
+	var team string
+	if teamID != nil {
+		team = *teamID
+	}
+	var members []string
+	if memberIDs != nil {
+		members = *memberIDs
+	}
+	return p.bulkUpdate("assign", `
+		UPDATE vs_incidents SET
+			assigned_team_id    = CASE WHEN $2 THEN $3 ELSE assigned_team_id END,
+			assigned_member_ids = CASE WHEN $4 THEN $5::TEXT[] ELSE assigned_member_ids END
+		WHERE id = ANY($1) AND (
+			($2 AND COALESCE(assigned_team_id, '') <> $3) OR
+			($4 AND assigned_member_ids IS DISTINCT FROM $5::TEXT[]))
+		RETURNING `+incidentColumns,
+		ids, teamID != nil, team, memberIDs != nil, textArrayParam(members),
+	)
+}
+
+func (p *postgresProvider) BulkTagIncidents(ids []string, add, remove []string) ([]*IncidentRecord, error) {
+	if add == nil {
+		add = []string{}
+	}
+	if remove == nil {
+		remove = []string{}
+	}
+	return p.bulkUpdate("tag", `
+		UPDATE vs_incidents SET tags = `+bulkTagsSQL+`
+		WHERE id = ANY($1)
+			AND tags IS DISTINCT FROM `+bulkTagsSQL+`
+			AND COALESCE(cardinality(`+bulkTagsSQL+`), 0) <= $4
+		RETURNING `+incidentColumns, ids, add, remove, MaxIncidentTags,
+	)
+}
+
+func (p *postgresProvider) BulkDeleteIncidents(ids []string) ([]*IncidentRecord, error) {
+	return p.bulkUpdate("delete", `
+		DELETE FROM vs_incidents WHERE id = ANY($1)
+		RETURNING `+incidentColumns, ids,
+	)
+}
+
+// bulkUpdate runs one bulk statement and scans the rows it returns.
+func (p *postgresProvider) bulkUpdate(op, q string, args ...any) ([]*IncidentRecord, error) {
+	rows, err := p.db.Query(q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("storage: bulk %s incidents: %w", op, err)
+	}
+	defer rows.Close()
+	return scanIncidentRows(rows, p.keys)
+}
+
+// ---------------------------------------------------------------------------
+// Timeline (implements the optional storage.Timeline capability)
+// ---------------------------------------------------------------------------
+
+// pgForeignKeyViolation is the SQLSTATE Postgres raises when an insert
+// references a row that does not exist.
+const pgForeignKeyViolation = "23503"
+
+// AppendTimelineEntry inserts one row. The incident_id foreign key is the
+// existence check: a violation maps to ErrNotFound, matching the file and
+// memory backends.
+func (p *postgresProvider) AppendTimelineEntry(e *TimelineEntry) error {
+	if err := e.validate(); err != nil {
+		return err
+	}
+	data, err := marshalIncidentContent(e.Data)
+	if err != nil {
+		return fmt.Errorf("storage: marshal timeline data: %w", err)
+	}
+	_, err = p.db.Exec(`
+		INSERT INTO vs_incident_timeline (id, org_id, incident_id, kind, actor, body, data, created_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), $7, $8)
+	`, e.ID, e.OrgID, e.IncidentID, e.Kind, e.Actor, e.Body, data, e.CreatedAt)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgForeignKeyViolation {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("storage: append timeline entry: %w", err)
+	}
+	return nil
+}
+
+// ListTimeline selects the newest limit rows through the
+// (incident_id, created_at DESC) index and flips them back to chronological
+// order in the outer query.
+func (p *postgresProvider) ListTimeline(incidentID string, limit int) ([]*TimelineEntry, error) {
+	if limit <= 0 {
+		limit = DefaultTimelineLimit
+	}
+	rows, err := p.db.Query(`
+		SELECT id, org_id, incident_id, kind, COALESCE(actor, ''), COALESCE(body, ''), data, created_at
+		FROM (
+			SELECT * FROM vs_incident_timeline
+			WHERE incident_id = $1
+			ORDER BY created_at DESC
+			LIMIT $2
+		) newest
+		ORDER BY created_at ASC
+	`, incidentID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("storage: list timeline: %w", err)
+	}
+	defer rows.Close()
+	out := make([]*TimelineEntry, 0)
+	for rows.Next() {
+		var (
+			e   TimelineEntry
+			raw []byte
+		)
+		if err := rows.Scan(&e.ID, &e.OrgID, &e.IncidentID, &e.Kind, &e.Actor, &e.Body, &raw, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("storage: scan timeline row: %w", err)
+		}
+		if e.Data, err = unmarshalIncidentContent(raw); err != nil {
+			return nil, fmt.Errorf("storage: unmarshal timeline data: %w", err)
+		}
+		e.CreatedAt = e.CreatedAt.UTC()
+		out = append(out, &e)
+	}
+	return out, rows.Err()
 }
 
 func (p *postgresProvider) Close() error {
@@ -1191,39 +1664,66 @@ func allBlobTables() []string {
 	return tables
 }
 
+// lifecycleWhere is the purge predicate per row domain, over $1 = cutoff.
+// DomainBlobs spans several tables and is handled separately.
+var lifecycleWhere = map[string]struct{ table, where string }{
+	DomainIncidents:         {"vs_incidents", `created_at < $1`},
+	DomainResolvedIncidents: {"vs_incidents", `resolved AND COALESCE(resolved_at, created_at) < $1`},
+	DomainAnalyses:          {"vs_analyses", `requested_at < $1`},
+}
+
 func (p *postgresProvider) PurgeOlderThan(domain string, cutoff time.Time) (int, error) {
 	cut := cutoff.UTC()
-	switch domain {
-	case DomainIncidents:
-		res, err := p.db.Exec(`DELETE FROM vs_incidents WHERE created_at < $1`, cut)
+	if d, ok := lifecycleWhere[domain]; ok {
+		res, err := p.db.Exec(fmt.Sprintf(`DELETE FROM %s WHERE %s`, d.table, d.where), cut)
 		if err != nil {
-			return 0, fmt.Errorf("storage: purge incidents: %w", err)
+			return 0, fmt.Errorf("storage: purge %s: %w", domain, err)
 		}
 		n, _ := res.RowsAffected()
 		return int(n), nil
-	case DomainAnalyses:
-		res, err := p.db.Exec(`DELETE FROM vs_analyses WHERE requested_at < $1`, cut)
+	}
+	if domain != DomainBlobs {
+		return 0, ErrUnknownDomain
+	}
+	total := 0
+	for _, table := range allBlobTables() {
+		res, err := p.db.Exec(
+			fmt.Sprintf(`DELETE FROM %s WHERE updated_at < $1`, table), cut,
+		)
 		if err != nil {
-			return 0, fmt.Errorf("storage: purge analyses: %w", err)
+			return total, fmt.Errorf("storage: purge blobs %s: %w", table, err)
 		}
 		n, _ := res.RowsAffected()
-		return int(n), nil
-	case DomainBlobs:
-		total := 0
-		for _, table := range allBlobTables() {
-			res, err := p.db.Exec(
-				fmt.Sprintf(`DELETE FROM %s WHERE updated_at < $1`, table), cut,
-			)
-			if err != nil {
-				return total, fmt.Errorf("storage: purge blobs %s: %w", table, err)
-			}
-			n, _ := res.RowsAffected()
-			total += int(n)
+		total += int(n)
+	}
+	return total, nil
+}
+
+// CountOlderThan implements the optional storage.LifecycleCounter
+// capability: PurgeOlderThan's predicates as COUNT queries.
+func (p *postgresProvider) CountOlderThan(domain string, cutoff time.Time) (int, error) {
+	cut := cutoff.UTC()
+	if d, ok := lifecycleWhere[domain]; ok {
+		var n int
+		if err := p.db.QueryRow(fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE %s`, d.table, d.where), cut).Scan(&n); err != nil {
+			return 0, fmt.Errorf("storage: count %s: %w", domain, err)
 		}
-		return total, nil
-	default:
+		return n, nil
+	}
+	if domain != DomainBlobs {
 		return 0, ErrUnknownDomain
 	}
+	total := 0
+	for _, table := range allBlobTables() {
+		var n int
+		if err := p.db.QueryRow(
+			fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE updated_at < $1`, table), cut,
+		).Scan(&n); err != nil {
+			return total, fmt.Errorf("storage: count blobs %s: %w", table, err)
+		}
+		total += n
+	}
+	return total, nil
 }
 
 func (p *postgresProvider) DeleteByID(domain, id string) error {
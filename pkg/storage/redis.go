@@ -1,20 +1,979 @@
 package storage
 
-// RedisOptions mirrors the redis sub-block of `storage:`. The redis
-// backend is config-only today — instantiating it returns ErrUnsupported.
-// The struct exists so the config layer can validate its shape.
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisOptions mirrors the redis sub-block of `storage:`. TLS and Cluster
+// come from the top-level `redis:` block, so storage dials the same way as
+// the on-call and cursor client.
 type RedisOptions struct {
 	Host               string
 	Port               int
 	Password           string
 	DB                 int
 	InsecureSkipVerify bool
-	KeyPrefix          string
-	MaxIncidents       int
+	KeyPrefix          string // default DefaultRedisKeyPrefix
+	MaxIncidents       int    // default MaxIncidentsDefault
+
+	TLS     bool // dial over TLS (REDIS_CA_CERT adds a CA to the system pool)
+	Cluster bool // build a cluster client that follows MOVED/ASK redirects
+
+	Keys *Keyring // seals blobs, incident content and analysis output; nil stores them clear
+}
+
+// DefaultRedisKeyPrefix namespaces every key the redis backend writes.
+const DefaultRedisKeyPrefix = "versus:storage"
+
+// redisOpTimeout bounds one backend call; the Provider methods carry no ctx.
+const redisOpTimeout = 10 * time.Second
+
+// redisBatch bounds one HMGET / MGET / delete round trip.
+const redisBatch = 500
+
+// redisProvider stores everything under one key prefix:
+//
+//	{prefix}:blob:<name>              string   blob bytes
+//	{prefix}:blobs                    zset     blob name → updated_at (ms)
+//	{prefix}:incidents                hash     id → IncidentRecord JSON
+//	{prefix}:incidents:created        zset     id → created_at (ms)
+//	{prefix}:incidents:origin:<o>     zset     id → created_at, per origin
+//	{prefix}:analyses                 hash     id → AnalysisRecord JSON
+//	{prefix}:analyses:requested       zset     id → requested_at (ms)
+//	{prefix}:analyses:incident:<id>   zset     id → requested_at, per incident
+//	{prefix}:timeline:<incident id>   zset     TimelineEntry JSON → created_at (µs)
+//
+// The prefix is wrapped in a hash tag, so in cluster mode every key lands in
+// one slot and the MULTI/EXEC and multi-key reads below stay legal. The
+// sorted sets are the newest-first indexes; records are decoded from the
+// hashes. Incident history is capped at maxIncidents, like the file
+// backend, so the whole-set counts stay a bounded scan.
+type redisProvider struct {
+	rdb          redis.UniversalClient
+	prefix       string // "{<KeyPrefix>}"
+	maxIncidents int
+	keys         *Keyring
+	closeOnce    sync.Once
+}
+
+// NewRedis connects to Redis and returns a ready Provider. Callers must call
+// Close when done.
+func NewRedis(opts RedisOptions) (Provider, error) {
+	if opts.Host == "" {
+		return nil, fmt.Errorf("storage: redis host is required (set storage.redis.host)")
+	}
+	port := opts.Port
+	if port == 0 {
+		port = 6379
+	}
+	addr := opts.Host + ":" + strconv.Itoa(port)
+	tlsConfig, err := redisTLSConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	var rdb redis.UniversalClient
+	if opts.Cluster {
+		rdb = redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:     []string{addr},
+			Password:  opts.Password,
+			TLSConfig: tlsConfig,
+		})
+	} else {
+		rdb = redis.NewClient(&redis.Options{
+			Addr:      addr,
+			Password:  opts.Password,
+			DB:        opts.DB,
+			TLSConfig: tlsConfig,
+		})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+	defer cancel()
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		_ = rdb.Close()
+		return nil, fmt.Errorf("storage: cannot reach redis at %s: %w", addr, err)
+	}
+
+	prefix := strings.TrimRight(opts.KeyPrefix, ":")
+	if prefix == "" {
+		prefix = DefaultRedisKeyPrefix
+	}
+	max := opts.MaxIncidents
+	if max <= 0 {
+		max = MaxIncidentsDefault
+	}
+	return &redisProvider{rdb: rdb, prefix: "{" + prefix + "}", maxIncidents: max, keys: opts.Keys}, nil
+}
+
+// redisTLSConfig builds the dial TLS config, or nil for plaintext.
+func redisTLSConfig(opts RedisOptions) (*tls.Config, error) {
+	if !opts.TLS {
+		return nil, nil
+	}
+	if opts.InsecureSkipVerify {
+		return &tls.Config{InsecureSkipVerify: true}, nil
+	}
+	rootCAs, _ := x509.SystemCertPool()
+	if rootCAs == nil {
+		rootCAs = x509.NewCertPool()
+	}
+	if path := os.Getenv("REDIS_CA_CERT"); path != "" {
+		pem, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("storage: read REDIS_CA_CERT: %w", err)
+		}
+		if !rootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("storage: REDIS_CA_CERT holds no PEM certificate")
+		}
+	}
+	return &tls.Config{RootCAs: rootCAs, MinVersion: tls.VersionTLS12}, nil
+}
+
+func (p *redisProvider) key(parts ...string) string {
+	return p.prefix + ":" + strings.Join(parts, ":")
+}
+
+func (p *redisProvider) ctx() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), redisOpTimeout)
+}
+
+// redisScore is the sorted-set score for t: Unix milliseconds, exact in a
+// float64.
+func redisScore(t time.Time) float64 {
+	return float64(t.UnixMilli())
+}
+
+func (p *redisProvider) Close() error {
+	var err error
+	p.closeOnce.Do(func() { err = p.rdb.Close() })
+	return err
+}
+
+// ---------------------------------------------------------------------------
+// Blobs
+// ---------------------------------------------------------------------------
+
+func (p *redisProvider) ReadBlob(name string) ([]byte, error) {
+	ctx, cancel := p.ctx()
+	defer cancel()
+	data, err := p.rdb.Get(ctx, p.key("blob", name)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("storage: read blob %q: %w", name, err)
+	}
+	return p.keys.openBlob(name, data)
+}
+
+func (p *redisProvider) WriteBlob(name string, data []byte) error {
+	data, err := p.keys.sealBlob(name, data)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := p.ctx()
+	defer cancel()
+	_, err = p.rdb.TxPipelined(ctx, func(tx redis.Pipeliner) error {
+		tx.Set(ctx, p.key("blob", name), data, 0)
+		tx.ZAdd(ctx, p.key("blobs"), redis.Z{Score: redisScore(time.Now()), Member: name})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("storage: write blob %q: %w", name, err)
+	}
+	return nil
+}
+
+// CreateBlobIfAbsent implements the optional storage.BlobCreator capability
+// with SETNX, so exactly one of any number of concurrent creators — across
+// replicas sharing the Redis — observes written==true. Only the winner
+// indexes the blob's age.
+func (p *redisProvider) CreateBlobIfAbsent(name string, data []byte) (bool, error) {
+	data, err := p.keys.sealBlob(name, data)
+	if err != nil {
+		return false, err
+	}
+	ctx, cancel := p.ctx()
+	defer cancel()
+	ok, err := p.rdb.SetNX(ctx, p.key("blob", name), data, 0).Result()
+	if err != nil {
+		return false, fmt.Errorf("storage: create blob %q: %w", name, err)
+	}
+	if !ok {
+		return false, nil
+	}
+	if err := p.rdb.ZAdd(ctx, p.key("blobs"), redis.Z{Score: redisScore(time.Now()), Member: name}).Err(); err != nil {
+		return true, fmt.Errorf("storage: index blob %q: %w", name, err)
+	}
+	return true, nil
+}
+
+// ListBlobs walks the blob index rather than SCANning the keyspace, which
+// in cluster mode would have to visit every node.
+func (p *redisProvider) ListBlobs(prefix string) ([]Blob, error) {
+	ctx, cancel := p.ctx()
+	defer cancel()
+	names, err := p.rdb.ZRange(ctx, p.key("blobs"), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("storage: list blobs: %w", err)
+	}
+	matched := make([]string, 0)
+	for _, name := range names {
+		if strings.HasPrefix(name, prefix) {
+			matched = append(matched, name)
+		}
+	}
+	out := make([]Blob, 0, len(matched))
+	for _, chunk := range redisChunks(matched) {
+		keys := make([]string, len(chunk))
+		for i, name := range chunk {
+			keys[i] = p.key("blob", name)
+		}
+		vals, err := p.rdb.MGet(ctx, keys...).Result()
+		if err != nil {
+			return nil, fmt.Errorf("storage: list blobs: %w", err)
+		}
+		for i, v := range vals {
+			if s, ok := v.(string); ok {
+				out = append(out, Blob{Name: chunk[i], Data: []byte(s)})
+			}
+		}
+	}
+	return out, p.keys.openBlobs(out)
+}
+
+// ---------------------------------------------------------------------------
+// Incidents
+// ---------------------------------------------------------------------------
+
+// originKey is the per-origin index rec belongs in.
+func (p *redisProvider) originKey(origin string) string {
+	return p.key("incidents", "origin", origin)
+}
+
+// indexIncident queues the writes that store rec and index it.
+func (p *redisProvider) indexIncident(ctx context.Context, tx redis.Pipeliner, rec *IncidentRecord, data []byte) {
+	z := redis.Z{Score: redisScore(rec.CreatedAt), Member: rec.ID}
+	tx.HSet(ctx, p.key("incidents"), rec.ID, data)
+	tx.ZAdd(ctx, p.key("incidents", "created"), z)
+	tx.ZRem(ctx, p.originKey(OriginAIDetect), rec.ID)
+	tx.ZRem(ctx, p.originKey(OriginWebhook), rec.ID)
+	tx.ZAdd(ctx, p.originKey(rec.EffectiveOrigin()), z)
+}
+
+// unindexIncidents queues the writes that remove ids and their indexes. The
+// returned command counts the records removed once the pipeline runs.
+func (p *redisProvider) unindexIncidents(ctx context.Context, tx redis.Pipeliner, ids []string) *redis.IntCmd {
+	members := make([]interface{}, len(ids))
+	for i, id := range ids {
+		members[i] = id
+	}
+	removed := tx.HDel(ctx, p.key("incidents"), ids...)
+	tx.ZRem(ctx, p.key("incidents", "created"), members...)
+	tx.ZRem(ctx, p.originKey(OriginAIDetect), members...)
+	tx.ZRem(ctx, p.originKey(OriginWebhook), members...)
+	// Timelines go with their incident, like the Postgres ON DELETE CASCADE.
+	timelines := make([]string, len(ids))
+	for i, id := range ids {
+		timelines[i] = p.key("timeline", id)
+	}
+	tx.Del(ctx, timelines...)
+	return removed
+}
+
+func (p *redisProvider) SaveIncident(rec *IncidentRecord) error {
+	if rec.ID == "" {
+		return fmt.Errorf("storage: SaveIncident: missing id")
+	}
+	rec.OrgID = NormalizeOrgID(rec.OrgID)
+	sealed, err := p.keys.sealIncident(rec)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(sealed)
+	if err != nil {
+		return fmt.Errorf("storage: marshal incident: %w", err)
+	}
+	ctx, cancel := p.ctx()
+	defer cancel()
+	var card *redis.IntCmd
+	_, err = p.rdb.TxPipelined(ctx, func(tx redis.Pipeliner) error {
+		p.indexIncident(ctx, tx, rec, data)
+		card = tx.ZCard(ctx, p.key("incidents", "created"))
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("storage: save incident %q: %w", rec.ID, err)
+	}
+	if over := card.Val() - int64(p.maxIncidents); over > 0 {
+		return p.trimIncidents(ctx, over)
+	}
+	return nil
+}
+
+// trimIncidents drops the n oldest incidents to hold the rolling cap.
+func (p *redisProvider) trimIncidents(ctx context.Context, n int64) error {
+	ids, err := p.rdb.ZRange(ctx, p.key("incidents", "created"), 0, n-1).Result()
+	if err != nil || len(ids) == 0 {
+		return err
+	}
+	_, err = p.rdb.TxPipelined(ctx, func(tx redis.Pipeliner) error {
+		p.unindexIncidents(ctx, tx, ids)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("storage: trim incidents: %w", err)
+	}
+	return nil
+}
+
+func (p *redisProvider) UpdateIncidentAck(id string, ackedAt time.Time) error {
+	ctx, cancel := p.ctx()
+	defer cancel()
+	key := p.key("incidents")
+	// WATCH the hash so a concurrent writer makes this read-modify-write
+	// retry instead of being overwritten.
+	for attempt := 0; attempt < 5; attempt++ {
+		err := p.rdb.Watch(ctx, func(tx *redis.Tx) error {
+			raw, err := tx.HGet(ctx, key, id).Bytes()
+			if errors.Is(err, redis.Nil) {
+				return ErrNotFound
+			}
+			if err != nil {
+				return err
+			}
+			var rec IncidentRecord
+			if err := json.Unmarshal(raw, &rec); err != nil {
+				return err
+			}
+			t := ackedAt.UTC()
+			// Best-effort, as in the other backends: only an unengaged
+			// incident becomes acknowledged.
+			_, _ = rec.Transition(StatusAcknowledged, t, nil)
+			rec.AckedAt = &t
+			data, err := json.Marshal(&rec)
+			if err != nil {
+				return err
+			}
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.HSet(ctx, key, id, data)
+				return nil
+			})
+			return err
+		}, key)
+		if errors.Is(err, redis.TxFailedErr) {
+			continue
+		}
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return fmt.Errorf("storage: ack incident %q: %w", id, err)
+		}
+		return err
+	}
+	return fmt.Errorf("storage: ack incident %q: too much contention", id)
+}
+
+func (p *redisProvider) GetIncident(id string) (*IncidentRecord, error) {
+	ctx, cancel := p.ctx()
+	defer cancel()
+	raw, err := p.rdb.HGet(ctx, p.key("incidents"), id).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("storage: get incident %q: %w", id, err)
+	}
+	var rec IncidentRecord
+	if err := json.Unmarshal(raw, &rec); err != nil {
+		return nil, fmt.Errorf("storage: decode incident %q: %w", id, err)
+	}
+	if err := p.keys.openIncident(&rec); err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+func (p *redisProvider) ListIncidents(limit int) ([]*IncidentRecord, error) {
+	stop := int64(-1)
+	if limit > 0 {
+		stop = int64(limit) - 1
+	}
+	return p.incidentsPage(p.key("incidents", "created"), 0, stop)
+}
+
+// incidentsPage reads ranks [start, stop] of a newest-first index.
+func (p *redisProvider) incidentsPage(index string, start, stop int64) ([]*IncidentRecord, error) {
+	ctx, cancel := p.ctx()
+	defer cancel()
+	ids, err := p.rdb.ZRevRange(ctx, index, start, stop).Result()
+	if err != nil {
+		return nil, fmt.Errorf("storage: list incidents: %w", err)
+	}
+	recs, err := p.incidentsByID(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	return recs, p.keys.openIncidents(recs)
+}
+
+// incidentsByID decodes ids in order, skipping any removed since the index
+// was read. Content stays sealed: the counting and retention paths never
+// read it, and the listing paths open it themselves.
+func (p *redisProvider) incidentsByID(ctx context.Context, ids []string) ([]*IncidentRecord, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	out := make([]*IncidentRecord, 0, len(ids))
+	for _, chunk := range redisChunks(ids) {
+		vals, err := p.rdb.HMGet(ctx, p.key("incidents"), chunk...).Result()
+		if err != nil {
+			return nil, fmt.Errorf("storage: load incidents: %w", err)
+		}
+		for i, v := range vals {
+			s, ok := v.(string)
+			if !ok {
+				continue
+			}
+			var rec IncidentRecord
+			if err := json.Unmarshal([]byte(s), &rec); err != nil {
+				return nil, fmt.Errorf("storage: decode incident %q: %w", chunk[i], err)
+			}
+			out = append(out, &rec)
+		}
+	}
+	return out, nil
+}
+
+// allIncidents decodes the whole (capped) history for counting; content
+// stays sealed.
+func (p *redisProvider) allIncidents() ([]*IncidentRecord, error) {
+	ctx, cancel := p.ctx()
+	defer cancel()
+	vals, err := p.rdb.HVals(ctx, p.key("incidents")).Result()
+	if err != nil {
+		return nil, fmt.Errorf("storage: load incidents: %w", err)
+	}
+	out := make([]*IncidentRecord, 0, len(vals))
+	for _, s := range vals {
+		var rec IncidentRecord
+		if err := json.Unmarshal([]byte(s), &rec); err != nil {
+			return nil, fmt.Errorf("storage: decode incident: %w", err)
+		}
+		out = append(out, &rec)
+	}
+	return out, nil
+}
+
+// CountIncidents implements the optional storage.IncidentPager capability.
+// The history is capped, so one pass over it is cheap. Counts reflect open
+// work: resolved incidents are skipped, matching the other backends.
+func (p *redisProvider) CountIncidents() (IncidentCounts, error) {
+	recs, err := p.allIncidents()
+	if err != nil {
+		return IncidentCounts{}, err
+	}
+	var c IncidentCounts
+	for _, rec := range recs {
+		if rec.Resolved {
+			continue
+		}
+		if rec.EffectiveOrigin() == OriginAIDetect {
+			c.AIDetect++
+		} else {
+			c.Webhook++
+		}
+	}
+	c.Total = c.AIDetect + c.Webhook
+	return c, nil
+}
+
+// CountIncidentsByStatus implements the optional storage.IncidentPager
+// capability with the shared StatusCountsOf tally over the capped history.
+func (p *redisProvider) CountIncidentsByStatus() (IncidentStatusCounts, error) {
+	recs, err := p.allIncidents()
+	if err != nil {
+		return IncidentStatusCounts{}, err
+	}
+	return StatusCountsOf(recs), nil
+}
+
+// ListIncidentsPage implements the optional storage.IncidentPager
+// capability: a rank range over the created_at index, or over the origin's
+// own index when filtered, so a page never loads more than limit records.
+func (p *redisProvider) ListIncidentsPage(origin string, offset, limit int) ([]*IncidentRecord, error) {
+	if limit <= 0 {
+		limit = DefaultIncidentPageSize
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	index := p.key("incidents", "created")
+	if origin == OriginAIDetect || origin == OriginWebhook {
+		index = p.originKey(origin)
+	}
+	out, err := p.incidentsPage(index, int64(offset), int64(offset+limit-1))
+	if out == nil && err == nil {
+		out = []*IncidentRecord{}
+	}
+	return out, err
+}
+
+// ListIncidentsInRange implements storage.RangeLister: a score range over
+// the created_at index, then an exact bound on the decoded records (scores
+// are millisecond-grained). A zero end is an open upper bound.
+func (p *redisProvider) ListIncidentsInRange(start, end time.Time, limit int) ([]*IncidentRecord, error) {
+	ctx, cancel := p.ctx()
+	defer cancel()
+	max := "+inf"
+	if !end.IsZero() {
+		max = strconv.FormatInt(end.UnixMilli(), 10)
+	}
+	ids, err := p.rdb.ZRevRangeByScore(ctx, p.key("incidents", "created"), &redis.ZRangeBy{
+		Min: strconv.FormatInt(start.UnixMilli(), 10),
+		Max: max,
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("storage: list incidents in range: %w", err)
+	}
+	recs, err := p.incidentsByID(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*IncidentRecord, 0, len(recs))
+	for _, rec := range recs {
+		if rec.CreatedAt.Before(start) || (!end.IsZero() && !rec.CreatedAt.Before(end)) {
+			continue
+		}
+		out = append(out, rec)
+		if limit > 0 && len(out) >= limit {
+			break
+		}
+	}
+	return out, p.keys.openIncidents(out)
+}
+
+// ---------------------------------------------------------------------------
+// Analyses
+// ---------------------------------------------------------------------------
+
+func (p *redisProvider) analysisIncidentKey(incidentID string) string {
+	return p.key("analyses", "incident", incidentID)
+}
+
+func (p *redisProvider) SaveAnalysis(rec *AnalysisRecord) error {
+	if rec == nil || rec.ID == "" {
+		return ErrNotFound
+	}
+	rec.OrgID = NormalizeOrgID(rec.OrgID)
+	sealed, err := p.keys.sealAnalysis(rec)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(sealed)
+	if err != nil {
+		return fmt.Errorf("storage: marshal analysis: %w", err)
+	}
+	ctx, cancel := p.ctx()
+	defer cancel()
+	// A replaced analysis may have moved to another incident; drop it from
+	// the old incident's index.
+	prev, err := p.analysisByID(ctx, rec.ID)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	z := redis.Z{Score: redisScore(rec.RequestedAt), Member: rec.ID}
+	_, err = p.rdb.TxPipelined(ctx, func(tx redis.Pipeliner) error {
+		if prev != nil && prev.IncidentID != rec.IncidentID {
+			tx.ZRem(ctx, p.analysisIncidentKey(prev.IncidentID), rec.ID)
+		}
+		tx.HSet(ctx, p.key("analyses"), rec.ID, data)
+		tx.ZAdd(ctx, p.key("analyses", "requested"), z)
+		tx.ZAdd(ctx, p.analysisIncidentKey(rec.IncidentID), z)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("storage: save analysis %q: %w", rec.ID, err)
+	}
+	return nil
+}
+
+func (p *redisProvider) analysisByID(ctx context.Context, id string) (*AnalysisRecord, error) {
+	raw, err := p.rdb.HGet(ctx, p.key("analyses"), id).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("storage: get analysis %q: %w", id, err)
+	}
+	var rec AnalysisRecord
+	if err := json.Unmarshal(raw, &rec); err != nil {
+		return nil, fmt.Errorf("storage: decode analysis %q: %w", id, err)
+	}
+	return &rec, nil
+}
+
+func (p *redisProvider) GetAnalysis(id string) (*AnalysisRecord, error) {
+	ctx, cancel := p.ctx()
+	defer cancel()
+	rec, err := p.analysisByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return rec, p.keys.openAnalysis(rec)
+}
+
+func (p *redisProvider) ListAnalysesByIncident(incidentID string, limit int) ([]*AnalysisRecord, error) {
+	stop := int64(-1)
+	if limit > 0 {
+		stop = int64(limit) - 1
+	}
+	return p.analysesPage(p.analysisIncidentKey(incidentID), 0, stop)
+}
+
+func (p *redisProvider) ListAnalyses(limit int) ([]*AnalysisRecord, error) {
+	stop := int64(-1)
+	if limit > 0 {
+		stop = int64(limit) - 1
+	}
+	return p.analysesPage(p.key("analyses", "requested"), 0, stop)
+}
+
+// CountAnalyses implements the optional storage.AnalysisPager capability.
+func (p *redisProvider) CountAnalyses() (int, error) {
+	ctx, cancel := p.ctx()
+	defer cancel()
+	n, err := p.rdb.ZCard(ctx, p.key("analyses", "requested")).Result()
+	if err != nil {
+		return 0, fmt.Errorf("storage: count analyses: %w", err)
+	}
+	return int(n), nil
+}
+
+// ListAnalysesPage implements the optional storage.AnalysisPager capability:
+// a rank range over the requested_at index.
+func (p *redisProvider) ListAnalysesPage(offset, limit int) ([]*AnalysisRecord, error) {
+	if limit <= 0 {
+		limit = DefaultAnalysisPageSize
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	out, err := p.analysesPage(p.key("analyses", "requested"), int64(offset), int64(offset+limit-1))
+	if out == nil && err == nil {
+		out = []*AnalysisRecord{}
+	}
+	return out, err
+}
+
+// analysesPage reads ranks [start, stop] of a newest-first index.
+func (p *redisProvider) analysesPage(index string, start, stop int64) ([]*AnalysisRecord, error) {
+	ctx, cancel := p.ctx()
+	defer cancel()
+	ids, err := p.rdb.ZRevRange(ctx, index, start, stop).Result()
+	if err != nil {
+		return nil, fmt.Errorf("storage: list analyses: %w", err)
+	}
+	recs, err := p.analysesByID(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	return recs, p.keys.openAnalyses(recs)
+}
+
+func (p *redisProvider) analysesByID(ctx context.Context, ids []string) ([]*AnalysisRecord, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	out := make([]*AnalysisRecord, 0, len(ids))
+	for _, chunk := range redisChunks(ids) {
+		vals, err := p.rdb.HMGet(ctx, p.key("analyses"), chunk...).Result()
+		if err != nil {
+			return nil, fmt.Errorf("storage: load analyses: %w", err)
+		}
+		for i, v := range vals {
+			s, ok := v.(string)
+			if !ok {
+				continue
+			}
+			var rec AnalysisRecord
+			if err := json.Unmarshal([]byte(s), &rec); err != nil {
+				return nil, fmt.Errorf("storage: decode analysis %q: %w", chunk[i], err)
+			}
+			out = append(out, &rec)
+		}
+	}
+	return out, nil
+}
+
+func (p *redisProvider) DeleteAnalysis(id string) error {
+	ctx, cancel := p.ctx()
+	defer cancel()
+	rec, err := p.analysisByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return p.deleteAnalyses(ctx, []*AnalysisRecord{rec})
+}
+
+// deleteAnalyses removes recs and their index entries.
+func (p *redisProvider) deleteAnalyses(ctx context.Context, recs []*AnalysisRecord) error {
+	if len(recs) == 0 {
+		return nil
+	}
+	_, err := p.rdb.TxPipelined(ctx, func(tx redis.Pipeliner) error {
+		for _, rec := range recs {
+			tx.HDel(ctx, p.key("analyses"), rec.ID)
+			tx.ZRem(ctx, p.key("analyses", "requested"), rec.ID)
+			tx.ZRem(ctx, p.analysisIncidentKey(rec.IncidentID), rec.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("storage: delete analyses: %w", err)
+	}
+	return nil
+}
+
+// ---------------------------------------------------------------------------
+// Timeline (implements the optional storage.Timeline capability)
+// ---------------------------------------------------------------------------
+
+// maxRedisTimelineEntries caps one incident's timeline; the oldest entries
+// are dropped past it.
+const maxRedisTimelineEntries = 5000
+
+func (p *redisProvider) AppendTimelineEntry(e *TimelineEntry) error {
+	if err := e.validate(); err != nil {
+		return err
+	}
+	ctx, cancel := p.ctx()
+	defer cancel()
+	exists, err := p.rdb.HExists(ctx, p.key("incidents"), e.IncidentID).Result()
+	if err != nil {
+		return fmt.Errorf("storage: append timeline: %w", err)
+	}
+	if !exists {
+		return ErrNotFound
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("storage: marshal timeline entry: %w", err)
+	}
+	// Scored in microseconds so entries in the same millisecond keep their
+	// order; still exact in a float64.
+	key := p.key("timeline", e.IncidentID)
+	_, err = p.rdb.TxPipelined(ctx, func(tx redis.Pipeliner) error {
+		tx.ZAdd(ctx, key, redis.Z{Score: float64(e.CreatedAt.UnixMicro()), Member: data})
+		tx.ZRemRangeByRank(ctx, key, 0, -maxRedisTimelineEntries-1)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("storage: append timeline: %w", err)
+	}
+	return nil
+}
+
+func (p *redisProvider) ListTimeline(incidentID string, limit int) ([]*TimelineEntry, error) {
+	if limit <= 0 {
+		limit = DefaultTimelineLimit
+	}
+	ctx, cancel := p.ctx()
+	defer cancel()
+	vals, err := p.rdb.ZRange(ctx, p.key("timeline", incidentID), int64(-limit), -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("storage: list timeline: %w", err)
+	}
+	out := make([]*TimelineEntry, 0, len(vals))
+	for _, s := range vals {
+		var e TimelineEntry
+		if err := json.Unmarshal([]byte(s), &e); err != nil {
+			return nil, fmt.Errorf("storage: decode timeline entry: %w", err)
+		}
+		out = append(out, &e)
+	}
+	return out, nil
+}
+
+// ---------------------------------------------------------------------------
+// Lifecycle (implements the optional storage.Lifecycle capability)
+// ---------------------------------------------------------------------------
+
+// PurgeOlderThan reads the candidates off the domain's age index and
+// deletes them in batches. Resolved incidents are aged by resolution, which
+// is never before creation, so the created_at index still bounds them.
+func (p *redisProvider) PurgeOlderThan(domain string, cutoff time.Time) (int, error) {
+	return p.lifecycle(domain, cutoff, true)
+}
+
+// CountOlderThan implements the optional storage.LifecycleCounter
+// capability with the same predicates as PurgeOlderThan.
+func (p *redisProvider) CountOlderThan(domain string, cutoff time.Time) (int, error) {
+	return p.lifecycle(domain, cutoff, false)
+}
+
+func (p *redisProvider) lifecycle(domain string, cutoff time.Time, purge bool) (int, error) {
+	var index string
+	max := strconv.FormatInt(cutoff.UnixMilli(), 10)
+	switch domain {
+	case DomainIncidents, DomainResolvedIncidents:
+		index = p.key("incidents", "created")
+	case DomainAnalyses:
+		index = p.key("analyses", "requested")
+	case DomainBlobs:
+		// Blobs are aged by their index score alone, so the bound is exact
+		// and exclusive.
+		index, max = p.key("blobs"), "("+max
+	default:
+		return 0, ErrUnknownDomain
+	}
+	ctx, cancel := p.ctx()
+	defer cancel()
+	ids, err := p.rdb.ZRangeByScore(ctx, index, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: max,
+	}).Result()
+	if err != nil {
+		return 0, fmt.Errorf("storage: purge %s: %w", domain, err)
+	}
+
+	n := 0
+	for _, chunk := range redisChunks(ids) {
+		var k int
+		switch domain {
+		case DomainIncidents, DomainResolvedIncidents:
+			k, err = p.expireIncidents(ctx, domain, cutoff, chunk, purge)
+		case DomainAnalyses:
+			k, err = p.expireAnalyses(ctx, cutoff, chunk, purge)
+		case DomainBlobs:
+			k, err = p.expireBlobs(ctx, chunk, purge)
+		}
+		n += k
+		if err != nil {
+			return n, fmt.Errorf("storage: purge %s: %w", domain, err)
+		}
+	}
+	return n, nil
+}
+
+func (p *redisProvider) expireIncidents(ctx context.Context, domain string, cutoff time.Time, ids []string, purge bool) (int, error) {
+	recs, err := p.incidentsByID(ctx, ids)
+	if err != nil {
+		return 0, err
+	}
+	expired := make([]string, 0, len(recs))
+	for _, rec := range recs {
+		if IncidentExpired(rec, domain, cutoff) {
+			expired = append(expired, rec.ID)
+		}
+	}
+	if !purge || len(expired) == 0 {
+		return len(expired), nil
+	}
+	_, err = p.rdb.TxPipelined(ctx, func(tx redis.Pipeliner) error {
+		p.unindexIncidents(ctx, tx, expired)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(expired), nil
+}
+
+func (p *redisProvider) expireAnalyses(ctx context.Context, cutoff time.Time, ids []string, purge bool) (int, error) {
+	recs, err := p.analysesByID(ctx, ids)
+	if err != nil {
+		return 0, err
+	}
+	expired := make([]*AnalysisRecord, 0, len(recs))
+	for _, rec := range recs {
+		if rec.RequestedAt.Before(cutoff) {
+			expired = append(expired, rec)
+		}
+	}
+	if !purge {
+		return len(expired), nil
+	}
+	if err := p.deleteAnalyses(ctx, expired); err != nil {
+		return 0, err
+	}
+	return len(expired), nil
+}
+
+func (p *redisProvider) expireBlobs(ctx context.Context, names []string, purge bool) (int, error) {
+	if !purge {
+		return len(names), nil
+	}
+	_, err := p.rdb.TxPipelined(ctx, func(tx redis.Pipeliner) error {
+		p.deleteBlobs(ctx, tx, names)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(names), nil
+}
+
+func (p *redisProvider) deleteBlobs(ctx context.Context, tx redis.Pipeliner, names []string) {
+	keys := make([]string, len(names))
+	members := make([]interface{}, len(names))
+	for i, name := range names {
+		keys[i] = p.key("blob", name)
+		members[i] = name
+	}
+	tx.Del(ctx, keys...)
+	tx.ZRem(ctx, p.key("blobs"), members...)
+}
+
+func (p *redisProvider) DeleteByID(domain, id string) error {
+	ctx, cancel := p.ctx()
+	defer cancel()
+	var removed *redis.IntCmd
+	var err error
+	switch domain {
+	case DomainIncidents:
+		_, err = p.rdb.TxPipelined(ctx, func(tx redis.Pipeliner) error {
+			removed = p.unindexIncidents(ctx, tx, []string{id})
+			return nil
+		})
+	case DomainAnalyses:
+		return p.DeleteAnalysis(id)
+	case DomainBlobs:
+		_, err = p.rdb.TxPipelined(ctx, func(tx redis.Pipeliner) error {
+			removed = tx.Exists(ctx, p.key("blob", id))
+			p.deleteBlobs(ctx, tx, []string{id})
+			return nil
+		})
+	default:
+		return ErrUnknownDomain
+	}
+	if err != nil {
+		return fmt.Errorf("storage: delete %s %q: %w", domain, id, err)
+	}
+	if removed.Val() == 0 {
+		return ErrNotFound
+	}
+	return nil
 }
 
-// NewRedis is a placeholder that returns ErrUnsupported. The redis
-// backend will be implemented alongside its first dependency.
-func NewRedis(_ RedisOptions) (Provider, error) {
-	return nil, ErrUnsupported
+// redisChunks splits ids into batches of at most redisBatch.
+func redisChunks(ids []string) [][]string {
+	var out [][]string
+	for len(ids) > redisBatch {
+		out = append(out, ids[:redisBatch])
+		ids = ids[redisBatch:]
+	}
+	if len(ids) > 0 {
+		out = append(out, ids)
+	}
+	return out
 }
@@ -0,0 +1,201 @@
+package storage_test
+
+// redis_test.go — the redis backend. It runs the shared CRUD, blob, create-
+// if-absent, timeline and bulk suites plus the redis-specific cap, range and lifecycle
+// checks. Gated on TEST_REDIS_ADDR like the Postgres suite on
+// TEST_POSTGRES_DSN; each test writes under its own key prefix and removes
+// it afterwards, so a shared Redis is safe to point at.
+//
+//   TEST_REDIS_ADDR=localhost:6379 go test ./pkg/storage/...
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/storage"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestRedis(t *testing.T) storage.Provider {
+	t.Helper()
+	return newTestRedisCapped(t, 0)
+}
+
+func newTestRedisCapped(t *testing.T, maxIncidents int) storage.Provider {
+	t.Helper()
+	opts := testRedisOptions(t)
+	opts.MaxIncidents = maxIncidents
+	p, err := storage.NewRedis(opts)
+	if err != nil {
+		t.Fatalf("NewRedis: %v", err)
+	}
+	t.Cleanup(func() { _ = p.Close() })
+	return p
+}
+
+// testRedisOptions points at TEST_REDIS_ADDR under a fresh key prefix and
+// removes every key under it when the test ends.
+func testRedisOptions(t *testing.T) storage.RedisOptions {
+	t.Helper()
+	addr := os.Getenv("TEST_REDIS_ADDR")
+	if addr == "" {
+		t.Skip("TEST_REDIS_ADDR not set; skipping redis tests")
+	}
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		t.Fatalf("TEST_REDIS_ADDR: %v", err)
+	}
+	port, _ := strconv.Atoi(portStr)
+	prefix := fmt.Sprintf("versus-test:%d", time.Now().UnixNano())
+	t.Cleanup(func() {
+		rdb := redis.NewClient(&redis.Options{Addr: addr})
+		defer rdb.Close()
+		ctx := context.Background()
+		iter := rdb.Scan(ctx, 0, "{"+prefix+"}:*", 0).Iterator()
+		for iter.Next(ctx) {
+			rdb.Del(ctx, iter.Val())
+		}
+	})
+	return storage.RedisOptions{Host: host, Port: port, KeyPrefix: prefix}
+}
+
+func TestRedisRequiresHost(t *testing.T) {
+	if _, err := storage.NewRedis(storage.RedisOptions{}); err == nil {
+		t.Fatal("NewRedis with no host succeeded")
+	}
+}
+
+func TestRedisIncidentCRUD(t *testing.T)   { runIncidentCRUD(t, newTestRedis(t)) }
+func TestRedisAnalysisCRUD(t *testing.T)   { runAnalysisCRUD(t, newTestRedis(t)) }
+func TestRedisBlobCRUD(t *testing.T)       { runBlobCRUD(t, newTestRedis(t)) }
+func TestRedisBlobListing(t *testing.T)    { runBlobListing(t, newTestRedis(t)) }
+func TestRedisCreateIfAbsent(t *testing.T) { runCreateIfAbsent(t, newTestRedis(t)) }
+func TestRedisCreateRace(t *testing.T)     { runCreateRace(t, newTestRedis(t)) }
+func TestRedisBulkIncidents(t *testing.T)  { testBulkIncidents(t, newTestRedis(t)) }
+func TestRedisTimeline(t *testing.T)       { runTimeline(t, newTestRedis(t)) }
+
+// TestRedisTimeline_DeletedWithIncident checks a purged incident takes its
+// timeline with it, as the Postgres ON DELETE CASCADE does.
+func TestRedisTimeline_DeletedWithIncident(t *testing.T) {
+	p := newTestRedis(t)
+	old := time.Now().UTC().Add(-48 * time.Hour)
+	_ = p.SaveIncident(&storage.IncidentRecord{ID: "inc-1", CreatedAt: old})
+	tl := p.(storage.Timeline)
+	if err := tl.AppendTimelineEntry(&storage.TimelineEntry{ID: "a", IncidentID: "inc-1", Kind: storage.TimelineNote}); err != nil {
+		t.Fatalf("AppendTimelineEntry: %v", err)
+	}
+	if n, err := p.(storage.Lifecycle).PurgeOlderThan(storage.DomainIncidents, time.Now().Add(-time.Hour)); err != nil || n != 1 {
+		t.Fatalf("PurgeOlderThan = %d, %v", n, err)
+	}
+	_ = p.SaveIncident(&storage.IncidentRecord{ID: "inc-1", CreatedAt: time.Now().UTC()})
+	if got, _ := tl.ListTimeline("inc-1", 0); len(got) != 0 {
+		t.Fatalf("timeline outlived its incident: %+v", got)
+	}
+}
+
+// TestRedisIncidentCapAndRange checks the rolling cap drops the oldest by
+// created_at, and the range read is exact at both bounds.
+func TestRedisIncidentCapAndRange(t *testing.T) {
+	p := newTestRedisCapped(t, 3)
+	base := time.Now().UTC().Truncate(time.Second).Add(-time.Hour)
+	// Saved out of created_at order: the cap and the indexes go by
+	// created_at, not arrival.
+	for _, i := range []int{4, 0, 2, 1, 3} {
+		rec := &storage.IncidentRecord{ID: string(rune('a' + i)), CreatedAt: base.Add(time.Duration(i) * time.Minute)}
+		if err := p.SaveIncident(rec); err != nil {
+			t.Fatalf("SaveIncident %d: %v", i, err)
+		}
+	}
+	list, err := p.ListIncidents(0)
+	if err != nil || len(list) != 3 || list[0].ID != "e" || list[2].ID != "c" {
+		t.Fatalf("ListIncidents = %v, %v; want e d c", incidentIDs(list), err)
+	}
+
+	rl, ok := p.(storage.RangeLister)
+	if !ok {
+		t.Fatal("redis backend must implement storage.RangeLister")
+	}
+	got, err := rl.ListIncidentsInRange(base.Add(2*time.Minute), base.Add(4*time.Minute), 0)
+	if err != nil || len(got) != 2 || got[0].ID != "d" || got[1].ID != "c" {
+		t.Fatalf("range = %v, %v; want d c (end exclusive)", incidentIDs(got), err)
+	}
+	if got, _ := rl.ListIncidentsInRange(base.Add(3*time.Minute), time.Time{}, 1); len(got) != 1 || got[0].ID != "e" {
+		t.Fatalf("open range limit 1 = %v, want e", incidentIDs(got))
+	}
+}
+
+// TestRedisLifecycle purges each domain and checks the count preview and
+// the indexes agree with what is deleted.
+func TestRedisLifecycle(t *testing.T) {
+	p := newTestRedis(t)
+	lc := p.(storage.Lifecycle)
+	lcc := p.(storage.LifecycleCounter)
+	now := time.Now().UTC()
+	resolvedAt := now.Add(-48 * time.Hour)
+	for _, rec := range []*storage.IncidentRecord{
+		{ID: "open-old", CreatedAt: now.Add(-72 * time.Hour), Origin: storage.OriginAIDetect},
+		{ID: "resolved-old", CreatedAt: now.Add(-72 * time.Hour), Resolved: true, ResolvedAt: &resolvedAt},
+		{ID: "fresh", CreatedAt: now},
+	} {
+		if err := p.SaveIncident(rec); err != nil {
+			t.Fatalf("SaveIncident: %v", err)
+		}
+	}
+	if err := p.SaveAnalysis(&storage.AnalysisRecord{ID: "a-old", IncidentID: "fresh", Status: "ok", RequestedAt: now.Add(-72 * time.Hour)}); err != nil {
+		t.Fatalf("SaveAnalysis: %v", err)
+	}
+	if err := p.WriteBlob("k1", []byte("v1")); err != nil {
+		t.Fatalf("WriteBlob: %v", err)
+	}
+	cutoff := now.Add(-24 * time.Hour)
+
+	if n, err := lcc.CountOlderThan(storage.DomainResolvedIncidents, cutoff); err != nil || n != 1 {
+		t.Fatalf("CountOlderThan(resolved) = %d, %v; want 1", n, err)
+	}
+	if n, err := lc.PurgeOlderThan(storage.DomainResolvedIncidents, cutoff); err != nil || n != 1 {
+		t.Fatalf("PurgeOlderThan(resolved) = %d, %v; want 1", n, err)
+	}
+	if n, err := lc.PurgeOlderThan(storage.DomainIncidents, cutoff); err != nil || n != 1 {
+		t.Fatalf("PurgeOlderThan(incidents) = %d, %v; want open-old", n, err)
+	}
+	page, _ := p.(storage.IncidentPager).ListIncidentsPage(storage.OriginAIDetect, 0, 0)
+	if len(page) != 0 {
+		t.Fatalf("ai_detect index still lists %v after purge", incidentIDs(page))
+	}
+	if n, err := lc.PurgeOlderThan(storage.DomainAnalyses, cutoff); err != nil || n != 1 {
+		t.Fatalf("PurgeOlderThan(analyses) = %d, %v", n, err)
+	}
+	if list, _ := p.ListAnalysesByIncident("fresh", 0); len(list) != 0 {
+		t.Fatalf("per-incident analyses index kept %d", len(list))
+	}
+	if n, err := lc.PurgeOlderThan(storage.DomainBlobs, now.Add(time.Hour)); err != nil || n != 1 {
+		t.Fatalf("PurgeOlderThan(blobs) = %d, %v", n, err)
+	}
+	if err := lc.DeleteByID(storage.DomainIncidents, "fresh"); err != nil {
+		t.Fatalf("DeleteByID: %v", err)
+	}
+	if err := lc.DeleteByID(storage.DomainIncidents, "fresh"); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("second DeleteByID = %v, want ErrNotFound", err)
+	}
+	if err := lc.DeleteByID(storage.DomainBlobs, "k1"); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("DeleteByID purged blob = %v, want ErrNotFound", err)
+	}
+	if _, err := lc.PurgeOlderThan("nope", now); !errors.Is(err, storage.ErrUnknownDomain) {
+		t.Fatalf("unknown domain = %v", err)
+	}
+}
+
+func incidentIDs(recs []*storage.IncidentRecord) []string {
+	ids := make([]string, len(recs))
+	for i, r := range recs {
+		ids[i] = r.ID
+	}
+	return ids
+}
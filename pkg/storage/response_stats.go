@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"errors"
+	"time"
+)
+
+// Response-time analytics groupings. GroupByNone aggregates the whole range
+// into one row.
+const (
+	GroupByNone     = ""
+	GroupByService  = "service"
+	GroupByTeam     = "team"
+	GroupBySource   = "source"
+	GroupByOrigin   = "origin"
+	GroupBySeverity = "severity"
+)
+
+// IsResponseGroupBy reports whether g is one of the groupings.
+func IsResponseGroupBy(g string) bool {
+	switch g {
+	case GroupByNone, GroupByService, GroupByTeam, GroupBySource, GroupByOrigin, GroupBySeverity:
+		return true
+	}
+	return false
+}
+
+// ErrGroupNotAggregated is returned by ResponseAggregator when the backend
+// cannot compute a grouping in the database. The caller falls back to
+// computing it from the records, so the result is the same either way.
+var ErrGroupNotAggregated = errors.New("storage: grouping not aggregated by backend")
+
+// DurationStats summarizes one set of durations, in seconds. Percentiles
+// interpolate linearly between the closest ranks, matching Postgres
+// percentile_cont. Every field is zero when Count is zero.
+type DurationStats struct {
+	Count int     `json:"count"`
+	Mean  float64 `json:"mean_seconds"`
+	P50   float64 `json:"p50_seconds"`
+	P90   float64 `json:"p90_seconds"`
+	P95   float64 `json:"p95_seconds"`
+	P99   float64 `json:"p99_seconds"`
+}
+
+// ResponseStats is the response-time summary of one group of incidents.
+//
+// MTTA (time to acknowledge) is AckedAt − CreatedAt over acknowledged
+// incidents. MTTR (time to resolve) is ResolvedAt − CreatedAt over resolved
+// incidents, excluding duplicates closed by a merge, whose resolution time
+// says nothing about the response. An incident resolved without a
+// ResolvedAt (resolved in its payload) counts as resolved but has no MTTR.
+// EscalationRate is Escalated / Incidents, where escalated means on-call
+// was paged.
+type ResponseStats struct {
+	Key            string        `json:"key"`
+	Incidents      int           `json:"incidents"`
+	Acknowledged   int           `json:"acknowledged"`
+	Resolved       int           `json:"resolved"`
+	Escalated      int           `json:"escalated"`
+	EscalationRate float64       `json:"escalation_rate"`
+	MTTA           DurationStats `json:"mtta"`
+	MTTR           DurationStats `json:"mttr"`
+}
+
+// ResponseAggregator is an optional capability a backend may implement on
+// top of Provider: compute ResponseStats for the incidents created in
+// [start, end) in the database, one row per group, largest group first. A
+// zero end is an open upper bound. A grouping the backend cannot compute
+// exactly returns ErrGroupNotAggregated, and the caller falls back to the
+// records (via RangeLister when available), the same way the report treats
+// a missing RangeLister.
+type ResponseAggregator interface {
+	AggregateResponseStats(start, end time.Time, groupBy string) ([]ResponseStats, error)
+}
@@ -0,0 +1,147 @@
+package storage_test
+
+// response_stats_test.go — the Postgres ResponseAggregator and RangeLister.
+// Gated on TEST_POSTGRES_DSN (skipped when unset); the Go fallback that the
+// file/memory backends use is covered in services/analytics_test.go.
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/services"
+	"github.com/VersusControl/versus-incident/pkg/storage"
+)
+
+func TestPostgresAggregateResponseStats(t *testing.T) {
+	p := newTestPostgres(t) // skips when TEST_POSTGRES_DSN is unset
+	agg, ok := p.(storage.ResponseAggregator)
+	if !ok {
+		t.Fatal("postgres provider does not implement ResponseAggregator")
+	}
+	base := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	at := func(d time.Duration) *time.Time { t := base.Add(d); return &t }
+	for _, r := range []*storage.IncidentRecord{
+		{ID: "rs-a", CreatedAt: base, AssignedTeamID: "sre", Service: "api", Severity: "Critical", AckedAt: at(time.Minute), Resolved: true, ResolvedAt: at(time.Hour), OnCallTriggered: true},
+		{ID: "rs-b", CreatedAt: base, AssignedTeamID: "sre", Service: "api", Content: map[string]interface{}{"level": "warn"}, AckedAt: at(3 * time.Minute)},
+		{ID: "rs-c", CreatedAt: base, Resolved: true, ResolvedAt: at(2 * time.Hour), MergedInto: "rs-a"},
+		{ID: "rs-old", CreatedAt: base.Add(-48 * time.Hour)},
+	} {
+		if err := p.SaveIncident(r); err != nil {
+			t.Fatalf("SaveIncident: %v", err)
+		}
+	}
+
+	rows, err := agg.AggregateResponseStats(base.Add(-time.Minute), time.Time{}, storage.GroupByNone)
+	if err != nil {
+		t.Fatalf("AggregateResponseStats: %v", err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("overall rows = %d, want 1", len(rows))
+	}
+	s := rows[0]
+	if s.Incidents != 3 || s.Acknowledged != 2 || s.Resolved != 2 || s.Escalated != 1 {
+		t.Fatalf("counts = %+v", s)
+	}
+	if s.MTTA.Count != 2 || s.MTTA.P50 != 120 || s.MTTR.Count != 1 || s.MTTR.P50 != 3600 {
+		t.Fatalf("durations: MTTA %+v MTTR %+v", s.MTTA, s.MTTR)
+	}
+
+	teams, err := agg.AggregateResponseStats(base.Add(-time.Minute), time.Time{}, storage.GroupByTeam)
+	if err != nil {
+		t.Fatalf("AggregateResponseStats(team): %v", err)
+	}
+	if len(teams) != 2 || teams[0].Key != "sre" || teams[0].Incidents != 2 || teams[1].Key != "unassigned" {
+		t.Fatalf("teams = %+v", teams)
+	}
+
+	// rs-c has no service column, so the service grouping needs the payload
+	// and is left to the Go path; severity always is.
+	for _, g := range []string{storage.GroupByService, storage.GroupBySeverity} {
+		if _, err := agg.AggregateResponseStats(base.Add(-time.Minute), time.Time{}, g); !errors.Is(err, storage.ErrGroupNotAggregated) {
+			t.Errorf("AggregateResponseStats(%s) err = %v, want ErrGroupNotAggregated", g, err)
+		}
+	}
+
+	recs, err := p.(storage.RangeLister).ListIncidentsInRange(base.Add(-time.Minute), base.Add(time.Minute), 0)
+	if err != nil {
+		t.Fatalf("ListIncidentsInRange: %v", err)
+	}
+	if len(recs) != 3 {
+		t.Fatalf("ListIncidentsInRange = %d records, want 3 (rs-old excluded)", len(recs))
+	}
+}
+
+// TestPostgresAggregateResponseStats_MatchesGoPath runs one set of records
+// through the SQL aggregation and through services.ComputeResponseStats, the
+// path every other backend takes, for every grouping. A grouping SQL cannot
+// compute exactly must say so rather than differ.
+func TestPostgresAggregateResponseStats_MatchesGoPath(t *testing.T) {
+	p := newTestPostgres(t) // skips when TEST_POSTGRES_DSN is unset
+	agg := p.(storage.ResponseAggregator)
+	base := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	at := func(d time.Duration) *time.Time { t := base.Add(d); return &t }
+	fixtures := func(legacy bool) []*storage.IncidentRecord {
+		recs := []*storage.IncidentRecord{
+			{ID: "mp-a", CreatedAt: base, Service: "api", Severity: " Critical ", TeamID: "sre", Source: "grafana", AckedAt: at(time.Minute), Resolved: true, ResolvedAt: at(time.Hour), OnCallTriggered: true},
+			{ID: "mp-b", CreatedAt: base, Service: "api", Content: map[string]interface{}{"Level": "WARN"}, AssignedTeamID: "db", AckedAt: at(3 * time.Minute)},
+			{ID: "mp-c", CreatedAt: base, Service: "worker", Origin: storage.OriginAIDetect, Content: map[string]interface{}{"priority": "P2"}, Resolved: true, ResolvedAt: at(2 * time.Hour)},
+			{ID: "mp-d", CreatedAt: base, Service: "worker", Resolved: true, ResolvedAt: at(30 * time.Minute), MergedInto: "mp-c"},
+		}
+		if legacy {
+			// No service column: ServiceLabel reads it from the payload
+			// with the key case folded, which SQL cannot mirror.
+			recs = append(recs, &storage.IncidentRecord{ID: "mp-e", CreatedAt: base, Content: map[string]interface{}{"SERVICE": "billing", "commonLabels": map[string]interface{}{"Severity": "info"}}})
+		}
+		return recs
+	}
+
+	for _, legacy := range []bool{false, true} {
+		for _, r := range fixtures(legacy) {
+			if err := p.SaveIncident(r); err != nil {
+				t.Fatalf("SaveIncident: %v", err)
+			}
+		}
+		recs, err := p.(storage.RangeLister).ListIncidentsInRange(base.Add(-time.Minute), time.Time{}, 0)
+		if err != nil {
+			t.Fatalf("ListIncidentsInRange: %v", err)
+		}
+		for _, g := range []string{storage.GroupByNone, storage.GroupByService, storage.GroupByTeam, storage.GroupBySource, storage.GroupByOrigin, storage.GroupBySeverity} {
+			got, err := agg.AggregateResponseStats(base.Add(-time.Minute), time.Time{}, g)
+			if errors.Is(err, storage.ErrGroupNotAggregated) {
+				if g == storage.GroupByService && !legacy {
+					t.Errorf("service: every row has the column, want it aggregated in SQL")
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatalf("AggregateResponseStats(%s): %v", g, err)
+			}
+			assertSameResponseStats(t, g, got, services.ComputeResponseStats(recs, g))
+		}
+	}
+}
+
+func assertSameResponseStats(t *testing.T, groupBy string, sql, goPath []storage.ResponseStats) {
+	t.Helper()
+	byKey := func(rows []storage.ResponseStats) {
+		sort.Slice(rows, func(i, j int) bool { return rows[i].Key < rows[j].Key })
+	}
+	byKey(sql)
+	byKey(goPath)
+	if len(sql) != len(goPath) {
+		t.Fatalf("%s: SQL %+v, Go %+v", groupBy, sql, goPath)
+	}
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-6 }
+	for i := range sql {
+		a, b := sql[i], goPath[i]
+		if a.Key != b.Key || a.Incidents != b.Incidents || a.Acknowledged != b.Acknowledged ||
+			a.Resolved != b.Resolved || a.Escalated != b.Escalated ||
+			a.MTTA.Count != b.MTTA.Count || !near(a.MTTA.P50, b.MTTA.P50) || !near(a.MTTA.Mean, b.MTTA.Mean) ||
+			a.MTTR.Count != b.MTTR.Count || !near(a.MTTR.P50, b.MTTR.P50) || !near(a.MTTR.Mean, b.MTTR.Mean) {
+			t.Errorf("%s: group %q differs:\n SQL %+v\n Go  %+v", groupBy, a.Key, a, b)
+		}
+	}
+}
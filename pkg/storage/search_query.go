@@ -0,0 +1,442 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// search_query.go — the incident and analysis search syntax, shared by every
+// backend that implements Searcher.
+//
+//	disk full            both words, each matching a word that starts with it
+//	"connection refused" the words next to each other, in that order
+//	service:payments     the service is exactly "payments" (case-insensitive)
+//	source:sns           the source is exactly "sns"
+//	severity:critical    the severity (operator-set, else the payload's)
+//	-timeout             exclude matches; works on words, phrases and fields
+//
+// Clauses are ANDed. Text is matched word by word: a document is lower-cased
+// and split on every character that is not a letter or a digit, so "db-1"
+// holds the words "db" and "1", and a query word with punctuation in it is a
+// phrase of its parts. Postgres indexes the same words in a tsvector column
+// (migration 013); the file and memory backends match them in process here;
+// SQLite reuses the parser but matches substrings with LIKE.
+
+// Search fields a clause may filter on.
+const (
+	SearchFieldService  = "service"
+	SearchFieldSource   = "source"
+	SearchFieldSeverity = "severity"
+)
+
+// maxSearchDocRunes bounds the text indexed per field, matching the
+// left(..., 100000) in the Postgres search functions. It keeps a huge
+// payload from overflowing a tsvector.
+const maxSearchDocRunes = 100000
+
+// searchClause is one term of a parsed query.
+type searchClause struct {
+	// field is one of the SearchField* names, or "" for free text.
+	field string
+	// value is the lower-cased field value (field clauses).
+	value string
+	// words are the lower-cased words of a free-text clause.
+	words []string
+	// phrase requires words to be adjacent and matched whole; a single
+	// unquoted word matches as a prefix.
+	phrase bool
+	negate bool
+}
+
+// searchQuery is a parsed query: every clause must hold.
+type searchQuery struct {
+	clauses []searchClause
+}
+
+// parseSearchQuery parses q. It never fails: an unknown field prefix is free
+// text, an unclosed quote runs to the end, and a clause with no letters or
+// digits is dropped.
+func parseSearchQuery(q string) searchQuery {
+	var out searchQuery
+	rs := []rune(q)
+	for i := 0; i < len(rs); {
+		if unicode.IsSpace(rs[i]) {
+			i++
+			continue
+		}
+		c := searchClause{}
+		if rs[i] == '-' && i+1 < len(rs) && !unicode.IsSpace(rs[i+1]) {
+			c.negate = true
+			i++
+		}
+		if rs[i] == '"' {
+			var text string
+			text, i = readQuoted(rs, i)
+			c.words, c.phrase = searchWords(text), true
+		} else {
+			start := i
+			for i < len(rs) && !unicode.IsSpace(rs[i]) && rs[i] != '"' {
+				i++
+			}
+			word := string(rs[start:i])
+			if name, val, ok := strings.Cut(word, ":"); ok && isSearchField(strings.ToLower(name)) {
+				if val == "" && i < len(rs) && rs[i] == '"' {
+					val, i = readQuoted(rs, i)
+				}
+				if val = strings.ToLower(strings.TrimSpace(val)); val != "" {
+					c.field, c.value = strings.ToLower(name), val
+					out.clauses = append(out.clauses, c)
+				}
+				continue
+			}
+			c.words = searchWords(word)
+			c.phrase = len(c.words) > 1
+		}
+		if len(c.words) > 0 {
+			out.clauses = append(out.clauses, c)
+		}
+	}
+	return out
+}
+
+// readQuoted returns the text between the quote at rs[i] and the next one
+// (or the end), and the index after the closing quote.
+func readQuoted(rs []rune, i int) (string, int) {
+	start := i + 1
+	end := start
+	for end < len(rs) && rs[end] != '"' {
+		end++
+	}
+	next := end
+	if next < len(rs) {
+		next++
+	}
+	return string(rs[start:end]), next
+}
+
+func isSearchField(name string) bool {
+	switch name {
+	case SearchFieldService, SearchFieldSource, SearchFieldSeverity:
+		return true
+	}
+	return false
+}
+
+// searchWords lower-cases s and splits it on anything that is not a letter
+// or a digit.
+func searchWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// empty reports whether the query has no clauses, so a search degrades to a
+// plain list.
+func (q searchQuery) empty() bool { return len(q.clauses) == 0 }
+
+// ranked reports whether the query has a free-text clause to rank by. A
+// query of only field filters and exclusions keeps newest-first order.
+func (q searchQuery) ranked() bool {
+	for _, c := range q.clauses {
+		if c.field == "" && !c.negate {
+			return true
+		}
+	}
+	return false
+}
+
+// asText turns each field clause into a phrase of its value, for analyses,
+// which have no field columns.
+func (q searchQuery) asText() searchQuery {
+	out := searchQuery{clauses: make([]searchClause, 0, len(q.clauses))}
+	for _, c := range q.clauses {
+		if c.field != "" {
+			c = searchClause{words: searchWords(c.value), phrase: true, negate: c.negate}
+			if len(c.words) == 0 {
+				continue
+			}
+		}
+		out.clauses = append(out.clauses, c)
+	}
+	return out
+}
+
+// tsquery renders the free-text clauses as a Postgres tsquery, or "" when
+// there are none. Words hold only letters and digits, so they need no
+// escaping inside the quotes.
+func (q searchQuery) tsquery() string {
+	var parts []string
+	for _, c := range q.clauses {
+		if c.field != "" {
+			continue
+		}
+		var term string
+		if c.phrase {
+			quoted := make([]string, len(c.words))
+			for i, w := range c.words {
+				quoted[i] = "'" + w + "'"
+			}
+			term = "(" + strings.Join(quoted, " <-> ") + ")"
+		} else {
+			term = "'" + c.words[0] + "':*"
+		}
+		if c.negate {
+			term = "!" + term
+		}
+		parts = append(parts, term)
+	}
+	return strings.Join(parts, " & ")
+}
+
+// searchDocField is one indexed field of a document: its words and the
+// weight a match there adds to the rank.
+type searchDocField struct {
+	words  []string
+	weight float64
+}
+
+// Rank weights, the Postgres ts_rank defaults for weights A to D.
+const (
+	searchWeightA = 1.0
+	searchWeightB = 0.4
+	searchWeightC = 0.2
+	searchWeightD = 0.1
+)
+
+func docField(text string, weight float64) searchDocField {
+	if rs := []rune(text); len(rs) > maxSearchDocRunes {
+		text = string(rs[:maxSearchDocRunes])
+	}
+	return searchDocField{words: searchWords(text), weight: weight}
+}
+
+// jsonText renders v as Postgres renders jsonb as text, near enough for its
+// words: no HTML escaping, so "<" stays out of the word list.
+func jsonText(v any) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return ""
+	}
+	return buf.String()
+}
+
+// incidentSearchDoc weights the same fields as the Postgres search_tsv
+// column: title A; service, source and tags B; severity, priority, labels
+// and custom fields C; the payload D.
+func incidentSearchDoc(rec *IncidentRecord) []searchDocField {
+	doc := []searchDocField{
+		docField(rec.Title, searchWeightA),
+		docField(rec.Service, searchWeightB),
+		docField(rec.Source, searchWeightB),
+		docField(strings.Join(rec.Tags, " "), searchWeightB),
+		docField(rec.Severity, searchWeightC),
+		docField(rec.Priority, searchWeightC),
+	}
+	// Empty JSON columns are NULL in Postgres, so they add no words.
+	if len(rec.Labels) > 0 {
+		doc = append(doc, docField(jsonText(rec.Labels), searchWeightC))
+	}
+	if len(rec.CustomFields) > 0 {
+		doc = append(doc, docField(jsonText(rec.CustomFields), searchWeightC))
+	}
+	if len(rec.Content) > 0 {
+		doc = append(doc, docField(jsonText(rec.Content), searchWeightD))
+	}
+	return doc
+}
+
+// score returns how well the clause's words match doc: the summed weight of
+// every match, 0 for none.
+func (c searchClause) score(doc []searchDocField) float64 {
+	var s float64
+	for _, f := range doc {
+		for i := range f.words {
+			if c.matchesAt(f.words, i) {
+				s += f.weight
+			}
+		}
+	}
+	return s
+}
+
+func (c searchClause) matchesAt(words []string, i int) bool {
+	if !c.phrase {
+		return strings.HasPrefix(words[i], c.words[0])
+	}
+	if i+len(c.words) > len(words) {
+		return false
+	}
+	for j, w := range c.words {
+		if words[i+j] != w {
+			return false
+		}
+	}
+	return true
+}
+
+// matchIncident reports whether rec satisfies every clause, and its rank.
+func (q searchQuery) matchIncident(rec *IncidentRecord) (bool, float64) {
+	var doc []searchDocField
+	var rank float64
+	for _, c := range q.clauses {
+		var hit bool
+		switch c.field {
+		case SearchFieldService:
+			hit = strings.EqualFold(strings.TrimSpace(rec.Service), c.value)
+		case SearchFieldSource:
+			hit = strings.EqualFold(strings.TrimSpace(rec.Source), c.value)
+		case SearchFieldSeverity:
+			hit = strings.EqualFold(strings.TrimSpace(rec.SeverityLabel()), c.value)
+		default:
+			if doc == nil {
+				doc = incidentSearchDoc(rec)
+			}
+			s := c.score(doc)
+			hit = s > 0
+			if !c.negate {
+				rank += s
+			}
+		}
+		if hit == c.negate {
+			return false, 0
+		}
+	}
+	return true, rank
+}
+
+// matchAnalysis reports whether rec satisfies every clause, and its rank.
+// An analysis has one text field, its JSON body; a field clause there
+// matches its value as a phrase, as in Postgres.
+func (q searchQuery) matchAnalysis(rec *AnalysisRecord) (bool, float64) {
+	doc := []searchDocField{docField(jsonText(rec), searchWeightD)}
+	var rank float64
+	for _, c := range q.asText().clauses {
+		s := c.score(doc)
+		if (s > 0) == c.negate {
+			return false, 0
+		}
+		if !c.negate {
+			rank += s
+		}
+	}
+	return true, rank
+}
+
+// searchIncidentsInProcess returns the records matching query, best match
+// first and newest first among equals. recs must be newest first. It backs
+// the file and memory backends' Searcher.
+func searchIncidentsInProcess(recs []*IncidentRecord, query string) []*IncidentRecord {
+	q := parseSearchQuery(query)
+	if q.empty() {
+		return recs
+	}
+	out := make([]*IncidentRecord, 0)
+	ranks := make(map[*IncidentRecord]float64)
+	for _, rec := range recs {
+		if ok, rank := q.matchIncident(rec); ok {
+			out = append(out, rec)
+			ranks[rec] = rank
+		}
+	}
+	if q.ranked() {
+		sort.SliceStable(out, func(i, j int) bool { return ranks[out[i]] > ranks[out[j]] })
+	}
+	return out
+}
+
+// searchAnalysesInProcess is the analysis twin of searchIncidentsInProcess.
+func searchAnalysesInProcess(recs []*AnalysisRecord, query string) []*AnalysisRecord {
+	q := parseSearchQuery(query)
+	if q.empty() {
+		return recs
+	}
+	out := make([]*AnalysisRecord, 0)
+	ranks := make(map[*AnalysisRecord]float64)
+	for _, rec := range recs {
+		if ok, rank := q.matchAnalysis(rec); ok {
+			out = append(out, rec)
+			ranks[rec] = rank
+		}
+	}
+	if q.ranked() {
+		sort.SliceStable(out, func(i, j int) bool { return ranks[out[i]] > ranks[out[j]] })
+	}
+	return out
+}
+
+// SearchIncidentRecords filters recs held outside a backend (the incident
+// archive) with the same matching and ranking as the file and memory
+// backends. recs must be newest first.
+func SearchIncidentRecords(recs []*IncidentRecord, query string) []*IncidentRecord {
+	return searchIncidentsInProcess(recs, query)
+}
+
+// SearchAnalysisRecords is the analysis twin of SearchIncidentRecords.
+func SearchAnalysisRecords(recs []*AnalysisRecord, query string) []*AnalysisRecord {
+	return searchAnalysesInProcess(recs, query)
+}
+
+// incidentCountsOf tallies the unresolved records per origin, as
+// CountIncidents does.
+func incidentCountsOf(recs []*IncidentRecord) IncidentCounts {
+	var c IncidentCounts
+	for _, rec := range recs {
+		if rec.Resolved {
+			continue
+		}
+		if rec.EffectiveOrigin() == OriginAIDetect {
+			c.AIDetect++
+		} else {
+			c.Webhook++
+		}
+	}
+	c.Total = c.AIDetect + c.Webhook
+	return c
+}
+
+// pageIncidents returns one page of recs filtered to origin (empty = all),
+// as ListIncidentsPage does.
+func pageIncidents(recs []*IncidentRecord, origin string, offset, limit int) []*IncidentRecord {
+	if limit <= 0 {
+		limit = DefaultIncidentPageSize
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	filtered := origin == OriginAIDetect || origin == OriginWebhook
+	out := make([]*IncidentRecord, 0, limit)
+	skipped := 0
+	for _, rec := range recs {
+		if filtered && rec.EffectiveOrigin() != origin {
+			continue
+		}
+		if skipped < offset {
+			skipped++
+			continue
+		}
+		out = append(out, rec)
+		if len(out) >= limit {
+			break
+		}
+	}
+	return out
+}
+
+func limitIncidents(recs []*IncidentRecord, limit int) []*IncidentRecord {
+	if limit > 0 && len(recs) > limit {
+		return recs[:limit]
+	}
+	return recs
+}
+
+func limitAnalyses(recs []*AnalysisRecord, limit int) []*AnalysisRecord {
+	if limit > 0 && len(recs) > limit {
+		return recs[:limit]
+	}
+	return recs
+}
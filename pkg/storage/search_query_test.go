@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestParseSearchQuery covers the syntax: words, phrases, field filters,
+// exclusions, and the tsquery each renders to.
+func TestParseSearchQuery(t *testing.T) {
+	cases := []struct {
+		in      string
+		tsquery string
+		fields  []searchClause
+	}{
+		{"disk full", "'disk':* & 'full':*", nil},
+		{`"Connection refused"`, "('connection' <-> 'refused')", nil},
+		{"db-1", "('db' <-> '1')", nil},
+		{"-timeout -\"gc pause\"", "!'timeout':* & !('gc' <-> 'pause')", nil},
+		{"Service:Payments -source:sns", "", []searchClause{
+			{field: SearchFieldService, value: "payments"},
+			{field: SearchFieldSource, value: "sns", negate: true},
+		}},
+		{`severity:"Sev 1" oom`, "'oom':*", []searchClause{{field: SearchFieldSeverity, value: "sev 1"}}},
+		{"http://x host:web", "('http' <-> 'x') & ('host' <-> 'web')", nil},
+		{"' & !| -", "", nil},
+		{`"unclosed phrase`, "('unclosed' <-> 'phrase')", nil},
+	}
+	for _, tc := range cases {
+		q := parseSearchQuery(tc.in)
+		if got := q.tsquery(); got != tc.tsquery {
+			t.Errorf("%q: tsquery = %q, want %q", tc.in, got, tc.tsquery)
+		}
+		var fields []searchClause
+		for _, c := range q.clauses {
+			if c.field != "" {
+				fields = append(fields, c)
+			}
+		}
+		if !reflect.DeepEqual(fields, tc.fields) {
+			t.Errorf("%q: fields = %+v, want %+v", tc.in, fields, tc.fields)
+		}
+	}
+	if !parseSearchQuery("' & !|").empty() {
+		t.Error("a query with no words must be empty")
+	}
+}
+
+// TestSearchIncidentsInProcess matches and ranks the way the Postgres index
+// does: title matches above payload matches, then newest first.
+func TestSearchIncidentsInProcess(t *testing.T) {
+	recs := []*IncidentRecord{ // newest first
+		{ID: "payload", Title: "Alert", Service: "api", Content: map[string]interface{}{"msg": "disk full on db-1"}},
+		{ID: "title", Title: "Disk full", Service: "db", Source: "sns", Severity: "critical"},
+		{ID: "other", Title: "Latency", Service: "api", Content: map[string]interface{}{"severity": "warning"}},
+	}
+	ids := func(query string) []string {
+		var out []string
+		for _, r := range searchIncidentsInProcess(recs, query) {
+			out = append(out, r.ID)
+		}
+		return out
+	}
+	for query, want := range map[string][]string{
+		"disk":                         {"title", "payload"},
+		`"full on db"`:                 {"payload"},
+		"db-1":                         {"payload"},
+		"disk -service:db":             {"payload"},
+		"service:API":                  {"payload", "other"},
+		"severity:warning":             {"other"},
+		"severity:critical source:sns": {"title"},
+		"-disk":                        {"other"},
+		"dis ful":                      {"title", "payload"},
+		`"dis ful"`:                    nil,
+	} {
+		if got := ids(query); !reflect.DeepEqual(got, want) {
+			t.Errorf("%q = %v, want %v", query, got, want)
+		}
+	}
+}
@@ -0,0 +1,1057 @@
+package storage
+
+import (
+	"database/sql"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	// modernc.org/sqlite is a pure-Go SQLite — registers "sqlite" with
+	// database/sql and keeps the binary cgo-free.
+	_ "modernc.org/sqlite"
+)
+
+//go:embed sqlite_migrations/*.sql
+var sqliteMigrationFiles embed.FS
+
+// DefaultSQLiteFile is the database file the SQLite backend opens under
+// DefaultDataDir when no DSN is configured.
+const DefaultSQLiteFile = "versus.db"
+
+// sqliteTimeLayout is how every timestamp column is stored: UTC, fixed
+// width, nanosecond precision. Fixed width makes string order equal time
+// order, so range filters, ORDER BY and the retention cutoffs compare the
+// text directly.
+const sqliteTimeLayout = "2006-01-02T15:04:05.000000000Z"
+
+// SQLiteOptions configures the SQLite backend. Path is a file path or a
+// `file:` URI; empty falls back to DefaultDataDir/DefaultSQLiteFile.
+type SQLiteOptions struct {
+	Path string
+	Keys *Keyring // seals blobs, incident content and analysis output; nil stores them clear
+}
+
+// sqliteProvider stores incidents, analyses, blobs and the incident
+// timeline in one SQLite database. It is the single-node counterpart of the
+// Postgres backend: the same typed incident columns and the same SQL-side
+// paging, counting and search, so history is unbounded without operating a
+// database server.
+//
+// It deliberately does NOT implement SQLAccessor: that capability is the
+// boot path's "this is Postgres" switch (typed signal tables, enterprise
+// migrations), and none of that SQL runs on SQLite.
+type sqliteProvider struct {
+	db   *sql.DB
+	keys *Keyring
+}
+
+// NewSQLite opens (creating if needed) the SQLite database at opts.Path,
+// applies the embedded sqlite_migrations set through RunSQLMigrations and
+// returns a ready Provider. Callers must call Close when done.
+func NewSQLite(opts SQLiteOptions) (Provider, error) {
+	path := strings.TrimSpace(opts.Path)
+	if path == "" {
+		path = filepath.Join(DefaultDataDir, DefaultSQLiteFile)
+	}
+	if dir := sqliteDir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("storage: mkdir %s: %w", dir, err)
+		}
+	}
+	db, err := sql.Open("sqlite", sqliteDSN(path))
+	if err != nil {
+		return nil, fmt.Errorf("storage: open sqlite: %w", err)
+	}
+	// SQLite has one writer at a time; a single pooled connection turns
+	// writer contention into a wait on the pool instead of SQLITE_BUSY,
+	// and keeps an in-memory database alive for the provider's lifetime.
+	db.SetMaxOpenConns(1)
+	if err := db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("storage: open sqlite %s: %w", path, err)
+	}
+	if err := RunSQLMigrations(db, sqliteMigrationFiles, "sqlite_migrations", ossMigrationLedger); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("storage: sqlite migrate: %w", err)
+	}
+	return &sqliteProvider{db: db, keys: opts.Keys}, nil
+}
+
+// sqliteDir returns the directory holding the database file, or "" for an
+// in-memory database or a bare file name.
+func sqliteDir(path string) string {
+	name := strings.TrimPrefix(path, "file:")
+	if i := strings.IndexByte(name, '?'); i >= 0 {
+		if strings.Contains(name[i:], "mode=memory") {
+			return ""
+		}
+		name = name[:i]
+	}
+	if name == "" || strings.HasPrefix(name, ":memory:") {
+		return ""
+	}
+	if dir := filepath.Dir(name); dir != "." {
+		return dir
+	}
+	return ""
+}
+
+// sqliteDSN appends the connection pragmas every connection needs: foreign
+// keys (the timeline cascades with its incident), a busy timeout for other
+// processes holding the file, and WAL so readers never block the writer.
+func sqliteDSN(path string) string {
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	return path + sep + "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+}
+
+// sqliteTime formats t for a timestamp column.
+func sqliteTime(t time.Time) string {
+	return t.UTC().Format(sqliteTimeLayout)
+}
+
+// sqliteTimePtr binds a nil *time.Time as SQL NULL.
+func sqliteTimePtr(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return sqliteTime(*t)
+}
+
+// sqliteRangeEnd binds a zero end as SQL NULL (no upper bound).
+func sqliteRangeEnd(end time.Time) any {
+	if end.IsZero() {
+		return nil
+	}
+	return sqliteTime(end)
+}
+
+// parseSQLiteTime reads a timestamp column back; a NULL yields nil.
+func parseSQLiteTime(s sql.NullString) (*time.Time, error) {
+	if !s.Valid {
+		return nil, nil
+	}
+	t, err := time.Parse(sqliteTimeLayout, s.String)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// sqliteJSON renders v for a nullable JSON text column. n is v's length; an
+// empty value binds as SQL NULL so it reads back as nil.
+func sqliteJSON(n int, v any) (any, error) {
+	if n == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// sqliteNow is the :now argument sqliteEffectiveStatusSQL compares snooze
+// deadlines against. Named arguments go after every positional one: the
+// driver binds $N by ordinal.
+func sqliteNow() sql.NamedArg {
+	return sql.Named("now", sqliteTime(time.Now()))
+}
+
+// ---------------------------------------------------------------------------
+// Blobs
+// ---------------------------------------------------------------------------
+
+func (p *sqliteProvider) ReadBlob(name string) ([]byte, error) {
+	var data []byte
+	err := p.db.QueryRow(`SELECT data FROM vs_blobs WHERE name = $1`, name).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil // missing blob → nil,nil per Provider contract
+	}
+	if err != nil {
+		return nil, fmt.Errorf("storage: read blob %q: %w", name, err)
+	}
+	return p.keys.openBlob(name, data)
+}
+
+func (p *sqliteProvider) WriteBlob(name string, data []byte) error {
+	data, err := p.keys.sealBlob(name, data)
+	if err != nil {
+		return err
+	}
+	_, err = p.db.Exec(`
+		INSERT INTO vs_blobs (name, data, updated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (name) DO UPDATE
+		SET data = excluded.data, updated_at = excluded.updated_at
+	`, name, sqliteBlobData(data), sqliteTime(time.Now()))
+	if err != nil {
+		return fmt.Errorf("storage: write blob %q: %w", name, err)
+	}
+	return nil
+}
+
+// sqliteBlobData keeps an empty blob distinct from NULL, which the NOT NULL
+// data column would reject.
+func sqliteBlobData(data []byte) []byte {
+	if data == nil {
+		return []byte{}
+	}
+	return data
+}
+
+// CreateBlobIfAbsent implements the optional storage.BlobCreator capability:
+// `INSERT … ON CONFLICT DO NOTHING` writes only when the name is free, so
+// exactly one of several racing callers sees written==true.
+func (p *sqliteProvider) CreateBlobIfAbsent(name string, data []byte) (bool, error) {
+	data, err := p.keys.sealBlob(name, data)
+	if err != nil {
+		return false, err
+	}
+	res, err := p.db.Exec(`
+		INSERT INTO vs_blobs (name, data, updated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (name) DO NOTHING
+	`, name, sqliteBlobData(data), sqliteTime(time.Now()))
+	if err != nil {
+		return false, fmt.Errorf("storage: create blob %q: %w", name, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("storage: create blob %q rows: %w", name, err)
+	}
+	return n == 1, nil
+}
+
+// ListBlobs returns every blob whose name begins with prefix. The prefix is
+// compared with substr rather than LIKE, which is case-insensitive in
+// SQLite and would match "Models/" for "models/".
+func (p *sqliteProvider) ListBlobs(prefix string) ([]Blob, error) {
+	rows, err := p.db.Query(`
+		SELECT name, data FROM vs_blobs
+		WHERE substr(name, 1, length($1)) = $1
+		ORDER BY name`, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("storage: list blobs: %w", err)
+	}
+	defer rows.Close()
+	var out []Blob
+	for rows.Next() {
+		var b Blob
+		if err := rows.Scan(&b.Name, &b.Data); err != nil {
+			return nil, fmt.Errorf("storage: scan blob: %w", err)
+		}
+		out = append(out, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, p.keys.openBlobs(out)
+}
+
+// ---------------------------------------------------------------------------
+// Incidents
+//
+// The same one-column-per-property layout as Postgres. String slices,
+// labels, custom fields and content are JSON text; timestamps are
+// sqliteTimeLayout text.
+// ---------------------------------------------------------------------------
+
+// sqliteIncidentColumns is the SELECT list every incident read uses. The
+// order MUST match the Scan order in scanSQLiteIncident.
+const sqliteIncidentColumns = `id, created_at, acked_at, org_id, team_id, title,
+	source, service, origin, resolved,
+	channels_enabled, channels_notified,
+	oncall_triggered, oncall_error, notify_status, notify_error,
+	resolved_at, content, assigned_team_id, assigned_member_ids,
+	status, snoozed_until, severity, priority, labels,
+	tags, custom_fields, merged_into, parent_id, related_ids`
+
+// sqliteEffectiveStatusSQL is IncidentRecord.EffectiveStatus as a SQL
+// expression, the twin of effectiveStatusSQL. The current time binds as
+// :now (see sqliteNow).
+const sqliteEffectiveStatusSQL = `CASE
+		WHEN status = 'snoozed' AND (snoozed_until IS NULL OR snoozed_until <= :now) THEN 'triggered'
+		WHEN status IN ('triggered', 'acknowledged', 'investigating', 'mitigated', 'resolved', 'reopened', 'snoozed') THEN status
+		WHEN resolved THEN 'resolved'
+		WHEN acked_at IS NOT NULL THEN 'acknowledged'
+		ELSE 'triggered'
+	END`
+
+func (p *sqliteProvider) SaveIncident(rec *IncidentRecord) error {
+	if rec == nil || rec.ID == "" {
+		return fmt.Errorf("storage: SaveIncident: missing id")
+	}
+	rec.OrgID = NormalizeOrgID(rec.OrgID)
+	sealed, err := p.keys.sealIncident(rec)
+	if err != nil {
+		return err
+	}
+	cols := []struct {
+		name string
+		n    int
+		v    any
+	}{
+		{"channels_enabled", len(rec.ChannelsEnabled), rec.ChannelsEnabled},
+		{"channels_notified", len(rec.ChannelsNotified), rec.ChannelsNotified},
+		{"content", len(sealed.Content), sealed.Content},
+		{"assigned_member_ids", len(rec.AssignedMemberIDs), rec.AssignedMemberIDs},
+		{"labels", len(rec.Labels), rec.Labels},
+		{"tags", len(rec.Tags), rec.Tags},
+		{"custom_fields", len(rec.CustomFields), rec.CustomFields},
+		{"related_ids", len(rec.RelatedIDs), rec.RelatedIDs},
+	}
+	js := make([]any, len(cols))
+	for i, c := range cols {
+		v, err := sqliteJSON(c.n, c.v)
+		if err != nil {
+			return fmt.Errorf("storage: marshal incident %s: %w", c.name, err)
+		}
+		js[i] = v
+	}
+	// Full-column upsert, as on Postgres. ON CONFLICT DO UPDATE (never
+	// INSERT OR REPLACE, which deletes the row and would cascade away the
+	// incident's timeline).
+	_, err = p.db.Exec(`
+		INSERT INTO vs_incidents (
+			id, created_at, acked_at, org_id, team_id, title, source, service,
+			origin, resolved, channels_enabled, channels_notified,
+			oncall_triggered, oncall_error, notify_status, notify_error,
+			resolved_at, content, assigned_team_id, assigned_member_ids,
+			status, snoozed_until, severity, priority, labels, tags,
+			custom_fields, merged_into, parent_id, related_ids
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8,
+			$9, $10, $11, $12,
+			$13, $14, $15, $16,
+			$17, $18, $19, $20,
+			NULLIF($21, ''), $22, NULLIF($23, ''), NULLIF($24, ''), $25, $26,
+			$27, NULLIF($28, ''), NULLIF($29, ''), $30
+		)
+		ON CONFLICT (id) DO UPDATE SET
+			created_at          = excluded.created_at,
+			acked_at            = excluded.acked_at,
+			org_id              = excluded.org_id,
+			team_id             = excluded.team_id,
+			title               = excluded.title,
+			source              = excluded.source,
+			service             = excluded.service,
+			origin              = excluded.origin,
+			resolved            = excluded.resolved,
+			channels_enabled    = excluded.channels_enabled,
+			channels_notified   = excluded.channels_notified,
+			oncall_triggered    = excluded.oncall_triggered,
+			oncall_error        = excluded.oncall_error,
+			notify_status       = excluded.notify_status,
+			notify_error        = excluded.notify_error,
+			resolved_at         = excluded.resolved_at,
+			content             = excluded.content,
+			assigned_team_id    = excluded.assigned_team_id,
+			assigned_member_ids = excluded.assigned_member_ids,
+			status              = excluded.status,
+			snoozed_until       = excluded.snoozed_until,
+			severity            = excluded.severity,
+			priority            = excluded.priority,
+			labels              = excluded.labels,
+			tags                = excluded.tags,
+			custom_fields       = excluded.custom_fields,
+			merged_into         = excluded.merged_into,
+			parent_id           = excluded.parent_id,
+			related_ids         = excluded.related_ids
+	`,
+		rec.ID, sqliteTime(rec.CreatedAt), sqliteTimePtr(rec.AckedAt), rec.OrgID, rec.TeamID,
+		rec.Title, rec.Source, rec.Service, rec.EffectiveOrigin(), rec.Resolved,
+		js[0], js[1],
+		rec.OnCallTriggered, rec.OnCallError, rec.NotifyStatus, rec.NotifyError,
+		sqliteTimePtr(rec.ResolvedAt), js[2], rec.AssignedTeamID, js[3],
+		rec.Status, sqliteTimePtr(rec.SnoozedUntil), rec.Severity, rec.Priority,
+		js[4], js[5], js[6],
+		rec.MergedInto, rec.ParentID, js[7],
+	)
+	if err != nil {
+		return fmt.Errorf("storage: save incident: %w", err)
+	}
+	// Like Postgres, history is unbounded; retention goes through
+	// storage.Lifecycle.
+	return nil
+}
+
+// UpdateIncidentAck stamps acked_at and moves a triggered, reopened or
+// snoozed incident to acknowledged, exactly as the Postgres backend does.
+func (p *sqliteProvider) UpdateIncidentAck(id string, ackedAt time.Time) error {
+	res, err := p.db.Exec(`
+		UPDATE vs_incidents SET
+			acked_at      = $2,
+			status        = CASE WHEN `+sqliteEffectiveStatusSQL+` IN ('triggered', 'reopened', 'snoozed')
+			                     THEN 'acknowledged' ELSE `+sqliteEffectiveStatusSQL+` END,
+			snoozed_until = NULL
+		WHERE id = $1`, id, sqliteTime(ackedAt), sqliteNow(),
+	)
+	if err != nil {
+		return fmt.Errorf("storage: ack update: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (p *sqliteProvider) GetIncident(id string) (*IncidentRecord, error) {
+	row := p.db.QueryRow(`SELECT `+sqliteIncidentColumns+` FROM vs_incidents WHERE id = $1`, id)
+	rec, err := scanSQLiteIncident(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("storage: get incident: %w", err)
+	}
+	if err := p.keys.openIncident(rec); err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+func (p *sqliteProvider) ListIncidents(limit int) ([]*IncidentRecord, error) {
+	return p.queryIncidents("list incidents", `
+		SELECT `+sqliteIncidentColumns+` FROM vs_incidents
+		ORDER BY created_at DESC
+		LIMIT $1`, sqliteLimit(limit))
+}
+
+// sqliteLimit maps the Provider's "limit <= 0 means everything" to SQLite's
+// LIMIT -1.
+func sqliteLimit(limit int) int {
+	if limit <= 0 {
+		return -1
+	}
+	return limit
+}
+
+// queryIncidents runs one incident SELECT and scans every row.
+func (p *sqliteProvider) queryIncidents(op, q string, args ...any) ([]*IncidentRecord, error) {
+	rows, err := p.db.Query(q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("storage: %s: %w", op, err)
+	}
+	defer rows.Close()
+	var out []*IncidentRecord
+	for rows.Next() {
+		rec, err := scanSQLiteIncident(rows)
+		if err != nil {
+			return nil, fmt.Errorf("storage: scan incident row: %w", err)
+		}
+		out = append(out, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, p.keys.openIncidents(out)
+}
+
+// scanSQLiteIncident reads one row selected via sqliteIncidentColumns. A NULL
+// column becomes the field's zero value (empty string, nil slice, nil map).
+func scanSQLiteIncident(sc rowScanner) (*IncidentRecord, error) {
+	var (
+		rec         IncidentRecord
+		createdAt   string
+		ackedAt     sql.NullString
+		resolvedAt  sql.NullString
+		snoozedTill sql.NullString
+		teamID      sql.NullString
+		title       sql.NullString
+		source      sql.NullString
+		service     sql.NullString
+		origin      sql.NullString
+		oncallTrig  sql.NullBool
+		oncallErr   sql.NullString
+		notifyStat  sql.NullString
+		notifyErr   sql.NullString
+		assignTeam  sql.NullString
+		status      sql.NullString
+		severity    sql.NullString
+		priority    sql.NullString
+		mergedInto  sql.NullString
+		parentID    sql.NullString
+		chEnabled   []byte
+		chNotified  []byte
+		content     []byte
+		assignedIDs []byte
+		labels      []byte
+		tags        []byte
+		custom      []byte
+		relatedIDs  []byte
+	)
+	if err := sc.Scan(
+		&rec.ID, &createdAt, &ackedAt, &rec.OrgID, &teamID, &title,
+		&source, &service, &origin, &rec.Resolved,
+		&chEnabled, &chNotified,
+		&oncallTrig, &oncallErr, &notifyStat, &notifyErr,
+		&resolvedAt, &content, &assignTeam, &assignedIDs,
+		&status, &snoozedTill, &severity, &priority, &labels,
+		&tags, &custom, &mergedInto, &parentID, &relatedIDs,
+	); err != nil {
+		return nil, err
+	}
+	created, err := time.Parse(sqliteTimeLayout, createdAt)
+	if err != nil {
+		return nil, fmt.Errorf("decode created_at: %w", err)
+	}
+	rec.CreatedAt = created
+	if rec.AckedAt, err = parseSQLiteTime(ackedAt); err != nil {
+		return nil, fmt.Errorf("decode acked_at: %w", err)
+	}
+	if rec.ResolvedAt, err = parseSQLiteTime(resolvedAt); err != nil {
+		return nil, fmt.Errorf("decode resolved_at: %w", err)
+	}
+	if rec.SnoozedUntil, err = parseSQLiteTime(snoozedTill); err != nil {
+		return nil, fmt.Errorf("decode snoozed_until: %w", err)
+	}
+	rec.TeamID = teamID.String
+	rec.Title = title.String
+	rec.Source = source.String
+	rec.Service = service.String
+	rec.Origin = origin.String
+	rec.OnCallTriggered = oncallTrig.Bool
+	rec.OnCallError = oncallErr.String
+	rec.NotifyStatus = notifyStat.String
+	rec.NotifyError = notifyErr.String
+	rec.AssignedTeamID = assignTeam.String
+	rec.Status = status.String
+	rec.Severity = severity.String
+	rec.Priority = priority.String
+	rec.MergedInto = mergedInto.String
+	rec.ParentID = parentID.String
+
+	if rec.ChannelsEnabled, err = jsonStringSlice(chEnabled); err != nil {
+		return nil, fmt.Errorf("decode channels_enabled: %w", err)
+	}
+	if rec.ChannelsNotified, err = jsonStringSlice(chNotified); err != nil {
+		return nil, fmt.Errorf("decode channels_notified: %w", err)
+	}
+	if rec.AssignedMemberIDs, err = jsonStringSlice(assignedIDs); err != nil {
+		return nil, fmt.Errorf("decode assigned_member_ids: %w", err)
+	}
+	if rec.Content, err = unmarshalIncidentContent(content); err != nil {
+		return nil, fmt.Errorf("decode content: %w", err)
+	}
+	if rec.Tags, err = jsonStringSlice(tags); err != nil {
+		return nil, fmt.Errorf("decode tags: %w", err)
+	}
+	if rec.RelatedIDs, err = jsonStringSlice(relatedIDs); err != nil {
+		return nil, fmt.Errorf("decode related_ids: %w", err)
+	}
+	if len(labels) > 0 {
+		if err := json.Unmarshal(labels, &rec.Labels); err != nil {
+			return nil, fmt.Errorf("decode labels: %w", err)
+		}
+	}
+	if len(custom) > 0 {
+		if err := json.Unmarshal(custom, &rec.CustomFields); err != nil {
+			return nil, fmt.Errorf("decode custom_fields: %w", err)
+		}
+	}
+	return &rec, nil
+}
+
+// ListIncidentsReferencing implements the optional storage.RelationLister
+// capability; related_ids is a JSON array, searched with json_each.
+func (p *sqliteProvider) ListIncidentsReferencing(id string) ([]*IncidentRecord, error) {
+	return p.queryIncidents("list referencing incidents", `
+		SELECT `+sqliteIncidentColumns+` FROM vs_incidents
+		WHERE merged_into = $1 OR parent_id = $1
+		   OR EXISTS (SELECT 1 FROM json_each(vs_incidents.related_ids) WHERE value = $1)
+		ORDER BY created_at DESC
+		LIMIT $2`, id, MaxIncidentCluster,
+	)
+}
+
+// CountIncidents implements the optional storage.IncidentPager capability:
+// the per-origin tally of UNRESOLVED incidents in one COUNT query.
+func (p *sqliteProvider) CountIncidents() (IncidentCounts, error) {
+	return p.countOpen("", "count incidents")
+}
+
+// countOpen tallies unresolved incidents matching where (all when empty),
+// split by origin. The where clause binds its pattern as $1.
+func (p *sqliteProvider) countOpen(where, op string, args ...any) (IncidentCounts, error) {
+	q := `
+		SELECT
+			COUNT(*) FILTER (WHERE origin = 'ai_detect'),
+			COUNT(*) FILTER (WHERE origin = 'webhook'),
+			COUNT(*)
+		FROM vs_incidents
+		WHERE resolved = 0`
+	if where != "" {
+		q += ` AND (` + where + `)`
+	}
+	var c IncidentCounts
+	if err := p.db.QueryRow(q, args...).Scan(&c.AIDetect, &c.Webhook, &c.Total); err != nil {
+		return IncidentCounts{}, fmt.Errorf("storage: %s: %w", op, err)
+	}
+	return c, nil
+}
+
+// CountIncidentsByStatus implements the optional storage.IncidentPager
+// capability with one grouped COUNT, as on Postgres.
+func (p *sqliteProvider) CountIncidentsByStatus() (IncidentStatusCounts, error) {
+	c, err := p.countByStatus("")
+	if err != nil {
+		return IncidentStatusCounts{}, fmt.Errorf("storage: count incidents by status: %w", err)
+	}
+	return c, nil
+}
+
+// countByStatus groups the rows matching where (all rows when empty) by
+// ai_detect-or-not × effective status and folds the groups into the
+// per-state buckets.
+func (p *sqliteProvider) countByStatus(where string, args ...any) (IncidentStatusCounts, error) {
+	q := `
+		SELECT origin = 'ai_detect' AS ai, ` + sqliteEffectiveStatusSQL + ` AS st, COUNT(*)
+		FROM vs_incidents`
+	if where != "" {
+		q += ` WHERE (` + where + `)`
+	}
+	q += ` GROUP BY 1, 2`
+	rows, err := p.db.Query(q, append(args, sqliteNow())...)
+	if err != nil {
+		return IncidentStatusCounts{}, err
+	}
+	defer rows.Close()
+	var total, ai StatusCounts
+	for rows.Next() {
+		var (
+			isAI sql.NullBool
+			st   string
+			n    int
+		)
+		if err := rows.Scan(&isAI, &st, &n); err != nil {
+			return IncidentStatusCounts{}, err
+		}
+		total.add(st, n)
+		if isAI.Bool {
+			ai.add(st, n)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return IncidentStatusCounts{}, err
+	}
+	return AssembleStatusCounts(total, ai), nil
+}
+
+// ListIncidentsPage implements the optional storage.IncidentPager
+// capability: one newest-first LIMIT/OFFSET page, filtered by origin in SQL.
+func (p *sqliteProvider) ListIncidentsPage(origin string, offset, limit int) ([]*IncidentRecord, error) {
+	return p.SearchIncidentsPage("", origin, offset, limit)
+}
+
+// ---------------------------------------------------------------------------
+// Analyses
+// ---------------------------------------------------------------------------
+
+func (p *sqliteProvider) SaveAnalysis(rec *AnalysisRecord) error {
+	if rec == nil || rec.ID == "" {
+		return fmt.Errorf("storage: SaveAnalysis: missing id")
+	}
+	rec.OrgID = NormalizeOrgID(rec.OrgID)
+	sealed, err := p.keys.sealAnalysis(rec)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(sealed)
+	if err != nil {
+		return fmt.Errorf("storage: marshal analysis: %w", err)
+	}
+	_, err = p.db.Exec(`
+		INSERT INTO vs_analyses (id, incident_id, data, requested_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (id) DO UPDATE
+		SET data         = excluded.data,
+		    incident_id  = excluded.incident_id,
+		    requested_at = excluded.requested_at
+	`, rec.ID, rec.IncidentID, string(data), sqliteTime(rec.RequestedAt))
+	if err != nil {
+		return fmt.Errorf("storage: save analysis: %w", err)
+	}
+	return nil
+}
+
+func (p *sqliteProvider) GetAnalysis(id string) (*AnalysisRecord, error) {
+	var raw []byte
+	err := p.db.QueryRow(`SELECT data FROM vs_analyses WHERE id = $1`, id).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("storage: get analysis: %w", err)
+	}
+	var rec AnalysisRecord
+	if err := json.Unmarshal(raw, &rec); err != nil {
+		return nil, fmt.Errorf("storage: unmarshal analysis: %w", err)
+	}
+	if err := p.keys.openAnalysis(&rec); err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+func (p *sqliteProvider) ListAnalysesByIncident(incidentID string, limit int) ([]*AnalysisRecord, error) {
+	return p.queryAnalyses("list analyses by incident", `
+		SELECT data FROM vs_analyses
+		WHERE incident_id = $1
+		ORDER BY requested_at DESC
+		LIMIT $2`, incidentID, sqliteLimit(limit))
+}
+
+func (p *sqliteProvider) ListAnalyses(limit int) ([]*AnalysisRecord, error) {
+	return p.queryAnalyses("list analyses", `
+		SELECT data FROM vs_analyses ORDER BY requested_at DESC LIMIT $1`, sqliteLimit(limit))
+}
+
+// CountAnalyses implements the optional storage.AnalysisPager capability.
+func (p *sqliteProvider) CountAnalyses() (int, error) {
+	var n int
+	if err := p.db.QueryRow(`SELECT COUNT(*) FROM vs_analyses`).Scan(&n); err != nil {
+		return 0, fmt.Errorf("storage: count analyses: %w", err)
+	}
+	return n, nil
+}
+
+// ListAnalysesPage implements the optional storage.AnalysisPager capability.
+func (p *sqliteProvider) ListAnalysesPage(offset, limit int) ([]*AnalysisRecord, error) {
+	if limit <= 0 {
+		limit = DefaultAnalysisPageSize
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return p.queryAnalyses("list analyses page", `
+		SELECT data FROM vs_analyses ORDER BY requested_at DESC LIMIT $1 OFFSET $2`, limit, offset)
+}
+
+// queryAnalyses runs one analysis SELECT and decodes every row.
+func (p *sqliteProvider) queryAnalyses(op, q string, args ...any) ([]*AnalysisRecord, error) {
+	rows, err := p.db.Query(q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("storage: %s: %w", op, err)
+	}
+	defer rows.Close()
+	return scanAnalysisRows(rows, p.keys)
+}
+
+func (p *sqliteProvider) DeleteAnalysis(id string) error {
+	res, err := p.db.Exec(`DELETE FROM vs_analyses WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("storage: delete analysis: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ---------------------------------------------------------------------------
+// Search (implements the optional storage.Searcher and
+// storage.IncidentSearchPager capabilities)
+// ---------------------------------------------------------------------------
+
+// sqliteSearchColumns are the columns a free-text clause matches, the same
+// fields as the Postgres search_tsv column.
+const sqliteSearchColumns = `title, service, source, severity, priority, tags, labels, custom_fields, content`
+
+// sqliteSeveritySQL is the severity a severity: clause compares, as
+// postgresSeveritySQL.
+const sqliteSeveritySQL = `lower(trim(coalesce(nullif(severity, ''),
+		json_extract(content, '$.severity'), json_extract(content, '$.Severity'),
+		json_extract(content, '$.level'), json_extract(content, '$.priority'),
+		json_extract(content, '$.labels.severity'), json_extract(content, '$.commonLabels.severity'), '')))`
+
+// sqliteSearch renders a parsed query (see search_query.go) as a WHERE
+// predicate binding its values from $first on. A free-text clause is a LIKE
+// over cols, matching its words as substrings in order, so it is looser than
+// the word matching of the other backends. SQLite's LIKE is
+// case-insensitive for ASCII letters only, so "Ü" does not match "ü". Words
+// hold only letters and digits, so they need no LIKE escaping.
+func sqliteSearch(q searchQuery, cols string, first int) (string, []any) {
+	var (
+		preds []string
+		args  []any
+	)
+	bind := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", first+len(args)-1)
+	}
+	for _, c := range q.clauses {
+		var pred string
+		switch c.field {
+		case SearchFieldService:
+			pred = `lower(trim(coalesce(service, ''))) = ` + bind(c.value)
+		case SearchFieldSource:
+			pred = `lower(trim(coalesce(source, ''))) = ` + bind(c.value)
+		case SearchFieldSeverity:
+			pred = sqliteSeveritySQL + ` = ` + bind(c.value)
+		default:
+			ph := bind("%" + strings.Join(c.words, "%") + "%")
+			var likes []string
+			for _, col := range strings.Split(cols, ", ") {
+				likes = append(likes, col+` LIKE `+ph)
+			}
+			pred = `coalesce(` + strings.Join(likes, ` OR `) + `, 0)`
+		}
+		if c.negate {
+			pred = `NOT (` + pred + `)`
+		}
+		preds = append(preds, pred)
+	}
+	return strings.Join(preds, ` AND `), args
+}
+
+// SearchIncidents matches the query syntax of search_query.go, newest first.
+// An empty query degrades to ListIncidents.
+func (p *sqliteProvider) SearchIncidents(query string, limit int) ([]*IncidentRecord, error) {
+	q := parseSearchQuery(query)
+	if q.empty() {
+		return p.ListIncidents(limit)
+	}
+	where, args := sqliteSearch(q, sqliteSearchColumns, 1)
+	return p.queryIncidents("search incidents", fmt.Sprintf(`
+		SELECT `+sqliteIncidentColumns+` FROM vs_incidents
+		WHERE %s
+		ORDER BY created_at DESC
+		LIMIT $%d`, where, len(args)+1), append(args, sqliteLimit(limit))...)
+}
+
+// CountIncidentsMatching implements the optional storage.IncidentSearchPager
+// capability: the per-origin tally of UNRESOLVED matches in one COUNT query.
+func (p *sqliteProvider) CountIncidentsMatching(query string) (IncidentCounts, error) {
+	q := parseSearchQuery(query)
+	if q.empty() {
+		return p.CountIncidents()
+	}
+	where, args := sqliteSearch(q, sqliteSearchColumns, 1)
+	return p.countOpen(where, "count matching incidents", args...)
+}
+
+// CountIncidentsMatchingByStatus implements the optional
+// storage.IncidentSearchPager capability: the search twin of
+// CountIncidentsByStatus.
+func (p *sqliteProvider) CountIncidentsMatchingByStatus(query string) (IncidentStatusCounts, error) {
+	q := parseSearchQuery(query)
+	if q.empty() {
+		return p.CountIncidentsByStatus()
+	}
+	where, args := sqliteSearch(q, sqliteSearchColumns, 1)
+	c, err := p.countByStatus(where, args...)
+	if err != nil {
+		return IncidentStatusCounts{}, fmt.Errorf("storage: count matching incidents by status: %w", err)
+	}
+	return c, nil
+}
+
+// SearchIncidentsPage implements the optional storage.IncidentSearchPager
+// capability: one bounded, newest-first page of matches with the query, the
+// origin filter and LIMIT/OFFSET all in SQL. An empty query is the plain
+// list page.
+func (p *sqliteProvider) SearchIncidentsPage(query, origin string, offset, limit int) ([]*IncidentRecord, error) {
+	if limit <= 0 {
+		limit = DefaultIncidentPageSize
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	var (
+		where []string
+		args  []any
+	)
+	q := parseSearchQuery(query)
+	if !q.empty() {
+		pred, qargs := sqliteSearch(q, sqliteSearchColumns, 1)
+		where = append(where, `(`+pred+`)`)
+		args = append(args, qargs...)
+	}
+	if origin == OriginAIDetect || origin == OriginWebhook {
+		args = append(args, origin)
+		where = append(where, fmt.Sprintf(`origin = $%d`, len(args)))
+	}
+	stmt := `SELECT ` + sqliteIncidentColumns + ` FROM vs_incidents`
+	if len(where) > 0 {
+		stmt += ` WHERE ` + strings.Join(where, ` AND `)
+	}
+	stmt += fmt.Sprintf(` ORDER BY created_at DESC LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2)
+	op := "search incidents page"
+	if q.empty() {
+		op = "list incidents page"
+	}
+	return p.queryIncidents(op, stmt, append(args, limit, offset)...)
+}
+
+// SearchAnalyses matches the query against the analysis JSON body, newest
+// first. A field clause matches its value there, as in search_query.go.
+func (p *sqliteProvider) SearchAnalyses(query string, limit int) ([]*AnalysisRecord, error) {
+	q := parseSearchQuery(query)
+	if q.empty() {
+		return p.ListAnalyses(limit)
+	}
+	where, args := sqliteSearch(q.asText(), "data", 1)
+	return p.queryAnalyses("search analyses", fmt.Sprintf(`
+		SELECT data FROM vs_analyses
+		WHERE %s
+		ORDER BY requested_at DESC
+		LIMIT $%d`, where, len(args)+1), append(args, sqliteLimit(limit))...)
+}
+
+// ListIncidentsInRange implements storage.RangeLister: the incidents created
+// in [start, end), newest first, served by the created_at index. A zero end
+// is an open upper bound.
+func (p *sqliteProvider) ListIncidentsInRange(start, end time.Time, limit int) ([]*IncidentRecord, error) {
+	return p.queryIncidents("list incidents in range", `
+		SELECT `+sqliteIncidentColumns+` FROM vs_incidents
+		WHERE created_at >= $1 AND ($2 IS NULL OR created_at < $2)
+		ORDER BY created_at DESC
+		LIMIT $3`, sqliteTime(start), sqliteRangeEnd(end), sqliteLimit(limit))
+}
+
+// ---------------------------------------------------------------------------
+// Timeline (implements the optional storage.Timeline capability)
+// ---------------------------------------------------------------------------
+
+// AppendTimelineEntry inserts one row only when the incident exists, so an
+// unknown incident maps to ErrNotFound without parsing driver errors. The
+// foreign key still cascades the row away with its incident.
+func (p *sqliteProvider) AppendTimelineEntry(e *TimelineEntry) error {
+	if err := e.validate(); err != nil {
+		return err
+	}
+	data, err := sqliteJSON(len(e.Data), e.Data)
+	if err != nil {
+		return fmt.Errorf("storage: marshal timeline data: %w", err)
+	}
+	res, err := p.db.Exec(`
+		INSERT INTO vs_incident_timeline (id, org_id, incident_id, kind, actor, body, data, created_at)
+		SELECT $1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), $7, $8
+		WHERE EXISTS (SELECT 1 FROM vs_incidents WHERE id = $3)
+	`, e.ID, e.OrgID, e.IncidentID, e.Kind, e.Actor, e.Body, data, sqliteTime(e.CreatedAt))
+	if err != nil {
+		return fmt.Errorf("storage: append timeline entry: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListTimeline selects the newest limit rows and flips them back to
+// chronological order; rowid breaks ties in insertion order.
+func (p *sqliteProvider) ListTimeline(incidentID string, limit int) ([]*TimelineEntry, error) {
+	if limit <= 0 {
+		limit = DefaultTimelineLimit
+	}
+	rows, err := p.db.Query(`
+		SELECT id, org_id, incident_id, kind, COALESCE(actor, ''), COALESCE(body, ''), data, created_at
+		FROM (
+			SELECT *, rowid AS seq FROM vs_incident_timeline
+			WHERE incident_id = $1
+			ORDER BY created_at DESC, seq DESC
+			LIMIT $2
+		) newest
+		ORDER BY created_at ASC, seq ASC
+	`, incidentID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("storage: list timeline: %w", err)
+	}
+	defer rows.Close()
+	out := make([]*TimelineEntry, 0)
+	for rows.Next() {
+		var (
+			e       TimelineEntry
+			raw     []byte
+			created string
+		)
+		if err := rows.Scan(&e.ID, &e.OrgID, &e.IncidentID, &e.Kind, &e.Actor, &e.Body, &raw, &created); err != nil {
+			return nil, fmt.Errorf("storage: scan timeline row: %w", err)
+		}
+		if e.Data, err = unmarshalIncidentContent(raw); err != nil {
+			return nil, fmt.Errorf("storage: unmarshal timeline data: %w", err)
+		}
+		if e.CreatedAt, err = time.Parse(sqliteTimeLayout, created); err != nil {
+			return nil, fmt.Errorf("storage: decode timeline created_at: %w", err)
+		}
+		out = append(out, &e)
+	}
+	return out, rows.Err()
+}
+
+func (p *sqliteProvider) Close() error {
+	return p.db.Close()
+}
+
+// ---------------------------------------------------------------------------
+// Lifecycle (implements the optional storage.Lifecycle and
+// storage.LifecycleCounter capabilities)
+// ---------------------------------------------------------------------------
+
+// sqliteLifecycleWhere is the purge predicate per domain, over $1 = cutoff.
+// Table names are constants, never caller input.
+var sqliteLifecycleWhere = map[string]struct{ table, where string }{
+	DomainIncidents:         {"vs_incidents", `created_at < $1`},
+	DomainResolvedIncidents: {"vs_incidents", `resolved AND COALESCE(resolved_at, created_at) < $1`},
+	DomainAnalyses:          {"vs_analyses", `requested_at < $1`},
+	DomainBlobs:             {"vs_blobs", `updated_at < $1`},
+}
+
+func (p *sqliteProvider) PurgeOlderThan(domain string, cutoff time.Time) (int, error) {
+	d, ok := sqliteLifecycleWhere[domain]
+	if !ok {
+		return 0, ErrUnknownDomain
+	}
+	res, err := p.db.Exec(fmt.Sprintf(`DELETE FROM %s WHERE %s`, d.table, d.where), sqliteTime(cutoff))
+	if err != nil {
+		return 0, fmt.Errorf("storage: purge %s: %w", domain, err)
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+// CountOlderThan reports what PurgeOlderThan would delete.
+func (p *sqliteProvider) CountOlderThan(domain string, cutoff time.Time) (int, error) {
+	d, ok := sqliteLifecycleWhere[domain]
+	if !ok {
+		return 0, ErrUnknownDomain
+	}
+	var n int
+	if err := p.db.QueryRow(fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE %s`, d.table, d.where), sqliteTime(cutoff)).Scan(&n); err != nil {
+		return 0, fmt.Errorf("storage: count %s: %w", domain, err)
+	}
+	return n, nil
+}
+
+func (p *sqliteProvider) DeleteByID(domain, id string) error {
+	var q string
+	switch domain {
+	case DomainIncidents:
+		q = `DELETE FROM vs_incidents WHERE id = $1`
+	case DomainAnalyses:
+		q = `DELETE FROM vs_analyses WHERE id = $1`
+	case DomainBlobs:
+		q = `DELETE FROM vs_blobs WHERE name = $1`
+	default:
+		return ErrUnknownDomain
+	}
+	res, err := p.db.Exec(q, id)
+	if err != nil {
+		return fmt.Errorf("storage: delete %s %q: %w", domain, id, err)
+	}
+	n, _ := res.RowsAffected()
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+-- 001_init.sql — Versus Incident SQLite schema.
+--
+-- The SQLite twin of the Postgres set, collapsed into its final shape: every
+-- IncidentRecord property has its own column on vs_incidents, analyses keep
+-- their JSON document, blobs share one table, and the timeline cascades with
+-- its incident. SQLite has no array, JSONB or timestamptz types, so:
+--   - string slices, labels, custom fields and content are JSON text;
+--   - timestamps are UTC text in a fixed-width RFC 3339 layout with
+--     nanoseconds (sqliteTimeLayout), which sorts and compares correctly as
+--     plain strings;
+--   - booleans are 0/1 integers.
+--
+-- Ledger-tracked by RunSQLMigrations, so this file runs exactly once.
+
+CREATE TABLE vs_blobs (
+    name       TEXT PRIMARY KEY,
+    data       BLOB NOT NULL,
+    updated_at TEXT NOT NULL
+);
+
+CREATE INDEX idx_blobs_updated_at ON vs_blobs (updated_at);
+
+CREATE TABLE vs_incidents (
+    id                  TEXT    PRIMARY KEY,
+    created_at          TEXT    NOT NULL,
+    acked_at            TEXT,
+    org_id              TEXT    NOT NULL DEFAULT 'default',
+    team_id             TEXT,
+    title               TEXT,
+    source              TEXT,
+    service             TEXT,
+    origin              TEXT,
+    resolved            INTEGER NOT NULL DEFAULT 0,
+    channels_enabled    TEXT,
+    channels_notified   TEXT,
+    oncall_triggered    INTEGER,
+    oncall_error        TEXT,
+    notify_status       TEXT,
+    notify_error        TEXT,
+    resolved_at         TEXT,
+    content             TEXT,
+    assigned_team_id    TEXT,
+    assigned_member_ids TEXT,
+    status              TEXT,
+    snoozed_until       TEXT,
+    severity            TEXT,
+    priority            TEXT,
+    labels              TEXT,
+    tags                TEXT,
+    custom_fields       TEXT,
+    merged_into         TEXT,
+    parent_id           TEXT,
+    related_ids         TEXT
+);
+
+CREATE INDEX idx_incidents_created_at
+    ON vs_incidents (created_at DESC);
+CREATE INDEX idx_incidents_origin_created_at
+    ON vs_incidents (origin, created_at DESC);
+CREATE INDEX idx_incidents_unresolved_created_at
+    ON vs_incidents (created_at DESC) WHERE resolved = 0;
+CREATE INDEX idx_incidents_merged_into
+    ON vs_incidents (merged_into) WHERE merged_into IS NOT NULL;
+CREATE INDEX idx_incidents_parent_id
+    ON vs_incidents (parent_id) WHERE parent_id IS NOT NULL;
+
+CREATE TABLE vs_analyses (
+    id           TEXT PRIMARY KEY,
+    incident_id  TEXT NOT NULL,
+    data         TEXT NOT NULL,
+    requested_at TEXT NOT NULL
+);
+
+CREATE INDEX idx_analyses_incident_id  ON vs_analyses (incident_id);
+CREATE INDEX idx_analyses_requested_at ON vs_analyses (requested_at DESC);
+
+CREATE TABLE vs_incident_timeline (
+    id          TEXT PRIMARY KEY,
+    org_id      TEXT NOT NULL DEFAULT 'default',
+    incident_id TEXT NOT NULL REFERENCES vs_incidents (id) ON DELETE CASCADE,
+    kind        TEXT NOT NULL,
+    actor       TEXT,
+    body        TEXT,
+    data        TEXT,
+    created_at  TEXT NOT NULL
+);
+
+CREATE INDEX idx_incident_timeline_incident_created_at
+    ON vs_incident_timeline (incident_id, created_at DESC);
@@ -0,0 +1,217 @@
+package storage_test
+
+// sqlite_test.go — the SQLite backend. It is pure Go and needs no server,
+// so unlike Postgres and redis it runs unconditionally: the shared CRUD,
+// blob, create-if-absent, timeline, column round-trip and bulk suites, plus
+// search, range, lifecycle and reopen checks.
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/storage"
+)
+
+func newTestSQLite(t *testing.T) storage.Provider {
+	t.Helper()
+	p, err := storage.NewSQLite(storage.SQLiteOptions{Path: filepath.Join(t.TempDir(), "versus.db")})
+	if err != nil {
+		t.Fatalf("NewSQLite: %v", err)
+	}
+	t.Cleanup(func() { _ = p.Close() })
+	return p
+}
+
+func TestSQLiteIncidentCRUD(t *testing.T)            { runIncidentCRUD(t, newTestSQLite(t)) }
+func TestSQLiteAnalysisCRUD(t *testing.T)            { runAnalysisCRUD(t, newTestSQLite(t)) }
+func TestSQLiteBlobCRUD(t *testing.T)                { runBlobCRUD(t, newTestSQLite(t)) }
+func TestSQLiteBlobListing(t *testing.T)             { runBlobListing(t, newTestSQLite(t)) }
+func TestSQLiteCreateIfAbsent(t *testing.T)          { runCreateIfAbsent(t, newTestSQLite(t)) }
+func TestSQLiteCreateRace(t *testing.T)              { runCreateRace(t, newTestSQLite(t)) }
+func TestSQLiteBulkIncidents(t *testing.T)           { testBulkIncidents(t, newTestSQLite(t)) }
+func TestSQLiteTimeline(t *testing.T)                { runTimeline(t, newTestSQLite(t)) }
+func TestSQLiteIncidentColumnRoundTrip(t *testing.T) { runIncidentColumnRoundTrip(t, newTestSQLite(t)) }
+func TestSQLiteUnresolvedCount(t *testing.T)         { runUnresolvedCount(t, newTestSQLite(t)) }
+
+// TestDatabaseDriverSQLite routes storage.type=database through the sqlite
+// driver and rejects the drivers that are not implemented.
+func TestDatabaseDriverSQLite(t *testing.T) {
+	p, err := storage.New(storage.Config{
+		Type:     "database",
+		Database: storage.DatabaseOptions{Driver: "sqlite", DSN: filepath.Join(t.TempDir(), "sub", "v.db")},
+	})
+	if err != nil {
+		t.Fatalf("New(database/sqlite): %v", err)
+	}
+	_ = p.Close()
+	if _, ok := p.(storage.Searcher); !ok {
+		t.Fatal("sqlite backend must implement storage.Searcher")
+	}
+	if _, ok := p.(storage.SQLAccessor); ok {
+		t.Fatal("sqlite backend must not implement storage.SQLAccessor (it selects the Postgres-only paths)")
+	}
+	for _, driver := range []string{"mysql", "postgres"} {
+		_, err := storage.NewDatabase(storage.DatabaseOptions{Driver: driver})
+		if !errors.Is(err, storage.ErrUnsupported) {
+			t.Errorf("driver %s: err = %v, want ErrUnsupported", driver, err)
+		}
+	}
+}
+
+// TestSQLiteReopen proves history, timeline and blobs survive a restart and
+// the migrations do not re-run over existing tables.
+func TestSQLiteReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "versus.db")
+	p, err := storage.NewSQLite(storage.SQLiteOptions{Path: path})
+	if err != nil {
+		t.Fatalf("NewSQLite: %v", err)
+	}
+	_ = p.SaveIncident(&storage.IncidentRecord{ID: "inc-1", Title: "disk full", CreatedAt: time.Now().UTC()})
+	_ = p.(storage.Timeline).AppendTimelineEntry(&storage.TimelineEntry{ID: "n-1", IncidentID: "inc-1", Kind: storage.TimelineNote, Body: "looking"})
+	_ = p.WriteBlob("teams", []byte(`{"t":1}`))
+	_ = p.Close()
+
+	p2, err := storage.NewSQLite(storage.SQLiteOptions{Path: path})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer p2.Close()
+	if got, err := p2.GetIncident("inc-1"); err != nil || got.Title != "disk full" {
+		t.Fatalf("GetIncident after reopen = %+v, %v", got, err)
+	}
+	if got, _ := p2.(storage.Timeline).ListTimeline("inc-1", 0); len(got) != 1 || got[0].Body != "looking" {
+		t.Fatalf("timeline after reopen = %+v", got)
+	}
+	if got, _ := p2.ReadBlob("teams"); string(got) != `{"t":1}` {
+		t.Fatalf("blob after reopen = %q", got)
+	}
+}
+
+// TestSQLiteHistoryUnbounded checks there is no file-style rolling cap.
+func TestSQLiteHistoryUnbounded(t *testing.T) {
+	p := newTestSQLite(t)
+	base := time.Now().UTC().Add(-time.Hour)
+	n := storage.MaxIncidentsDefault + 5
+	for i := 0; i < n; i++ {
+		rec := &storage.IncidentRecord{ID: fmt.Sprintf("i-%d", i), CreatedAt: base.Add(time.Duration(i) * time.Millisecond)}
+		if err := p.SaveIncident(rec); err != nil {
+			t.Fatalf("SaveIncident: %v", err)
+		}
+	}
+	all, err := p.ListIncidents(0)
+	if err != nil {
+		t.Fatalf("ListIncidents: %v", err)
+	}
+	if len(all) != n {
+		t.Fatalf("kept %d incidents, want %d", len(all), n)
+	}
+}
+
+// TestSQLiteSearch covers storage.Searcher and storage.IncidentSearchPager.
+func TestSQLiteSearch(t *testing.T) {
+	p := newTestSQLite(t)
+	seedPagerRecords(t, p)
+	now := time.Now().UTC()
+	_ = p.SaveIncident(&storage.IncidentRecord{ID: "s-1", Title: "Database pool exhausted", Service: "payments-api", Tags: []string{"db"}, CreatedAt: now})
+
+	searcher := p.(storage.Searcher)
+	got, err := searcher.SearchIncidents("PAYMENTS", 0)
+	if err != nil {
+		t.Fatalf("SearchIncidents: %v", err)
+	}
+	if len(got) != 1 || got[0].ID != "s-1" {
+		t.Fatalf("SearchIncidents(PAYMENTS) = %v, want [s-1]", incidentIDs(got))
+	}
+	// The shared syntax, matched with LIKE.
+	for query, want := range map[string]int{
+		`"pool exhausted"`:       1,
+		"service:payments-api":   1,
+		"service:payments":       0,
+		"incident -source:agent": 5,
+		"incident source:sqs":    1,
+		`-"incident" -"pool"`:    0,
+		`severity:critical`:      0,
+	} {
+		got, err := searcher.SearchIncidents(query, 0)
+		if err != nil {
+			t.Fatalf("SearchIncidents(%s): %v", query, err)
+		}
+		if len(got) != want {
+			t.Errorf("SearchIncidents(%s) = %v, want %d matches", query, incidentIDs(got), want)
+		}
+	}
+
+	sp := p.(storage.IncidentSearchPager)
+	counts, err := sp.CountIncidentsMatching("incident")
+	if err != nil {
+		t.Fatalf("CountIncidentsMatching: %v", err)
+	}
+	if counts.Total != 8 || counts.AIDetect != 4 {
+		t.Fatalf("matching counts = %+v, want total 8 / ai 4", counts)
+	}
+	byStatus, err := sp.CountIncidentsMatchingByStatus("incident")
+	if err != nil {
+		t.Fatalf("CountIncidentsMatchingByStatus: %v", err)
+	}
+	assertStatusInvariants(t, byStatus)
+	page, err := sp.SearchIncidentsPage("incident", storage.OriginAIDetect, 1, 2)
+	if err != nil {
+		t.Fatalf("SearchIncidentsPage: %v", err)
+	}
+	if len(page) != 2 {
+		t.Fatalf("search page = %d rows, want 2", len(page))
+	}
+	for _, r := range page {
+		if r.EffectiveOrigin() != storage.OriginAIDetect {
+			t.Errorf("search page holds %s origin row %s", r.EffectiveOrigin(), r.ID)
+		}
+	}
+}
+
+// TestSQLiteRangeAndLifecycle covers storage.RangeLister, the Lifecycle
+// purge and count, and the timeline cascade on delete.
+func TestSQLiteRangeAndLifecycle(t *testing.T) {
+	p := newTestSQLite(t)
+	now := time.Now().UTC()
+	resolvedAt := now.Add(-30 * time.Hour)
+	for _, rec := range []*storage.IncidentRecord{
+		{ID: "old", CreatedAt: now.Add(-48 * time.Hour)},
+		{ID: "old-resolved", CreatedAt: now.Add(-40 * time.Hour), Resolved: true, ResolvedAt: &resolvedAt},
+		{ID: "fresh", CreatedAt: now.Add(-time.Hour)},
+	} {
+		if err := p.SaveIncident(rec); err != nil {
+			t.Fatalf("SaveIncident: %v", err)
+		}
+	}
+	_ = p.(storage.Timeline).AppendTimelineEntry(&storage.TimelineEntry{ID: "a", IncidentID: "old", Kind: storage.TimelineNote})
+
+	got, err := p.(storage.RangeLister).ListIncidentsInRange(now.Add(-45*time.Hour), time.Time{}, 0)
+	if err != nil {
+		t.Fatalf("ListIncidentsInRange: %v", err)
+	}
+	if ids := incidentIDs(got); len(ids) != 2 || ids[0] != "fresh" || ids[1] != "old-resolved" {
+		t.Fatalf("range = %v, want [fresh old-resolved]", ids)
+	}
+
+	cutoff := now.Add(-24 * time.Hour)
+	if n, err := p.(storage.LifecycleCounter).CountOlderThan(storage.DomainResolvedIncidents, cutoff); err != nil || n != 1 {
+		t.Fatalf("CountOlderThan(resolved) = %d, %v; want 1", n, err)
+	}
+	lc := p.(storage.Lifecycle)
+	if n, err := lc.PurgeOlderThan(storage.DomainIncidents, cutoff); err != nil || n != 2 {
+		t.Fatalf("PurgeOlderThan = %d, %v; want 2", n, err)
+	}
+	_ = p.SaveIncident(&storage.IncidentRecord{ID: "old", CreatedAt: now})
+	if tl, _ := p.(storage.Timeline).ListTimeline("old", 0); len(tl) != 0 {
+		t.Fatalf("timeline outlived its incident: %+v", tl)
+	}
+	if _, err := lc.PurgeOlderThan("nope", cutoff); !errors.Is(err, storage.ErrUnknownDomain) {
+		t.Fatalf("unknown domain err = %v", err)
+	}
+	if err := lc.DeleteByID(storage.DomainIncidents, "missing"); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("DeleteByID(missing) err = %v", err)
+	}
+}
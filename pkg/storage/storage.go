@@ -16,7 +16,7 @@
 // Backends today:
 //   - file (pkg/storage/file.go) — production
 //   - memory (pkg/storage/memory.go) — tests only
-//   - redis (pkg/storage/redis.go) — config stub, returns ErrUnsupported
+//   - redis (pkg/storage/redis.go) — production, shares the on-call Redis
 //   - database (pkg/storage/database.go) — config stub
 package storage
 
@@ -36,7 +36,8 @@ import (
 // callers can rely on errors.Is(err, storage.ErrNotFound).
 var ErrNotFound = errors.New("storage: not found")
 
-// ErrUnsupported is returned by the redis/database stub backends, and by
+// ErrUnsupported is returned by the database backend for a driver other
+// than sqlite, and by
 // the model-state purge path when the configured backend does not
 // implement the optional storage.Lifecycle capability.
 var ErrUnsupported = errors.New("storage: backend not implemented")
@@ -58,6 +59,11 @@ const (
 	// the learned model-state artifacts under the models/ namespace;
 	// age is compared against the blob's updated_at.
 	DomainBlobs = "blobs"
+	// DomainResolvedIncidents targets resolved incidents only; age is
+	// compared against resolved_at, or created_at when a record was
+	// resolved in its payload and has none. Purge-only: DeleteByID does
+	// not accept it.
+	DomainResolvedIncidents = "resolved_incidents"
 )
 
 // DefaultDataDir is the application's persistent data directory. The
@@ -214,20 +220,73 @@ type IncidentCounts struct {
 	Total    int
 }
 
-// StatusCounts is the per-status split of a set of incidents: how many are
-// open, acked, and resolved, with Total the sum of the three. The buckets are
-// mutually exclusive and match both how the UI labels an incident and how the
-// row is stored:
+// StatusCounts is the per-status split of a set of incidents. Each lifecycle
+// state has its own bucket, classified via EffectiveStatus so legacy rows and
+// lapsed snoozes land where the UI shows them. The buckets are mutually
+// exclusive and sum to Total.
 //
-//	Open     = resolved = false AND acked_at IS NULL
-//	Acked    = resolved = false AND acked_at IS NOT NULL
-//	Resolved = resolved = true
+// Open and Acked are the coarse rollups the count surfaces have always shown,
+// kept so existing consumers read the same shape:
+//
+//	Open     = Triggered + Reopened + Snoozed (unresolved, nobody engaged)
+//	Acked    = Acknowledged + Investigating + Mitigated (unresolved, engaged)
+//	Resolved = resolved
 //	Total    = Open + Acked + Resolved (every row)
 type StatusCounts struct {
 	Open     int
 	Acked    int
 	Resolved int
 	Total    int
+
+	Triggered     int
+	Acknowledged  int
+	Investigating int
+	Mitigated     int
+	Reopened      int
+	Snoozed       int
+}
+
+// add counts n incidents in state st, maintaining the rollups.
+func (c *StatusCounts) add(st string, n int) {
+	switch st {
+	case StatusResolved:
+		c.Resolved += n
+	case StatusAcknowledged:
+		c.Acknowledged += n
+		c.Acked += n
+	case StatusInvestigating:
+		c.Investigating += n
+		c.Acked += n
+	case StatusMitigated:
+		c.Mitigated += n
+		c.Acked += n
+	case StatusReopened:
+		c.Reopened += n
+		c.Open += n
+	case StatusSnoozed:
+		c.Snoozed += n
+		c.Open += n
+	default:
+		c.Triggered += n
+		c.Open += n
+	}
+	c.Total += n
+}
+
+// minus returns c − o bucket by bucket.
+func (c StatusCounts) minus(o StatusCounts) StatusCounts {
+	return StatusCounts{
+		Open:          c.Open - o.Open,
+		Acked:         c.Acked - o.Acked,
+		Resolved:      c.Resolved - o.Resolved,
+		Total:         c.Total - o.Total,
+		Triggered:     c.Triggered - o.Triggered,
+		Acknowledged:  c.Acknowledged - o.Acknowledged,
+		Investigating: c.Investigating - o.Investigating,
+		Mitigated:     c.Mitigated - o.Mitigated,
+		Reopened:      c.Reopened - o.Reopened,
+		Snoozed:       c.Snoozed - o.Snoozed,
+	}
 }
 
 // IncidentStatusCounts is the whole-set per-origin × per-status tally the
@@ -247,30 +306,22 @@ type IncidentStatusCounts struct {
 
 // StatusCountsOf tallies a materialized set of records into the per-origin ×
 // per-status breakdown, classifying each row via EffectiveOrigin (so legacy
-// empty-origin rows land in webhook) and bucketing by its stored status. The
-// file and memory backends use it over their capped in-memory slice, and the
-// controllers' fallback path (a backend with no bounded pager) uses it over
-// the materialized window, so every path produces the identical shape.
+// empty-origin rows land in webhook) and EffectiveStatus (so legacy rows and
+// lapsed snoozes land in their derived state). The file and memory backends
+// use it over their capped in-memory slice, and the controllers' fallback
+// path (a backend with no bounded pager) uses it over the materialized
+// window, so every path produces the identical shape.
 func StatusCountsOf(recs []*IncidentRecord) IncidentStatusCounts {
 	var out IncidentStatusCounts
+	now := time.Now()
 	for _, rec := range recs {
 		bucket := &out.Webhook
 		if rec.EffectiveOrigin() == OriginAIDetect {
 			bucket = &out.AIDetect
 		}
-		switch {
-		case rec.Resolved:
-			bucket.Resolved++
-			out.Total.Resolved++
-		case rec.AckedAt != nil:
-			bucket.Acked++
-			out.Total.Acked++
-		default:
-			bucket.Open++
-			out.Total.Open++
-		}
-		bucket.Total++
-		out.Total.Total++
+		st := rec.EffectiveStatus(now)
+		bucket.add(st, 1)
+		out.Total.add(st, 1)
 	}
 	return out
 }
@@ -282,24 +333,18 @@ func StatusCountsOf(recs []*IncidentRecord) IncidentStatusCounts {
 // backends compute `total` and `ai_detect` with COUNT/FILTER and hand them
 // here rather than counting webhook separately.
 func AssembleStatusCounts(total, ai StatusCounts) IncidentStatusCounts {
-	web := StatusCounts{
-		Open:     total.Open - ai.Open,
-		Acked:    total.Acked - ai.Acked,
-		Resolved: total.Resolved - ai.Resolved,
-		Total:    total.Total - ai.Total,
-	}
-	return IncidentStatusCounts{AIDetect: ai, Webhook: web, Total: total}
+	return IncidentStatusCounts{AIDetect: ai, Webhook: total.minus(ai), Total: total}
 }
 
 // IncidentPager is an optional capability a backend may implement on top of
 // Provider to serve the incident list without ever loading the whole table.
 // It splits the two things the list endpoint needs — a cheap count and a
-// bounded page — so a large backend (Postgres, unbounded history) can push
+// bounded page — so a large backend (Postgres or SQLite, unbounded history) can push
 // both into SQL: the per-origin tally becomes a COUNT with FILTER and a page
 // becomes ORDER BY created_at DESC LIMIT/OFFSET. File and memory backends
 // implement it over their already-capped in-memory slice (a len()/map tally
-// and a slice window), so callers get one uniform seam. Backends that cannot
-// (the redis stub) do not implement it; callers type-assert and fall back to
+// and a slice window), so callers get one uniform seam. A backend that cannot
+// does not implement it; callers type-assert and fall back to
 // ListIncidents, exactly like Searcher/Lifecycle.
 type IncidentPager interface {
 	// CountIncidents returns the whole-set per-origin tally and grand total of
@@ -334,9 +379,9 @@ type IncidentPager interface {
 // (Postgres, unbounded vs_analyses) pushes both into SQL (COUNT(*) and ORDER
 // BY requested_at DESC LIMIT/OFFSET). File and memory backends implement it
 // over their already-capped in-memory slice (a len() count and a slice
-// window). Backends that cannot (the redis/database stubs) do not implement
-// it; callers type-assert and fall back to a bounded ListAnalyses, exactly
-// like IncidentPager.
+// window). A backend that cannot does not implement it; callers
+// type-assert and fall back to a bounded ListAnalyses, exactly like
+// IncidentPager.
 type AnalysisPager interface {
 	// CountAnalyses returns the total number of stored analyses, computed
 	// without materializing rows.
@@ -352,9 +397,10 @@ type AnalysisPager interface {
 // top of Searcher: the search-path twin of IncidentPager. It lets a backend
 // count and page full-text search matches without materializing the whole
 // match set, so a broad query against a large history returns the first page
-// in one bounded query plus one count query. Only the Postgres backend
-// implements it (it is the only unbounded Searcher); callers type-assert and
-// fall back to a bounded SearchIncidents when the assertion fails.
+// in one bounded query plus one count query. Every Searcher implements it:
+// Postgres and SQLite in SQL, file and memory over their capped history.
+// Callers type-assert and fall back to a bounded SearchIncidents when the
+// assertion fails.
 type IncidentSearchPager interface {
 	// CountIncidentsMatching returns the per-origin tally and total of
 	// UNRESOLVED (open) incidents matching query, computed without
@@ -370,7 +416,8 @@ type IncidentSearchPager interface {
 	// too, never a tally of the loaded page.
 	CountIncidentsMatchingByStatus(query string) (IncidentStatusCounts, error)
 	// SearchIncidentsPage returns one bounded page of incidents matching
-	// query, newest first, filtered to origin (empty = all origins), skipping
+	// query, best match first where the backend ranks and newest first
+	// otherwise and among equals, filtered to origin (empty = all origins), skipping
 	// offset rows and returning at most limit rows. The page lists ALL
 	// matching incidents (resolved and open alike); only the counts are
 	// unresolved-only. An empty query degrades to the plain list page.
@@ -379,18 +426,21 @@ type IncidentSearchPager interface {
 }
 
 // Searcher is an optional capability a backend may implement on top of
-// Provider. It exposes full-text-style search over incidents and
-// analyses. Backends that cannot search efficiently (memory, file) do
-// not implement it; callers type-assert and fall back to ListIncidents
-// when the assertion fails. The Postgres backend implements it.
+// Provider. It exposes full-text search over incidents and analyses, with
+// the query syntax of search_query.go (words, "phrases", service:, source:,
+// severity: and -exclusions). The Postgres, SQLite, file and memory backends
+// implement it; Redis does not, and callers type-assert and fall back to
+// ListIncidents when the assertion fails.
 type Searcher interface {
 	// SearchIncidents returns incidents whose title, service, source,
-	// or JSON body match the case-insensitive query, newest first.
+	// severity, priority, tags, labels, custom fields or JSON body match
+	// the case-insensitive query, best match first where the backend ranks
+	// (Postgres, file, memory) and newest first otherwise and among equals.
 	// An empty query returns the most recent incidents (same as
 	// ListIncidents). limit <= 0 returns the full window.
 	SearchIncidents(query string, limit int) ([]*IncidentRecord, error)
 	// SearchAnalyses returns analyses whose JSON body matches the
-	// case-insensitive query, newest first. limit <= 0 returns the
+	// case-insensitive query, in the same order as SearchIncidents. limit <= 0 returns the
 	// full window.
 	SearchAnalyses(query string, limit int) ([]*AnalysisRecord, error)
 }
@@ -415,10 +465,10 @@ type RangeLister interface {
 // Provider. It is a mechanical, tier-neutral delete primitive: it
 // carries NO org or policy concept — the caller decides what to purge and
 // when. The enterprise retention policy engine consumes it; single-tenant
-// OSS may call it directly. Backends that cannot delete efficiently (file,
-// redis stub) do not implement it; callers type-assert and treat a failed
-// assertion as "purge unsupported" rather than silently succeeding. The
-// Postgres and memory backends implement it.
+// OSS may call it directly. Backends that cannot delete efficiently (file)
+// do not implement it; callers type-assert and treat a failed assertion as
+// "purge unsupported" rather than silently succeeding. The Postgres, SQLite,
+// redis and memory backends implement it.
 type Lifecycle interface {
 	// PurgeOlderThan deletes every record in domain whose natural age
 	// column is strictly older than cutoff, returning the number deleted.
@@ -433,6 +483,14 @@ type Lifecycle interface {
 	DeleteByID(domain, id string) error
 }
 
+// LifecycleCounter is an optional capability a backend may implement on top
+// of Lifecycle: CountOlderThan reports how many records PurgeOlderThan would
+// delete for the same domain and cutoff, without deleting anything. The
+// retention dry run uses it; a backend without it cannot preview a purge.
+type LifecycleCounter interface {
+	CountOlderThan(domain string, cutoff time.Time) (int, error)
+}
+
 // BlobCreator is an optional capability a backend may implement on top of
 // Provider. It adds a single atomic create-if-absent blob write
 // used to elect ONE writer across multiple instances that share a store —
@@ -442,14 +500,14 @@ type Lifecycle interface {
 // concept and never overwrites an existing blob, so it is strictly additive
 // and does not weaken WriteBlob's last-write-wins semantics.
 //
-// Backends that cannot create atomically (the redis/database stubs) do not
-// implement it; callers type-assert and treat a failed assertion as
-// "unsupported", exactly like Lifecycle/Searcher. The Postgres (shared,
-// multi-writer) and memory (tests) backends implement it because they are
-// the HA substrate and the test path; the file backend implements it
-// best-effort via O_CREATE|O_EXCL, which is coherent only on a single node —
-// the only place file storage is allowed under HA (see the
-// file-storage guard).
+// Backends that cannot create atomically do not implement it; callers
+// type-assert and treat a failed assertion as "unsupported", exactly like
+// Lifecycle/Searcher. The Postgres (shared, multi-writer) and memory (tests)
+// backends implement it because they are the HA substrate and the test path,
+// redis and SQLite implement it natively (SETNX, ON CONFLICT DO NOTHING), and
+// the file backend implements it best-effort via O_CREATE|O_EXCL, which is
+// coherent only on a single node — the only place file storage is allowed
+// under HA (see the file-storage guard).
 type BlobCreator interface {
 	// CreateBlobIfAbsent atomically writes data under key only if key does
 	// not already exist. It returns written==true when THIS call created the
@@ -470,7 +528,7 @@ type BlobCreator interface {
 // and typed queries on the SAME connection pool the Provider already owns,
 // instead of opening a second pool.
 //
-// Only the Postgres backend implements it; file / memory / redis do not, so
+// Only the Postgres backend implements it; file / memory / redis / SQLite do not, so
 // a type assertion `store.(storage.SQLAccessor)` IS the backend-type switch
 // the boot path selects on (Postgres ⇒ typed signal tables; anything else ⇒
 // the inline whole-blob path). It carries no tier or table knowledge: OSS
@@ -486,6 +544,15 @@ type SQLAccessor interface {
 	DB() *sql.DB
 }
 
+// KeyringHolder is an optional capability a backend may implement on top of
+// Provider: it hands out the keyring the backend seals with, so a store that
+// writes to the backend's tables outside Provider seals the same way. Only
+// the Postgres backend implements it, for the catalog store's pattern
+// samples. Keyring returns nil when encryption at rest is off.
+type KeyringHolder interface {
+	Keyring() *Keyring
+}
+
 // IncidentRecord is the durable shape of an incident. It mirrors the
 // runtime models.Incident plus the audit fields the UI needs (when it
 // happened, who got notified, was it acked, raw payload for debugging).
@@ -508,6 +575,14 @@ type IncidentRecord struct {
 	// miscounted.
 	Origin   string `json:"origin,omitempty"`
 	Resolved bool   `json:"resolved"`
+	// Status is the lifecycle state (StatusTriggered, StatusAcknowledged,
+	// ...). It is moved only through Transition, which keeps Resolved,
+	// ResolvedAt and AckedAt in step. Legacy records persisted before this
+	// field are empty on disk; EffectiveStatus derives a value from the
+	// legacy fields.
+	Status string `json:"status,omitempty"`
+	// SnoozedUntil is the wake-up time of a snoozed incident; nil otherwise.
+	SnoozedUntil *time.Time `json:"snoozed_until,omitempty"`
 	// ChannelsEnabled is the snapshot of channels that were configured
 	// when the alert fired. ChannelsNotified is the subset that
 	// actually succeeded. The two diverge whenever a channel fails:
@@ -535,6 +610,24 @@ type IncidentRecord struct {
 	// holds the references. Empty means unassigned.
 	AssignedTeamID    string   `json:"assigned_team_id,omitempty"`
 	AssignedMemberIDs []string `json:"assigned_member_ids,omitempty"`
+
+	// Severity is the operator-set severity; empty means "whatever the
+	// payload says" (see SeverityLabel). Priority is P1-P5 or empty. Labels,
+	// Tags and CustomFields are operator metadata for filtering and search.
+	// All five are edited only through the admin API and normalized by the
+	// Normalize* helpers in incident_fields.go.
+	Severity     string            `json:"severity,omitempty"`
+	Priority     string            `json:"priority,omitempty"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	Tags         []string          `json:"tags,omitempty"`
+	CustomFields []CustomField     `json:"custom_fields,omitempty"`
+
+	// MergedInto, ParentID and RelatedIDs relate this incident to others;
+	// see incident_relations.go. MergedInto is set on a closed duplicate,
+	// ParentID on a child, and RelatedIDs on both sides of a link.
+	MergedInto string   `json:"merged_into,omitempty"`
+	ParentID   string   `json:"parent_id,omitempty"`
+	RelatedIDs []string `json:"related_ids,omitempty"`
 }
 
 // EffectiveOrigin returns the record's explicit Origin, or derives one
@@ -593,6 +686,10 @@ type AnalysisRecord struct {
 	// Status is one of: "ok", "error", "rate_limited".
 	Status string `json:"status"`
 	Error  string `json:"error,omitempty"`
+
+	// Sealed is ToolCalls, Finding and RawResponse encrypted at rest (see
+	// encryption.go). Backends open it on read, so callers never see it set.
+	Sealed string `json:"sealed,omitempty"`
 }
 
 // AnalysisToolCall captures one tool round-trip for the audit log.
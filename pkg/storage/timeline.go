@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// Timeline entry kinds. Notes and comments are written by operators through
+// the admin API; every other kind is emitted by the server as the incident
+// moves through its lifecycle.
+const (
+	// TimelineNote is an operator's working note on the incident.
+	TimelineNote = "note"
+	// TimelineComment is an operator's comment, typically a reply or an
+	// update meant for other responders.
+	TimelineComment = "comment"
+	// TimelineStatusChange records an ack or resolve, with the actor.
+	TimelineStatusChange = "status_change"
+	// TimelineAssignment records a change of assigned team/members.
+	TimelineAssignment = "assignment"
+	// TimelineRelation records a merge, a related link or a parent change.
+	TimelineRelation = "relation"
+	// TimelineFieldChange records an edit of severity, priority, labels,
+	// tags or custom fields.
+	TimelineFieldChange = "field_change"
+	// TimelineNotification records the outcome of the channel fan-out.
+	TimelineNotification = "notification"
+	// TimelineOnCall records an on-call trigger (or its failure).
+	TimelineOnCall = "oncall"
+	// TimelineAnalysis records an analyze-mode run against the incident.
+	TimelineAnalysis = "analysis"
+)
+
+// IsOperatorTimelineKind reports whether kind may be written directly by an
+// operator. The other kinds are server-emitted so the timeline stays a
+// faithful record of what the system did.
+func IsOperatorTimelineKind(kind string) bool {
+	return kind == TimelineNote || kind == TimelineComment
+}
+
+// DefaultTimelineLimit bounds one timeline read when the caller passes no
+// limit, so a long-running incident never returns an unbounded list.
+const DefaultTimelineLimit = 500
+
+// TimelineEntry is one append-only event on an incident's timeline. Entries
+// are never edited once written; a correction is a new entry.
+type TimelineEntry struct {
+	ID string `json:"id"`
+	// OrgID scopes the entry to one organization. Defaults to
+	// storage.DefaultOrgID ("default"); see IncidentRecord.OrgID.
+	OrgID      string `json:"org_id,omitempty"`
+	IncidentID string `json:"incident_id"`
+	Kind       string `json:"kind"`
+	// Actor is who caused the entry: an operator name for notes, comments
+	// and manual status changes, or a system label ("system", "ack-link")
+	// for server-emitted events.
+	Actor string `json:"actor,omitempty"`
+	// Body is the human-readable text: the note itself, or a one-line
+	// summary of a server-emitted event.
+	Body string `json:"body,omitempty"`
+	// Data carries the structured detail of a server-emitted event (the
+	// from/to status, the channels notified, the analysis id, ...).
+	Data      map[string]interface{} `json:"data,omitempty"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+// validate checks the fields every backend requires and normalizes the org.
+func (e *TimelineEntry) validate() error {
+	if e == nil || e.ID == "" {
+		return fmt.Errorf("storage: AppendTimelineEntry: missing id")
+	}
+	if e.IncidentID == "" {
+		return fmt.Errorf("storage: AppendTimelineEntry: missing incident id")
+	}
+	if e.Kind == "" {
+		return fmt.Errorf("storage: AppendTimelineEntry: missing kind")
+	}
+	e.OrgID = NormalizeOrgID(e.OrgID)
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now().UTC()
+	}
+	e.CreatedAt = e.CreatedAt.UTC()
+	return nil
+}
+
+// Timeline is an optional capability a backend may implement on top of
+// Provider: an append-only, per-incident event log holding operator notes
+// and comments alongside the status, assignment, notification, on-call and
+// analysis events the server records. Every backend implements it;
+// callers still type-assert and treat a failed assertion as "timeline
+// unsupported", exactly like Searcher/Lifecycle.
+type Timeline interface {
+	// AppendTimelineEntry stores one entry. The incident must exist:
+	// an unknown IncidentID returns ErrNotFound. A zero CreatedAt is
+	// stamped with the current time.
+	AppendTimelineEntry(e *TimelineEntry) error
+	// ListTimeline returns the incident's most recent entries, at most
+	// limit of them, in chronological order (oldest first). limit <= 0
+	// uses DefaultTimelineLimit. An incident with no entries returns an
+	// empty slice, not ErrNotFound.
+	ListTimeline(incidentID string, limit int) ([]*TimelineEntry, error)
+}
+
+// newestTimelineWindow returns the last limit entries of a chronological
+// slice, copied so callers cannot mutate backend state.
+func newestTimelineWindow(entries []*TimelineEntry, limit int) []*TimelineEntry {
+	if limit <= 0 {
+		limit = DefaultTimelineLimit
+	}
+	if over := len(entries) - limit; over > 0 {
+		entries = entries[over:]
+	}
+	out := make([]*TimelineEntry, 0, len(entries))
+	for _, e := range entries {
+		cp := *e
+		out = append(out, &cp)
+	}
+	return out
+}
+
+// insertTimelineSorted appends e to a chronological slice, keeping it sorted
+// by CreatedAt. Entries usually arrive in order, so the walk back is short;
+// ties keep append order.
+func insertTimelineSorted(entries []*TimelineEntry, e *TimelineEntry) []*TimelineEntry {
+	entries = append(entries, e)
+	i := len(entries) - 1
+	for i > 0 && entries[i-1].CreatedAt.After(e.CreatedAt) {
+		entries[i] = entries[i-1]
+		i--
+	}
+	entries[i] = e
+	return entries
+}
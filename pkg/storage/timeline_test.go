@@ -0,0 +1,206 @@
+package storage_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VersusControl/versus-incident/pkg/storage"
+)
+
+// runTimeline exercises the optional storage.Timeline capability so every
+// backend appends, orders, windows and rejects orphans identically.
+func runTimeline(t *testing.T, p storage.Provider) {
+	t.Helper()
+	tl, ok := p.(storage.Timeline)
+	if !ok {
+		t.Fatalf("%T does not implement storage.Timeline", p)
+	}
+
+	base := time.Now().UTC().Truncate(time.Millisecond).Add(-time.Hour)
+	for _, id := range []string{"inc-1", "inc-2"} {
+		if err := p.SaveIncident(&storage.IncidentRecord{ID: id, CreatedAt: base}); err != nil {
+			t.Fatalf("SaveIncident(%s): %v", id, err)
+		}
+	}
+
+	// Empty timeline is an empty list, not an error.
+	got, err := tl.ListTimeline("inc-1", 0)
+	if err != nil || len(got) != 0 {
+		t.Fatalf("empty ListTimeline = %v, %v; want [], nil", got, err)
+	}
+
+	// Append out of order; reads come back chronological.
+	for i, kind := range []string{storage.TimelineNote, storage.TimelineStatusChange, storage.TimelineComment} {
+		e := &storage.TimelineEntry{
+			ID:         fmt.Sprintf("tl-%d", i),
+			IncidentID: "inc-1",
+			Kind:       kind,
+			Actor:      "alice",
+			Body:       kind,
+			Data:       map[string]interface{}{"n": float64(i)},
+			CreatedAt:  base.Add(time.Duration(3-i) * time.Minute),
+		}
+		if err := tl.AppendTimelineEntry(e); err != nil {
+			t.Fatalf("AppendTimelineEntry(%s): %v", e.ID, err)
+		}
+	}
+	if err := tl.AppendTimelineEntry(&storage.TimelineEntry{ID: "tl-other", IncidentID: "inc-2", Kind: storage.TimelineNote, CreatedAt: base}); err != nil {
+		t.Fatalf("AppendTimelineEntry(other): %v", err)
+	}
+
+	got, err = tl.ListTimeline("inc-1", 0)
+	if err != nil {
+		t.Fatalf("ListTimeline: %v", err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("ListTimeline len = %d, want 3 (other incidents excluded)", len(got))
+	}
+	if got[0].ID != "tl-2" || got[2].ID != "tl-0" {
+		t.Fatalf("order = %s,%s,%s; want oldest first", got[0].ID, got[1].ID, got[2].ID)
+	}
+	if got[0].OrgID != storage.DefaultOrgID || got[0].Actor != "alice" || got[0].Data["n"] != float64(2) {
+		t.Fatalf("round-trip lost fields: %+v", got[0])
+	}
+
+	// A limit keeps the newest entries, still oldest first.
+	got, err = tl.ListTimeline("inc-1", 2)
+	if err != nil {
+		t.Fatalf("ListTimeline limit: %v", err)
+	}
+	if len(got) != 2 || got[0].ID != "tl-1" || got[1].ID != "tl-0" {
+		t.Fatalf("limited = %+v; want tl-1, tl-0", got)
+	}
+
+	// An entry for an unknown incident is rejected.
+	err = tl.AppendTimelineEntry(&storage.TimelineEntry{ID: "tl-orphan", IncidentID: "missing", Kind: storage.TimelineNote})
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("orphan append = %v, want ErrNotFound", err)
+	}
+	// Missing required fields are rejected.
+	if err := tl.AppendTimelineEntry(&storage.TimelineEntry{IncidentID: "inc-1", Kind: storage.TimelineNote}); err == nil {
+		t.Fatal("append without an id should fail")
+	}
+}
+
+func TestMemoryTimeline(t *testing.T) {
+	runTimeline(t, storage.NewMemory())
+}
+
+func TestFileTimeline(t *testing.T) {
+	p, err := storage.NewFile(storage.FileOptions{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewFile: %v", err)
+	}
+	defer p.Close()
+	runTimeline(t, p)
+}
+
+func TestPostgresTimeline(t *testing.T) {
+	runTimeline(t, newTestPostgres(t))
+}
+
+// TestFileTimeline_SurvivesRestartAndDropsOrphans checks an incident rolling
+// out of the cap takes its timeline log with it at once, and that a reopen
+// reloads the logs of the incidents kept.
+func TestFileTimeline_SurvivesRestartAndDropsOrphans(t *testing.T) {
+	dir := t.TempDir()
+	p, err := storage.NewFile(storage.FileOptions{DataDir: dir, MaxIncidents: 1})
+	if err != nil {
+		t.Fatalf("NewFile: %v", err)
+	}
+	now := time.Now().UTC()
+	_ = p.SaveIncident(&storage.IncidentRecord{ID: "old", CreatedAt: now.Add(-time.Minute)})
+	if err := p.(storage.Timeline).AppendTimelineEntry(&storage.TimelineEntry{ID: "a", IncidentID: "old", Kind: storage.TimelineNote}); err != nil {
+		t.Fatalf("append old: %v", err)
+	}
+	// Saving a second incident pushes "old" out of the cap of 1.
+	_ = p.SaveIncident(&storage.IncidentRecord{ID: "new", CreatedAt: now})
+	if err := p.(storage.Timeline).AppendTimelineEntry(&storage.TimelineEntry{ID: "b", IncidentID: "new", Kind: storage.TimelineNote}); err != nil {
+		t.Fatalf("append new: %v", err)
+	}
+	if got, _ := p.(storage.Timeline).ListTimeline("old", 0); len(got) != 0 {
+		t.Fatalf("evicted incident kept its entries: %+v", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "timeline", "old.ndjson")); !os.IsNotExist(err) {
+		t.Fatalf("evicted incident's log still on disk: %v", err)
+	}
+	_ = p.Close()
+
+	p2, err := storage.NewFile(storage.FileOptions{DataDir: dir, MaxIncidents: 1})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer p2.Close()
+	tl := p2.(storage.Timeline)
+	if got, _ := tl.ListTimeline("new", 0); len(got) != 1 || got[0].ID != "b" {
+		t.Fatalf("after restart new = %+v, want [b]", got)
+	}
+	if got, _ := tl.ListTimeline("old", 0); len(got) != 0 {
+		t.Fatalf("orphaned entries survived compaction: %+v", got)
+	}
+}
+
+// TestFileTimeline_AppendsOneLineAndMigrates checks an append adds one line
+// to the incident's own log, and a legacy timeline.json is split into logs
+// on open, dropping the entries of incidents no longer stored.
+func TestFileTimeline_AppendsOneLineAndMigrates(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now().UTC()
+	legacy := `{"version":1,"entries":[
+		{"id":"l1","incident_id":"kept","kind":"note","created_at":"` + now.Add(-time.Minute).Format(time.RFC3339Nano) + `"},
+		{"id":"l2","incident_id":"gone","kind":"note","created_at":"` + now.Format(time.RFC3339Nano) + `"}]}`
+	if err := os.WriteFile(filepath.Join(dir, "timeline.json"), []byte(legacy), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	incidents := `{"version":1,"incidents":[{"id":"kept","created_at":"` + now.Format(time.RFC3339Nano) + `"}]}`
+	if err := os.WriteFile(filepath.Join(dir, "incidents.json"), []byte(incidents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := storage.NewFile(storage.FileOptions{DataDir: dir})
+	if err != nil {
+		t.Fatalf("NewFile: %v", err)
+	}
+	defer p.Close()
+	if _, err := os.Stat(filepath.Join(dir, "timeline.json")); !os.IsNotExist(err) {
+		t.Fatalf("legacy timeline.json not removed: %v", err)
+	}
+	tl := p.(storage.Timeline)
+	if err := tl.AppendTimelineEntry(&storage.TimelineEntry{ID: "n1", IncidentID: "kept", Kind: storage.TimelineNote}); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "timeline", "kept.ndjson"))
+	if err != nil {
+		t.Fatalf("read log: %v", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 2 {
+		t.Fatalf("log has %d lines, want the migrated entry plus the append", lines)
+	}
+	if got, _ := tl.ListTimeline("kept", 0); len(got) != 2 || got[0].ID != "l1" || got[1].ID != "n1" {
+		t.Fatalf("kept = %+v, want [l1 n1]", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "timeline", "gone.ndjson")); !os.IsNotExist(err) {
+		t.Fatalf("entries of a missing incident were migrated: %v", err)
+	}
+}
+
+// TestMemoryTimeline_DeletedWithIncident mirrors the Postgres ON DELETE
+// CASCADE: removing an incident through Lifecycle drops its timeline.
+func TestMemoryTimeline_DeletedWithIncident(t *testing.T) {
+	p := storage.NewMemory()
+	_ = p.SaveIncident(&storage.IncidentRecord{ID: "inc-1", CreatedAt: time.Now().UTC()})
+	tl := p.(storage.Timeline)
+	_ = tl.AppendTimelineEntry(&storage.TimelineEntry{ID: "a", IncidentID: "inc-1", Kind: storage.TimelineNote})
+	if err := p.(storage.Lifecycle).DeleteByID(storage.DomainIncidents, "inc-1"); err != nil {
+		t.Fatalf("DeleteByID: %v", err)
+	}
+	_ = p.SaveIncident(&storage.IncidentRecord{ID: "inc-1", CreatedAt: time.Now().UTC()})
+	if got, _ := tl.ListTimeline("inc-1", 0); len(got) != 0 {
+		t.Fatalf("timeline outlived its incident: %+v", got)
+	}
+}